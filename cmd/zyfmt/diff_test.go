@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if s, ok := change[i]; ok {
+				if s != "" {
+					b.WriteString(s + "\n")
+				}
+				continue
+			}
+			b.WriteString(string(rune('a'+i-1)) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{"from empty", "", "a\n", "@@ -0,0 +1,1 @@\n+a\n"},
+		{"to empty", "a\n", "", "@@ -1,1 +0,0 @@\n-a\n"},
+		{
+			"context",
+			lines(10, nil),
+			lines(10, map[int]string{5: "E"}),
+			"@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		{
+			"nearby changes share a hunk",
+			lines(12, nil),
+			lines(12, map[int]string{2: "B", 8: "H"}),
+			"@@ -1,11 +1,11 @@\n a\n-b\n+B\n c\n d\n e\n f\n g\n-h\n+H\n i\n j\n k\n",
+		},
+		{
+			"distant changes",
+			lines(20, nil),
+			lines(20, map[int]string{2: "", 18: "R"}),
+			"@@ -1,5 +1,4 @@\n a\n-b\n c\n d\n e\n@@ -15,6 +14,6 @@\n o\n p\n q\n-r\n+R\n s\n t\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff("old", "new", []byte(tt.before), []byte(tt.after))
+			want := tt.expected
+			if want != "" {
+				want = "--- old\n+++ new\n" + want
+			}
+			if got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
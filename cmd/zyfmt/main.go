@@ -0,0 +1,153 @@
+// Command zyfmt formats zylisp source code.
+//
+// Usage:
+//
+//	zyfmt [flags] [path ...]
+//
+// Without paths it formats standard input. A directory stands for the
+// zylisp files under it. By default the formatted source is written to
+// standard output; the flags instead rewrite files in place, print a
+// diff or list the files whose formatting differs.
+//
+// The flags are:
+//
+//	-d	print a diff of the changes instead of the formatted source
+//	-l	list the files whose formatting differs
+//	-w	write the formatted source back to each file
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/zylisp/lang/format"
+	"github.com/zylisp/lang/interpreter"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// options are the command's flags
+type options struct {
+	diff  bool
+	list  bool
+	write bool
+}
+
+// run runs the command with args, returning its exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("zyfmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: zyfmt [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+	var opts options
+	flags.BoolVar(&opts.diff, "d", false, "print a diff of the changes instead of the formatted source")
+	flags.BoolVar(&opts.list, "l", false, "list the files whose formatting differs")
+	flags.BoolVar(&opts.write, "w", false, "write the formatted source back to each file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if opts.write {
+			fmt.Fprintln(stderr, "zyfmt: cannot use -w with standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err == nil {
+			_, err = formatFile("<standard input>", src, opts, stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "zyfmt: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		if err := formatPath(path, opts, stdout); err != nil {
+			fmt.Fprintf(stderr, "zyfmt: %v\n", err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// formatPath formats the file at path, or each zylisp file under it if
+// it is a directory
+func formatPath(path string, opts options, stdout io.Writer) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return formatNamedFile(path, info.Mode().Perm(), opts, stdout)
+	}
+
+	var errs []error
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(file) != interpreter.SourceExt {
+			return nil
+		}
+		info, err := d.Info()
+		if err == nil {
+			err = formatNamedFile(file, info.Mode().Perm(), opts, stdout)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// formatNamedFile formats the file at path, rewriting it with perm if
+// -w is given
+func formatNamedFile(path string, perm fs.FileMode, opts options, stdout io.Writer) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	formatted, err := formatFile(path, src, opts, stdout)
+	if err != nil || !opts.write || bytes.Equal(src, formatted) {
+		return err
+	}
+	return os.WriteFile(path, formatted, perm)
+}
+
+// formatFile formats src, read from the file called name, and reports
+// the result as the options ask, returning the formatted source
+func formatFile(name string, src []byte, opts options, stdout io.Writer) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	changed := !bytes.Equal(src, formatted)
+	if opts.list && changed {
+		fmt.Fprintln(stdout, name)
+	}
+	if opts.diff && changed {
+		io.WriteString(stdout, diff(name+".orig", name, src, formatted))
+	}
+	if !opts.list && !opts.diff && !opts.write {
+		stdout.Write(formatted)
+	}
+	return formatted, nil
+}
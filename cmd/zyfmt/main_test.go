@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStdin(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		status int
+		output string
+		errors string
+	}{
+		{"formats", nil, "(+  1\n 2)", 0, "(+ 1 2)\n", ""},
+		{"diff", []string{"-d"}, "(a)\n(+  1 2)\n", 0,
+			"--- <standard input>.orig\n+++ <standard input>\n@@ -1,2 +1,2 @@\n (a)\n-(+  1 2)\n+(+ 1 2)\n", ""},
+		{"no diff", []string{"-d"}, "(a)\n", 0, "", ""},
+		{"list", []string{"-l"}, "(a )", 0, "<standard input>\n", ""},
+		{"write", []string{"-w"}, "(a)", 2, "", "zyfmt: cannot use -w"},
+		{"read error", nil, "(a", 1, "", "zyfmt: <standard input>: unclosed list"},
+		{"bad flag", []string{"-x"}, "", 2, "", "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errs bytes.Buffer
+			status := run(tt.args, strings.NewReader(tt.stdin), &out, &errs)
+			if status != tt.status {
+				t.Errorf("status %d, want %d (errors %q)", status, tt.status, errs)
+			}
+			if out.String() != tt.output {
+				t.Errorf("output %q, want %q", out, tt.output)
+			}
+			if !strings.HasPrefix(errs.String(), tt.errors) || (tt.errors == "") != (errs.Len() == 0) {
+				t.Errorf("errors %q, want %q", errs, tt.errors)
+			}
+		})
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.zy":         "(a  b)\n",
+		"sub/b.zy":     "(b)\n",
+		"sub/c.txt":    "(c  d)\n",
+		"sub/d/bad.zy": "(d\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out, errs bytes.Buffer
+	if status := run([]string{"-l", "-w", dir}, nil, &out, &errs); status != 1 {
+		t.Errorf("status %d, want 1", status)
+	}
+	if want := filepath.Join(dir, "a.zy") + "\n"; out.String() != want {
+		t.Errorf("listed %q, want %q", out, want)
+	}
+	if !strings.Contains(errs.String(), "bad.zy: unclosed list") {
+		t.Errorf("errors %q", errs)
+	}
+
+	for name, want := range map[string]string{
+		"a.zy":         "(a b)\n",
+		"sub/b.zy":     "(b)\n",
+		"sub/c.txt":    "(c  d)\n",
+		"sub/d/bad.zy": "(d\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s: %q, want %q", name, data, want)
+		}
+	}
+
+	out.Reset()
+	errs.Reset()
+	if status := run([]string{filepath.Join(dir, "sub/c.txt"), "missing.zy"}, nil, &out, &errs); status != 1 {
+		t.Errorf("status %d, want 1", status)
+	}
+	if out.String() != "(c d)\n" {
+		t.Errorf("output %q", out)
+	}
+	if !strings.HasPrefix(errs.String(), "zyfmt: stat missing.zy") {
+		t.Errorf("errors %q", errs)
+	}
+}
@@ -0,0 +1,155 @@
+// Command zylisp runs zylisp programs.
+//
+// Usage:
+//
+//	zylisp [file [args...]]
+//	zylisp -e expr
+//
+// Given a file, zylisp evaluates it, with the arguments after it reported
+// by command-line-args; a file named "-" is read from standard input.
+// With -e, it evaluates expr and prints its value. With neither, it
+// starts an interactive REPL if standard input is a terminal, and
+// otherwise evaluates standard input as a program.
+//
+// A call to exit ends the program with its status; any other error is
+// reported and the status is 1.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/repl"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with args, returning its exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("zylisp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: zylisp [file [args...]]\n       zylisp -e expr")
+		flags.PrintDefaults()
+	}
+	expr := flags.String("e", "", "evaluate `expr` and print its value")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	env := interpreter.NewEnv(nil)
+	interpreter.LoadPrimitives(env)
+	env.Runtime().SetInput(sexpr.NewInputPort("stdin", stdin))
+	env.Runtime().SetOutput(sexpr.NewOutputPort("stdout", stdout))
+	env.Runtime().SetErrorOutput(sexpr.NewOutputPort("stderr", stderr))
+
+	var err error
+	switch {
+	case *expr != "":
+		if flags.NArg() > 0 {
+			flags.Usage()
+			return 2
+		}
+		err = evalPrint(*expr, env, stdout)
+	case flags.NArg() > 0:
+		env.Runtime().SetArgs(flags.Args()[1:])
+		err = runFile(flags.Arg(0), stdin, env)
+	case isTerminal(stdin):
+		err = runREPL(env, stdin, stdout, stderr)
+	default:
+		err = runFile("-", stdin, env)
+	}
+	return exitStatus(err, stderr)
+}
+
+// exitStatus reports err, if it is not a call to exit, and returns the
+// status the command exits with
+func exitStatus(err error, stderr io.Writer) int {
+	var exit *interpreter.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		return exit.Code
+	default:
+		fmt.Fprintf(stderr, "zylisp: %v\n", err)
+		return 1
+	}
+}
+
+// evalPrint evaluates the forms in src and prints the value of the last
+func evalPrint(src string, env *interpreter.Env, stdout io.Writer) error {
+	forms, err := parser.ReadSource("-e", src)
+	if err != nil {
+		return err
+	}
+	var value sexpr.SExpr = sexpr.Nil{}
+	for _, form := range forms {
+		if value, err = interpreter.Eval(form, env); err != nil {
+			return err
+		}
+	}
+	if values, ok := value.(sexpr.Values); ok {
+		for _, v := range values.Elements {
+			fmt.Fprintln(stdout, sexpr.Write(v))
+		}
+	} else if _, ok := value.(sexpr.Nil); !ok {
+		fmt.Fprintln(stdout, sexpr.Write(value))
+	}
+	return nil
+}
+
+// runFile evaluates the program in the file at path, or in stdin if path
+// is "-"
+func runFile(path string, stdin io.Reader, env *interpreter.Env) error {
+	if path != "-" {
+		_, err := interpreter.LoadFile(path, env)
+		return err
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return err
+	}
+	forms, err := parser.ReadSource("<stdin>", string(data))
+	if err != nil {
+		return err
+	}
+	for _, form := range forms {
+		if _, err := interpreter.Eval(form, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runREPL starts an interactive session, keeping its history in the
+// user's home directory
+func runREPL(env *interpreter.Env, stdin io.Reader, stdout, stderr io.Writer) error {
+	r := repl.New(env)
+	r.In, r.Out, r.Err = stdin, stdout, stderr
+	if home, err := os.UserHomeDir(); err == nil {
+		r.HistoryFile = filepath.Join(home, ".zylisp_history")
+	}
+	return r.Run(context.Background())
+}
+
+// isTerminal reports whether r is a terminal rather than a file or pipe
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
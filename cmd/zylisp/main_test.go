@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.zy")
+	src := `(display (command-line-args)) (newline) (exit 4)`
+	if err := os.WriteFile(script, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		status int
+		output string
+		errors string
+	}{
+		{"expression", []string{"-e", "(+ 1 2)"}, "", 0, "3\n", ""},
+		{"several forms", []string{"-e", "(define x 2) (* x 3)"}, "", 0, "6\n", ""},
+		{"nil is not printed", []string{"-e", "(when false 1)"}, "", 0, "", ""},
+		{"multiple values", []string{"-e", "(values 1 2)"}, "", 0, "1\n2\n", ""},
+		{"expression output", []string{"-e", `(display "hi")`}, "", 0, "hi", ""},
+		{"expression error", []string{"-e", "(car 1)"}, "", 1, "", "zylisp: car:"},
+		{"read error", []string{"-e", "(+ 1"}, "", 1, "", "zylisp: "},
+		{"expression with file", []string{"-e", "1", script}, "", 2, "", "usage:"},
+		{"script", []string{script, "a", "-b"}, "", 4, "(a -b)\n", ""},
+		{"missing script", []string{"missing.zy"}, "", 1, "", "zylisp: "},
+		{"script from stdin", []string{"-", "x"}, "(display (command-line-args))", 0, "(x)", ""},
+		{"piped stdin", nil, `(display (+ 1 2)) (exit 0) (display "no")`, 0, "3", ""},
+		{"piped error", nil, "(car 1)", 1, "", "zylisp: car:"},
+		{"bad flag", []string{"-x"}, "", 2, "", "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errs bytes.Buffer
+			status := run(tt.args, strings.NewReader(tt.stdin), &out, &errs)
+			if status != tt.status {
+				t.Errorf("status %d, want %d (errors %q)", status, tt.status, errs)
+			}
+			if out.String() != tt.output {
+				t.Errorf("output %q, want %q", out, tt.output)
+			}
+			if !strings.HasPrefix(errs.String(), tt.errors) || (tt.errors == "") != (errs.Len() == 0) {
+				t.Errorf("errors %q, want %q", errs, tt.errors)
+			}
+		})
+	}
+}
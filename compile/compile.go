@@ -0,0 +1,1232 @@
+// Package compile translates a subset of zylisp into standalone Go
+// source, so that performance-critical scripts can be compiled ahead of
+// time and linked into the host binary.
+//
+// A compiled file is a sequence of top-level definitions. Each function
+// definition becomes an exported Go function taking and returning
+// sexpr.SExpr values, and each definition of a constant becomes a
+// package variable. Function bodies may use if, cond, when, unless, and,
+// or, begin, let, let*, letrec, lambda, set!, define, quote, loop and
+// recur, and may call the other compiled functions and the interpreter's
+// primitives. Other special forms and macros are reported as errors.
+package compile
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// DefaultPackage is the package name of generated code if Options does
+// not give one
+const DefaultPackage = "zylisp"
+
+// runtimePath is the import path of this package, which generated code
+// calls for its runtime support
+const runtimePath = "github.com/zylisp/lang/compile"
+
+// Options controls the generated code
+type Options struct {
+	Package string // package clause; DefaultPackage if empty
+}
+
+// Error reports a form that cannot be compiled
+type Error struct {
+	Form    sexpr.SExpr
+	Pos     *sexpr.Position // position of the form or the nearest enclosing one, if known
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pos == nil {
+		return e.Message
+	}
+	return e.Pos.String() + ": " + e.Message
+}
+
+// CompileSource reads the source text of file and compiles it
+func CompileSource(file, src string, opts Options) ([]byte, error) {
+	forms, err := parser.ReadSource(file, src)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(forms, opts)
+}
+
+// Compile returns a formatted Go source file defining forms, which must
+// all be definitions
+func Compile(forms []sexpr.SExpr, opts Options) ([]byte, error) {
+	g := newGenerator()
+	if err := g.declare(forms); err != nil {
+		return nil, err
+	}
+	for _, d := range g.defs {
+		if err := g.define(d); err != nil {
+			return nil, err
+		}
+	}
+
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = DefaultPackage
+	}
+	src, err := format.Source(g.file(pkg))
+	if err != nil {
+		return nil, fmt.Errorf("compile: formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// definition is a top-level define
+type definition struct {
+	form   sexpr.List
+	name   string
+	goName string
+
+	// A function has params and a body; a variable has a value
+	fn     bool
+	params []sexpr.Symbol
+	rest   *sexpr.Symbol
+	body   []sexpr.SExpr
+	value  sexpr.SExpr
+}
+
+// generator accumulates the generated code
+type generator struct {
+	env      *interpreter.Env // where builtins are looked up
+	defs     []*definition
+	globals  map[string]*definition
+	goNames  map[string]string // Go names of definitions, to the names they came from
+	mutated  map[string]bool   // names assigned by set! anywhere
+	builtins map[string]string // builtins used, to the variables holding them
+	runtime  bool              // whether the code calls this package
+
+	decls strings.Builder // package-level declarations
+	out   strings.Builder // the function being compiled
+	next  int             // suffix of the next local or temporary
+	pos   *sexpr.Position // position of the form being compiled
+}
+
+func newGenerator() *generator {
+	env := interpreter.NewEnv(nil)
+	interpreter.LoadPrimitives(env)
+	return &generator{
+		env:      env,
+		globals:  make(map[string]*definition),
+		goNames:  make(map[string]string),
+		mutated:  make(map[string]bool),
+		builtins: make(map[string]string),
+	}
+}
+
+// errorf returns an Error for form
+func (g *generator) errorf(form sexpr.SExpr, format string, args ...interface{}) error {
+	pos := g.pos
+	if p, ok := sexpr.PositionOf(form); ok {
+		pos = &p
+	}
+	return &Error{Form: form, Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// enter records the position of form, if known, for errors in it, and
+// returns a function restoring the previous one
+func (g *generator) enter(form sexpr.SExpr) func() {
+	prev := g.pos
+	if p, ok := sexpr.PositionOf(form); ok {
+		g.pos = &p
+	}
+	return func() { g.pos = prev }
+}
+
+// emit writes a line of the function being compiled
+func (g *generator) emit(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format, args...)
+	g.out.WriteByte('\n')
+}
+
+// rt returns a reference to the runtime function name
+func (g *generator) rt(name string) string {
+	g.runtime = true
+	return "compile." + name
+}
+
+// local returns a fresh Go name for a binding of name
+func (g *generator) local(name string) string {
+	goName := mangle(name)
+	if goName == "" {
+		goName = "v"
+	}
+	g.next++
+	return fmt.Sprintf("%s%s_%d", strings.ToLower(goName[:1]), goName[1:], g.next)
+}
+
+// temp returns a fresh Go name for an intermediate value
+func (g *generator) temp() string {
+	g.next++
+	return fmt.Sprintf("v_%d", g.next)
+}
+
+// file assembles the generated file
+func (g *generator) file(pkg string) []byte {
+	var b strings.Builder
+	b.WriteString("// Code generated by zylisp compile. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+	var imports []string
+	if g.runtime {
+		imports = append(imports, runtimePath)
+	}
+	if len(g.defs) > 0 {
+		imports = append(imports, "github.com/zylisp/lang/sexpr")
+	}
+	if len(imports) > 0 {
+		b.WriteString("import (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&b, "%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+
+	if len(g.builtins) > 0 {
+		names := make([]string, 0, len(g.builtins))
+		for name := range g.builtins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("// Interpreter primitives the code calls\nvar (\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s = compile.Builtin(%q)\n", g.builtins[name], name)
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString(g.decls.String())
+	return []byte(b.String())
+}
+
+// declare records the top-level definitions, so that functions can call
+// those defined after them
+func (g *generator) declare(forms []sexpr.SExpr) error {
+	for _, form := range forms {
+		d, err := g.parseDefinition(form)
+		if err != nil {
+			return err
+		}
+		if _, ok := g.globals[d.name]; ok {
+			return g.errorf(form, "%s is already defined", d.name)
+		}
+		d.goName = exported(mangle(d.name))
+		if d.goName == "" {
+			return g.errorf(form, "cannot make a Go name for %s", d.name)
+		}
+		if other, ok := g.goNames[d.goName]; ok {
+			return g.errorf(form, "%s and %s both become %s in Go", other, d.name, d.goName)
+		}
+		g.goNames[d.goName] = d.name
+		g.globals[d.name] = d
+		g.defs = append(g.defs, d)
+		findMutated(form, g.mutated)
+	}
+	return nil
+}
+
+// parseDefinition parses a top-level (define (name params...) body...),
+// (define name (lambda params body...)) or (define name constant)
+func (g *generator) parseDefinition(form sexpr.SExpr) (*definition, error) {
+	defer g.enter(form)()
+	list, ok := form.(sexpr.List)
+	if !ok || len(list.Elements) == 0 || !isSymbolNamed(list.Elements[0], "define") {
+		return nil, g.errorf(form, "only definitions are allowed at top level, got %v", form)
+	}
+	if len(list.Elements) < 3 {
+		return nil, g.errorf(form, "define requires a name and a value")
+	}
+
+	d := &definition{form: list}
+	switch head := list.Elements[1].(type) {
+	case sexpr.List, sexpr.Pair:
+		name, params, ok := splitHead(head)
+		if !ok {
+			return nil, g.errorf(form, "function definition requires a name")
+		}
+		d.name, d.fn, d.body = name.Name, true, list.Elements[2:]
+		return d, g.parseParams(d, params)
+
+	case sexpr.Symbol:
+		d.name = head.Name
+		if len(list.Elements) != 3 {
+			return nil, g.errorf(form, "define of %s requires one value", d.name)
+		}
+		value := list.Elements[2]
+		if lambda, ok := value.(sexpr.List); ok && len(lambda.Elements) >= 3 && isSymbolNamed(lambda.Elements[0], "lambda") {
+			d.fn, d.body = true, lambda.Elements[2:]
+			return d, g.parseParams(d, lambda.Elements[1])
+		}
+		if !isConstant(value) && !isQuote(value) {
+			return nil, g.errorf(form, "top-level value of %s must be a lambda or a constant", d.name)
+		}
+		d.value = value
+		return d, nil
+	}
+	return nil, g.errorf(form, "first argument of define must be a symbol")
+}
+
+// splitHead splits the head of (define (name params...) body...)
+func splitHead(head sexpr.SExpr) (sexpr.Symbol, sexpr.SExpr, bool) {
+	var name, params sexpr.SExpr
+	switch h := head.(type) {
+	case sexpr.List:
+		if len(h.Elements) == 0 {
+			return sexpr.Symbol{}, nil, false
+		}
+		name, params = h.Elements[0], sexpr.List{Elements: h.Elements[1:]}
+	case sexpr.Pair:
+		name, params = h.Car, h.Cdr
+	}
+	sym, ok := name.(sexpr.Symbol)
+	return sym, params, ok
+}
+
+// parseParams parses a parameter list into d
+func (g *generator) parseParams(d *definition, spec sexpr.SExpr) error {
+	params, rest, err := g.params(spec)
+	d.params, d.rest = params, rest
+	return err
+}
+
+// params parses a parameter list, (a b), (a b . rest) or args
+func (g *generator) params(spec sexpr.SExpr) ([]sexpr.Symbol, *sexpr.Symbol, error) {
+	var params []sexpr.Symbol
+	for {
+		switch s := spec.(type) {
+		case sexpr.Symbol:
+			return params, &s, nil
+		case sexpr.List:
+			for _, p := range s.Elements {
+				sym, ok := p.(sexpr.Symbol)
+				if !ok {
+					return nil, nil, g.errorf(p, "parameter must be a symbol, got %v", p)
+				}
+				params = append(params, sym)
+			}
+			return params, nil, nil
+		case sexpr.Pair:
+			sym, ok := s.Car.(sexpr.Symbol)
+			if !ok {
+				return nil, nil, g.errorf(s.Car, "parameter must be a symbol, got %v", s.Car)
+			}
+			params = append(params, sym)
+			spec = s.Cdr
+		default:
+			return nil, nil, g.errorf(spec, "parameters must be a list or symbol")
+		}
+	}
+}
+
+// define generates the declaration of d
+func (g *generator) define(d *definition) error {
+	defer g.enter(d.form)()
+	if !d.fn {
+		value, err := g.literal(quoted(d.value))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.decls, "// %s is the zylisp variable %s\nvar %s sexpr.SExpr = %s\n\n", d.goName, d.name, d.goName, value)
+		return nil
+	}
+
+	g.out.Reset()
+	g.next = 0
+	s := newScope(nil)
+	var params []string
+	for _, p := range d.params {
+		local := g.local(p.Name)
+		s.names[p.Name] = local
+		params = append(params, local+" sexpr.SExpr")
+	}
+	if d.rest != nil {
+		params = append(params, "rest ...sexpr.SExpr")
+		local := g.local(d.rest.Name)
+		s.names[d.rest.Name] = local
+		g.emit("var %s sexpr.SExpr = sexpr.List{Elements: append([]sexpr.SExpr{}, rest...)}", local)
+		g.emit("_ = %s", local)
+	}
+
+	result, err := g.body(d.body, s, nil)
+	if err != nil {
+		return err
+	}
+	g.emit("return %s, nil", result)
+
+	fmt.Fprintf(&g.decls, "// %s is the zylisp function %s\nfunc %s(%s) (sexpr.SExpr, error) {\n%s}\n\n",
+		d.goName, d.name, d.goName, strings.Join(params, ", "), g.out.String())
+	return nil
+}
+
+// scope maps the names bound by enclosing forms to Go variables
+type scope struct {
+	names  map[string]string
+	parent *scope
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{names: make(map[string]string), parent: parent}
+}
+
+func (s *scope) lookup(name string) (string, bool) {
+	for ; s != nil; s = s.parent {
+		if local, ok := s.names[name]; ok {
+			return local, true
+		}
+	}
+	return "", false
+}
+
+// loop is the loop whose body is being compiled in tail position
+type loop struct {
+	carriers []string // variables holding the next iteration's values
+	result   string   // variable receiving the loop's value
+}
+
+// expr compiles x, emitting the statements that compute it, and returns
+// a Go expression for its value
+func (g *generator) expr(x sexpr.SExpr, s *scope) (string, error) {
+	return g.form(x, s, nil)
+}
+
+// form compiles x. If lp is not nil, x is in tail position in the body of
+// lp, and form emits statements that end the iteration with x's value or
+// start the next one instead of returning an expression.
+func (g *generator) form(x sexpr.SExpr, s *scope, lp *loop) (string, error) {
+	switch v := x.(type) {
+	case sexpr.Symbol:
+		value, err := g.ref(v, s)
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+	case sexpr.List:
+		if len(v.Elements) == 0 {
+			return g.finish("sexpr.List{}", lp), nil
+		}
+		defer g.enter(v)()
+		if head, ok := v.Elements[0].(sexpr.Symbol); ok && interpreter.IsSpecialForm(head.Name) {
+			return g.special(head.Name, v, s, lp)
+		}
+		value, err := g.call(v, s)
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+	}
+
+	if !isConstant(x) {
+		return "", g.errorf(x, "unsupported expression %v", x)
+	}
+	value, err := g.literal(x)
+	if err != nil {
+		return "", err
+	}
+	return g.finish(value, lp), nil
+}
+
+// finish returns value, or ends the iteration of lp with it
+func (g *generator) finish(value string, lp *loop) string {
+	if lp == nil {
+		return value
+	}
+	g.emit("%s = %s", lp.result, value)
+	g.emit("break")
+	return ""
+}
+
+// discard marks a value as unused, which Go requires of variables
+func (g *generator) discard(value string) {
+	if identifier.MatchString(value) {
+		g.emit("_ = %s", value)
+	}
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ref compiles a variable reference
+func (g *generator) ref(sym sexpr.Symbol, s *scope) (string, error) {
+	if local, ok := s.lookup(sym.Name); ok {
+		return g.read(sym.Name, local), nil
+	}
+	if d, ok := g.globals[sym.Name]; ok {
+		if d.fn {
+			return g.funcValue(d), nil
+		}
+		return g.read(sym.Name, d.goName), nil
+	}
+	return g.builtin(sym)
+}
+
+// read returns the Go variable holding name. A variable that set! may
+// change is copied, so that the value read is the one at this point of
+// the evaluation.
+func (g *generator) read(name, variable string) string {
+	if !g.mutated[name] {
+		return variable
+	}
+	t := g.temp()
+	g.emit("%s := %s", t, variable)
+	return t
+}
+
+// builtin returns the variable holding the interpreter primitive sym
+func (g *generator) builtin(sym sexpr.Symbol) (string, error) {
+	if variable, ok := g.builtins[sym.Name]; ok {
+		return variable, nil
+	}
+	value, err := g.env.Lookup(sym.Name)
+	if err != nil {
+		return "", g.errorf(sym, "undefined: %s", sym.Name)
+	}
+	if _, ok := value.(sexpr.Macro); ok {
+		return "", g.errorf(sym, "macro %s is not supported", sym.Name)
+	}
+
+	variable := "zy" + mangle(sym.Name)
+	for taken := true; taken; {
+		taken = false
+		for _, other := range g.builtins {
+			if other == variable {
+				variable += "_"
+				taken = true
+			}
+		}
+	}
+	g.builtins[sym.Name] = variable
+	g.runtime = true
+	return variable, nil
+}
+
+// funcValue returns a function value calling the compiled function d
+func (g *generator) funcValue(d *definition) string {
+	n := len(d.params)
+	args := make([]string, n)
+	for i := range args {
+		args[i] = fmt.Sprintf("args[%d]", i)
+	}
+	check := fmt.Sprintf("len(args) != %d", n)
+	max := n
+	if d.rest != nil {
+		check = fmt.Sprintf("len(args) < %d", n)
+		max = -1
+		args = append(args, fmt.Sprintf("args[%d:]...", n))
+	}
+
+	t := g.temp()
+	g.emit("%s := %s(%q, func(args []sexpr.SExpr) (sexpr.SExpr, error) {", t, g.rt("Func"), d.name)
+	g.emit("if %s {", check)
+	g.emit("return nil, %s(%q, %d, %d, len(args))", g.rt("ArityError"), d.name, n, max)
+	g.emit("}")
+	g.emit("return %s(%s)", d.goName, strings.Join(args, ", "))
+	g.emit("})")
+	return t
+}
+
+// fastPaths are the runtime functions for calls of builtins with two
+// arguments
+var fastPaths = map[string]string{
+	"+": "Add", "-": "Sub", "*": "Mul", "=": "NumEq",
+	"<": "Less", ">": "Greater", "<=": "LessEq", ">=": "GreaterEq",
+}
+
+// call compiles a function call
+func (g *generator) call(list sexpr.List, s *scope) (string, error) {
+	head, operands := list.Elements[0], list.Elements[1:]
+
+	var callee string
+	if sym, ok := head.(sexpr.Symbol); ok {
+		_, isLocal := s.lookup(sym.Name)
+		d, isGlobal := g.globals[sym.Name]
+		switch {
+		case isLocal:
+		case isGlobal && d.fn:
+			n := len(d.params)
+			if len(operands) < n || (d.rest == nil && len(operands) > n) {
+				return "", g.errorf(list, "%s takes %s, got %d", d.name, arguments(n, d.rest != nil), len(operands))
+			}
+			callee = d.goName
+		case !isGlobal && fastPaths[sym.Name] != "" && len(operands) == 2:
+			callee = g.rt(fastPaths[sym.Name])
+		}
+	}
+
+	var args []string
+	if callee == "" {
+		fn, err := g.expr(head, s)
+		if err != nil {
+			return "", err
+		}
+		callee = g.rt("Apply")
+		args = append(args, fn)
+	}
+	for _, x := range operands {
+		arg, err := g.expr(x, s)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+	return g.check(fmt.Sprintf("%s(%s)", callee, strings.Join(args, ", "))), nil
+}
+
+// arguments describes an argument count
+func arguments(n int, rest bool) string {
+	s := "s"
+	if n == 1 {
+		s = ""
+	}
+	if rest {
+		return fmt.Sprintf("at least %d argument%s", n, s)
+	}
+	return fmt.Sprintf("%d argument%s", n, s)
+}
+
+// check emits a call returning a value and an error, and returns the
+// variable holding the value
+func (g *generator) check(call string) string {
+	t := g.temp()
+	g.emit("%s, err := %s", t, call)
+	g.emit("if err != nil {")
+	g.emit("return nil, err")
+	g.emit("}")
+	return t
+}
+
+// special compiles a special form
+func (g *generator) special(name string, list sexpr.List, s *scope, lp *loop) (string, error) {
+	elems := list.Elements
+	switch name {
+	case "quote":
+		if len(elems) != 2 {
+			return "", g.errorf(list, "quote requires one argument")
+		}
+		value, err := g.literal(elems[1])
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+
+	case "if":
+		if len(elems) != 4 {
+			return "", g.errorf(list, "if requires a test, a consequent and an alternative")
+		}
+		return g.ifForm(elems[1], elems[2], elems[3], s, lp)
+
+	case "when", "unless":
+		if len(elems) < 2 {
+			return "", g.errorf(list, "%s requires a test", name)
+		}
+		var body sexpr.SExpr = sexpr.Nil{}
+		if len(elems) > 2 {
+			body = withElements(list, append([]sexpr.SExpr{sexpr.Symbol{Name: "begin"}}, elems[2:]...)...)
+		}
+		if name == "when" {
+			return g.ifForm(elems[1], body, sexpr.Nil{}, s, lp)
+		}
+		return g.ifForm(elems[1], sexpr.Nil{}, body, s, lp)
+
+	case "cond":
+		form, err := g.desugarCond(list, elems[1:])
+		if err != nil {
+			return "", err
+		}
+		return g.form(form, s, lp)
+
+	case "and", "or":
+		value, err := g.andOr(elems[1:], s, name == "and")
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+
+	case "begin":
+		// A define in a begin is local to it, unlike in the interpreter,
+		// so that it cannot outlive the Go block it is declared in
+		return g.body(elems[1:], newScope(s), lp)
+
+	case "let", "let*", "letrec":
+		return g.let(name, list, s, lp)
+
+	case "loop":
+		value, err := g.loop(list, s)
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+
+	case "recur":
+		return "", g.recur(list, s, lp)
+
+	case "lambda":
+		if len(elems) != 3 {
+			return "", g.errorf(list, "lambda requires parameters and a body")
+		}
+		params, rest, err := g.params(elems[1])
+		if err != nil {
+			return "", err
+		}
+		value, err := g.lambda(params, rest, elems[2:], s)
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+
+	case "set!":
+		value, err := g.set(list, s)
+		if err != nil {
+			return "", err
+		}
+		return g.finish(value, lp), nil
+
+	case "string-interpolate":
+		// Interpolated strings always join with the builtin str
+		fn, err := g.builtin(sexpr.Symbol{Name: "str"})
+		if err != nil {
+			return "", err
+		}
+		args := []string{fn}
+		for _, x := range elems[1:] {
+			arg, err := g.expr(x, s)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, arg)
+		}
+		value := g.check(fmt.Sprintf("%s(%s)", g.rt("Apply"), strings.Join(args, ", ")))
+		return g.finish(value, lp), nil
+
+	case "define":
+		return "", g.errorf(list, "define is only allowed at top level and in bodies")
+	}
+	return "", g.errorf(list, "unsupported form %s", name)
+}
+
+// ifForm compiles (if test then else)
+func (g *generator) ifForm(test, then, els sexpr.SExpr, s *scope, lp *loop) (string, error) {
+	cond, err := g.expr(test, s)
+	if err != nil {
+		return "", err
+	}
+	var result string
+	if lp == nil {
+		result = g.temp()
+		g.emit("var %s sexpr.SExpr", result)
+	}
+
+	g.emit("if %s(%s) {", g.rt("Truthy"), cond)
+	if err := g.branch(then, s, lp, result); err != nil {
+		return "", err
+	}
+	g.emit("} else {")
+	if err := g.branch(els, s, lp, result); err != nil {
+		return "", err
+	}
+	g.emit("}")
+	return result, nil
+}
+
+// branch compiles a branch of a conditional, assigning its value to
+// result unless it is in tail position of a loop
+func (g *generator) branch(x sexpr.SExpr, s *scope, lp *loop, result string) error {
+	value, err := g.form(x, s, lp)
+	if err != nil {
+		return err
+	}
+	if lp == nil {
+		g.emit("%s = %s", result, value)
+	}
+	return nil
+}
+
+// desugarCond rewrites the clauses of a cond as nested ifs
+func (g *generator) desugarCond(list sexpr.List, clauses []sexpr.SExpr) (sexpr.SExpr, error) {
+	if len(clauses) == 0 {
+		return sexpr.Nil{}, nil
+	}
+	parts, ok := sexpr.Elements(clauses[0])
+	if !ok || len(parts) == 0 {
+		return nil, g.errorf(list, "cond clause must be a non-empty list, got %v", clauses[0])
+	}
+	if len(parts) > 1 && isSymbolNamed(parts[1], "=>") {
+		return nil, g.errorf(clauses[0], "cond clauses with => are not supported")
+	}
+	body := withElements(list, append([]sexpr.SExpr{sexpr.Symbol{Name: "begin"}}, parts[1:]...)...)
+	if isSymbolNamed(parts[0], "else") {
+		return body, nil
+	}
+
+	rest, err := g.desugarCond(list, clauses[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 1 {
+		return withElements(list, sexpr.Symbol{Name: "or"}, parts[0], rest), nil
+	}
+	return withElements(list, sexpr.Symbol{Name: "if"}, parts[0], body, rest), nil
+}
+
+// andOr compiles the operands of an and or an or
+func (g *generator) andOr(operands []sexpr.SExpr, s *scope, isAnd bool) (string, error) {
+	if len(operands) == 0 {
+		return g.literal(sexpr.Bool{Value: isAnd})
+	}
+	result := g.temp()
+	g.emit("var %s sexpr.SExpr", result)
+	negate := "!"
+	if isAnd {
+		negate = ""
+	}
+	for i, x := range operands {
+		value, err := g.expr(x, s)
+		if err != nil {
+			return "", err
+		}
+		g.emit("%s = %s", result, value)
+		if i < len(operands)-1 {
+			g.emit("if %s%s(%s) {", negate, g.rt("Truthy"), result)
+		}
+	}
+	for range operands[1:] {
+		g.emit("}")
+	}
+	return result, nil
+}
+
+// body compiles a sequence of expressions, returning the value of the
+// last. A define among them binds a new variable in s.
+func (g *generator) body(exprs []sexpr.SExpr, s *scope, lp *loop) (string, error) {
+	if len(exprs) == 0 {
+		return g.finish("sexpr.Nil{}", lp), nil
+	}
+	for i, x := range exprs {
+		last := i == len(exprs)-1
+		var value string
+		var err error
+		if list, ok := x.(sexpr.List); ok && len(list.Elements) > 0 && isSymbolNamed(list.Elements[0], "define") {
+			if value, err = g.localDefine(list, s); err == nil && last {
+				value = g.finish(value, lp)
+			}
+		} else if last {
+			value, err = g.form(x, s, lp)
+		} else {
+			value, err = g.expr(x, s)
+		}
+		if err != nil {
+			return "", err
+		}
+		if !last {
+			g.discard(value)
+		} else {
+			return value, nil
+		}
+	}
+	panic("unreachable")
+}
+
+// localDefine compiles a define in a body
+func (g *generator) localDefine(list sexpr.List, s *scope) (string, error) {
+	defer g.enter(list)()
+	if len(list.Elements) < 3 {
+		return "", g.errorf(list, "define requires a name and a value")
+	}
+
+	var name sexpr.Symbol
+	value := list.Elements[2]
+	switch head := list.Elements[1].(type) {
+	case sexpr.Symbol:
+		if len(list.Elements) != 3 {
+			return "", g.errorf(list, "define of %s requires one value", head.Name)
+		}
+		name = head
+	case sexpr.List, sexpr.Pair:
+		var params sexpr.SExpr
+		var ok bool
+		if name, params, ok = splitHead(head); !ok {
+			return "", g.errorf(list, "function definition requires a name")
+		}
+		value = withElements(list, append([]sexpr.SExpr{sexpr.Symbol{Name: "lambda"}, params}, list.Elements[2:]...)...)
+	default:
+		return "", g.errorf(list, "first argument of define must be a symbol")
+	}
+
+	local, ok := s.names[name.Name]
+	if !ok {
+		local = g.local(name.Name)
+		s.names[name.Name] = local
+		g.emit("var %s sexpr.SExpr", local)
+	}
+	v, err := g.expr(value, s)
+	if err != nil {
+		return "", err
+	}
+	g.emit("%s = %s", local, v)
+	return local, nil
+}
+
+// bindings parses the bindings of a let, let*, letrec or loop
+func (g *generator) bindings(form string, list sexpr.List) ([]sexpr.Symbol, []sexpr.SExpr, error) {
+	if len(list.Elements) < 3 {
+		return nil, nil, g.errorf(list, "%s requires bindings and a body", form)
+	}
+	specs, ok := list.Elements[1].(sexpr.List)
+	if !ok {
+		return nil, nil, g.errorf(list, "%s bindings must be a list", form)
+	}
+	names := make([]sexpr.Symbol, len(specs.Elements))
+	values := make([]sexpr.SExpr, len(specs.Elements))
+	for i, spec := range specs.Elements {
+		pair, ok := spec.(sexpr.List)
+		if !ok || len(pair.Elements) != 2 {
+			return nil, nil, g.errorf(spec, "%s binding must be (name value), got %v", form, spec)
+		}
+		if names[i], ok = pair.Elements[0].(sexpr.Symbol); !ok {
+			return nil, nil, g.errorf(spec, "%s binding name must be a symbol, got %v", form, pair.Elements[0])
+		}
+		values[i] = pair.Elements[1]
+	}
+	return names, values, nil
+}
+
+// let compiles (let ((name value)...) body...), let* and letrec
+func (g *generator) let(form string, list sexpr.List, s *scope, lp *loop) (string, error) {
+	names, values, err := g.bindings(form, list)
+	if err != nil {
+		return "", err
+	}
+
+	inner := newScope(s)
+	switch form {
+	case "let":
+		computed := make([]string, len(values))
+		for i, x := range values {
+			if computed[i], err = g.expr(x, s); err != nil {
+				return "", err
+			}
+		}
+		for i, name := range names {
+			inner.names[name.Name] = g.bind(name.Name, computed[i])
+		}
+
+	case "let*":
+		for i, name := range names {
+			value, err := g.expr(values[i], inner)
+			if err != nil {
+				return "", err
+			}
+			inner = newScope(inner)
+			inner.names[name.Name] = g.bind(name.Name, value)
+		}
+
+	case "letrec":
+		for _, name := range names {
+			local := g.local(name.Name)
+			g.emit("var %s sexpr.SExpr = sexpr.Nil{}", local)
+			g.emit("_ = %s", local)
+			inner.names[name.Name] = local
+		}
+		for i, name := range names {
+			value, err := g.expr(values[i], inner)
+			if err != nil {
+				return "", err
+			}
+			g.emit("%s = %s", inner.names[name.Name], value)
+		}
+	}
+	return g.body(list.Elements[2:], inner, lp)
+}
+
+// bind declares a variable for name holding value
+func (g *generator) bind(name, value string) string {
+	local := g.local(name)
+	g.emit("var %s sexpr.SExpr = %s", local, value)
+	g.emit("_ = %s", local)
+	return local
+}
+
+// loop compiles (loop ((name init)...) body...) as a Go for loop. Each
+// iteration binds fresh variables, so that closures created in one do
+// not see the values of the next.
+func (g *generator) loop(list sexpr.List, s *scope) (string, error) {
+	names, values, err := g.bindings("loop", list)
+	if err != nil {
+		return "", err
+	}
+
+	lp := &loop{result: g.temp()}
+	inits := make([]string, len(values))
+	for i, x := range values {
+		if inits[i], err = g.expr(x, s); err != nil {
+			return "", err
+		}
+	}
+	for i, name := range names {
+		carrier := g.local(name.Name)
+		g.emit("var %s sexpr.SExpr = %s", carrier, inits[i])
+		lp.carriers = append(lp.carriers, carrier)
+	}
+
+	g.emit("var %s sexpr.SExpr", lp.result)
+	g.emit("for {")
+	inner := newScope(s)
+	for i, name := range names {
+		inner.names[name.Name] = g.bind(name.Name, lp.carriers[i])
+	}
+	if _, err := g.body(list.Elements[2:], inner, lp); err != nil {
+		return "", err
+	}
+	g.emit("}")
+	return lp.result, nil
+}
+
+// recur compiles (recur value...) in tail position of lp
+func (g *generator) recur(list sexpr.List, s *scope, lp *loop) error {
+	if lp == nil {
+		return g.errorf(list, "recur must be in tail position of a loop")
+	}
+	operands := list.Elements[1:]
+	if len(operands) != len(lp.carriers) {
+		return g.errorf(list, "loop has %d bindings, recur gives %d values", len(lp.carriers), len(operands))
+	}
+
+	values := make([]string, len(operands))
+	for i, x := range operands {
+		var err error
+		if values[i], err = g.expr(x, s); err != nil {
+			return err
+		}
+	}
+	if len(values) > 0 {
+		g.emit("%s = %s", strings.Join(lp.carriers, ", "), strings.Join(values, ", "))
+	}
+	g.emit("continue")
+	return nil
+}
+
+// lambda compiles a function value. Its body is compiled outside any
+// loop, since recur cannot cross a function boundary.
+func (g *generator) lambda(params []sexpr.Symbol, rest *sexpr.Symbol, body []sexpr.SExpr, s *scope) (string, error) {
+	n := len(params)
+	check, max := fmt.Sprintf("len(args) != %d", n), n
+	if rest != nil {
+		check, max = fmt.Sprintf("len(args) < %d", n), -1
+	}
+
+	t := g.temp()
+	g.emit("%s := %s(\"lambda\", func(args []sexpr.SExpr) (sexpr.SExpr, error) {", t, g.rt("Func"))
+	g.emit("if %s {", check)
+	g.emit("return nil, %s(\"\", %d, %d, len(args))", g.rt("ArityError"), n, max)
+	g.emit("}")
+	inner := newScope(s)
+	for i, p := range params {
+		inner.names[p.Name] = g.bind(p.Name, fmt.Sprintf("args[%d]", i))
+	}
+	if rest != nil {
+		inner.names[rest.Name] = g.bind(rest.Name, fmt.Sprintf("sexpr.List{Elements: append([]sexpr.SExpr{}, args[%d:]...)}", n))
+	}
+	result, err := g.body(body, inner, nil)
+	if err != nil {
+		return "", err
+	}
+	g.emit("return %s, nil", result)
+	g.emit("})")
+	return t, nil
+}
+
+// set compiles (set! name value)
+func (g *generator) set(list sexpr.List, s *scope) (string, error) {
+	if len(list.Elements) != 3 {
+		return "", g.errorf(list, "set! requires a name and a value")
+	}
+	name, ok := list.Elements[1].(sexpr.Symbol)
+	if !ok {
+		return "", g.errorf(list, "first argument of set! must be a symbol")
+	}
+	value, err := g.expr(list.Elements[2], s)
+	if err != nil {
+		return "", err
+	}
+
+	variable, ok := s.lookup(name.Name)
+	if !ok {
+		d, isGlobal := g.globals[name.Name]
+		if !isGlobal || d.fn {
+			return "", g.errorf(list, "cannot set! %s", name.Name)
+		}
+		variable = d.goName
+	}
+	g.emit("%s = %s", variable, value)
+	return value, nil
+}
+
+// literal returns a Go expression constructing the datum x
+func (g *generator) literal(x sexpr.SExpr) (string, error) {
+	switch v := x.(type) {
+	case sexpr.Number:
+		return fmt.Sprintf("sexpr.Number{Value: %d}", v.Value), nil
+	case sexpr.BigInt:
+		return fmt.Sprintf("sexpr.BigInt{Value: %s(%q)}", g.rt("Big"), v.Value.String()), nil
+	case sexpr.Float:
+		if math.IsInf(v.Value, 0) || math.IsNaN(v.Value) {
+			return "", g.errorf(x, "unsupported literal %v", x)
+		}
+		return fmt.Sprintf("sexpr.Float{Value: %s}", strconv.FormatFloat(v.Value, 'g', -1, 64)), nil
+	case sexpr.String:
+		return fmt.Sprintf("sexpr.String{Value: %s}", strconv.Quote(v.Value)), nil
+	case sexpr.Bool:
+		return fmt.Sprintf("sexpr.Bool{Value: %t}", v.Value), nil
+	case sexpr.Nil:
+		return "sexpr.Nil{}", nil
+	case sexpr.Keyword:
+		return fmt.Sprintf("sexpr.Keyword{Name: %q}", v.Name), nil
+	case sexpr.Char:
+		return fmt.Sprintf("sexpr.Char{Value: %q}", v.Value), nil
+	case sexpr.Symbol:
+		return fmt.Sprintf("sexpr.Symbol{Name: %q}", v.Name), nil
+	case sexpr.List:
+		if len(v.Elements) == 0 {
+			return "sexpr.List{}", nil
+		}
+		elems, err := g.literals(v.Elements)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("sexpr.List{Elements: []sexpr.SExpr{%s}}", elems), nil
+	case sexpr.Pair:
+		car, err := g.literal(v.Car)
+		if err != nil {
+			return "", err
+		}
+		cdr, err := g.literal(v.Cdr)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("sexpr.Cons(%s, %s)", car, cdr), nil
+	case sexpr.Vector:
+		elems, err := g.literals(v.Elements())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("sexpr.NewVector(%s)", elems), nil
+	}
+	return "", g.errorf(x, "unsupported literal %v", x)
+}
+
+// literals returns a comma-separated list of literals
+func (g *generator) literals(xs []sexpr.SExpr) (string, error) {
+	elems := make([]string, len(xs))
+	for i, x := range xs {
+		var err error
+		if elems[i], err = g.literal(x); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(elems, ", "), nil
+}
+
+// findMutated adds the names x assigns with set! to mutated
+func findMutated(x sexpr.SExpr, mutated map[string]bool) {
+	list, ok := x.(sexpr.List)
+	if !ok || len(list.Elements) == 0 || isSymbolNamed(list.Elements[0], "quote") {
+		return
+	}
+	if isSymbolNamed(list.Elements[0], "set!") && len(list.Elements) > 1 {
+		if name, ok := list.Elements[1].(sexpr.Symbol); ok {
+			mutated[name.Name] = true
+		}
+	}
+	for _, elem := range list.Elements {
+		findMutated(elem, mutated)
+	}
+}
+
+// charNames spell out the punctuation in names for Go identifiers
+var charNames = map[rune]string{
+	'+': "Plus", '-': "Minus", '*': "Star", '/': "Slash", '%': "Percent",
+	'<': "Lt", '>': "Gt", '=': "Eq", '?': "P", '!': "Bang", '&': "And",
+	'$': "Dollar", '.': "Dot", ':': "Colon", '~': "Tilde", '^': "Caret",
+	'@': "At", '|': "Bar",
+}
+
+// mangle turns a zylisp name into a camel-cased Go identifier, treating
+// hyphens and underscores between words as separators and spelling out
+// other punctuation: list-sum becomes ListSum and even? becomes EvenP.
+func mangle(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			if upper && r >= 'a' && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+			b.WriteRune(r)
+			upper = false
+		case (r == '-' || r == '_') && b.Len() > 0:
+			upper = true
+		case charNames[r] != "":
+			b.WriteString(charNames[r])
+			upper = true
+		default:
+			fmt.Fprintf(&b, "U%04X", r)
+			upper = true
+		}
+	}
+	return b.String()
+}
+
+// exported makes a mangled name usable as an exported Go identifier
+func exported(name string) string {
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		return "X" + name
+	}
+	return name
+}
+
+// isConstant reports whether x evaluates to itself
+func isConstant(x sexpr.SExpr) bool {
+	switch x.(type) {
+	case sexpr.Number, sexpr.BigInt, sexpr.Float, sexpr.String,
+		sexpr.Bool, sexpr.Nil, sexpr.Keyword, sexpr.Char:
+		return true
+	}
+	return false
+}
+
+// isQuote reports whether x is (quote datum)
+func isQuote(x sexpr.SExpr) bool {
+	list, ok := x.(sexpr.List)
+	return ok && len(list.Elements) == 2 && isSymbolNamed(list.Elements[0], "quote")
+}
+
+// quoted returns the datum of a constant or quote form
+func quoted(x sexpr.SExpr) sexpr.SExpr {
+	if isQuote(x) {
+		return x.(sexpr.List).Elements[1]
+	}
+	return x
+}
+
+// isSymbolNamed reports whether x is the symbol name
+func isSymbolNamed(x sexpr.SExpr, name string) bool {
+	sym, ok := x.(sexpr.Symbol)
+	return ok && sym.Name == name
+}
+
+// withElements returns a list with list's metadata and the given elements
+func withElements(list sexpr.List, elems ...sexpr.SExpr) sexpr.List {
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
@@ -0,0 +1,355 @@
+package compile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/parser"
+)
+
+func TestCompileOutput(t *testing.T) {
+	src, err := CompileSource("square.zy", `
+(define unit 1)
+(define (square x) (* x x))
+`, Options{Package: "square"})
+	if err != nil {
+		t.Fatalf("CompileSource: %v", err)
+	}
+
+	expected := `// Code generated by zylisp compile. DO NOT EDIT.
+
+package square
+
+import (
+	"github.com/zylisp/lang/compile"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Unit is the zylisp variable unit
+var Unit sexpr.SExpr = sexpr.Number{Value: 1}
+
+// Square is the zylisp function square
+func Square(x_1 sexpr.SExpr) (sexpr.SExpr, error) {
+	v_2, err := compile.Mul(x_1, x_1)
+	if err != nil {
+		return nil, err
+	}
+	return v_2, nil
+}
+`
+	if string(src) != expected {
+		t.Errorf("CompileSource generated:\n%s\nwant:\n%s", src, expected)
+	}
+}
+
+func TestCompileGenerates(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+	}{
+		{"direct call", "(define (f x) (g x 1)) (define (g a b) a)", []string{"G(x_1, sexpr.Number{Value: 1})"}},
+		{"builtin", "(define (f x) (list x))", []string{`zyList = compile.Builtin("list")`, "compile.Apply(zyList, x_1)"}},
+		{"fast path needs two arguments", "(define (f) (+ 1 2 3))", []string{`compile.Apply(zyPlus,`}},
+		{"rest parameters", "(define (f a . more) more)", []string{"func F(a_1 sexpr.SExpr, rest ...sexpr.SExpr)"}},
+		{"names", "(define (list-sum? xs) xs) (define (set-x!) 1)", []string{"func ListSumP(", "func SetXBang("}},
+		{"default package", "(define x 1)", []string{"package zylisp"}},
+		{"no runtime", "(define x '(a \"b\" :c))", []string{`sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: "a"}, sexpr.String{Value: "b"}, sexpr.Keyword{Name: "c"}}}`}},
+		{"characters", `(define x '(#\a #\newline))`, []string{`sexpr.Char{Value: 'a'}, sexpr.Char{Value: '\n'}`}},
+		{"function value", "(define (f) f)", []string{`compile.Func("f",`, "return F()"}},
+		{"loop", "(define (f n) (loop ((i 0)) (if (< i n) (recur (+ i 1)) i)))", []string{"for {", "continue", "break"}},
+		{"mutated reads are copied", "(define (f x) (list x (begin (set! x 2) x)))", []string{"v_2 := x_1"}},
+		{"interpolation uses the builtin str", `(define (f str) #"v=${str}")`, []string{`zyStr = compile.Builtin("str")`, `compile.Apply(zyStr, sexpr.String{Value: "v="}, str_1)`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := CompileSource("test.zy", tt.input, Options{})
+			if err != nil {
+				t.Fatalf("CompileSource: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(src), want) {
+					t.Errorf("generated code does not contain %q:\n%s", want, src)
+				}
+			}
+		})
+	}
+
+	src, err := CompileSource("test.zy", "(define x '(1 2))", Options{})
+	if err != nil {
+		t.Fatalf("CompileSource: %v", err)
+	}
+	if strings.Contains(string(src), runtimePath) {
+		t.Errorf("generated code imports the runtime without using it:\n%s", src)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(+ 1 2)", "test.zy:1:1: only definitions are allowed at top level"},
+		{"(define x (+ 1 2))", "top-level value of x must be a lambda or a constant"},
+		{"(define (f) 1) (define (f) 2)", "test.zy:1:16: f is already defined"},
+		{"(define (a-b) 1) (define (a_b) 2)", "a-b and a_b both become AB in Go"},
+		{"(define (f) (g))", "test.zy:1:13: undefined: g"},
+		{"(define (f) (try 1 (catch e 2)))", "unsupported form try"},
+		{"(define (f x) (f))", "test.zy:1:15: f takes 1 argument, got 0"},
+		{"(define (f . xs) 1) (define (g) (f))", ""},
+		{"(define (f x . xs) 1) (define (g) (f))", "f takes at least 1 argument, got 0"},
+		{"(define (f) (recur 1))", "recur must be in tail position of a loop"},
+		{"(define (f) (loop ((i 0)) (+ 1 (recur i))))", "test.zy:1:32: recur must be in tail position"},
+		{"(define (f) (loop ((i 0)) (lambda () (recur i))))", "recur must be in tail position"},
+		{"(define (f) (loop ((i 0)) (recur)))", "loop has 1 bindings, recur gives 0 values"},
+		{"(define (f) (if 1 2))", "if requires a test"},
+		{"(define (f) (set! g 1))", "cannot set! g"},
+		{"(define (f) (set! f 1))", "cannot set! f"},
+		{"(define (f) (if (define y 1) 1 2))", "define is only allowed"},
+		{"(define (f) (cond (1 => car)))", "=> are not supported"},
+		{"(define (f (a b)) a)", "parameter must be a symbol"},
+		{"(define (f) (lambda (x) 1 2))", "lambda requires parameters and a body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := CompileSource("test.zy", tt.input, Options{})
+			if tt.expected == "" {
+				if err != nil {
+					t.Fatalf("CompileSource(%s): %v", tt.input, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("CompileSource(%s) succeeded, want error %q", tt.input, tt.expected)
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("CompileSource(%s) error = %q, want %q", tt.input, err, tt.expected)
+			}
+			var compileErr *Error
+			if !errors.As(err, &compileErr) {
+				t.Errorf("CompileSource(%s) error %T is not an *Error", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestMangle(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"fib", "Fib"},
+		{"list-sum", "ListSum"},
+		{"even?", "EvenP"},
+		{"set-car!", "SetCarBang"},
+		{"->string", "MinusGtString"},
+		{"+", "Plus"},
+		{"a*b", "AStarB"},
+		{"snake_case", "SnakeCase"},
+		{"x2", "X2"},
+	}
+
+	for _, tt := range tests {
+		if got := mangle(tt.name); got != tt.expected {
+			t.Errorf("mangle(%q) = %q, want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+// compiledProgram exercises the supported forms. Each call in
+// compiledCalls is run both compiled and interpreted.
+const compiledProgram = `
+(define limit 10)
+(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))
+(define (sum-to n)
+  (loop ((i 0) (acc 0))
+    (if (> i n) acc (recur (+ i 1) (+ acc i)))))
+(define (adder n) (lambda (x) (+ x n)))
+(define (counter)
+  (let ((c 0))
+    (lambda () (begin (set! c (+ c 1)) c))))
+(define (count-twice)
+  (let ((next (counter)))
+    (next)
+    (next)))
+(define (classify x)
+  (cond ((< x 0) 'negative)
+        ((= x 0) 'zero)
+        ((> x limit) 'big)
+        (else 'small)))
+(define (parity n)
+  (letrec ((even? (lambda (n) (if (= n 0) true (odd? (- n 1)))))
+           (odd? (lambda (n) (if (= n 0) false (even? (- n 1))))))
+    (if (even? n) :even :odd)))
+(define (args . xs) xs)
+(define (first-and-rest x . xs) (list x xs))
+(define (logic a b) (list (and a b) (or a b) (and) (or)))
+(define (guarded x) (list (when (> x 0) 'pos) (unless (> x 0) 'nonpos)))
+(define (nested n)
+  (define (twice y) (* 2 y))
+  (define base 100)
+  (let* ((a (twice n)) (b (+ a base)))
+    (begin a b)))
+(define (bump!) (set! limit (+ limit 1)) limit)
+(define (closures)
+  (loop ((i 0) (fs '()))
+    (if (< i 3)
+        (recur (+ i 1) (cons (lambda () i) fs))
+        (apply list (list ((car fs)) ((car (cdr fs))) ((car (cdr (cdr fs)))))))))
+(define (call-with f x) (f x))
+(define (overflow) (* 4611686018427387904 4))
+(define (big) (+ 100000000000000000000 1))
+(define (divide) (list (/ 7 2) (/ 6 3)))
+(define (order x) (list x (begin (set! x 2) x) x))
+(define (quoted) '(a (b . c) "s" :k [1 2] nil true))
+(define (fail) (car 1))
+`
+
+var compiledCalls = []struct {
+	goCall string
+	expr   string
+}{
+	{"Fib(sexpr.Number{Value: 20})", "(fib 20)"},
+	{"SumTo(sexpr.Number{Value: 100})", "(sum-to 100)"},
+	{"compile.Apply(must(Adder(sexpr.Number{Value: 3})), sexpr.Number{Value: 4})", "((adder 3) 4)"},
+	{"CountTwice()", "(count-twice)"},
+	{"Classify(sexpr.Number{Value: -5})", "(classify -5)"},
+	{"Classify(sexpr.Number{Value: 0})", "(classify 0)"},
+	{"Classify(sexpr.Number{Value: 50})", "(classify 50)"},
+	{"Classify(sexpr.Number{Value: 5})", "(classify 5)"},
+	{"Parity(sexpr.Number{Value: 7})", "(parity 7)"},
+	{"Args()", "(args)"},
+	{"Args(sexpr.Number{Value: 1}, sexpr.Number{Value: 2})", "(args 1 2)"},
+	{"FirstAndRest(sexpr.Number{Value: 1}, sexpr.Number{Value: 2}, sexpr.Number{Value: 3})", "(first-and-rest 1 2 3)"},
+	{"Logic(sexpr.Number{Value: 1}, sexpr.Bool{Value: false})", "(logic 1 false)"},
+	{"Logic(sexpr.Bool{Value: false}, sexpr.Number{Value: 2})", "(logic false 2)"},
+	{"Guarded(sexpr.Number{Value: 1})", "(guarded 1)"},
+	{"Guarded(sexpr.Number{Value: -1})", "(guarded -1)"},
+	{"Nested(sexpr.Number{Value: 5})", "(nested 5)"},
+	{"BumpBang()", "(bump!)"},
+	{"BumpBang()", "(bump!)"},
+	{"Closures()", "(closures)"},
+	{`CallWith(compile.Builtin("car"), sexpr.List{Elements: []sexpr.SExpr{sexpr.Number{Value: 9}}})`, "(call-with car '(9))"},
+	{"CallWith(must(Adder(sexpr.Number{Value: 1})), sexpr.Number{Value: 2})", "(call-with (adder 1) 2)"},
+	{"Overflow()", "(overflow)"},
+	{"Big()", "(big)"},
+	{"Divide()", "(divide)"},
+	{"Order(sexpr.Number{Value: 1})", "(order 1)"},
+	{"Quoted()", "(quoted)"},
+	{"Fail()", "(fail)"},
+}
+
+// TestCompiledProgram builds and runs a compiled program and checks it
+// gives the same results as the interpreter
+func TestCompiledProgram(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a Go program")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	src, err := CompileSource("program.zy", compiledProgram, Options{Package: "main"})
+	if err != nil {
+		t.Fatalf("CompileSource: %v", err)
+	}
+
+	var main strings.Builder
+	main.WriteString(`package main
+
+import (
+	"fmt"
+
+	"github.com/zylisp/lang/compile"
+	"github.com/zylisp/lang/sexpr"
+)
+
+var _ = compile.Apply
+
+func must(v sexpr.SExpr, err error) sexpr.SExpr {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func show(v sexpr.SExpr, err error) {
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(v)
+}
+
+func main() {
+`)
+	for _, c := range compiledCalls {
+		fmt.Fprintf(&main, "\tshow(%s)\n", c.goCall)
+	}
+	main.WriteString("}\n")
+
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	goMod := fmt.Sprintf("module example.com/program\n\ngo 1.24\n\nrequire github.com/zylisp/lang v0.0.0\n\nreplace github.com/zylisp/lang => %s\n", root)
+	for name, content := range map[string]string{
+		"go.mod":     goMod,
+		"program.go": string(src),
+		"main.go":    main.String(),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s\ngenerated code:\n%s", err, out, src)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(got) != len(compiledCalls) {
+		t.Fatalf("program printed %d lines, want %d:\n%s", len(got), len(compiledCalls), out)
+	}
+
+	env := interpreter.NewEnv(nil)
+	interpreter.LoadPrimitives(env)
+	forms, err := parser.ReadAll(compiledProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, form := range forms {
+		if _, err := interpreter.Eval(form, env); err != nil {
+			t.Fatalf("Eval(%v): %v", form, err)
+		}
+	}
+	for i, c := range compiledCalls {
+		expr, err := parser.ReadAll(c.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := interpreter.Eval(expr[0], env)
+		want := fmt.Sprint(value)
+		if err != nil {
+			// Compiled code does not trace the calls an error passes
+			// through
+			want, _, _ = strings.Cut("error: "+err.Error(), "\n")
+		}
+		if got[i] != want {
+			t.Errorf("%s: compiled gives %s, interpreted gives %s", c.expr, got[i], want)
+		}
+	}
+}
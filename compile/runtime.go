@@ -0,0 +1,172 @@
+package compile
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// The functions in this file support the generated code. They are not
+// meant to be called by hand.
+
+// runtimeEnv returns the environment builtins called by generated code
+// run in. It has the interpreter's primitives and nothing else.
+var runtimeEnv = sync.OnceValue(func() *interpreter.Env {
+	env := interpreter.NewEnv(nil)
+	interpreter.LoadPrimitives(env)
+	return env
+})
+
+// Builtin returns the value of the interpreter primitive name. It panics
+// if there is none, which Compile rules out for the names it generates.
+func Builtin(name string) sexpr.SExpr {
+	value, err := runtimeEnv().Lookup(name)
+	if err != nil {
+		panic(fmt.Sprintf("compile: no builtin %s", name))
+	}
+	return value
+}
+
+// Apply calls fn with args. fn may be a compiled function, an interpreter
+// primitive or an interpreted function.
+func Apply(fn sexpr.SExpr, args ...sexpr.SExpr) (sexpr.SExpr, error) {
+	return interpreter.Apply(fn, args, runtimeEnv())
+}
+
+// Func makes a compiled function into a value that can be passed around,
+// stored and called by Apply or by the interpreter
+func Func(name string, fn func(args []sexpr.SExpr) (sexpr.SExpr, error)) sexpr.SExpr {
+	return sexpr.Primitive{
+		Name: name,
+		Fn: func(args []sexpr.SExpr, _ interface{}) (sexpr.SExpr, error) {
+			return fn(args)
+		},
+	}
+}
+
+// ArityError reports a call of the function name with got arguments that
+// needed between min and max, or at least min if max is -1
+func ArityError(name string, min, max, got int) error {
+	return &interpreter.ArityError{Name: name, Min: min, Max: max, Got: got}
+}
+
+// Truthy reports whether v counts as true in a conditional
+func Truthy(v sexpr.SExpr) bool {
+	switch b := v.(type) {
+	case sexpr.Bool:
+		return b.Value
+	case sexpr.Nil:
+		return false
+	}
+	return true
+}
+
+// Big parses the decimal integer s, for big integer literals
+func Big(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(fmt.Sprintf("compile: invalid integer %s", s))
+	}
+	return n
+}
+
+// Arithmetic and comparison of two arguments take a fast path for
+// integers that fit in an int64 and otherwise call the builtin, which
+// promotes to big integers and floats as the interpreter does
+
+var (
+	addBuiltin = sync.OnceValue(func() sexpr.SExpr { return Builtin("+") })
+	subBuiltin = sync.OnceValue(func() sexpr.SExpr { return Builtin("-") })
+	mulBuiltin = sync.OnceValue(func() sexpr.SExpr { return Builtin("*") })
+	eqBuiltin  = sync.OnceValue(func() sexpr.SExpr { return Builtin("=") })
+	ltBuiltin  = sync.OnceValue(func() sexpr.SExpr { return Builtin("<") })
+	gtBuiltin  = sync.OnceValue(func() sexpr.SExpr { return Builtin(">") })
+	lteBuiltin = sync.OnceValue(func() sexpr.SExpr { return Builtin("<=") })
+	gteBuiltin = sync.OnceValue(func() sexpr.SExpr { return Builtin(">=") })
+)
+
+// integers returns the values of a and b if both are Numbers
+func integers(a, b sexpr.SExpr) (int64, int64, bool) {
+	x, ok := a.(sexpr.Number)
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := b.(sexpr.Number)
+	return x.Value, y.Value, ok
+}
+
+// Add returns a + b
+func Add(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		if s := x + y; (s^x)&(s^y) >= 0 {
+			return sexpr.Number{Value: s}, nil
+		}
+	}
+	return Apply(addBuiltin(), a, b)
+}
+
+// Sub returns a - b
+func Sub(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		if d := x - y; (x^y)&(d^x) >= 0 {
+			return sexpr.Number{Value: d}, nil
+		}
+	}
+	return Apply(subBuiltin(), a, b)
+}
+
+// Mul returns a * b
+func Mul(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		if x == 0 || y == 0 {
+			return sexpr.Number{Value: 0}, nil
+		}
+		if p := x * y; p/y == x && !(x == -1 && y == -1<<63) && !(y == -1 && x == -1<<63) {
+			return sexpr.Number{Value: p}, nil
+		}
+	}
+	return Apply(mulBuiltin(), a, b)
+}
+
+// NumEq returns whether the numbers a and b are equal
+func NumEq(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		return sexpr.Bool{Value: x == y}, nil
+	}
+	return Apply(eqBuiltin(), a, b)
+}
+
+// Less returns whether a < b
+func Less(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		return sexpr.Bool{Value: x < y}, nil
+	}
+	return Apply(ltBuiltin(), a, b)
+}
+
+// Greater returns whether a > b
+func Greater(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		return sexpr.Bool{Value: x > y}, nil
+	}
+	return Apply(gtBuiltin(), a, b)
+}
+
+// LessEq returns whether a <= b
+func LessEq(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		return sexpr.Bool{Value: x <= y}, nil
+	}
+	return Apply(lteBuiltin(), a, b)
+}
+
+// GreaterEq returns whether a >= b
+func GreaterEq(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := integers(a, b); ok {
+		return sexpr.Bool{Value: x >= y}, nil
+	}
+	return Apply(gteBuiltin(), a, b)
+}
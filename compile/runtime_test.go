@@ -0,0 +1,105 @@
+package compile
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestArithmetic(t *testing.T) {
+	num := func(n int64) sexpr.SExpr { return sexpr.Number{Value: n} }
+	tests := []struct {
+		name     string
+		fn       func(a, b sexpr.SExpr) (sexpr.SExpr, error)
+		a, b     sexpr.SExpr
+		expected string
+	}{
+		{"add", Add, num(2), num(3), "5"},
+		{"add overflows to big", Add, num(math.MaxInt64), num(1), "9223372036854775808"},
+		{"sub", Sub, num(2), num(3), "-1"},
+		{"sub overflows to big", Sub, num(math.MinInt64), num(1), "-9223372036854775809"},
+		{"mul", Mul, num(-4), num(3), "-12"},
+		{"mul by zero", Mul, num(0), num(math.MinInt64), "0"},
+		{"mul overflows to big", Mul, num(math.MinInt64), num(-1), "9223372036854775808"},
+		{"mul big", Mul, num(1 << 62), num(4), "18446744073709551616"},
+		{"big operands", Add, sexpr.BigInt{Value: Big("100000000000000000000")}, num(1), "100000000000000000001"},
+		{"equal", NumEq, num(2), num(2), "true"},
+		{"less", Less, num(2), num(3), "true"},
+		{"greater", Greater, num(2), num(3), "false"},
+		{"less or equal", LessEq, num(3), num(3), "true"},
+		{"greater or equal", GreaterEq, num(2), num(3), "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("%v, %v: %v", tt.a, tt.b, err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("%v, %v = %v, want %s", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Add(num(1), sexpr.String{Value: "a"}); err == nil {
+		t.Error("Add(1, \"a\") succeeded")
+	}
+}
+
+func TestFunc(t *testing.T) {
+	double := Func("double", func(args []sexpr.SExpr) (sexpr.SExpr, error) {
+		if len(args) != 1 {
+			return nil, ArityError("double", 1, 1, len(args))
+		}
+		return Mul(args[0], sexpr.Number{Value: 2})
+	})
+
+	got, err := Apply(double, sexpr.Number{Value: 21})
+	if err != nil || got.String() != "42" {
+		t.Errorf("Apply(double, 21) = %v, %v, want 42", got, err)
+	}
+
+	// A compiled function can be called by interpreted code
+	mapped, err := Apply(Builtin("apply"), double, sexpr.List{Elements: []sexpr.SExpr{sexpr.Number{Value: 5}}})
+	if err != nil || mapped.String() != "10" {
+		t.Errorf("(apply double '(5)) = %v, %v, want 10", mapped, err)
+	}
+
+	_, err = Apply(double)
+	var arityErr *interpreter.ArityError
+	if !errors.As(err, &arityErr) || arityErr.Got != 0 {
+		t.Errorf("Apply(double) error = %v, want an arity error", err)
+	}
+}
+
+func TestTruthy(t *testing.T) {
+	tests := []struct {
+		value    sexpr.SExpr
+		expected bool
+	}{
+		{sexpr.Bool{Value: true}, true},
+		{sexpr.Bool{Value: false}, false},
+		{sexpr.Nil{}, false},
+		{sexpr.Number{Value: 0}, true},
+		{sexpr.List{}, true},
+	}
+
+	for _, tt := range tests {
+		if got := Truthy(tt.value); got != tt.expected {
+			t.Errorf("Truthy(%v) = %t, want %t", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestBuiltinPanicsOnUnknownName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Builtin of an unknown name did not panic")
+		}
+	}()
+	Builtin("no-such-primitive")
+}
@@ -0,0 +1,182 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// FromJSON parses a JSON document into an S-expression. Objects become
+// maps with string keys in document order, arrays become lists, integral
+// numbers become Number (or BigInt), other numbers Float, and null
+// becomes nil.
+func FromJSON(data []byte) (sexpr.SExpr, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeJSON(dec)
+	if err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("json: unexpected data after top-level value")
+	}
+	return value, nil
+}
+
+func decodeJSON(dec *json.Decoder) (sexpr.SExpr, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			elems := []sexpr.SExpr{}
+			for dec.More() {
+				elem, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, elem)
+			}
+			_, err := dec.Token() // consume ']'
+			return sexpr.List{Elements: elems}, err
+		}
+
+		m := sexpr.Map{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			if m, err = m.Assoc(sexpr.String{Value: keyTok.(string)}, value); err != nil {
+				return nil, err
+			}
+		}
+		_, err := dec.Token() // consume '}'
+		return m, err
+
+	case json.Number:
+		return jsonNumber(t)
+	case string:
+		return sexpr.String{Value: t}, nil
+	case bool:
+		return sexpr.Bool{Value: t}, nil
+	default:
+		return sexpr.Nil{}, nil
+	}
+}
+
+// jsonNumber converts a JSON number, keeping integers exact
+func jsonNumber(n json.Number) (sexpr.SExpr, error) {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return sexpr.Number{Value: i}, nil
+		}
+		if b, ok := new(big.Int).SetString(s, 10); ok {
+			return sexpr.BigInt{Value: b}, nil
+		}
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Float{Value: f}, nil
+}
+
+// ToJSON encodes an S-expression as JSON. Maps must have string or
+// symbol keys and become objects with keys in insertion order; proper
+// lists and vectors become arrays; symbols and keywords become strings; nil becomes null. Other
+// values, and non-finite floats, are rejected.
+func ToJSON(expr sexpr.SExpr) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, expr); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeJSON(buf *bytes.Buffer, expr sexpr.SExpr) error {
+	switch e := expr.(type) {
+	case sexpr.Nil:
+		buf.WriteString("null")
+	case sexpr.Bool:
+		buf.WriteString(strconv.FormatBool(e.Value))
+	case sexpr.Number, sexpr.BigInt:
+		buf.WriteString(e.String())
+	case sexpr.Float:
+		if math.IsInf(e.Value, 0) || math.IsNaN(e.Value) {
+			return fmt.Errorf("cannot encode %v", e)
+		}
+		buf.WriteString(strconv.FormatFloat(e.Value, 'g', -1, 64))
+	case sexpr.String:
+		writeJSONString(buf, e.Value)
+	case sexpr.Symbol:
+		writeJSONString(buf, e.Name)
+	case sexpr.Keyword:
+		writeJSONString(buf, e.Name)
+	case sexpr.Char:
+		writeJSONString(buf, string(e.Value))
+	case sexpr.Map:
+		buf.WriteByte('{')
+		for i, entry := range e.Entries() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			switch k := entry.Key.(type) {
+			case sexpr.String:
+				writeJSONString(buf, k.Value)
+			case sexpr.Symbol:
+				writeJSONString(buf, k.Name)
+			case sexpr.Keyword:
+				writeJSONString(buf, k.Name)
+			default:
+				return fmt.Errorf("object keys must be strings, symbols or keywords, got %v", entry.Key)
+			}
+			buf.WriteByte(':')
+			if err := encodeJSON(buf, entry.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case sexpr.List, sexpr.Pair, sexpr.Vector:
+		elems, ok := seqElements(e)
+		if !ok {
+			return fmt.Errorf("cannot encode improper list %v", e)
+		}
+		buf.WriteByte('[')
+		for i, elem := range elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return fmt.Errorf("cannot encode %v", expr)
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoded, _ := json.Marshal(s)
+	buf.Write(encoded)
+}
@@ -0,0 +1,98 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`42`, "42"},
+		{`-1.5`, "-1.5"},
+		{`123456789012345678901234567890`, "123456789012345678901234567890"},
+		{`1e3`, "1000.0"},
+		{`"hi\n"`, `"hi\n"`},
+		{`true`, "true"},
+		{`null`, "nil"},
+		{`[1, "two", [3]]`, `(1 "two" (3))`},
+		{`[]`, "()"},
+		{`{"b": 1, "a": {"c": null}}`, `{"b" 1 "a" {"c" nil}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := FromJSON([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	for _, input := range []string{`{"a": }`, `[1, 2`, `1 2`, ``} {
+		if _, err := FromJSON([]byte(input)); err == nil {
+			t.Errorf("FromJSON(%q): expected error", input)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	m, _ := sexpr.NewMap(
+		sexpr.Symbol{Name: "name"}, sexpr.String{Value: "zy\"lisp"},
+		sexpr.String{Value: "tags"}, sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.Number{Value: 1}, sexpr.Float{Value: 2.5}, sexpr.Nil{}, sexpr.Bool{Value: false}, sexpr.Char{Value: 'z'},
+		}},
+	)
+
+	result, err := ToJSON(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"name":"zy\"lisp","tags":[1,2.5,null,false,"z"]}`
+	if string(result) != expected {
+		t.Errorf("got %s, want %s", result, expected)
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	badKey, _ := sexpr.NewMap(sexpr.Number{Value: 1}, sexpr.Nil{})
+
+	tests := []sexpr.SExpr{
+		badKey,
+		sexpr.Cons(sexpr.Number{Value: 1}, sexpr.Number{Value: 2}),
+		sexpr.Primitive{Name: "+"},
+	}
+
+	for _, expr := range tests {
+		if _, err := ToJSON(expr); err == nil {
+			t.Errorf("ToJSON(%v): expected error", expr)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	input := `{"id":7,"items":[{"sku":"a-1","qty":2}],"total":19.99}`
+
+	expr, err := FromJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+
+	output, err := ToJSON(expr)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+
+	if string(output) != input {
+		t.Errorf("got %s, want %s", output, input)
+	}
+}
@@ -0,0 +1,399 @@
+// Package convert translates between Go values and S-expressions using
+// reflection, in the style of encoding/json.
+//
+// Go values map to S-expressions as follows:
+//
+//	bool                      Bool
+//	integers, *big.Int        Number (BigInt when out of int64 range)
+//	float32, float64          Float
+//	string                    String
+//	[]byte                    Bytes
+//	slices and arrays         List
+//	maps                      Map
+//	structs                   Map keyed by field-name symbols
+//	nil pointers, interfaces  Nil
+//	sexpr.SExpr               the value itself
+//
+// Struct fields are keyed by the kebab-case form of their Go name
+// (FirstName becomes first-name) unless a `zy:"name"` tag overrides it.
+// The tag option omitempty skips zero values and the name "-" skips the
+// field entirely. Unexported fields are ignored. The fields of an
+// embedded struct without a tag name are promoted into the outer map as
+// encoding/json promotes them: a shallower field hides deeper ones of the
+// same name, and of several at the same depth a tagged one wins, or
+// none if the tie remains.
+//
+// Like encoding/json, Marshal fails on a value that contains itself
+// through a pointer, map or slice rather than recursing forever.
+package convert
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Marshaler is implemented by types that convert themselves to an
+// S-expression
+type Marshaler interface {
+	MarshalSExpr() (sexpr.SExpr, error)
+}
+
+var (
+	sexprType     = reflect.TypeOf((*sexpr.SExpr)(nil)).Elem()
+	marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	bigIntType    = reflect.TypeOf(big.Int{})
+)
+
+// Marshal converts a Go value to an S-expression
+func Marshal(v any) (sexpr.SExpr, error) {
+	if v == nil {
+		return sexpr.Nil{}, nil
+	}
+	e := &encoder{visiting: map[visit]bool{}}
+	return e.marshalValue(reflect.ValueOf(v))
+}
+
+// encoder converts one value, tracking the references it is inside of
+type encoder struct {
+	visiting map[visit]bool
+}
+
+// visit identifies the target of a pointer, map or slice. Slices that
+// share an array differ in length, and a struct and its first field in
+// type.
+type visit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// enter marks the target of v as being converted, failing if it already
+// is, and returns a function that unmarks it
+func (e *encoder) enter(v reflect.Value) (func(), error) {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if e.visiting[key] {
+		return nil, fmt.Errorf("convert: encountered a cycle via %s", v.Type())
+	}
+	e.visiting[key] = true
+	return func() { delete(e.visiting, key) }, nil
+}
+
+func (e *encoder) marshalValue(v reflect.Value) (sexpr.SExpr, error) {
+	if !v.IsValid() {
+		return sexpr.Nil{}, nil
+	}
+
+	if v.Type().Implements(marshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return sexpr.Nil{}, nil
+		}
+		return v.Interface().(Marshaler).MarshalSExpr()
+	}
+	if v.Type().Implements(sexprType) && v.Kind() != reflect.Interface {
+		return v.Interface().(sexpr.SExpr), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return sexpr.Bool{Value: v.Bool()}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sexpr.Number{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > 1<<63-1 {
+			return sexpr.BigInt{Value: new(big.Int).SetUint64(u)}, nil
+		}
+		return sexpr.Number{Value: int64(u)}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return sexpr.Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return sexpr.String{Value: v.String()}, nil
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return sexpr.Bytes{Value: bytes.Clone(v.Bytes())}, nil
+		}
+		if v.IsNil() {
+			return sexpr.List{Elements: []sexpr.SExpr{}}, nil
+		}
+		leave, err := e.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		return e.marshalSeq(v)
+
+	case reflect.Array:
+		return e.marshalSeq(v)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return sexpr.Map{}, nil
+		}
+		leave, err := e.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		return e.marshalMap(v)
+
+	case reflect.Struct:
+		if v.Type() == bigIntType {
+			n := v.Addr().Interface().(*big.Int)
+			if n.IsInt64() {
+				return sexpr.Number{Value: n.Int64()}, nil
+			}
+			return sexpr.BigInt{Value: new(big.Int).Set(n)}, nil
+		}
+		return e.marshalStruct(v)
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return sexpr.Nil{}, nil
+		}
+		leave, err := e.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		return e.marshalValue(v.Elem())
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return sexpr.Nil{}, nil
+		}
+		return e.marshalValue(v.Elem())
+
+	default:
+		return nil, fmt.Errorf("convert: unsupported type %s", v.Type())
+	}
+}
+
+func (e *encoder) marshalSeq(v reflect.Value) (sexpr.SExpr, error) {
+	elems := make([]sexpr.SExpr, v.Len())
+	for i := range elems {
+		elem, err := e.marshalValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = elem
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+// marshalMap converts the entries of v in sorted key order, as
+// encoding/json does, so that the result does not depend on Go's random
+// map iteration order
+func (e *encoder) marshalMap(v reflect.Value) (sexpr.SExpr, error) {
+	m := sexpr.Map{}
+	keys := v.MapKeys()
+	slices.SortFunc(keys, compareKeys)
+	for _, k := range keys {
+		key, err := e.marshalValue(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := e.marshalValue(v.MapIndex(k))
+		if err != nil {
+			return nil, err
+		}
+		if m, err = m.Assoc(key, value); err != nil {
+			return nil, fmt.Errorf("convert: %v", err)
+		}
+	}
+	return m, nil
+}
+
+// compareKeys orders map keys: numbers by value, strings and booleans
+// naturally, and other keys by type and then printed form. The keys of
+// an interface-keyed map are compared by their dynamic values.
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+		if !a.IsValid() || !b.IsValid() {
+			return cmp.Compare(boolRank(a.IsValid()), boolRank(b.IsValid()))
+		}
+		if a.Type() != b.Type() {
+			return strings.Compare(a.Type().String(), b.Type().String())
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Bool:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// boolRank orders false before true
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (e *encoder) marshalStruct(v reflect.Value) (sexpr.SExpr, error) {
+	if !v.CanAddr() {
+		// Copy so that fields of types such as big.Int can be addressed
+		addressable := reflect.New(v.Type()).Elem()
+		addressable.Set(v)
+		v = addressable
+	}
+
+	m := sexpr.Map{}
+	for _, field := range structFields(v.Type()) {
+		fv, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// The field is in an embedded struct through a nil pointer
+			continue
+		}
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		value, err := e.marshalValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("convert: field %s: %w", field.name, err)
+		}
+
+		m, err = m.Assoc(sexpr.Symbol{Name: field.name}, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// field describes how a struct field is converted
+type field struct {
+	name      string
+	index     []int // for reflect.Value.FieldByIndex
+	omitEmpty bool
+	tagged    bool // whether a tag gave the name
+}
+
+// structFields returns the convertible fields of a struct type, with
+// those of embedded structs promoted
+func structFields(t reflect.Type) []field {
+	candidates := collectFields(t, nil, map[reflect.Type]bool{})
+
+	// Of the fields with the same name, the shallowest wins, provided it
+	// is the only one at its depth or the only tagged one
+	byName := map[string][]field{}
+	for _, f := range candidates {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var fields []field
+	for _, f := range candidates {
+		if dominant(f, byName[f.name]) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// collectFields returns the fields of struct type t, found through the
+// embedded fields at index, in order. Structs already being searched
+// are skipped so that a type embedding a pointer to itself terminates.
+func collectFields(t reflect.Type, index []int, searching map[reflect.Type]bool) []field {
+	searching[t] = true
+	defer delete(searching, t)
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		embedded := sf.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		isStruct := sf.Anonymous && embedded.Kind() == reflect.Struct
+		// Exported fields of an unexported embedded struct are promoted
+		if !sf.IsExported() && !isStruct {
+			continue
+		}
+
+		f := field{name: kebabCase(sf.Name), index: append(index[:len(index):len(index)], i)}
+		if tag, ok := sf.Tag.Lookup("zy"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				f.name, f.tagged = parts[0], true
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					f.omitEmpty = true
+				}
+			}
+		}
+
+		if isStruct && !f.tagged {
+			if !searching[embedded] {
+				fields = append(fields, collectFields(embedded, f.index, searching)...)
+			}
+			continue
+		}
+		if sf.IsExported() {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// dominant reports whether f is the field converted of those with its
+// name
+func dominant(f field, named []field) bool {
+	for _, other := range named {
+		if slices.Equal(other.index, f.index) || len(other.index) > len(f.index) {
+			continue
+		}
+		if len(other.index) < len(f.index) || other.tagged || !f.tagged {
+			return false
+		}
+	}
+	return true
+}
+
+// kebabCase converts a Go identifier such as HTTPServerName to
+// http-server-name
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+			if startsWord {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
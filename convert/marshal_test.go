@@ -0,0 +1,237 @@
+package convert
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+type server struct {
+	Name     string
+	Port     int
+	HTTPOnly bool
+	Tags     []string
+	Limits   map[string]int `zy:"limits,omitempty"`
+	Secret   string         `zy:"-"`
+	Owner    *owner         `zy:"owner,omitempty"`
+	internal int
+}
+
+type owner struct {
+	UserID int64
+}
+
+type celsius int
+
+func (c celsius) MarshalSExpr() (sexpr.SExpr, error) {
+	return sexpr.List{Elements: []sexpr.SExpr{
+		sexpr.Symbol{Name: "celsius"}, sexpr.Number{Value: int64(c)},
+	}}, nil
+}
+
+func (c *celsius) UnmarshalSExpr(expr sexpr.SExpr) error {
+	list := expr.(sexpr.List)
+	*c = celsius(list.Elements[1].(sexpr.Number).Value)
+	return nil
+}
+
+func TestMarshalScalars(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"nil", nil, "nil"},
+		{"bool", true, "true"},
+		{"int", 42, "42"},
+		{"uint8", uint8(7), "7"},
+		{"large uint64", uint64(1 << 63), "9223372036854775808"},
+		{"float", 2.5, "2.5"},
+		{"string", "hi", `"hi"`},
+		{"slice", []int{1, 2, 3}, "(1 2 3)"},
+		{"array", [2]string{"a", "b"}, `("a" "b")`},
+		{"nil slice", []int(nil), "()"},
+		{"bytes", []byte("hi"), "#u8(104 105)"},
+		{"nil pointer", (*int)(nil), "nil"},
+		{"big int", big.NewInt(5), "5"},
+		{"sexpr passthrough", sexpr.Symbol{Name: "x"}, "x"},
+		{"marshaler", celsius(21), "(celsius 21)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarshalStruct(t *testing.T) {
+	s := server{
+		Name:     "web",
+		Port:     8080,
+		HTTPOnly: true,
+		Tags:     []string{"prod"},
+		Secret:   "hunter2",
+		internal: 1,
+	}
+
+	result, err := Marshal(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{name "web" port 8080 http-only true tags ("prod")}`
+	if result.String() != expected {
+		t.Errorf("got %v, want %s", result, expected)
+	}
+}
+
+func TestMarshalMapOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"string keys", map[string]int{"c": 3, "a": 1, "b": 2, "d": 4}, `{"a" 1 "b" 2 "c" 3 "d" 4}`},
+		{"int keys", map[int]bool{10: true, -1: false, 9: true, 100: false}, "{-1 false 9 true 10 true 100 false}"},
+		{"float keys", map[float64]int{2.5: 1, -0.5: 2, 10: 3}, "{-0.5 2 2.5 1 10.0 3}"},
+		{"bool keys", map[bool]int{true: 1, false: 0}, "{false 0 true 1}"},
+		{"mixed keys", map[any]int{"b": 1, 2: 2, "a": 3, 1: 4}, `{1 4 2 2 "a" 3 "b" 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Go randomizes map iteration, so a single run could pass
+			// by chance
+			for range 20 {
+				result, err := Marshal(tt.value)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if result.String() != tt.expected {
+					t.Fatalf("got %v, want %s", result, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+func TestMarshalCycles(t *testing.T) {
+	loop := &node{Value: 1}
+	loop.Next = loop
+	m := map[string]any{}
+	m["self"] = m
+	s := []any{nil}
+	s[0] = s
+
+	for name, value := range map[string]any{"pointer": loop, "map": m, "slice": s} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Marshal(value)
+			if err == nil || !strings.Contains(err.Error(), "encountered a cycle") {
+				t.Errorf("expected a cycle error, got %v", err)
+			}
+		})
+	}
+
+	// A value reached twice without a cycle is converted twice
+	shared := &node{Value: 2}
+	result, err := Marshal([]*node{shared, shared})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "({value 2 next nil} {value 2 next nil})"
+	if result.String() != expected {
+		t.Errorf("got %v, want %s", result, expected)
+	}
+}
+
+type base struct {
+	ID   int
+	Name string
+}
+
+type audit struct {
+	Created string
+	Name    string `zy:"audit-name"`
+}
+
+type record struct {
+	base
+	*audit
+	Name  string
+	Extra struct {
+		Note string
+	}
+}
+
+type tie struct {
+	base
+	Other
+}
+
+type Other struct {
+	ID int
+}
+
+func TestMarshalEmbedded(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{
+			"promoted fields",
+			record{base: base{ID: 1, Name: "hidden"}, audit: &audit{Created: "now", Name: "a"}, Name: "outer"},
+			`{id 1 created "now" audit-name "a" name "outer" extra {note ""}}`,
+		},
+		{"nil embedded pointer", record{base: base{ID: 1}}, `{id 1 name "" extra {note ""}}`},
+		{"ambiguous fields are dropped", tie{base: base{ID: 1, Name: "n"}, Other: Other{ID: 2}}, `{name "n"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	if _, err := Marshal(make(chan int)); err == nil {
+		t.Error("expected error for channel")
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":           "name",
+		"FirstName":      "first-name",
+		"HTTPServerName": "http-server-name",
+		"UserID":         "user-id",
+		"ID":             "id",
+	}
+
+	for input, expected := range tests {
+		if got := kebabCase(input); got != expected {
+			t.Errorf("kebabCase(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
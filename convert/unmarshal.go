@@ -0,0 +1,321 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Unmarshaler is implemented by types that populate themselves from an
+// S-expression
+type Unmarshaler interface {
+	UnmarshalSExpr(sexpr.SExpr) error
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// Unmarshal stores the Go equivalent of expr in the value pointed to by
+// v, following the mapping described in the package documentation.
+// Struct fields are matched by their tag or kebab-case name against
+// symbol, keyword or string keys; keys with no matching field are
+// ignored. Slices and arrays are filled from lists or vectors.
+//
+// When the target is an empty interface, Unmarshal stores bool, int64,
+// *big.Int, float64, string, []any, map[string]any (for maps with symbol,
+// keyword or string keys) or nil, and any other value as its sexpr.SExpr.
+func Unmarshal(expr sexpr.SExpr, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("convert: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	return unmarshalValue(expr, rv.Elem())
+}
+
+func unmarshalValue(expr sexpr.SExpr, v reflect.Value) error {
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).UnmarshalSExpr(expr)
+	}
+
+	// S-expression targets receive the value unchanged
+	if v.Type().Implements(sexprType) {
+		value := reflect.ValueOf(expr)
+		if !value.Type().AssignableTo(v.Type()) {
+			return mismatch(expr, v.Type())
+		}
+		v.Set(value)
+		return nil
+	}
+
+	if _, ok := expr.(sexpr.Nil); ok {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, ok := expr.(sexpr.Bool)
+		if !ok {
+			return mismatch(expr, v.Type())
+		}
+		v.SetBool(b.Value)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := expr.(sexpr.Number)
+		if !ok || v.OverflowInt(n.Value) {
+			return mismatch(expr, v.Type())
+		}
+		v.SetInt(n.Value)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := toUint(expr)
+		if !ok || v.OverflowUint(u) {
+			return mismatch(expr, v.Type())
+		}
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		switch n := expr.(type) {
+		case sexpr.Float:
+			v.SetFloat(n.Value)
+		case sexpr.Number:
+			v.SetFloat(float64(n.Value))
+		default:
+			return mismatch(expr, v.Type())
+		}
+
+	case reflect.String:
+		s, ok := expr.(sexpr.String)
+		if !ok {
+			return mismatch(expr, v.Type())
+		}
+		v.SetString(s.Value)
+
+	case reflect.Slice:
+		if b, ok := expr.(sexpr.Bytes); ok && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(bytes.Clone(b.Value))
+			return nil
+		}
+		elems, ok := seqElements(expr)
+		if !ok {
+			return mismatch(expr, v.Type())
+		}
+		slice := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := unmarshalValue(elem, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+	case reflect.Array:
+		elems, ok := seqElements(expr)
+		if !ok || len(elems) != v.Len() {
+			return mismatch(expr, v.Type())
+		}
+		for i, elem := range elems {
+			if err := unmarshalValue(elem, v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		return unmarshalMap(expr, v)
+
+	case reflect.Struct:
+		if v.Type() == bigIntType {
+			return unmarshalBig(expr, v.Addr().Interface().(*big.Int))
+		}
+		return unmarshalStruct(expr, v)
+
+	case reflect.Pointer:
+		target := reflect.New(v.Type().Elem())
+		if err := unmarshalValue(expr, target.Elem()); err != nil {
+			return err
+		}
+		v.Set(target)
+
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch(expr, v.Type())
+		}
+		natural := naturalValue(expr)
+		if natural == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(natural))
+		}
+
+	default:
+		return fmt.Errorf("convert: unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func unmarshalMap(expr sexpr.SExpr, v reflect.Value) error {
+	m, ok := expr.(sexpr.Map)
+	if !ok {
+		return mismatch(expr, v.Type())
+	}
+
+	result := reflect.MakeMapWithSize(v.Type(), m.Len())
+	for _, entry := range m.Entries() {
+		key := reflect.New(v.Type().Key()).Elem()
+		keyExpr := entry.Key
+		if name, ok := keyName(keyExpr); ok && key.Kind() == reflect.String {
+			keyExpr = sexpr.String{Value: name}
+		}
+		if err := unmarshalValue(keyExpr, key); err != nil {
+			return err
+		}
+
+		value := reflect.New(v.Type().Elem()).Elem()
+		if err := unmarshalValue(entry.Value, value); err != nil {
+			return err
+		}
+		result.SetMapIndex(key, value)
+	}
+	v.Set(result)
+	return nil
+}
+
+func unmarshalStruct(expr sexpr.SExpr, v reflect.Value) error {
+	m, ok := expr.(sexpr.Map)
+	if !ok {
+		return mismatch(expr, v.Type())
+	}
+
+	for _, field := range structFields(v.Type()) {
+		value, ok := m.Get(sexpr.Symbol{Name: field.name})
+		if !ok {
+			value, ok = m.Get(sexpr.Keyword{Name: field.name})
+		}
+		if !ok {
+			value, ok = m.Get(sexpr.String{Value: field.name})
+		}
+		if !ok {
+			continue
+		}
+		fv, err := settableField(v, field.index)
+		if err == nil {
+			err = unmarshalValue(value, fv)
+		}
+		if err != nil {
+			return fmt.Errorf("convert: field %s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+// settableField returns the field of struct v at index, allocating the
+// embedded structs it is reached through that are nil pointers
+func settableField(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func unmarshalBig(expr sexpr.SExpr, n *big.Int) error {
+	switch e := expr.(type) {
+	case sexpr.Number:
+		n.SetInt64(e.Value)
+	case sexpr.BigInt:
+		n.Set(e.Value)
+	default:
+		return mismatch(expr, bigIntType)
+	}
+	return nil
+}
+
+// naturalValue converts expr to the Go value stored in an empty interface
+func naturalValue(expr sexpr.SExpr) any {
+	switch e := expr.(type) {
+	case sexpr.Nil:
+		return nil
+	case sexpr.Bool:
+		return e.Value
+	case sexpr.Number:
+		return e.Value
+	case sexpr.BigInt:
+		return new(big.Int).Set(e.Value)
+	case sexpr.Float:
+		return e.Value
+	case sexpr.String:
+		return e.Value
+	case sexpr.List, sexpr.Pair, sexpr.Vector:
+		elems, ok := seqElements(e)
+		if !ok {
+			return expr
+		}
+		result := make([]any, len(elems))
+		for i, elem := range elems {
+			result[i] = naturalValue(elem)
+		}
+		return result
+	case sexpr.Map:
+		result := make(map[string]any, e.Len())
+		for _, entry := range e.Entries() {
+			key, ok := keyName(entry.Key)
+			if !ok {
+				return expr
+			}
+			result[key] = naturalValue(entry.Value)
+		}
+		return result
+	default:
+		return expr
+	}
+}
+
+// seqElements returns the elements of a proper list or vector
+func seqElements(expr sexpr.SExpr) ([]sexpr.SExpr, bool) {
+	if v, ok := expr.(sexpr.Vector); ok {
+		return v.Elements(), true
+	}
+	return sexpr.Elements(expr)
+}
+
+// keyName returns the name of a symbol, keyword or string map key
+func keyName(expr sexpr.SExpr) (string, bool) {
+	switch k := expr.(type) {
+	case sexpr.Symbol:
+		return k.Name, true
+	case sexpr.Keyword:
+		return k.Name, true
+	case sexpr.String:
+		return k.Value, true
+	default:
+		return "", false
+	}
+}
+
+func toUint(expr sexpr.SExpr) (uint64, bool) {
+	switch e := expr.(type) {
+	case sexpr.Number:
+		return uint64(e.Value), e.Value >= 0
+	case sexpr.BigInt:
+		return e.Value.Uint64(), e.Value.IsUint64()
+	default:
+		return 0, false
+	}
+}
+
+func mismatch(expr sexpr.SExpr, t reflect.Type) error {
+	return fmt.Errorf("convert: cannot unmarshal %v into %s", expr, t)
+}
@@ -0,0 +1,189 @@
+package convert
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	original := server{
+		Name:     "web",
+		Port:     8080,
+		HTTPOnly: true,
+		Tags:     []string{"prod", "eu"},
+		Limits:   map[string]int{"conns": 100},
+		Owner:    &owner{UserID: 7},
+	}
+
+	expr, err := Marshal(original)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var decoded server
+	if err := Unmarshal(expr, &decoded); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("got %+v, want %+v", decoded, original)
+	}
+}
+
+type Stamp struct {
+	Created string
+}
+
+type entry struct {
+	base
+	*Stamp
+	Name string
+}
+
+func TestUnmarshalEmbedded(t *testing.T) {
+	original := entry{base: base{ID: 1, Name: "hidden"}, Stamp: &Stamp{Created: "now"}, Name: "outer"}
+	expr, err := Marshal(original)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var decoded entry
+	if err := Unmarshal(expr, &decoded); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	// The embedded Name is hidden by the outer one
+	original.base.Name = ""
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("got %+v, want %+v", decoded, original)
+	}
+
+	// A nil pointer to an unexported struct cannot be allocated
+	m, _ := sexpr.NewMap(sexpr.Symbol{Name: "created"}, sexpr.String{Value: "now"})
+	if err := Unmarshal(m, &record{}); err == nil {
+		t.Error("expected error for unexported embedded pointer")
+	}
+}
+
+func TestUnmarshalStringKeysAndUnknownFields(t *testing.T) {
+	m, _ := sexpr.NewMap(
+		sexpr.String{Value: "name"}, sexpr.String{Value: "db"},
+		sexpr.Symbol{Name: "unknown"}, sexpr.Number{Value: 1},
+	)
+
+	var s server
+	if err := Unmarshal(m, &s); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if s.Name != "db" {
+		t.Errorf("got name %q, want db", s.Name)
+	}
+}
+
+func TestUnmarshalKeywordKeysAndVectors(t *testing.T) {
+	m, _ := sexpr.NewMap(
+		sexpr.Keyword{Name: "name"}, sexpr.String{Value: "cache"},
+		sexpr.Keyword{Name: "tags"}, sexpr.NewVector(
+			sexpr.String{Value: "a"}, sexpr.String{Value: "b"},
+		),
+		sexpr.Keyword{Name: "limits"}, mustMap(t, sexpr.Keyword{Name: "conns"}, sexpr.Number{Value: 5}),
+	)
+
+	var s server
+	if err := Unmarshal(m, &s); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	expected := server{Name: "cache", Tags: []string{"a", "b"}, Limits: map[string]int{"conns": 5}}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("got %+v, want %+v", s, expected)
+	}
+}
+
+func mustMap(t *testing.T, kv ...sexpr.SExpr) sexpr.Map {
+	t.Helper()
+	m, err := sexpr.NewMap(kv...)
+	if err != nil {
+		t.Fatalf("NewMap error: %v", err)
+	}
+	return m
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	m, _ := sexpr.NewMap(
+		sexpr.Symbol{Name: "n"}, sexpr.Number{Value: 1},
+		sexpr.Symbol{Name: "xs"}, sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.String{Value: "a"}, sexpr.Bool{Value: false}, sexpr.Nil{},
+		}},
+	)
+
+	var v any
+	if err := Unmarshal(m, &v); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	expected := map[string]any{
+		"n":  int64(1),
+		"xs": []any{"a", false, nil},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("got %#v, want %#v", v, expected)
+	}
+}
+
+func TestUnmarshalSpecialTargets(t *testing.T) {
+	var c celsius
+	if err := Unmarshal(sexpr.List{Elements: []sexpr.SExpr{
+		sexpr.Symbol{Name: "celsius"}, sexpr.Number{Value: 30},
+	}}, &c); err != nil || c != 30 {
+		t.Errorf("unmarshaler: got %v, %v", c, err)
+	}
+
+	var n big.Int
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	if err := Unmarshal(sexpr.BigInt{Value: huge}, &n); err != nil || n.Cmp(huge) != 0 {
+		t.Errorf("big.Int: got %v, %v", &n, err)
+	}
+
+	var f float64
+	if err := Unmarshal(sexpr.Number{Value: 3}, &f); err != nil || f != 3 {
+		t.Errorf("float64 from integer: got %v, %v", f, err)
+	}
+
+	var b []byte
+	if err := Unmarshal(sexpr.Bytes{Value: []byte{1, 2}}, &b); err != nil ||
+		!reflect.DeepEqual(b, []byte{1, 2}) {
+		t.Errorf("[]byte: got %v, %v", b, err)
+	}
+
+	var expr sexpr.SExpr
+	if err := Unmarshal(sexpr.Symbol{Name: "x"}, &expr); err != nil ||
+		!expr.Equal(sexpr.Symbol{Name: "x"}) {
+		t.Errorf("SExpr: got %v, %v", expr, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   sexpr.SExpr
+		target any
+	}{
+		{"non-pointer", sexpr.Number{Value: 1}, 0},
+		{"type mismatch", sexpr.String{Value: "1"}, new(int)},
+		{"overflow", sexpr.Number{Value: 300}, new(int8)},
+		{"negative unsigned", sexpr.Number{Value: -1}, new(uint)},
+		{"array length", sexpr.List{Elements: []sexpr.SExpr{sexpr.Number{Value: 1}}}, new([2]int)},
+		{"struct from list", sexpr.List{}, new(server)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal(tt.expr, tt.target); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
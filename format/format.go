@@ -0,0 +1,353 @@
+// Package format formats zylisp source code in a canonical layout.
+//
+// Forms that fit within the line width are joined onto one line; longer
+// ones are broken with their arguments aligned under the first, or, for
+// the body of definition and binding forms, indented by two columns.
+// Comments and single blank lines between forms are kept, as is the
+// source text of each literal.
+package format
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zylisp/lang/parser"
+)
+
+// Width is the line length the formatter keeps within where it can
+const Width = 80
+
+// bodyForms maps forms with a body to the number of arguments kept on
+// the line of the operator when the form is broken; the rest are
+// indented by two columns
+var bodyForms = map[string]int{
+	"begin":              0,
+	"case":               1,
+	"cond":               0,
+	"define":             1,
+	"define-record-type": 1,
+	"define-syntax":      1,
+	"defmacro":           1,
+	"delay":              0,
+	"future":             0,
+	"generator":          0,
+	"go":                 0,
+	"lambda":             1,
+	"let":                1,
+	"let*":               1,
+	"let-values":         1,
+	"letrec":             1,
+	"loop":               1,
+	"match":              1,
+	"module":             1,
+	"receive":            2,
+	"syntax-rules":       1,
+	"time":               0,
+	"try":                0,
+	"unless":             1,
+	"when":               1,
+}
+
+// Source formats src, returning an error if it is not well-formed
+func Source(src []byte) ([]byte, error) {
+	if _, err := parser.ReadAll(string(src)); err != nil {
+		return nil, err
+	}
+	tokens, err := parser.TokenizeWithTrivia(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	b := builder{tokens: tokens}
+	forms, err := b.seq(parser.EOF)
+	if err != nil {
+		return nil, err
+	}
+
+	p := printer{width: Width}
+	for i, n := range forms {
+		switch {
+		case i == 0:
+		case n.kind == commentNode && n.trailing:
+			p.write(" ")
+		case n.blankBefore:
+			p.write("\n\n")
+		default:
+			p.write("\n")
+		}
+		p.print(n)
+	}
+	if p.out.Len() == 0 {
+		return nil, nil
+	}
+	p.write("\n")
+	return []byte(p.out.String()), nil
+}
+
+type nodeKind int
+
+const (
+	atomNode nodeKind = iota
+	listNode
+	prefixNode
+	commentNode
+)
+
+// node is a form or comment in the source
+type node struct {
+	kind     nodeKind
+	tokType  parser.TokenType // the type of an atom's token
+	text     string           // an atom's or comment's source, or a list's or prefix's opening
+	close    string           // the closing bracket of a list
+	sep      string           // what separates a prefix from its form
+	children []*node          // the elements of a list, or a prefix's form
+
+	blankBefore bool // a blank line precedes it in the source
+	trailing    bool // a comment on the same line as the form before it
+}
+
+// builder makes nodes from a token stream that includes trivia
+type builder struct {
+	tokens  []parser.Token
+	pos     int
+	pending []*node // comments found inside a prefix, to go before it
+}
+
+var closers = map[parser.TokenType]parser.TokenType{
+	parser.LPAREN:   parser.RPAREN,
+	parser.LBRACKET: parser.RBRACKET,
+	parser.LBRACE:   parser.RBRACE,
+	parser.SETOPEN:  parser.RBRACE,
+}
+
+// seq reads nodes up to a token of type end, which it consumes
+func (b *builder) seq(end parser.TokenType) ([]*node, error) {
+	var nodes []*node
+	newlines := 0
+	for {
+		tok := b.tokens[b.pos]
+		switch {
+		case tok.Type == parser.WHITESPACE:
+			newlines += strings.Count(tok.Value, "\n")
+			b.pos++
+			continue
+		case tok.Type == end:
+			b.pos++
+			return nodes, nil
+		case tok.Type == parser.EOF:
+			return nil, fmt.Errorf("unexpected end of input")
+		}
+
+		var n *node
+		if tok.Type == parser.COMMENT {
+			n = &node{kind: commentNode, text: strings.TrimRight(tok.Value, " \t\r")}
+			n.trailing = newlines == 0 && len(nodes) > 0
+			b.pos++
+		} else {
+			var err error
+			if n, err = b.form(); err != nil {
+				return nil, err
+			}
+		}
+		n.blankBefore = newlines > 1 && len(nodes) > 0
+		nodes = append(nodes, b.pending...)
+		nodes = append(nodes, n)
+		b.pending = nil
+		newlines = 0
+	}
+}
+
+// form reads the form starting at the current token
+func (b *builder) form() (*node, error) {
+	tok := b.tokens[b.pos]
+	b.pos++
+
+	switch tok.Type {
+	case parser.LPAREN, parser.LBRACKET, parser.LBRACE, parser.SETOPEN:
+		children, err := b.seq(closers[tok.Type])
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: listNode, text: tok.Raw, close: b.tokens[b.pos-1].Raw, children: children}, nil
+	case parser.QUOTE, parser.QUASIQUOTE, parser.UNQUOTE, parser.UNQUOTESPLICING,
+		parser.DISCARD, parser.TAG:
+		n := &node{kind: prefixNode, text: tok.Raw}
+		if tok.Type == parser.TAG && b.tokens[b.pos].Type == parser.WHITESPACE {
+			n.sep = " "
+		}
+		for b.tokens[b.pos].IsTrivia() {
+			if c := b.tokens[b.pos]; c.Type == parser.COMMENT {
+				b.pending = append(b.pending, &node{kind: commentNode, text: strings.TrimRight(c.Value, " \t\r")})
+			}
+			b.pos++
+		}
+		child, err := b.form()
+		if err != nil {
+			return nil, err
+		}
+		n.children = []*node{child}
+		return n, nil
+	case parser.RPAREN, parser.RBRACKET, parser.RBRACE, parser.EOF:
+		return nil, fmt.Errorf("unexpected %q at line %d, col %d", tok.Raw, tok.Line, tok.Col)
+	}
+	return &node{kind: atomNode, tokType: tok.Type, text: tok.Raw}, nil
+}
+
+// flat returns n on one line, or false if it must span several because
+// it holds a comment or a literal with a newline
+func (n *node) flat() (string, bool) {
+	switch n.kind {
+	case commentNode:
+		return "", false
+	case prefixNode:
+		child, ok := n.children[0].flat()
+		return n.text + n.sep + child, ok
+	case listNode:
+		parts := make([]string, len(n.children))
+		for i, c := range n.children {
+			s, ok := c.flat()
+			if !ok {
+				return "", false
+			}
+			parts[i] = s
+		}
+		return n.text + strings.Join(parts, " ") + n.close, true
+	}
+	return n.text, !strings.Contains(n.text, "\n")
+}
+
+// printer writes nodes, tracking the column it is at
+type printer struct {
+	out   strings.Builder
+	col   int
+	width int
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.col = utf8.RuneCountInString(s[i+1:])
+	} else {
+		p.col += utf8.RuneCountInString(s)
+	}
+}
+
+// newline starts a line indented to col, after a blank line if blank
+func (p *printer) newline(col int, blank bool) {
+	if blank {
+		p.write("\n")
+	}
+	p.write("\n" + strings.Repeat(" ", col))
+}
+
+func (p *printer) print(n *node) {
+	if s, ok := n.flat(); ok && p.col+utf8.RuneCountInString(s) <= p.width {
+		p.write(s)
+		return
+	}
+
+	switch n.kind {
+	case listNode:
+		p.printList(n)
+	case prefixNode:
+		p.write(n.text + n.sep)
+		p.print(n.children[0])
+	default:
+		p.write(n.text)
+	}
+}
+
+// printList writes a list across several lines. On the first line go
+// the opening bracket and, for a call, the operator and its first
+// argument, or, for a body form, the arguments before the body; the
+// rest are aligned or indented below them.
+func (p *printer) printList(n *node) {
+	col := p.col
+	p.write(n.text)
+
+	var head *node
+	for _, c := range n.children {
+		if c.kind != commentNode {
+			head = c
+			break
+		}
+	}
+
+	first, indent := 1, col+utf8.RuneCountInString(n.text)
+	switch {
+	case n.text == "{":
+		p.printPairs(n.children, indent)
+		p.write(n.close)
+		return
+	case n.text == "(" && head != nil && head.kind == atomNode && head.tokType == parser.SYMBOL:
+		argCol := indent + utf8.RuneCountInString(head.text) + 1
+		if k, ok := bodyForms[head.text]; ok {
+			if head.text == "let" && len(n.children) > 1 && n.children[1].tokType == parser.SYMBOL {
+				k++ // a named let
+			}
+			first, indent = 1+k, col+2
+		} else if argCol > p.width/2 {
+			indent = col + 2
+		} else {
+			first, indent = 2, argCol
+		}
+	}
+
+	placed, broken := 0, false
+	for _, c := range n.children {
+		if c.kind == commentNode {
+			if c.trailing {
+				p.write(" ")
+			} else if placed > 0 || broken {
+				p.newline(indent, c.blankBefore)
+			}
+			p.write(c.text)
+			broken = true
+			continue
+		}
+		switch {
+		case placed == 0 && !broken:
+		case placed < first && !broken:
+			p.write(" ")
+		default:
+			p.newline(indent, c.blankBefore)
+		}
+		p.print(c)
+		placed++
+	}
+	if broken && n.children[len(n.children)-1].kind == commentNode {
+		p.newline(indent, false)
+	}
+	p.write(n.close)
+}
+
+// printPairs writes the entries of a map one to a line at column col
+func (p *printer) printPairs(children []*node, col int) {
+	placed, broken := 0, false
+	for _, c := range children {
+		if c.kind == commentNode {
+			if c.trailing {
+				p.write(" ")
+			} else if placed > 0 || broken {
+				p.newline(col, c.blankBefore)
+			}
+			p.write(c.text)
+			broken = true
+			continue
+		}
+		switch {
+		case placed == 0 && !broken:
+		case placed%2 == 1 && !broken:
+			p.write(" ")
+		default:
+			p.newline(col, c.blankBefore)
+		}
+		p.print(c)
+		placed++
+		broken = false
+	}
+	if broken {
+		p.newline(col, false)
+	}
+}
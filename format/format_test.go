@@ -0,0 +1,103 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"only space", " \n\n ", ""},
+		{"joins short forms", "(+ 1\n   2)", "(+ 1 2)\n"},
+		{"collapses space", "(  foo   bar  )  ", "(foo bar)\n"},
+		{"one form per line", "(a) (b)", "(a)\n(b)\n"},
+		{"keeps one blank line", "(a)\n\n\n\n(b)\n", "(a)\n\n(b)\n"},
+		{"keeps literals", `(f 1.50 "a\tb" :k #\x)`, "(f 1.50 \"a\\tb\" :k #\\x)\n"},
+		{"quotes", "' (a  b) `(c ,d ,@ e)", "'(a b)\n`(c ,d ,@e)\n"},
+		{"vectors maps and sets", "[1  2] {:a  1} #{ x }", "[1 2]\n{:a 1}\n#{x}\n"},
+		{"dotted pair", "(a .  b)", "(a . b)\n"},
+		{"byte vector", "#u8( 1 2 )", "#u8(1 2)\n"},
+		{"discard", "(a #_ b c)", "(a #_b c)\n"},
+		{
+			"body form",
+			"(define (f x) (let ((y (* x x)) (z (+ x x))) (list y z y z y z y z y z y z y z y z)))",
+			"(define (f x)\n  (let ((y (* x x)) (z (+ x x))) (list y z y z y z y z y z y z y z y z)))\n",
+		},
+		{
+			"call aligns arguments",
+			"(some-function (first-argument-expression a b c) (second-argument-expression d e f))",
+			"(some-function (first-argument-expression a b c)\n               (second-argument-expression d e f))\n",
+		},
+		{
+			"named let",
+			"(let loop ((i 0) (acc (quote ()))) (if (< i 10) (recur (+ i 1) (cons i acc)) acc))",
+			"(let loop ((i 0) (acc (quote ())))\n  (if (< i 10) (recur (+ i 1) (cons i acc)) acc))\n",
+		},
+		{
+			"cond",
+			"(cond ((< x 0) (quote negative-number)) ((> x 0) (quote positive-number)) (else 0))",
+			"(cond\n  ((< x 0) (quote negative-number))\n  ((> x 0) (quote positive-number))\n  (else 0))\n",
+		},
+		{
+			"data list",
+			"(1000000000000 2000000000000 3000000000000 4000000000000 5000000000000 6000000000000)",
+			"(1000000000000\n 2000000000000\n 3000000000000\n 4000000000000\n 5000000000000\n 6000000000000)\n",
+		},
+		{
+			"map entries",
+			"{:name \"a fairly long project name\" :version \"1.0.0\" :description \"something else\"}",
+			"{:name \"a fairly long project name\"\n :version \"1.0.0\"\n :description \"something else\"}\n",
+		},
+		{"top-level comments", ";; header\n\n(a) ; trailing\n;; next\n(b)", ";; header\n\n(a) ; trailing\n;; next\n(b)\n"},
+		{"comment breaks a form", "(define (f x) ; doc\n x)", "(define (f x) ; doc\n  x)\n"},
+		{"own-line comment", "(when ok\n  ;; say it\n  (display 1))", "(when ok\n  ;; say it\n  (display 1))\n"},
+		{"comment before close", "(list 1\n  2 ; two\n  )", "(list 1\n      2 ; two\n      )\n"},
+		{"comment in map", "{:a 1 ; one\n :b 2}", "{:a 1 ; one\n :b 2}\n"},
+		{"comment after quote", "'\n; c\nx", "; c\n'x\n"},
+		{"blank line in body", "(begin\n  (a)\n\n  (b))", "(begin (a) (b))\n"},
+		{"blank line in broken body", "(begin\n  (a) ; x\n\n  (b))", "(begin\n  (a) ; x\n\n  (b))\n"},
+		{"trailing whitespace in comment", "; c  \n", "; c\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Source([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.expected)
+			}
+			again, err := Source(got)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("formatting is not idempotent: %q", again)
+			}
+		})
+	}
+}
+
+func TestSourceWidth(t *testing.T) {
+	src := "(define (f) (list " + strings.Repeat("item ", 30) + "))"
+	got, err := Source([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(got), "\n") {
+		if len(line) > Width {
+			t.Errorf("line longer than %d columns: %q", Width, line)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	for _, src := range []string{"(a", "a)", `"open`, "(a ]", "#!x"} {
+		if _, err := Source([]byte(src)); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
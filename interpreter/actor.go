@@ -0,0 +1,205 @@
+package interpreter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Actor is a goroutine with a mailbox. Messages sent to an actor queue up
+// until it takes them with receive, which may skip messages that match
+// none of its patterns. Actors are compared by identity.
+type Actor struct {
+	id      uint64
+	mu      sync.Mutex
+	recv    sync.Mutex    // serializes receive on the mailbox
+	mailbox []sexpr.SExpr // messages not yet received
+	signal  chan struct{} // notified when a message arrives
+	done    chan struct{} // closed when the actor exits
+	err     error         // why the actor exited, if it failed
+}
+
+func newActor(rt *Runtime) *Actor {
+	return &Actor{
+		id:     rt.actors.Add(1),
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+func (a *Actor) String() string {
+	return fmt.Sprintf("#<actor %d>", a.id)
+}
+
+// Equal reports whether other is the same actor
+func (a *Actor) Equal(other sexpr.SExpr) bool {
+	o, ok := other.(*Actor)
+	return ok && a == o
+}
+
+// Alive reports whether the actor is still running
+func (a *Actor) Alive() bool {
+	select {
+	case <-a.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Err returns the error that stopped the actor, or nil if it is running
+// or returned normally
+func (a *Actor) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Send queues msg in the actor's mailbox. Messages to an actor that has
+// exited are dropped.
+func (a *Actor) Send(msg sexpr.SExpr) {
+	if !a.Alive() {
+		return
+	}
+	a.mu.Lock()
+	a.mailbox = append(a.mailbox, msg)
+	a.mu.Unlock()
+	select {
+	case a.signal <- struct{}{}:
+	default:
+	}
+}
+
+// pending returns the messages currently in the mailbox
+func (a *Actor) pending() []sexpr.SExpr {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.mailbox[:len(a.mailbox):len(a.mailbox)]
+}
+
+// take removes the message at index i of the mailbox
+func (a *Actor) take(i int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mailbox = append(a.mailbox[:i:i], a.mailbox[i+1:]...)
+}
+
+// exit marks the actor as stopped by err
+func (a *Actor) exit(err error) {
+	a.mu.Lock()
+	a.err = err
+	a.mailbox = nil
+	a.mu.Unlock()
+	close(a.done)
+}
+
+// currentActor returns the actor evaluation in env belongs to: the one
+// it was spawned in, or the runtime's main actor
+func currentActor(env *Env) *Actor {
+	if env.actor != nil {
+		return env.actor
+	}
+	return env.runtime.mainActor()
+}
+
+// spawnActor starts fn applied to args in a new actor. When restarts is
+// positive, a failure reapplies fn up to that many times, keeping the
+// actor's identity and mailbox.
+func spawnActor(fn sexpr.SExpr, args []sexpr.SExpr, restarts int, env *Env) *Actor {
+	actor := newActor(env.runtime)
+	actorEnv := *env.background()
+	actorEnv.actor = actor
+	actorEnv.depth = 0
+
+	go func() {
+		for {
+			_, err := apply(fn, args, &actorEnv)
+			if err == nil || restarts <= 0 || !catchable(err) {
+				actor.exit(err)
+				return
+			}
+			restarts--
+		}
+	}()
+	return actor
+}
+
+// evalReceive handles (receive clause... [(after ms body...)]). Each
+// clause is a match clause, (pattern [:when guard] body...). The oldest
+// message in the current actor's mailbox that matches a clause is
+// removed and the first matching clause's body evaluated with the
+// pattern variables bound; messages matching no clause stay queued.
+// Without a match receive waits for more messages, for at most ms
+// milliseconds if there is an after clause, whose body then runs.
+func evalReceive(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	clauses := list.Elements[1:]
+	var timeout <-chan time.Time
+	var after []sexpr.SExpr
+	if n := len(clauses); n > 0 {
+		if parts, ok := sexpr.Elements(clauses[n-1]); ok && len(parts) >= 2 {
+			if head, ok := parts[0].(sexpr.Symbol); ok && head.Name == "after" {
+				ms, err := Eval(parts[1], env)
+				if err != nil {
+					return nil, err
+				}
+				n, ok := ms.(sexpr.Number)
+				if !ok || n.Value < 0 {
+					return nil, typeError("receive", "non-negative timeout in milliseconds", ms)
+				}
+				timer := time.NewTimer(time.Duration(n.Value) * time.Millisecond)
+				defer timer.Stop()
+				timeout = timer.C
+				after = parts[2:]
+				clauses = clauses[:len(clauses)-1]
+			}
+		}
+	}
+
+	actor := currentActor(env)
+	body, clauseEnv, err := actor.receive(clauses, timeout, env)
+	if err != nil {
+		return nil, err
+	}
+	if clauseEnv == nil {
+		return evalBody(after, env)
+	}
+	return evalBody(body, clauseEnv)
+}
+
+// receive removes the oldest message matching one of clauses from the
+// mailbox, waiting for one to arrive, and returns the body and
+// environment of the clause it matched. It returns a nil environment if
+// timeout fires first. The body is left to the caller so that it can
+// receive again.
+func (a *Actor) receive(clauses []sexpr.SExpr, timeout <-chan time.Time, env *Env) ([]sexpr.SExpr, *Env, error) {
+	a.recv.Lock()
+	defer a.recv.Unlock()
+
+	seen := 0
+	for {
+		messages := a.pending()
+		for i := seen; i < len(messages); i++ {
+			for _, clause := range clauses {
+				body, clauseEnv, ok, err := matchClause("receive", clause, messages[i], env)
+				if err != nil {
+					return nil, nil, err
+				}
+				if ok {
+					a.take(i)
+					return body, clauseEnv, nil
+				}
+			}
+		}
+		seen = len(messages)
+
+		select {
+		case <-a.signal:
+		case <-timeout:
+			return nil, nil, nil
+		case <-env.Context().Done():
+			return nil, nil, env.interrupted()
+		}
+	}
+}
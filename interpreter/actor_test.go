@@ -0,0 +1,130 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestActors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(actor? (self))", "true"},
+		{"(actor? 1)", "false"},
+		{"(equal? (self) (self))", "true"},
+		{"(begin (send (self) 42) (receive (x x)))", "42"},
+		{"(receive (x x) (after 10 :timeout))", ":timeout"},
+		{"(receive (after 0))", "nil"},
+		// Selective receive skips messages matching no clause
+		{`(begin
+		   (send (self) '(:b 2))
+		   (send (self) '(:a 1))
+		   (list (receive ((:a n) n)) (receive ((:b n) n))))`, "(1 2)"},
+		{`(begin
+		   (send (self) 1)
+		   (send (self) 5)
+		   (receive (n :when (> n 3) n)))`, "5"},
+		{`(let ((echo (spawn (lambda (parent)
+		                      (receive ((from msg) (send from (list :echo msg)))))
+		                    (self))))
+		   (send echo (list (self) "hi"))
+		   (receive ((:echo msg) msg)))`, `"hi"`},
+		{`(begin
+		   (define (counter n)
+		     (receive
+		       ((:inc) (counter (+ n 1)))
+		       ((:get from) (send from n) (counter n))))
+		   (define c (spawn counter 0))
+		   (send c '(:inc))
+		   (send c '(:inc))
+		   (send c (list :get (self)))
+		   (receive (n n)))`, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestActorSupervision(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		`(define (server)
+		   (receive
+		     ((:crash) (raise :boom))
+		     ((:ping from) (send from :pong) (server))))`,
+		"(define s (supervise 1 server))",
+		"(define u (spawn server))",
+	)
+
+	// The supervised actor survives one crash and keeps its mailbox
+	result := evalForms(t, env,
+		"(send s '(:crash))",
+		"(send s (list :ping (self)))",
+		"(receive (:pong :pong) (after 1000 :timeout))",
+	)
+	if result.String() != ":pong" {
+		t.Errorf("got %v, want :pong", result)
+	}
+
+	result = evalForms(t, env,
+		"(send s '(:crash))",
+		"(send s (list :ping (self)))",
+		"(receive (:pong :pong) (after 50 :timeout))",
+	)
+	if result.String() != ":timeout" {
+		t.Errorf("got %v after restarts were exhausted, want :timeout", result)
+	}
+
+	evalForms(t, env, "(send u '(:crash))")
+	u := evalForms(t, env, "u").(*Actor)
+	select {
+	case <-u.done:
+	case <-time.After(time.Second):
+		t.Fatal("unsupervised actor did not exit")
+	}
+	var raised *RaiseError
+	if !errors.As(u.Err(), &raised) {
+		t.Errorf("got exit error %v, want raised :boom", u.Err())
+	}
+	if result := evalForms(t, env, "(actor-alive? u)"); result.String() != "false" {
+		t.Errorf("actor-alive? = %v, want false", result)
+	}
+}
+
+func TestActorErrors(t *testing.T) {
+	tests := []string{
+		"(spawn)",
+		"(supervise 1)",
+		"(supervise -1 car)",
+		"(send 1 2)",
+		"(self 1)",
+		"(actor-alive? 1)",
+		"(receive (x x) (after -1 :late))",
+		"(begin (send (self) 1) (receive ()))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestReceiveInterrupted(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	expr := evalForms(t, env, "'(receive (x x))")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := EvalContext(ctx, expr, env); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,431 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Analyze checks the special forms in expr for mistakes that would make
+// them fail when evaluated, such as an if without an alternative, a
+// lambda parameter that is neither a symbol nor a pattern, or a recur
+// outside any loop. Unlike evaluation it does not stop at the first
+// problem, and it finds problems in code that would only run later, such
+// as function bodies.
+//
+// Each problem is the *EvalError or *ArityError evaluation would fail
+// with, its Location set to the malformed form and the source position
+// of that form or the nearest enclosing one, when known. Analyze
+// evaluates nothing, so it cannot tell macro uses from calls: their
+// arguments are checked as expressions. Quoted data and syntax-rules
+// templates are not checked.
+func Analyze(expr sexpr.SExpr) []error {
+	a := &analyzer{rt: newRuntime()}
+	a.expr(expr, nil)
+	return a.problems
+}
+
+// analyzer collects the problems found in an expression
+type analyzer struct {
+	rt       *Runtime // names the parameters of destructured lambdas
+	problems []error
+	located  sexpr.SExpr // innermost list with a source position
+}
+
+// loopContext describes the innermost loop around an expression, for
+// checking recur
+type loopContext struct {
+	bindings int
+	crossed  bool // a function boundary lies between the loop and the expression
+}
+
+// report records err as a problem with form
+func (a *analyzer) report(form sexpr.SExpr, err error) {
+	var located interface{ location() *Location }
+	if errors.As(err, &located) && located.location().Expr == nil {
+		loc := located.location()
+		loc.Expr = form
+		if pos, ok := sexpr.PositionOf(form); ok {
+			loc.Pos = &pos
+		} else if pos, ok := sexpr.PositionOf(a.located); ok {
+			loc.Pos = &pos
+		}
+	}
+	a.problems = append(a.problems, err)
+}
+
+// crossing returns the loop context inside a function or other form
+// evaluated apart from its surroundings
+func crossing(loop *loopContext) *loopContext {
+	if loop == nil {
+		return nil
+	}
+	return &loopContext{bindings: loop.bindings, crossed: true}
+}
+
+func (a *analyzer) expr(x sexpr.SExpr, loop *loopContext) {
+	switch e := x.(type) {
+	case sexpr.List:
+		if _, ok := sexpr.PositionOf(e); ok {
+			outer := a.located
+			a.located = e
+			defer func() { a.located = outer }()
+		}
+		a.list(e, loop)
+	case sexpr.Vector:
+		a.each(e.Elements(), loop)
+	}
+}
+
+// each checks exprs
+func (a *analyzer) each(exprs []sexpr.SExpr, loop *loopContext) {
+	for _, x := range exprs {
+		a.expr(x, loop)
+	}
+}
+
+func (a *analyzer) list(list sexpr.List, loop *loopContext) {
+	elems := list.Elements
+	if len(elems) == 0 {
+		return
+	}
+	head, ok := elems[0].(sexpr.Symbol)
+	if !ok || !specialForms[head.Name] {
+		a.each(elems, loop)
+		return
+	}
+	args := elems[1:]
+
+	switch head.Name {
+	case "quote":
+		if len(args) != 1 {
+			a.report(list, arityError("quote", 1, 1, len(args)))
+		}
+	case "quasiquote":
+		if len(args) != 1 {
+			a.report(list, arityError("quasiquote", 1, 1, len(args)))
+		}
+		for _, x := range args {
+			a.unquoted(x, loop)
+		}
+	case "define":
+		a.define(list, loop)
+	case "set!":
+		if len(args) != 2 {
+			a.report(list, arityError("set!", 2, 2, len(args)))
+		} else if _, ok := args[0].(sexpr.Symbol); !ok {
+			a.report(list, evalError("set!", "first argument must be a symbol"))
+		}
+		if len(args) > 1 {
+			a.each(args[1:], loop)
+		}
+	case "lambda":
+		form, _ := splitDoc(list)
+		args = form.Elements[1:]
+		if len(args) != 2 {
+			a.report(list, arityError("lambda", 2, 2, len(args)))
+		}
+		if len(args) > 0 {
+			a.params(list, args[0])
+			a.each(args[1:], crossing(loop))
+		}
+	case "if":
+		if len(args) != 3 {
+			a.report(list, arityError("if", 3, 3, len(args)))
+		}
+		a.each(args, loop)
+	case "when", "unless":
+		if len(args) == 0 {
+			a.report(list, evalError("", "%s requires a test", head.Name))
+		}
+		a.each(args, loop)
+	case "cond":
+		a.cond(list, loop)
+	case "case":
+		a.caseForm(list, loop)
+	case "try":
+		a.try(list, loop)
+	case "let", "let*", "letrec", "loop":
+		a.let(head.Name, list, loop)
+	case "let-values":
+		a.letValues(list, loop)
+	case "recur":
+		switch {
+		case loop == nil:
+			a.report(list, evalError("recur", "not inside a loop"))
+		case loop.crossed:
+			a.report(list, evalError("recur", "cannot cross a function boundary"))
+		case len(args) != loop.bindings:
+			a.report(list, evalError("recur", "loop has %d bindings, got %d values", loop.bindings, len(args)))
+		}
+		a.each(args, loop)
+	case "delay", "go", "future":
+		if len(args) != 1 {
+			a.report(list, arityError(head.Name, 1, 1, len(args)))
+		}
+		a.each(args, crossing(loop))
+	case "time":
+		if len(args) != 1 {
+			a.report(list, arityError("time", 1, 1, len(args)))
+		}
+		a.each(args, crossing(loop))
+	case "assert":
+		if len(args) != 1 && len(args) != 2 {
+			a.report(list, arityError("assert", 1, 2, len(args)))
+		}
+		a.each(args, crossing(loop))
+	case "generator":
+		a.each(args, crossing(loop))
+	case "defmacro":
+		if len(args) < 3 {
+			a.report(list, evalError("", "defmacro requires a name, parameters and a body"))
+			return
+		}
+		if _, ok := args[0].(sexpr.Symbol); !ok {
+			a.report(list, evalError("defmacro", "name must be a symbol, got %v", args[0]))
+		}
+		if _, _, err := parseParams(args[1]); err != nil {
+			a.report(list, fmt.Errorf("defmacro: %w", err))
+		}
+		a.each(args[2:], nil)
+	case "match", "receive":
+		// Clauses are (pattern [:when guard] body...); only the guards
+		// and bodies are expressions
+		if head.Name == "match" {
+			if len(args) == 0 {
+				a.report(list, evalError("", "match requires an expression"))
+				return
+			}
+			a.expr(args[0], loop)
+			args = args[1:]
+		}
+		for _, clause := range args {
+			if parts, ok := sexpr.Elements(clause); ok && len(parts) > 0 {
+				a.each(parts[1:], loop)
+			}
+		}
+	case "module":
+		a.module(list)
+	case "begin", "and", "or", "string-interpolate":
+		a.each(args, loop)
+	}
+	// import, define-syntax, syntax-rules and define-record-type contain
+	// no expressions to check
+}
+
+// define checks (define name value) and (define (name params...) body...)
+func (a *analyzer) define(list sexpr.List, loop *loopContext) {
+	args := list.Elements[1:]
+	if len(args) > 0 {
+		switch args[0].(type) {
+		case sexpr.List, sexpr.Pair:
+			define, err := desugarDefine(list)
+			if err != nil {
+				a.report(list, err)
+				return
+			}
+			if _, ok := define.Elements[1].(sexpr.Symbol); !ok {
+				a.report(list, evalError("define", "first argument must be a symbol"))
+			}
+			lambda := define.Elements[2].(sexpr.List)
+			a.params(list, lambda.Elements[1])
+			a.expr(lambda.Elements[2], crossing(loop))
+			return
+		}
+	}
+
+	if len(args) != 2 {
+		a.report(list, arityError("define", 2, 2, len(args)))
+	} else if _, ok := args[0].(sexpr.Symbol); !ok {
+		a.report(list, evalError("define", "first argument must be a symbol"))
+	}
+	if len(args) > 1 {
+		a.each(args[1:], loop)
+	}
+}
+
+// params checks the parameter list of a lambda: a symbol, or a possibly
+// dotted list of symbols and patterns ending in a symbol
+func (a *analyzer) params(form sexpr.List, spec sexpr.SExpr) {
+	spec, _, err := destructureParams(spec, sexpr.Nil{}, a.rt)
+	if err == nil {
+		_, _, err = parseParams(spec)
+	}
+	if err != nil {
+		a.report(form, err)
+	}
+}
+
+// unquoted checks the unquoted expressions in a quasiquote template
+func (a *analyzer) unquoted(template sexpr.SExpr, loop *loopContext) {
+	parts, ok := listParts(template)
+	if !ok {
+		return
+	}
+	if len(parts.elems) == 2 && !parts.vector && parts.tail == nil &&
+		(isSymbolNamed(parts.elems[0], "unquote") || isSymbolNamed(parts.elems[0], "unquote-splicing")) {
+		a.expr(parts.elems[1], loop)
+		return
+	}
+	for _, elem := range parts.elems {
+		a.unquoted(elem, loop)
+	}
+}
+
+// cond checks the clauses of a cond
+func (a *analyzer) cond(list sexpr.List, loop *loopContext) {
+	clauses := list.Elements[1:]
+	for i, clause := range clauses {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) == 0 {
+			a.report(list, evalError("cond", "clause must be a non-empty list, got %v", clause))
+			continue
+		}
+		if isSymbolNamed(parts[0], "else") {
+			if i != len(clauses)-1 {
+				a.report(list, evalError("cond", "else must be the last clause"))
+			}
+			a.each(parts[1:], loop)
+			continue
+		}
+		if len(parts) > 1 && isSymbolNamed(parts[1], "=>") {
+			if len(parts) != 3 {
+				a.report(list, evalError("cond", "=> requires exactly 1 function, got %v", clause))
+			}
+			a.expr(parts[0], loop)
+			a.each(parts[2:], loop)
+			continue
+		}
+		a.each(parts, loop)
+	}
+}
+
+// caseForm checks (case key ((datum...) expr...)... (else expr...))
+func (a *analyzer) caseForm(list sexpr.List, loop *loopContext) {
+	if len(list.Elements) < 2 {
+		a.report(list, evalError("", "case requires a key"))
+		return
+	}
+	a.expr(list.Elements[1], loop)
+	clauses := list.Elements[2:]
+	for i, clause := range clauses {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) < 2 {
+			a.report(list, evalError("case", "clause must be (data expr...), got %v", clause))
+			continue
+		}
+		if isSymbolNamed(parts[0], "else") {
+			if i != len(clauses)-1 {
+				a.report(list, evalError("case", "else must be the last clause"))
+			}
+		} else if _, ok := sexpr.Elements(parts[0]); !ok {
+			a.report(list, evalError("case", "data must be a list, got %v", parts[0]))
+		}
+		if isSymbolNamed(parts[1], "=>") && len(parts) != 3 {
+			a.report(list, evalError("case", "=> requires exactly 1 function, got %v", clause))
+		}
+		a.each(parts[1:], loop)
+	}
+}
+
+// try checks (try body... [(catch name handler...)] [(finally cleanup...)])
+func (a *analyzer) try(list sexpr.List, loop *loopContext) {
+	body := list.Elements[1:]
+	hasCatch, hasFinally := false, false
+	for len(body) > 0 {
+		clause, ok := tryClause(body[len(body)-1])
+		if !ok {
+			break
+		}
+		switch clause[0].(sexpr.Symbol).Name {
+		case "finally":
+			if hasFinally || hasCatch {
+				a.report(list, evalError("try", "finally must be the last clause"))
+			}
+			hasFinally = true
+			a.each(clause[1:], loop)
+		case "catch":
+			if hasCatch {
+				a.report(list, evalError("try", "only one catch clause is allowed"))
+			}
+			hasCatch = true
+			if len(clause) < 2 {
+				a.report(list, evalError("try", "catch requires a name"))
+				break
+			}
+			if _, ok := clause[1].(sexpr.Symbol); !ok {
+				a.report(list, evalError("try", "catch name must be a symbol, got %v", clause[1]))
+			}
+			a.each(clause[2:], loop)
+		}
+		body = body[:len(body)-1]
+	}
+	a.each(body, loop)
+}
+
+// let checks a let, let*, letrec or loop
+func (a *analyzer) let(form string, list sexpr.List, loop *loopContext) {
+	bindings, err := parseBindings(form, list)
+	if err != nil {
+		a.report(list, err)
+		return
+	}
+	for _, b := range bindings {
+		a.expr(b.value, loop)
+	}
+	if form == "loop" {
+		loop = &loopContext{bindings: len(bindings)}
+	}
+	a.each(list.Elements[2:], loop)
+}
+
+// letValues checks (let-values ((formals expr)...) body...)
+func (a *analyzer) letValues(list sexpr.List, loop *loopContext) {
+	if len(list.Elements) < 3 {
+		a.report(list, evalError("", "let-values requires bindings and a body"))
+		return
+	}
+	specs, ok := sexpr.Elements(list.Elements[1])
+	if !ok {
+		a.report(list, evalError("let-values", "bindings must be a list, got %v", list.Elements[1]))
+		return
+	}
+	for _, spec := range specs {
+		pair, ok := sexpr.Elements(spec)
+		if !ok || len(pair) != 2 {
+			a.report(list, evalError("let-values", "binding must be (formals expr), got %v", spec))
+			continue
+		}
+		if _, _, err := parseParams(pair[0]); err != nil {
+			a.report(list, fmt.Errorf("let-values: %w", err))
+		}
+		a.expr(pair[1], loop)
+	}
+	a.each(list.Elements[2:], loop)
+}
+
+// module checks (module name [(export symbol...)] body...)
+func (a *analyzer) module(list sexpr.List) {
+	if len(list.Elements) < 2 {
+		a.report(list, evalError("", "module requires a name"))
+		return
+	}
+	name := list.Elements[1]
+	if _, ok := name.(sexpr.Symbol); !ok {
+		a.report(list, evalError("module", "name must be a symbol, got %v", name))
+	}
+	body := list.Elements[2:]
+	if len(body) > 0 {
+		if clause, ok := body[0].(sexpr.List); ok && len(clause.Elements) > 0 &&
+			isSymbolNamed(clause.Elements[0], "export") {
+			for _, e := range clause.Elements[1:] {
+				if _, ok := e.(sexpr.Symbol); !ok {
+					a.report(clause, evalError("", "module %v: export must be a symbol, got %v", name, e))
+				}
+			}
+			body = body[1:]
+		}
+	}
+	a.each(body, nil)
+}
@@ -0,0 +1,113 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"valid program", "(define (f x . rest) (if x (let ((y 1)) (+ y 2)) (cond ((= x 1) 2) (else 3))))", nil},
+		{"valid loop", "(loop ((i 0) (acc 1)) (if (= i 3) acc (recur (+ i 1) (* acc 2))))", nil},
+		{"valid patterns", "(lambda ((a b) c) (list a b c))", nil},
+		{"docstrings", `(lambda (x) "Identity." x) (define (f x) "Identity." x)`, nil},
+		{"quoted data", "'(if) (quote (lambda 1 2 3))", nil},
+		{"if arity", "(if 1 2)", []string{"if: requires 3 arguments, got 2"}},
+		{"define arity", "(define x)", []string{"define: requires 2 arguments, got 1"}},
+		{"define name", "(define 1 2)", []string{"define: first argument must be a symbol"}},
+		{"define function name", "(define ((f) x) x)", []string{"define: first argument must be a symbol"}},
+		{"define body", "(define (f x))", []string{"define: function definition requires a body"}},
+		{"set! name", "(set! 1 2)", []string{"set!: first argument must be a symbol"}},
+		{"lambda arity", "(lambda (x))", []string{"lambda: requires 2 arguments, got 1"}},
+		{"lambda parameter", "(lambda (x 1) x)", []string{"lambda: binding name must be a symbol or pattern, got 1"}},
+		{"lambda parameters", "(lambda 1 x)", []string{"lambda: parameters must be a list or symbol"}},
+		{"defmacro parameter", "(defmacro m (1) 1)", []string{"defmacro: lambda: parameter must be a symbol, got 1"}},
+		{"nested problems", "(define (f) (begin (if 1) (g (lambda))))", []string{
+			"if: requires 3 arguments, got 1",
+			"lambda: requires 2 arguments, got 0",
+		}},
+		{"cond clause", "(cond 1 (else 2) (3 4))", []string{
+			"cond: clause must be a non-empty list, got 1",
+			"cond: else must be the last clause",
+		}},
+		{"case key", "(case)", []string{"case requires a key"}},
+		{"when test", "(when)", []string{"when requires a test"}},
+		{"let bindings", "(let (x) x)", []string{"let: binding must be (name value), got x"}},
+		{"try catch", "(try 1 (catch 2 3))", []string{"try: catch name must be a symbol, got 2"}},
+		{"recur outside loop", "(recur 1)", []string{"recur: not inside a loop"}},
+		{"recur count", "(loop ((i 0)) (recur 1 2))", []string{"recur: loop has 1 bindings, got 2 values"}},
+		{"recur across function", "(loop ((i 0)) (lambda () (recur 1)))", []string{"recur: cannot cross a function boundary"}},
+		{"unquoted", "`(a ,(if 1))", []string{"if: requires 3 arguments, got 1"}},
+		{"vector literal", "[(if)]", []string{"if: requires 3 arguments, got 0"}},
+		{"match guard", "(match 1 (x (if x)))", []string{"if: requires 3 arguments, got 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forms, err := parser.ReadAll(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var problems []error
+			for _, form := range forms {
+				problems = append(problems, Analyze(form)...)
+			}
+
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("got %d problems %v, want %d", len(problems), problems, len(tt.expected))
+			}
+			for i, problem := range problems {
+				if problem.Error() != tt.expected[i] {
+					t.Errorf("problem %d: got %q, want %q", i, problem, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+// Analyze reports the same errors evaluation fails with
+func TestAnalyzeMatchesEval(t *testing.T) {
+	inputs := []string{"(if 1 2)", "(define 1 2)", "(lambda (x 1) x)", "(cond (else 1) (2 3))", "(recur 1)"}
+	for _, input := range inputs {
+		env := NewEnv(nil)
+		LoadPrimitives(env)
+		_, evalErr := evalString(env, input)
+
+		forms, err := parser.ReadAll(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		problems := Analyze(forms[0])
+		if evalErr == nil || len(problems) != 1 || problems[0].Error() != evalErr.Error() {
+			t.Errorf("%s: Analyze = %v, Eval error = %v", input, problems, evalErr)
+		}
+	}
+}
+
+func TestAnalyzePositions(t *testing.T) {
+	forms, err := parser.ReadSource("f.zy", "(define (f x)\n  (g x\n     (if x)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := Analyze(forms[0])
+	if len(problems) != 1 {
+		t.Fatalf("got problems %v, want 1", problems)
+	}
+	var evalErr *ArityError
+	if !errors.As(problems[0], &evalErr) {
+		t.Fatalf("got %T, want *ArityError", problems[0])
+	}
+	if evalErr.Expr.String() != "(if x)" {
+		t.Errorf("Expr = %v, want (if x)", evalErr.Expr)
+	}
+	if evalErr.Pos == nil || evalErr.Pos.String() != "f.zy:3:6" {
+		t.Errorf("Pos = %v, want f.zy:3:6", evalErr.Pos)
+	}
+}
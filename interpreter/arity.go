@@ -0,0 +1,141 @@
+package interpreter
+
+import (
+	"strings"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Params returns the parameter list of a function, primitive or macro.
+// A function's is a list of its parameters, dotted if it takes a rest
+// parameter; a pattern parameter appears as the name its argument is
+// bound to. A primitive's is the one its signature shows, in which
+// optional parameters are in brackets and a parameter followed by ...
+// may be repeated. It reports false for a primitive with no known
+// signature and for other values.
+func Params(fn sexpr.SExpr) (sexpr.SExpr, bool) {
+	switch f := fn.(type) {
+	case sexpr.Func:
+		var params sexpr.SExpr = sexpr.List{}
+		if f.Rest != nil {
+			params = *f.Rest
+		}
+		for i := len(f.Params) - 1; i >= 0; i-- {
+			params = sexpr.Cons(f.Params[i], params)
+		}
+		return params, true
+	case sexpr.Primitive:
+		return f.Params, f.Params != nil
+	case sexpr.Macro:
+		return Params(f.Fn)
+	}
+	return nil, false
+}
+
+// Arity returns the least and greatest number of arguments a function,
+// primitive or macro accepts. max is -1 if there is no limit. It reports
+// false when Params does.
+func Arity(fn sexpr.SExpr) (min, max int, ok bool) {
+	switch f := fn.(type) {
+	case sexpr.Func:
+		if f.Rest != nil {
+			return len(f.Params), -1, true
+		}
+		return len(f.Params), len(f.Params), true
+	case sexpr.Primitive:
+		if elems, ok := sexpr.Elements(f.Params); ok {
+			min, max = signatureArity(elems)
+			return min, max, true
+		}
+	case sexpr.Macro:
+		return Arity(f.Fn)
+	}
+	return 0, 0, false
+}
+
+// signatureArity counts the arguments a signature's parameters accept
+func signatureArity(params []sexpr.SExpr) (min, max int) {
+	for _, p := range params {
+		if v, ok := p.(sexpr.Vector); ok {
+			_, optional := signatureArity(v.Elements())
+			if optional < 0 {
+				max = -1
+			} else if max >= 0 {
+				max += optional
+			}
+			continue
+		}
+
+		min++
+		if sym, ok := p.(sexpr.Symbol); ok && strings.HasSuffix(sym.Name, "...") {
+			max = -1
+		} else if max >= 0 {
+			max++
+		}
+	}
+	return min, max
+}
+
+// signatureParams returns the parameters in the signature on the first
+// line of a primitive's docstring, or nil if it has none
+func signatureParams(doc string) sexpr.SExpr {
+	line, _, _ := strings.Cut(doc, "\n")
+	forms, err := parser.ReadAll(line)
+	if err != nil || len(forms) != 1 {
+		return nil
+	}
+	sig, ok := forms[0].(sexpr.List)
+	if !ok || len(sig.Elements) == 0 {
+		return nil
+	}
+	return sexpr.List{Elements: sig.Elements[1:]}
+}
+
+var (
+	minKey = sexpr.Keyword{Name: "min"}
+	maxKey = sexpr.Keyword{Name: "max"}
+)
+
+// primArity handles (arity f), a map of the least number of arguments
+// f accepts, :min, and the greatest, :max, which is missing if there is
+// no limit. f may also be a symbol naming a function. It returns nil if
+// the arity is unknown.
+func primArity(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("arity", 1, 1, len(args))
+	}
+
+	fn, err := namedValue(args[0], env)
+	if err != nil {
+		return nil, err
+	}
+	min, max, ok := Arity(fn)
+	if !ok {
+		return sexpr.Nil{}, nil
+	}
+	entries := []sexpr.SExpr{minKey, sexpr.Number{Value: int64(min)}}
+	if max >= 0 {
+		entries = append(entries, maxKey, sexpr.Number{Value: int64(max)})
+	}
+	// Keywords are always hashable
+	m, _ := sexpr.NewMap(entries...)
+	return m, nil
+}
+
+// primParams handles (params f), the parameter list of f, or nil if it
+// is unknown. f may also be a symbol naming a function.
+func primParams(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("params", 1, 1, len(args))
+	}
+
+	fn, err := namedValue(args[0], env)
+	if err != nil {
+		return nil, err
+	}
+	if params, ok := Params(fn); ok {
+		return params, nil
+	}
+	return sexpr.Nil{}, nil
+}
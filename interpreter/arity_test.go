@@ -0,0 +1,120 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestArityAndParams(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		"(define (f a b) (+ a b))",
+		"(define (g a . rest) rest)",
+		"(define (h . args) args)",
+		"(define (k ((x y) z)) x)",
+		"(defmacro m (test body) body)",
+		"(define-record-type point (make-point x y) point? (x point-x) (y point-y))",
+	)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(arity f)", "{:min 2 :max 2}"},
+		{"(arity 'f)", "{:min 2 :max 2}"},
+		{"(arity g)", "{:min 1}"},
+		{"(arity h)", "{:min 0}"},
+		{"(arity (lambda () 1))", "{:min 0 :max 0}"},
+		{"(arity 'm)", "{:min 2 :max 2}"},
+		{"(arity car)", "{:min 1 :max 1}"},
+		{"(arity +)", "{:min 0}"},
+		{"(arity -)", "{:min 1}"},
+		{"(arity substring)", "{:min 2 :max 3}"},
+		{"(arity iota)", "{:min 1 :max 3}"},
+		{"(arity deref)", "{:min 1 :max 3}"},
+		{"(arity apply)", "{:min 2}"},
+		{"(arity hash-map)", "{:min 0}"},
+		{"(arity map)", "{:min 2}"},
+		{"(arity make-point)", "{:min 2 :max 2}"},
+		{"(arity point-x)", "nil"},
+		{"(arity 5)", "nil"},
+		{"(params f)", "(a b)"},
+		{"(params g)", "(a . rest)"},
+		{"(params h)", "args"},
+		{"(params (lambda () 1))", "()"},
+		{"(params 'm)", "(test body)"},
+		{"(params substring)", "(s start [end])"},
+		{"(params +)", "([n...])"},
+		{"(params make-point)", "(x y)"},
+		{"(params \"f\")", "nil"},
+		{"(length (params k))", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := evalString(env, tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("got %v, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"(arity)", "(params f g)", "(arity 'undefined-name)"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestPrimitiveSignatures(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	for _, name := range env.frame.names {
+		value, err := env.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := value.(sexpr.Primitive); !ok {
+			continue
+		}
+		if _, _, ok := Arity(value); !ok {
+			t.Errorf("%s has no signature", name)
+		}
+	}
+}
+
+func TestSignatureArity(t *testing.T) {
+	tests := []struct {
+		signature string
+		min, max  int
+	}{
+		{"(f)", 0, 0},
+		{"(f a b)", 2, 2},
+		{"(f a [b])", 1, 2},
+		{"(f a [b [c]])", 1, 3},
+		{"(f a [b c])", 1, 3},
+		{"(f a...)", 1, -1},
+		{"(f [a...])", 0, -1},
+		{"(f [a...] [b])", 0, -1},
+		{"(f a [b...] c)", 2, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.signature, func(t *testing.T) {
+			params, ok := sexpr.Elements(signatureParams(tt.signature))
+			if !ok {
+				t.Fatalf("no parameters in %s", tt.signature)
+			}
+			min, max := signatureArity(params)
+			if min != tt.min || max != tt.max {
+				t.Errorf("got %d, %d, want %d, %d", min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
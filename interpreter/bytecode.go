@@ -0,0 +1,588 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// opcode is a bytecode instruction. Each is one byte followed by its
+// operands, which are two bytes each, big-endian.
+type opcode byte
+
+const (
+	opConst       opcode = iota // k: push constant k
+	opGlobal                    // k: push the variable of the globalRef in constant k
+	opLocal                     // k: push the variable of the localRef in constant k
+	opDefine                    // k: bind the name in constant k to the top value
+	opSet                       // k: set the variable named by constant k to the top value
+	opPop                       // drop the top value
+	opJump                      // t: continue at t
+	opJumpIfFalse               // t: pop a value and continue at t if it is falsy
+	opJumpIfTrue                // t: pop a value and continue at t if it is truthy
+	opAnd                       // t: continue at t if the top value is falsy, else pop it
+	opOr                        // t: continue at t if the top value is truthy, else pop it
+	opClosure                   // f: push a function of lambda f closed over the current frame
+	opMacro                     // k t: if the top value is a macro, replace it with the value of its use in constant k and continue at t
+	opCall                      // n: call the value below the top n with them as arguments
+	opReturn                    // return the top value from the current function
+	opEval                      // k: evaluate constant k directly
+	opLet                       // k n: enter a frame binding the names in constants k on to the top n values
+	opLeave                     // n: leave n frames
+	opLoop                      // k n: like opLet, for an iteration of a loop
+	opLoopNext                  // n t: leave the loop frame, and if the top value is a recur replace it with its n values and continue at t
+	opLoopCheck                 // fail unless inside a loop
+	opRecur                     // n: replace the top n values with a recur of them
+)
+
+// opInfo gives the name and number of operands of each opcode
+var opInfo = [...]struct {
+	name     string
+	operands int
+}{
+	opConst:       {"const", 1},
+	opGlobal:      {"global", 1},
+	opLocal:       {"local", 1},
+	opDefine:      {"define", 1},
+	opSet:         {"set", 1},
+	opPop:         {"pop", 0},
+	opJump:        {"jump", 1},
+	opJumpIfFalse: {"jump-if-false", 1},
+	opJumpIfTrue:  {"jump-if-true", 1},
+	opAnd:         {"and", 1},
+	opOr:          {"or", 1},
+	opClosure:     {"closure", 1},
+	opMacro:       {"macro", 2},
+	opCall:        {"call", 1},
+	opReturn:      {"return", 0},
+	opEval:        {"eval", 1},
+	opLet:         {"let", 2},
+	opLeave:       {"leave", 1},
+	opLoop:        {"loop", 2},
+	opLoopNext:    {"loop-next", 2},
+	opLoopCheck:   {"loop-check", 0},
+	opRecur:       {"recur", 1},
+}
+
+// maxOperand is the largest value an operand can hold
+const maxOperand = 1<<16 - 1
+
+// Code is an expression compiled to bytecode by Compile. It is itself an
+// expression: evaluating it with Eval runs it on the virtual machine.
+type Code struct {
+	ops     []byte
+	consts  []sexpr.SExpr
+	lambdas []compiledLambda
+	spans   []span // source forms of the instructions, by offset
+	source  sexpr.SExpr
+}
+
+// compiledLambda is a lambda expression with its body compiled
+type compiledLambda struct {
+	params []sexpr.Symbol
+	rest   *sexpr.Symbol
+	body   *Code
+	meta   *sexpr.Map
+}
+
+// span records that the instructions from start on were compiled from
+// form, the innermost list around them, and located, the innermost with
+// a source position
+type span struct {
+	start   int
+	form    sexpr.SExpr
+	located sexpr.SExpr
+}
+
+func (c *Code) String() string {
+	return c.source.String()
+}
+
+// Equal reports whether other is code compiled from the same expression
+func (c *Code) Equal(other sexpr.SExpr) bool {
+	o, ok := other.(*Code)
+	return ok && c.source.Equal(o.source)
+}
+
+// Disassemble lists the instructions of c, one per line, followed by
+// those of the lambdas it contains
+func (c *Code) Disassemble() string {
+	var b strings.Builder
+	c.disassemble(&b, "")
+	return b.String()
+}
+
+func (c *Code) disassemble(b *strings.Builder, indent string) {
+	for ip := 0; ip < len(c.ops); {
+		op := opcode(c.ops[ip])
+		info := opInfo[op]
+		fmt.Fprintf(b, "%s%04d %s", indent, ip, info.name)
+		for i := 0; i < info.operands; i++ {
+			fmt.Fprintf(b, " %d", c.arg(ip+1+2*i))
+		}
+		switch op {
+		case opConst, opGlobal, opLocal, opDefine, opSet, opEval, opMacro:
+			fmt.Fprintf(b, " ; %v", c.consts[c.arg(ip+1)])
+		}
+		b.WriteByte('\n')
+		ip += 1 + 2*info.operands
+	}
+	for i, l := range c.lambdas {
+		fmt.Fprintf(b, "%slambda %d:\n", indent, i)
+		l.body.disassemble(b, indent+"  ")
+	}
+}
+
+// arg decodes the operand at offset i
+func (c *Code) arg(i int) int {
+	return int(c.ops[i])<<8 | int(c.ops[i+1])
+}
+
+// trace annotates err, raised by the instruction at offset at, with the
+// forms it was compiled from
+func (c *Code) trace(err error, at int) error {
+	i := sort.Search(len(c.spans), func(i int) bool { return c.spans[i].start > at }) - 1
+	if i < 0 {
+		return err
+	}
+	if s := c.spans[i]; s.form != nil {
+		err = traceForm(err, s.form)
+		if s.located != nil {
+			err = traceForm(err, s.located)
+		}
+	}
+	return err
+}
+
+// callName returns the name of the function called by the call
+// instruction at offset at, for stack traces
+func (c *Code) callName(at int) string {
+	i := sort.Search(len(c.spans), func(i int) bool { return c.spans[i].start > at }) - 1
+	if i >= 0 {
+		if call, ok := c.spans[i].form.(sexpr.List); ok && len(call.Elements) > 0 {
+			if head, ok := call.Elements[0].(sexpr.Symbol); ok {
+				return head.Name
+			}
+		}
+	}
+	return "lambda"
+}
+
+// Compile translates expr to bytecode for evaluation in env. Local
+// variables of env are compiled to slots that are checked when the code
+// runs, so it may also run in other environments, only more slowly.
+// Forms the compiler does not handle, such as try and match, are
+// evaluated directly when reached, and malformed forms fail only when
+// reached, as they would when walked.
+func Compile(expr sexpr.SExpr, env *Env) (*Code, error) {
+	c := &compiler{rt: env.Runtime(), code: &Code{source: expr}, scope: envScope(env)}
+	c.expr(unresolve(expr))
+	c.emit(opReturn)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.code, nil
+}
+
+// compiler emits the bytecode for one function body or top-level
+// expression, modeling the frames it will run in like the resolver
+type compiler struct {
+	rt    *Runtime
+	code  *Code
+	scope *scope
+	err   error
+
+	form    sexpr.SExpr // innermost list being compiled
+	located sexpr.SExpr // innermost list with a source position
+	moved   bool        // form changed since the last span
+}
+
+// emit appends an instruction, returning its offset
+func (c *compiler) emit(op opcode, args ...int) int {
+	at := len(c.code.ops)
+	if c.moved {
+		c.code.spans = append(c.code.spans, span{start: at, form: c.form, located: c.located})
+		c.moved = false
+	}
+	c.code.ops = append(c.code.ops, byte(op))
+	for _, a := range args {
+		if a > maxOperand && c.err == nil {
+			c.err = evalError("", "expression too large to compile")
+		}
+		c.code.ops = append(c.code.ops, byte(a>>8), byte(a))
+	}
+	return at
+}
+
+// patch points the last operand of the jump at offset at to the next
+// instruction
+func (c *compiler) patch(at int) {
+	target := len(c.code.ops)
+	if target > maxOperand && c.err == nil {
+		c.err = evalError("", "expression too large to compile")
+	}
+	i := at + 2*opInfo[c.code.ops[at]].operands - 1
+	c.code.ops[i], c.code.ops[i+1] = byte(target>>8), byte(target)
+}
+
+func (c *compiler) constant(x sexpr.SExpr) int {
+	c.code.consts = append(c.code.consts, x)
+	return len(c.code.consts) - 1
+}
+
+// names adds names as consecutive constants, returning the first's index
+func (c *compiler) names(names []string) int {
+	k := len(c.code.consts)
+	for _, name := range names {
+		c.constant(sexpr.Symbol{Name: name})
+	}
+	return k
+}
+
+// enter makes list the form being compiled and returns a function that
+// restores the previous one
+func (c *compiler) enter(list sexpr.List) func() {
+	form, located := c.form, c.located
+	c.form = list
+	if _, ok := sexpr.PositionOf(list); ok {
+		c.located = list
+	}
+	c.moved = true
+	return func() {
+		c.form, c.located, c.moved = form, located, true
+	}
+}
+
+func (c *compiler) expr(x sexpr.SExpr) {
+	switch e := x.(type) {
+	case sexpr.Symbol:
+		if depth, index, ok := c.scope.find(e.Name); ok {
+			c.emit(opLocal, c.constant(localRef{name: e.Name, depth: depth, index: index, bit: nameBit(e.Name)}))
+		} else {
+			c.emit(opGlobal, c.constant(newGlobalRef(e.Name)))
+		}
+	case sexpr.List:
+		defer c.enter(e)()
+		c.list(e)
+	case sexpr.Number, sexpr.BigInt, sexpr.Float, sexpr.String,
+		sexpr.Bool, sexpr.Nil, sexpr.Keyword, sexpr.Char, sexpr.Bytes:
+		c.emit(opConst, c.constant(x))
+	default:
+		c.emit(opEval, c.constant(x))
+	}
+}
+
+func (c *compiler) list(list sexpr.List) {
+	elems := list.Elements
+	if len(elems) == 0 {
+		c.emit(opConst, c.constant(sexpr.Nil{}))
+		return
+	}
+	head, ok := elems[0].(sexpr.Symbol)
+	if !ok || !specialForms[head.Name] {
+		c.call(list)
+		return
+	}
+
+	switch head.Name {
+	case "quote":
+		if len(elems) == 2 {
+			c.emit(opConst, c.constant(elems[1]))
+			return
+		}
+	case "if":
+		if len(elems) == 4 {
+			c.expr(elems[1])
+			otherwise := c.emit(opJumpIfFalse, 0)
+			c.expr(elems[2])
+			end := c.emit(opJump, 0)
+			c.patch(otherwise)
+			c.expr(elems[3])
+			c.patch(end)
+			return
+		}
+	case "define":
+		if c.define(list) {
+			return
+		}
+	case "set!":
+		if len(elems) == 3 {
+			if name, ok := elems[1].(sexpr.Symbol); ok {
+				c.expr(elems[2])
+				c.emit(opSet, c.constant(name))
+				return
+			}
+		}
+	case "lambda":
+		if c.lambda(list) {
+			return
+		}
+	case "begin":
+		c.body(elems[1:])
+		return
+	case "and", "or":
+		c.andOr(elems[1:], head.Name == "and")
+		return
+	case "when", "unless":
+		if len(elems) >= 2 {
+			c.when(elems[1], elems[2:], head.Name == "when")
+			return
+		}
+	case "cond":
+		if c.cond(elems[1:]) {
+			return
+		}
+	case "let", "let*", "letrec", "loop":
+		if names, ok := bindingNames(list); ok {
+			c.let(head.Name, list, names)
+			return
+		}
+	case "string-interpolate":
+		c.emit(opConst, c.constant(strPrimitive))
+		for _, arg := range elems[1:] {
+			c.expr(arg)
+		}
+		c.emit(opCall, len(elems)-1)
+		return
+	case "recur":
+		c.emit(opLoopCheck)
+		for _, arg := range elems[1:] {
+			c.expr(arg)
+		}
+		c.emit(opRecur, len(elems)-1)
+		return
+	}
+
+	// Leave other forms, and malformed ones, to the tree walker
+	c.emit(opEval, c.constant(list))
+}
+
+// call compiles a function call, or a macro use if the head turns out
+// to be a macro when the code runs
+func (c *compiler) call(list sexpr.List) {
+	c.expr(list.Elements[0])
+	macro := c.emit(opMacro, c.constant(list), 0)
+	for _, arg := range list.Elements[1:] {
+		c.expr(arg)
+	}
+	c.emit(opCall, len(list.Elements)-1)
+	c.patch(macro)
+}
+
+// define compiles (define name value) and the function shorthand,
+// reporting false if the form is malformed
+func (c *compiler) define(list sexpr.List) bool {
+	if len(list.Elements) == 3 {
+		if name, ok := list.Elements[1].(sexpr.Symbol); ok {
+			c.expr(list.Elements[2])
+			c.emit(opDefine, c.constant(name))
+			return true
+		}
+	}
+	define, err := desugarDefine(list)
+	if err != nil {
+		return false
+	}
+	c.list(define)
+	return true
+}
+
+// lambda compiles a lambda expression's body as a separate function,
+// reporting false if the expression is malformed
+func (c *compiler) lambda(list sexpr.List) bool {
+	list, doc := splitDoc(list)
+	if len(list.Elements) != 3 {
+		return false
+	}
+	spec, body, err := destructureParams(list.Elements[1], list.Elements[2], c.rt)
+	if err != nil {
+		return false
+	}
+	params, rest, err := parseParams(spec)
+	if err != nil {
+		return false
+	}
+
+	inner := newScope(c.scope)
+	for _, p := range params {
+		inner.add(p.Name)
+	}
+	if rest != nil {
+		inner.add(rest.Name)
+	}
+	fc := &compiler{
+		rt:      c.rt,
+		code:    &Code{source: list},
+		scope:   inner,
+		form:    c.form,
+		located: c.located,
+		moved:   true,
+	}
+	fc.expr(body)
+	fc.emit(opReturn)
+	if fc.err != nil {
+		return false
+	}
+
+	c.code.lambdas = append(c.code.lambdas, compiledLambda{params: params, rest: rest, body: fc.code, meta: docMeta(doc)})
+	c.emit(opClosure, len(c.code.lambdas)-1)
+	return true
+}
+
+// body compiles exprs in sequence, leaving the value of the last
+func (c *compiler) body(exprs []sexpr.SExpr) {
+	if len(exprs) == 0 {
+		c.emit(opConst, c.constant(sexpr.Nil{}))
+		return
+	}
+	for i, x := range exprs {
+		if i > 0 {
+			c.emit(opPop)
+		}
+		c.expr(x)
+	}
+}
+
+func (c *compiler) andOr(exprs []sexpr.SExpr, isAnd bool) {
+	if len(exprs) == 0 {
+		c.emit(opConst, c.constant(sexpr.Bool{Value: isAnd}))
+		return
+	}
+	op := opOr
+	if isAnd {
+		op = opAnd
+	}
+	var exits []int
+	for i, x := range exprs {
+		c.expr(x)
+		if i < len(exprs)-1 {
+			exits = append(exits, c.emit(op, 0))
+		}
+	}
+	for _, at := range exits {
+		c.patch(at)
+	}
+}
+
+func (c *compiler) when(test sexpr.SExpr, body []sexpr.SExpr, want bool) {
+	c.expr(test)
+	op := opJumpIfFalse
+	if !want {
+		op = opJumpIfTrue
+	}
+	skip := c.emit(op, 0)
+	c.body(body)
+	end := c.emit(opJump, 0)
+	c.patch(skip)
+	c.emit(opConst, c.constant(sexpr.Nil{}))
+	c.patch(end)
+}
+
+// cond compiles the clauses of a cond, reporting false if they are
+// malformed or use =>, which the tree walker handles
+func (c *compiler) cond(clauses []sexpr.SExpr) bool {
+	for i, clause := range clauses {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) == 0 {
+			return false
+		}
+		if isSymbolNamed(parts[0], "else") && i != len(clauses)-1 {
+			return false
+		}
+		if len(parts) > 1 && isSymbolNamed(parts[1], "=>") {
+			return false
+		}
+	}
+
+	var exits []int
+	for _, clause := range clauses {
+		parts, _ := sexpr.Elements(clause)
+		if isSymbolNamed(parts[0], "else") {
+			c.body(parts[1:])
+			exits = append(exits, c.emit(opJump, 0))
+			break
+		}
+		c.expr(parts[0])
+		if len(parts) == 1 {
+			exits = append(exits, c.emit(opOr, 0))
+			continue
+		}
+		next := c.emit(opJumpIfFalse, 0)
+		c.body(parts[1:])
+		exits = append(exits, c.emit(opJump, 0))
+		c.patch(next)
+	}
+	c.emit(opConst, c.constant(sexpr.Nil{}))
+	for _, at := range exits {
+		c.patch(at)
+	}
+	return true
+}
+
+// let compiles a let, let*, letrec or loop whose bindings are all to
+// plain names, creating the same frames as the tree walker
+func (c *compiler) let(form string, list sexpr.List, names []string) {
+	specs := list.Elements[1].(sexpr.List).Elements
+	value := func(i int) sexpr.SExpr {
+		return specs[i].(sexpr.List).Elements[1]
+	}
+	outer := c.scope
+	defer func() { c.scope = outer }()
+
+	frames := 1
+	switch form {
+	case "let":
+		for i := range specs {
+			c.expr(value(i))
+		}
+		c.emit(opLet, c.names(names), len(names))
+		c.scope = newScope(outer, names...)
+	case "let*":
+		// The first value is evaluated in the empty let frame, and each
+		// later binding gets a frame of its own
+		c.emit(opLet, 0, 0)
+		c.scope = newScope(outer)
+		for i := range specs {
+			c.expr(value(i))
+			if i == 0 {
+				c.emit(opDefine, c.constant(sexpr.Symbol{Name: names[0]}))
+				c.emit(opPop)
+				c.scope.add(names[0])
+				continue
+			}
+			c.emit(opLet, c.names(names[i:i+1]), 1)
+			c.scope = newScope(c.scope, names[i])
+			frames++
+		}
+	case "letrec":
+		for range names {
+			c.emit(opConst, c.constant(sexpr.Nil{}))
+		}
+		c.emit(opLet, c.names(names), len(names))
+		c.scope = newScope(outer, names...)
+		for i := range specs {
+			c.expr(value(i))
+			c.emit(opDefine, c.constant(sexpr.Symbol{Name: names[i]}))
+			c.emit(opPop)
+		}
+	case "loop":
+		for i := range specs {
+			c.expr(value(i))
+		}
+		start := c.emit(opLoop, c.names(names), len(names))
+		c.scope = newScope(outer, append([]string{loopMarker}, names...)...)
+		c.body(list.Elements[2:])
+		c.emit(opLoopNext, len(names), start)
+		return
+	}
+
+	c.body(list.Elements[2:])
+	c.emit(opLeave, frames)
+}
+
+func isSymbolNamed(x sexpr.SExpr, name string) bool {
+	sym, ok := x.(sexpr.Symbol)
+	return ok && sym.Name == name
+}
@@ -0,0 +1,107 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// compileString reads input and compiles it for env
+func compileString(t *testing.T, env *Env, input string) *Code {
+	t.Helper()
+	tokens, err := parser.Tokenize(input)
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	code, err := Compile(expr, env)
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+	return code
+}
+
+func TestCompileDisassemble(t *testing.T) {
+	env := NewEnv(nil)
+	code := compileString(t, env, "(define (f x) (if (< x 0) (- x) x))")
+
+	expected := strings.Join([]string{
+		"0000 closure 0",
+		"0003 define 0 ; f",
+		"0006 return",
+		"lambda 0:",
+		"  0000 global 0 ; <",
+		"  0003 macro 1 17 ; (< x 0)",
+		"  0008 local 2 ; x",
+		"  0011 const 3 ; 0",
+		"  0014 call 2",
+		"  0017 jump-if-false 37",
+		"  0020 global 4 ; -",
+		"  0023 macro 5 34 ; (- x)",
+		"  0028 local 6 ; x",
+		"  0031 call 1",
+		"  0034 jump 40",
+		"  0037 local 7 ; x",
+		"  0040 return",
+		"",
+	}, "\n")
+	if got := code.Disassemble(); got != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestCompileFallback(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	// Forms the compiler leaves to the tree walker still run
+	code := compileString(t, env, "(match '(1 2) ((a b) (+ a b)))")
+	if !strings.Contains(code.Disassemble(), "eval") {
+		t.Errorf("expected match to be evaluated directly:\n%s", code.Disassemble())
+	}
+	result, err := Eval(code, env)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+	if !result.Equal(sexpr.Number{Value: 3}) {
+		t.Errorf("got %v, want 3", result)
+	}
+
+	// Malformed forms compile and fail only when reached
+	code = compileString(t, env, "(define (f x) (if x))")
+	if _, err := Eval(code, env); err != nil {
+		t.Fatalf("defining f: %v", err)
+	}
+	if _, err := evalString(env, "(f 1)"); err == nil || !strings.Contains(err.Error(), "if: requires 3 arguments") {
+		t.Errorf("expected if arity error, got %v", err)
+	}
+}
+
+func TestCompiledCodeIsAnExpression(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	code := compileString(t, env, "(+ 1 2)")
+
+	if code.String() != "(+ 1 2)" {
+		t.Errorf("String() = %s, want (+ 1 2)", code)
+	}
+	if !code.Equal(compileString(t, env, "(+ 1 2)")) {
+		t.Error("code compiled from equal expressions should be equal")
+	}
+
+	// Code can be evaluated more than once, and in other environments
+	for i := 0; i < 2; i++ {
+		result, err := Eval(code, env.Extend())
+		if err != nil {
+			t.Fatalf("eval error: %v", err)
+		}
+		if !result.Equal(sexpr.Number{Value: 3}) {
+			t.Errorf("got %v, want 3", result)
+		}
+	}
+}
@@ -0,0 +1,590 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// compiledFn evaluates a compiled expression in an environment
+type compiledFn func(env *Env) (sexpr.SExpr, error)
+
+// Compiled is an expression translated by CompileClosures into a tree of
+// Go closures, one per node, so that running it again does not repeat
+// the type switches and special form dispatch of walking it. It is
+// itself an expression: evaluating it with Eval runs it.
+type Compiled struct {
+	run    compiledFn
+	source sexpr.SExpr
+}
+
+func (c *Compiled) String() string {
+	return c.source.String()
+}
+
+// Equal reports whether other is compiled from the same expression
+func (c *Compiled) Equal(other sexpr.SExpr) bool {
+	o, ok := other.(*Compiled)
+	return ok && c.source.Equal(o.source)
+}
+
+// CompileClosures translates expr into closures for evaluation in env.
+// Like Compile, it resolves local variables of env to slots checked when
+// the code runs, evaluates forms it does not handle directly when they
+// are reached, and leaves malformed forms to fail when reached.
+func CompileClosures(expr sexpr.SExpr, env *Env) *Compiled {
+	c := &closureCompiler{rt: env.Runtime(), scope: envScope(env)}
+	return &Compiled{run: c.expr(unresolve(expr)), source: expr}
+}
+
+// closureCompiler compiles one function body or top-level expression,
+// modeling the frames it will run in like the resolver
+type closureCompiler struct {
+	rt    *Runtime
+	scope *scope
+	tail  bool // whether the form being compiled is in tail position of a function body
+}
+
+// expr compiles x, which is not in tail position
+func (c *closureCompiler) expr(x sexpr.SExpr) compiledFn {
+	tail := c.tail
+	c.tail = false
+	defer func() { c.tail = tail }()
+	return c.last(x)
+}
+
+// last compiles x, the last expression of the form being compiled, which
+// is in tail position if the form is. A call of a function in tail
+// position gives a tailCall for applyFunc to make, so tail calls run in
+// constant space.
+func (c *closureCompiler) last(x sexpr.SExpr) compiledFn {
+	switch e := x.(type) {
+	case sexpr.Symbol:
+		if depth, index, ok := c.scope.find(e.Name); ok {
+			ref := localRef{name: e.Name, depth: depth, index: index, bit: nameBit(e.Name)}
+			return ref.lookup
+		}
+		return func(env *Env) (sexpr.SExpr, error) {
+			return lookupSymbol(e.Name, env)
+		}
+	case sexpr.List:
+		fn := c.list(e)
+		return func(env *Env) (sexpr.SExpr, error) {
+			v, err := fn(env)
+			if err != nil {
+				return nil, traceForm(err, e)
+			}
+			return v, nil
+		}
+	case sexpr.Number, sexpr.BigInt, sexpr.Float, sexpr.String,
+		sexpr.Bool, sexpr.Nil, sexpr.Keyword, sexpr.Char, sexpr.Bytes:
+		return constant(x)
+	}
+	if c.tail {
+		return tailFallback(x)
+	}
+	return fallback(x)
+}
+
+// constant returns a function giving x
+func constant(x sexpr.SExpr) compiledFn {
+	return func(*Env) (sexpr.SExpr, error) {
+		return x, nil
+	}
+}
+
+// fallback returns a function evaluating x directly, for forms the
+// compiler leaves to the tree walker
+func fallback(x sexpr.SExpr) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		view := *env
+		view.direct = true
+		return Eval(x, &view)
+	}
+}
+
+// tailFallback is fallback for x in tail position, where a call x ends
+// with is given as a tailCall
+func tailFallback(x sexpr.SExpr) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		view := *env
+		view.direct = true
+		return evalTail(x, &view)
+	}
+}
+
+func (c *closureCompiler) list(list sexpr.List) compiledFn {
+	elems := list.Elements
+	if len(elems) == 0 {
+		return constant(sexpr.Nil{})
+	}
+	head, ok := elems[0].(sexpr.Symbol)
+	if !ok || !specialForms[head.Name] {
+		return c.call(list)
+	}
+
+	switch head.Name {
+	case "quote":
+		if len(elems) == 2 {
+			return constant(elems[1])
+		}
+	case "if":
+		if len(elems) == 4 {
+			return c.ifForm(c.expr(elems[1]), c.last(elems[2]), c.last(elems[3]))
+		}
+	case "define":
+		if fn := c.define(list); fn != nil {
+			return fn
+		}
+	case "set!":
+		if len(elems) == 3 {
+			if name, ok := elems[1].(sexpr.Symbol); ok {
+				return c.set(name.Name, c.expr(elems[2]))
+			}
+		}
+	case "lambda":
+		if fn := c.lambda(list); fn != nil {
+			return fn
+		}
+	case "begin":
+		return c.body(elems[1:])
+	case "and", "or":
+		return c.andOr(elems[1:], head.Name == "and")
+	case "when", "unless":
+		if len(elems) >= 2 {
+			return c.when(c.expr(elems[1]), c.body(elems[2:]), head.Name == "when")
+		}
+	case "cond":
+		if fn := c.cond(elems[1:]); fn != nil {
+			return fn
+		}
+	case "let", "let*", "letrec", "loop":
+		if names, ok := bindingNames(list); ok {
+			return c.let(head.Name, list, names)
+		}
+	case "recur":
+		return c.recur(c.each(elems[1:]))
+	case "string-interpolate":
+		args := c.each(elems[1:])
+		return func(env *Env) (sexpr.SExpr, error) {
+			values, err := evalAll(args, env)
+			if err != nil {
+				return nil, err
+			}
+			return primStr(values, env)
+		}
+	}
+
+	// Leave other forms, and malformed ones, to the tree walker
+	if c.tail {
+		return tailFallback(list)
+	}
+	return fallback(list)
+}
+
+// each compiles exprs
+func (c *closureCompiler) each(exprs []sexpr.SExpr) []compiledFn {
+	fns := make([]compiledFn, len(exprs))
+	for i, x := range exprs {
+		fns[i] = c.expr(x)
+	}
+	return fns
+}
+
+// evalAll runs fns in order and collects their values
+func evalAll(fns []compiledFn, env *Env) ([]sexpr.SExpr, error) {
+	if len(fns) == 0 {
+		return nil, nil
+	}
+	values := make([]sexpr.SExpr, len(fns))
+	for i, fn := range fns {
+		v, err := fn(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// call compiles a function call, or a macro use if the head turns out
+// to be a macro when the code runs
+func (c *closureCompiler) call(list sexpr.List) compiledFn {
+	tail := c.tail
+	head := c.expr(list.Elements[0])
+	operands := list.Elements[1:]
+	args := c.each(operands)
+	name := "lambda"
+	if sym, ok := list.Elements[0].(sexpr.Symbol); ok {
+		name = sym.Name
+	}
+
+	return func(env *Env) (sexpr.SExpr, error) {
+		fn, err := head(env)
+		if err != nil {
+			return nil, err
+		}
+		if macro, ok := fn.(sexpr.Macro); ok {
+			expanded, err := expandMacro(macro, append([]sexpr.SExpr{}, operands...), env)
+			if err != nil {
+				return nil, err
+			}
+			// Count the expansion as a call so that a macro that expands
+			// to itself hits the depth limit
+			deeper, err := env.deeper()
+			if err != nil {
+				return nil, err
+			}
+			if tail {
+				tc := &closureCompiler{rt: c.rt, scope: envScope(deeper), tail: true}
+				return tc.last(unresolve(expanded))(deeper)
+			}
+			return Eval(expanded, deeper)
+		}
+
+		values, err := evalAll(args, env)
+		if err != nil {
+			return nil, err
+		}
+		if err := env.runtime.step(); err != nil {
+			return nil, err
+		}
+		f, ok := fn.(sexpr.Func)
+		if !ok {
+			return apply(fn, values, env)
+		}
+		if tail {
+			return tailCall{fn: f, args: values, name: name, form: list}, nil
+		}
+		v, err := applyFunc(f, values, env)
+		if err != nil {
+			return nil, traceCall(err, name)
+		}
+		return v, nil
+	}
+}
+
+func (c *closureCompiler) ifForm(test, then, otherwise compiledFn) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		v, err := test(env)
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(v) {
+			return then(env)
+		}
+		return otherwise(env)
+	}
+}
+
+// define compiles (define name value) and the function shorthand,
+// returning nil if the form is malformed
+func (c *closureCompiler) define(list sexpr.List) compiledFn {
+	if len(list.Elements) == 3 {
+		if name, ok := list.Elements[1].(sexpr.Symbol); ok {
+			value := c.expr(list.Elements[2])
+			return func(env *Env) (sexpr.SExpr, error) {
+				v, err := value(env)
+				if err != nil {
+					return nil, err
+				}
+				env.Define(name.Name, v)
+				return v, nil
+			}
+		}
+	}
+	define, err := desugarDefine(list)
+	if err != nil {
+		return nil
+	}
+	return c.list(define)
+}
+
+func (c *closureCompiler) set(name string, value compiledFn) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		v, err := value(env)
+		if err != nil {
+			return nil, err
+		}
+		if err := env.Set(name, v); err != nil {
+			return nil, fmt.Errorf("set!: %w", err)
+		}
+		return v, nil
+	}
+}
+
+// lambda compiles a lambda expression's body once, for all the functions
+// the expression creates, returning nil if the expression is malformed
+func (c *closureCompiler) lambda(list sexpr.List) compiledFn {
+	list, doc := splitDoc(list)
+	if len(list.Elements) != 3 {
+		return nil
+	}
+	spec, body, err := destructureParams(list.Elements[1], list.Elements[2], c.rt)
+	if err != nil {
+		return nil
+	}
+	params, rest, err := parseParams(spec)
+	if err != nil {
+		return nil
+	}
+
+	inner := newScope(c.scope)
+	for _, p := range params {
+		inner.add(p.Name)
+	}
+	if rest != nil {
+		inner.add(rest.Name)
+	}
+	fc := &closureCompiler{rt: c.rt, scope: inner, tail: true}
+	compiled := &Compiled{run: fc.last(body), source: body}
+	meta := docMeta(doc)
+
+	return func(env *Env) (sexpr.SExpr, error) {
+		return sexpr.Func{Params: params, Rest: rest, Body: compiled, Env: env, Meta: meta}, nil
+	}
+}
+
+// body compiles exprs in sequence, giving the value of the last
+func (c *closureCompiler) body(exprs []sexpr.SExpr) compiledFn {
+	switch len(exprs) {
+	case 0:
+		return constant(sexpr.Nil{})
+	case 1:
+		return c.last(exprs[0])
+	}
+	fns := append(c.each(exprs[:len(exprs)-1]), c.last(exprs[len(exprs)-1]))
+	return func(env *Env) (sexpr.SExpr, error) {
+		for _, fn := range fns[:len(fns)-1] {
+			if _, err := fn(env); err != nil {
+				return nil, err
+			}
+		}
+		return fns[len(fns)-1](env)
+	}
+}
+
+func (c *closureCompiler) andOr(exprs []sexpr.SExpr, isAnd bool) compiledFn {
+	if len(exprs) == 0 {
+		return constant(sexpr.Bool{Value: isAnd})
+	}
+	fns := append(c.each(exprs[:len(exprs)-1]), c.last(exprs[len(exprs)-1]))
+	return func(env *Env) (sexpr.SExpr, error) {
+		for _, fn := range fns[:len(fns)-1] {
+			v, err := fn(env)
+			if err != nil {
+				return nil, err
+			}
+			if isTruthy(v) != isAnd {
+				return v, nil
+			}
+		}
+		return fns[len(fns)-1](env)
+	}
+}
+
+func (c *closureCompiler) when(test, body compiledFn, want bool) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		v, err := test(env)
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(v) != want {
+			return sexpr.Nil{}, nil
+		}
+		return body(env)
+	}
+}
+
+// condClause is a compiled cond clause. A clause with no body gives the
+// value of its test.
+type condClause struct {
+	test compiledFn // nil for else
+	body compiledFn // nil if there are no expressions
+}
+
+// cond compiles the clauses of a cond, returning nil if they are
+// malformed or use =>, which the tree walker handles
+func (c *closureCompiler) cond(clauses []sexpr.SExpr) compiledFn {
+	compiled := make([]condClause, 0, len(clauses))
+	for i, clause := range clauses {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) == 0 {
+			return nil
+		}
+		if isSymbolNamed(parts[0], "else") {
+			if i != len(clauses)-1 {
+				return nil
+			}
+			compiled = append(compiled, condClause{body: c.body(parts[1:])})
+			continue
+		}
+		if len(parts) > 1 && isSymbolNamed(parts[1], "=>") {
+			return nil
+		}
+		cc := condClause{test: c.expr(parts[0])}
+		if len(parts) > 1 {
+			cc.body = c.body(parts[1:])
+		}
+		compiled = append(compiled, cc)
+	}
+
+	return func(env *Env) (sexpr.SExpr, error) {
+		for _, clause := range compiled {
+			if clause.test == nil {
+				return clause.body(env)
+			}
+			v, err := clause.test(env)
+			if err != nil {
+				return nil, err
+			}
+			if !isTruthy(v) {
+				continue
+			}
+			if clause.body == nil {
+				return v, nil
+			}
+			return clause.body(env)
+		}
+		return sexpr.Nil{}, nil
+	}
+}
+
+// let compiles a let, let*, letrec or loop whose bindings are all to
+// plain names, creating the same frames as the tree walker
+func (c *closureCompiler) let(form string, list sexpr.List, names []string) compiledFn {
+	specs := list.Elements[1].(sexpr.List).Elements
+	values := make([]sexpr.SExpr, len(specs))
+	for i, spec := range specs {
+		values[i] = spec.(sexpr.List).Elements[1]
+	}
+	outer := c.scope
+	defer func() { c.scope = outer }()
+
+	switch form {
+	case "let":
+		inits := c.each(values)
+		c.scope = newScope(outer, names...)
+		body := c.body(list.Elements[2:])
+		return func(env *Env) (sexpr.SExpr, error) {
+			vs, err := evalAll(inits, env)
+			if err != nil {
+				return nil, err
+			}
+			letEnv := newEnv(env, len(names))
+			for i, name := range names {
+				letEnv.Define(name, vs[i])
+			}
+			return body(letEnv)
+		}
+
+	case "let*":
+		// The first value is evaluated in the empty let frame, and each
+		// later binding gets a frame of its own
+		c.scope = newScope(outer)
+		inits := make([]compiledFn, len(values))
+		for i, x := range values {
+			inits[i] = c.expr(x)
+			if i == 0 {
+				c.scope.add(names[0])
+			} else {
+				c.scope = newScope(c.scope, names[i])
+			}
+		}
+		body := c.body(list.Elements[2:])
+		return func(env *Env) (sexpr.SExpr, error) {
+			env = newEnv(env, 0)
+			for i, init := range inits {
+				v, err := init(env)
+				if err != nil {
+					return nil, err
+				}
+				if i > 0 {
+					env = newEnv(env, 1)
+				}
+				env.Define(names[i], v)
+			}
+			return body(env)
+		}
+
+	case "letrec":
+		c.scope = newScope(outer, names...)
+		inits := c.each(values)
+		body := c.body(list.Elements[2:])
+		return func(env *Env) (sexpr.SExpr, error) {
+			letEnv := newEnv(env, len(names))
+			for _, name := range names {
+				letEnv.Define(name, sexpr.Nil{})
+			}
+			for i, init := range inits {
+				v, err := init(letEnv)
+				if err != nil {
+					return nil, err
+				}
+				letEnv.Define(names[i], v)
+			}
+			return body(letEnv)
+		}
+	}
+
+	// The body of a loop gives a recur to the loop, not a tail call
+	inits := c.each(values)
+	c.scope = newScope(outer, append([]string{loopMarker}, names...)...)
+	tail := c.tail
+	c.tail = false
+	defer func() { c.tail = tail }()
+	return c.loop(names, inits, c.body(list.Elements[2:]))
+}
+
+// loop runs the body of a loop binding names until it gives a value
+// other than a recur
+func (c *closureCompiler) loop(names []string, inits []compiledFn, body compiledFn) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		values, err := evalAll(inits, env)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			if err := env.interrupted(); err != nil {
+				return nil, err
+			}
+			if err := env.runtime.step(); err != nil {
+				return nil, err
+			}
+
+			// A fresh frame per iteration keeps closures created in one
+			// iteration from seeing the next iteration's values
+			loopEnv := newEnv(env, len(names)+1)
+			loopEnv.Define(loopMarker, sexpr.Bool{Value: true})
+			for i, name := range names {
+				loopEnv.Define(name, values[i])
+			}
+
+			result, err := body(loopEnv)
+			if err != nil {
+				return nil, err
+			}
+			recur, ok := result.(recurSignal)
+			if !ok {
+				return result, nil
+			}
+			if len(recur.args) != len(names) {
+				return nil, evalError("recur", "loop has %d bindings, got %d values", len(names), len(recur.args))
+			}
+			values = recur.args
+		}
+	}
+}
+
+func (c *closureCompiler) recur(args []compiledFn) compiledFn {
+	return func(env *Env) (sexpr.SExpr, error) {
+		if _, err := env.Lookup(loopMarker); err != nil {
+			return nil, evalError("recur", "not inside a loop")
+		}
+		values, err := evalAll(args, env)
+		if err != nil {
+			return nil, err
+		}
+		return recurSignal{args: values}, nil
+	}
+}
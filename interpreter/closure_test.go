@@ -0,0 +1,149 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zylisp/lang/parser"
+)
+
+// newClosureEnv returns a global environment whose runtime uses the
+// closure compiler
+func newClosureEnv() *Env {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetEngine(ClosureCompiler)
+	return env
+}
+
+func TestClosureCompiler(t *testing.T) {
+	for _, tt := range engineTests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled := evalForms(t, newClosureEnv(), tt.inputs...)
+			if compiled.String() != tt.expected {
+				t.Errorf("closure compiler got %v, want %s", compiled, tt.expected)
+			}
+
+			tree := NewEnv(nil)
+			LoadPrimitives(tree)
+			if walked := evalForms(t, tree, tt.inputs...); walked.String() != compiled.String() {
+				t.Errorf("tree walker got %v, closure compiler got %v", walked, compiled)
+			}
+		})
+	}
+}
+
+func TestClosureCompilerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		expected string
+	}{
+		{"stack trace", []string{"(define (f x) (g x))", "(define (g x) (car x))", "(f 1)"}, "car: expected list, got 1\n  in g\n  called from f"},
+		{"arity", []string{"(define (f x) x)", "(f 1 2)"}, "requires 1 argument, got 2\n  in f"},
+		{"undefined", []string{"(define (f) nope)", "(f)"}, "undefined variable: nope\n  in f"},
+		{"malformed if", []string{"(define (f) (if 1 2))", "(f)"}, "if: requires 3 arguments, got 2\n  in f"},
+		{"recur outside loop", []string{"(recur 1)"}, "recur: not inside a loop"},
+		{"recur count", []string{"(loop ((i 0)) (recur 1 2))"}, "recur: loop has 1 bindings, got 2 values"},
+		{"recur across function", []string{"(loop ((i 0)) ((lambda () (recur 1))))"}, "recur: cannot cross a function boundary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newClosureEnv()
+			evalForms(t, env, tt.inputs[:len(tt.inputs)-1]...)
+			_, err := evalString(env, tt.inputs[len(tt.inputs)-1])
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("got:\n%v\nwant:\n%s", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClosureCompilerLimits(t *testing.T) {
+	env := newClosureEnv()
+	evalForms(t, env, "(define (down n) (if (= n 0) 0 (+ 1 (down (- n 1)))))")
+
+	env.Runtime().SetMaxDepth(100)
+	_, err := evalString(env, "(down 200)")
+	if !errors.Is(err, ErrDepth) {
+		t.Errorf("expected ErrDepth, got %v", err)
+	}
+
+	env.Runtime().SetMaxDepth(0)
+	env.Runtime().SetFuel(50)
+	_, err = evalString(env, "(loop ((i 0)) (recur (+ i 1)))")
+	if !errors.Is(err, ErrFuel) {
+		t.Errorf("expected ErrFuel, got %v", err)
+	}
+	env.Runtime().SetFuel(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	tokens, err := parser.Tokenize("(loop ((i 0)) (recur (+ i 1)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = EvalContext(ctx, CompileClosures(expr, env), env)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestCompiledIsAnExpression(t *testing.T) {
+	env := newClosureEnv()
+	tokens, err := parser.Tokenize("(+ 1 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compiled := CompileClosures(expr, env)
+	if compiled.String() != "(+ 1 2)" {
+		t.Errorf("String() = %s, want (+ 1 2)", compiled)
+	}
+	if !compiled.Equal(CompileClosures(expr, env)) {
+		t.Error("code compiled from the same expression is not Equal")
+	}
+
+	// Compiled code runs under any engine
+	env.Runtime().SetEngine(TreeWalker)
+	if got, err := Eval(compiled, env); err != nil || got.String() != "3" {
+		t.Errorf("Eval = %v, %v, want 3", got, err)
+	}
+}
+
+func TestClosureCompilerInterop(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (walked x) (* x 2))")
+
+	env.Runtime().SetEngine(BytecodeVM)
+	evalForms(t, env, "(define (vm x) (+ (walked x) 1))")
+
+	env.Runtime().SetEngine(ClosureCompiler)
+	evalForms(t, env, "(define (compiled x) (- (vm x) 3))")
+	if got := evalForms(t, env, "(compiled 20)"); got.String() != "38" {
+		t.Errorf("got %v, want 38", got)
+	}
+
+	for _, engine := range []Engine{TreeWalker, BytecodeVM} {
+		env.Runtime().SetEngine(engine)
+		if got := evalForms(t, env, "(apply compiled '(1))"); got.String() != "0" {
+			t.Errorf("%s: got %v, want 0", engine, got)
+		}
+	}
+}
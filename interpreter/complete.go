@@ -0,0 +1,31 @@
+package interpreter
+
+import (
+	"slices"
+	"strings"
+)
+
+// Complete returns the names starting with prefix that an expression
+// evaluated in e could refer to: the variables bound in e and its
+// ancestors, and the special forms. The names are sorted and each is
+// listed once.
+func (e *Env) Complete(prefix string) []string {
+	var names []string
+	for name := range specialForms {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	for env := e; env != nil; env = env.parent {
+		env.frame.mu.RLock()
+		for _, name := range env.frame.names {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		env.frame.mu.RUnlock()
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestComplete(t *testing.T) {
+	global := NewEnv(nil)
+	LoadPrimitives(global)
+	evalForms(t, global, "(define string-pad 1)", "(define car 2)")
+	local := NewEnv(global)
+	local.Define("strange", sexpr.Number{Value: 3})
+	local.Define("car", sexpr.Number{Value: 4})
+
+	tests := []struct {
+		env      *Env
+		prefix   string
+		expected []string
+	}{
+		{local, "string-t", []string{"string-trim"}},
+		{local, "stra", []string{"strange"}},
+		{global, "stra", nil},
+		{local, "string-p", []string{"string-pad"}},
+		{local, "car", []string{"car"}},
+		{local, "le", []string{"length", "let", "let*", "let-values", "letrec"}},
+		{local, "define-r", []string{"define-record-type"}},
+		{local, "no-such-", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := tt.env.Complete(tt.prefix); !slices.Equal(got, tt.expected) {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	all := global.Complete("")
+	if !slices.IsSorted(all) || !slices.Contains(all, "lambda") || !slices.Contains(all, "map") {
+		t.Errorf("Complete(\"\") is missing names or unsorted: %d names", len(all))
+	}
+}
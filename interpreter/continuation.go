@@ -0,0 +1,59 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// continuation is the escape point captured by call/cc. It is valid only
+// while the call/cc that created it is still running.
+type continuation struct {
+	done bool
+}
+
+// escape is the error that unwinds evaluation back to the call/cc that
+// created k, carrying the value passed to the continuation
+type escape struct {
+	k     *continuation
+	value sexpr.SExpr
+}
+
+func (e *escape) Error() string {
+	return "continuation invoked outside its call/cc"
+}
+
+// primCallCC handles (call/cc f). f is called with a continuation; when
+// the continuation is invoked with a value, call/cc returns that value
+// immediately. Continuations are escape-only: invoking one after its
+// call/cc has returned is an error.
+func primCallCC(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("call/cc", 1, 1, len(args))
+	}
+
+	k := &continuation{}
+	defer func() { k.done = true }()
+
+	resume := makePrimitive("continuation", func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if k.done {
+			return nil, evalError("continuation", "call/cc has already returned")
+		}
+		var value sexpr.SExpr = sexpr.Nil{}
+		switch len(args) {
+		case 0:
+		case 1:
+			value = args[0]
+		default:
+			return nil, arityError("continuation", 0, 1, len(args))
+		}
+		return nil, &escape{k: k, value: value}
+	})
+
+	result, err := apply(args[0], []sexpr.SExpr{resume}, env)
+	var esc *escape
+	if errors.As(err, &esc) && esc.k == k {
+		return esc.value, nil
+	}
+	return result, err
+}
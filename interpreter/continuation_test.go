@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestCallCC(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(call/cc (lambda (k) 1))", "1"},
+		{"(call/cc (lambda (k) (+ 1 (k 42))))", "42"},
+		{"(+ 1 (call/cc (lambda (k) (k 2))))", "3"},
+		{"(call-with-current-continuation (lambda (k) (k)))", "nil"},
+		// Escaping from nested calls
+		{"(begin (define (find-neg xs) (call/cc (lambda (return) (loop ((xs xs)) (if (null? xs) false (begin (if (< (car xs) 0) (return (car xs)) false) (recur (cdr xs)))))))) (find-neg (list 1 -2 3)))", "-2"},
+		// An inner continuation does not stop at an outer call/cc
+		{"(call/cc (lambda (outer) (+ 10 (call/cc (lambda (inner) (outer 1))))))", "1"},
+		// try does not catch escapes but runs finally
+		{"(let ((log (list))) (call/cc (lambda (k) (try (k 1) (catch e (set! log (cons 'caught log))) (finally (set! log (cons 'finally log)))))) log)", "(finally)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestCallCCErrors(t *testing.T) {
+	tests := []string{
+		"(call/cc)",
+		"(call/cc 1)",
+		"(call/cc (lambda (k) (k 1 2)))",
+		// Escape-only: the continuation is dead once call/cc returns
+		"(begin (define saved false) (call/cc (lambda (k) (set! saved k))) (saved 1))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
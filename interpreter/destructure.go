@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// A binding pattern is a symbol, or a list, dotted list or vector of
+// patterns. Lists match proper lists of the same length, a dotted tail
+// collects the remaining elements, and vectors match vectors.
+
+// checkPattern reports whether pattern is a valid binding pattern
+func checkPattern(pattern sexpr.SExpr) error {
+	switch p := pattern.(type) {
+	case sexpr.Symbol:
+		return nil
+	case sexpr.List, sexpr.Pair, sexpr.Vector:
+		ps, _ := listParts(p)
+		for _, elem := range ps.elems {
+			if err := checkPattern(elem); err != nil {
+				return err
+			}
+		}
+		if ps.tail != nil {
+			if _, ok := ps.tail.(sexpr.Symbol); !ok {
+				return evalError("", "pattern tail must be a symbol, got %v", ps.tail)
+			}
+		}
+		return nil
+	default:
+		return evalError("", "binding name must be a symbol or pattern, got %v", pattern)
+	}
+}
+
+// patternNames lists the symbols bound by pattern
+func patternNames(pattern sexpr.SExpr) []sexpr.Symbol {
+	var names []sexpr.Symbol
+	sexpr.Walk(pattern, func(x sexpr.SExpr) bool {
+		if sym, ok := x.(sexpr.Symbol); ok {
+			names = append(names, sym)
+		}
+		return true
+	})
+	return names
+}
+
+// bindPattern defines the symbols of pattern in env from the matching
+// parts of value
+func bindPattern(form string, env *Env, pattern, value sexpr.SExpr) error {
+	if sym, ok := pattern.(sexpr.Symbol); ok {
+		env.Define(sym.Name, value)
+		return nil
+	}
+
+	ps, _ := listParts(pattern)
+	vs, ok := listParts(value)
+	if !ok || ps.vector != vs.vector || vs.tail != nil ||
+		len(vs.elems) < len(ps.elems) || (ps.tail == nil && len(vs.elems) != len(ps.elems)) {
+		what := "list"
+		if ps.vector {
+			what = "vector"
+		}
+		size := fmt.Sprintf("%d", len(ps.elems))
+		if ps.tail != nil {
+			size = "at least " + size
+		}
+		return evalError(form, "cannot destructure %v: expected a %s of %s elements, got %v", pattern, what, size, value)
+	}
+
+	for i, p := range ps.elems {
+		if err := bindPattern(form, env, p, vs.elems[i]); err != nil {
+			return err
+		}
+	}
+	if ps.tail != nil {
+		rest := append([]sexpr.SExpr{}, vs.elems[len(ps.elems):]...)
+		env.Define(ps.tail.(sexpr.Symbol).Name, sexpr.List{Elements: rest})
+	}
+	return nil
+}
+
+// destructureParams rewrites a lambda parameter list containing patterns,
+// such as ((x y) z), into plain parameters. Each pattern is replaced by
+// a fresh parameter, and the body is wrapped in a let that destructures
+// it.
+func destructureParams(spec, body sexpr.SExpr, rt *Runtime) (sexpr.SExpr, sexpr.SExpr, error) {
+	ps, ok := listParts(spec)
+	if !ok || ps.vector {
+		return spec, body, nil
+	}
+
+	var bindings []sexpr.SExpr
+	params := make([]sexpr.SExpr, len(ps.elems))
+	for i, p := range ps.elems {
+		if _, ok := p.(sexpr.Symbol); ok {
+			params[i] = p
+			continue
+		}
+		if err := checkPattern(p); err != nil {
+			return nil, nil, fmt.Errorf("lambda: %w", err)
+		}
+		arg := rt.Gensym("#arg")
+		params[i] = arg
+		bindings = append(bindings, sexpr.List{Elements: []sexpr.SExpr{p, arg}})
+	}
+	if bindings == nil {
+		return spec, body, nil
+	}
+
+	var newSpec sexpr.SExpr = sexpr.List{Elements: params}
+	if ps.tail != nil {
+		newSpec = ps.tail
+		for i := len(params) - 1; i >= 0; i-- {
+			newSpec = sexpr.Cons(params[i], newSpec)
+		}
+	}
+	newBody := sexpr.List{Elements: []sexpr.SExpr{
+		sexpr.Symbol{Name: "let"}, sexpr.List{Elements: bindings}, body,
+	}}
+	return newSpec, newBody, nil
+}
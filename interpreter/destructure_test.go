@@ -0,0 +1,49 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(let (((a b) (list 1 2))) (+ a b))", "3"},
+		{"(let (((a (b c)) (list 1 (list 2 3)))) (list c b a))", "(3 2 1)"},
+		{"(let (((head . tail) (list 1 2 3))) tail)", "(2 3)"},
+		{"(let (((a . rest) (list 1))) rest)", "()"},
+		{"(let (([x y] [1 2])) (* x y))", "2"},
+		{"(let* (((a b) (list 1 2)) (c (+ a b))) c)", "3"},
+		{"(letrec (((even? odd?) (list (lambda (n) (if (= n 0) true (odd? (- n 1)))) (lambda (n) (if (= n 0) false (even? (- n 1))))))) (even? 10))", "true"},
+		{"((lambda ((x y)) (+ x y)) (list 3 4))", "7"},
+		{"((lambda (a (b c) . more) (list a b c more)) 1 (list 2 3) 4 5)", "(1 2 3 (4 5))"},
+		{"(begin (define (swap (a b)) (list b a)) (swap (list 1 2)))", "(2 1)"},
+		{"(loop (((i acc) (list 0 0))) (if (= i 3) acc (recur (list (+ i 1) (+ acc i)))))", "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []string{
+		"(let (((a b) (list 1))) a)",
+		"(let (((a b) (list 1 2 3))) a)",
+		"(let (((a b) 5)) a)",
+		"(let (((a . b) (list))) a)",
+		"(let (([a] (list 1))) a)",
+		"(let (((a 1) (list 1 1))) a)",
+		"((lambda ((x y)) x) (list 1))",
+		"(lambda ((x 2)) x)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package interpreter
+
+import (
+	"sync"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// docKey is the metadata key holding a function's docstring
+var docKey = sexpr.Keyword{Name: "doc"}
+
+// Doc returns the docstring of a function, primitive or macro, if it has
+// one. A lambda's docstring is the string before its body; a primitive's
+// starts with a line showing how it is called.
+func Doc(value sexpr.SExpr) (string, bool) {
+	if m, ok := value.(sexpr.Macro); ok {
+		value = m.Fn
+	}
+	meta := sexpr.MetaOf(value)
+	if meta == nil {
+		return "", false
+	}
+	doc, ok := meta.Get(docKey)
+	if !ok {
+		return "", false
+	}
+	s, ok := doc.(sexpr.String)
+	return s.Value, ok
+}
+
+// docMeta returns metadata recording doc, or nil if doc is empty
+func docMeta(doc string) *sexpr.Map {
+	if doc == "" {
+		return nil
+	}
+	m, _ := sexpr.Map{}.Assoc(docKey, sexpr.String{Value: doc})
+	return &m
+}
+
+// splitDoc returns a (lambda spec doc body) form without its docstring,
+// and the docstring. Any other form is returned as it is.
+func splitDoc(list sexpr.List) (sexpr.List, string) {
+	if len(list.Elements) != 4 {
+		return list, ""
+	}
+	doc, ok := list.Elements[2].(sexpr.String)
+	if !ok {
+		return list, ""
+	}
+	elems := []sexpr.SExpr{list.Elements[0], list.Elements[1], list.Elements[3]}
+	return sexpr.List{Elements: elems, Meta: list.Meta}, doc.Value
+}
+
+// primDoc handles (doc f), the docstring of a function, primitive or
+// macro, or nil if it has none. f may also be a symbol naming one, or
+// naming a special form.
+func primDoc(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("doc", 1, 1, len(args))
+	}
+
+	if sym, ok := args[0].(sexpr.Symbol); ok && specialForms[sym.Name] {
+		return sexpr.String{Value: specialFormDocs[sym.Name]}, nil
+	}
+	value, err := namedValue(args[0], env)
+	if err != nil {
+		return nil, err
+	}
+	if doc, ok := Doc(value); ok {
+		return sexpr.String{Value: doc}, nil
+	}
+	return sexpr.Nil{}, nil
+}
+
+// namedValue returns the value of x, or of the variable x names if it
+// is a symbol
+func namedValue(x sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if sym, ok := x.(sexpr.Symbol); ok {
+		return env.Lookup(sym.Name)
+	}
+	return x, nil
+}
+
+// primitiveSignature is what loadPrimitiveDocs attaches to a primitive
+type primitiveSignature struct {
+	meta   *sexpr.Map
+	params sexpr.SExpr
+}
+
+// primitiveSignatures are the docstrings of primitiveDocs and the
+// parameters their signatures show, worked out once
+var primitiveSignatures = sync.OnceValue(func() map[string]primitiveSignature {
+	sigs := make(map[string]primitiveSignature, len(primitiveDocs))
+	for name, doc := range primitiveDocs {
+		sigs[name] = primitiveSignature{meta: docMeta(doc), params: signatureParams(doc)}
+	}
+	return sigs
+})
+
+// loadPrimitiveDocs attaches the docstrings and parameters of
+// primitiveDocs to the primitives defined in env
+func loadPrimitiveDocs(env *Env) {
+	for name, sig := range primitiveSignatures() {
+		value, err := env.Lookup(name)
+		if err != nil {
+			continue
+		}
+		if p, ok := value.(sexpr.Primitive); ok && p.Name == name {
+			p.Meta, p.Params = sig.meta, sig.params
+			env.Define(name, p)
+		}
+	}
+}
+
+// primitiveDocs documents the standard primitives. Each entry starts
+// with a line showing how the primitive is called: optional arguments
+// are in brackets and ... marks one that may be repeated, so [x...]
+// stands for any number of arguments.
+var primitiveDocs = map[string]string{
+	// Arithmetic
+	"+":         "(+ [n...])\nThe sum of the numbers, 0 if there are none.",
+	"-":         "(- n [m...])\nn minus each m in turn, or the negation of n alone.",
+	"*":         "(* [n...])\nThe product of the numbers, 1 if there are none.",
+	"/":         "(/ n [m...])\nn divided by each m in turn, or the reciprocal of n alone.",
+	"quotient":  "(quotient a b)\na / b truncated toward zero.",
+	"remainder": "(remainder a b)\nThe remainder of (quotient a b), with the sign of a.",
+	"modulo":    "(modulo a b)\nThe remainder of a / b floored, with the sign of b.",
+	"min":       "(min a [b...])\nThe smallest of the arguments.",
+	"max":       "(max a [b...])\nThe largest of the arguments.",
+	"abs":       "(abs n)\nThe absolute value of n.",
+	"gcd":       "(gcd [n...])\nThe greatest common divisor of integers, 0 if there are none.",
+	"lcm":       "(lcm [n...])\nThe least common multiple of integers, 1 if there are none.",
+
+	// Comparison
+	"=":      "(= a b)\nWhether two numbers are equal.",
+	"<":      "(< a b)\nWhether a is less than b.",
+	">":      "(> a b)\nWhether a is greater than b.",
+	"<=":     "(<= a b)\nWhether a is less than or equal to b.",
+	">=":     "(>= a b)\nWhether a is greater than or equal to b.",
+	"eq?":    "(eq? a b)\nWhether a and b are the same object.",
+	"eqv?":   "(eqv? a b)\nLike eq?, but numbers of the same exactness are compared by value.",
+	"equal?": "(equal? a b)\nWhether a and b are structurally equal.",
+
+	// Lists
+	"list":     "(list [x...])\nA list of the arguments.",
+	"car":      "(car pair)\nThe first element of a pair or list.",
+	"cdr":      "(cdr pair)\nThe rest of a pair or list after its first element.",
+	"cons":     "(cons x tail)\nA pair of x and tail; a list tail gives a list.",
+	"length":   "(length list)\nThe number of elements in a list.",
+	"append":   "(append [list...] [tail])\nThe lists joined in order, ending with tail.",
+	"reverse":  "(reverse list)\nA list of the elements of list in reverse order.",
+	"nth":      "(nth list n)\nThe element of list at index n.",
+	"list-ref": "(list-ref list n)\nThe element of list at index n.",
+	"last":     "(last list)\nThe last element of a non-empty list.",
+	"take":     "(take list n)\nThe first n elements of list.",
+	"drop":     "(drop list n)\nlist without its first n elements.",
+	"flatten":  "(flatten list)\nThe elements of list and of the lists nested in it, as one list.",
+	"member":   "(member x list [equal])\nThe tail of list starting at the first element equal to x, or false.",
+	"memq":     "(memq x list)\nThe tail of list starting at the first element eq? to x, or false.",
+	"range":    "(range [start] end [step])\nThe numbers from start, 0 by default, up to end by step.",
+	"iota":     "(iota count [start [step]])\nA list of count numbers from start, 0 by default, by step.",
+	"assq":     "(assq key alist)\nThe first entry of alist whose key is eq? to key, or false.",
+	"assoc": "(assoc x y [z...])\nWith a map or vector x and values z, x with each key y bound to the value z after it. " +
+		"Otherwise the first entry of the association list y whose key is equal to x, or false; z, if given, compares keys.",
+	"alist->map": "(alist->map alist)\nA map of the bindings of an association list.",
+	"map":        "(map f list...)\nThe results of calling f on the elements of the lists in turn.",
+	"for-each":   "(for-each f list...)\nCalls f on the elements of the lists in turn, for its effects.",
+	"filter":     "(filter pred list)\nThe elements of list for which pred is true.",
+	"reduce":     "(reduce f [init] list)\nCombines the elements of list from the left with (f acc elem).",
+	"fold-left":  "(fold-left f init list...)\nCombines the elements of the lists from the left with (f acc elem...).",
+	"fold-right": "(fold-right f init list...)\nCombines the elements of the lists from the right with (f elem... acc).",
+	"sort":       "(sort list [less?])\nA stable sort of list, in ascending order by default.",
+	"sort-by":    "(sort-by key list [less?])\nSorts list by the result of calling key on each element.",
+
+	// Predicates
+	"number?":    "(number? x)\nWhether x is a number.",
+	"symbol?":    "(symbol? x)\nWhether x is a symbol.",
+	"list?":      "(list? x)\nWhether x is a proper list.",
+	"null?":      "(null? x)\nWhether x is the empty list or nil.",
+	"go-value?":  "(go-value? x)\nWhether x is a wrapped Go value.",
+	"promise?":   "(promise? x)\nWhether x is a promise.",
+	"boolean?":   "(boolean? x)\nWhether x is true or false.",
+	"string?":    "(string? x)\nWhether x is a string.",
+	"procedure?": "(procedure? x)\nWhether x can be called as a function.",
+	"type-of":    "(type-of x)\nA symbol naming the type of x.",
+	"not":        "(not x)\nWhether x is false or nil.",
+	"zero?":      "(zero? n)\nWhether n is zero.",
+	"positive?":  "(positive? n)\nWhether n is greater than zero.",
+	"negative?":  "(negative? n)\nWhether n is less than zero.",
+	"even?":      "(even? n)\nWhether the integer n is even.",
+	"odd?":       "(odd? n)\nWhether the integer n is odd.",
+
+	// Control
+	"force":        "(force promise)\nThe value of a promise, computing it the first time.",
+	"apply":        "(apply f [arg...] list)\nCalls f with the arguments followed by the elements of list.",
+	"eval":         "(eval expr [env])\nEvaluates expr in env, by default the environment of the call.",
+	"values":       "(values [v...])\nReturns any number of values.",
+	"environment?": "(environment? x)\nWhether x is an environment.",
+
+	"call-with-values":               "(call-with-values producer consumer)\nCalls consumer with the values returned by producer.",
+	"current-environment":            "(current-environment)\nThe environment of the call.",
+	"global-environment":             "(global-environment)\nThe global environment.",
+	"call/cc":                        "(call/cc f)\nCalls f with the current continuation.",
+	"call-with-current-continuation": "(call-with-current-continuation f)\nCalls f with the current continuation.",
+
+	// Loading, macros and metadata
+	"load":          "(load path)\nEvaluates the file at path in the environment of the call.",
+	"require":       "(require name)\nLoads name.zy from the search path once, returning its module.",
+	"gensym":        "(gensym [prefix])\nA new symbol that is distinct from every other.",
+	"macroexpand-1": "(macroexpand-1 form)\nform with its outermost macro call expanded once.",
+	"macroexpand":   "(macroexpand form)\nform with its outermost macro calls expanded until none is left.",
+	"meta":          "(meta x)\nThe metadata map of x, or nil.",
+	"with-meta":     "(with-meta x meta)\nA copy of x carrying the metadata meta.",
+	"doc":           "(doc f)\nThe docstring of a function or macro, or of the one or the special form a symbol names.",
+	"arity":         "(arity f)\nA map of the least, :min, and greatest, :max, number of arguments f accepts.",
+	"params":        "(params f)\nThe parameter list of a function or macro.",
+
+	// Maps
+	"hash-map":  "(hash-map [key value...])\nA map of the keys to the values after them.",
+	"map?":      "(map? x)\nWhether x is a map.",
+	"get":       "(get coll key [default])\nThe value of key in a map or vector, or default.",
+	"dissoc":    "(dissoc map [key...])\nmap without the given keys.",
+	"contains?": "(contains? coll key)\nWhether a map, set or vector has key.",
+	"keys":      "(keys map)\nThe keys of a map, in insertion order.",
+	"vals":      "(vals map)\nThe values of a map, in insertion order.",
+	"count":     "(count coll)\nThe number of entries or elements in a collection or string.",
+	"merge":     "(merge [map...])\nA map of the entries of every map; later maps win.",
+	"get-in":    "(get-in coll path [default])\nThe value at the end of path through nested maps and vectors.",
+	"assoc-in":  "(assoc-in coll path value)\ncoll with value bound at the end of path.",
+
+	// Vectors
+	"vector?":       "(vector? x)\nWhether x is a vector.",
+	"vector":        "(vector [x...])\nA vector of the arguments.",
+	"make-vector":   "(make-vector n [fill])\nA mutable vector of n elements that are all fill, or nil.",
+	"vector-length": "(vector-length v)\nThe number of elements in a vector.",
+	"vector-ref":    "(vector-ref v i)\nThe element of v at index i.",
+	"vector-assoc":  "(vector-assoc v i x)\nv with the element at index i replaced by x.",
+	"vector-set!":   "(vector-set! v i x)\nReplaces the element at index i of a mutable vector by x.",
+	"vector->list":  "(vector->list v)\nA list of the elements of a vector.",
+	"list->vector":  "(list->vector list)\nA vector of the elements of a list.",
+
+	// Errors
+	"make-error":    "(make-error kind message [data])\nAn error value.",
+	"error?":        "(error? x)\nWhether x is an error.",
+	"error-kind":    "(error-kind e)\nThe kind of an error, a symbol.",
+	"error-message": "(error-message e)\nThe message of an error.",
+	"error-data":    "(error-data e)\nThe data of an error.",
+	"raise":         "(raise value)\nAborts evaluation with value, usually an error.",
+	"error":         "(error message [data...])\nRaises an error of kind error.",
+
+	// Ports
+	"current-input-port":  "(current-input-port)\nThe port read by default.",
+	"current-output-port": "(current-output-port)\nThe port written by default.",
+	"current-error-port":  "(current-error-port)\nThe port errors of go forms are reported to.",
+	"port?":               "(port? x)\nWhether x is a port.",
+	"input-port?":         "(input-port? x)\nWhether x is a port that can be read.",
+	"output-port?":        "(output-port? x)\nWhether x is a port that can be written.",
+	"open-input-string":   "(open-input-string s)\nAn input port reading the string s.",
+	"open-output-string":  "(open-output-string)\nAn output port collecting a string.",
+	"get-output-string":   "(get-output-string port)\nThe text written so far to a string output port.",
+	"print":               "(print [value...])\nWrites the values to the current output port.",
+	"println":             "(println [value...])\nprint followed by a newline.",
+	"display":             "(display value [port])\nWrites the display form of value.",
+	"newline":             "(newline [port])\nWrites a newline.",
+	"read-line":           "(read-line [port])\nThe next line without its line ending, or nil at the end.",
+	"read-char":           "(read-char [port])\nThe next character, or nil at the end.",
+	"write-string":        "(write-string s [port])\nWrites the string s.",
+	"close-port":          "(close-port port)\nCloses a port.",
+	"format":              "(format dest fmt [arg...])\nFormats the arguments with the ~ directives of fmt.",
+	"read":                "(read s)\nThe first expression in the string s as data, or nil.",
+	"read-port":           "(read-port [port])\nThe next expression read from port as data, or nil at the end.",
+
+	// Files, the OS and processes
+	"slurp":              "(slurp path)\nThe contents of a file as a string.",
+	"spit":               "(spit path value [append?])\nWrites the display form of value to a file.",
+	"open-input-file":    "(open-input-file path)\nAn input port reading a file.",
+	"open-output-file":   "(open-output-file path [append?])\nAn output port writing a file.",
+	"getenv":             "(getenv name)\nThe value of an environment variable, or nil.",
+	"setenv":             "(setenv name value)\nSets an environment variable.",
+	"exit":               "(exit [code])\nEnds the program with an exit code, 0 by default.",
+	"command-line-args":  "(command-line-args)\nThe list of the program's arguments.",
+	"current-directory":  "(current-directory)\nThe working directory of the process.",
+	"run-command":        "(run-command command [arg...])\nRuns a command, returning a map of :exit, :out and :err.",
+	"open-input-command": "(open-input-command command [arg...])\nAn input port reading the output of a command.",
+
+	// JSON and bytes
+	"json->sexpr":   "(json->sexpr s)\nThe value of a JSON string.",
+	"sexpr->json":   "(sexpr->json value)\nThe JSON encoding of value.",
+	"bytes?":        "(bytes? x)\nWhether x is a byte vector.",
+	"bytes":         "(bytes [b...])\nA byte vector of the arguments.",
+	"make-bytes":    "(make-bytes n [fill])\nA byte vector of n bytes that are all fill, or 0.",
+	"bytes-length":  "(bytes-length b)\nThe number of bytes in a byte vector.",
+	"bytes-ref":     "(bytes-ref b i)\nThe byte at index i.",
+	"bytes-slice":   "(bytes-slice b start [end])\nA copy of the bytes from start up to end.",
+	"bytes-append":  "(bytes-append [b...])\nThe byte vectors joined in order.",
+	"bytes->list":   "(bytes->list b)\nA list of the bytes of a byte vector.",
+	"list->bytes":   "(list->bytes list)\nA byte vector of a list of bytes.",
+	"string->bytes": "(string->bytes s)\nThe UTF-8 encoding of a string.",
+	"bytes->string": "(bytes->string b)\nThe string encoded in UTF-8 by a byte vector.",
+
+	// Math and time
+	"sqrt":             "(sqrt x)\nThe square root of x.",
+	"expt":             "(expt base power)\nbase raised to power.",
+	"exp":              "(exp x)\ne raised to x.",
+	"log":              "(log x [base])\nThe logarithm of x, natural by default.",
+	"sin":              "(sin x)\nThe sine of x radians.",
+	"cos":              "(cos x)\nThe cosine of x radians.",
+	"tan":              "(tan x)\nThe tangent of x radians.",
+	"floor":            "(floor x)\nThe largest integer not greater than x.",
+	"ceiling":          "(ceiling x)\nThe smallest integer not less than x.",
+	"round":            "(round x)\nx rounded to the nearest integer, ties to even.",
+	"truncate":         "(truncate x)\nx truncated toward zero.",
+	"random":           "(random n)\nA random number from 0 up to n.",
+	"random-float":     "(random-float)\nA random float from 0 up to 1.",
+	"random-seed!":     "(random-seed! seed)\nReseeds the random number source.",
+	"current-time":     "(current-time)\nThe wall clock time in milliseconds since the Unix epoch.",
+	"monotonic-millis": "(monotonic-millis)\nMilliseconds elapsed by a clock that never goes backwards.",
+
+	// Strings and characters
+	"str":              "(str [value...])\nThe display forms of the arguments joined together.",
+	"string-length":    "(string-length s)\nThe number of characters in a string.",
+	"string-append":    "(string-append [s...])\nThe strings joined in order.",
+	"substring":        "(substring s start [end])\nThe characters of s from start up to end.",
+	"string-split":     "(string-split s [sep])\nThe parts of s around each sep, or around whitespace.",
+	"string-join":      "(string-join strings [sep])\nThe strings joined with sep between them.",
+	"string-trim":      "(string-trim s)\ns without leading and trailing whitespace.",
+	"string-contains?": "(string-contains? s sub)\nWhether s contains sub.",
+	"string-index":     "(string-index s sub)\nThe index of the first sub in s, or nil.",
+	"string-upcase":    "(string-upcase s)\ns in upper case.",
+	"string-downcase":  "(string-downcase s)\ns in lower case.",
+	"string->number":   "(string->number s [radix])\nThe number written in s, or nil.",
+	"number->string":   "(number->string n [radix])\nThe digits of n.",
+	"string->symbol":   "(string->symbol s)\nThe symbol named s.",
+	"symbol->string":   "(symbol->string sym)\nThe name of a symbol.",
+	"symbol->keyword":  "(symbol->keyword sym)\nThe keyword with the name of a symbol.",
+	"keyword->symbol":  "(keyword->symbol k)\nThe symbol with the name of a keyword.",
+	"char?":            "(char? x)\nWhether x is a character.",
+	"char-alphabetic?": "(char-alphabetic? c)\nWhether c is a letter.",
+	"char-numeric?":    "(char-numeric? c)\nWhether c is a decimal digit.",
+	"char-whitespace?": "(char-whitespace? c)\nWhether c is white space.",
+	"char-upcase":      "(char-upcase c)\nc in upper case.",
+	"char-downcase":    "(char-downcase c)\nc in lower case.",
+	"char->integer":    "(char->integer c)\nThe Unicode code point of c.",
+	"integer->char":    "(integer->char n)\nThe character with the Unicode code point n.",
+	"string->list":     "(string->list s)\nA list of the characters of s.",
+	"list->string":     "(list->string list)\nThe string of a list of characters.",
+
+	// Concurrency
+	"chan":             "(chan [size])\nA channel buffering size values, unbuffered by default.",
+	"channel?":         "(channel? x)\nWhether x is a channel.",
+	"send!":            "(send! ch value)\nSends value on a channel, blocking until it is taken or buffered.",
+	"recv!":            "(recv! ch)\nThe next value from a channel, or nil once it is closed and drained.",
+	"close!":           "(close! ch)\nCloses a channel.",
+	"spawn":            "(spawn f [arg...])\nAn actor running f with the arguments.",
+	"supervise":        "(supervise restarts f [arg...])\nLike spawn, but restarts f up to restarts times when it fails.",
+	"send":             "(send actor msg)\nQueues msg in an actor's mailbox.",
+	"self":             "(self)\nThe current actor.",
+	"actor?":           "(actor? x)\nWhether x is an actor.",
+	"actor-alive?":     "(actor-alive? actor)\nWhether an actor is still running.",
+	"atom":             "(atom value)\nA new atom holding value.",
+	"atom?":            "(atom? x)\nWhether x is an atom.",
+	"deref":            "(deref ref [timeout-ms default])\nThe value of an atom, or the result of a future.",
+	"reset!":           "(reset! atom value)\nSets the value of an atom.",
+	"swap!":            "(swap! atom f [arg...])\nSets an atom to (f value arg...), returning the new value.",
+	"compare-and-set!": "(compare-and-set! atom old new)\nSets an atom to new if its value is equal to old.",
+	"await":            "(await future)\nWaits for the result of a future.",
+	"future?":          "(future? x)\nWhether x is a future.",
+	"future-done?":     "(future-done? future)\nWhether a future has finished.",
+	"yield":            "(yield value)\nProduces the next value of the running generator.",
+	"next":             "(next generator [default])\nThe next value of a generator, or default once it is exhausted.",
+	"generator?":       "(generator? x)\nWhether x is a generator.",
+}
+
+// specialFormDocs documents the special forms, in the style of
+// primitiveDocs
+var specialFormDocs = map[string]string{
+	// Definitions and assignment
+	"define": "(define name value) or (define (name params...) [doc] body...)\n" +
+		"Binds name to value, or to a function, in the current environment.",
+	"set!":   "(set! name value)\nChanges the nearest existing binding of name to value.",
+	"lambda": "(lambda params [doc] body...)\nA function of params, which may be dotted or a single symbol collecting all arguments.",
+	"define-record-type": "(define-record-type name (constructor field...) predicate (field accessor [modifier])...)\n" +
+		"Defines a record type with its constructor, predicate, accessors and modifiers.",
+
+	// Quoting
+	"quote":              "(quote x)\nx, unevaluated; 'x is short for it.",
+	"quasiquote":         "(quasiquote template)\ntemplate, unevaluated except for unquote and unquote-splicing forms; `x is short for it.",
+	"string-interpolate": "(string-interpolate [x...])\nThe values joined like str; interpolated strings #\"...\" read as it.",
+
+	// Macros
+	"defmacro":      "(defmacro name params [doc] body...)\nDefines a macro that rewrites its unevaluated argument forms.",
+	"define-syntax": "(define-syntax name transformer)\nBinds name to a macro such as one built by syntax-rules.",
+	"syntax-rules":  "(syntax-rules [ellipsis] (literals...) (pattern template)...)\nA macro that rewrites a use with the first rule whose pattern matches it.",
+
+	// Conditionals
+	"if":     "(if test then else)\nthen if test is true, otherwise else.",
+	"cond":   "(cond (test expr...)... [(else expr...)])\nThe expressions of the first clause whose test is true; (test => f) calls f with it.",
+	"case":   "(case key ((datum...) expr...)... [(else expr...)])\nThe expressions of the first clause with a datum equal to key.",
+	"match":  "(match x (pattern [:when guard] body...)...)\nThe body of the first clause whose pattern matches x, with its variables bound.",
+	"when":   "(when test body...)\nThe value of body if test is true, otherwise nil.",
+	"unless": "(unless test body...)\nThe value of body if test is false, otherwise nil.",
+	"and":    "(and [x...])\nThe first false value, or the last value, or true if there are none.",
+	"or":     "(or [x...])\nThe first true value, or the last value, or false if there are none.",
+
+	// Sequencing and binding
+	"begin":      "(begin [x...])\nEvaluates the expressions in order, returning the last value.",
+	"let":        "(let ((name value)...) body...)\nbody with the names bound to values evaluated outside their scope.",
+	"let*":       "(let* ((name value)...) body...)\nLike let, but each value can refer to the names before it.",
+	"letrec":     "(letrec ((name value)...) body...)\nLike let, but every name is in scope in every value.",
+	"let-values": "(let-values ((params expr)...) body...)\nbody with the multiple values of each expr bound to params.",
+	"loop":       "(loop ((name init)...) body...)\nbody with the names bound like let, run again by recur.",
+	"recur":      "(recur [value...])\nRuns the enclosing loop again with its names bound to the values.",
+
+	// Errors
+	"try":    "(try body... [(catch name handler...)] [(finally cleanup...)])\nbody, or handler with name bound to the error if body fails; cleanup always runs.",
+	"assert": "(assert x [message])\nRaises an assertion-error if x is false or nil.",
+
+	// Concurrency and laziness
+	"delay":     "(delay x)\nA promise that evaluates x when first forced.",
+	"go":        "(go x)\nEvaluates x in a new goroutine and returns nil at once.",
+	"future":    "(future x)\nA future for the value of x, evaluated in a new goroutine.",
+	"generator": "(generator body...)\nA generator that runs body, producing each value passed to yield.",
+	"receive":   "(receive (pattern [:when guard] body...)... [(after ms body...)])\nThe body of the first clause matching a message in the actor's mailbox.",
+
+	// Modules
+	"module": "(module name [(export name...)] body...)\nA module of the definitions in body, whose exports are named module/name.",
+	"import": "(import spec...)\nBinds the exports of the module each spec names, or only those listed in (module name...).",
+
+	// Timing
+	"time": "(time x)\nTwo values: the value of x and the milliseconds it took.",
+}
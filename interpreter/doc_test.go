@@ -0,0 +1,141 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestDocstrings(t *testing.T) {
+	for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+		t.Run(engine.String(), func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			env.Runtime().SetEngine(engine)
+			evalForms(t, env,
+				`(define (square x) "Multiplies x by itself." (* x x))`,
+				`(define (greeting) "hello")`,
+				`(define cube (lambda (x) "Cubes x." (* x x x)))`,
+				`(define (plain x) x)`,
+				`(defmacro my-when (test . body) "Runs body when test is true." (list 'if test (cons 'begin body) false))`,
+				"(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp)))))",
+				"(define-syntax my-or (syntax-rules () ((_) false) ((_ e . rest) (let ((t e)) (if t t (my-or . rest))))))",
+			)
+
+			tests := []struct {
+				input    string
+				expected string
+			}{
+				{"(square 3)", "9"},
+				{"(greeting)", `"hello"`},
+				{"(cube 2)", "8"},
+				{"(doc square)", `"Multiplies x by itself."`},
+				{"(doc 'square)", `"Multiplies x by itself."`},
+				{"(doc cube)", `"Cubes x."`},
+				{"(doc 'my-when)", `"Runs body when test is true."`},
+				{"(doc greeting)", "nil"},
+				{"(doc plain)", "nil"},
+				{"(doc 5)", "nil"},
+				{`(doc (lambda () "only a body"))`, "nil"},
+				{`(doc (let ((f (lambda (x) "Inner." x))) f))`, `"Inner."`},
+				{"(doc 'swap!)", `"(swap! a b)"`},
+				{"(doc 'my-or)", `"(my-or)\n(my-or e . rest)"`},
+				{"(doc 'when)", `"(when test body...)\nThe value of body if test is true, otherwise nil."`},
+			}
+			for _, tt := range tests {
+				got, err := evalString(env, tt.input)
+				if err != nil {
+					t.Errorf("%s: %v", tt.input, err)
+					continue
+				}
+				if got.String() != tt.expected {
+					t.Errorf("%s: got %v, want %s", tt.input, got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDocErrors(t *testing.T) {
+	tests := []string{
+		"(doc)",
+		"(doc car cdr)",
+		"(doc 'undefined-name)",
+		`(lambda (x) "doc" x x)`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestPrimitiveDocs(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	for _, name := range env.frame.names {
+		value, err := env.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := value.(sexpr.Primitive); !ok {
+			continue
+		}
+		doc, ok := Doc(value)
+		if !ok {
+			t.Errorf("%s has no docstring", name)
+			continue
+		}
+		if !strings.HasPrefix(doc, "("+name) {
+			t.Errorf("%s: docstring %q does not start with its signature", name, doc)
+		}
+	}
+	for name := range primitiveDocs {
+		if _, err := env.Lookup(name); err != nil {
+			t.Errorf("docstring for undefined primitive %s", name)
+		}
+	}
+
+	got := evalForms(t, env, "(doc car)")
+	if !strings.HasPrefix(got.String(), `"(car pair)`) {
+		t.Errorf("(doc car) = %v", got)
+	}
+}
+
+func TestSpecialFormDocs(t *testing.T) {
+	for name := range specialForms {
+		doc, ok := specialFormDocs[name]
+		if !ok {
+			t.Errorf("%s has no docstring", name)
+			continue
+		}
+		if !strings.HasPrefix(doc, "("+name+" ") && !strings.HasPrefix(doc, "("+name+")") {
+			t.Errorf("%s: docstring %q does not start with its signature", name, doc)
+		}
+	}
+	for name := range specialFormDocs {
+		if !specialForms[name] {
+			t.Errorf("docstring for unknown special form %s", name)
+		}
+	}
+}
+
+func TestDocGoAPI(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, `(define (f) "Does nothing." 0)`)
+
+	f, err := env.Lookup("f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc, ok := Doc(f); !ok || doc != "Does nothing." {
+		t.Errorf("Doc(f) = %q, %v", doc, ok)
+	}
+	if _, ok := Doc(sexpr.Number{Value: 1}); ok {
+		t.Error("Doc(1) reported a docstring")
+	}
+}
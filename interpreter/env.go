@@ -1,34 +1,68 @@
 package interpreter
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/zylisp/lang/sexpr"
 )
 
-// Env represents a lexical environment for variable bindings
+// Env represents a lexical environment for variable bindings. It is safe
+// for concurrent use by goroutines started with go.
 type Env struct {
-	bindings map[string]sexpr.SExpr
-	parent   *Env
+	frame     *frame // bindings, shared with views of this environment
+	parent    *Env
+	runtime   *Runtime
+	depth     int             // number of active calls when this environment was entered
+	ctx       context.Context // cancels evaluation, if set
+	session   context.Context // cancels work that outlives the evaluation, if not ctx
+	actor     *Actor          // actor evaluation runs in, if spawned
+	generator *Generator      // generator whose body is running, for yield
+	direct    bool            // evaluate lists directly, for forms compiled code falls back on
 }
 
-// NewEnv creates a new environment with an optional parent
+// NewEnv creates a new environment with an optional parent. A child
+// environment shares its parent's runtime; a root environment gets a
+// fresh one.
 func NewEnv(parent *Env) *Env {
-	return &Env{
-		bindings: make(map[string]sexpr.SExpr),
-		parent:   parent,
+	return newEnv(parent, 0)
+}
+
+// newEnv creates an environment with room for size bindings
+func newEnv(parent *Env, size int) *Env {
+	env := &Env{
+		frame:  newFrame(size),
+		parent: parent,
+	}
+	if parent != nil {
+		env.runtime = parent.runtime
+		env.runtime.memory.Add(frameSize)
+		env.depth = parent.depth
+		env.ctx = parent.ctx
+		env.session = parent.session
+		env.actor = parent.actor
+		env.generator = parent.generator
+		env.direct = parent.direct
+	} else {
+		env.runtime = newRuntime()
 	}
+	return env
+}
+
+// Runtime returns the interpreter-wide state shared with this
+// environment's ancestors and descendants
+func (e *Env) Runtime() *Runtime {
+	return e.runtime
 }
 
 // Define binds a value to a name in this environment
 func (e *Env) Define(name string, value sexpr.SExpr) {
-	e.bindings[name] = value
+	e.frame.define(name, value)
 }
 
 // Set updates an existing binding, searching parent environments
 func (e *Env) Set(name string, value sexpr.SExpr) error {
-	if _, ok := e.bindings[name]; ok {
-		e.bindings[name] = value
+	if e.frame.set(name, value) {
 		return nil
 	}
 
@@ -36,12 +70,12 @@ func (e *Env) Set(name string, value sexpr.SExpr) error {
 		return e.parent.Set(name, value)
 	}
 
-	return fmt.Errorf("undefined variable: %s", name)
+	return evalError("", "undefined variable: %s", name)
 }
 
 // Lookup finds a value by name, searching parent environments
 func (e *Env) Lookup(name string) (sexpr.SExpr, error) {
-	if value, ok := e.bindings[name]; ok {
+	if value, ok := e.frame.lookup(name); ok {
 		return value, nil
 	}
 
@@ -49,7 +83,108 @@ func (e *Env) Lookup(name string) (sexpr.SExpr, error) {
 		return e.parent.Lookup(name)
 	}
 
-	return nil, fmt.Errorf("undefined variable: %s", name)
+	return nil, evalError("", "undefined variable: %s", name)
+}
+
+// binding returns the frame that binds name as seen from e, or nil
+func (e *Env) binding(name string) *frame {
+	for ; e != nil; e = e.parent {
+		if e.frame.find(name) >= 0 {
+			return e.frame
+		}
+	}
+	return nil
+}
+
+// Context returns the context evaluation in env is running under, for
+// primitives that block or run for a long time
+func (e *Env) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// background returns a view of env for work that outlives the current
+// evaluation, such as the body of a go form, which runs under the
+// session's context rather than the evaluation's
+func (e *Env) background() *Env {
+	view := *e
+	if e.session != nil {
+		view.ctx = e.session
+	}
+	return &view
+}
+
+// interrupted returns an error if the evaluation's context is done
+func (e *Env) interrupted() error {
+	if e.ctx == nil {
+		return nil
+	}
+	if err := e.ctx.Err(); err != nil {
+		return fmt.Errorf("evaluation interrupted: %w", err)
+	}
+	return nil
+}
+
+// pollInterval is how many iterations a primitive's loop runs between
+// checks of the evaluation's context
+const pollInterval = 1024
+
+// poll accounts for iteration i, counting from 0, of a loop in a
+// primitive whose length scales with its input. Each iteration uses one
+// step of fuel; once every pollInterval iterations it also fails if the
+// evaluation's context is done.
+func (e *Env) poll(i int) error {
+	if err := e.runtime.step(); err != nil {
+		return err
+	}
+	if i%pollInterval != 0 {
+		return nil
+	}
+	return e.interrupted()
+}
+
+// deeper returns a view of env for evaluating one call deeper. The view
+// shares env's bindings; it fails once the runtime's maximum depth is
+// exceeded or the evaluation's context is done.
+func (e *Env) deeper() (*Env, error) {
+	if err := e.checkCall(); err != nil {
+		return nil, err
+	}
+	view := *e
+	view.depth++
+	return &view, nil
+}
+
+// checkCall fails if evaluation may not go one call deeper than env
+func (e *Env) checkCall() error {
+	if err := e.interrupted(); err != nil {
+		return err
+	}
+	if max := e.runtime.MaxDepth(); max > 0 && e.depth >= max {
+		return &LimitError{Limit: ErrDepth, Max: int64(max)}
+	}
+	return nil
+}
+
+// Global returns the root environment this environment descends from
+func (e *Env) Global() *Env {
+	for e.parent != nil {
+		e = e.parent
+	}
+	return e
+}
+
+// String makes environments first-class values
+func (e *Env) String() string {
+	return "#<environment>"
+}
+
+// Equal reports whether other is the same environment
+func (e *Env) Equal(other sexpr.SExpr) bool {
+	o, ok := other.(*Env)
+	return ok && e == o
 }
 
 // Extend creates a child environment
@@ -110,3 +110,15 @@ func TestEnvExtend(t *testing.T) {
 		t.Errorf("got %v, want 42", value)
 	}
 }
+
+func TestEnvGlobal(t *testing.T) {
+	root := NewEnv(nil)
+	child := root.Extend().Extend()
+
+	if child.Global() != root {
+		t.Error("Global did not return the root environment")
+	}
+	if !child.Equal(child) || child.Equal(root) {
+		t.Error("environments should only equal themselves")
+	}
+}
@@ -0,0 +1,306 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Sentinel errors for use with errors.Is. Every EvalError, ArityError
+// and TypeError is ErrEval; the latter two are also ErrArity and ErrType.
+var (
+	ErrEval  = errors.New("evaluation error")
+	ErrArity = errors.New("wrong number of arguments")
+	ErrType  = errors.New("wrong argument type")
+)
+
+// Sentinel errors for the Limit of a LimitError: ErrDepth for too deeply
+// nested calls, ErrFuel for exhausting the runtime's step budget and
+// ErrMemory for exceeding its allocation budget
+var (
+	ErrDepth  = errors.New("maximum recursion depth exceeded")
+	ErrFuel   = errors.New("evaluation step budget exhausted")
+	ErrMemory = errors.New("memory limit exceeded")
+)
+
+// LimitError reports that evaluation exceeded one of the runtime's
+// resource limits. Limit is the sentinel error for the limit, such as
+// ErrDepth, and errors.Is matches it.
+type LimitError struct {
+	Location
+	Limit error
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %d)", e.Limit, e.Max)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == e.Limit
+}
+
+// Location identifies where an error arose. The evaluator fills it in
+// with the innermost list whose evaluation failed, and that list's
+// source position when it has one.
+type Location struct {
+	Expr sexpr.SExpr
+	Pos  *sexpr.Position
+}
+
+func (l *Location) location() *Location {
+	return l
+}
+
+// EvalError is a failure evaluating a form, such as malformed syntax, an
+// undefined variable or an invalid argument. Name is the special form or
+// primitive that failed, if any.
+type EvalError struct {
+	Location
+	Name    string
+	Message string
+}
+
+func (e *EvalError) Error() string {
+	if e.Name == "" {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
+
+func (e *EvalError) Is(target error) bool {
+	return target == ErrEval
+}
+
+// ArityError reports a call with the wrong number of arguments. Max is
+// -1 when there is no upper limit.
+type ArityError struct {
+	Location
+	Name     string
+	Min, Max int
+	Got      int
+}
+
+func (e *ArityError) Error() string {
+	var want string
+	switch {
+	case e.Max < 0:
+		want = "at least " + arguments(e.Min)
+	case e.Min == e.Max:
+		want = arguments(e.Min)
+	case e.Max == e.Min+1:
+		want = fmt.Sprintf("%d or %s", e.Min, arguments(e.Max))
+	default:
+		want = fmt.Sprintf("%d to %s", e.Min, arguments(e.Max))
+	}
+	msg := fmt.Sprintf("requires %s, got %d", want, e.Got)
+	if e.Name == "" {
+		return msg
+	}
+	return e.Name + ": " + msg
+}
+
+func (e *ArityError) Is(target error) bool {
+	return target == ErrArity || target == ErrEval
+}
+
+// arguments pluralizes "argument"
+func arguments(n int) string {
+	if n == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
+
+// TypeError reports a value of the wrong type. Expected describes the
+// acceptable values, such as "number" or "list".
+type TypeError struct {
+	Location
+	Name     string
+	Expected string
+	Got      sexpr.SExpr
+}
+
+func (e *TypeError) Error() string {
+	msg := fmt.Sprintf("expected %s, got %v", e.Expected, e.Got)
+	if e.Name == "" {
+		return msg
+	}
+	return e.Name + ": " + msg
+}
+
+func (e *TypeError) Is(target error) bool {
+	return target == ErrType || target == ErrEval
+}
+
+// evalError returns an EvalError for name with a formatted message
+func evalError(name, format string, args ...any) error {
+	return &EvalError{Name: name, Message: fmt.Sprintf(format, args...)}
+}
+
+// arityError returns an ArityError for a call of name with got
+// arguments that needed between min and max (-1 for no limit)
+func arityError(name string, min, max, got int) error {
+	return &ArityError{Name: name, Min: min, Max: max, Got: got}
+}
+
+// typeError returns a TypeError for name receiving got where it
+// expected a value described by expected
+func typeError(name, expected string, got sexpr.SExpr) error {
+	return &TypeError{Name: name, Expected: expected, Got: got}
+}
+
+// ExitError is returned when zylisp code calls exit. It unwinds the whole
+// evaluation, which try cannot stop, so that the host can end the process
+// with Code or ignore it.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// RaiseError is the Go error returned when zylisp code raises a value.
+// It carries the raised value unchanged so that handlers and host code
+// can inspect it.
+type RaiseError struct {
+	Value sexpr.SExpr
+}
+
+func (e *RaiseError) Error() string {
+	if cond, ok := e.Value.(sexpr.Error); ok {
+		return cond.Kind.Name + ": " + cond.Message
+	}
+	return "raised: " + sexpr.Write(e.Value)
+}
+
+// errorValue converts an evaluation error into the value bound by a
+// catch clause: the raised value for RaiseError, otherwise an error value
+// of kind eval-error carrying the message without any stack trace
+func errorValue(err error) sexpr.SExpr {
+	var raised *RaiseError
+	if errors.As(err, &raised) {
+		return raised.Value
+	}
+	var se *StackError
+	if errors.As(err, &se) {
+		err = se.Err
+	}
+	return sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "eval-error"},
+		Message: err.Error(),
+		Data:    sexpr.Nil{},
+	}
+}
+
+// evalTry handles (try body... [(catch name handler...)] [(finally
+// cleanup...)]). If the body fails, the handler runs with name bound to
+// the error value and its result becomes the result of the try. The
+// cleanup forms always run, including when a continuation escapes
+// through the try; an error they raise replaces any other outcome.
+// Interrupted evaluations and exhausted resource limits are not caught.
+func evalTry(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	body := list.Elements[1:]
+	var catch, finally []sexpr.SExpr
+	var catchName sexpr.Symbol
+	hasCatch := false
+
+	for len(body) > 0 {
+		clause, ok := tryClause(body[len(body)-1])
+		if !ok {
+			break
+		}
+		switch clause[0].(sexpr.Symbol).Name {
+		case "finally":
+			if finally != nil || hasCatch {
+				return nil, evalError("try", "finally must be the last clause")
+			}
+			finally = clause[1:]
+		case "catch":
+			if hasCatch {
+				return nil, evalError("try", "only one catch clause is allowed")
+			}
+			if len(clause) < 2 {
+				return nil, evalError("try", "catch requires a name")
+			}
+			name, ok := clause[1].(sexpr.Symbol)
+			if !ok {
+				return nil, evalError("try", "catch name must be a symbol, got %v", clause[1])
+			}
+			catchName, catch, hasCatch = name, clause[2:], true
+		}
+		body = body[:len(body)-1]
+	}
+
+	result, err := evalBody(body, env)
+	if err != nil && hasCatch && catchable(err) {
+		handlerEnv := env.Extend()
+		handlerEnv.Define(catchName.Name, errorValue(err))
+		result, err = evalBody(catch, handlerEnv)
+	}
+
+	if finally != nil {
+		if _, ferr := evalBody(finally, env); ferr != nil {
+			return nil, ferr
+		}
+	}
+	return result, err
+}
+
+// evalAssert handles (assert expr [message]), raising an error of kind
+// assertion-error if expr is false or nil. The error's message includes
+// the source position of the assertion when it is known, and its data
+// is expr.
+func evalAssert(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 2 && len(list.Elements) != 3 {
+		return nil, arityError("assert", 1, 2, len(list.Elements)-1)
+	}
+
+	value, err := Eval(list.Elements[1], env)
+	if err != nil || isTruthy(value) {
+		return sexpr.Nil{}, err
+	}
+
+	message := "assertion failed: " + sexpr.Write(list.Elements[1])
+	if len(list.Elements) == 3 {
+		detail, err := Eval(list.Elements[2], env)
+		if err != nil {
+			return nil, err
+		}
+		message = "assertion failed: " + sexpr.Display(detail)
+	}
+	if pos, ok := sexpr.PositionOf(list); ok {
+		message += " at " + pos.String()
+	}
+	return nil, &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "assertion-error"},
+		Message: message,
+		Data:    list.Elements[1],
+	}}
+}
+
+// catchable reports whether try may handle err. Continuation escapes,
+// exits, interrupted evaluations and exhausted fuel or memory pass
+// through.
+func catchable(err error) bool {
+	var esc *escape
+	var exit *ExitError
+	return !errors.As(err, &esc) && !errors.As(err, &exit) && !errors.Is(err, ErrFuel) && !errors.Is(err, ErrMemory) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// tryClause returns the elements of a (catch ...) or (finally ...) form
+func tryClause(expr sexpr.SExpr) ([]sexpr.SExpr, bool) {
+	list, ok := expr.(sexpr.List)
+	if !ok || len(list.Elements) == 0 {
+		return nil, false
+	}
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || (head.Name != "catch" && head.Name != "finally") {
+		return nil, false
+	}
+	return list.Elements, true
+}
@@ -0,0 +1,214 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestTry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(try 1 2)", "2"},
+		{"(try (+ 1 2) (catch e 0))", "3"},
+		{"(try (raise 42) (catch e (+ e 1)))", "43"},
+		{"(try (raise (make-error 'io \"boom\")) (catch e (error-kind e)))", "io"},
+		// Go-level evaluation errors are caught as eval-error values
+		{"(try (car 1) (catch e (error-kind e)))", "eval-error"},
+		{"(try undefined-var (catch e (error? e)))", "true"},
+		{"(let ((log (list))) (try (set! log (cons 1 log)) (finally (set! log (cons 2 log)))) log)", "(2 1)"},
+		{"(let ((log (list))) (try (raise 1) (catch e (set! log (cons e log))) (finally (set! log (cons 2 log)))) log)", "(2 1)"},
+		{"(try (raise 1) (catch e (try (raise 2) (catch e e))))", "2"},
+		{"(try)", "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestTryErrors(t *testing.T) {
+	tests := []string{
+		// Without a catch the error propagates after finally runs
+		"(try (raise 1) (finally 2))",
+		// An error in the handler propagates
+		"(try (raise 1) (catch e (raise e)))",
+		// An error in finally replaces the result
+		"(try 1 (finally (car 1)))",
+		"(try 1 (finally 2) (catch e 3))",
+		"(try 1 (catch e 2) (catch e 3))",
+		"(try 1 (catch 2))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	tests := []struct {
+		input  string
+		target error
+		errs   []error
+	}{
+		{"(car 1 2)", &ArityError{}, []error{ErrArity, ErrEval}},
+		{"((lambda (x) x))", &ArityError{}, []error{ErrArity, ErrEval}},
+		{"(-)", &ArityError{}, []error{ErrArity, ErrEval}},
+		{"(/)", &ArityError{}, []error{ErrArity, ErrEval}},
+		{"(car 1)", &TypeError{}, []error{ErrType, ErrEval}},
+		{"(+ 1 (bytes-ref 1 0))", &TypeError{}, []error{ErrType, ErrEval}},
+		{"undefined-var", &EvalError{}, []error{ErrEval}},
+		{"(let ((x)) x)", &EvalError{}, []error{ErrEval}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			_, err := evalString(env, tt.input)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if reflect.TypeOf(tt.target) != reflect.TypeOf(unwrapTyped(err)) {
+				t.Errorf("got %T, want %T", unwrapTyped(err), tt.target)
+			}
+			for _, target := range tt.errs {
+				if !errors.Is(err, target) {
+					t.Errorf("errors.Is(%v) = false", target)
+				}
+			}
+			if errors.Is(err, ErrArity) && errors.Is(err, ErrType) {
+				t.Error("error is both an arity and a type error")
+			}
+		})
+	}
+}
+
+// unwrapTyped returns the innermost EvalError, ArityError or TypeError
+func unwrapTyped(err error) error {
+	var ee *EvalError
+	var ae *ArityError
+	var te *TypeError
+	switch {
+	case errors.As(err, &ae):
+		return ae
+	case errors.As(err, &te):
+		return te
+	case errors.As(err, &ee):
+		return ee
+	}
+	return err
+}
+
+func TestErrorDetails(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	_, err := evalString(env, "(list (car (quote x)))")
+	var te *TypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected TypeError, got %T", err)
+	}
+	if te.Name != "car" || te.Expected != "list" || te.Got.String() != "x" {
+		t.Errorf("got %+v", te)
+	}
+	// The location is the innermost failing form
+	if te.Expr == nil || te.Expr.String() != "(car (quote x))" {
+		t.Errorf("got expression %v", te.Expr)
+	}
+
+	dir := t.TempDir()
+	path := writeSource(t, dir, "f.zy", "(define x 1)\n(car x)")
+	_, err = LoadFile(path, env)
+	if !errors.As(err, &te) {
+		t.Fatalf("expected TypeError, got %T", err)
+	}
+	if te.Pos == nil || te.Pos.Line != 2 || te.Pos.File != path {
+		t.Errorf("got position %v", te.Pos)
+	}
+
+	var ae *ArityError
+	_, err = evalString(env, "(cons 1)")
+	if !errors.As(err, &ae) || ae.Min != 2 || ae.Max != 2 || ae.Got != 1 {
+		t.Errorf("got %+v", ae)
+	}
+	if ae.Error() != "cons: requires 2 arguments, got 1" {
+		t.Errorf("got message %q", ae.Error())
+	}
+}
+
+func TestArityErrorMessages(t *testing.T) {
+	tests := []struct {
+		err      *ArityError
+		expected string
+	}{
+		{&ArityError{Name: "f", Min: 1, Max: 1, Got: 0}, "f: requires 1 argument, got 0"},
+		{&ArityError{Name: "f", Min: 1, Max: 2, Got: 3}, "f: requires 1 or 2 arguments, got 3"},
+		{&ArityError{Name: "f", Min: 1, Max: 3, Got: 0}, "f: requires 1 to 3 arguments, got 0"},
+		{&ArityError{Name: "f", Min: 2, Max: -1, Got: 1}, "f: requires at least 2 arguments, got 1"},
+		{&ArityError{Min: 0, Max: 0, Got: 1}, "requires 0 arguments, got 1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.expected {
+			t.Errorf("got %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestAssert(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(assert (= 1 1))", "nil"},
+		{`(assert 0 "zero is true")`, "nil"},
+		{"(try (assert (= 1 2)) (catch e (list (error-kind e) (error-message e) (error-data e))))",
+			`(assertion-error "assertion failed: (= 1 2)" (= 1 2))`},
+		{`(try (assert false (string-append "bad " "input")) (catch e (error-message e)))`,
+			`"assertion failed: bad input"`},
+		{`(try (assert (when false 1) 'missing) (catch e (error-message e)))`,
+			`"assertion failed: missing"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{"(assert)", "(assert 1 2 3)", "(assert (car 1))"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestAssertPosition(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	exprs, err := parser.ReadSource("check.zy", "(define x 1)\n  (assert (> x 2) \"x too small\")")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = evalBody(exprs, env)
+	var raised *RaiseError
+	if !errors.As(err, &raised) {
+		t.Fatalf("got %v, want an assertion-error", err)
+	}
+	want := "assertion failed: x too small at check.zy:2:3"
+	if msg := raised.Value.(sexpr.Error).Message; msg != want {
+		t.Errorf("got message %q, want %q", msg, want)
+	}
+}
@@ -1,7 +1,9 @@
 package interpreter
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/zylisp/lang/sexpr"
 )
@@ -13,24 +15,120 @@ func Eval(expr sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	// Self-evaluating types
 	case sexpr.Number:
 		return e, nil
+	case sexpr.BigInt:
+		return e, nil
+	case sexpr.Float:
+		return e, nil
 	case sexpr.String:
 		return e, nil
 	case sexpr.Bool:
 		return e, nil
 	case sexpr.Nil:
 		return e, nil
+	case sexpr.Keyword:
+		return e, nil
+	case sexpr.Char:
+		return e, nil
+	case sexpr.Bytes:
+		return e, nil
+	case sexpr.Tagged:
+		return e, nil
+	case sexpr.GoValue:
+		return e, nil
+	case sexpr.Error:
+		return e, nil
+	case *sexpr.Port:
+		return e, nil
+	case *sexpr.Promise:
+		return e, nil
+	case *sexpr.Channel:
+		return e, nil
+	case *sexpr.Atom:
+		return e, nil
+	case *sexpr.Future:
+		return e, nil
+	case *Generator:
+		return e, nil
+	case *Actor:
+		return e, nil
+	case *sexpr.Record:
+		return e, nil
+	case *sexpr.RecordType:
+		return e, nil
 
 	// Symbol lookup
 	case sexpr.Symbol:
-		return env.Lookup(e.Name)
+		return lookupSymbol(e.Name, env)
+	case localRef:
+		return e.lookup(env)
+	case lambdaForm:
+		return sexpr.Func{Params: e.params, Rest: e.rest, Body: e.body, Env: env, Meta: e.meta}, nil
+	case *Code:
+		return e.run(env)
+	case *Compiled:
+		return e.run(env)
+
+	// Collection literals evaluate their elements
+	case sexpr.Vector:
+		return evalVector(e, env)
+	case sexpr.Map:
+		return evalMap(e, env)
+	case sexpr.Set:
+		return evalSet(e, env)
 
 	// List evaluation
 	case sexpr.List:
-		return evalList(e, env)
+		if len(e.Elements) > 0 && !env.direct {
+			switch env.runtime.Engine() {
+			case BytecodeVM:
+				code, err := Compile(e, env)
+				if err != nil {
+					return nil, traceForm(err, e)
+				}
+				return code.run(env)
+			case ClosureCompiler:
+				return CompileClosures(e, env).run(env)
+			}
+		}
+		result, err := evalList(e, env)
+		if err != nil {
+			return nil, traceForm(err, e)
+		}
+		return result, nil
+	case sexpr.Pair:
+		elems, ok := sexpr.Elements(e)
+		if !ok {
+			return nil, evalError("", "cannot evaluate improper list: %v", e)
+		}
+		return evalList(sexpr.List{Elements: elems}, env)
 
 	default:
-		return nil, fmt.Errorf("cannot evaluate: %v", expr)
+		return nil, evalError("", "cannot evaluate: %v", expr)
+	}
+}
+
+// EvalContext evaluates expr in env like Eval, but stops with an error
+// wrapping ctx.Err() once ctx is done. The context is checked at every
+// function call and loop iteration, and is available to primitives
+// through Env.Context. Definitions made by expr still go into env.
+func EvalContext(ctx context.Context, expr sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	view := *env
+	view.ctx = ctx
+	if err := view.interrupted(); err != nil {
+		return nil, err
 	}
+	return Eval(expr, &view)
+}
+
+// EvalSession is EvalContext for one of a series of evaluations, such as
+// the inputs of a REPL, that make up a session. ctx, which should derive
+// from session, stops the evaluation; the go and future bodies,
+// generators and actors it starts run under session instead, so they
+// can outlive it.
+func EvalSession(session, ctx context.Context, expr sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	view := *env
+	view.session = session
+	return EvalContext(ctx, expr, &view)
 }
 
 // evalList evaluates a list expression
@@ -38,6 +136,9 @@ func evalList(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	if len(list.Elements) == 0 {
 		return sexpr.Nil{}, nil
 	}
+	if err := env.runtime.step(); err != nil {
+		return nil, err
+	}
 
 	first := list.Elements[0]
 
@@ -46,12 +147,74 @@ func evalList(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 		switch sym.Name {
 		case "define":
 			return evalDefine(list, env)
+		case "set!":
+			return evalSetBang(list, env)
 		case "lambda":
 			return evalLambda(list, env)
 		case "if":
 			return evalIf(list, env)
 		case "quote":
 			return evalQuote(list, env)
+		case "quasiquote":
+			return evalQuasiquote(list, env)
+		case "defmacro":
+			return evalDefmacro(list, env)
+		case "define-syntax":
+			return evalDefineSyntax(list, env)
+		case "syntax-rules":
+			return evalSyntaxRules(list, env)
+		case "delay":
+			return evalDelay(list, env)
+		case "go":
+			return evalGo(list, env)
+		case "future":
+			return evalFuture(list, env)
+		case "generator":
+			return evalGenerator(list, env)
+		case "receive":
+			return evalReceive(list, env)
+		case "module":
+			return evalModule(list, env)
+		case "import":
+			return evalImport(list, env)
+		case "define-record-type":
+			return evalDefineRecordType(list, env)
+		case "cond":
+			return evalCond(list, env)
+		case "case":
+			return evalCase(list, env)
+		case "match":
+			return evalMatch(list, env)
+		case "when":
+			return evalWhen(list, env, true)
+		case "unless":
+			return evalWhen(list, env, false)
+		case "try":
+			return evalTry(list, env)
+		case "begin":
+			return evalBody(list.Elements[1:], env)
+		case "and":
+			return evalAnd(list, env)
+		case "or":
+			return evalOr(list, env)
+		case "let":
+			return evalLet(list, env)
+		case "let*":
+			return evalLetStar(list, env)
+		case "letrec":
+			return evalLetrec(list, env)
+		case "let-values":
+			return evalLetValues(list, env)
+		case "loop":
+			return evalLoop(list, env)
+		case "recur":
+			return evalRecur(list, env)
+		case "time":
+			return evalTime(list, env)
+		case "assert":
+			return evalAssert(list, env)
+		case "string-interpolate":
+			return evalStringInterpolate(list, env)
 		}
 	}
 
@@ -59,16 +222,23 @@ func evalList(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	return evalApply(list, env)
 }
 
-// evalDefine handles (define name value)
+// evalDefine handles (define name value) and the function shorthand
+// (define (name params...) body...)
 func evalDefine(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) > 1 {
+		switch list.Elements[1].(type) {
+		case sexpr.List, sexpr.Pair:
+			return evalDefineFunc(list, env)
+		}
+	}
+
 	if len(list.Elements) != 3 {
-		return nil, fmt.Errorf("define requires 2 arguments, got %d",
-			len(list.Elements)-1)
+		return nil, arityError("define", 2, 2, len(list.Elements)-1)
 	}
 
 	name, ok := list.Elements[1].(sexpr.Symbol)
 	if !ok {
-		return nil, fmt.Errorf("define: first argument must be a symbol")
+		return nil, evalError("define", "first argument must be a symbol")
 	}
 
 	value, err := Eval(list.Elements[2], env)
@@ -80,41 +250,122 @@ func evalDefine(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	return value, nil
 }
 
-// evalLambda handles (lambda (params...) body)
-func evalLambda(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+// evalSetBang handles (set! name value), changing the nearest existing
+// binding of name
+func evalSetBang(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	if len(list.Elements) != 3 {
-		return nil, fmt.Errorf("lambda requires 2 arguments, got %d",
-			len(list.Elements)-1)
+		return nil, arityError("set!", 2, 2, len(list.Elements)-1)
 	}
 
-	paramsList, ok := list.Elements[1].(sexpr.List)
+	name, ok := list.Elements[1].(sexpr.Symbol)
 	if !ok {
-		return nil, fmt.Errorf("lambda: parameters must be a list")
+		return nil, evalError("set!", "first argument must be a symbol")
 	}
 
-	var params []sexpr.Symbol
-	for _, p := range paramsList.Elements {
-		sym, ok := p.(sexpr.Symbol)
-		if !ok {
-			return nil, fmt.Errorf("lambda: parameter must be a symbol, got %v", p)
+	value, err := Eval(list.Elements[2], env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.Set(name.Name, value); err != nil {
+		return nil, fmt.Errorf("set!: %w", err)
+	}
+	return value, nil
+}
+
+// evalDefineFunc handles the function shorthand of define
+func evalDefineFunc(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	define, err := desugarDefine(list)
+	if err != nil {
+		return nil, err
+	}
+	return evalDefine(define, env)
+}
+
+// desugarDefine rewrites (define (name params...) body...) as
+// (define name (lambda (params...) body...)), wrapping several body
+// expressions in a begin. A string before further body expressions is
+// the function's docstring.
+func desugarDefine(list sexpr.List) (sexpr.List, error) {
+	if len(list.Elements) < 3 {
+		return sexpr.List{}, evalError("define", "function definition requires a body")
+	}
+
+	var name, params sexpr.SExpr
+	switch head := list.Elements[1].(type) {
+	case sexpr.List:
+		if len(head.Elements) == 0 {
+			return sexpr.List{}, evalError("define", "function definition requires a name")
 		}
-		params = append(params, sym)
+		name, params = head.Elements[0], sexpr.List{Elements: head.Elements[1:]}
+	case sexpr.Pair:
+		name, params = head.Car, head.Cdr
+	default:
+		return sexpr.List{}, evalError("define", "first argument must be a symbol")
+	}
+
+	lambda := []sexpr.SExpr{sexpr.Symbol{Name: "lambda"}, params}
+	exprs := list.Elements[2:]
+	if doc, ok := exprs[0].(sexpr.String); ok && len(exprs) > 1 {
+		lambda = append(lambda, doc)
+		exprs = exprs[1:]
 	}
 
-	body := list.Elements[2]
+	body := exprs[0]
+	if len(exprs) > 1 {
+		body = sexpr.List{Elements: append([]sexpr.SExpr{sexpr.Symbol{Name: "begin"}}, exprs...)}
+	}
+	return sexpr.List{Elements: []sexpr.SExpr{list.Elements[0], name,
+		sexpr.List{Elements: append(lambda, body)}}}, nil
+}
 
-	return sexpr.Func{
-		Params: params,
-		Body:   body,
-		Env:    env,
-	}, nil
+// evalLambda handles (lambda (params...) [doc] body), where doc is an
+// optional docstring. The parameter list may be dotted, (a b . rest), or
+// a single symbol, args, to collect extra arguments into a list. A
+// parameter may also be a pattern such as (x y) that destructures its
+// argument.
+func evalLambda(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	form, err := resolver{rt: env.Runtime()}.resolveLambda(list, envScope(env))
+	if err != nil {
+		return nil, err
+	}
+	return Eval(form, env)
+}
+
+// parseParams splits a lambda parameter list into its fixed parameters
+// and optional rest parameter
+func parseParams(spec sexpr.SExpr) ([]sexpr.Symbol, *sexpr.Symbol, error) {
+	var params []sexpr.Symbol
+	for {
+		switch s := spec.(type) {
+		case sexpr.Symbol:
+			return params, &s, nil
+		case sexpr.List:
+			for _, p := range s.Elements {
+				sym, ok := p.(sexpr.Symbol)
+				if !ok {
+					return nil, nil, evalError("lambda", "parameter must be a symbol, got %v", p)
+				}
+				params = append(params, sym)
+			}
+			return params, nil, nil
+		case sexpr.Pair:
+			sym, ok := s.Car.(sexpr.Symbol)
+			if !ok {
+				return nil, nil, evalError("lambda", "parameter must be a symbol, got %v", s.Car)
+			}
+			params = append(params, sym)
+			spec = s.Cdr
+		default:
+			return nil, nil, evalError("lambda", "parameters must be a list or symbol")
+		}
+	}
 }
 
 // evalIf handles (if test then else)
 func evalIf(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	if len(list.Elements) != 4 {
-		return nil, fmt.Errorf("if requires 3 arguments, got %d",
-			len(list.Elements)-1)
+		return nil, arityError("if", 3, 3, len(list.Elements)-1)
 	}
 
 	test, err := Eval(list.Elements[1], env)
@@ -128,16 +379,506 @@ func evalIf(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	return Eval(list.Elements[3], env)
 }
 
+// evalCond handles (cond (test expr...)... (else expr...)). The first
+// clause whose test is truthy has its expressions evaluated; a clause
+// with no expressions returns the test value, and (test => f) calls f
+// with it. If no clause matches the result is nil.
+func evalCond(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	test, body, fn, err := selectClause(list, env)
+	switch {
+	case err != nil:
+		return nil, err
+	case fn != nil:
+		return apply(fn, []sexpr.SExpr{test}, env)
+	case len(body) == 0:
+		return test, nil
+	}
+	return evalBody(body, env)
+}
+
+// selectClause finds the first clause of a cond that applies and returns
+// the value of its test with either the expressions of its body or, for
+// a (test => f) clause, the value of f. When there is no body to
+// evaluate, the test value is the result: nil for an else clause or when
+// no clause applies.
+func selectClause(list sexpr.List, env *Env) (sexpr.SExpr, []sexpr.SExpr, sexpr.SExpr, error) {
+	clauses := list.Elements[1:]
+	for i, clause := range clauses {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) == 0 {
+			return nil, nil, nil, evalError("cond", "clause must be a non-empty list, got %v", clause)
+		}
+
+		if sym, ok := parts[0].(sexpr.Symbol); ok && sym.Name == "else" {
+			if i != len(clauses)-1 {
+				return nil, nil, nil, evalError("cond", "else must be the last clause")
+			}
+			return sexpr.Nil{}, parts[1:], nil, nil
+		}
+
+		test, err := Eval(parts[0], env)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !isTruthy(test) {
+			continue
+		}
+
+		if len(parts) > 1 {
+			if sym, ok := parts[1].(sexpr.Symbol); ok && sym.Name == "=>" {
+				if len(parts) != 3 {
+					return nil, nil, nil, evalError("cond", "=> requires exactly 1 function, got %v", clause)
+				}
+				fn, err := Eval(parts[2], env)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				return test, nil, fn, nil
+			}
+		}
+		return test, parts[1:], nil, nil
+	}
+
+	return sexpr.Nil{}, nil, nil, nil
+}
+
+// evalCase handles (case key ((datum...) expr...)... (else expr...)).
+// The key is evaluated once and compared with the unevaluated data using
+// Equal; the first clause containing a match has its expressions
+// evaluated, or with (... => f), f is called with the key. If no clause
+// matches the result is nil.
+func evalCase(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) < 2 {
+		return nil, evalError("", "case requires a key")
+	}
+
+	key, err := Eval(list.Elements[1], env)
+	if err != nil {
+		return nil, err
+	}
+
+	clauses := list.Elements[2:]
+	for i, clause := range clauses {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) < 2 {
+			return nil, evalError("case", "clause must be (data expr...), got %v", clause)
+		}
+
+		matched := false
+		if sym, ok := parts[0].(sexpr.Symbol); ok && sym.Name == "else" {
+			if i != len(clauses)-1 {
+				return nil, evalError("case", "else must be the last clause")
+			}
+			matched = true
+		} else {
+			data, ok := sexpr.Elements(parts[0])
+			if !ok {
+				return nil, evalError("case", "data must be a list, got %v", parts[0])
+			}
+			for _, datum := range data {
+				if datum.Equal(key) {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		if sym, ok := parts[1].(sexpr.Symbol); ok && sym.Name == "=>" {
+			if len(parts) != 3 {
+				return nil, evalError("case", "=> requires exactly 1 function, got %v", clause)
+			}
+			fn, err := Eval(parts[2], env)
+			if err != nil {
+				return nil, err
+			}
+			return apply(fn, []sexpr.SExpr{key}, env)
+		}
+		return evalBody(parts[1:], env)
+	}
+
+	return sexpr.Nil{}, nil
+}
+
+// evalWhen handles (when test body...) and, when want is false,
+// (unless test body...). The body runs if the truthiness of test matches
+// want; otherwise the result is nil.
+func evalWhen(list sexpr.List, env *Env, want bool) (sexpr.SExpr, error) {
+	if len(list.Elements) < 2 {
+		return nil, evalError("", "%s requires a test", list.Elements[0])
+	}
+
+	test, err := Eval(list.Elements[1], env)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(test) != want {
+		return sexpr.Nil{}, nil
+	}
+	return evalBody(list.Elements[2:], env)
+}
+
+// evalAnd handles (and expr...), stopping at the first falsy value and
+// returning it. (and) is true; otherwise the last value is returned.
+func evalAnd(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	var result sexpr.SExpr = sexpr.Bool{Value: true}
+	for _, expr := range list.Elements[1:] {
+		var err error
+		if result, err = Eval(expr, env); err != nil {
+			return nil, err
+		}
+		if !isTruthy(result) {
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// evalOr handles (or expr...), stopping at the first truthy value and
+// returning it. (or) is false; otherwise the last value is returned.
+func evalOr(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	var result sexpr.SExpr = sexpr.Bool{Value: false}
+	for _, expr := range list.Elements[1:] {
+		var err error
+		if result, err = Eval(expr, env); err != nil {
+			return nil, err
+		}
+		if isTruthy(result) {
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
 // evalQuote handles (quote expr)
 func evalQuote(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	if len(list.Elements) != 2 {
-		return nil, fmt.Errorf("quote requires 1 argument, got %d",
-			len(list.Elements)-1)
+		return nil, arityError("quote", 1, 1, len(list.Elements)-1)
 	}
 
 	return list.Elements[1], nil
 }
 
+// evalLet handles (let ((name value)...) body...). The values are
+// evaluated in the enclosing environment, so they cannot refer to each
+// other.
+func evalLet(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	letEnv, err := bindLet(list, env)
+	if err != nil {
+		return nil, err
+	}
+	return evalBody(list.Elements[2:], letEnv)
+}
+
+// bindLet evaluates the bindings of a let and returns the environment
+// for its body
+func bindLet(list sexpr.List, env *Env) (*Env, error) {
+	bindings, err := parseBindings("let", list)
+	if err != nil {
+		return nil, err
+	}
+
+	letEnv := env.Extend()
+	for _, b := range bindings {
+		value, err := Eval(b.value, env)
+		if err != nil {
+			return nil, err
+		}
+		if err := bindPattern("let", letEnv, b.pattern, value); err != nil {
+			return nil, err
+		}
+	}
+	return letEnv, nil
+}
+
+// evalLetStar handles (let* ((name value)...) body...). Each value can
+// refer to the bindings before it.
+func evalLetStar(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	letEnv, err := bindLetStar(list, env)
+	if err != nil {
+		return nil, err
+	}
+	return evalBody(list.Elements[2:], letEnv)
+}
+
+// bindLetStar evaluates the bindings of a let* and returns the
+// environment for its body
+func bindLetStar(list sexpr.List, env *Env) (*Env, error) {
+	bindings, err := parseBindings("let*", list)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each binding gets its own scope so that closures created by later
+	// values do not see a shadowing rebinding of the same name
+	letEnv := env.Extend()
+	for i, b := range bindings {
+		value, err := Eval(b.value, letEnv)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			letEnv = letEnv.Extend()
+		}
+		if err := bindPattern("let*", letEnv, b.pattern, value); err != nil {
+			return nil, err
+		}
+	}
+	return letEnv, nil
+}
+
+// evalLetrec handles (letrec ((name value)...) body...). All names are
+// in scope in every value, allowing mutually recursive local functions.
+// A name read before its value has been computed is nil.
+func evalLetrec(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	letEnv, err := bindLetrec(list, env)
+	if err != nil {
+		return nil, err
+	}
+	return evalBody(list.Elements[2:], letEnv)
+}
+
+// bindLetrec evaluates the bindings of a letrec and returns the
+// environment for its body
+func bindLetrec(list sexpr.List, env *Env) (*Env, error) {
+	bindings, err := parseBindings("letrec", list)
+	if err != nil {
+		return nil, err
+	}
+
+	letEnv := env.Extend()
+	for _, b := range bindings {
+		for _, name := range patternNames(b.pattern) {
+			letEnv.Define(name.Name, sexpr.Nil{})
+		}
+	}
+	for _, b := range bindings {
+		value, err := Eval(b.value, letEnv)
+		if err != nil {
+			return nil, err
+		}
+		if err := bindPattern("letrec", letEnv, b.pattern, value); err != nil {
+			return nil, err
+		}
+	}
+	return letEnv, nil
+}
+
+// evalLetValues handles (let-values ((formals expr)...) body...). Each
+// expr may return multiple values, which are bound to formals as if they
+// were the arguments of a call to (lambda formals ...).
+func evalLetValues(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) < 3 {
+		return nil, evalError("", "let-values requires bindings and a body")
+	}
+
+	specs, ok := sexpr.Elements(list.Elements[1])
+	if !ok {
+		return nil, evalError("let-values", "bindings must be a list, got %v", list.Elements[1])
+	}
+
+	letEnv := env.Extend()
+	for _, spec := range specs {
+		pair, ok := sexpr.Elements(spec)
+		if !ok || len(pair) != 2 {
+			return nil, evalError("let-values", "binding must be (formals expr), got %v", spec)
+		}
+		params, rest, err := parseParams(pair[0])
+		if err != nil {
+			return nil, fmt.Errorf("let-values: %w", err)
+		}
+
+		value, err := Eval(pair[1], env)
+		if err != nil {
+			return nil, err
+		}
+		values := valuesOf(value)
+		if len(values) < len(params) || (rest == nil && len(values) != len(params)) {
+			return nil, evalError("let-values", "%v expects %d values, got %d", pair[0], len(params), len(values))
+		}
+
+		for i, param := range params {
+			letEnv.Define(param.Name, values[i])
+		}
+		if rest != nil {
+			letEnv.Define(rest.Name, sexpr.List{Elements: append([]sexpr.SExpr{}, values[len(params):]...)})
+		}
+	}
+
+	return evalBody(list.Elements[2:], letEnv)
+}
+
+// binding is a single (pattern value) pair of a let form. The pattern
+// is usually a symbol but may destructure the value.
+type binding struct {
+	pattern sexpr.SExpr
+	value   sexpr.SExpr
+}
+
+// parseBindings checks the shape of a let form and returns its bindings
+func parseBindings(form string, list sexpr.List) ([]binding, error) {
+	if len(list.Elements) < 3 {
+		return nil, evalError("", "%s requires bindings and a body", form)
+	}
+
+	specs, ok := sexpr.Elements(list.Elements[1])
+	if !ok {
+		return nil, evalError(form, "bindings must be a list, got %v", list.Elements[1])
+	}
+
+	bindings := make([]binding, len(specs))
+	for i, spec := range specs {
+		pair, ok := sexpr.Elements(spec)
+		if !ok || len(pair) != 2 {
+			return nil, evalError(form, "binding must be (name value), got %v", spec)
+		}
+		if err := checkPattern(pair[0]); err != nil {
+			return nil, fmt.Errorf("%s: %w", form, err)
+		}
+		bindings[i] = binding{pattern: pair[0], value: pair[1]}
+	}
+	return bindings, nil
+}
+
+// evalBody evaluates exprs in order and returns the last result, or nil
+// if there are none. It implements (begin expr...), whose definitions
+// go into the enclosing environment.
+func evalBody(exprs []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	var result sexpr.SExpr = sexpr.Nil{}
+	for _, expr := range exprs {
+		var err error
+		if result, err = Eval(expr, env); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// evalVector evaluates the elements of a vector literal
+func evalVector(v sexpr.Vector, env *Env) (sexpr.SExpr, error) {
+	elems, err := evalEach(v.Elements(), env)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.NewVector(elems...), nil
+}
+
+// evalMap evaluates the keys and values of a map literal
+func evalMap(m sexpr.Map, env *Env) (sexpr.SExpr, error) {
+	result := sexpr.Map{}
+	for _, entry := range m.Entries() {
+		key, err := Eval(entry.Key, env)
+		if err != nil {
+			return nil, err
+		}
+		value, err := Eval(entry.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = result.Assoc(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// evalSet evaluates the elements of a set literal
+func evalSet(s sexpr.Set, env *Env) (sexpr.SExpr, error) {
+	elems, err := evalEach(s.Elements(), env)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.NewSet(elems...)
+}
+
+// evalEach evaluates exprs in order
+func evalEach(exprs []sexpr.SExpr, env *Env) ([]sexpr.SExpr, error) {
+	values := make([]sexpr.SExpr, len(exprs))
+	for i, expr := range exprs {
+		value, err := Eval(expr, env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// evalDelay handles (delay expr), returning a promise that evaluates expr
+// in the current environment when first forced
+func evalDelay(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 2 {
+		return nil, arityError("delay", 1, 1, len(list.Elements)-1)
+	}
+
+	body := list.Elements[1]
+	return sexpr.NewPromise(func() (sexpr.SExpr, error) {
+		return Eval(body, env)
+	}), nil
+}
+
+// evalGo handles (go expr), evaluating expr in a new goroutine and
+// returning nil at once. The goroutine shares the environment and stops
+// when the evaluation's context is done. Its result is discarded, so it
+// should report back over a channel; an error, other than being stopped,
+// is written to the runtime's error port.
+func evalGo(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 2 {
+		return nil, arityError("go", 1, 1, len(list.Elements)-1)
+	}
+
+	body := list.Elements[1]
+	env = env.background()
+	go func() {
+		_, err := Eval(body, env)
+		if err == nil || env.Context().Err() != nil {
+			return
+		}
+		port := env.runtime.ErrorOutput()
+		if !port.Closed() {
+			fmt.Fprintf(port.Writer(), "go: %v\n", err)
+		}
+	}()
+	return sexpr.Nil{}, nil
+}
+
+// evalFuture handles (future expr), evaluating expr in a new goroutine
+// and returning a future for its result, which await or deref waits for
+func evalFuture(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 2 {
+		return nil, arityError("future", 1, 1, len(list.Elements)-1)
+	}
+
+	body := list.Elements[1]
+	env = env.background()
+	return sexpr.NewFuture(func() (sexpr.SExpr, error) {
+		return Eval(body, env)
+	}), nil
+}
+
+// evalTime handles (time expr), returning two values: the value of expr
+// and the milliseconds its evaluation took, as a float. It needs the
+// clock capability.
+func evalTime(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 2 {
+		return nil, arityError("time", 1, 1, len(list.Elements)-1)
+	}
+	if !env.runtime.policy.Allows(CapClock) {
+		return nil, &PolicyError{Name: "time", Capability: CapClock}
+	}
+
+	start := time.Now()
+	value, err := Eval(list.Elements[1], env)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+	return sexpr.Values{Elements: []sexpr.SExpr{value, sexpr.Float{Value: elapsed}}}, nil
+}
+
 // evalApply handles function application
 func evalApply(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 	// Evaluate the function
@@ -146,6 +887,15 @@ func evalApply(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 		return nil, err
 	}
 
+	// Macros receive their arguments unevaluated
+	if macro, ok := fn.(sexpr.Macro); ok {
+		expanded, deeper, err := expandCall(macro, list, env)
+		if err != nil {
+			return nil, err
+		}
+		return Eval(expanded, deeper)
+	}
+
 	// Evaluate arguments
 	var args []sexpr.SExpr
 	for _, arg := range list.Elements[1:] {
@@ -156,36 +906,131 @@ func evalApply(list sexpr.List, env *Env) (sexpr.SExpr, error) {
 		args = append(args, value)
 	}
 
-	// Apply function
+	result, err := apply(fn, args, env)
+	if _, isFunc := fn.(sexpr.Func); isFunc && err != nil {
+		return nil, traceCall(err, callName(list))
+	}
+	return result, err
+}
+
+// expandCall expands list, a use of macro, returning the expansion and
+// the environment to evaluate it in
+func expandCall(macro sexpr.Macro, list sexpr.List, env *Env) (sexpr.SExpr, *Env, error) {
+	args := make([]sexpr.SExpr, len(list.Elements)-1)
+	for i, arg := range list.Elements[1:] {
+		args[i] = unresolve(arg)
+	}
+	expanded, err := expandMacro(macro, args, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Count the expansion as a call so that a macro that expands to
+	// itself hits the depth limit
+	deeper, err := env.deeper()
+	if err != nil {
+		return nil, nil, err
+	}
+	return expanded, deeper, nil
+}
+
+// callName returns the name of the function called by list for stack
+// traces
+func callName(list sexpr.List) string {
+	switch head := list.Elements[0].(type) {
+	case sexpr.Symbol:
+		return head.Name
+	case localRef:
+		return head.name
+	}
+	return "lambda"
+}
+
+// Apply calls fn, a primitive or function value, with args as if from
+// code evaluated in env
+func Apply(fn sexpr.SExpr, args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return apply(fn, args, env)
+}
+
+// apply calls a primitive or user-defined function with evaluated
+// arguments
+func apply(fn sexpr.SExpr, args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	switch f := fn.(type) {
 	case sexpr.Primitive:
 		return f.Fn(args, env)
 
 	case sexpr.Func:
-		return applyFunc(f, args)
+		return applyFunc(f, args, env)
 
 	default:
-		return nil, fmt.Errorf("not a function: %v", fn)
+		return nil, evalError("", "not a function: %v", fn)
 	}
 }
 
-// applyFunc applies a user-defined function
-func applyFunc(fn sexpr.Func, args []sexpr.SExpr) (sexpr.SExpr, error) {
-	if len(args) != len(fn.Params) {
-		return nil, fmt.Errorf("function expects %d arguments, got %d",
-			len(fn.Params), len(args))
+// applyFunc applies a user-defined function called from env. Calls of
+// functions in tail position of the body are not nested but made here in
+// turn, so tail calls, including mutually recursive ones, run in
+// constant space. The frames they replace are missing from stack traces.
+func applyFunc(fn sexpr.Func, args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	var call *tailCall // the tail call being made, if any
+	for {
+		funcEnv, err := callEnv(fn, args, env)
+		if err == nil {
+			var result sexpr.SExpr
+			if result, err = evalTail(fn.Body, funcEnv); err == nil {
+				switch r := result.(type) {
+				case tailCall:
+					fn, args, call = r.fn, r.args, &r
+					continue
+				case recurSignal:
+					return nil, evalError("recur", "cannot cross a function boundary")
+				}
+				return result, nil
+			}
+		}
+
+		if call != nil {
+			err = traceForm(traceCall(err, call.name), call.form)
+		}
+		return nil, err
 	}
+}
 
-	// Create new environment extending the function's closure
-	funcEnv := fn.Env.(*Env).Extend()
+// callEnv checks the arguments of a call of fn from env and returns the
+// environment to run its body in, with the parameters bound
+func callEnv(fn sexpr.Func, args []sexpr.SExpr, env *Env) (*Env, error) {
+	if fn.Rest == nil && len(args) != len(fn.Params) {
+		return nil, arityError("", len(fn.Params), len(fn.Params), len(args))
+	}
+	if fn.Rest != nil && len(args) < len(fn.Params) {
+		return nil, arityError("", len(fn.Params), -1, len(args))
+	}
+
+	if err := env.checkCall(); err != nil {
+		return nil, err
+	}
+
+	// Create new environment extending the function's closure, one call
+	// deeper than the caller and sharing its context, actor and generator
+	size := len(fn.Params)
+	if fn.Rest != nil {
+		size++
+	}
+	funcEnv := newEnv(fn.Env.(*Env), size)
+	funcEnv.depth = env.depth + 1
+	funcEnv.ctx = env.ctx
+	funcEnv.session = env.session
+	funcEnv.actor = env.actor
+	funcEnv.generator = env.generator
 
 	// Bind parameters to arguments
 	for i, param := range fn.Params {
 		funcEnv.Define(param.Name, args[i])
 	}
-
-	// Evaluate body in new environment
-	return Eval(fn.Body, funcEnv)
+	if fn.Rest != nil {
+		rest := append([]sexpr.SExpr{}, args[len(fn.Params):]...)
+		funcEnv.Define(fn.Rest.Name, sexpr.List{Elements: rest})
+	}
+	return funcEnv, nil
 }
 
 // isTruthy determines if a value is truthy
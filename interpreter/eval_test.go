@@ -1,7 +1,10 @@
 package interpreter
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/zylisp/lang/parser"
 	"github.com/zylisp/lang/sexpr"
@@ -31,6 +34,31 @@ func testEval(t *testing.T, input string, expected sexpr.SExpr) {
 	}
 }
 
+// evalForms evaluates each input in env in order and returns the result
+// of the last one
+func evalForms(t *testing.T, env *Env, inputs ...string) sexpr.SExpr {
+	t.Helper()
+
+	var result sexpr.SExpr
+	for _, input := range inputs {
+		tokens, err := parser.Tokenize(input)
+		if err != nil {
+			t.Fatalf("tokenize error: %v", err)
+		}
+
+		expr, err := parser.Read(tokens)
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+
+		result, err = Eval(expr, env)
+		if err != nil {
+			t.Fatalf("eval error in %s: %v", input, err)
+		}
+	}
+	return result
+}
+
 func TestEvalSelfEvaluating(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -153,3 +181,472 @@ func TestEvalQuote(t *testing.T) {
 		t.Errorf("got %d elements, want 3", len(list.Elements))
 	}
 }
+
+func TestEvalDelayAndForce(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	calls := 0
+	env.Define("tick", sexpr.Primitive{
+		Name: "tick",
+		Fn: func(args []sexpr.SExpr, env interface{}) (sexpr.SExpr, error) {
+			calls++
+			return sexpr.Number{Value: int64(calls)}, nil
+		},
+	})
+
+	for _, input := range []string{"(define p (delay (tick)))", "(promise? p)"} {
+		tokens, _ := parser.Tokenize(input)
+		expr, _ := parser.Read(tokens)
+		if _, err := Eval(expr, env); err != nil {
+			t.Fatalf("eval error: %v", err)
+		}
+	}
+
+	if calls != 0 {
+		t.Fatalf("delay should not evaluate its body, ran %d times", calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		tokens, _ := parser.Tokenize("(force p)")
+		expr, _ := parser.Read(tokens)
+		result, err := Eval(expr, env)
+		if err != nil {
+			t.Fatalf("eval error: %v", err)
+		}
+		if !result.Equal(sexpr.Number{Value: 1}) {
+			t.Errorf("got %v, want 1", result)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("body ran %d times, want 1", calls)
+	}
+}
+
+func TestEvalCollectionLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{":key", ":key"},
+		{"[1 (+ 1 1) :c]", "[1 2 :c]"},
+		{"{:a (+ 1 2)}", "{:a 3}"},
+		{"#{(+ 1 1) 3}", "#{2 3}"},
+		{"(quote [a b])", "[a b]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestEvalLet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(let ((x 1) (y 2)) (+ x y))", "3"},
+		{"(let () 42)", "42"},
+		{"(let ((x 1)) (define y 2) (+ x y))", "3"},
+		// Values see the enclosing scope, not earlier bindings
+		{"((lambda (x) (let ((x 10) (y x)) y)) 1)", "1"},
+		{"(let* ((x 1) (y (+ x 1))) (* x y))", "2"},
+		{"(let* ((x 1) (x (+ x 1))) x)", "2"},
+		{"(letrec ((even? (lambda (n) (if (= n 0) true (odd? (- n 1))))) " +
+			"(odd? (lambda (n) (if (= n 0) false (even? (- n 1)))))) " +
+			"(even? 10))", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestEvalLetScope(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	evalForms(t, env, "(define x 1)", "(let ((x 2)) (define y x) y)")
+
+	if v, _ := env.Lookup("x"); !v.Equal(sexpr.Number{Value: 1}) {
+		t.Errorf("outer x = %v, want 1", v)
+	}
+	if _, err := env.Lookup("y"); err == nil {
+		t.Error("define inside let body should not leak into the enclosing scope")
+	}
+}
+
+func TestEvalLetErrors(t *testing.T) {
+	tests := []string{
+		"(let ((x 1)))",
+		"(let (x 1) x)",
+		"(let ((1 2)) 1)",
+		"(let ((x)) x)",
+		"(let x 1)",
+		"(let* ((x 1) (y z)) y)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestEvalCond(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(cond (false 1) (true 2))", "2"},
+		{"(cond ((< 2 1) 1) ((> 2 1) 2) (else 3))", "2"},
+		{"(cond (false 1) (else 2 3))", "3"},
+		{"(cond (false 1))", "nil"},
+		{"(cond ((+ 1 2)))", "3"},
+		{"(cond ((+ 1 2) => (lambda (x) (* x 10))))", "30"},
+		{"(cond)", "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestEvalCondErrors(t *testing.T) {
+	tests := []string{
+		"(cond 1)",
+		"(cond ())",
+		"(cond (else 1) (true 2))",
+		"(cond (true => car cdr))",
+		"(cond (undefined-var 1))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestEvalAndOr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(and)", "true"},
+		{"(and 1 2 3)", "3"},
+		{"(and 1 false 3)", "false"},
+		{"(or)", "false"},
+		{"(or false 2 3)", "2"},
+		{"(or false false)", "false"},
+		{"(or (list) 1)", "()"},
+		// Operands after the deciding value are not evaluated
+		{"(and false undefined-var)", "false"},
+		{"(or 1 undefined-var)", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestEvalBegin(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		"(define f (lambda (x) (begin (define y (* x 2)) (+ y 1))))",
+		"(if true (begin 1 2 (f 3)) 0)")
+	if !result.Equal(sexpr.Number{Value: 7}) {
+		t.Errorf("got %v, want 7", result)
+	}
+
+	// Definitions at top level go into the enclosing environment
+	evalForms(t, env, "(begin (define a 1) (define b 2))")
+	if v, err := env.Lookup("b"); err != nil || !v.Equal(sexpr.Number{Value: 2}) {
+		t.Errorf("b = %v, %v", v, err)
+	}
+
+	if result := evalForms(t, env, "(begin)"); !result.Equal(sexpr.Nil{}) {
+		t.Errorf("(begin) = %v, want nil", result)
+	}
+}
+
+func TestEvalDefineFunction(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		"(define (square x) (* x x))",
+		"(square 7)")
+	if !result.Equal(sexpr.Number{Value: 49}) {
+		t.Errorf("got %v, want 49", result)
+	}
+
+	// Several body expressions run in order
+	result = evalForms(t, env,
+		"(define (f) (define a 1) (+ a 1))",
+		"(f)")
+	if !result.Equal(sexpr.Number{Value: 2}) {
+		t.Errorf("got %v, want 2", result)
+	}
+
+	result = evalForms(t, env,
+		"(define (fact n) (if (= n 0) 1 (* n (fact (- n 1)))))",
+		"(fact 10)")
+	if !result.Equal(sexpr.Number{Value: 3628800}) {
+		t.Errorf("got %v, want 3628800", result)
+	}
+}
+
+func TestEvalDefineErrors(t *testing.T) {
+	tests := []string{
+		"(define)",
+		"(define x)",
+		"(define (f x))",
+		"(define () 1)",
+		"(define (1 x) x)",
+		"(define (f 1) 1)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestEvalVariadicLambda(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"((lambda args args))", "()"},
+		{"((lambda args args) 1 2 3)", "(1 2 3)"},
+		{"((lambda (a b . rest) (list a b rest)) 1 2)", "(1 2 ())"},
+		{"((lambda (a b . rest) (list a b rest)) 1 2 3 4)", "(1 2 (3 4))"},
+		{"(begin (define (f first . more) more) (f 1 2 3))", "(2 3)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestEvalLambdaArityErrors(t *testing.T) {
+	tests := []string{
+		"((lambda (a b . rest) a) 1)",
+		"((lambda (a) a))",
+		"((lambda (a) a) 1 2)",
+		"(lambda (a . 1) a)",
+		"(lambda 1 1)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestEvalSet(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	// A closure keeps its own counter
+	result := evalForms(t, env,
+		"(define (make-counter) (let ((n 0)) (lambda () (set! n (+ n 1)))))",
+		"(define c (make-counter))",
+		"(c)",
+		"(c)")
+	if !result.Equal(sexpr.Number{Value: 2}) {
+		t.Errorf("got %v, want 2", result)
+	}
+
+	// set! changes the binding in the scope that defines it
+	evalForms(t, env, "(define x 1)", "(let ((y 0)) (set! x 5))")
+	if v, _ := env.Lookup("x"); !v.Equal(sexpr.Number{Value: 5}) {
+		t.Errorf("x = %v, want 5", v)
+	}
+}
+
+func TestEvalSetErrors(t *testing.T) {
+	tests := []string{
+		"(set! undefined-var 1)",
+		"(set! 1 2)",
+		"(set! x)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestEvalWhenUnless(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(when true 1 2)", "2"},
+		{"(when false undefined-var)", "nil"},
+		{"(when (< 1 2))", "nil"},
+		{"(unless false 1 2)", "2"},
+		{"(unless true undefined-var)", "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	testEvalError(t, "(when)")
+	testEvalError(t, "(unless)")
+}
+
+func TestEvalCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(case (+ 1 1) ((1) 1) ((2 3) 2 3) (else 0))", "3"},
+		{"(case 5 ((1) 1) (else 0))", "0"},
+		{"(case 5 ((1) 1))", "nil"},
+		{"(case :b ((:a) 1) ((:b :c) 2))", "2"},
+		{`(case "x" (("x") 1))`, "1"},
+		{"(case (quote sym) ((sym) 1))", "1"},
+		{"(case 4 ((4) => (lambda (k) (* k k))))", "16"},
+		{"(case 4 (else => (lambda (k) (+ k 1))))", "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestEvalCaseErrors(t *testing.T) {
+	tests := []string{
+		"(case)",
+		"(case 1 (1 2))",
+		"(case 1 ((1)))",
+		"(case 1 (else 0) ((1) 1))",
+		"(case 1 ((1) => car cdr))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestEvalContext(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))")
+
+	if got := env.Context(); got != context.Background() {
+		t.Errorf("Context() = %v, want context.Background()", got)
+	}
+
+	tests := []string{
+		"(loop () (recur))",
+		"(fib 100)",
+		"(try (loop () (recur)) (catch e :caught))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			tokens, err := parser.Tokenize(input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+			expr, err := parser.Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err = EvalContext(ctx, expr, env)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got error %v, want deadline exceeded", err)
+			}
+		})
+	}
+
+	t.Run("define", func(t *testing.T) {
+		expr := sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.Symbol{Name: "define"}, sexpr.Symbol{Name: "x"}, sexpr.Number{Value: 1},
+		}}
+		if _, err := EvalContext(context.Background(), expr, env); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := env.Lookup("x"); err != nil {
+			t.Errorf("x not defined in env: %v", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := EvalContext(ctx, sexpr.Number{Value: 1}, env)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want canceled", err)
+		}
+	})
+}
+
+func TestPrimitiveLoopsInterrupted(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		"(define xs (reverse (range 10000)))",
+		`(define strs (map number->string xs))`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := *env
+	canceled.ctx = ctx
+
+	tests := []struct {
+		name string
+		args string
+	}{
+		{"range", "(list 100000)"},
+		{"iota", "(list 100000)"},
+		{"sort", "(list xs)"},
+		{"sort-by", "(list - xs)"},
+		{"make-vector", "(list 100000)"},
+		{"str", "xs"},
+		{"string-append", "strs"},
+		{"string-join", "(list strs)"},
+		{"string-split", `(list (string-join strs " "))`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prim := evalForms(t, env, tt.name).(sexpr.Primitive)
+			args, _ := sexpr.Elements(evalForms(t, env, tt.args))
+			_, err := prim.Fn(args, &canceled)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v, want canceled", err)
+			}
+		})
+	}
+}
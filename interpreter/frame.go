@@ -0,0 +1,155 @@
+package interpreter
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// indexThreshold is the number of names above which a frame keeps a map
+// from names to slots instead of scanning its names
+const indexThreshold = 8
+
+// frame holds the bindings of one environment. Slots are never removed
+// or reordered, so a slot found once stays valid for the frame's life;
+// resolved variable references rely on this.
+type frame struct {
+	mu     sync.RWMutex
+	names  []string
+	values []sexpr.SExpr
+	index  map[string]int // name to slot, for large frames
+	bloom  atomic.Uint64  // nameBit of every bound name, readable without the lock
+}
+
+func newFrame(size int) *frame {
+	// Allocate the frames of most calls together with their slots
+	switch size {
+	case 1:
+		s := &struct {
+			frame
+			names  [1]string
+			values [1]sexpr.SExpr
+		}{}
+		s.frame.names, s.frame.values = s.names[:0], s.values[:0]
+		return &s.frame
+	case 2:
+		s := &struct {
+			frame
+			names  [2]string
+			values [2]sexpr.SExpr
+		}{}
+		s.frame.names, s.frame.values = s.names[:0], s.values[:0]
+		return &s.frame
+	case 3:
+		s := &struct {
+			frame
+			names  [3]string
+			values [3]sexpr.SExpr
+		}{}
+		s.frame.names, s.frame.values = s.names[:0], s.values[:0]
+		return &s.frame
+	}
+	return &frame{
+		names:  make([]string, 0, size),
+		values: make([]sexpr.SExpr, 0, size),
+	}
+}
+
+// nameBit returns the bit a name sets in a frame's bloom filter. A clear
+// bit proves the frame does not bind the name.
+func nameBit(name string) uint64 {
+	if name == "" {
+		return 1
+	}
+	return 1 << ((uint(len(name))*31 + uint(name[0]) + uint(name[len(name)-1])) & 63)
+}
+
+// slot returns the slot of name, or -1. The caller holds the lock.
+func (f *frame) slot(name string) int {
+	if !f.binds(nameBit(name)) {
+		return -1
+	}
+	if f.index != nil {
+		if i, ok := f.index[name]; ok {
+			return i
+		}
+		return -1
+	}
+	for i := len(f.names) - 1; i >= 0; i-- {
+		if f.names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// define binds name in the frame, reusing its slot if it has one
+func (f *frame) define(name string, value sexpr.SExpr) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.slot(name); i >= 0 {
+		f.values[i] = value
+		return
+	}
+
+	f.names = append(f.names, name)
+	f.values = append(f.values, value)
+	f.bloom.Or(nameBit(name))
+	switch {
+	case f.index != nil:
+		f.index[name] = len(f.names) - 1
+	case len(f.names) > indexThreshold:
+		f.index = make(map[string]int, len(f.names)*2)
+		for i, n := range f.names {
+			f.index[n] = i
+		}
+	}
+}
+
+// set changes the value of name if the frame binds it
+func (f *frame) set(name string, value sexpr.SExpr) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.slot(name); i >= 0 {
+		f.values[i] = value
+		return true
+	}
+	return false
+}
+
+// lookup returns the value of name if the frame binds it
+func (f *frame) lookup(name string) (sexpr.SExpr, bool) {
+	if !f.binds(nameBit(name)) {
+		return nil, false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if i := f.slot(name); i >= 0 {
+		return f.values[i], true
+	}
+	return nil, false
+}
+
+// find returns the slot of name, or -1
+func (f *frame) find(name string) int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.slot(name)
+}
+
+// at returns the value in slot i if it holds name
+func (f *frame) at(i int, name string) (sexpr.SExpr, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if i >= 0 && i < len(f.names) && f.names[i] == name {
+		return f.values[i], true
+	}
+	return nil, false
+}
+
+// binds reports whether the frame may bind a name with the given
+// nameBit; false is certain, true may be a false positive
+func (f *frame) binds(bit uint64) bool {
+	return f.bloom.Load()&bit != 0
+}
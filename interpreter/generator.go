@@ -0,0 +1,109 @@
+package interpreter
+
+import (
+	"sync"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Generator is a body of code that produces values on demand. Each call
+// to next runs the body until it yields a value, then suspends it until
+// the next call. The body runs on its own goroutine, which stays blocked
+// if the generator is abandoned before it finishes, unless evaluation is
+// cancelled through its context.
+type Generator struct {
+	body []sexpr.SExpr
+	env  *Env
+
+	mu       sync.Mutex // serializes next
+	started  bool
+	finished bool
+	resume   chan struct{}
+	yields   chan yielded
+}
+
+// yielded is a value passed from a generator's body to next. done is set
+// when the body has finished, with err if it failed.
+type yielded struct {
+	value sexpr.SExpr
+	err   error
+	done  bool
+}
+
+func (g *Generator) String() string {
+	return "#<generator>"
+}
+
+// Equal reports whether other is the same generator
+func (g *Generator) Equal(other sexpr.SExpr) bool {
+	o, ok := other.(*Generator)
+	return ok && g == o
+}
+
+// evalGenerator handles (generator body...), returning a generator that
+// runs body in a new scope, producing each value passed to yield
+func evalGenerator(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	return &Generator{
+		body:   list.Elements[1:],
+		env:    env,
+		resume: make(chan struct{}),
+		yields: make(chan yielded),
+	}, nil
+}
+
+// next runs the generator to its next yield. It returns false once the
+// body has finished, and the body's error if it failed.
+func (g *Generator) next(env *Env) (sexpr.SExpr, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.finished {
+		return nil, false, nil
+	}
+
+	if !g.started {
+		g.started = true
+		genEnv := g.env.Extend()
+		genEnv.ctx = env.background().ctx
+		genEnv.session = env.session
+		genEnv.generator = g
+		go func() {
+			_, err := evalBody(g.body, genEnv)
+			select {
+			case g.yields <- yielded{err: err, done: true}:
+			case <-genEnv.Context().Done():
+			}
+		}()
+	} else {
+		select {
+		case g.resume <- struct{}{}:
+		case <-env.Context().Done():
+			return nil, false, env.interrupted()
+		}
+	}
+
+	select {
+	case y := <-g.yields:
+		if y.done {
+			g.finished = true
+			return nil, false, y.err
+		}
+		return y.value, true, nil
+	case <-env.Context().Done():
+		return nil, false, env.interrupted()
+	}
+}
+
+// yield passes value to the caller of next and waits to be resumed
+func (g *Generator) yield(value sexpr.SExpr, env *Env) error {
+	select {
+	case g.yields <- yielded{value: value}:
+	case <-env.Context().Done():
+		return env.interrupted()
+	}
+	select {
+	case <-g.resume:
+		return nil
+	case <-env.Context().Done():
+		return env.interrupted()
+	}
+}
@@ -0,0 +1,73 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(generator (yield 1))", "#<generator>"},
+		{"(generator? (generator))", "true"},
+		{"(generator? 1)", "false"},
+		{"(let ((g (generator (yield 1) (yield 2)))) (list (next g) (next g) (next g)))", "(1 2 nil)"},
+		{"(let ((g (generator))) (list (next g :done) (next g :done)))", "(:done :done)"},
+		// The body runs lazily, only as far as each next needs
+		{`(let ((a (atom 0)))
+		   (define g (generator (reset! a 1) (yield :x) (reset! a 2)))
+		   (list (deref a) (next g) (deref a) (next g) (deref a)))`, "(0 :x 1 nil 2)"},
+		// Yield from a function called by the body
+		{`(begin
+		   (define (count-from n) (yield n) (count-from (+ n 1)))
+		   (define g (generator (count-from 10)))
+		   (list (next g) (next g) (next g)))`, "(10 11 12)"},
+		// A consumer pipeline over a generator of squares
+		{`(begin
+		   (define (squares n) (generator (loop ((i 1)) (when (<= i n) (yield (* i i)) (recur (+ i 1))))))
+		   (define g (squares 4))
+		   (loop ((sum 0))
+		     (let ((v (next g :end)))
+		       (if (equal? v :end) sum (recur (+ sum v))))))`, "30"},
+		{"(let ((g (generator (yield 1) (car 1)))) (next g) (try (next g) (catch e :failed)))", ":failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []string{
+		"(yield 1)",
+		"(next 1)",
+		"(next (generator) 1 2)",
+		"(next (generator (yield)))",
+		"(next (generator (car 1)))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestGeneratorInterrupted(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define g (generator (loop () (recur))))")
+	expr := evalForms(t, env, "'(next g)")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := EvalContext(ctx, expr, env); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want deadline exceeded", err)
+	}
+}
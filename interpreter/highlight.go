@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zylisp/lang/parser"
+)
+
+// SpanKind classifies a range of source text for syntax highlighting
+type SpanKind int
+
+const (
+	SpanParen       SpanKind = iota // a bracket: ( ) [ ] { } or #{
+	SpanNumber                      // a number
+	SpanString                      // a string, interpolated string or character
+	SpanSymbol                      // a symbol
+	SpanSpecialForm                 // the name of a special form in operator position
+	SpanComment                     // a comment
+	SpanKeyword                     // a keyword such as :name
+	SpanBool                        // true, false or nil
+	SpanPunct                       // a quote, unquote, dot, tag or discard marker
+	SpanInvalid                     // text that is not zylisp syntax
+)
+
+// String returns the kind's name, such as "special-form", which is
+// suitable as a CSS class
+func (k SpanKind) String() string {
+	switch k {
+	case SpanParen:
+		return "paren"
+	case SpanNumber:
+		return "number"
+	case SpanString:
+		return "string"
+	case SpanSymbol:
+		return "symbol"
+	case SpanSpecialForm:
+		return "special-form"
+	case SpanComment:
+		return "comment"
+	case SpanKeyword:
+		return "keyword"
+	case SpanBool:
+		return "bool"
+	case SpanPunct:
+		return "punct"
+	case SpanInvalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
+
+// Span is a classified range of source text, from byte offset Start up
+// to End
+type Span struct {
+	Start, End int
+	Kind       SpanKind
+}
+
+// spanKinds maps the tokens that are highlighted to their kinds
+var spanKinds = map[parser.TokenType]SpanKind{
+	parser.LPAREN:          SpanParen,
+	parser.RPAREN:          SpanParen,
+	parser.LBRACKET:        SpanParen,
+	parser.RBRACKET:        SpanParen,
+	parser.LBRACE:          SpanParen,
+	parser.RBRACE:          SpanParen,
+	parser.SETOPEN:         SpanParen,
+	parser.NUMBER:          SpanNumber,
+	parser.STRING:          SpanString,
+	parser.INTERPOLATED:    SpanString,
+	parser.CHAR:            SpanString,
+	parser.SYMBOL:          SpanSymbol,
+	parser.COMMENT:         SpanComment,
+	parser.KEYWORD:         SpanKeyword,
+	parser.BOOL:            SpanBool,
+	parser.NIL:             SpanBool,
+	parser.QUOTE:           SpanPunct,
+	parser.QUASIQUOTE:      SpanPunct,
+	parser.UNQUOTE:         SpanPunct,
+	parser.UNQUOTESPLICING: SpanPunct,
+	parser.DOT:             SpanPunct,
+	parser.TAG:             SpanPunct,
+	parser.DISCARD:         SpanPunct,
+}
+
+// Highlight classifies the tokens of src for syntax highlighting, in
+// order. Whitespace is not covered by any span. A symbol naming a special
+// form is a SpanSpecialForm when it follows an opening parenthesis.
+// Source that cannot be read, such as a string still being typed, is
+// classified as far as possible: an unterminated string runs to the end,
+// and any other character the lexer rejects is a SpanInvalid.
+func Highlight(src string) []Span {
+	var spans []Span
+	for offset := 0; offset < len(src); {
+		tokens, err := parser.TokenizeWithTrivia(src[offset:])
+		end, operator := offset, false
+		for _, tok := range tokens {
+			start := offset + tok.Offset
+			end = start + len(tok.Raw)
+			if tok.Type == parser.WHITESPACE || tok.Type == parser.EOF {
+				continue
+			}
+
+			kind := spanKinds[tok.Type]
+			if kind == SpanSymbol && operator && specialForms[tok.Value] {
+				kind = SpanSpecialForm
+			}
+			if tok.Type != parser.COMMENT {
+				operator = tok.Type == parser.LPAREN
+			}
+			spans = append(spans, Span{Start: start, End: end, Kind: kind})
+		}
+		if err == nil {
+			break
+		}
+
+		rest := src[end:]
+		if errors.Is(err, parser.ErrIncomplete) {
+			kind := SpanInvalid
+			if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, `#"`) {
+				kind = SpanString
+			}
+			spans = append(spans, Span{Start: end, End: len(src), Kind: kind})
+			break
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		if size == 0 {
+			break
+		}
+		spans = append(spans, Span{Start: end, End: end + size, Kind: SpanInvalid})
+		offset = end + size
+	}
+	return spans
+}
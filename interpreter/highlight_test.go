@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+// highlighted renders the spans of src as "kind:text" items
+func highlighted(src string) string {
+	var parts []string
+	for _, span := range Highlight(src) {
+		parts = append(parts, span.Kind.String()+":"+src[span.Start:span.End])
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"", ""},
+		{"(+ 1 2)", "paren:( symbol:+ number:1 number:2 paren:)"},
+		{`(define x "hi") ; note`, `paren:( special-form:define symbol:x string:"hi" paren:) comment:; note`},
+		{"(f if)", "paren:( symbol:f symbol:if paren:)"},
+		{"[if] (\n  ; c\n  if)", "paren:[ symbol:if paren:] paren:( comment:; c special-form:if paren:)"},
+		{"{:a true} #{1.5}", "paren:{ keyword::a bool:true paren:} paren:#{ number:1.5 paren:}"},
+		{"'(a . b) `(,x ,@y) #_z", "punct:' paren:( symbol:a punct:. symbol:b paren:) punct:` paren:( punct:, symbol:x punct:,@ symbol:y paren:) punct:#_ symbol:z"},
+		{`#"a${b}" #u8(1)`, `string:#"a${b}" punct:#u8 paren:( number:1 paren:)`},
+		{`(display "unfinished`, `paren:( symbol:display string:"unfinished`},
+		{"(a @ b)", "paren:( symbol:a invalid:@ symbol:b paren:)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			if got := highlighted(tt.src); got != tt.expected {
+				t.Errorf("got  %s\nwant %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHighlightCoversTokens(t *testing.T) {
+	src := "(define (f x)\n  ;; square\n  (* x x))\n"
+	prev := 0
+	for _, span := range Highlight(src) {
+		if span.Start < prev || span.End <= span.Start {
+			t.Fatalf("span %v out of order", span)
+		}
+		if gap := src[prev:span.Start]; strings.TrimSpace(gap) != "" {
+			t.Errorf("text %q is not highlighted", gap)
+		}
+		prev = span.End
+	}
+	if strings.TrimSpace(src[prev:]) != "" {
+		t.Errorf("text %q is not highlighted", src[prev:])
+	}
+}
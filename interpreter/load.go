@@ -0,0 +1,108 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// SourceExt is the file extension of zylisp source files
+const SourceExt = ".zy"
+
+// LoadFile reads every form in the file at path and evaluates them in
+// order in env, returning the value of the last one
+func LoadFile(path string, env *Env) (sexpr.SExpr, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	exprs, err := parser.ReadSource(path, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return evalBody(exprs, env)
+}
+
+// SearchPath returns the directories searched by require: the entries
+// of ZYLISP_PATH, or the current directory if it is unset
+func SearchPath() []string {
+	if path := os.Getenv("ZYLISP_PATH"); path != "" {
+		return filepath.SplitList(path)
+	}
+	return []string{"."}
+}
+
+// primLoad handles (load path), evaluating the file in the environment
+// of the call
+func primLoad(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("load", 1, 1, len(args))
+	}
+
+	path, ok := args[0].(sexpr.String)
+	if !ok {
+		return nil, typeError("load", "string", args[0])
+	}
+
+	result, err := LoadFile(path.Value, env)
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+	return result, nil
+}
+
+// primRequire handles (require 'name). The first time a name is
+// required, name.zy is found on the search path and loaded into the
+// global environment; later calls do nothing. It returns the module
+// named name if the file defined one, and nil otherwise.
+func primRequire(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("require", 1, 1, len(args))
+	}
+
+	name, ok := args[0].(sexpr.Symbol)
+	if !ok {
+		return nil, typeError("require", "symbol", args[0])
+	}
+
+	rt := env.Runtime()
+	if !rt.markRequired(name.Name) {
+		return requiredModule(rt, name.Name), nil
+	}
+
+	path, err := findSource(name.Name)
+	if err == nil {
+		_, err = LoadFile(path, env.Global())
+	}
+	if err != nil {
+		rt.unmarkRequired(name.Name)
+		return nil, fmt.Errorf("require %s: %w", name.Name, err)
+	}
+	return requiredModule(rt, name.Name), nil
+}
+
+// findSource looks for name.zy in the directories of the search path
+func findSource(name string) (string, error) {
+	for _, dir := range SearchPath() {
+		path := filepath.Join(dir, filepath.FromSlash(name)+SourceExt)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", evalError("", "%s%s not found in %v", name, SourceExt, SearchPath())
+}
+
+func requiredModule(rt *Runtime, name string) sexpr.SExpr {
+	if module, ok := rt.Module(name); ok {
+		return module
+	}
+	return sexpr.Nil{}
+}
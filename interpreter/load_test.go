@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSource(t *testing.T, dir, name, src string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSource(t, dir, "defs.zy", "(define a 1)\n; comment\n(define (inc x) (+ x a))\n(inc 41)")
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env, `(load "`+filepath.ToSlash(path)+`")`)
+	if result.String() != "42" {
+		t.Errorf("got %v, want 42", result)
+	}
+	if result := evalForms(t, env, "(inc 1)"); result.String() != "2" {
+		t.Errorf("got %v, want 2", result)
+	}
+
+	// Forms are evaluated in the environment of the call
+	result = evalForms(t, env, `(let ((a 10)) (load "`+filepath.ToSlash(path)+`") (inc 0))`)
+	if result.String() != "1" {
+		t.Errorf("got %v, want 1", result)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "counter.zy", `
+(set! loads (+ loads 1))
+(module counter (export twice) (define (twice x) (* 2 x)))`)
+	writeSource(t, dir, "lib/util.zy", "(define util-loaded true)")
+	t.Setenv("ZYLISP_PATH", filepath.Join(t.TempDir(), "missing")+string(filepath.ListSeparator)+dir)
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define loads 0)")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(require 'counter)", "#<module counter>"},
+		{"(require 'counter)", "#<module counter>"},
+		// The file is only loaded once
+		{"loads", "1"},
+		{"(counter/twice 4)", "8"},
+		{"(require 'lib/util)", "nil"},
+		{"util-loaded", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := evalForms(t, env, tt.input)
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeSource(t, dir, "bad.zy", "(define x")
+	t.Setenv("ZYLISP_PATH", dir)
+
+	tests := []string{
+		`(load "` + filepath.ToSlash(filepath.Join(dir, "missing.zy")) + `")`,
+		`(load "` + filepath.ToSlash(bad) + `")`,
+		`(load 1)`,
+		`(require 'missing)`,
+		`(require 'bad)`,
+		`(require "bad")`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loopMarker is bound in the body environment of a loop so that recur
+// can check that it appears inside one. It cannot be written as a symbol.
+const loopMarker = "#loop"
+
+// recurSignal is the value of (recur args...). It passes unchanged
+// through forms in tail position back to the enclosing loop, which
+// rebinds its variables and runs the body again.
+type recurSignal struct {
+	args []sexpr.SExpr
+}
+
+func (r recurSignal) String() string {
+	return "#<recur>"
+}
+
+func (r recurSignal) Equal(other sexpr.SExpr) bool {
+	return false
+}
+
+// evalLoop handles (loop ((name init)...) body...). The body runs with
+// the names bound like let; when it ends in (recur value...), the names
+// are rebound to the new values and the body runs again. Iteration does
+// not grow the Go stack or the environment chain.
+func evalLoop(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	bindings, err := parseBindings("loop", list)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]sexpr.SExpr, len(bindings))
+	for i, b := range bindings {
+		if values[i], err = Eval(b.value, env); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if err := env.interrupted(); err != nil {
+			return nil, err
+		}
+
+		// A fresh environment per iteration keeps closures created in
+		// one iteration from seeing the next iteration's values
+		loopEnv := env.Extend()
+		loopEnv.Define(loopMarker, sexpr.Bool{Value: true})
+		for i, b := range bindings {
+			if err := bindPattern("loop", loopEnv, b.pattern, values[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := evalBody(list.Elements[2:], loopEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		recur, ok := result.(recurSignal)
+		if !ok {
+			return result, nil
+		}
+		if len(recur.args) != len(bindings) {
+			return nil, evalError("recur", "loop has %d bindings, got %d values", len(bindings), len(recur.args))
+		}
+		values = recur.args
+	}
+}
+
+// evalRecur handles (recur value...), which must be in tail position in
+// the body of a loop
+func evalRecur(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if _, err := env.Lookup(loopMarker); err != nil {
+		return nil, evalError("recur", "not inside a loop")
+	}
+
+	args, err := evalEach(list.Elements[1:], env)
+	if err != nil {
+		return nil, err
+	}
+	return recurSignal{args: args}, nil
+}
@@ -0,0 +1,52 @@
+package interpreter
+
+import "testing"
+
+func TestLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(loop ((i 0) (acc 0)) (if (> i 10) acc (recur (+ i 1) (+ acc i))))", "55"},
+		{"(loop () 42)", "42"},
+		// Deep enough to overflow the stack if each iteration recursed
+		{"(loop ((i 0)) (if (= i 100000) i (recur (+ i 1))))", "100000"},
+		// recur is found through cond, let and begin in tail position
+		{"(loop ((i 0)) (cond ((< i 3) (let ((j (+ i 1))) (begin (recur j)))) (else i)))", "3"},
+		{"(loop ((xs (list 1 2 3)) (n 0)) (if (null? xs) n (recur (cdr xs) (+ n 1))))", "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestLoopClosuresCaptureEachIteration(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		"(define fs (loop ((i 0) (acc (list))) (if (= i 3) acc (recur (+ i 1) (cons (lambda () i) acc)))))",
+		"((car fs))")
+	if result.String() != "2" {
+		t.Errorf("got %v, want 2", result)
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []string{
+		"(recur 1)",
+		"(loop ((i 0)) (recur))",
+		"(loop ((i 0)) ((lambda () (recur 1))))",
+		"(loop (i 0) i)",
+		"(loop ((i 0)))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
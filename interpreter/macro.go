@@ -0,0 +1,256 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// evalDefmacro handles (defmacro name (params...) body...). The macro
+// receives its argument forms unevaluated and returns the form to
+// evaluate in place of the call. Macros are not hygienic: use gensym for
+// names introduced by an expansion.
+func evalDefmacro(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) < 4 {
+		return nil, evalError("", "defmacro requires a name, parameters and a body")
+	}
+
+	name, ok := list.Elements[1].(sexpr.Symbol)
+	if !ok {
+		return nil, evalError("defmacro", "name must be a symbol, got %v", list.Elements[1])
+	}
+
+	params, rest, err := parseParams(list.Elements[2])
+	if err != nil {
+		return nil, fmt.Errorf("defmacro: %w", err)
+	}
+
+	exprs := list.Elements[3:]
+	var doc string
+	if s, ok := exprs[0].(sexpr.String); ok && len(exprs) > 1 {
+		doc, exprs = s.Value, exprs[1:]
+	}
+	body := exprs[0]
+	if len(exprs) > 1 {
+		body = sexpr.List{Elements: append([]sexpr.SExpr{sexpr.Symbol{Name: "begin"}}, exprs...)}
+	}
+
+	macro := sexpr.Macro{
+		Name: name.Name,
+		Fn:   sexpr.Func{Params: params, Rest: rest, Body: body, Env: env, Meta: docMeta(doc)},
+	}
+	env.Define(name.Name, macro)
+	return macro, nil
+}
+
+// expandMacro applies a macro to the unevaluated arguments of a call
+// made in env
+func expandMacro(macro sexpr.Macro, args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	expanded, err := apply(macro.Fn, args, env)
+	if err != nil {
+		return nil, fmt.Errorf("expanding %s: %w", macro.Name, err)
+	}
+	return expanded, nil
+}
+
+// Expand1 expands form once if it is a use of a macro defined in env,
+// reporting whether it was. Only the form itself is expanded, not the
+// forms inside it.
+func Expand1(form sexpr.SExpr, env *Env) (sexpr.SExpr, bool, error) {
+	list, ok := form.(sexpr.List)
+	if !ok || len(list.Elements) == 0 {
+		return form, false, nil
+	}
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || specialForms[head.Name] {
+		return form, false, nil
+	}
+	value, err := env.Lookup(head.Name)
+	if err != nil {
+		return form, false, nil
+	}
+	macro, ok := value.(sexpr.Macro)
+	if !ok {
+		return form, false, nil
+	}
+
+	expanded, err := expandMacro(macro, list.Elements[1:], env)
+	if err != nil {
+		return nil, false, err
+	}
+	return expanded, true, nil
+}
+
+// Expand expands form with Expand1 until it is no longer a use of a
+// macro. A macro that keeps expanding to another macro use fails once
+// the runtime's maximum depth is exceeded.
+func Expand(form sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	for {
+		expanded, ok, err := Expand1(form, env)
+		if err != nil || !ok {
+			return expanded, err
+		}
+		if env, err = env.deeper(); err != nil {
+			return nil, err
+		}
+		form = expanded
+	}
+}
+
+// primMacroexpand1 handles (macroexpand-1 form), form expanded once if
+// it is a macro use
+func primMacroexpand1(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("macroexpand-1", 1, 1, len(args))
+	}
+	expanded, _, err := Expand1(args[0], env)
+	return expanded, err
+}
+
+// primMacroexpand handles (macroexpand form), form expanded until it is
+// no longer a macro use
+func primMacroexpand(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("macroexpand", 1, 1, len(args))
+	}
+	return Expand(args[0], env)
+}
+
+// evalQuasiquote handles (quasiquote template): the template is returned
+// unevaluated except for (unquote expr) forms, which are replaced by the
+// value of expr, and (unquote-splicing expr) forms, whose list values
+// are spliced into the enclosing list or vector
+func evalQuasiquote(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 2 {
+		return nil, arityError("quasiquote", 1, 1, len(list.Elements)-1)
+	}
+	return quasiquote(list.Elements[1], 1, env)
+}
+
+// quasiquote expands template at the given nesting depth; only unquotes
+// at depth 1 are evaluated
+func quasiquote(template sexpr.SExpr, depth int, env *Env) (sexpr.SExpr, error) {
+	switch t := template.(type) {
+	case sexpr.Vector:
+		elems, err := quasiquoteSeq(t.Elements(), depth, env)
+		if err != nil {
+			return nil, err
+		}
+		return sexpr.NewVector(elems...), nil
+
+	case sexpr.List, sexpr.Pair:
+		elems, ok := sexpr.Elements(t)
+		if !ok {
+			// Dotted template such as (a . b)
+			pair := t.(sexpr.Pair)
+			car, err := quasiquote(pair.Car, depth, env)
+			if err != nil {
+				return nil, err
+			}
+			cdr, err := quasiquote(pair.Cdr, depth, env)
+			if err != nil {
+				return nil, err
+			}
+			return sexpr.Cons(car, cdr), nil
+		}
+
+		if len(elems) == 2 {
+			if head, ok := elems[0].(sexpr.Symbol); ok {
+				switch head.Name {
+				case "unquote":
+					if depth == 1 {
+						return Eval(elems[1], env)
+					}
+					return quasiquoteNested(head, elems[1], depth-1, env)
+				case "unquote-splicing":
+					if depth == 1 {
+						return nil, evalError("unquote-splicing", "not inside a list")
+					}
+					return quasiquoteNested(head, elems[1], depth-1, env)
+				case "quasiquote":
+					return quasiquoteNested(head, elems[1], depth+1, env)
+				}
+			}
+		}
+
+		// (a . ,b) reads as (a unquote b)
+		if n := len(elems); n > 2 && depth == 1 {
+			if sym, ok := elems[n-2].(sexpr.Symbol); ok && sym.Name == "unquote" {
+				tail, err := Eval(elems[n-1], env)
+				if err != nil {
+					return nil, err
+				}
+				head, err := quasiquoteSeq(elems[:n-2], depth, env)
+				if err != nil {
+					return nil, err
+				}
+				for i := len(head) - 1; i >= 0; i-- {
+					tail = sexpr.Cons(head[i], tail)
+				}
+				return tail, nil
+			}
+		}
+
+		expanded, err := quasiquoteSeq(elems, depth, env)
+		if err != nil {
+			return nil, err
+		}
+		return sexpr.List{Elements: expanded}, nil
+
+	default:
+		return template, nil
+	}
+}
+
+// quasiquoteNested rebuilds (head form) with form expanded at depth
+func quasiquoteNested(head sexpr.Symbol, form sexpr.SExpr, depth int, env *Env) (sexpr.SExpr, error) {
+	expanded, err := quasiquote(form, depth, env)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.List{Elements: []sexpr.SExpr{head, expanded}}, nil
+}
+
+// quasiquoteSeq expands the elements of a list or vector template,
+// splicing in the values of unquote-splicing forms at depth 1
+func quasiquoteSeq(elems []sexpr.SExpr, depth int, env *Env) ([]sexpr.SExpr, error) {
+	result := []sexpr.SExpr{}
+	for _, elem := range elems {
+		if form, ok := unquoteSplicing(elem); ok && depth == 1 {
+			value, err := Eval(form, env)
+			if err != nil {
+				return nil, err
+			}
+			spliced, ok := sexpr.Elements(value)
+			if !ok {
+				if v, isVector := value.(sexpr.Vector); isVector {
+					spliced = v.Elements()
+				} else {
+					return nil, typeError("unquote-splicing", "list", value)
+				}
+			}
+			result = append(result, spliced...)
+			continue
+		}
+
+		expanded, err := quasiquote(elem, depth, env)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expanded)
+	}
+	return result, nil
+}
+
+// unquoteSplicing returns the form inside (unquote-splicing form)
+func unquoteSplicing(expr sexpr.SExpr) (sexpr.SExpr, bool) {
+	list, ok := expr.(sexpr.List)
+	if !ok || len(list.Elements) != 2 {
+		return nil, false
+	}
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || head.Name != "unquote-splicing" {
+		return nil, false
+	}
+	return list.Elements[1], true
+}
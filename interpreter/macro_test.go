@@ -0,0 +1,172 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestQuasiquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"`(1 2)", "(1 2)"},
+		{"`(1 ,(+ 1 1) 3)", "(1 2 3)"},
+		{"`(a ,@(list 1 2) b)", "(a 1 2 b)"},
+		{"`(a ,@(list) b)", "(a b)"},
+		{"`[x ,(+ 1 1) ,@(list 3 4)]", "[x 2 3 4]"},
+		{"`(a . ,(+ 1 2))", "(a . 3)"},
+		{"`x", "x"},
+		{"`,(+ 1 2)", "3"},
+		// Only unquotes nested as deeply as their quasiquotes are evaluated
+		{"`(a `(b ,(c ,(+ 1 2))))", "(a (quasiquote (b (unquote (c 3)))))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestDefmacro(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	evalForms(t, env,
+		"(defmacro my-unless (test . body) `(if ,test false (begin ,@body)))",
+		"(defmacro swap! (a b) (let ((tmp (gensym))) `(let ((,tmp ,a)) (set! ,a ,b) (set! ,b ,tmp))))",
+	)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(my-unless false 1 2)", "2"},
+		// The body is not evaluated when the macro discards it
+		{"(my-unless true undefined-var)", "false"},
+		{"(begin (define x 1) (define y 2) (swap! x y) (list x y))", "(2 1)"},
+		{"my-unless", "#<macro my-unless>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := evalForms(t, env, tt.input)
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMacroExpansionUsesCallerEnvironment(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	// The expansion refers to x, which is resolved where the macro is used
+	result := evalForms(t, env,
+		"(defmacro get-x () 'x)",
+		"(let ((x 42)) (get-x))")
+	if !result.Equal(sexpr.Number{Value: 42}) {
+		t.Errorf("got %v, want 42", result)
+	}
+}
+
+func TestGensym(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	a := evalForms(t, env, "(gensym)")
+	b := evalForms(t, env, `(gensym "tmp")`)
+	if a.Equal(b) {
+		t.Errorf("gensym returned %v twice", a)
+	}
+	if sym, ok := b.(sexpr.Symbol); !ok || sym.Name[:3] != "tmp" {
+		t.Errorf("got %v, want a symbol starting with tmp", b)
+	}
+}
+
+func TestMacroErrors(t *testing.T) {
+	tests := []string{
+		"(defmacro m)",
+		"(defmacro 1 () 1)",
+		"(defmacro m 1 1)",
+		"(begin (defmacro m (a) a) (m))",
+		"`(a ,@1)",
+		"`,@(list 1)",
+		"(gensym 1)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestMacroexpand(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		"(defmacro my-unless (test . body) `(if ,test false (begin ,@body)))",
+		"(defmacro my-not (x) `(my-unless ,x true))",
+		"(define-syntax my-or (syntax-rules () ((_ a b) (if a a b))))",
+		"(define (f x) x)",
+	)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(macroexpand-1 '(my-unless ok (f 1)))", "(if ok false (begin (f 1)))"},
+		{"(macroexpand-1 '(my-not ok))", "(my-unless ok true)"},
+		{"(macroexpand '(my-not ok))", "(if ok false (begin true))"},
+		// Only the outermost form is expanded
+		{"(macroexpand '(f (my-not ok)))", "(f (my-not ok))"},
+		{"(macroexpand '(my-or a b))", "(if a a b)"},
+		{"(macroexpand '(if a b c))", "(if a b c)"},
+		{"(macroexpand 'my-not)", "my-not"},
+		{"(macroexpand '(undefined 1))", "(undefined 1)"},
+		{"(macroexpand '())", "()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := evalForms(t, env, tt.input); got.String() != tt.expected {
+				t.Errorf("got %v, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	// The Go API expands in the given environment
+	form := sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: "my-not"}, sexpr.Symbol{Name: "ok"}}}
+	once, ok, err := Expand1(form, env)
+	if err != nil || !ok || once.String() != "(my-unless ok true)" {
+		t.Errorf("Expand1 = %v, %v, %v", once, ok, err)
+	}
+	if _, ok, _ := Expand1(once, NewEnv(nil)); ok {
+		t.Error("Expand1 expanded a macro undefined in the environment")
+	}
+	if full, err := Expand(form, env); err != nil || full.String() != "(if ok false (begin true))" {
+		t.Errorf("Expand = %v, %v", full, err)
+	}
+}
+
+func TestMacroexpandErrors(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetMaxDepth(50)
+	evalForms(t, env,
+		"(defmacro forever () '(forever))",
+		"(defmacro two (a b) a)",
+	)
+
+	for _, input := range []string{"(macroexpand '(forever))", "(macroexpand-1 '(two 1))", "(macroexpand)"} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := evalString(env, input); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// evalMatch handles (match expr clause...), where each clause is
+// (pattern body...) or (pattern :when guard body...). The value of expr
+// is tested against each pattern in turn; the body of the first clause
+// whose pattern matches, and whose guard is true, is evaluated with the
+// pattern variables bound. It is an error for no clause to match.
+//
+// Patterns are:
+//
+//	_               matches anything
+//	name            matches anything and binds it to name; a name used
+//	                twice must match equal values
+//	'datum          matches values equal to datum
+//	literal         numbers, strings, booleans, keywords and other
+//	                self-evaluating values match equal values
+//	(p ...)         matches a list of the same length
+//	(p ... . rest)  matches a list at least as long, binding rest
+//	[p ...]         matches a vector of the same length
+func evalMatch(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) < 2 {
+		return nil, evalError("", "match requires an expression")
+	}
+
+	value, err := Eval(list.Elements[1], env)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, clause := range list.Elements[2:] {
+		body, clauseEnv, ok, err := matchClause("match", clause, value, env)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return evalBody(body, clauseEnv)
+		}
+	}
+
+	return nil, evalError("match", "no clause matches %v", value)
+}
+
+// matchClause tests value against a (pattern [:when guard] body...)
+// clause of the form named form. If the pattern matches and the guard is
+// true, it returns the body and an environment extending env with the
+// pattern variables bound.
+func matchClause(form string, clause, value sexpr.SExpr, env *Env) ([]sexpr.SExpr, *Env, bool, error) {
+	parts, ok := sexpr.Elements(clause)
+	if !ok || len(parts) == 0 {
+		return nil, nil, false, evalError(form, "clause must be (pattern body...), got %v", clause)
+	}
+
+	b := map[string]sexpr.SExpr{}
+	if !matchPattern(parts[0], value, b) {
+		return nil, nil, false, nil
+	}
+
+	clauseEnv := env.Extend()
+	for name, v := range b {
+		clauseEnv.Define(name, v)
+	}
+
+	body := parts[1:]
+	if len(body) > 0 {
+		if kw, ok := body[0].(sexpr.Keyword); ok && kw.Name == "when" {
+			if len(body) < 2 {
+				return nil, nil, false, evalError(form, ":when requires a guard")
+			}
+			guard, err := Eval(body[1], clauseEnv)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			if !isTruthy(guard) {
+				return nil, nil, false, nil
+			}
+			body = body[2:]
+		}
+	}
+	return body, clauseEnv, true, nil
+}
+
+// matchPattern reports whether value matches pattern, recording the
+// pattern variables in b
+func matchPattern(pattern, value sexpr.SExpr, b map[string]sexpr.SExpr) bool {
+	switch p := pattern.(type) {
+	case sexpr.Symbol:
+		if p.Name == "_" {
+			return true
+		}
+		if bound, ok := b[p.Name]; ok {
+			return bound.Equal(value)
+		}
+		b[p.Name] = value
+		return true
+
+	case sexpr.List, sexpr.Pair, sexpr.Vector:
+		ps, _ := listParts(p)
+		if datum, ok := quotedDatum(p); ok {
+			return datum.Equal(value)
+		}
+
+		vs, ok := listParts(value)
+		if !ok || ps.vector != vs.vector || vs.tail != nil || len(vs.elems) < len(ps.elems) ||
+			(ps.tail == nil && len(vs.elems) != len(ps.elems)) {
+			return false
+		}
+		for i, elem := range ps.elems {
+			if !matchPattern(elem, vs.elems[i], b) {
+				return false
+			}
+		}
+		if ps.tail != nil {
+			rest := append([]sexpr.SExpr{}, vs.elems[len(ps.elems):]...)
+			return matchPattern(ps.tail, sexpr.List{Elements: rest}, b)
+		}
+		return true
+
+	default:
+		return pattern.Equal(value)
+	}
+}
+
+// quotedDatum returns the datum of a (quote datum) pattern
+func quotedDatum(pattern sexpr.SExpr) (sexpr.SExpr, bool) {
+	list, ok := pattern.(sexpr.List)
+	if !ok || len(list.Elements) != 2 {
+		return nil, false
+	}
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || head.Name != "quote" {
+		return nil, false
+	}
+	return list.Elements[1], true
+}
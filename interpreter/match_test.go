@@ -0,0 +1,53 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(match 1 (1 'one) (2 'two))", "one"},
+		{"(match 2 (1 'one) (_ 'other))", "other"},
+		{"(match \"hi\" (\"hi\" 1))", "1"},
+		{"(match :k (:j 1) (:k 2))", "2"},
+		{"(match 'foo ('bar 1) ('foo 2))", "2"},
+		{"(match (list) ('() 'empty))", "empty"},
+		{"(match 5 (x (* x x)))", "25"},
+		{"(match (list 1 2) ((a b) (+ a b)))", "3"},
+		{"(match (list 1 2 3) ((a b) 'two) ((a . rest) rest))", "(2 3)"},
+		{"(match (list 'add 1 2) (('add x y) (+ x y)) (('sub x y) (- x y)))", "3"},
+		{"(match (list 1 (list 2 3)) ((a (b c)) (list c b a)))", "(3 2 1)"},
+		{"(match [1 2] ((a b) 'list) ([a b] 'vector))", "vector"},
+		// A repeated variable must match equal values
+		{"(match (list 1 2) ((x x) 'same) ((x y) 'different))", "different"},
+		{"(match (list 3 3) ((x x) 'same) ((x y) 'different))", "same"},
+		{"(match 5 (x :when (< x 0) 'negative) (x :when (> x 0) 'positive) (_ 'zero))", "positive"},
+		{"(match 0 (x :when (< x 0) 'negative) (_ 'zero))", "zero"},
+		{"(match 1 (_))", "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []string{
+		"(match)",
+		"(match 3 (1 'one) (2 'two))",
+		"(match (list 1) ((a b) a))",
+		"(match 1 x)",
+		"(match 1 (x :when))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Module is a named environment whose exported bindings can be imported
+// into other environments or referred to as module/name
+type Module struct {
+	Name    string
+	Env     *Env
+	Exports []string
+}
+
+func (m *Module) String() string {
+	return "#<module " + m.Name + ">"
+}
+
+// Equal reports whether other is the same module
+func (m *Module) Equal(other sexpr.SExpr) bool {
+	o, ok := other.(*Module)
+	return ok && m == o
+}
+
+// Lookup returns the value of an exported binding
+func (m *Module) Lookup(name string) (sexpr.SExpr, error) {
+	for _, export := range m.Exports {
+		if export == name {
+			return m.Env.Lookup(name)
+		}
+	}
+	return nil, evalError("", "module %s does not export %s", m.Name, name)
+}
+
+// evalModule handles (module name [(export symbol...)] body...). The
+// body is evaluated in a fresh environment extending the global one, so
+// its definitions do not leak into the caller's environment. The module
+// is registered with the runtime under name, replacing any earlier
+// module of that name.
+func evalModule(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) < 2 {
+		return nil, evalError("", "module requires a name")
+	}
+
+	name, ok := list.Elements[1].(sexpr.Symbol)
+	if !ok {
+		return nil, evalError("module", "name must be a symbol, got %v", list.Elements[1])
+	}
+
+	module := &Module{Name: name.Name, Env: env.Global().Extend()}
+	body := list.Elements[2:]
+	if len(body) > 0 {
+		if clause, ok := body[0].(sexpr.List); ok && len(clause.Elements) > 0 &&
+			clause.Elements[0].Equal(sexpr.Symbol{Name: "export"}) {
+			for _, e := range clause.Elements[1:] {
+				sym, ok := e.(sexpr.Symbol)
+				if !ok {
+					return nil, evalError("", "module %s: export must be a symbol, got %v", name.Name, e)
+				}
+				module.Exports = append(module.Exports, sym.Name)
+			}
+			body = body[1:]
+		}
+	}
+
+	if _, err := evalBody(body, module.Env); err != nil {
+		return nil, fmt.Errorf("module %s: %w", name.Name, err)
+	}
+	for _, export := range module.Exports {
+		if _, err := module.Env.Lookup(export); err != nil {
+			return nil, evalError("", "module %s: exported name %s is not defined", name.Name, export)
+		}
+	}
+
+	env.Runtime().AddModule(module)
+	return module, nil
+}
+
+// evalImport handles (import spec...), where each spec is a module name,
+// importing all of its exports, or (name symbol...), importing only the
+// listed ones. Imported values are copied into env.
+func evalImport(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	for _, spec := range list.Elements[1:] {
+		var name sexpr.SExpr = spec
+		var only []sexpr.SExpr
+		if l, ok := spec.(sexpr.List); ok && len(l.Elements) > 0 {
+			name, only = l.Elements[0], l.Elements[1:]
+		}
+
+		sym, ok := name.(sexpr.Symbol)
+		if !ok {
+			return nil, evalError("import", "module name must be a symbol, got %v", name)
+		}
+		module, ok := env.Runtime().Module(sym.Name)
+		if !ok {
+			return nil, evalError("import", "unknown module %s", sym.Name)
+		}
+
+		names := module.Exports
+		if only != nil {
+			names = nil
+			for _, o := range only {
+				s, ok := o.(sexpr.Symbol)
+				if !ok {
+					return nil, evalError("import", "name must be a symbol, got %v", o)
+				}
+				names = append(names, s.Name)
+			}
+		}
+
+		for _, n := range names {
+			value, err := module.Lookup(n)
+			if err != nil {
+				return nil, fmt.Errorf("import: %w", err)
+			}
+			env.Define(n, value)
+		}
+	}
+	return sexpr.Nil{}, nil
+}
+
+// lookupSymbol resolves a symbol in env. A symbol such as math/square
+// that is not bound under its own name refers to the export square of
+// the module math. The module name ends at the last slash, so that
+// modules named like geo/shapes work, except for a trailing slash, which
+// names an export such as / itself.
+func lookupSymbol(name string, env *Env) (sexpr.SExpr, error) {
+	value, err := env.Lookup(name)
+	if err == nil {
+		return value, nil
+	}
+
+	if i := strings.LastIndex(strings.TrimSuffix(name, "/"), "/"); i > 0 {
+		if module, ok := env.Runtime().Module(name[:i]); ok {
+			return module.Lookup(name[i+1:])
+		}
+	}
+	return nil, err
+}
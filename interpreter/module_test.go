@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestModules(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	evalForms(t, env,
+		"(module math (export square cube) (define (square x) (* x x)) (define (cube x) (* x (square x))) (define hidden 1))",
+		"(module greet (export hello) (define (hello name) (str \"hi \" name)))",
+		"(module geo/shapes (export area) (define (area w h) (* w h)))",
+		"(module ops (export /) (define (/ a b) (list a b)))",
+	)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(math/square 3)", "9"},
+		{"(math/cube 2)", "8"},
+		{"(geo/shapes/area 2 3)", "6"},
+		{"(ops// 1 2)", "(1 2)"},
+		{"(begin (import math) (square 4))", "16"},
+		{"(begin (import (greet hello)) (hello \"zy\"))", `"hi zy"`},
+		// Module definitions do not leak into the caller
+		{"(let ((x 1)) (module m (define x 2)) x)", "1"},
+		// Names containing / still resolve normally
+		{"(call/cc (lambda (k) (k 1)))", "1"},
+		{"(module empty)", "#<module empty>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := evalForms(t, env, tt.input)
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	tests := []string{
+		"(begin (module m (export x) (define y 1)) 1)",
+		"(begin (module m (export x) (define x 1) (define y 2)) m/y)",
+		"(begin (module m (define x 1)) (import (m x)))",
+		"(import nowhere)",
+		"nowhere/x",
+		"(module 1)",
+		"(module m (export 1))",
+		"(module m (car 1))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package interpreter
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Numeric tower helpers. Integers are represented as sexpr.Number while
+// they fit in an int64 and are promoted to sexpr.BigInt on overflow.
+// Results are always normalized back to Number when they fit. Integers
+// are exact; sexpr.Float is inexact, and an operation with a float
+// operand gives a float result.
+
+// isNumber reports whether value is a numeric type
+func isNumber(value sexpr.SExpr) bool {
+	switch value.(type) {
+	case sexpr.Number, sexpr.BigInt, sexpr.Float:
+		return true
+	default:
+		return false
+	}
+}
+
+// isInteger reports whether value is an exact integer
+func isInteger(value sexpr.SExpr) bool {
+	switch value.(type) {
+	case sexpr.Number, sexpr.BigInt:
+		return true
+	default:
+		return false
+	}
+}
+
+// floats returns a and b as float64s if either is a float
+func floats(a, b sexpr.SExpr) (float64, float64, bool) {
+	_, ok1 := a.(sexpr.Float)
+	_, ok2 := b.(sexpr.Float)
+	if !ok1 && !ok2 {
+		return 0, 0, false
+	}
+	return toFloat(a), toFloat(b), true
+}
+
+// toFloat converts a numeric value to the nearest float64
+func toFloat(value sexpr.SExpr) float64 {
+	switch v := value.(type) {
+	case sexpr.Number:
+		return float64(v.Value)
+	case sexpr.BigInt:
+		f, _ := new(big.Float).SetInt(v.Value).Float64()
+		return f
+	case sexpr.Float:
+		return v.Value
+	default:
+		panic(fmt.Sprintf("toFloat: not a number: %v", value))
+	}
+}
+
+// toBig converts an integer value to a big.Int
+func toBig(value sexpr.SExpr) *big.Int {
+	switch v := value.(type) {
+	case sexpr.Number:
+		return big.NewInt(v.Value)
+	case sexpr.BigInt:
+		return v.Value
+	default:
+		panic(fmt.Sprintf("toBig: not an integer: %v", value))
+	}
+}
+
+// normalizeBig returns n as a Number when it fits in an int64
+func normalizeBig(n *big.Int) sexpr.SExpr {
+	if n.IsInt64() {
+		return sexpr.Number{Value: n.Int64()}
+	}
+	return sexpr.BigInt{Value: n}
+}
+
+// addNumbers returns a + b
+func addNumbers(a, b sexpr.SExpr) sexpr.SExpr {
+	if x, y, ok := floats(a, b); ok {
+		return sexpr.Float{Value: x + y}
+	}
+	x, ok1 := a.(sexpr.Number)
+	y, ok2 := b.(sexpr.Number)
+	if ok1 && ok2 {
+		sum := x.Value + y.Value
+		if (x.Value^sum)&(y.Value^sum) >= 0 {
+			return sexpr.Number{Value: sum}
+		}
+	}
+	return normalizeBig(new(big.Int).Add(toBig(a), toBig(b)))
+}
+
+// subNumbers returns a - b
+func subNumbers(a, b sexpr.SExpr) sexpr.SExpr {
+	if x, y, ok := floats(a, b); ok {
+		return sexpr.Float{Value: x - y}
+	}
+	x, ok1 := a.(sexpr.Number)
+	y, ok2 := b.(sexpr.Number)
+	if ok1 && ok2 {
+		diff := x.Value - y.Value
+		if (x.Value^y.Value)&(x.Value^diff) >= 0 {
+			return sexpr.Number{Value: diff}
+		}
+	}
+	return normalizeBig(new(big.Int).Sub(toBig(a), toBig(b)))
+}
+
+// mulNumbers returns a * b
+func mulNumbers(a, b sexpr.SExpr) sexpr.SExpr {
+	if x, y, ok := floats(a, b); ok {
+		return sexpr.Float{Value: x * y}
+	}
+	x, ok1 := a.(sexpr.Number)
+	y, ok2 := b.(sexpr.Number)
+	if ok1 && ok2 {
+		if x.Value == 0 || y.Value == 0 {
+			return sexpr.Number{Value: 0}
+		}
+		product := x.Value * y.Value
+		overflow := product/y.Value != x.Value ||
+			(x.Value == -1 && y.Value == math.MinInt64) ||
+			(y.Value == -1 && x.Value == math.MinInt64)
+		if !overflow {
+			return sexpr.Number{Value: product}
+		}
+	}
+	return normalizeBig(new(big.Int).Mul(toBig(a), toBig(b)))
+}
+
+// quoNumbers returns a / b, truncated toward zero if both are integers.
+// Float division by zero gives an infinity or NaN.
+func quoNumbers(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if x, y, ok := floats(a, b); ok {
+		return sexpr.Float{Value: x / y}, nil
+	}
+	if isZero(b) {
+		return nil, evalError("", "division by zero")
+	}
+
+	x, ok1 := a.(sexpr.Number)
+	y, ok2 := b.(sexpr.Number)
+	if ok1 && ok2 && !(x.Value == math.MinInt64 && y.Value == -1) {
+		return sexpr.Number{Value: x.Value / y.Value}, nil
+	}
+	return normalizeBig(new(big.Int).Quo(toBig(a), toBig(b))), nil
+}
+
+// remNumbers returns the remainder of a / b truncated toward zero, which
+// has the sign of a
+func remNumbers(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	if isZero(b) {
+		return nil, evalError("", "division by zero")
+	}
+
+	x, ok1 := a.(sexpr.Number)
+	y, ok2 := b.(sexpr.Number)
+	if ok1 && ok2 {
+		return sexpr.Number{Value: x.Value % y.Value}, nil
+	}
+	return normalizeBig(new(big.Int).Rem(toBig(a), toBig(b))), nil
+}
+
+// modNumbers returns a modulo b, the remainder of a / b floored toward
+// negative infinity, which has the sign of b
+func modNumbers(a, b sexpr.SExpr) (sexpr.SExpr, error) {
+	rem, err := remNumbers(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if !isZero(rem) && (compareNumbers(rem, sexpr.Number{}) < 0) != (compareNumbers(b, sexpr.Number{}) < 0) {
+		return addNumbers(rem, b), nil
+	}
+	return rem, nil
+}
+
+// checkOverflow returns an overflow-error if result, the value of name
+// applied to the int64s a and b, was promoted to a big integer and env's
+// runtime raises on overflow. Without an environment, as when Optimize
+// folds a call, overflow is raised so the call is left to run time.
+func checkOverflow(name string, a, b, result sexpr.SExpr, env *Env) error {
+	_, promoted := result.(sexpr.BigInt)
+	_, ok1 := a.(sexpr.Number)
+	_, ok2 := b.(sexpr.Number)
+	if !promoted || !ok1 || !ok2 || (env != nil && env.runtime.OverflowMode() == OverflowPromote) {
+		return nil
+	}
+	return &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "overflow-error"},
+		Message: name + ": integer overflow",
+		Data:    sexpr.List{Elements: []sexpr.SExpr{a, b}},
+	}}
+}
+
+// gcdNumbers returns the greatest common divisor of the integers a and b,
+// which is never negative. The gcd of 0 and 0 is 0.
+func gcdNumbers(a, b sexpr.SExpr) sexpr.SExpr {
+	x, y := new(big.Int).Abs(toBig(a)), new(big.Int).Abs(toBig(b))
+	return normalizeBig(new(big.Int).GCD(nil, nil, x, y))
+}
+
+// lcmNumbers returns the least common multiple of the integers a and b,
+// which is never negative. The lcm of 0 and anything is 0.
+func lcmNumbers(a, b sexpr.SExpr) sexpr.SExpr {
+	if isZero(a) || isZero(b) {
+		return sexpr.Number{Value: 0}
+	}
+	x, y := new(big.Int).Abs(toBig(a)), new(big.Int).Abs(toBig(b))
+	gcd := new(big.Int).GCD(nil, nil, x, y)
+	return normalizeBig(x.Mul(x.Quo(x, gcd), y))
+}
+
+// negateNumber returns -a
+func negateNumber(a sexpr.SExpr) sexpr.SExpr {
+	if f, ok := a.(sexpr.Float); ok {
+		return sexpr.Float{Value: -f.Value}
+	}
+	return subNumbers(sexpr.Number{Value: 0}, a)
+}
+
+// isZero reports whether a numeric value is zero
+func isZero(a sexpr.SExpr) bool {
+	n, ok := a.(sexpr.Number)
+	return ok && n.Value == 0
+}
+
+// compareNumbers returns -1, 0 or 1 depending on whether a is less than,
+// equal to or greater than b. Integers are compared with floats as
+// float64s; a NaN is less than every other number and equal to itself.
+func compareNumbers(a, b sexpr.SExpr) int {
+	if x, y, ok := floats(a, b); ok {
+		return cmp.Compare(x, y)
+	}
+	x, ok1 := a.(sexpr.Number)
+	y, ok2 := b.(sexpr.Number)
+	if ok1 && ok2 {
+		switch {
+		case x.Value < y.Value:
+			return -1
+		case x.Value > y.Value:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return toBig(a).Cmp(toBig(b))
+}
@@ -0,0 +1,265 @@
+package interpreter
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func bigFromString(t *testing.T, s string) sexpr.BigInt {
+	t.Helper()
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("invalid big integer %q", s)
+	}
+	return sexpr.BigInt{Value: n}
+}
+
+func TestArithmeticPromotesOnOverflow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(+ 9223372036854775807 1)", "9223372036854775808"},
+		{"(- -9223372036854775808 1)", "-9223372036854775809"},
+		{"(* 4294967296 4294967296)", "18446744073709551616"},
+		{"(- -9223372036854775808)", "9223372036854775808"},
+		{"(/ -9223372036854775808 -1)", "9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalWithPrimitives(t, tt.input, bigFromString(t, tt.expected))
+		})
+	}
+}
+
+func TestArithmeticDemotesWhenResultFits(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"(- (+ 9223372036854775807 1) 1)", 9223372036854775807},
+		{"(/ 18446744073709551616 4294967296)", 4294967296},
+		{"(* 99999999999999999999 0)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+
+			tokens, _ := parser.Tokenize(tt.input)
+			expr, _ := parser.Read(tokens)
+			result, err := Eval(expr, env)
+			if err != nil {
+				t.Fatalf("eval error: %v", err)
+			}
+
+			num, ok := result.(sexpr.Number)
+			if !ok {
+				t.Fatalf("expected Number, got %T", result)
+			}
+			if num.Value != tt.expected {
+				t.Errorf("got %d, want %d", num.Value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverflowRaise(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetOverflowMode(OverflowRaise)
+
+	for _, input := range []string{
+		"(+ 9223372036854775807 1)",
+		"(- -9223372036854775808 1)",
+		"(- -9223372036854775808)",
+		"(* 4294967296 4294967296)",
+		"(+ 1 2 9223372036854775807)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := evalString(env, input)
+			var raised *RaiseError
+			if !errors.As(err, &raised) {
+				t.Fatalf("expected a raised error, got %v", err)
+			}
+			if kind := raised.Value.(sexpr.Error).Kind.Name; kind != "overflow-error" {
+				t.Errorf("got kind %s, want overflow-error", kind)
+			}
+		})
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(+ 9223372036854775806 1)", "9223372036854775807"},
+		{"(+ 99999999999999999999 1)", "100000000000000000000"},
+		{"(* 1.5 9223372036854775807)", "1.3835058055282164e+19"},
+		{"(try (+ 9223372036854775807 1) (catch e (error-kind e)))", "overflow-error"},
+		{"(try (* 9223372036854775807 2) (catch e (error-data e)))", "(9223372036854775807 2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := evalForms(t, env, tt.input); got.String() != tt.expected {
+				t.Errorf("got %v, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBigComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"(< 1 99999999999999999999)", true},
+		{"(> -99999999999999999999 1)", false},
+		{"(= 99999999999999999999 99999999999999999999)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalWithPrimitives(t, tt.input, sexpr.Bool{Value: tt.expected})
+		})
+	}
+}
+
+func TestFactorialOfTwentyFive(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	tokens, _ := parser.Tokenize(
+		"(define fact (lambda (n) (if (= n 0) 1 (* n (fact (- n 1))))))")
+	expr, _ := parser.Read(tokens)
+	if _, err := Eval(expr, env); err != nil {
+		t.Fatalf("eval define error: %v", err)
+	}
+
+	tokens, _ = parser.Tokenize("(fact 25)")
+	expr, _ = parser.Read(tokens)
+	result, err := Eval(expr, env)
+	if err != nil {
+		t.Fatalf("eval call error: %v", err)
+	}
+
+	expected := "15511210043330985984000000"
+	if result.String() != expected {
+		t.Errorf("got %v, want %s", result, expected)
+	}
+}
+
+func TestIntegerDivision(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(quotient 7 2)", "3"},
+		{"(quotient -7 2)", "-3"},
+		{"(quotient 7 -2)", "-3"},
+		{"(remainder 7 2)", "1"},
+		{"(remainder -7 2)", "-1"},
+		{"(remainder 7 -2)", "1"},
+		{"(remainder -7 -2)", "-1"},
+		{"(modulo 7 2)", "1"},
+		{"(modulo -7 2)", "1"},
+		{"(modulo 7 -2)", "-1"},
+		{"(modulo -7 -2)", "-1"},
+		{"(modulo 6 3)", "0"},
+		{"(modulo -6 3)", "0"},
+		{"(quotient -9223372036854775808 -1)", "9223372036854775808"},
+		{"(remainder -9223372036854775808 -1)", "0"},
+		{"(modulo -100000000000000000000 3)", "2"},
+		{"(remainder -100000000000000000000 3)", "-1"},
+		{"(modulo 100000000000000000000 -7)", "-5"},
+		{"(quotient 100000000000000000000 10)", "10000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestIntegerDivisionErrors(t *testing.T) {
+	for _, input := range []string{
+		"(modulo 1 0)", "(remainder 1 0)", "(quotient 1 0)",
+		"(modulo 1)", "(modulo 1 2 3)", `(modulo "a" 2)`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestMinMaxAbs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(min 3)", "3"},
+		{"(min 3 1 2)", "1"},
+		{"(max 3 1 2)", "3"},
+		{"(min -99999999999999999999 0 5)", "-99999999999999999999"},
+		{"(max 1 99999999999999999999)", "99999999999999999999"},
+		{"(abs -5)", "5"},
+		{"(abs 5)", "5"},
+		{"(abs 0)", "0"},
+		{"(abs -9223372036854775808)", "9223372036854775808"},
+		{"(abs -99999999999999999999)", "99999999999999999999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{"(min)", "(max 1 'a)", "(abs)", "(abs 1 2)", `(abs "a")`} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestGcdLcm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(gcd)", "0"},
+		{"(gcd 12)", "12"},
+		{"(gcd -12)", "12"},
+		{"(gcd 12 18)", "6"},
+		{"(gcd 12 -18 27)", "3"},
+		{"(gcd 0 5)", "5"},
+		{"(gcd 0 0)", "0"},
+		{"(gcd -9223372036854775808)", "9223372036854775808"},
+		{"(gcd 100000000000000000000 30)", "10"},
+		{"(lcm)", "1"},
+		{"(lcm -4)", "4"},
+		{"(lcm 4 6)", "12"},
+		{"(lcm 2 3 4)", "12"},
+		{"(lcm 0 5)", "0"},
+		{"(lcm 9223372036854775807 2)", "18446744073709551614"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{"(gcd 1.5 3)", "(lcm 'a)", `(gcd 2 "4")`} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
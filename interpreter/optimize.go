@@ -0,0 +1,334 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// foldable are the primitives Optimize may call at optimization time.
+// They are pure, so a call with constant arguments always gives the same
+// value.
+var foldable = map[string]func([]sexpr.SExpr, *Env) (sexpr.SExpr, error){
+	"+": primAdd, "-": primSub, "*": primMul, "/": primDiv,
+	"quotient": primQuotient, "remainder": primRemainder, "modulo": primModulo,
+	"min": primMin, "max": primMax, "abs": primAbs, "gcd": primGcd, "lcm": primLcm,
+	"=": primEq, "<": primLt, ">": primGt, "<=": primLte, ">=": primGte,
+	"equal?": primIsEqual, "not": primNot,
+	"zero?": primIsZero, "positive?": primIsPositive, "negative?": primIsNegative,
+	"even?": primIsEven, "odd?": primIsOdd,
+}
+
+// Optimize returns an equivalent expression with constant work done in
+// advance. Calls of arithmetic and comparison primitives with constant
+// arguments are replaced by their values, if, when and unless with a
+// constant test are replaced by the branch taken, cond clauses with a
+// constant false test are dropped and those after a constant true one
+// are cut, and constants are dropped from and and or, and from begin
+// where their values are unused. Calls that would fail, or whose integer
+// arithmetic overflows an int64, are left to run time.
+//
+// Optimize assumes the primitives it folds have their standard global
+// definitions; a name rebound by a lambda or let in expr is respected,
+// but one redefined globally is not. It descends into lambda, define,
+// set!, let, let*, letrec and loop, and leaves other special forms and
+// quoted data as they are.
+//
+// Optimize knows nothing of macros, so it treats a macro use as a call
+// and may change the forms passed to the macro. Run it on expanded code,
+// or use OptimizeIn.
+func Optimize(expr sexpr.SExpr) sexpr.SExpr {
+	return optimizer{}.expr(expr)
+}
+
+// OptimizeIn is Optimize for code that will run in env. Forms whose head
+// is bound to a macro in env are left as they are, arguments included.
+func OptimizeIn(expr sexpr.SExpr, env *Env) sexpr.SExpr {
+	return optimizer{env: env}.expr(expr)
+}
+
+// optimizer records the names bound by enclosing forms, which shadow
+// the foldable primitives and any macros in env
+type optimizer struct {
+	shadowed map[string]bool
+	env      *Env // environment the code will run in, if known
+}
+
+// binding returns an optimizer for the body of a form binding names
+func (o optimizer) binding(names []string) optimizer {
+	shadowed := make(map[string]bool, len(o.shadowed)+len(names))
+	for name := range o.shadowed {
+		shadowed[name] = true
+	}
+	for _, name := range names {
+		shadowed[name] = true
+	}
+	return optimizer{shadowed: shadowed, env: o.env}
+}
+
+// isMacro reports whether name is bound to a macro where the code runs
+func (o optimizer) isMacro(name string) bool {
+	if o.env == nil || o.shadowed[name] {
+		return false
+	}
+	value, err := o.env.Lookup(name)
+	if err != nil {
+		return false
+	}
+	_, ok := value.(sexpr.Macro)
+	return ok
+}
+
+func (o optimizer) expr(x sexpr.SExpr) sexpr.SExpr {
+	list, ok := x.(sexpr.List)
+	if !ok || len(list.Elements) == 0 {
+		return x
+	}
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if ok && o.isMacro(head.Name) {
+		return list
+	}
+	if !ok || !specialForms[head.Name] {
+		return o.call(list)
+	}
+
+	switch head.Name {
+	case "if":
+		return o.optimizeIf(list)
+	case "when", "unless":
+		return o.optimizeWhen(list, head.Name == "when")
+	case "cond":
+		return o.optimizeCond(list)
+	case "and", "or":
+		return o.optimizeAndOr(list, head.Name == "and")
+	case "begin":
+		return o.optimizeBegin(list)
+	case "recur", "string-interpolate":
+		return o.each(list, 1)
+	case "define", "set!":
+		if len(list.Elements) == 3 {
+			if _, ok := list.Elements[1].(sexpr.Symbol); ok {
+				return o.each(list, 2)
+			}
+		}
+	case "lambda":
+		if form, _ := splitDoc(list); len(form.Elements) == 3 {
+			var names []string
+			for _, name := range patternNames(list.Elements[1]) {
+				names = append(names, name.Name)
+			}
+			// The body is last, after any docstring
+			last := len(list.Elements) - 1
+			body := o.binding(names).expr(list.Elements[last])
+			return withElements(list, append(list.Elements[:last:last], body)...)
+		}
+	case "let", "let*", "letrec", "loop":
+		if names, ok := bindingNames(list); ok {
+			return o.let(head.Name, list, names)
+		}
+	}
+	return list
+}
+
+// call optimizes the operands of a call, then folds it if it calls a
+// foldable primitive with constant arguments
+func (o optimizer) call(list sexpr.List) sexpr.SExpr {
+	list = o.each(list, 0)
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || o.shadowed[head.Name] {
+		return list
+	}
+	fn, ok := foldable[head.Name]
+	if !ok {
+		return list
+	}
+
+	args := list.Elements[1:]
+	for _, arg := range args {
+		if !isConstant(arg) {
+			return list
+		}
+	}
+	value, err := fn(args, nil)
+	if err != nil {
+		return list
+	}
+	return value
+}
+
+// optimizeIf handles (if test then else)
+func (o optimizer) optimizeIf(list sexpr.List) sexpr.SExpr {
+	list = o.each(list, 1)
+	if len(list.Elements) != 4 || !isConstant(list.Elements[1]) {
+		return list
+	}
+	if isTruthy(list.Elements[1]) {
+		return list.Elements[2]
+	}
+	return list.Elements[3]
+}
+
+// optimizeWhen handles (when test body...) and (unless test body...)
+func (o optimizer) optimizeWhen(list sexpr.List, want bool) sexpr.SExpr {
+	list = o.each(list, 1)
+	if len(list.Elements) < 2 || !isConstant(list.Elements[1]) {
+		return list
+	}
+	if isTruthy(list.Elements[1]) != want {
+		return sexpr.Nil{}
+	}
+	body := append([]sexpr.SExpr{sexpr.Symbol{Name: "begin"}}, list.Elements[2:]...)
+	return o.optimizeBegin(sexpr.List{Elements: body, Meta: list.Meta})
+}
+
+// optimizeCond handles (cond clause...), dropping clauses whose test is
+// constant and false and everything after one whose test is constant and
+// true. A cond whose first remaining clause is taken is replaced by that
+// clause's value. Malformed conds are left to fail at run time.
+func (o optimizer) optimizeCond(list sexpr.List) sexpr.SExpr {
+	clauses := list.Elements[1:]
+	elems := []sexpr.SExpr{list.Elements[0]}
+	for i, c := range clauses {
+		clause, ok := c.(sexpr.List)
+		if !ok || len(clause.Elements) == 0 {
+			return list
+		}
+		clause = o.each(clause, 0)
+		test := clause.Elements[0]
+		if isSymbolNamed(test, "else") {
+			if i != len(clauses)-1 {
+				return list
+			}
+			elems = append(elems, clause)
+			break
+		}
+		if isConstant(test) {
+			if !isTruthy(test) {
+				continue
+			}
+			elems = append(elems, clause)
+			break
+		}
+		elems = append(elems, clause)
+	}
+
+	if len(elems) == 1 {
+		return sexpr.Nil{}
+	}
+	first := elems[1].(sexpr.List).Elements
+	test, body := first[0], first[1:]
+	if isSymbolNamed(test, "else") {
+		test = sexpr.Nil{}
+	} else if !isConstant(test) || (len(body) > 0 && isSymbolNamed(body[0], "=>")) {
+		return sexpr.List{Elements: elems, Meta: list.Meta}
+	}
+	if len(body) == 0 {
+		return test
+	}
+	begin := append([]sexpr.SExpr{sexpr.Symbol{Name: "begin"}}, body...)
+	return o.optimizeBegin(sexpr.List{Elements: begin, Meta: list.Meta})
+}
+
+// optimizeAndOr handles (and expr...) and (or expr...), dropping
+// constants that do not end the evaluation and everything after one
+// that does
+func (o optimizer) optimizeAndOr(list sexpr.List, isAnd bool) sexpr.SExpr {
+	list = o.each(list, 1)
+	elems := []sexpr.SExpr{list.Elements[0]}
+	operands := list.Elements[1:]
+	for i, x := range operands {
+		last := i == len(operands)-1
+		if isConstant(x) {
+			// A constant that stops evaluation is the result if nothing
+			// before it could have stopped it
+			if isTruthy(x) != isAnd {
+				elems = append(elems, x)
+				break
+			}
+			if !last {
+				continue
+			}
+		}
+		elems = append(elems, x)
+	}
+
+	switch len(elems) {
+	case 1:
+		return sexpr.Bool{Value: isAnd}
+	case 2:
+		return elems[1]
+	}
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// optimizeBegin handles (begin expr...), dropping constants whose values
+// are unused
+func (o optimizer) optimizeBegin(list sexpr.List) sexpr.SExpr {
+	list = o.each(list, 1)
+	elems := []sexpr.SExpr{list.Elements[0]}
+	body := list.Elements[1:]
+	for i, x := range body {
+		if i < len(body)-1 && isConstant(x) {
+			continue
+		}
+		elems = append(elems, x)
+	}
+
+	switch len(elems) {
+	case 1:
+		return sexpr.Nil{}
+	case 2:
+		return elems[1]
+	}
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// let optimizes a let, let*, letrec or loop whose bindings are all to
+// plain names
+func (o optimizer) let(form string, list sexpr.List, names []string) sexpr.SExpr {
+	inner := o.binding(names)
+	values := o
+	if form == "letrec" {
+		values = inner
+	}
+
+	specs := list.Elements[1].(sexpr.List).Elements
+	bindings := make([]sexpr.SExpr, len(specs))
+	for i, spec := range specs {
+		pair := spec.(sexpr.List)
+		v := values
+		if form == "let*" {
+			v = o.binding(names[:i])
+		}
+		bindings[i] = withElements(pair, pair.Elements[0], v.expr(pair.Elements[1]))
+	}
+
+	elems := []sexpr.SExpr{list.Elements[0], withElements(list.Elements[1].(sexpr.List), bindings...)}
+	for _, x := range list.Elements[2:] {
+		elems = append(elems, inner.expr(x))
+	}
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// each optimizes the elements of list from index start on
+func (o optimizer) each(list sexpr.List, start int) sexpr.List {
+	elems := make([]sexpr.SExpr, len(list.Elements))
+	copy(elems, list.Elements[:start])
+	for i := start; i < len(elems); i++ {
+		elems[i] = o.expr(list.Elements[i])
+	}
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// withElements returns a list with list's metadata and the given elements
+func withElements(list sexpr.List, elems ...sexpr.SExpr) sexpr.List {
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// isConstant reports whether x evaluates to itself without side effects
+func isConstant(x sexpr.SExpr) bool {
+	switch x.(type) {
+	case sexpr.Number, sexpr.BigInt, sexpr.Float, sexpr.String,
+		sexpr.Bool, sexpr.Nil, sexpr.Keyword, sexpr.Char:
+		return true
+	}
+	return false
+}
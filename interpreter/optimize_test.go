@@ -0,0 +1,149 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(+ 1 2)", "3"},
+		{"(* (+ 1 2) (- 10 4))", "18"},
+		{"(modulo (- 7) 2)", "1"},
+		{"(modulo 1 0)", "(modulo 1 0)"},
+		{"(+ 9223372036854775807 1)", "(+ 9223372036854775807 1)"},
+		{"(+ x (* 2 3))", "(+ x 6)"},
+		{"(< 1 2)", "true"},
+		{"(equal? \"a\" \"a\")", "true"},
+		{"(if (< 1 2) (f) (g))", "(f)"},
+		{"(if (> 1 2) (f) (g))", "(g)"},
+		{"(if x (+ 1 1) 0)", "(if x 2 0)"},
+		{"(when true (f) (g))", "(begin (f) (g))"},
+		{"(when false (f))", "nil"},
+		{"(unless false (f))", "(f)"},
+		{"(and true x)", "x"},
+		{"(and x false y)", "(and x false)"},
+		{"(and true true)", "true"},
+		{"(or false x y)", "(or x y)"},
+		{"(or x 1 y)", "(or x 1)"},
+		{"(or)", "false"},
+		{"(cond (false 1) (true 2) (else 3))", "2"},
+		{"(cond ((> 1 2) (f)) (x (+ 1 1)) ((< 1 2) (g)) (y 4))", "(cond (x 2) (true (g)))"},
+		{"(cond (x 1) (true 2) (else 3))", "(cond (x 1) (true 2))"},
+		{"(cond (false 1) (else (f) (g)))", "(begin (f) (g))"},
+		{"(cond ((+ 1 2) 3))", "3"},
+		{"(cond (7))", "7"},
+		{"(cond (false 1))", "nil"},
+		{"(cond (1 => f))", "(cond (1 => f))"},
+		{"(cond (else 1) (x 2))", "(cond (else 1) (x 2))"},
+		{"(begin 1 2 (f) 3)", "(begin (f) 3)"},
+		{"(begin 1)", "1"},
+		{"(define x (+ 1 2))", "(define x 3)"},
+		{"(lambda (n) (* n (+ 1 1)))", "(lambda (n) (* n 2))"},
+		{"(let ((y (+ 1 2))) (* y 2))", "(let ((y 3)) (* y 2))"},
+		// Quoted data and unmodelled forms are left alone
+		{"'(+ 1 2)", "(quote (+ 1 2))"},
+		// Calls that fail are kept so they fail at run time
+		{"(/ 1 0)", "(/ 1 0)"},
+		{"(+ 1 \"a\")", "(+ 1 \"a\")"},
+		// Local bindings shadow the primitives
+		{"(lambda (+) (+ 1 2))", "(lambda (+) (+ 1 2))"},
+		{"(let ((+ -)) (+ 1 2))", "(let ((+ -)) (+ 1 2))"},
+		{"(let* ((a (+ 1 2)) (+ -)) (+ a 1))", "(let* ((a 3) (+ -)) (+ a 1))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr := readOptimizeInput(t, tt.input)
+			if got := Optimize(expr).String(); got != tt.expected {
+				t.Errorf("Optimize(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestOptimizeEquivalence checks that optimized code evaluates to the
+// same value as the original
+func TestOptimizeEquivalence(t *testing.T) {
+	tests := []string{
+		"(+ 1 (* 2 3))",
+		"((lambda (n) (if (< 1 2) (* n (+ 1 1)) 0)) 21)",
+		"(let ((x 5)) (and (> 2 1) (or false x)))",
+		"(begin 1 2 (when (= 1 1) 3 4))",
+		"(letrec ((f (lambda (n) (if (= n 0) (- 10 9) (* n (f (- n 1))))))) (f 5))",
+		"(loop ((i 0) (acc (+ 0 0))) (if (< i 4) (recur (+ i 1) (+ acc i)) acc))",
+		"(let ((x 3)) (cond ((> 1 2) 0) ((= x 3) (+ 1 1)) (true 9)))",
+		"(cond (false 1) (5 => (lambda (v) (* v 2))) (else 0))",
+		"(cond ((< 2 1) 1) (else))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expr := readOptimizeInput(t, input)
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			want, err := Eval(expr, env)
+			if err != nil {
+				t.Fatalf("eval error: %v", err)
+			}
+			got, err := Eval(Optimize(expr), env)
+			if err != nil {
+				t.Fatalf("eval error after optimizing: %v", err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("optimized %s = %v, want %v", input, got, want)
+			}
+		})
+	}
+}
+
+func TestOptimizeInLeavesMacros(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(defmacro show (x) (list 'quote x))")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(show (+ 1 2))", "(show (+ 1 2))"},
+		{"(list (show (* 2 3)) (+ 1 2))", "(list (show (* 2 3)) 3)"},
+		// A local binding of the name is not the macro
+		{"(lambda (show) (show (+ 1 2)))", "(lambda (show) (show 3))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr := readOptimizeInput(t, tt.input)
+			if got := OptimizeIn(expr, env).String(); got != tt.expected {
+				t.Errorf("OptimizeIn(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	result, err := Eval(OptimizeIn(readOptimizeInput(t, "(show (+ 1 2))"), env), env)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+	if result.String() != "(+ 1 2)" {
+		t.Errorf("got %v, want (+ 1 2)", result)
+	}
+}
+
+func readOptimizeInput(t *testing.T, input string) sexpr.SExpr {
+	t.Helper()
+	tokens, err := parser.Tokenize(input)
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	return expr
+}
@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Capability is a set of groups of primitives that reach outside the
+// interpreter. A sandbox policy lists the capabilities scripts may use.
+type Capability uint
+
+const (
+	CapFilesystem Capability = 1 << iota // reading and writing files, load and require
+	CapNetwork                           // network connections
+	CapProcess                           // environment variables, subprocesses and exit
+	CapClock                             // the current time and sleeping
+
+	// CapAll grants every capability
+	CapAll = CapFilesystem | CapNetwork | CapProcess | CapClock
+)
+
+var capabilityNames = []struct {
+	cap  Capability
+	name string
+}{
+	{CapFilesystem, "filesystem"},
+	{CapNetwork, "network"},
+	{CapProcess, "process"},
+	{CapClock, "clock"},
+}
+
+func (c Capability) String() string {
+	var names []string
+	for _, cn := range capabilityNames {
+		if c&cn.cap != 0 {
+			names = append(names, cn.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// Policy describes what evaluation in a sandbox may do. Primitives
+// needing a capability outside Allow remain defined but fail with a
+// PolicyError when called.
+type Policy struct {
+	Allow Capability
+}
+
+// Unrestricted is the policy of environments created by NewEnv
+var Unrestricted = Policy{Allow: CapAll}
+
+// Allows reports whether the policy grants every capability in c
+func (p Policy) Allows(c Capability) bool {
+	return p.Allow&c == c
+}
+
+// ErrPolicy matches every PolicyError
+var ErrPolicy = errors.New("not permitted by sandbox policy")
+
+// PolicyError reports a call to a primitive whose capability the
+// sandbox policy does not grant
+type PolicyError struct {
+	Location
+	Name       string
+	Capability Capability
+}
+
+func (e *PolicyError) Error() string {
+	return e.Name + ": " + e.Capability.String() + " access " + ErrPolicy.Error()
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrPolicy
+}
+
+// NewSandbox creates a global environment with the primitives loaded
+// under policy
+func NewSandbox(policy Policy) *Env {
+	env := NewEnv(nil)
+	env.runtime.policy = policy
+	LoadPrimitives(env)
+	return env
+}
+
+// restricted wraps the primitive name so that it fails unless the
+// runtime's policy grants c
+func restricted(c Capability, name string, fn func([]sexpr.SExpr, *Env) (sexpr.SExpr, error)) sexpr.Primitive {
+	return makePrimitive(name, func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if !env.runtime.policy.Allows(c) {
+			return nil, &PolicyError{Name: name, Capability: c}
+		}
+		return fn(args, env)
+	})
+}
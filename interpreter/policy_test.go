@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCapabilityString(t *testing.T) {
+	tests := []struct {
+		cap      Capability
+		expected string
+	}{
+		{0, "none"},
+		{CapFilesystem, "filesystem"},
+		{CapClock | CapNetwork, "network|clock"},
+		{CapAll, "filesystem|network|process|clock"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cap.String(); got != tt.expected {
+			t.Errorf("Capability(%d).String() = %q, want %q", tt.cap, got, tt.expected)
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSource(t, dir, "lib.zy", "(define loaded 1)")
+
+	env := NewSandbox(Policy{Allow: CapClock})
+	if env.Runtime().Policy().Allows(CapFilesystem) {
+		t.Fatal("sandbox policy allows filesystem access")
+	}
+
+	// Unrestricted primitives still work
+	if result := evalForms(t, env, "(+ 1 2)"); result.String() != "3" {
+		t.Errorf("got %v, want 3", result)
+	}
+
+	for _, input := range []string{`(load "` + path + `")`, `(require "lib")`, `(slurp "` + path + `")`} {
+		t.Run(input, func(t *testing.T) {
+			_, err := evalString(env, input)
+			var pe *PolicyError
+			if !errors.Is(err, ErrPolicy) || !errors.As(err, &pe) {
+				t.Fatalf("expected policy error, got %v", err)
+			}
+			if pe.Capability != CapFilesystem {
+				t.Errorf("got capability %v, want filesystem", pe.Capability)
+			}
+		})
+	}
+
+	// Policy errors can be caught
+	result := evalForms(t, env, `(try (load "`+path+`") (catch e :denied))`)
+	if result.String() != ":denied" {
+		t.Errorf("got %v, want :denied", result)
+	}
+
+	env = NewSandbox(Unrestricted)
+	if result := evalForms(t, env, `(load "`+path+`")`, "loaded"); result.String() != "1" {
+		t.Errorf("got %v, want 1", result)
+	}
+}
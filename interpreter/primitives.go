@@ -1,7 +1,8 @@
 package interpreter
 
 import (
-	"fmt"
+	"cmp"
+	"math"
 
 	"github.com/zylisp/lang/sexpr"
 )
@@ -13,6 +14,14 @@ func LoadPrimitives(env *Env) {
 	env.Define("-", makePrimitive("-", primSub))
 	env.Define("*", makePrimitive("*", primMul))
 	env.Define("/", makePrimitive("/", primDiv))
+	env.Define("quotient", makePrimitive("quotient", primQuotient))
+	env.Define("remainder", makePrimitive("remainder", primRemainder))
+	env.Define("modulo", makePrimitive("modulo", primModulo))
+	env.Define("min", makePrimitive("min", primMin))
+	env.Define("max", makePrimitive("max", primMax))
+	env.Define("abs", makePrimitive("abs", primAbs))
+	env.Define("gcd", makePrimitive("gcd", primGcd))
+	env.Define("lcm", makePrimitive("lcm", primLcm))
 
 	// Comparison
 	env.Define("=", makePrimitive("=", primEq))
@@ -20,6 +29,9 @@ func LoadPrimitives(env *Env) {
 	env.Define(">", makePrimitive(">", primGt))
 	env.Define("<=", makePrimitive("<=", primLte))
 	env.Define(">=", makePrimitive(">=", primGte))
+	env.Define("eq?", makePrimitive("eq?", primIsEq))
+	env.Define("eqv?", makePrimitive("eqv?", primIsEqv))
+	env.Define("equal?", makePrimitive("equal?", primIsEqual))
 
 	// List operations
 	env.Define("list", makePrimitive("list", primList))
@@ -32,6 +44,73 @@ func LoadPrimitives(env *Env) {
 	env.Define("symbol?", makePrimitive("symbol?", primIsSymbol))
 	env.Define("list?", makePrimitive("list?", primIsList))
 	env.Define("null?", makePrimitive("null?", primIsNull))
+	env.Define("go-value?", makePrimitive("go-value?", primIsGoValue))
+	env.Define("promise?", makePrimitive("promise?", primIsPromise))
+	env.Define("boolean?", makePrimitive("boolean?", primIsBoolean))
+	env.Define("string?", makePrimitive("string?", primIsString))
+	env.Define("procedure?", makePrimitive("procedure?", primIsProcedure))
+	env.Define("type-of", makePrimitive("type-of", primTypeOf))
+
+	// Logic
+	env.Define("not", makePrimitive("not", primNot))
+
+	// Numeric predicates
+	env.Define("zero?", makePrimitive("zero?", primIsZero))
+	env.Define("positive?", makePrimitive("positive?", primIsPositive))
+	env.Define("negative?", makePrimitive("negative?", primIsNegative))
+	env.Define("even?", makePrimitive("even?", primIsEven))
+	env.Define("odd?", makePrimitive("odd?", primIsOdd))
+
+	// Promises
+	env.Define("force", makePrimitive("force", primForce))
+
+	// Control
+	env.Define("apply", makePrimitive("apply", primApply))
+	env.Define("eval", makePrimitive("eval", primEval))
+	env.Define("values", makePrimitive("values", primValues))
+	env.Define("call-with-values", makePrimitive("call-with-values", primCallWithValues))
+	env.Define("current-environment", makePrimitive("current-environment", primCurrentEnvironment))
+	env.Define("global-environment", makePrimitive("global-environment", primGlobalEnvironment))
+	env.Define("environment?", makePrimitive("environment?", primIsEnvironment))
+	env.Define("call/cc", makePrimitive("call/cc", primCallCC))
+	env.Define("call-with-current-continuation", makePrimitive("call-with-current-continuation", primCallCC))
+
+	// Loading
+	env.Define("load", restricted(CapFilesystem, "load", primLoad))
+	env.Define("require", restricted(CapFilesystem, "require", primRequire))
+
+	// Macros
+	env.Define("gensym", makePrimitive("gensym", primGensym))
+	env.Define("macroexpand-1", makePrimitive("macroexpand-1", primMacroexpand1))
+	env.Define("macroexpand", makePrimitive("macroexpand", primMacroexpand))
+
+	// Metadata
+	env.Define("meta", makePrimitive("meta", primMeta))
+	env.Define("with-meta", makePrimitive("with-meta", primWithMeta))
+	env.Define("doc", makePrimitive("doc", primDoc))
+	env.Define("arity", makePrimitive("arity", primArity))
+	env.Define("params", makePrimitive("params", primParams))
+
+	loadListPrimitives(env)
+	loadMapPrimitives(env)
+	loadVectorPrimitives(env)
+	loadErrorPrimitives(env)
+	loadPortPrimitives(env)
+	loadFilePrimitives(env)
+	loadOSPrimitives(env)
+	loadProcessPrimitives(env)
+	loadJSONPrimitives(env)
+	loadBytesPrimitives(env)
+	loadMathPrimitives(env)
+	loadTimePrimitives(env)
+	loadStringPrimitives(env)
+	loadCharPrimitives(env)
+	loadChannelPrimitives(env)
+	loadActorPrimitives(env)
+	loadAtomPrimitives(env)
+	loadFuturePrimitives(env)
+	loadGeneratorPrimitives(env)
+	loadPrimitiveDocs(env)
 }
 
 func makePrimitive(name string, fn func([]sexpr.SExpr, *Env) (sexpr.SExpr, error)) sexpr.Primitive {
@@ -47,245 +126,408 @@ func makePrimitive(name string, fn func([]sexpr.SExpr, *Env) (sexpr.SExpr, error
 // Arithmetic primitives
 
 func primAdd(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
-	if len(args) == 0 {
-		return sexpr.Number{Value: 0}, nil
-	}
-
-	var sum int64
+	var sum sexpr.SExpr = sexpr.Number{Value: 0}
 	for _, arg := range args {
-		num, ok := arg.(sexpr.Number)
-		if !ok {
-			return nil, fmt.Errorf("+: expected number, got %v", arg)
+		if !isNumber(arg) {
+			return nil, typeError("+", "number", arg)
 		}
-		sum += num.Value
+		next := addNumbers(sum, arg)
+		if err := checkOverflow("+", sum, arg, next, env); err != nil {
+			return nil, err
+		}
+		sum = next
 	}
 
-	return sexpr.Number{Value: sum}, nil
+	return sum, nil
 }
 
 func primSub(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) == 0 {
-		return nil, fmt.Errorf("-: requires at least 1 argument")
+		return nil, arityError("-", 1, -1, len(args))
 	}
 
-	first, ok := args[0].(sexpr.Number)
-	if !ok {
-		return nil, fmt.Errorf("-: expected number, got %v", args[0])
+	first := args[0]
+	if !isNumber(first) {
+		return nil, typeError("-", "number", first)
 	}
 
 	if len(args) == 1 {
-		return sexpr.Number{Value: -first.Value}, nil
+		result := negateNumber(first)
+		if err := checkOverflow("-", sexpr.Number{}, first, result, env); err != nil {
+			return nil, err
+		}
+		return result, nil
 	}
 
-	result := first.Value
+	result := first
 	for _, arg := range args[1:] {
-		num, ok := arg.(sexpr.Number)
-		if !ok {
-			return nil, fmt.Errorf("-: expected number, got %v", arg)
+		if !isNumber(arg) {
+			return nil, typeError("-", "number", arg)
+		}
+		next := subNumbers(result, arg)
+		if err := checkOverflow("-", result, arg, next, env); err != nil {
+			return nil, err
 		}
-		result -= num.Value
+		result = next
 	}
 
-	return sexpr.Number{Value: result}, nil
+	return result, nil
 }
 
 func primMul(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
-	if len(args) == 0 {
-		return sexpr.Number{Value: 1}, nil
-	}
-
-	product := int64(1)
+	var product sexpr.SExpr = sexpr.Number{Value: 1}
 	for _, arg := range args {
-		num, ok := arg.(sexpr.Number)
-		if !ok {
-			return nil, fmt.Errorf("*: expected number, got %v", arg)
+		if !isNumber(arg) {
+			return nil, typeError("*", "number", arg)
 		}
-		product *= num.Value
+		next := mulNumbers(product, arg)
+		if err := checkOverflow("*", product, arg, next, env); err != nil {
+			return nil, err
+		}
+		product = next
 	}
 
-	return sexpr.Number{Value: product}, nil
+	return product, nil
 }
 
 func primDiv(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) == 0 {
-		return nil, fmt.Errorf("/: requires at least 1 argument")
+		return nil, arityError("/", 1, -1, len(args))
 	}
 
-	first, ok := args[0].(sexpr.Number)
-	if !ok {
-		return nil, fmt.Errorf("/: expected number, got %v", args[0])
+	first := args[0]
+	if !isNumber(first) {
+		return nil, typeError("/", "number", first)
 	}
 
 	if len(args) == 1 {
-		if first.Value == 0 {
-			return nil, fmt.Errorf("/: division by zero")
+		result, err := quoNumbers(sexpr.Number{Value: 1}, first)
+		if err != nil {
+			return nil, evalError("/", "%v", err)
 		}
-		return sexpr.Number{Value: 1 / first.Value}, nil
+		return result, nil
 	}
 
-	result := first.Value
+	result := first
 	for _, arg := range args[1:] {
-		num, ok := arg.(sexpr.Number)
-		if !ok {
-			return nil, fmt.Errorf("/: expected number, got %v", arg)
+		if !isNumber(arg) {
+			return nil, typeError("/", "number", arg)
 		}
-		if num.Value == 0 {
-			return nil, fmt.Errorf("/: division by zero")
+		var err error
+		result, err = quoNumbers(result, arg)
+		if err != nil {
+			return nil, evalError("/", "%v", err)
 		}
-		result /= num.Value
 	}
 
-	return sexpr.Number{Value: result}, nil
+	return result, nil
+}
+
+// primQuotient handles (quotient a b), a / b truncated toward zero
+func primQuotient(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return integerDivision("quotient", quoNumbers, args)
+}
+
+// primRemainder handles (remainder a b), the remainder of quotient,
+// which has the sign of a: (remainder -7 2) is -1
+func primRemainder(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return integerDivision("remainder", remNumbers, args)
+}
+
+// primModulo handles (modulo a b), the remainder of a / b floored, which
+// has the sign of b: (modulo -7 2) is 1
+func primModulo(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return integerDivision("modulo", modNumbers, args)
+}
+
+// integerDivision checks the two integer arguments of the primitive name
+// and applies op to them
+func integerDivision(name string, op func(a, b sexpr.SExpr) (sexpr.SExpr, error), args []sexpr.SExpr) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError(name, 2, 2, len(args))
+	}
+	for _, arg := range args {
+		if !isInteger(arg) {
+			return nil, typeError(name, "integer", arg)
+		}
+	}
+
+	result, err := op(args[0], args[1])
+	if err != nil {
+		return nil, evalError(name, "%v", err)
+	}
+	return result, nil
+}
+
+// primMin handles (min a b...), the smallest of its arguments
+func primMin(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return extremum("min", -1, args)
+}
+
+// primMax handles (max a b...), the largest of its arguments
+func primMax(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return extremum("max", 1, args)
+}
+
+// extremum returns the argument of the primitive name that compares
+// to each of the others with the sign want, or is equal to it. If any
+// argument is a float, so is the result.
+func extremum(name string, want int, args []sexpr.SExpr) (sexpr.SExpr, error) {
+	if len(args) == 0 {
+		return nil, arityError(name, 1, -1, 0)
+	}
+
+	var result sexpr.SExpr
+	inexact := false
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, typeError(name, "number", arg)
+		}
+		if _, ok := arg.(sexpr.Float); ok {
+			inexact = true
+		}
+		if result == nil || compareNumbers(arg, result) == want {
+			result = arg
+		}
+	}
+	if inexact {
+		return sexpr.Float{Value: toFloat(result)}, nil
+	}
+	return result, nil
+}
+
+// primAbs handles (abs n)
+func primAbs(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("abs", 1, 1, len(args))
+	}
+	if !isNumber(args[0]) {
+		return nil, typeError("abs", "number", args[0])
+	}
+
+	if f, ok := args[0].(sexpr.Float); ok {
+		return sexpr.Float{Value: math.Abs(f.Value)}, nil
+	}
+	if compareNumbers(args[0], sexpr.Number{}) < 0 {
+		return negateNumber(args[0]), nil
+	}
+	return args[0], nil
+}
+
+// primGcd handles (gcd n...), the greatest common divisor of integers.
+// (gcd) is 0.
+func primGcd(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return foldIntegers("gcd", gcdNumbers, sexpr.Number{Value: 0}, args)
+}
+
+// primLcm handles (lcm n...), the least common multiple of integers.
+// (lcm) is 1.
+func primLcm(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return foldIntegers("lcm", lcmNumbers, sexpr.Number{Value: 1}, args)
+}
+
+// foldIntegers combines the integer arguments of the primitive name with
+// op, starting from identity
+func foldIntegers(name string, op func(a, b sexpr.SExpr) sexpr.SExpr, identity sexpr.SExpr, args []sexpr.SExpr) (sexpr.SExpr, error) {
+	result := identity
+	for _, arg := range args {
+		if !isInteger(arg) {
+			return nil, typeError(name, "integer", arg)
+		}
+		result = op(result, arg)
+	}
+	return result, nil
 }
 
 // Comparison primitives
 
 func primEq(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 2 {
-		return nil, fmt.Errorf("=: requires 2 arguments, got %d", len(args))
+		return nil, arityError("=", 2, 2, len(args))
 	}
 
-	a, ok1 := args[0].(sexpr.Number)
-	b, ok2 := args[1].(sexpr.Number)
-
-	if !ok1 || !ok2 {
-		return nil, fmt.Errorf("=: expected numbers")
+	if !isNumber(args[0]) || !isNumber(args[1]) {
+		return nil, evalError("=", "expected numbers")
 	}
 
-	return sexpr.Bool{Value: a.Value == b.Value}, nil
+	return sexpr.Bool{Value: compareNumbers(args[0], args[1]) == 0}, nil
 }
 
 func primLt(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 2 {
-		return nil, fmt.Errorf("<: requires 2 arguments, got %d", len(args))
+		return nil, arityError("<", 2, 2, len(args))
 	}
 
-	a, ok1 := args[0].(sexpr.Number)
-	b, ok2 := args[1].(sexpr.Number)
-
-	if !ok1 || !ok2 {
-		return nil, fmt.Errorf("<: expected numbers")
+	if !isNumber(args[0]) || !isNumber(args[1]) {
+		return nil, evalError("<", "expected numbers")
 	}
 
-	return sexpr.Bool{Value: a.Value < b.Value}, nil
+	return sexpr.Bool{Value: compareNumbers(args[0], args[1]) < 0}, nil
 }
 
 func primGt(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 2 {
-		return nil, fmt.Errorf(">: requires 2 arguments, got %d", len(args))
+		return nil, arityError(">", 2, 2, len(args))
 	}
 
-	a, ok1 := args[0].(sexpr.Number)
-	b, ok2 := args[1].(sexpr.Number)
-
-	if !ok1 || !ok2 {
-		return nil, fmt.Errorf(">: expected numbers")
+	if !isNumber(args[0]) || !isNumber(args[1]) {
+		return nil, evalError(">", "expected numbers")
 	}
 
-	return sexpr.Bool{Value: a.Value > b.Value}, nil
+	return sexpr.Bool{Value: compareNumbers(args[0], args[1]) > 0}, nil
 }
 
 func primLte(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 2 {
-		return nil, fmt.Errorf("<=: requires 2 arguments, got %d", len(args))
+		return nil, arityError("<=", 2, 2, len(args))
 	}
 
-	a, ok1 := args[0].(sexpr.Number)
-	b, ok2 := args[1].(sexpr.Number)
-
-	if !ok1 || !ok2 {
-		return nil, fmt.Errorf("<=: expected numbers")
+	if !isNumber(args[0]) || !isNumber(args[1]) {
+		return nil, evalError("<=", "expected numbers")
 	}
 
-	return sexpr.Bool{Value: a.Value <= b.Value}, nil
+	return sexpr.Bool{Value: compareNumbers(args[0], args[1]) <= 0}, nil
 }
 
 func primGte(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 2 {
-		return nil, fmt.Errorf(">=: requires 2 arguments, got %d", len(args))
+		return nil, arityError(">=", 2, 2, len(args))
 	}
 
-	a, ok1 := args[0].(sexpr.Number)
-	b, ok2 := args[1].(sexpr.Number)
+	if !isNumber(args[0]) || !isNumber(args[1]) {
+		return nil, evalError(">=", "expected numbers")
+	}
 
-	if !ok1 || !ok2 {
-		return nil, fmt.Errorf(">=: expected numbers")
+	return sexpr.Bool{Value: compareNumbers(args[0], args[1]) >= 0}, nil
+}
+
+// primIsEq tests identity: whether its arguments are the same object.
+// See sexpr.Identical for what that means for each type.
+func primIsEq(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("eq?", 2, 2, len(args))
 	}
 
-	return sexpr.Bool{Value: a.Value >= b.Value}, nil
+	return sexpr.Bool{Value: sexpr.Identical(args[0], args[1])}, nil
+}
+
+// primIsEqv is eq? extended to compare numbers of the same exactness by
+// value, so equal big integers are eqv? but 2 and 2.0 are not
+func primIsEqv(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("eqv?", 2, 2, len(args))
+	}
+
+	return sexpr.Bool{Value: sexpr.Equivalent(args[0], args[1])}, nil
+}
+
+// primIsEqual tests deep structural equality: lists, vectors, maps and
+// other containers are equal? when their elements are, strings and byte
+// vectors when their contents are, and numbers of the same exactness
+// when numerically equal. Atoms, channels and other reference values are
+// equal? only to themselves. A list is equal? to a pair chain with the
+// same elements but not to a vector.
+func primIsEqual(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("equal?", 2, 2, len(args))
+	}
+
+	return sexpr.Bool{Value: args[0].Equal(args[1])}, nil
 }
 
 // List primitives
 
 func primList(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if err := env.runtime.alloc(consSize * len(args)); err != nil {
+		return nil, err
+	}
 	return sexpr.List{Elements: args}, nil
 }
 
 func primCar(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("car: requires 1 argument, got %d", len(args))
+		return nil, arityError("car", 1, 1, len(args))
 	}
 
-	list, ok := args[0].(sexpr.List)
-	if !ok {
-		return nil, fmt.Errorf("car: expected list, got %v", args[0])
-	}
-
-	if len(list.Elements) == 0 {
-		return nil, fmt.Errorf("car: cannot take car of empty list")
+	switch v := args[0].(type) {
+	case sexpr.Pair:
+		return v.Car, nil
+	case sexpr.List:
+		if len(v.Elements) == 0 {
+			return nil, evalError("car", "cannot take car of empty list")
+		}
+		return v.Elements[0], nil
+	default:
+		return nil, typeError("car", "list", args[0])
 	}
-
-	return list.Elements[0], nil
 }
 
 func primCdr(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("cdr: requires 1 argument, got %d", len(args))
+		return nil, arityError("cdr", 1, 1, len(args))
 	}
 
-	list, ok := args[0].(sexpr.List)
-	if !ok {
-		return nil, fmt.Errorf("cdr: expected list, got %v", args[0])
-	}
-
-	if len(list.Elements) == 0 {
-		return nil, fmt.Errorf("cdr: cannot take cdr of empty list")
+	switch v := args[0].(type) {
+	case sexpr.Pair:
+		return v.Cdr, nil
+	case sexpr.List:
+		if len(v.Elements) == 0 {
+			return nil, evalError("cdr", "cannot take cdr of empty list")
+		}
+		return sexpr.List{Elements: v.Elements[1:]}, nil
+	default:
+		return nil, typeError("cdr", "list", args[0])
 	}
-
-	return sexpr.List{Elements: list.Elements[1:]}, nil
 }
 
+// primCons builds a pair in constant time; a list tail yields a proper
+// list and any other tail a dotted pair
 func primCons(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 2 {
-		return nil, fmt.Errorf("cons: requires 2 arguments, got %d", len(args))
+		return nil, arityError("cons", 2, 2, len(args))
 	}
-
-	list, ok := args[1].(sexpr.List)
-	if !ok {
-		return nil, fmt.Errorf("cons: second argument must be a list, got %v", args[1])
+	if err := env.runtime.alloc(consSize); err != nil {
+		return nil, err
 	}
 
-	elements := make([]sexpr.SExpr, 0, len(list.Elements)+1)
-	elements = append(elements, args[0])
-	elements = append(elements, list.Elements...)
+	return sexpr.Cons(args[0], args[1]), nil
+}
 
-	return sexpr.List{Elements: elements}, nil
+// Macros
+
+// primGensym handles (gensym [prefix])
+func primGensym(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	prefix := "G__"
+	switch len(args) {
+	case 0:
+	case 1:
+		switch p := args[0].(type) {
+		case sexpr.String:
+			prefix = p.Value
+		case sexpr.Symbol:
+			prefix = p.Name
+		default:
+			return nil, typeError("gensym", "string or symbol prefix", args[0])
+		}
+	default:
+		return nil, arityError("gensym", 0, 1, len(args))
+	}
+	return env.Runtime().Gensym(prefix), nil
 }
 
 // Type predicates
 
 func primIsNumber(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("number?: requires 1 argument, got %d", len(args))
+		return nil, arityError("number?", 1, 1, len(args))
 	}
 
-	_, ok := args[0].(sexpr.Number)
-	return sexpr.Bool{Value: ok}, nil
+	return sexpr.Bool{Value: isNumber(args[0])}, nil
 }
 
 func primIsSymbol(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("symbol?: requires 1 argument, got %d", len(args))
+		return nil, arityError("symbol?", 1, 1, len(args))
 	}
 
 	_, ok := args[0].(sexpr.Symbol)
@@ -294,16 +536,15 @@ func primIsSymbol(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 
 func primIsList(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("list?: requires 1 argument, got %d", len(args))
+		return nil, arityError("list?", 1, 1, len(args))
 	}
 
-	_, ok := args[0].(sexpr.List)
-	return sexpr.Bool{Value: ok}, nil
+	return sexpr.Bool{Value: sexpr.IsList(args[0])}, nil
 }
 
 func primIsNull(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 	if len(args) != 1 {
-		return nil, fmt.Errorf("null?: requires 1 argument, got %d", len(args))
+		return nil, arityError("null?", 1, 1, len(args))
 	}
 
 	list, ok := args[0].(sexpr.List)
@@ -313,3 +554,324 @@ func primIsNull(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
 
 	return sexpr.Bool{Value: len(list.Elements) == 0}, nil
 }
+
+func primIsGoValue(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("go-value?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(sexpr.GoValue)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+func primIsPromise(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("promise?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*sexpr.Promise)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// typePredicate returns a primitive reporting whether its argument
+// satisfies test
+func typePredicate(name string, test func(sexpr.SExpr) bool) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 1 {
+			return nil, arityError(name, 1, 1, len(args))
+		}
+		return sexpr.Bool{Value: test(args[0])}, nil
+	}
+}
+
+var (
+	primIsBoolean = typePredicate("boolean?", func(x sexpr.SExpr) bool {
+		_, ok := x.(sexpr.Bool)
+		return ok
+	})
+	primIsString = typePredicate("string?", func(x sexpr.SExpr) bool {
+		_, ok := x.(sexpr.String)
+		return ok
+	})
+	primIsProcedure = typePredicate("procedure?", func(x sexpr.SExpr) bool {
+		switch x.(type) {
+		case sexpr.Func, sexpr.Primitive:
+			return true
+		}
+		return false
+	})
+	// primNot handles (not x), true only for false and nil
+	primNot = typePredicate("not", func(x sexpr.SExpr) bool {
+		return !isTruthy(x)
+	})
+)
+
+// signPredicate returns a primitive reporting whether the sign of its
+// numeric argument, -1, 0 or 1, is want. NaN has no sign.
+func signPredicate(name string, want int) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		x, err := numberArg(name, args)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := x.(sexpr.Float); ok {
+			return sexpr.Bool{Value: !math.IsNaN(f.Value) && cmp.Compare(f.Value, 0) == want}, nil
+		}
+		return sexpr.Bool{Value: compareNumbers(x, sexpr.Number{}) == want}, nil
+	}
+}
+
+// parityPredicate returns a primitive reporting whether its integer
+// argument is odd, or even if odd is false
+func parityPredicate(name string, odd bool) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		x, err := numberArg(name, args)
+		if err != nil {
+			return nil, err
+		}
+		if !isInteger(x) {
+			return nil, typeError(name, "integer", x)
+		}
+		return sexpr.Bool{Value: (toBig(x).Bit(0) == 1) == odd}, nil
+	}
+}
+
+var (
+	primIsZero     = signPredicate("zero?", 0)
+	primIsPositive = signPredicate("positive?", 1)
+	primIsNegative = signPredicate("negative?", -1)
+	primIsEven     = parityPredicate("even?", false)
+	primIsOdd      = parityPredicate("odd?", true)
+)
+
+// primTypeOf handles (type-of x), a symbol naming the type of x, such as
+// number, string, list or function. A record's type is the name of its
+// record type. nil's type is null, as in null?, since the symbol nil
+// would read back as nil itself.
+func primTypeOf(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("type-of", 1, 1, len(args))
+	}
+	return sexpr.Symbol{Name: typeName(args[0])}, nil
+}
+
+// typeName returns the name type-of gives the type of value
+func typeName(value sexpr.SExpr) string {
+	switch v := value.(type) {
+	case sexpr.Nil:
+		return "null"
+	case sexpr.Bool:
+		return "boolean"
+	case sexpr.Number, sexpr.BigInt, sexpr.Float:
+		return "number"
+	case sexpr.String:
+		return "string"
+	case sexpr.Char:
+		return "char"
+	case sexpr.Symbol:
+		return "symbol"
+	case sexpr.Keyword:
+		return "keyword"
+	case sexpr.List:
+		return "list"
+	case sexpr.Pair:
+		return "pair"
+	case sexpr.Vector, *sexpr.MutableVector:
+		return "vector"
+	case sexpr.Map:
+		return "map"
+	case sexpr.Set:
+		return "set"
+	case sexpr.Bytes:
+		return "bytes"
+	case sexpr.Func, sexpr.Primitive:
+		return "function"
+	case sexpr.Macro:
+		return "macro"
+	case sexpr.Error:
+		return "error"
+	case sexpr.Tagged:
+		return "tagged"
+	case sexpr.Values:
+		return "values"
+	case sexpr.GoValue:
+		return "go-value"
+	case *sexpr.Record:
+		return v.Type.Name
+	case *sexpr.RecordType:
+		return "record-type"
+	case *sexpr.Port:
+		return "port"
+	case *sexpr.Channel:
+		return "channel"
+	case *sexpr.Atom:
+		return "atom"
+	case *sexpr.Promise:
+		return "promise"
+	case *sexpr.Future:
+		return "future"
+	case *Generator:
+		return "generator"
+	case *Actor:
+		return "actor"
+	case *Env:
+		return "environment"
+	case *Module:
+		return "module"
+	}
+	return "unknown"
+}
+
+// Promise primitives
+
+// primForce returns the value of a promise, or its argument unchanged if
+// it is not a promise
+func primForce(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("force", 1, 1, len(args))
+	}
+
+	promise, ok := args[0].(*sexpr.Promise)
+	if !ok {
+		return args[0], nil
+	}
+	return promise.Force()
+}
+
+// Control primitives
+
+// primApply handles (apply f arg... list), calling f with the given
+// arguments followed by the elements of list
+func primApply(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 {
+		return nil, arityError("apply", 2, -1, len(args))
+	}
+
+	last := args[len(args)-1]
+	spread, ok := sexpr.Elements(last)
+	if !ok {
+		if _, isNil := last.(sexpr.Nil); !isNil {
+			return nil, evalError("apply", "last argument must be a list, got %v", last)
+		}
+	}
+
+	callArgs := append(append([]sexpr.SExpr{}, args[1:len(args)-1]...), spread...)
+	return apply(args[0], callArgs, env)
+}
+
+// primValues handles (values v...). A single value is returned as is;
+// any other number of values is returned as a Values
+func primValues(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	return sexpr.Values{Elements: append([]sexpr.SExpr{}, args...)}, nil
+}
+
+// primCallWithValues handles (call-with-values producer consumer),
+// calling consumer with the values returned by producer
+func primCallWithValues(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("call-with-values", 2, 2, len(args))
+	}
+
+	produced, err := apply(args[0], nil, env)
+	if err != nil {
+		return nil, err
+	}
+	return apply(args[1], valuesOf(produced), env)
+}
+
+// valuesOf spreads a Values into its elements
+func valuesOf(value sexpr.SExpr) []sexpr.SExpr {
+	if v, ok := value.(sexpr.Values); ok {
+		return v.Elements
+	}
+	return []sexpr.SExpr{value}
+}
+
+// primEval handles (eval expr [env]), evaluating expr in env or, by
+// default, in the environment of the call
+func primEval(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, arityError("eval", 1, 2, len(args))
+	}
+
+	target := env
+	if len(args) == 2 {
+		e, ok := args[1].(*Env)
+		if !ok {
+			return nil, typeError("eval", "environment", args[1])
+		}
+		target = e
+	}
+	return Eval(args[0], target)
+}
+
+// primCurrentEnvironment returns the environment it is called from
+func primCurrentEnvironment(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("current-environment", 0, 0, len(args))
+	}
+	return env, nil
+}
+
+// primGlobalEnvironment returns the root of the calling environment
+func primGlobalEnvironment(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("global-environment", 0, 0, len(args))
+	}
+	return env.Global(), nil
+}
+
+func primIsEnvironment(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("environment?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*Env)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// Metadata primitives
+
+func primMeta(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("meta", 1, 1, len(args))
+	}
+
+	meta := sexpr.MetaOf(args[0])
+	if meta == nil {
+		return sexpr.Nil{}, nil
+	}
+	return *meta, nil
+}
+
+// primWithMeta accepts the metadata as a map, a list of alternating keys
+// and values, or nil to remove it
+func primWithMeta(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("with-meta", 2, 2, len(args))
+	}
+
+	var meta *sexpr.Map
+	switch m := args[1].(type) {
+	case sexpr.Map:
+		meta = &m
+	case sexpr.List:
+		built, err := sexpr.NewMap(m.Elements...)
+		if err != nil {
+			return nil, evalError("with-meta", "%v", err)
+		}
+		meta = &built
+	case sexpr.Nil:
+	default:
+		return nil, typeError("with-meta", "map", args[1])
+	}
+
+	result, err := sexpr.WithMeta(args[0], meta)
+	if err != nil {
+		return nil, evalError("with-meta", "%v", err)
+	}
+	return result, nil
+}
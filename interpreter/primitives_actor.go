@@ -0,0 +1,92 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadActorPrimitives adds the actor primitives to an environment. The
+// receive special form takes messages from the current actor's mailbox.
+func loadActorPrimitives(env *Env) {
+	env.Define("spawn", makePrimitive("spawn", primSpawn))
+	env.Define("supervise", makePrimitive("supervise", primSupervise))
+	env.Define("send", makePrimitive("send", primSendMessage))
+	env.Define("self", makePrimitive("self", primSelf))
+	env.Define("actor?", makePrimitive("actor?", primIsActor))
+	env.Define("actor-alive?", makePrimitive("actor-alive?", primIsActorAlive))
+}
+
+// primSpawn handles (spawn f arg...), applying f to the arguments in a
+// new actor and returning the actor
+func primSpawn(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 1 {
+		return nil, arityError("spawn", 1, -1, len(args))
+	}
+	return spawnActor(args[0], args[1:], 0, env), nil
+}
+
+// primSupervise handles (supervise restarts f arg...), which spawns an
+// actor like spawn but restarts f with the same arguments, actor and
+// mailbox when it fails, up to restarts times
+func primSupervise(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 {
+		return nil, arityError("supervise", 2, -1, len(args))
+	}
+
+	restarts, err := indexArg("supervise", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return spawnActor(args[1], args[2:], restarts, env), nil
+}
+
+// primSendMessage handles (send actor msg), queueing msg in the actor's
+// mailbox without waiting and returning msg
+func primSendMessage(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("send", 2, 2, len(args))
+	}
+
+	actor, err := actorArg("send", args[0])
+	if err != nil {
+		return nil, err
+	}
+	actor.Send(args[1])
+	return args[1], nil
+}
+
+// primSelf handles (self), returning the current actor
+func primSelf(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("self", 0, 0, len(args))
+	}
+	return currentActor(env), nil
+}
+
+func primIsActor(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("actor?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*Actor)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+func primIsActorAlive(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("actor-alive?", 1, 1, len(args))
+	}
+
+	actor, err := actorArg("actor-alive?", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Bool{Value: actor.Alive()}, nil
+}
+
+func actorArg(name string, value sexpr.SExpr) (*Actor, error) {
+	actor, ok := value.(*Actor)
+	if !ok {
+		return nil, typeError(name, "actor", value)
+	}
+	return actor, nil
+}
@@ -0,0 +1,105 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadAtomPrimitives adds the atom primitives to an environment
+func loadAtomPrimitives(env *Env) {
+	env.Define("atom", makePrimitive("atom", primAtom))
+	env.Define("atom?", makePrimitive("atom?", primIsAtom))
+	env.Define("deref", makePrimitive("deref", primDeref))
+	env.Define("reset!", makePrimitive("reset!", primReset))
+	env.Define("swap!", makePrimitive("swap!", primSwap))
+	env.Define("compare-and-set!", makePrimitive("compare-and-set!", primCompareAndSet))
+}
+
+func primAtom(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("atom", 1, 1, len(args))
+	}
+	return sexpr.NewAtom(args[0]), nil
+}
+
+func primIsAtom(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("atom?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*sexpr.Atom)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// primDeref handles (deref atom) and (deref future [timeout-ms
+// default]), which waits for a future's result, returning default if it
+// is not ready within the timeout
+func primDeref(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 0 {
+		if f, ok := args[0].(*sexpr.Future); ok {
+			return derefFuture(f, args[1:], env)
+		}
+	}
+	if len(args) != 1 {
+		return nil, arityError("deref", 1, 1, len(args))
+	}
+
+	a, err := atomArg("deref", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return a.Deref(), nil
+}
+
+// primReset handles (reset! atom value), returning value
+func primReset(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("reset!", 2, 2, len(args))
+	}
+
+	a, err := atomArg("reset!", args[0])
+	if err != nil {
+		return nil, err
+	}
+	a.Reset(args[1])
+	return args[1], nil
+}
+
+// primSwap handles (swap! atom f arg...), setting the atom to (f value
+// arg...) and returning the new value. f may be called more than once
+// when other goroutines update the atom concurrently.
+func primSwap(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 {
+		return nil, arityError("swap!", 2, -1, len(args))
+	}
+
+	a, err := atomArg("swap!", args[0])
+	if err != nil {
+		return nil, err
+	}
+	fn, extra := args[1], args[2:]
+	return a.Swap(func(v sexpr.SExpr) (sexpr.SExpr, error) {
+		return apply(fn, append([]sexpr.SExpr{v}, extra...), env)
+	})
+}
+
+// primCompareAndSet handles (compare-and-set! atom old new), setting the
+// atom to new only if its value is equal to old
+func primCompareAndSet(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 3 {
+		return nil, arityError("compare-and-set!", 3, 3, len(args))
+	}
+
+	a, err := atomArg("compare-and-set!", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Bool{Value: a.CompareAndSet(args[1], args[2])}, nil
+}
+
+func atomArg(name string, value sexpr.SExpr) (*sexpr.Atom, error) {
+	a, ok := value.(*sexpr.Atom)
+	if !ok {
+		return nil, typeError(name, "atom", value)
+	}
+	return a, nil
+}
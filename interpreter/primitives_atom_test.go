@@ -0,0 +1,51 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestAtomPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(atom 1)", "#<atom 1>"},
+		{"(atom? (atom 1))", "true"},
+		{"(atom? 1)", "false"},
+		{"(deref (atom '(1 2)))", "(1 2)"},
+		{"(let ((a (atom 1))) (reset! a 2) (deref a))", "2"},
+		{"(let ((a (atom 1))) (swap! a + 10))", "11"},
+		{"(let ((a (atom 1))) (swap! a (lambda (x) (* x 3))) (deref a))", "3"},
+		{"(let ((a (atom 1))) (list (compare-and-set! a 2 3) (compare-and-set! a 1 3) (deref a)))", "(false true 3)"},
+		{`(let ((a (atom 0)) (done (chan)))
+		   (define (work n) (when (> n 0) (swap! a + 1) (work (- n 1))))
+		   (go (begin (work 100) (send! done :ok)))
+		   (go (begin (work 100) (send! done :ok)))
+		   (recv! done)
+		   (recv! done)
+		   (deref a))`, "200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestAtomPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(atom)",
+		"(deref 1)",
+		"(reset! (atom 1))",
+		"(swap! (atom 1))",
+		"(swap! (atom 1) car)",
+		"(compare-and-set! 1 2 3)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
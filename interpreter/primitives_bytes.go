@@ -0,0 +1,247 @@
+package interpreter
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadBytesPrimitives adds the byte vector primitives to an environment
+func loadBytesPrimitives(env *Env) {
+	env.Define("bytes?", makePrimitive("bytes?", primIsBytes))
+	env.Define("bytes", makePrimitive("bytes", primBytes))
+	env.Define("make-bytes", makePrimitive("make-bytes", primMakeBytes))
+	env.Define("bytes-length", makePrimitive("bytes-length", primBytesLength))
+	env.Define("bytes-ref", makePrimitive("bytes-ref", primBytesRef))
+	env.Define("bytes-slice", makePrimitive("bytes-slice", primBytesSlice))
+	env.Define("bytes-append", makePrimitive("bytes-append", primBytesAppend))
+	env.Define("bytes->list", makePrimitive("bytes->list", primBytesToList))
+	env.Define("list->bytes", makePrimitive("list->bytes", primListToBytes))
+	env.Define("string->bytes", makePrimitive("string->bytes", primStringToBytes))
+	env.Define("bytes->string", makePrimitive("bytes->string", primBytesToString))
+}
+
+func primIsBytes(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("bytes?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(sexpr.Bytes)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+func primBytes(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if err := env.runtime.alloc(len(args)); err != nil {
+		return nil, err
+	}
+	octets := make([]byte, len(args))
+	for i, arg := range args {
+		b, err := byteArg("bytes", arg)
+		if err != nil {
+			return nil, err
+		}
+		octets[i] = b
+	}
+	return sexpr.Bytes{Value: octets}, nil
+}
+
+// primMakeBytes handles (make-bytes n [fill])
+func primMakeBytes(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("make-bytes", 1, 2, len(args))
+	}
+
+	n, err := indexArg("make-bytes", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var fill byte
+	if len(args) == 2 {
+		if fill, err = byteArg("make-bytes", args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := env.runtime.alloc(n); err != nil {
+		return nil, err
+	}
+
+	return sexpr.Bytes{Value: bytes.Repeat([]byte{fill}, n)}, nil
+}
+
+func primBytesLength(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("bytes-length", 1, 1, len(args))
+	}
+
+	b, err := bytesArg("bytes-length", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Number{Value: int64(len(b.Value))}, nil
+}
+
+func primBytesRef(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("bytes-ref", 2, 2, len(args))
+	}
+
+	b, err := bytesArg("bytes-ref", args[0])
+	if err != nil {
+		return nil, err
+	}
+	i, err := indexArg("bytes-ref", args[1])
+	if err != nil {
+		return nil, err
+	}
+	if i >= len(b.Value) {
+		return nil, evalError("bytes-ref", "index %d out of range for length %d", i, len(b.Value))
+	}
+
+	return sexpr.Number{Value: int64(b.Value[i])}, nil
+}
+
+// primBytesSlice handles (bytes-slice b start [end]), copying the bytes
+// from start up to but not including end
+func primBytesSlice(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("bytes-slice", 2, 3, len(args))
+	}
+
+	b, err := bytesArg("bytes-slice", args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := indexArg("bytes-slice", args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := len(b.Value)
+	if len(args) == 3 {
+		if end, err = indexArg("bytes-slice", args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if start > end || end > len(b.Value) {
+		return nil, evalError("bytes-slice", "range [%d, %d) out of bounds for length %d", start, end, len(b.Value))
+	}
+	if err := env.runtime.alloc(end - start); err != nil {
+		return nil, err
+	}
+
+	return sexpr.Bytes{Value: bytes.Clone(b.Value[start:end])}, nil
+}
+
+func primBytesAppend(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	size := 0
+	for _, arg := range args {
+		b, err := bytesArg("bytes-append", arg)
+		if err != nil {
+			return nil, err
+		}
+		size += len(b.Value)
+	}
+	if err := env.runtime.alloc(size); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, size)
+	for _, arg := range args {
+		result = append(result, arg.(sexpr.Bytes).Value...)
+	}
+	return sexpr.Bytes{Value: result}, nil
+}
+
+func primBytesToList(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("bytes->list", 1, 1, len(args))
+	}
+
+	b, err := bytesArg("bytes->list", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := env.runtime.alloc(consSize * len(b.Value)); err != nil {
+		return nil, err
+	}
+
+	elems := make([]sexpr.SExpr, len(b.Value))
+	for i, octet := range b.Value {
+		elems[i] = sexpr.Number{Value: int64(octet)}
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+func primListToBytes(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("list->bytes", 1, 1, len(args))
+	}
+
+	elems, ok := sexpr.Elements(args[0])
+	if !ok {
+		return nil, typeError("list->bytes", "list", args[0])
+	}
+	return primBytes(elems, env)
+}
+
+// primStringToBytes returns the UTF-8 encoding of a string
+func primStringToBytes(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("string->bytes", 1, 1, len(args))
+	}
+
+	s, ok := args[0].(sexpr.String)
+	if !ok {
+		return nil, typeError("string->bytes", "string", args[0])
+	}
+	if err := env.runtime.alloc(len(s.Value)); err != nil {
+		return nil, err
+	}
+	return sexpr.Bytes{Value: []byte(s.Value)}, nil
+}
+
+// primBytesToString decodes UTF-8 bytes as a string
+func primBytesToString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("bytes->string", 1, 1, len(args))
+	}
+
+	b, err := bytesArg("bytes->string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(b.Value) {
+		return nil, evalError("bytes->string", "invalid UTF-8 in %v", b)
+	}
+	if err := env.runtime.alloc(len(b.Value)); err != nil {
+		return nil, err
+	}
+	return sexpr.String{Value: string(b.Value)}, nil
+}
+
+func bytesArg(name string, value sexpr.SExpr) (sexpr.Bytes, error) {
+	b, ok := value.(sexpr.Bytes)
+	if !ok {
+		return sexpr.Bytes{}, typeError(name, "bytes", value)
+	}
+	return b, nil
+}
+
+func byteArg(name string, value sexpr.SExpr) (byte, error) {
+	n, ok := value.(sexpr.Number)
+	if !ok || n.Value < 0 || n.Value > 255 {
+		return 0, typeError(name, "byte", value)
+	}
+	return byte(n.Value), nil
+}
+
+// indexArg converts value to a non-negative int for use as an index or
+// length
+func indexArg(name string, value sexpr.SExpr) (int, error) {
+	n, ok := value.(sexpr.Number)
+	if !ok || n.Value < 0 || int64(int(n.Value)) != n.Value {
+		return 0, typeError(name, "non-negative integer", value)
+	}
+	return int(n.Value), nil
+}
@@ -0,0 +1,54 @@
+package interpreter
+
+import "testing"
+
+func TestBytesPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#u8(1 2 255)", "#u8(1 2 255)"},
+		{"(bytes? #u8())", "true"},
+		{`(bytes? "abc")`, "false"},
+		{"(bytes 104 105)", "#u8(104 105)"},
+		{"(make-bytes 3)", "#u8(0 0 0)"},
+		{"(make-bytes 2 7)", "#u8(7 7)"},
+		{"(bytes-length #u8(1 2 3))", "3"},
+		{"(bytes-ref #u8(10 20 30) 1)", "20"},
+		{"(bytes-slice #u8(1 2 3 4) 1)", "#u8(2 3 4)"},
+		{"(bytes-slice #u8(1 2 3 4) 1 3)", "#u8(2 3)"},
+		{"(bytes-append #u8(1) #u8() #u8(2 3))", "#u8(1 2 3)"},
+		{"(bytes-append)", "#u8()"},
+		{"(bytes->list #u8(1 2))", "(1 2)"},
+		{"(list->bytes (list 1 2))", "#u8(1 2)"},
+		{`(string->bytes "hé")`, "#u8(104 195 169)"},
+		{`(bytes->string #u8(104 195 169))`, `"hé"`},
+		{"(equal? #u8(1 2) (bytes 1 2))", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestBytesPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(bytes 256)",
+		"(bytes -1)",
+		"(make-bytes -1)",
+		"(bytes-ref #u8(1) 1)",
+		"(bytes-slice #u8(1 2) 2 1)",
+		"(bytes-slice #u8(1 2) 0 3)",
+		`(bytes-append #u8(1) "x")`,
+		"(bytes->string #u8(255))",
+		"(list->bytes 5)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadChannelPrimitives adds the channel primitives to an environment
+func loadChannelPrimitives(env *Env) {
+	env.Define("chan", makePrimitive("chan", primChan))
+	env.Define("channel?", makePrimitive("channel?", primIsChannel))
+	env.Define("send!", makePrimitive("send!", primSend))
+	env.Define("recv!", makePrimitive("recv!", primRecv))
+	env.Define("close!", makePrimitive("close!", primClose))
+}
+
+// primChan handles (chan [size]), making a channel with a buffer of size
+// values, unbuffered by default
+func primChan(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 1 {
+		return nil, arityError("chan", 0, 1, len(args))
+	}
+
+	size := 0
+	if len(args) == 1 {
+		var err error
+		if size, err = indexArg("chan", args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if err := env.runtime.alloc(consSize * (size + 1)); err != nil {
+		return nil, err
+	}
+	return sexpr.NewChannel(size), nil
+}
+
+func primIsChannel(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("channel?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*sexpr.Channel)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// primSend handles (send! ch value), blocking until the value is
+// received or buffered
+func primSend(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("send!", 2, 2, len(args))
+	}
+
+	ch, err := channelArg("send!", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Send(env.Context(), args[1]); err != nil {
+		if errors.Is(err, sexpr.ErrClosed) {
+			return nil, evalError("send!", "channel is closed")
+		}
+		return nil, env.interrupted()
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primRecv handles (recv! ch), blocking until a value is available. It
+// returns nil once the channel is closed and drained.
+func primRecv(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("recv!", 1, 1, len(args))
+	}
+
+	ch, err := channelArg("recv!", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, ok, err := ch.Recv(env.Context())
+	if err != nil {
+		return nil, env.interrupted()
+	}
+	if !ok {
+		return sexpr.Nil{}, nil
+	}
+	return value, nil
+}
+
+// primClose handles (close! ch). Further sends fail; receivers get the
+// values already buffered and then nil.
+func primClose(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("close!", 1, 1, len(args))
+	}
+
+	ch, err := channelArg("close!", args[0])
+	if err != nil {
+		return nil, err
+	}
+	ch.Close()
+	return sexpr.Nil{}, nil
+}
+
+func channelArg(name string, value sexpr.SExpr) (*sexpr.Channel, error) {
+	ch, ok := value.(*sexpr.Channel)
+	if !ok {
+		return nil, typeError(name, "channel", value)
+	}
+	return ch, nil
+}
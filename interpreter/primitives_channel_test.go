@@ -0,0 +1,103 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestChannelPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(chan)", "#<channel>"},
+		{"(channel? (chan 2))", "true"},
+		{"(channel? '())", "false"},
+		{"(let ((c (chan 1))) (send! c 42) (recv! c))", "42"},
+		{"(let ((c (chan))) (go (send! c (+ 1 2))) (recv! c))", "3"},
+		{"(let ((c (chan 1))) (send! c 1) (close! c) (list (recv! c) (recv! c)))", "(1 nil)"},
+		{`(let ((c (chan)))
+		   (define (producer n) (when (> n 0) (send! c n) (producer (- n 1))))
+		   (go (begin (producer 5) (close! c)))
+		   (loop ((sum 0))
+		     (let ((v (recv! c)))
+		       (if v (recur (+ sum v)) sum))))`, "15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestChannelPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(chan -1)",
+		"(chan 1 2)",
+		"(send! 1 2)",
+		"(send! (chan))",
+		"(recv! '())",
+		"(let ((c (chan 1))) (close! c) (send! c 1))",
+		"(go)",
+		"(go 1 2)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+// reportWriter passes each write to a channel
+type reportWriter chan string
+
+func (w reportWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestGoReportsErrors(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	reports := make(reportWriter, 1)
+	env.Runtime().SetErrorOutput(sexpr.NewOutputPort("test", reports))
+
+	evalForms(t, env, "(go (car 1))")
+	select {
+	case report := <-reports:
+		if !strings.HasPrefix(report, "go: car:") {
+			t.Errorf("got report %q", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the error of the goroutine was not reported")
+	}
+
+	result := evalForms(t, env, "(current-error-port)")
+	if port, ok := result.(*sexpr.Port); !ok || port.Name != "test" {
+		t.Errorf("current-error-port = %v", result)
+	}
+}
+
+func TestChannelInterrupted(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define c (chan))")
+
+	for _, input := range []string{"(recv! c)", "(send! c 1)"} {
+		t.Run(input, func(t *testing.T) {
+			expr := evalForms(t, env, "'"+input)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := EvalContext(ctx, expr, env); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got error %v, want deadline exceeded", err)
+			}
+		})
+	}
+}
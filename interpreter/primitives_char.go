@@ -0,0 +1,133 @@
+package interpreter
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadCharPrimitives adds the character primitives to an environment
+func loadCharPrimitives(env *Env) {
+	env.Define("char?", makePrimitive("char?", primIsChar))
+	env.Define("char-alphabetic?", makePrimitive("char-alphabetic?", charPredicate("char-alphabetic?", unicode.IsLetter)))
+	env.Define("char-numeric?", makePrimitive("char-numeric?", charPredicate("char-numeric?", unicode.IsDigit)))
+	env.Define("char-whitespace?", makePrimitive("char-whitespace?", charPredicate("char-whitespace?", unicode.IsSpace)))
+	env.Define("char-upcase", makePrimitive("char-upcase", charMapper("char-upcase", unicode.ToUpper)))
+	env.Define("char-downcase", makePrimitive("char-downcase", charMapper("char-downcase", unicode.ToLower)))
+	env.Define("char->integer", makePrimitive("char->integer", primCharToInteger))
+	env.Define("integer->char", makePrimitive("integer->char", primIntegerToChar))
+	env.Define("string->list", makePrimitive("string->list", primStringToList))
+	env.Define("list->string", makePrimitive("list->string", primListToString))
+}
+
+func primIsChar(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("char?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(sexpr.Char)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// charArg checks that args is a single character for the primitive name
+func charArg(name string, args []sexpr.SExpr) (rune, error) {
+	if len(args) != 1 {
+		return 0, arityError(name, 1, 1, len(args))
+	}
+	c, ok := args[0].(sexpr.Char)
+	if !ok {
+		return 0, typeError(name, "character", args[0])
+	}
+	return c.Value, nil
+}
+
+// charPredicate returns a primitive testing a character with fn
+func charPredicate(name string, fn func(rune) bool) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		r, err := charArg(name, args)
+		if err != nil {
+			return nil, err
+		}
+		return sexpr.Bool{Value: fn(r)}, nil
+	}
+}
+
+// charMapper returns a primitive applying fn to a character
+func charMapper(name string, fn func(rune) rune) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		r, err := charArg(name, args)
+		if err != nil {
+			return nil, err
+		}
+		return sexpr.Char{Value: fn(r)}, nil
+	}
+}
+
+// primCharToInteger returns the Unicode code point of a character
+func primCharToInteger(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	r, err := charArg("char->integer", args)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Number{Value: int64(r)}, nil
+}
+
+// primIntegerToChar returns the character with a Unicode code point
+func primIntegerToChar(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("integer->char", 1, 1, len(args))
+	}
+
+	n, ok := args[0].(sexpr.Number)
+	if !ok {
+		return nil, typeError("integer->char", "integer", args[0])
+	}
+	if n.Value < 0 || n.Value > utf8.MaxRune || !utf8.ValidRune(rune(n.Value)) {
+		return nil, evalError("integer->char", "%d is not a Unicode code point", n.Value)
+	}
+	return sexpr.Char{Value: rune(n.Value)}, nil
+}
+
+// primStringToList returns the characters of a string
+func primStringToList(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("string->list", 1, 1, len(args))
+	}
+
+	s, err := stringArg("string->list", args[0])
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(s)
+	if err := env.runtime.alloc(consSize * len(runes)); err != nil {
+		return nil, err
+	}
+
+	elems := make([]sexpr.SExpr, len(runes))
+	for i, r := range runes {
+		elems[i] = sexpr.Char{Value: r}
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+// primListToString returns the string made of a list of characters
+func primListToString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("list->string", 1, 1, len(args))
+	}
+
+	elems, ok := sexpr.Elements(args[0])
+	if !ok {
+		return nil, typeError("list->string", "list", args[0])
+	}
+	runes := make([]rune, len(elems))
+	for i, elem := range elems {
+		c, ok := elem.(sexpr.Char)
+		if !ok {
+			return nil, typeError("list->string", "character", elem)
+		}
+		runes[i] = c.Value
+	}
+	return newString(string(runes), env)
+}
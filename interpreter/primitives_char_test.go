@@ -0,0 +1,61 @@
+package interpreter
+
+import "testing"
+
+func TestCharPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#\a`, `#\a`},
+		{`(list #\space #\newline #\x41)`, `(#\space #\newline #\A)`},
+		{`(char? #\a)`, "true"},
+		{`(char? "a")`, "false"},
+		{`(char-alphabetic? #\é)`, "true"},
+		{`(char-alphabetic? #\1)`, "false"},
+		{`(char-numeric? #\7)`, "true"},
+		{`(char-numeric? #\x)`, "false"},
+		{`(char-whitespace? #\tab)`, "true"},
+		{`(char-upcase #\a)`, `#\A`},
+		{`(char-upcase #\1)`, `#\1`},
+		{`(char-downcase #\Λ)`, `#\λ`},
+		{`(char->integer #\A)`, "65"},
+		{`(integer->char 955)`, `#\λ`},
+		{`(integer->char (char->integer #\z))`, `#\z`},
+		{`(string->list "hé")`, `(#\h #\é)`},
+		{`(string->list "")`, "()"},
+		{`(list->string (list #\h #\é))`, `"hé"`},
+		{`(list->string '())`, `""`},
+		{`(equal? #\a #\a)`, "true"},
+		{`(eq? #\a (integer->char 97))`, "true"},
+		{`(equal? #\a "a")`, "false"},
+		{`(str #\a "b")`, `"ab"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestCharPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		`(char-upcase "a")`,
+		`(char-alphabetic?)`,
+		`(char->integer 65)`,
+		`(integer->char -1)`,
+		`(integer->char 55296)`,
+		`(integer->char 1114112)`,
+		`(integer->char #\a)`,
+		`(string->list 'abc)`,
+		`(list->string (list #\a "b"))`,
+		`(list->string "ab")`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadErrorPrimitives adds the error value primitives to an environment
+func loadErrorPrimitives(env *Env) {
+	env.Define("make-error", makePrimitive("make-error", primMakeError))
+	env.Define("error?", makePrimitive("error?", primIsError))
+	env.Define("error-kind", makePrimitive("error-kind", primErrorKind))
+	env.Define("error-message", makePrimitive("error-message", primErrorMessage))
+	env.Define("error-data", makePrimitive("error-data", primErrorData))
+	env.Define("raise", makePrimitive("raise", primRaise))
+	env.Define("error", makePrimitive("error", primError))
+}
+
+// primMakeError handles (make-error kind message [data])
+func primMakeError(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, arityError("make-error", 2, 3, len(args))
+	}
+
+	kind, ok := args[0].(sexpr.Symbol)
+	if !ok {
+		return nil, evalError("make-error", "kind must be a symbol, got %v", args[0])
+	}
+
+	message, ok := args[1].(sexpr.String)
+	if !ok {
+		return nil, evalError("make-error", "message must be a string, got %v", args[1])
+	}
+
+	var data sexpr.SExpr = sexpr.Nil{}
+	if len(args) == 3 {
+		data = args[2]
+	}
+
+	return sexpr.Error{Kind: kind, Message: message.Value, Data: data}, nil
+}
+
+func primIsError(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("error?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(sexpr.Error)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+func primErrorKind(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	cond, err := errorArg("error-kind", args)
+	if err != nil {
+		return nil, err
+	}
+	return cond.Kind, nil
+}
+
+func primErrorMessage(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	cond, err := errorArg("error-message", args)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.String{Value: cond.Message}, nil
+}
+
+func primErrorData(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	cond, err := errorArg("error-data", args)
+	if err != nil {
+		return nil, err
+	}
+	if cond.Data == nil {
+		return sexpr.Nil{}, nil
+	}
+	return cond.Data, nil
+}
+
+// primRaise aborts evaluation with the given value, which may be any
+// value but is usually an error
+func primRaise(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("raise", 1, 1, len(args))
+	}
+
+	return nil, &RaiseError{Value: args[0]}
+}
+
+// primError handles (error message data...), raising an error of kind
+// error whose data is the list of the remaining arguments, or nil if
+// there are none
+func primError(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 1 {
+		return nil, arityError("error", 1, -1, len(args))
+	}
+
+	message, ok := args[0].(sexpr.String)
+	if !ok {
+		return nil, typeError("error", "string", args[0])
+	}
+
+	var data sexpr.SExpr = sexpr.Nil{}
+	if len(args) > 1 {
+		data = sexpr.List{Elements: append([]sexpr.SExpr{}, args[1:]...)}
+	}
+	return nil, &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "error"},
+		Message: message.Value,
+		Data:    data,
+	}}
+}
+
+// errorArg checks that args holds a single error value
+func errorArg(name string, args []sexpr.SExpr) (sexpr.Error, error) {
+	if len(args) != 1 {
+		return sexpr.Error{}, arityError(name, 1, 1, len(args))
+	}
+
+	cond, ok := args[0].(sexpr.Error)
+	if !ok {
+		return sexpr.Error{}, typeError(name, "error", args[0])
+	}
+	return cond, nil
+}
@@ -0,0 +1,107 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestErrorPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(error? (make-error (quote io-error) "closed"))`, "true"},
+		{`(error? "closed")`, "false"},
+		{`(error-kind (make-error (quote io-error) "closed"))`, "io-error"},
+		{`(error-message (make-error (quote io-error) "closed"))`, `"closed"`},
+		{`(error-data (make-error (quote io-error) "closed" (list 1 2)))`, "(1 2)"},
+		{`(error-data (make-error (quote io-error) "closed"))`, "nil"},
+		{`(make-error (quote io-error) "closed")`, "#<error io-error: closed>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestPrimRaise(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected sexpr.SExpr
+		message  string
+	}{
+		{
+			`(raise (make-error (quote bad-input) "negative" -1))`,
+			sexpr.Error{Kind: sexpr.Symbol{Name: "bad-input"}, Message: "negative",
+				Data: sexpr.Number{Value: -1}},
+			"bad-input: negative",
+		},
+		{
+			`(raise (list 1 "two"))`,
+			sexpr.List{Elements: []sexpr.SExpr{sexpr.Number{Value: 1}, sexpr.String{Value: "two"}}},
+			`raised: (1 "two")`,
+		},
+		{
+			`(error "not found" 'key 2)`,
+			sexpr.Error{Kind: sexpr.Symbol{Name: "error"}, Message: "not found",
+				Data: sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: "key"}, sexpr.Number{Value: 2}}}},
+			"error: not found",
+		},
+		{
+			`(error "failed")`,
+			sexpr.Error{Kind: sexpr.Symbol{Name: "error"}, Message: "failed", Data: sexpr.Nil{}},
+			"error: failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+
+			tokens, _ := parser.Tokenize(tt.input)
+			expr, _ := parser.Read(tokens)
+			_, err := Eval(expr, env)
+
+			var raised *RaiseError
+			if !errors.As(err, &raised) {
+				t.Fatalf("expected RaiseError, got %v", err)
+			}
+			if !raised.Value.Equal(tt.expected) {
+				t.Errorf("raised %v, want %v", raised.Value, tt.expected)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("message %q, want %q", err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestErrorPrimitiveArgumentErrors(t *testing.T) {
+	inputs := []string{
+		`(make-error "io-error" "closed")`,
+		`(make-error (quote io-error) 42)`,
+		`(error-message 42)`,
+		`(raise)`,
+		`(error)`,
+		`(error 'oops)`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+
+			tokens, _ := parser.Tokenize(input)
+			expr, _ := parser.Read(tokens)
+			if _, err := Eval(expr, env); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
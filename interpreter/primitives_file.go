@@ -0,0 +1,120 @@
+package interpreter
+
+import (
+	"os"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadFilePrimitives adds the file primitives to an environment. They
+// need the filesystem capability; failures raise file-error conditions
+// whose data is the path.
+func loadFilePrimitives(env *Env) {
+	env.Define("slurp", restricted(CapFilesystem, "slurp", primSlurp))
+	env.Define("spit", restricted(CapFilesystem, "spit", primSpit))
+	env.Define("open-input-file", restricted(CapFilesystem, "open-input-file", primOpenInputFile))
+	env.Define("open-output-file", restricted(CapFilesystem, "open-output-file", primOpenOutputFile))
+}
+
+// fileError returns a file-error condition for err, a failure of name to
+// open, read or write the file at path
+func fileError(name, path string, err error) error {
+	return &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "file-error"},
+		Message: name + ": " + err.Error(),
+		Data:    sexpr.String{Value: path},
+	}}
+}
+
+// openFlags returns the flags for opening a file for writing, appending
+// to it if the optional argument args[i] is true
+func openFlags(args []sexpr.SExpr, i int) int {
+	if len(args) > i && isTruthy(args[i]) {
+		return os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+}
+
+// primSlurp handles (slurp path), the contents of a file as a string
+func primSlurp(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("slurp", 1, 1, len(args))
+	}
+
+	path, err := stringArg("slurp", args[0])
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fileError("slurp", path, err)
+	}
+	if err := env.runtime.alloc(int(info.Size())); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileError("slurp", path, err)
+	}
+	return sexpr.String{Value: string(data)}, nil
+}
+
+// primSpit handles (spit path value [append?]), writing the display form
+// of value to a file, replacing its contents unless append? is true
+func primSpit(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("spit", 2, 3, len(args))
+	}
+
+	path, err := stringArg("spit", args[0])
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, openFlags(args, 2), 0o666)
+	if err != nil {
+		return nil, fileError("spit", path, err)
+	}
+	_, err = f.WriteString(sexpr.Display(args[1]))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fileError("spit", path, err)
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primOpenInputFile handles (open-input-file path)
+func primOpenInputFile(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("open-input-file", 1, 1, len(args))
+	}
+
+	path, err := stringArg("open-input-file", args[0])
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fileError("open-input-file", path, err)
+	}
+	return sexpr.NewInputPort(path, f), nil
+}
+
+// primOpenOutputFile handles (open-output-file path [append?]). The file
+// is created if needed and truncated unless append? is true.
+func primOpenOutputFile(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("open-output-file", 1, 2, len(args))
+	}
+
+	path, err := stringArg("open-output-file", args[0])
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, openFlags(args, 1), 0o666)
+	if err != nil {
+		return nil, fileError("open-output-file", path, err)
+	}
+	return sexpr.NewOutputPort(path, f), nil
+}
@@ -0,0 +1,90 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestFilePrimitives(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	quoted := `"` + path + `"`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(spit " + quoted + ` "héllo\n")`, "nil"},
+		{"(slurp " + quoted + ")", `"héllo\n"`},
+		{"(spit " + quoted + " 42 true)", "nil"},
+		{"(slurp " + quoted + ")", `"héllo\n42"`},
+		{"(let ((p (open-input-file " + quoted + "))) (list (read-char p) (read-char p) (read-line p) (read-line p) (read-line p) (read-char p)))",
+			`(#\h #\é "llo" "42" nil nil)`},
+		{"(let ((p (open-output-file " + quoted + "))) (begin (write-string \"a\" p) (display 1 p) (close-port p) (slurp " + quoted + ")))", `"a1"`},
+		{"(let ((p (open-output-file " + quoted + " true))) (begin (write-string \"b\" p) (close-port p) (close-port p) (slurp " + quoted + ")))", `"a1b"`},
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := evalString(env, tt.input)
+			if err != nil {
+				t.Fatalf("eval error: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.txt")
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	for _, input := range []string{
+		`(slurp "` + missing + `")`,
+		`(open-input-file "` + missing + `")`,
+		`(spit "` + filepath.Join(missing, "x") + `" 1)`,
+		`(open-output-file "` + dir + `")`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := evalString(env, input)
+			var raised *RaiseError
+			if !errors.As(err, &raised) {
+				t.Fatalf("got %v, want a file-error", err)
+			}
+			cond := raised.Value.(sexpr.Error)
+			if cond.Kind.Name != "file-error" {
+				t.Errorf("got kind %s, want file-error", cond.Kind.Name)
+			}
+			if _, ok := cond.Data.(sexpr.String); !ok {
+				t.Errorf("got data %v, want the path", cond.Data)
+			}
+		})
+	}
+
+	// Closed ports raise io-error
+	path := filepath.Join(dir, "closed.txt")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := evalForms(t, env, `(let ((p (open-input-file "`+path+`")))
+		(begin (close-port p) (try (read-char p) (catch e (error-kind e)))))`)
+	if result.String() != "io-error" {
+		t.Errorf("got %v, want io-error", result)
+	}
+
+	for _, input := range []string{"(slurp 1)", "(spit)", "(write-string 1)", "(close-port 1)", "(read-char 1)"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package interpreter
+
+import (
+	"time"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadFuturePrimitives adds the future primitives to an environment. The
+// future special form creates futures and deref also waits for them.
+func loadFuturePrimitives(env *Env) {
+	env.Define("await", makePrimitive("await", primAwait))
+	env.Define("future?", makePrimitive("future?", primIsFuture))
+	env.Define("future-done?", makePrimitive("future-done?", primIsFutureDone))
+}
+
+// primAwait handles (await future), waiting for the future's result. An
+// error in the future's computation is returned again here.
+func primAwait(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("await", 1, 1, len(args))
+	}
+
+	f, err := futureArg("await", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return derefFuture(f, nil, env)
+}
+
+// derefFuture waits for f, for at most the timeout in milliseconds when
+// opts is (timeout default), returning default if it expires
+func derefFuture(f *sexpr.Future, opts []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	switch len(opts) {
+	case 0:
+		value, err := f.Await(env.Context())
+		if ierr := env.interrupted(); ierr != nil {
+			return nil, ierr
+		}
+		return value, err
+	case 2:
+		ms, err := indexArg("deref", opts[0])
+		if err != nil {
+			return nil, err
+		}
+		value, ok, err := f.AwaitTimeout(env.Context(), time.Duration(ms)*time.Millisecond)
+		if ierr := env.interrupted(); ierr != nil {
+			return nil, ierr
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return opts[1], nil
+		}
+		return value, nil
+	default:
+		return nil, arityError("deref", 1, 3, len(opts)+1)
+	}
+}
+
+func primIsFuture(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("future?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*sexpr.Future)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+func primIsFutureDone(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("future-done?", 1, 1, len(args))
+	}
+
+	f, err := futureArg("future-done?", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Bool{Value: f.IsDone()}, nil
+}
+
+func futureArg(name string, value sexpr.SExpr) (*sexpr.Future, error) {
+	f, ok := value.(*sexpr.Future)
+	if !ok {
+		return nil, typeError(name, "future", value)
+	}
+	return f, nil
+}
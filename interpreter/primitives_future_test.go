@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFuturePrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(future? (future 1))", "true"},
+		{"(future? 1)", "false"},
+		{"(await (future (+ 1 2)))", "3"},
+		{"(deref (future (+ 1 2)))", "3"},
+		{"(let ((f (future 1))) (await f) (future-done? f))", "true"},
+		{"(let ((c (chan))) (deref (future (recv! c)) 10 :late))", ":late"},
+		{"(deref (future :ready) 1000 :late)", ":ready"},
+		{`(let ((fs (list (future (* 2 3)) (future (* 4 5)) (future (* 6 7)))))
+		   (+ (await (car fs)) (await (car (cdr fs))) (await (car (cdr (cdr fs))))))`, "68"},
+		{"(try (await (future (car 1))) (catch e (error-message e)))", `"car: expected list, got 1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestFuturePrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(future)",
+		"(future 1 2)",
+		"(await 1)",
+		"(await (future (car 1)))",
+		"(deref (future 1) 10)",
+		"(deref (future 1) -1 :x)",
+		"(future-done? 1)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestFutureRaise(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	_, err := evalString(env, "(await (future (raise :boom)))")
+	var raised *RaiseError
+	if !errors.As(err, &raised) || raised.Value.String() != ":boom" {
+		t.Errorf("got error %v, want raised :boom", err)
+	}
+}
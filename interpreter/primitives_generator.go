@@ -0,0 +1,61 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadGeneratorPrimitives adds the generator primitives to an
+// environment. The generator special form creates generators.
+func loadGeneratorPrimitives(env *Env) {
+	env.Define("yield", makePrimitive("yield", primYield))
+	env.Define("next", makePrimitive("next", primNext))
+	env.Define("generator?", makePrimitive("generator?", primIsGenerator))
+}
+
+// primYield handles (yield value) in the body of a generator, or in a
+// function called from it
+func primYield(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("yield", 1, 1, len(args))
+	}
+	if env.generator == nil {
+		return nil, evalError("yield", "not inside a generator")
+	}
+	if err := env.generator.yield(args[0], env); err != nil {
+		return nil, err
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primNext handles (next generator [default]), returning the generator's
+// next value, or default (nil if omitted) once it is exhausted
+func primNext(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("next", 1, 2, len(args))
+	}
+
+	g, ok := args[0].(*Generator)
+	if !ok {
+		return nil, typeError("next", "generator", args[0])
+	}
+	value, ok, err := g.next(env)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return sexpr.Nil{}, nil
+	}
+	return value, nil
+}
+
+func primIsGenerator(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("generator?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*Generator)
+	return sexpr.Bool{Value: ok}, nil
+}
@@ -0,0 +1,41 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/convert"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadJSONPrimitives adds the JSON conversion primitives to an environment
+func loadJSONPrimitives(env *Env) {
+	env.Define("json->sexpr", makePrimitive("json->sexpr", primJSONToSExpr))
+	env.Define("sexpr->json", makePrimitive("sexpr->json", primSExprToJSON))
+}
+
+func primJSONToSExpr(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("json->sexpr", 1, 1, len(args))
+	}
+
+	s, ok := args[0].(sexpr.String)
+	if !ok {
+		return nil, typeError("json->sexpr", "string", args[0])
+	}
+
+	result, err := convert.FromJSON([]byte(s.Value))
+	if err != nil {
+		return nil, evalError("json->sexpr", "%v", err)
+	}
+	return result, nil
+}
+
+func primSExprToJSON(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("sexpr->json", 1, 1, len(args))
+	}
+
+	data, err := convert.ToJSON(args[0])
+	if err != nil {
+		return nil, evalError("sexpr->json", "%v", err)
+	}
+	return sexpr.String{Value: string(data)}, nil
+}
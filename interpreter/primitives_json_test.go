@@ -0,0 +1,41 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+)
+
+func TestJSONPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(json->sexpr "[1, 2, 3]")`, "(1 2 3)"},
+		{`(car (json->sexpr "[\"a\", true]"))`, `"a"`},
+		{`(json->sexpr "{\"k\": 1.5}")`, `{"k" 1.5}`},
+		{`(sexpr->json (list 1 "two" (list)))`, `"[1,\"two\",[]]"`},
+		{`(sexpr->json (json->sexpr "{\"a\":[null]}"))`, `"{\"a\":[null]}"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestJSONPrimitiveErrors(t *testing.T) {
+	for _, input := range []string{`(json->sexpr "{")`, `(json->sexpr 1)`, `(sexpr->json +)`} {
+		t.Run(input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+
+			tokens, _ := parser.Tokenize(input)
+			expr, _ := parser.Read(tokens)
+			if _, err := Eval(expr, env); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,773 @@
+package interpreter
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadListPrimitives adds the list library to an environment. Lists built
+// with cons are accepted wherever a list is, as long as they are proper.
+func loadListPrimitives(env *Env) {
+	env.Define("length", makePrimitive("length", primLength))
+	env.Define("append", makePrimitive("append", primAppend))
+	env.Define("reverse", makePrimitive("reverse", primReverse))
+	env.Define("nth", makePrimitive("nth", listRef("nth")))
+	env.Define("list-ref", makePrimitive("list-ref", listRef("list-ref")))
+	env.Define("last", makePrimitive("last", primLast))
+	env.Define("take", makePrimitive("take", primTake))
+	env.Define("drop", makePrimitive("drop", primDrop))
+	env.Define("flatten", makePrimitive("flatten", primFlatten))
+	env.Define("member", makePrimitive("member", primMember))
+	env.Define("memq", makePrimitive("memq", primMemq))
+	env.Define("range", makePrimitive("range", primRange))
+	env.Define("iota", makePrimitive("iota", primIota))
+
+	// Association lists
+	env.Define("assoc", makePrimitive("assoc", primAssoc))
+	env.Define("assq", makePrimitive("assq", primAssq))
+	env.Define("alist->map", makePrimitive("alist->map", primAlistToMap))
+
+	// Higher-order functions
+	env.Define("map", makePrimitive("map", primMap))
+	env.Define("for-each", makePrimitive("for-each", primForEach))
+	env.Define("filter", makePrimitive("filter", primFilter))
+	env.Define("reduce", makePrimitive("reduce", primReduce))
+	env.Define("fold-left", makePrimitive("fold-left", foldList("fold-left", false)))
+	env.Define("fold-right", makePrimitive("fold-right", foldList("fold-right", true)))
+	env.Define("sort", makePrimitive("sort", primSort))
+	env.Define("sort-by", makePrimitive("sort-by", primSortBy))
+}
+
+// listArg returns the elements of value, which must be a proper list
+func listArg(name string, value sexpr.SExpr) ([]sexpr.SExpr, error) {
+	elems, ok := sexpr.Elements(value)
+	if !ok {
+		return nil, typeError(name, "list", value)
+	}
+	return elems, nil
+}
+
+// newList returns elems as a list, charging its cells to the memory
+// budget
+func newList(elems []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if err := env.runtime.alloc(consSize * len(elems)); err != nil {
+		return nil, err
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+func primLength(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("length", 1, 1, len(args))
+	}
+
+	elems, err := listArg("length", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Number{Value: int64(len(elems))}, nil
+}
+
+// primAppend handles (append list... [tail]). Every argument but the
+// last must be a proper list; the last becomes the tail of the result, so
+// a tail that is not a list makes an improper list.
+func primAppend(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) == 0 {
+		return sexpr.List{}, nil
+	}
+
+	var elems []sexpr.SExpr
+	for _, arg := range args[:len(args)-1] {
+		list, err := listArg("append", arg)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, list...)
+	}
+
+	tail := args[len(args)-1]
+	if rest, ok := sexpr.Elements(tail); ok {
+		return newList(append(elems, rest...), env)
+	}
+	if err := env.runtime.alloc(consSize * len(elems)); err != nil {
+		return nil, err
+	}
+	for i := len(elems) - 1; i >= 0; i-- {
+		tail = sexpr.Cons(elems[i], tail)
+	}
+	return tail, nil
+}
+
+func primReverse(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("reverse", 1, 1, len(args))
+	}
+
+	elems, err := listArg("reverse", args[0])
+	if err != nil {
+		return nil, err
+	}
+	reversed := slices.Clone(elems)
+	slices.Reverse(reversed)
+	return newList(reversed, env)
+}
+
+// listRef returns a primitive handling (name list index), the element of
+// list at a zero-based index
+func listRef(name string) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 2 {
+			return nil, arityError(name, 2, 2, len(args))
+		}
+
+		elems, err := listArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		i, err := indexArg(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		if i >= len(elems) {
+			return nil, evalError(name, "index %d out of range for length %d", i, len(elems))
+		}
+		return elems[i], nil
+	}
+}
+
+func primLast(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("last", 1, 1, len(args))
+	}
+
+	elems, err := listArg("last", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(elems) == 0 {
+		return nil, evalError("last", "cannot take last of empty list")
+	}
+	return elems[len(elems)-1], nil
+}
+
+// countArgs checks (name list n) and returns the elements of list and n
+// limited to its length
+func countArgs(name string, args []sexpr.SExpr) ([]sexpr.SExpr, int, error) {
+	if len(args) != 2 {
+		return nil, 0, arityError(name, 2, 2, len(args))
+	}
+
+	elems, err := listArg(name, args[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := indexArg(name, args[1])
+	if err != nil {
+		return nil, 0, err
+	}
+	return elems, min(n, len(elems)), nil
+}
+
+// primTake handles (take list n), the first n elements of list, or all of
+// them if it is shorter
+func primTake(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	elems, n, err := countArgs("take", args)
+	if err != nil {
+		return nil, err
+	}
+	return newList(slices.Clone(elems[:n]), env)
+}
+
+// primDrop handles (drop list n), list without its first n elements, or
+// the empty list if it is shorter
+func primDrop(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	elems, n, err := countArgs("drop", args)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.List{Elements: elems[n:]}, nil
+}
+
+// primFlatten returns the elements of a list and of the lists nested in
+// it, in order, as one list. Vectors and other collections are elements
+// like any other value.
+func primFlatten(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("flatten", 1, 1, len(args))
+	}
+
+	elems, err := listArg("flatten", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return newList(flatten(nil, elems), env)
+}
+
+// flatten appends the elements of elems to result, descending into
+// proper lists
+func flatten(result, elems []sexpr.SExpr) []sexpr.SExpr {
+	for _, elem := range elems {
+		if nested, ok := sexpr.Elements(elem); ok {
+			result = flatten(result, nested)
+		} else {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
+
+// primRange handles (range end), (range start end) and (range start end
+// step), the list of numbers from start, 0 by default, counting by step,
+// 1 by default, up to but not including end
+func primRange(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, arityError("range", 1, 3, len(args))
+	}
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, typeError("range", "number", arg)
+		}
+	}
+
+	var start, step sexpr.SExpr = sexpr.Number{Value: 0}, sexpr.Number{Value: 1}
+	end := args[0]
+	if len(args) > 1 {
+		start, end = args[0], args[1]
+	}
+	if len(args) > 2 {
+		step = args[2]
+	}
+	sign := compareNumbers(step, sexpr.Number{})
+	if sign == 0 {
+		return nil, evalError("range", "step cannot be zero")
+	}
+
+	var elems []sexpr.SExpr
+	for n := start; compareNumbers(n, end) == -sign; n = addNumbers(n, step) {
+		if err := env.poll(len(elems)); err != nil {
+			return nil, err
+		}
+		if err := env.runtime.alloc(consSize); err != nil {
+			return nil, err
+		}
+		elems = append(elems, n)
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+// primIota handles (iota count [start [step]]), the list of count numbers
+// from start, 0 by default, counting by step, 1 by default
+func primIota(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, arityError("iota", 1, 3, len(args))
+	}
+	count, err := indexArg("iota", args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		if !isNumber(arg) {
+			return nil, typeError("iota", "number", arg)
+		}
+	}
+
+	var n, step sexpr.SExpr = sexpr.Number{Value: 0}, sexpr.Number{Value: 1}
+	if len(args) > 1 {
+		n = args[1]
+	}
+	if len(args) > 2 {
+		step = args[2]
+	}
+	if err := env.runtime.alloc(consSize * count); err != nil {
+		return nil, err
+	}
+
+	elems := make([]sexpr.SExpr, count)
+	for i := range elems {
+		if err := env.poll(i); err != nil {
+			return nil, err
+		}
+		elems[i] = n
+		n = addNumbers(n, step)
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+// findTail returns the tail of list starting at the first element that
+// matches x, or false if there is none. The tail shares structure with
+// list.
+func findTail(name string, x, list sexpr.SExpr, match func(a, b sexpr.SExpr) (bool, error)) (sexpr.SExpr, error) {
+	if _, err := listArg(name, list); err != nil {
+		return nil, err
+	}
+
+	for {
+		switch l := list.(type) {
+		case sexpr.Pair:
+			found, err := match(x, l.Car)
+			if err != nil || found {
+				return l, err
+			}
+			list = l.Cdr
+			continue
+		case sexpr.List:
+			for i, elem := range l.Elements {
+				found, err := match(x, elem)
+				if err != nil {
+					return nil, err
+				}
+				if found {
+					return sexpr.List{Elements: l.Elements[i:]}, nil
+				}
+			}
+		}
+		return sexpr.Bool{Value: false}, nil
+	}
+}
+
+// primMember handles (member x list [equal]), the tail of list starting
+// at the first element equal? to x, or false. A two-argument function
+// given as equal replaces equal?.
+func primMember(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("member", 2, 3, len(args))
+	}
+
+	return findTail("member", args[0], args[1], equalFunc(args, env))
+}
+
+// primMemq handles (memq x list), the tail of list starting at the first
+// element eq? to x, or false
+func primMemq(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("memq", 2, 2, len(args))
+	}
+
+	return findTail("memq", args[0], args[1], identical)
+}
+
+// equalFunc returns the test of member and assoc: the function given as
+// their third argument, if any, or else equal?
+func equalFunc(args []sexpr.SExpr, env *Env) func(a, b sexpr.SExpr) (bool, error) {
+	if len(args) < 3 {
+		return func(a, b sexpr.SExpr) (bool, error) {
+			return a.Equal(b), nil
+		}
+	}
+	return func(a, b sexpr.SExpr) (bool, error) {
+		result, err := apply(args[2], []sexpr.SExpr{a, b}, env)
+		return err == nil && isTruthy(result), err
+	}
+}
+
+// identical is the test of memq and assq
+func identical(a, b sexpr.SExpr) (bool, error) {
+	return sexpr.Identical(a, b), nil
+}
+
+// An association list is a list of entries whose car is a key and whose
+// cdr is the value bound to it, such as ((a . 1) (b . 2)). Entries may
+// also be proper lists, (a 1), whose value is then the list (1).
+
+// alistArg returns the entries of an association list
+func alistArg(name string, value sexpr.SExpr) ([]sexpr.SExpr, error) {
+	entries, err := listArg(name, value)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if _, ok := entryKey(entry); !ok {
+			return nil, typeError(name, "association list", value)
+		}
+	}
+	return entries, nil
+}
+
+// entryKey returns the car of an association list entry
+func entryKey(entry sexpr.SExpr) (sexpr.SExpr, bool) {
+	switch e := entry.(type) {
+	case sexpr.Pair:
+		return e.Car, true
+	case sexpr.List:
+		if len(e.Elements) > 0 {
+			return e.Elements[0], true
+		}
+	}
+	return nil, false
+}
+
+// entryValue returns the cdr of an association list entry
+func entryValue(entry sexpr.SExpr) sexpr.SExpr {
+	if p, ok := entry.(sexpr.Pair); ok {
+		return p.Cdr
+	}
+	return sexpr.List{Elements: entry.(sexpr.List).Elements[1:]}
+}
+
+// findEntry returns the first entry of alist whose key matches key, or
+// false if there is none
+func findEntry(name string, key, alist sexpr.SExpr, match func(a, b sexpr.SExpr) (bool, error)) (sexpr.SExpr, error) {
+	entries, err := alistArg(name, alist)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		k, _ := entryKey(entry)
+		found, err := match(key, k)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return entry, nil
+		}
+	}
+	return sexpr.Bool{Value: false}, nil
+}
+
+// primAssoc handles (assoc key alist [equal]), the first entry of alist
+// whose key is equal? to key, or false. A two-argument function given as
+// equal replaces equal?. Given three or more arguments, the first a map
+// or vector, assoc instead binds keys in it, as (assoc coll key
+// value...), unless the arguments are a key, an association list and a
+// function.
+func primAssoc(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) >= 3 && !isAlistLookup(args) {
+		switch args[0].(type) {
+		case sexpr.Map, sexpr.Vector:
+			return primAssocColl(args, env)
+		}
+	}
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("assoc", 2, 3, len(args))
+	}
+
+	return findEntry("assoc", args[0], args[1], equalFunc(args, env))
+}
+
+// isAlistLookup reports whether the three arguments of assoc are a key,
+// an association list and a function comparing keys
+func isAlistLookup(args []sexpr.SExpr) bool {
+	if len(args) != 3 {
+		return false
+	}
+	switch args[2].(type) {
+	case sexpr.Func, sexpr.Primitive:
+		_, err := alistArg("assoc", args[1])
+		return err == nil
+	}
+	return false
+}
+
+// primAssq handles (assq key alist), the first entry of alist whose key
+// is eq? to key, or false
+func primAssq(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("assq", 2, 2, len(args))
+	}
+
+	return findEntry("assq", args[0], args[1], identical)
+}
+
+// primAlistToMap returns a map with the bindings of an association list.
+// As with assoc, the first entry for a key wins.
+func primAlistToMap(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("alist->map", 1, 1, len(args))
+	}
+
+	entries, err := alistArg("alist->map", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := env.runtime.alloc(consSize * len(entries)); err != nil {
+		return nil, err
+	}
+
+	m := sexpr.Map{}
+	for _, entry := range entries {
+		key, _ := entryKey(entry)
+		if _, ok := m.Get(key); ok {
+			continue
+		}
+		if m, err = m.Assoc(key, entryValue(entry)); err != nil {
+			return nil, evalError("alist->map", "%v", err)
+		}
+	}
+	return m, nil
+}
+
+// listsArgs returns the elements of each of lists and the length of the
+// shortest, which is how far functions over several lists go
+func listsArgs(name string, lists []sexpr.SExpr) ([][]sexpr.SExpr, int, error) {
+	result := make([][]sexpr.SExpr, len(lists))
+	shortest := -1
+	for i, list := range lists {
+		elems, err := listArg(name, list)
+		if err != nil {
+			return nil, 0, err
+		}
+		result[i] = elems
+		if shortest < 0 || len(elems) < shortest {
+			shortest = len(elems)
+		}
+	}
+	return result, shortest, nil
+}
+
+// column returns the ith element of each of lists
+func column(lists [][]sexpr.SExpr, i int) []sexpr.SExpr {
+	args := make([]sexpr.SExpr, len(lists))
+	for j, list := range lists {
+		args[j] = list[i]
+	}
+	return args
+}
+
+// primMap handles (map f list...), the list of the results of calling f
+// with the first element of every list, then the second and so on, until
+// the shortest list runs out
+func primMap(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 {
+		return nil, arityError("map", 2, -1, len(args))
+	}
+
+	lists, n, err := listsArgs("map", args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if err := env.runtime.alloc(consSize * n); err != nil {
+		return nil, err
+	}
+
+	result := make([]sexpr.SExpr, n)
+	for i := range n {
+		if result[i], err = apply(args[0], column(lists, i), env); err != nil {
+			return nil, err
+		}
+	}
+	return sexpr.List{Elements: result}, nil
+}
+
+// primForEach handles (for-each f list...), calling f like map does for
+// its effects and returning nil
+func primForEach(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 {
+		return nil, arityError("for-each", 2, -1, len(args))
+	}
+
+	lists, n, err := listsArgs("for-each", args[1:])
+	if err != nil {
+		return nil, err
+	}
+	for i := range n {
+		if _, err := apply(args[0], column(lists, i), env); err != nil {
+			return nil, err
+		}
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primFilter handles (filter pred list), the elements of list for which
+// pred is true
+func primFilter(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("filter", 2, 2, len(args))
+	}
+
+	elems, err := listArg("filter", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var result []sexpr.SExpr
+	for _, elem := range elems {
+		keep, err := apply(args[0], []sexpr.SExpr{elem}, env)
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(keep) {
+			result = append(result, elem)
+		}
+	}
+	return newList(result, env)
+}
+
+// primReduce handles (reduce f [init] list), combining the elements of
+// list from the left with (f acc elem). Without init, the first element
+// starts the accumulation and list must not be empty.
+func primReduce(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("reduce", 2, 3, len(args))
+	}
+
+	elems, err := listArg("reduce", args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	var acc sexpr.SExpr
+	if len(args) == 3 {
+		acc = args[1]
+	} else {
+		if len(elems) == 0 {
+			return nil, evalError("reduce", "cannot reduce empty list without an initial value")
+		}
+		acc, elems = elems[0], elems[1:]
+	}
+
+	for _, elem := range elems {
+		if acc, err = apply(args[0], []sexpr.SExpr{acc, elem}, env); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+// foldList returns a primitive handling (name f init list...). fold-left
+// combines from the left with (f acc elem...), fold-right from the right
+// with (f elem... acc). Like map, it stops at the end of the shortest list.
+func foldList(name string, right bool) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) < 3 {
+			return nil, arityError(name, 3, -1, len(args))
+		}
+
+		lists, n, err := listsArgs(name, args[2:])
+		if err != nil {
+			return nil, err
+		}
+
+		acc := args[1]
+		for k := range n {
+			var callArgs []sexpr.SExpr
+			if right {
+				callArgs = append(column(lists, n-1-k), acc)
+			} else {
+				callArgs = append([]sexpr.SExpr{acc}, column(lists, k)...)
+			}
+			if acc, err = apply(args[0], callArgs, env); err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	}
+}
+
+// lessFunc returns the ordering used by sort: calls of the function less
+// if given, or else the natural order of numbers, strings and characters.
+// The first error is kept in *errp, and later comparisons report false.
+func lessFunc(name string, less sexpr.SExpr, env *Env, errp *error) func(a, b sexpr.SExpr) bool {
+	calls := 0
+	return func(a, b sexpr.SExpr) bool {
+		if *errp != nil {
+			return false
+		}
+		if *errp = env.poll(calls); *errp != nil {
+			return false
+		}
+		calls++
+		var result bool
+		if less == nil {
+			result, *errp = naturalLess(name, a, b)
+		} else {
+			var value sexpr.SExpr
+			value, *errp = apply(less, []sexpr.SExpr{a, b}, env)
+			result = *errp == nil && isTruthy(value)
+		}
+		return result
+	}
+}
+
+// naturalLess reports whether a sorts before b when both are numbers,
+// strings or characters
+func naturalLess(name string, a, b sexpr.SExpr) (bool, error) {
+	switch x := a.(type) {
+	case sexpr.String:
+		if y, ok := b.(sexpr.String); ok {
+			return x.Value < y.Value, nil
+		}
+	case sexpr.Char:
+		if y, ok := b.(sexpr.Char); ok {
+			return x.Value < y.Value, nil
+		}
+	default:
+		if isNumber(a) && isNumber(b) {
+			return compareNumbers(a, b) < 0, nil
+		}
+	}
+	return false, evalError(name, "cannot compare %v and %v without a comparator", a, b)
+}
+
+// primSort handles (sort list [less?]), a stable sort of list into the
+// order given by less?, which is called with two elements and returns
+// true if the first goes first. Without less?, numbers, strings and
+// characters are sorted in ascending order.
+func primSort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("sort", 1, 2, len(args))
+	}
+
+	elems, err := listArg("sort", args[0])
+	if err != nil {
+		return nil, err
+	}
+	var less sexpr.SExpr
+	if len(args) == 2 {
+		less = args[1]
+	}
+
+	sorted := slices.Clone(elems)
+	lessThan := lessFunc("sort", less, env, &err)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return lessThan(sorted[i], sorted[j])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newList(sorted, env)
+}
+
+// primSortBy handles (sort-by key list [less?]), sorting list like sort
+// by the result of calling key on each element. key is called once per
+// element.
+func primSortBy(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("sort-by", 2, 3, len(args))
+	}
+
+	elems, err := listArg("sort-by", args[1])
+	if err != nil {
+		return nil, err
+	}
+	var less sexpr.SExpr
+	if len(args) == 3 {
+		less = args[2]
+	}
+
+	type keyed struct {
+		key, elem sexpr.SExpr
+	}
+	items := make([]keyed, len(elems))
+	for i, elem := range elems {
+		key, err := apply(args[0], []sexpr.SExpr{elem}, env)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = keyed{key, elem}
+	}
+
+	lessThan := lessFunc("sort-by", less, env, &err)
+	sort.SliceStable(items, func(i, j int) bool {
+		return lessThan(items[i].key, items[j].key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]sexpr.SExpr, len(items))
+	for i, item := range items {
+		sorted[i] = item.elem
+	}
+	return newList(sorted, env)
+}
@@ -0,0 +1,312 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(length '())", "0"},
+		{"(length '(1 2 3))", "3"},
+		{"(length (cons 1 (cons 2 '())))", "2"},
+		{"(append)", "()"},
+		{"(append '(1) '() '(2 3))", "(1 2 3)"},
+		{"(append '(1 2) 3)", "(1 2 . 3)"},
+		{"(append '() 3)", "3"},
+		{"(append (cons 1 '()) '(2))", "(1 2)"},
+		{"(reverse '(1 2 3))", "(3 2 1)"},
+		{"(reverse '())", "()"},
+		{"(let ((xs '(1 2))) (reverse xs) xs)", "(1 2)"},
+		{"(nth '(a b c) 1)", "b"},
+		{"(list-ref '(a b c) 2)", "c"},
+		{"(last '(1 2 3))", "3"},
+		{"(take '(1 2 3) 2)", "(1 2)"},
+		{"(take '(1 2) 5)", "(1 2)"},
+		{"(drop '(1 2 3) 1)", "(2 3)"},
+		{"(drop '(1 2) 5)", "()"},
+		{"(flatten '(1 (2 (3 4)) () 5))", "(1 2 3 4 5)"},
+		{"(flatten '(1 [2 (3)] \"ab\"))", `(1 [2 (3)] "ab")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestListPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(length 5)",
+		"(length (cons 1 2))",
+		"(append 1 '(2))",
+		"(reverse)",
+		"(nth '(1 2) 2)",
+		"(nth '(1 2) -1)",
+		"(list-ref '(1 2))",
+		"(last '())",
+		"(take '(1 2) -1)",
+		"(drop 5 '(1 2))",
+		"(flatten 1)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestHigherOrderPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(map (lambda (x) (* x x)) '(1 2 3))", "(1 4 9)"},
+		{"(map + '(1 2 3) '(10 20 30))", "(11 22 33)"},
+		{"(map list '(1 2 3) '(a b))", "((1 a) (2 b))"},
+		{"(map car '())", "()"},
+		{"(for-each car '())", "nil"},
+		{"(let ((n 0)) (for-each (lambda (x) (set! n (+ n x))) '(1 2 3)) n)", "6"},
+		{"(let ((a (atom '()))) (for-each (lambda (x y) (swap! a (lambda (acc) (cons (+ x y) acc)))) '(1 2) '(10 20 30)) (deref a))", "(22 11)"},
+		{"(filter (lambda (x) (> x 1)) '(1 2 3))", "(2 3)"},
+		{"(filter number? '(a 1 \"b\" 2))", "(1 2)"},
+		{"(filter number? '())", "()"},
+		{"(reduce + '(1 2 3 4))", "10"},
+		{"(reduce + 0 '())", "0"},
+		{"(reduce (lambda (acc x) (cons x acc)) '() '(1 2 3))", "(3 2 1)"},
+		{"(reduce + '(5))", "5"},
+		{"(fold-left cons '() '(1 2 3))", "(((() . 1) . 2) . 3)"},
+		{"(fold-right cons '() '(1 2 3))", "(1 2 3)"},
+		{"(fold-left (lambda (acc x y) (+ acc (* x y))) 0 '(1 2 3) '(4 5 6))", "32"},
+		{"(fold-right (lambda (x y acc) (cons (list x y) acc)) '() '(1 2) '(a b c))", "((1 a) (2 b))"},
+		{"(let ((n 0)) (map (lambda (x) (set! n (+ n x))) '(1 2 3)) n)", "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestHigherOrderPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(map car)",
+		"(map car 5)",
+		"(map 5 '(1))",
+		"(map car '(1 2))",
+		"(for-each car)",
+		"(for-each car '(1))",
+		"(for-each car 5)",
+		"(filter number?)",
+		"(filter (lambda () true) '(1))",
+		"(reduce + '())",
+		"(reduce + 1 2 3)",
+		"(fold-left + 0)",
+		"(fold-right + 0 '(1) 5)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestHigherOrderPrimitivesOnEngines(t *testing.T) {
+	for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+		t.Run(engine.String(), func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			env.Runtime().SetEngine(engine)
+			got := evalForms(t, env,
+				"(define (square x) (* x x))",
+				"(fold-left + 0 (map square (filter (lambda (x) (> x 1)) '(1 2 3))))",
+			)
+			if got.String() != "13" {
+				t.Errorf("got %v, want 13", got)
+			}
+		})
+	}
+}
+
+func TestAssociationLists(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(assoc 'b '((a . 1) (b . 2)))", "(b . 2)"},
+		{"(assoc 'c '((a . 1) (b . 2)))", "false"},
+		{"(assoc \"k\" '((\"k\" . 1)))", `("k" . 1)`},
+		{"(assoc '(1) '(((1) . one)))", "((1) . one)"},
+		{"(assoc 'a '((a 1 2)))", "(a 1 2)"},
+		{"(assoc 'a '())", "false"},
+		{"(assoc 2.0 '((1 . one) (2 . two)) =)", "(2 . two)"},
+		{"(cdr (assoc 'a (list (cons 'a 1) (cons 'a 2))))", "1"},
+		{"(assoc [1 2] '(([1 2] . a)))", "([1 2] . a)"},
+		{"(assoc {:k 1} (list (cons {:k 1} 'a)))", "({:k 1} . a)"},
+		{"(assoc [1 2] '(([1 2] . a)) equal?)", "([1 2] . a)"},
+		{"(assq 'b '((a . 1) (b . 2)))", "(b . 2)"},
+		{"(assq \"k\" '((\"k\" . 1)))", "false"},
+		{"(let ((k \"k\")) (assq k (list (cons k 1))))", `("k" . 1)`},
+		{"(alist->map '((a . 1) (b . 2)))", "{a 1 b 2}"},
+		{"(alist->map '((a . 1) (a . 2)))", "{a 1}"},
+		{"(alist->map '((:k 1 2)))", "{:k (1 2)}"},
+		{"(alist->map '())", "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		"(assoc 'a)",
+		"(assoc 'a 5)",
+		"(assoc 'a '(1 2))",
+		"(assoc 'a '(()))",
+		"(assq 'a '((a . 1)) eq?)",
+		"(alist->map '(a))",
+		"(alist->map (list (cons (lambda (x) x) 1)))",
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(sort '(3 1 2))", "(1 2 3)"},
+		{"(sort '(3 1.5 2))", "(1.5 2 3)"},
+		{`(sort '("b" "c" "a"))`, `("a" "b" "c")`},
+		{`(sort '(#\b #\a))`, `(#\a #\b)`},
+		{"(sort '())", "()"},
+		{"(sort '(3 1 2) >)", "(3 2 1)"},
+		{"(let ((xs '(3 1 2))) (sort xs) xs)", "(3 1 2)"},
+		{"(sort '((1 . b) (0 . x) (1 . a) (0 . y)) (lambda (p q) (< (car p) (car q))))",
+			"((0 . x) (0 . y) (1 . b) (1 . a))"},
+		{"(sort-by length '((1 2 3) (1) (1 2)))", "((1) (1 2) (1 2 3))"},
+		{"(sort-by car '((2 a) (1 b) (2 c) (1 d)))", "((1 b) (1 d) (2 a) (2 c))"},
+		{"(sort-by car '((2 a) (1 b) (3 c)) >)", "((3 c) (2 a) (1 b))"},
+		{`(sort-by symbol->string '(b c a))`, "(a b c)"},
+		{"(let ((calls 0)) (sort-by (lambda (x) (begin (set! calls (+ calls 1)) x)) '(5 4 3 2 1)) calls)", "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		"(sort)",
+		"(sort 5)",
+		"(sort '(1 \"a\"))",
+		"(sort '(a b))",
+		"(sort '(1 2) car)",
+		"(sort '(1 2) (lambda (a b) (raise 'boom)))",
+		"(sort-by car)",
+		"(sort-by car '(1 2))",
+		"(sort-by length '(1) < 3)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestMember(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(member 2 '(1 2 3))", "(2 3)"},
+		{"(member 4 '(1 2 3))", "false"},
+		{"(member '(b) '(a (b) c))", "((b) c)"},
+		{"(member 2.0 '(1 2 3) =)", "(2 3)"},
+		{"(member 2 (cons 1 (cons 2 (cons 3 '()))))", "(2 3)"},
+		{"(let ((xs (cons 1 (cons 2 '())))) (eq? (member 1 xs) xs))", "true"},
+		{"(member 1 '())", "false"},
+		{"(memq 'c '(a b c d))", "(c d)"},
+		{"(memq \"b\" '(\"a\" \"b\"))", "false"},
+		{"(let ((s \"b\")) (memq s (list \"a\" s)))", `("b")`},
+		{"(if (member 'x '(x)) 'yes 'no)", "yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		"(member 1)",
+		"(member 1 5)",
+		"(member 3 (cons 1 2))",
+		"(memq 1 '(1) eq?)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(range 5)", "(0 1 2 3 4)"},
+		{"(range 0)", "()"},
+		{"(range -2)", "()"},
+		{"(range 2 5)", "(2 3 4)"},
+		{"(range 5 2)", "()"},
+		{"(range 0 10 3)", "(0 3 6 9)"},
+		{"(range 5 0 -2)", "(5 3 1)"},
+		{"(range 0 1 0.25)", "(0 0.25 0.5 0.75)"},
+		{"(range 9223372036854775806 9223372036854775809)", "(9223372036854775806 9223372036854775807 9223372036854775808)"},
+		{"(iota 3)", "(0 1 2)"},
+		{"(iota 0)", "()"},
+		{"(iota 3 1)", "(1 2 3)"},
+		{"(iota 4 0 -0.5)", "(0 -0.5 -1.0 -1.5)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		"(range)",
+		"(range 1 2 3 4)",
+		"(range 'a)",
+		"(range 0 10 0)",
+		"(iota -1)",
+		"(iota 2.5)",
+		"(iota 2 'a)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestRangeMemoryLimit(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetMaxMemory(1000)
+	if _, err := evalString(env, "(range 1000000000000)"); !errors.Is(err, ErrMemory) {
+		t.Errorf("expected ErrMemory, got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+package interpreter
+
+import (
+	"unicode/utf8"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadMapPrimitives adds the hash map primitives to an environment. Maps
+// are persistent: assoc, dissoc and merge return new maps and leave their
+// arguments unchanged. get, assoc and contains? also accept vectors,
+// indexed by position.
+func loadMapPrimitives(env *Env) {
+	env.Define("hash-map", makePrimitive("hash-map", primHashMap))
+	env.Define("map?", makePrimitive("map?", primIsMap))
+	env.Define("get", makePrimitive("get", primGet))
+	env.Define("dissoc", makePrimitive("dissoc", primDissoc))
+	env.Define("contains?", makePrimitive("contains?", primContains))
+	env.Define("keys", makePrimitive("keys", primKeys))
+	env.Define("vals", makePrimitive("vals", primVals))
+	env.Define("count", makePrimitive("count", primCount))
+	env.Define("merge", makePrimitive("merge", primMerge))
+	env.Define("get-in", makePrimitive("get-in", primGetIn))
+	env.Define("assoc-in", makePrimitive("assoc-in", primAssocIn))
+}
+
+func mapArg(name string, value sexpr.SExpr) (sexpr.Map, error) {
+	m, ok := value.(sexpr.Map)
+	if !ok {
+		return sexpr.Map{}, typeError(name, "map", value)
+	}
+	return m, nil
+}
+
+// seqArg returns the elements of a proper list or vector
+func seqArg(name string, value sexpr.SExpr) ([]sexpr.SExpr, error) {
+	if v, ok := value.(sexpr.Vector); ok {
+		return v.Elements(), nil
+	}
+	elems, ok := sexpr.Elements(value)
+	if !ok {
+		return nil, typeError(name, "list or vector", value)
+	}
+	return elems, nil
+}
+
+// primHashMap handles (hash-map key value...)
+func primHashMap(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if err := env.runtime.alloc(consSize * len(args) / 2); err != nil {
+		return nil, err
+	}
+	m, err := sexpr.NewMap(args...)
+	if err != nil {
+		return nil, evalError("hash-map", "%v", err)
+	}
+	return m, nil
+}
+
+func primIsMap(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("map?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(sexpr.Map)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// lookup returns the value of key in coll, a map or a vector. Nil, like
+// an empty map, has no keys.
+func lookup(name string, coll, key sexpr.SExpr) (sexpr.SExpr, bool, error) {
+	switch c := coll.(type) {
+	case sexpr.Map:
+		value, ok := c.Get(key)
+		return value, ok, nil
+	case sexpr.Vector:
+		i, ok := key.(sexpr.Number)
+		if !ok {
+			return nil, false, nil
+		}
+		value, ok := c.Nth(int(i.Value))
+		return value, ok && int64(int(i.Value)) == i.Value, nil
+	case sexpr.Nil:
+		return nil, false, nil
+	}
+	return nil, false, typeError(name, "map or vector", coll)
+}
+
+// primGet handles (get coll key [default]), the value of key in coll, or
+// default, nil if not given, when there is none
+func primGet(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("get", 2, 3, len(args))
+	}
+
+	value, ok, err := lookup("get", args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return value, nil
+	}
+	if len(args) == 3 {
+		return args[2], nil
+	}
+	return sexpr.Nil{}, nil
+}
+
+// assocColl returns coll, a map or vector, with key bound to value. A
+// vector index equal to its length appends.
+func assocColl(name string, coll, key, value sexpr.SExpr) (sexpr.SExpr, error) {
+	switch c := coll.(type) {
+	case sexpr.Map:
+		m, err := c.Assoc(key, value)
+		if err != nil {
+			return nil, evalError(name, "%v", err)
+		}
+		return m, nil
+	case sexpr.Vector:
+		i, err := indexArg(name, key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := c.Assoc(i, value)
+		if err != nil {
+			return nil, evalError(name, "%v", err)
+		}
+		return v, nil
+	}
+	return nil, typeError(name, "map or vector", coll)
+}
+
+// primAssocColl handles (assoc coll key value...), the map or vector coll
+// with each key bound to the value following it
+func primAssocColl(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 3 || len(args)%2 == 0 {
+		return nil, evalError("assoc", "requires a collection and pairs of keys and values, got %d arguments", len(args))
+	}
+	if err := env.runtime.alloc(consSize * (len(args) - 1) / 2); err != nil {
+		return nil, err
+	}
+
+	coll := args[0]
+	for i := 1; i < len(args); i += 2 {
+		var err error
+		if coll, err = assocColl("assoc", coll, args[i], args[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	return coll, nil
+}
+
+// primDissoc handles (dissoc map key...), map without the given keys
+func primDissoc(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 1 {
+		return nil, arityError("dissoc", 1, -1, len(args))
+	}
+
+	m, err := mapArg("dissoc", args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range args[1:] {
+		m = m.Dissoc(key)
+	}
+	return m, nil
+}
+
+// primContains handles (contains? coll key): whether a map has key, a set
+// has key as an element or a vector has an index key
+func primContains(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("contains?", 2, 2, len(args))
+	}
+
+	if s, ok := args[0].(sexpr.Set); ok {
+		return sexpr.Bool{Value: s.Contains(args[1])}, nil
+	}
+	_, ok, err := lookup("contains?", args[0], args[1])
+	if err != nil {
+		return nil, typeError("contains?", "map, set or vector", args[0])
+	}
+	return sexpr.Bool{Value: ok}, nil
+}
+
+// mapEntries returns a primitive listing part of each entry of a map, in
+// insertion order
+func mapEntries(name string, part func(sexpr.MapEntry) sexpr.SExpr) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 1 {
+			return nil, arityError(name, 1, 1, len(args))
+		}
+
+		m, err := mapArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		entries := m.Entries()
+		elems := make([]sexpr.SExpr, len(entries))
+		for i, entry := range entries {
+			elems[i] = part(entry)
+		}
+		return newList(elems, env)
+	}
+}
+
+var (
+	primKeys = mapEntries("keys", func(e sexpr.MapEntry) sexpr.SExpr { return e.Key })
+	primVals = mapEntries("vals", func(e sexpr.MapEntry) sexpr.SExpr { return e.Value })
+)
+
+// primCount handles (count coll), the number of entries or elements of a
+// map, set, vector, list, string or byte vector. Nil has none.
+func primCount(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("count", 1, 1, len(args))
+	}
+
+	var n int
+	switch c := args[0].(type) {
+	case sexpr.Map:
+		n = c.Len()
+	case sexpr.Set:
+		n = c.Len()
+	case sexpr.Vector:
+		n = c.Len()
+	case sexpr.String:
+		n = utf8.RuneCountInString(c.Value)
+	case sexpr.Bytes:
+		n = len(c.Value)
+	case sexpr.Nil:
+	default:
+		elems, ok := sexpr.Elements(c)
+		if !ok {
+			return nil, typeError("count", "collection", c)
+		}
+		n = len(elems)
+	}
+	return sexpr.Number{Value: int64(n)}, nil
+}
+
+// primMerge handles (merge map...), a map with the entries of every map
+// in turn, so later maps win. Nil arguments are skipped.
+func primMerge(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	result := sexpr.Map{}
+	for i, arg := range args {
+		if _, ok := arg.(sexpr.Nil); ok {
+			continue
+		}
+		m, err := mapArg("merge", arg)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result = m
+			continue
+		}
+		if err := env.runtime.alloc(consSize * m.Len()); err != nil {
+			return nil, err
+		}
+		for _, entry := range m.Entries() {
+			// Keys already in a map are hashable
+			result, _ = result.Assoc(entry.Key, entry.Value)
+		}
+	}
+	return result, nil
+}
+
+// primGetIn handles (get-in coll path [default]), following the keys of
+// path, a list or vector, through nested maps and vectors
+func primGetIn(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("get-in", 2, 3, len(args))
+	}
+
+	path, err := seqArg("get-in", args[1])
+	if err != nil {
+		return nil, err
+	}
+	value := args[0]
+	for _, key := range path {
+		next, ok, err := lookup("get-in", value, key)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if len(args) == 3 {
+				return args[2], nil
+			}
+			return sexpr.Nil{}, nil
+		}
+		value = next
+	}
+	return value, nil
+}
+
+// primAssocIn handles (assoc-in coll path value), coll with value bound
+// at the end of path through nested maps and vectors. Missing and nil
+// levels are created as maps.
+func primAssocIn(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 3 {
+		return nil, arityError("assoc-in", 3, 3, len(args))
+	}
+
+	path, err := seqArg("assoc-in", args[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, evalError("assoc-in", "path cannot be empty")
+	}
+	if err := env.runtime.alloc(consSize * len(path)); err != nil {
+		return nil, err
+	}
+	return assocIn(args[0], path, args[2])
+}
+
+func assocIn(coll sexpr.SExpr, path []sexpr.SExpr, value sexpr.SExpr) (sexpr.SExpr, error) {
+	if _, ok := coll.(sexpr.Nil); ok {
+		coll = sexpr.Map{}
+	}
+	if len(path) > 1 {
+		inner, ok, err := lookup("assoc-in", coll, path[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			inner = sexpr.Map{}
+		}
+		if value, err = assocIn(inner, path[1:], value); err != nil {
+			return nil, err
+		}
+	}
+	return assocColl("assoc-in", coll, path[0], value)
+}
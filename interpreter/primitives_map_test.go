@@ -0,0 +1,88 @@
+package interpreter
+
+import "testing"
+
+func TestMapPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(hash-map)", "{}"},
+		{"(hash-map :a 1 :b 2)", "{:a 1 :b 2}"},
+		{"(map? {:a 1})", "true"},
+		{"(map? '(1))", "false"},
+		{"(get {:a 1} :a)", "1"},
+		{"(get {:a 1} :b)", "nil"},
+		{"(get {:a 1} :b 0)", "0"},
+		{"(get (hash-map 0.0 1) -0.0)", "1"},
+		{"(get (when false 1) :a 0)", "0"},
+		{"(get [10 20] 1)", "20"},
+		{"(get [10 20] 2 'none)", "none"},
+		{"(assoc {:a 1} :b 2)", "{:a 1 :b 2}"},
+		{"(assoc {:a 1} :a 3 :c 4)", "{:a 3 :c 4}"},
+		{"(let ((m {:a 1})) (assoc m :a 2) m)", "{:a 1}"},
+		{"(assoc [1 2] 0 'x)", "[x 2]"},
+		{"(assoc [1 2] 2 3)", "[1 2 3]"},
+		{"(assoc 'a '((a . 1)))", "(a . 1)"},
+		{"(dissoc {:a 1 :b 2} :a)", "{:b 2}"},
+		{"(dissoc {:a 1} :z)", "{:a 1}"},
+		{"(contains? (hash-map :a (when false 1)) :a)", "true"},
+		{"(contains? {:a 1} :b)", "false"},
+		{"(contains? #{1 2} 2)", "true"},
+		{"(contains? [5 6] 1)", "true"},
+		{"(contains? [5 6] 2)", "false"},
+		{"(keys {:a 1 :b 2})", "(:a :b)"},
+		{"(vals {:a 1 :b 2})", "(1 2)"},
+		{"(keys {})", "()"},
+		{"(count {:a 1 :b 2})", "2"},
+		{"(count [1 2 3])", "3"},
+		{"(count #{1})", "1"},
+		{"(count '(1 2))", "2"},
+		{`(count "hé")`, "2"},
+		{"(count (when false 1))", "0"},
+		{"(merge {:a 1 :b 2} {:b 3} (when false 1) {:c 4})", "{:a 1 :b 3 :c 4}"},
+		{"(merge)", "{}"},
+		{"(get-in {:a {:b [1 {:c 42}]}} '(:a :b 1 :c))", "42"},
+		{"(get-in {:a {:b 1}} [:a :x])", "nil"},
+		{"(get-in {:a {:b 1}} [:a :x] 'none)", "none"},
+		{"(get-in {:a 1} [])", "{:a 1}"},
+		{"(assoc-in {:a {:b 1}} [:a :b] 2)", "{:a {:b 2}}"},
+		{"(assoc-in {} [:a :b :c] 1)", "{:a {:b {:c 1}}}"},
+		{"(assoc-in (when false 1) [:a] 1)", "{:a 1}"},
+		{"(assoc-in {:v [1 2]} [:v 1] 'x)", "{:v [1 x]}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestMapPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(hash-map :a)",
+		"(hash-map (lambda (x) x) 1)",
+		"(get '(1 2) 0)",
+		"(get {})",
+		"(assoc {:a 1} :b)",
+		"(assoc [1 2] 5 0)",
+		"(assoc [1 2] :a 0)",
+		"(dissoc '(1) 1)",
+		"(contains? 5 1)",
+		"(keys [1 2])",
+		"(vals)",
+		"(count 5)",
+		"(merge {:a 1} [1 2])",
+		"(get-in {:a 1} :a)",
+		"(get-in {:a 1} [:a :b])",
+		"(assoc-in {:a 1} [] 2)",
+		"(assoc-in {:a 1} [:a :b] 2)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadMathPrimitives adds the math library to an environment. Functions
+// without an exact answer, such as sin, return floats even for integer
+// arguments.
+func loadMathPrimitives(env *Env) {
+	env.Define("sqrt", makePrimitive("sqrt", primSqrt))
+	env.Define("expt", makePrimitive("expt", primExpt))
+	env.Define("exp", makePrimitive("exp", floatFunc("exp", math.Exp)))
+	env.Define("log", makePrimitive("log", primLog))
+	env.Define("sin", makePrimitive("sin", floatFunc("sin", math.Sin)))
+	env.Define("cos", makePrimitive("cos", floatFunc("cos", math.Cos)))
+	env.Define("tan", makePrimitive("tan", floatFunc("tan", math.Tan)))
+	env.Define("floor", makePrimitive("floor", roundingFunc("floor", math.Floor)))
+	env.Define("ceiling", makePrimitive("ceiling", roundingFunc("ceiling", math.Ceil)))
+	env.Define("round", makePrimitive("round", roundingFunc("round", math.RoundToEven)))
+	env.Define("truncate", makePrimitive("truncate", roundingFunc("truncate", math.Trunc)))
+	env.Define("random", makePrimitive("random", primRandom))
+	env.Define("random-float", makePrimitive("random-float", primRandomFloat))
+	env.Define("random-seed!", makePrimitive("random-seed!", primRandomSeed))
+}
+
+// numberArg checks that args is a single number for the primitive name
+func numberArg(name string, args []sexpr.SExpr) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError(name, 1, 1, len(args))
+	}
+	if !isNumber(args[0]) {
+		return nil, typeError(name, "number", args[0])
+	}
+	return args[0], nil
+}
+
+// floatFunc returns a primitive applying fn to its argument as a float
+func floatFunc(name string, fn func(float64) float64) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		x, err := numberArg(name, args)
+		if err != nil {
+			return nil, err
+		}
+		return sexpr.Float{Value: fn(toFloat(x))}, nil
+	}
+}
+
+// roundingFunc returns a primitive rounding a float to an integral float
+// with fn. Integers are already integral and are returned unchanged.
+func roundingFunc(name string, fn func(float64) float64) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		x, err := numberArg(name, args)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := x.(sexpr.Float); ok {
+			return sexpr.Float{Value: fn(f.Value)}, nil
+		}
+		return x, nil
+	}
+}
+
+// primSqrt handles (sqrt x). The square root of an exact square is
+// exact; any other is a float, NaN for a negative number.
+func primSqrt(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	x, err := numberArg("sqrt", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if isInteger(x) && compareNumbers(x, sexpr.Number{}) >= 0 {
+		n := toBig(x)
+		root := new(big.Int).Sqrt(n)
+		if new(big.Int).Mul(root, root).Cmp(n) == 0 {
+			return normalizeBig(root), nil
+		}
+	}
+	return sexpr.Float{Value: math.Sqrt(toFloat(x))}, nil
+}
+
+// primExpt handles (expt base power). An integer raised to a
+// non-negative integer power is exact; otherwise the result is a float.
+func primExpt(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("expt", 2, 2, len(args))
+	}
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, typeError("expt", "number", arg)
+		}
+	}
+
+	base, power := args[0], args[1]
+	if p, ok := power.(sexpr.Number); ok && isInteger(base) && p.Value >= 0 {
+		b := toBig(base)
+		if b.CmpAbs(big.NewInt(1)) > 0 {
+			// The result has about power times as many bits as base
+			bits := float64(b.BitLen()) * float64(p.Value)
+			if bits > math.MaxInt32 {
+				return nil, evalError("expt", "result too large")
+			}
+			if err := env.runtime.alloc(int(bits) / 8); err != nil {
+				return nil, err
+			}
+		}
+		return normalizeBig(new(big.Int).Exp(b, big.NewInt(p.Value), nil)), nil
+	}
+	return sexpr.Float{Value: math.Pow(toFloat(base), toFloat(power))}, nil
+}
+
+// primLog handles (log x), the natural logarithm of x, and (log x base)
+func primLog(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("log", 1, 2, len(args))
+	}
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, typeError("log", "number", arg)
+		}
+	}
+
+	result := math.Log(toFloat(args[0]))
+	if len(args) == 2 {
+		result /= math.Log(toFloat(args[1]))
+	}
+	return sexpr.Float{Value: result}, nil
+}
+
+// primRandom handles (random n), a random number in [0, n): an integer for
+// a positive integer n and a float for a positive float n
+func primRandom(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	n, err := numberArg("random", args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := n.(type) {
+	case sexpr.Number:
+		if n.Value > 0 {
+			return sexpr.Number{Value: env.runtime.randomInt(n.Value)}, nil
+		}
+	case sexpr.Float:
+		if n.Value > 0 && !math.IsInf(n.Value, 1) {
+			return sexpr.Float{Value: env.runtime.randomFloat() * n.Value}, nil
+		}
+	default:
+		return nil, typeError("random", "integer or float", n)
+	}
+	return nil, evalError("random", "limit must be positive, got %v", n)
+}
+
+// primRandomFloat handles (random-float), a random float in [0, 1)
+func primRandomFloat(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("random-float", 0, 0, len(args))
+	}
+	return sexpr.Float{Value: env.runtime.randomFloat()}, nil
+}
+
+// primRandomSeed handles (random-seed! seed), reseeding the runtime's
+// random number source with an integer
+func primRandomSeed(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("random-seed!", 1, 1, len(args))
+	}
+
+	seed, ok := args[0].(sexpr.Number)
+	if !ok {
+		return nil, typeError("random-seed!", "integer", args[0])
+	}
+	env.runtime.SeedRandom(uint64(seed.Value))
+	return sexpr.Nil{}, nil
+}
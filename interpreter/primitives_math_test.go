@@ -0,0 +1,125 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestMathPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(+ 1 1.5)", "2.5"},
+		{"(* 2 0.5)", "1.0"},
+		{"(/ 1.0 0)", "+inf.0"},
+		{"(- (/ 1.0 0))", "-inf.0"},
+		{"(= (/ 1.0 0) +inf.0)", "true"},
+		{"(< 1 1.5)", "true"},
+		{"(= 2 2.0)", "true"},
+		{"(min 1 2.0)", "1.0"},
+		{"(abs -2.5)", "2.5"},
+		{"(sqrt 16)", "4"},
+		{"(sqrt 2.25)", "1.5"},
+		{"(sqrt 2)", "1.4142135623730951"},
+		{"(sqrt 100000000000000000000000000000000)", "10000000000000000"},
+		{"(expt 2 10)", "1024"},
+		{"(expt 2 100)", "1267650600228229401496703205376"},
+		{"(expt 2 -1)", "0.5"},
+		{"(expt 2.0 3)", "8.0"},
+		{"(expt 4 0.5)", "2.0"},
+		{"(exp 0)", "1.0"},
+		{"(log 1)", "0.0"},
+		{"(log 8 2)", "3.0"},
+		{"(sin 0)", "0.0"},
+		{"(cos 0)", "1.0"},
+		{"(tan 0)", "0.0"},
+		{"(floor 2.5)", "2.0"},
+		{"(floor -2.5)", "-3.0"},
+		{"(ceiling 2.1)", "3.0"},
+		{"(round 2.5)", "2.0"},
+		{"(round 3.5)", "4.0"},
+		{"(truncate -2.7)", "-2.0"},
+		{"(floor 7)", "7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestMathPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		`(sqrt "4")`,
+		"(sqrt)",
+		"(expt 2)",
+		"(expt 2 1000000000000)",
+		"(log 1 2 3)",
+		"(sin 'x)",
+		"(floor 1 2)",
+		"(quotient 7.5 2)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestRandom(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	for range 100 {
+		n := evalForms(t, env, "(random 10)").(sexpr.Number).Value
+		if n < 0 || n >= 10 {
+			t.Fatalf("(random 10) = %d, want [0, 10)", n)
+		}
+		f := evalForms(t, env, "(random 2.5)").(sexpr.Float).Value
+		if f < 0 || f >= 2.5 {
+			t.Fatalf("(random 2.5) = %v, want [0, 2.5)", f)
+		}
+		f = evalForms(t, env, "(random-float)").(sexpr.Float).Value
+		if f < 0 || f >= 1 {
+			t.Fatalf("(random-float) = %v, want [0, 1)", f)
+		}
+	}
+
+	// The same seed gives the same numbers
+	sample := "(list (random 1000000) (random-float) (random 1.0))"
+	first := evalForms(t, env, "(random-seed! 42)", sample)
+	second := evalForms(t, env, "(random-seed! 42)", sample)
+	if !first.Equal(second) {
+		t.Errorf("got %v and %v after the same seed", first, second)
+	}
+
+	// Runtimes have separate sources
+	other := NewEnv(nil)
+	LoadPrimitives(other)
+	other.Runtime().SeedRandom(42)
+	if third := evalForms(t, other, sample); !first.Equal(third) {
+		t.Errorf("got %v, want %v from SeedRandom", third, first)
+	}
+}
+
+func TestRandomErrors(t *testing.T) {
+	tests := []string{
+		"(random)",
+		"(random 0)",
+		"(random -1.5)",
+		"(random 'a)",
+		"(random 100000000000000000000)",
+		"(random-float 1)",
+		"(random-seed! 1.5)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
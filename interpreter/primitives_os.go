@@ -0,0 +1,100 @@
+package interpreter
+
+import (
+	"os"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadOSPrimitives adds the operating system primitives to an
+// environment. The environment variable primitives and exit need the
+// process capability and current-directory the filesystem capability.
+func loadOSPrimitives(env *Env) {
+	env.Define("getenv", restricted(CapProcess, "getenv", primGetenv))
+	env.Define("setenv", restricted(CapProcess, "setenv", primSetenv))
+	env.Define("exit", restricted(CapProcess, "exit", primExit))
+	env.Define("command-line-args", makePrimitive("command-line-args", primCommandLineArgs))
+	env.Define("current-directory", restricted(CapFilesystem, "current-directory", primCurrentDirectory))
+}
+
+// primGetenv handles (getenv name), the value of an environment variable,
+// or nil if it is not set
+func primGetenv(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("getenv", 1, 1, len(args))
+	}
+
+	name, err := stringArg("getenv", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return sexpr.Nil{}, nil
+	}
+	return sexpr.String{Value: value}, nil
+}
+
+// primSetenv handles (setenv name value), setting an environment variable
+// for this process and the commands it runs
+func primSetenv(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("setenv", 2, 2, len(args))
+	}
+
+	kv, err := stringsArgs("setenv", args)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Setenv(kv[0], kv[1]); err != nil {
+		return nil, evalError("setenv", "%v", err)
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primExit handles (exit [code]), ending evaluation with an ExitError for
+// the host to act on. The code defaults to 0.
+func primExit(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 1 {
+		return nil, arityError("exit", 0, 1, len(args))
+	}
+
+	code := 0
+	if len(args) == 1 {
+		n, ok := args[0].(sexpr.Number)
+		if !ok || n.Value < 0 || n.Value > 255 {
+			return nil, typeError("exit", "integer from 0 to 255", args[0])
+		}
+		code = int(n.Value)
+	}
+	return nil, &ExitError{Code: code}
+}
+
+// primCommandLineArgs handles (command-line-args), the list of strings
+// the host passed to Runtime.SetArgs
+func primCommandLineArgs(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("command-line-args", 0, 0, len(args))
+	}
+
+	hostArgs := env.runtime.Args()
+	elems := make([]sexpr.SExpr, len(hostArgs))
+	for i, arg := range hostArgs {
+		elems[i] = sexpr.String{Value: arg}
+	}
+	return newList(elems, env)
+}
+
+// primCurrentDirectory handles (current-directory), the working directory
+// of the process
+func primCurrentDirectory(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("current-directory", 0, 0, len(args))
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fileError("current-directory", ".", err)
+	}
+	return sexpr.String{Value: dir}, nil
+}
@@ -0,0 +1,117 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestEnvironmentVariables(t *testing.T) {
+	t.Setenv("ZYLISP_TEST_VAR", "one")
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(getenv "ZYLISP_TEST_VAR")`, `"one"`},
+		{`(getenv "ZYLISP_TEST_UNSET")`, "nil"},
+		{`(setenv "ZYLISP_TEST_VAR" "two")`, "nil"},
+		{`(getenv "ZYLISP_TEST_VAR")`, `"two"`},
+	}
+	for _, tt := range tests {
+		if got := evalForms(t, env, tt.input); got.String() != tt.expected {
+			t.Errorf("%s = %v, want %s", tt.input, got, tt.expected)
+		}
+	}
+	if got := os.Getenv("ZYLISP_TEST_VAR"); got != "two" {
+		t.Errorf("process environment has %q, want %q", got, "two")
+	}
+
+	for _, input := range []string{"(getenv 1)", "(getenv)", `(setenv "A")`, `(setenv "A" 1)`} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestCommandLineArgs(t *testing.T) {
+	testEvalString(t, "(command-line-args)", "()")
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	args := []string{"-v", "file.txt"}
+	env.Runtime().SetArgs(args)
+	args[0] = "changed"
+	if got := evalForms(t, env, "(command-line-args)"); got.String() != `("-v" "file.txt")` {
+		t.Errorf("got %v, want (\"-v\" \"file.txt\")", got)
+	}
+}
+
+func TestCurrentDirectory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEvalString(t, "(current-directory)", `"`+wd+`"`)
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input string
+		code  int
+	}{
+		{"(exit)", 0},
+		{"(exit 3)", 3},
+		// try cannot catch an exit
+		{"(try (exit 4) (catch e 0))", 4},
+		{"(begin (exit 5) (exit 6))", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			_, err := evalString(env, tt.input)
+			var exit *ExitError
+			if !errors.As(err, &exit) {
+				t.Fatalf("got %v, want an ExitError", err)
+			}
+			if exit.Code != tt.code {
+				t.Errorf("got code %d, want %d", exit.Code, tt.code)
+			}
+		})
+	}
+
+	for _, input := range []string{"(exit 256)", "(exit -1)", "(exit 'a)", "(exit 1 2)"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestOSSandbox(t *testing.T) {
+	env := NewSandbox(Policy{})
+	for _, tt := range []struct {
+		input string
+		cap   Capability
+	}{
+		{`(getenv "HOME")`, CapProcess},
+		{`(setenv "A" "b")`, CapProcess},
+		{"(exit)", CapProcess},
+		{"(current-directory)", CapFilesystem},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := evalString(env, tt.input)
+			var pe *PolicyError
+			if !errors.As(err, &pe) || pe.Capability != tt.cap {
+				t.Fatalf("got %v, want a %v policy error", err, tt.cap)
+			}
+		})
+	}
+
+	if got := evalForms(t, env, "(command-line-args)"); got.String() != "()" {
+		t.Errorf("got %v, want ()", got)
+	}
+}
@@ -0,0 +1,486 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadPortPrimitives adds the port primitives to an environment
+func loadPortPrimitives(env *Env) {
+	env.Define("current-input-port", makePrimitive("current-input-port", primCurrentInputPort))
+	env.Define("current-output-port", makePrimitive("current-output-port", primCurrentOutputPort))
+	env.Define("current-error-port", makePrimitive("current-error-port", primCurrentErrorPort))
+	env.Define("port?", makePrimitive("port?", primIsPort))
+	env.Define("input-port?", makePrimitive("input-port?", primIsInputPort))
+	env.Define("output-port?", makePrimitive("output-port?", primIsOutputPort))
+	env.Define("open-input-string", makePrimitive("open-input-string", primOpenInputString))
+	env.Define("open-output-string", makePrimitive("open-output-string", primOpenOutputString))
+	env.Define("get-output-string", makePrimitive("get-output-string", primGetOutputString))
+	env.Define("print", makePrimitive("print", primPrint))
+	env.Define("println", makePrimitive("println", primPrintln))
+	env.Define("display", makePrimitive("display", primDisplay))
+	env.Define("newline", makePrimitive("newline", primNewline))
+	env.Define("read-line", makePrimitive("read-line", primReadLine))
+	env.Define("read-char", makePrimitive("read-char", primReadChar))
+	env.Define("write-string", makePrimitive("write-string", primWriteString))
+	env.Define("close-port", makePrimitive("close-port", primClosePort))
+	env.Define("format", makePrimitive("format", primFormat))
+	env.Define("read", makePrimitive("read", primRead))
+	env.Define("read-port", makePrimitive("read-port", primReadPort))
+}
+
+// ioError returns an io-error condition for err, a failure of name to read
+// or write a stream
+func ioError(name string, err error) error {
+	return &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "io-error"},
+		Message: name + ": " + err.Error(),
+		Data:    sexpr.Nil{},
+	}}
+}
+
+var errPortClosed = errors.New("port is closed")
+
+// outputPortArg returns args[i], which must be an open output port, or the
+// current output port if there is no such argument
+func outputPortArg(name string, args []sexpr.SExpr, i int, env *Env) (*sexpr.Port, error) {
+	port := env.runtime.Output()
+	if len(args) > i {
+		p, ok := args[i].(*sexpr.Port)
+		if !ok || !p.IsOutput() {
+			return nil, typeError(name, "output port", args[i])
+		}
+		port = p
+	}
+	if port.Closed() {
+		return nil, ioError(name, errPortClosed)
+	}
+	return port, nil
+}
+
+// inputPortArg returns args[i], which must be an open input port, or the
+// current input port if there is no such argument
+func inputPortArg(name string, args []sexpr.SExpr, i int, env *Env) (*sexpr.Port, error) {
+	port := env.runtime.Input()
+	if len(args) > i {
+		p, ok := args[i].(*sexpr.Port)
+		if !ok || !p.IsInput() {
+			return nil, typeError(name, "input port", args[i])
+		}
+		port = p
+	}
+	if port.Closed() {
+		return nil, ioError(name, errPortClosed)
+	}
+	return port, nil
+}
+
+// writePort writes s to port
+func writePort(name string, port *sexpr.Port, s string) error {
+	if _, err := io.WriteString(port.Writer(), s); err != nil {
+		return ioError(name, err)
+	}
+	return nil
+}
+
+// printValues writes the display form of each value to the current output
+// port, separated by spaces and followed by end
+func printValues(name string, args []sexpr.SExpr, end string, env *Env) (sexpr.SExpr, error) {
+	port, err := outputPortArg(name, nil, 0, env)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = sexpr.Display(arg)
+	}
+	if err := writePort(name, port, strings.Join(parts, " ")+end); err != nil {
+		return nil, err
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primPrint handles (print value...)
+func primPrint(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return printValues("print", args, "", env)
+}
+
+// primPrintln handles (println value...), print followed by a newline
+func primPrintln(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	return printValues("println", args, "\n", env)
+}
+
+// primDisplay handles (display value [port])
+func primDisplay(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("display", 1, 2, len(args))
+	}
+
+	port, err := outputPortArg("display", args, 1, env)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePort("display", port, sexpr.Display(args[0])); err != nil {
+		return nil, err
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primNewline handles (newline [port])
+func primNewline(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 1 {
+		return nil, arityError("newline", 0, 1, len(args))
+	}
+
+	port, err := outputPortArg("newline", args, 0, env)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePort("newline", port, "\n"); err != nil {
+		return nil, err
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primReadLine handles (read-line [port]), the next line without its line
+// ending, or nil at the end of the input
+func primReadLine(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 1 {
+		return nil, arityError("read-line", 0, 1, len(args))
+	}
+
+	port, err := inputPortArg("read-line", args, 0, env)
+	if err != nil {
+		return nil, err
+	}
+	line, err := port.Reader().ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, ioError("read-line", err)
+	}
+	if err != nil && line == "" {
+		return sexpr.Nil{}, nil
+	}
+	if err := env.runtime.alloc(len(line)); err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return sexpr.String{Value: strings.TrimSuffix(line, "\r")}, nil
+}
+
+func primCurrentInputPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("current-input-port", 0, 0, len(args))
+	}
+	return env.Runtime().Input(), nil
+}
+
+func primCurrentOutputPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("current-output-port", 0, 0, len(args))
+	}
+	return env.Runtime().Output(), nil
+}
+
+func primCurrentErrorPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("current-error-port", 0, 0, len(args))
+	}
+	return env.Runtime().ErrorOutput(), nil
+}
+
+func primIsPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("port?", 1, 1, len(args))
+	}
+
+	_, ok := args[0].(*sexpr.Port)
+	return sexpr.Bool{Value: ok}, nil
+}
+
+func primIsInputPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("input-port?", 1, 1, len(args))
+	}
+
+	port, ok := args[0].(*sexpr.Port)
+	return sexpr.Bool{Value: ok && port.IsInput()}, nil
+}
+
+func primIsOutputPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("output-port?", 1, 1, len(args))
+	}
+
+	port, ok := args[0].(*sexpr.Port)
+	return sexpr.Bool{Value: ok && port.IsOutput()}, nil
+}
+
+func primOpenInputString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("open-input-string", 1, 1, len(args))
+	}
+
+	s, ok := args[0].(sexpr.String)
+	if !ok {
+		return nil, typeError("open-input-string", "string", args[0])
+	}
+
+	return sexpr.NewInputPort("string", strings.NewReader(s.Value)), nil
+}
+
+func primOpenOutputString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("open-output-string", 0, 0, len(args))
+	}
+
+	return sexpr.NewOutputPort("string", &bytes.Buffer{}), nil
+}
+
+func primGetOutputString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("get-output-string", 1, 1, len(args))
+	}
+
+	port, ok := args[0].(*sexpr.Port)
+	if !ok {
+		return nil, typeError("get-output-string", "port", args[0])
+	}
+
+	buf, ok := port.Writer().(*bytes.Buffer)
+	if !ok {
+		return nil, evalError("get-output-string", "not a string output port: %v", port)
+	}
+	if err := env.runtime.alloc(buf.Len()); err != nil {
+		return nil, err
+	}
+
+	return sexpr.String{Value: buf.String()}, nil
+}
+
+// primReadChar handles (read-char [port]), the next character, or nil at
+// the end of the input
+func primReadChar(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 1 {
+		return nil, arityError("read-char", 0, 1, len(args))
+	}
+
+	port, err := inputPortArg("read-char", args, 0, env)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := port.Reader().ReadRune()
+	if errors.Is(err, io.EOF) {
+		return sexpr.Nil{}, nil
+	}
+	if err != nil {
+		return nil, ioError("read-char", err)
+	}
+	return sexpr.Char{Value: r}, nil
+}
+
+// primWriteString handles (write-string string [port])
+func primWriteString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("write-string", 1, 2, len(args))
+	}
+
+	s, err := stringArg("write-string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	port, err := outputPortArg("write-string", args, 1, env)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePort("write-string", port, s); err != nil {
+		return nil, err
+	}
+	return sexpr.Nil{}, nil
+}
+
+// primClosePort handles (close-port port). Closing a port twice is not an
+// error.
+func primClosePort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("close-port", 1, 1, len(args))
+	}
+
+	port, ok := args[0].(*sexpr.Port)
+	if !ok {
+		return nil, typeError("close-port", "port", args[0])
+	}
+	if err := port.Close(); err != nil {
+		return nil, ioError("close-port", err)
+	}
+	return sexpr.Nil{}, nil
+}
+
+// formatString expands the directives of format with args: ~a writes the
+// next argument's display form, ~s its written form and ~d the next
+// argument, which must be a number; ~% writes a newline and ~~ a tilde
+func formatString(format string, args []sexpr.SExpr) (string, error) {
+	var b strings.Builder
+	next := func(directive rune) (sexpr.SExpr, error) {
+		if len(args) == 0 {
+			return nil, evalError("format", "not enough arguments for ~%c", directive)
+		}
+		arg := args[0]
+		args = args[1:]
+		return arg, nil
+	}
+
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '~' {
+			b.WriteRune(runes[i])
+			continue
+		}
+		i++
+		if i == len(runes) {
+			return "", evalError("format", "format string ends with ~")
+		}
+		switch directive := unicode.ToLower(runes[i]); directive {
+		case 'a', 's', 'd':
+			arg, err := next(directive)
+			if err != nil {
+				return "", err
+			}
+			if directive == 'd' && !isNumber(arg) {
+				return "", typeError("format", "number", arg)
+			}
+			if directive == 's' {
+				b.WriteString(sexpr.Write(arg))
+			} else {
+				b.WriteString(sexpr.Display(arg))
+			}
+		case '%':
+			b.WriteByte('\n')
+		case '~':
+			b.WriteByte('~')
+		default:
+			return "", evalError("format", "unknown directive ~%c", runes[i])
+		}
+	}
+	if len(args) > 0 {
+		return "", evalError("format", "%d unused arguments", len(args))
+	}
+	return b.String(), nil
+}
+
+// primFormat handles (format dest format arg...). The formatted string is
+// returned when dest is false or nil, and otherwise written to dest, an
+// output port, or to the current output port when dest is true.
+func primFormat(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) < 2 {
+		return nil, arityError("format", 2, -1, len(args))
+	}
+
+	format, err := stringArg("format", args[1])
+	if err != nil {
+		return nil, err
+	}
+	s, err := formatString(format, args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if err := env.runtime.alloc(len(s)); err != nil {
+		return nil, err
+	}
+
+	var port *sexpr.Port
+	switch dest := args[0].(type) {
+	case sexpr.Nil:
+		return sexpr.String{Value: s}, nil
+	case sexpr.Bool:
+		if !dest.Value {
+			return sexpr.String{Value: s}, nil
+		}
+		port, err = outputPortArg("format", nil, 0, env)
+	default:
+		port, err = outputPortArg("format", args, 0, env)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := writePort("format", port, s); err != nil {
+		return nil, err
+	}
+	return sexpr.Nil{}, nil
+}
+
+// readError returns a read-error condition for err, a syntax error found
+// by name
+func readError(name string, err error) error {
+	return &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "read-error"},
+		Message: name + ": " + err.Error(),
+		Data:    sexpr.Nil{},
+	}}
+}
+
+// readDatum reads the next expression from port as data, without
+// evaluating it, or returns nil at the end of the input. Lines are read
+// until they hold a complete expression; the text after it is pushed
+// back for the next read.
+func readDatum(name string, port *sexpr.Port, env *Env) (sexpr.SExpr, error) {
+	var text strings.Builder
+	for {
+		line, err := port.Reader().ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, ioError(name, err)
+		}
+		if err := env.runtime.alloc(len(line)); err != nil {
+			return nil, err
+		}
+		text.WriteString(line)
+		atEnd := err != nil
+
+		expr, n, perr := parser.ReadPrefix(text.String())
+		switch {
+		case perr == nil:
+			port.Unread(text.String()[n:])
+			return expr, nil
+		case errors.Is(perr, io.EOF):
+			if atEnd {
+				return sexpr.Nil{}, nil
+			}
+			text.Reset()
+		case !errors.Is(perr, parser.ErrIncomplete) || atEnd:
+			return nil, readError(name, perr)
+		}
+	}
+}
+
+// primRead handles (read string), the first expression in string as
+// data, or nil if there is none
+func primRead(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("read", 1, 1, len(args))
+	}
+
+	s, err := stringArg("read", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return readDatum("read", sexpr.NewInputPort("string", strings.NewReader(s)), env)
+}
+
+// primReadPort handles (read-port [port]), the next expression read from
+// port as data, or nil at the end of the input
+func primReadPort(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) > 1 {
+		return nil, arityError("read-port", 0, 1, len(args))
+	}
+
+	port, err := inputPortArg("read-port", args, 0, env)
+	if err != nil {
+		return nil, err
+	}
+	return readDatum("read-port", port, env)
+}
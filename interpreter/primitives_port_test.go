@@ -0,0 +1,265 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestPortPredicates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(port? (current-output-port))", "true"},
+		{"(output-port? (current-output-port))", "true"},
+		{"(input-port? (current-output-port))", "false"},
+		{"(input-port? (current-input-port))", "true"},
+		{`(input-port? (open-input-string "abc"))`, "true"},
+		{"(port? 42)", "false"},
+		{"(get-output-string (open-output-string))", `""`},
+		{"(current-output-port)", "#<output-port stdout>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestCurrentPortsAreConfigurable(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	var buf bytes.Buffer
+	port := sexpr.NewOutputPort("buffer", &buf)
+	env.Runtime().SetOutput(port)
+
+	// Child environments share the runtime of their parent
+	child := env.Extend()
+
+	tokens, _ := parser.Tokenize("(current-output-port)")
+	expr, _ := parser.Read(tokens)
+	result, err := Eval(expr, child)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+
+	if !result.Equal(port) {
+		t.Errorf("got %v, want %v", result, port)
+	}
+}
+
+func TestGetOutputStringErrors(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	tokens, _ := parser.Tokenize("(get-output-string (current-input-port))")
+	expr, _ := parser.Read(tokens)
+	if _, err := Eval(expr, env); err == nil {
+		t.Error("expected error for non-string port")
+	}
+}
+
+func TestConsoleOutput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(print "a" 1 'b)`, "a 1 b"},
+		{`(println "x" "y")`, "x y\n"},
+		{"(println)", "\n"},
+		{"(print (list \"s\" #\\c))", "(s c)"},
+		{`(display "hi")`, "hi"},
+		{"(display 'sym)", "sym"},
+		{"(newline)", "\n"},
+		{`(begin (display 1) (newline) (display 2))`, "1\n2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			var buf bytes.Buffer
+			env.Runtime().SetOutput(sexpr.NewOutputPort("buffer", &buf))
+
+			result, err := evalString(env, tt.input)
+			if err != nil {
+				t.Fatalf("eval error: %v", err)
+			}
+			if _, ok := result.(sexpr.Nil); !ok {
+				t.Errorf("got result %v, want nil", result)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("got output %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestConsoleOutputToPort(t *testing.T) {
+	testEvalString(t, `(let ((p (open-output-string)))
+		(begin (display "a" p) (newline p) (get-output-string p)))`, `"a\n"`)
+}
+
+func TestReadLine(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetInput(sexpr.NewInputPort("input", strings.NewReader("one\r\ntwo\n\nthree")))
+
+	for _, want := range []string{`"one"`, `"two"`, `""`, `"three"`, "nil"} {
+		result, err := evalString(env, "(read-line)")
+		if err != nil {
+			t.Fatalf("eval error: %v", err)
+		}
+		if result.String() != want {
+			t.Errorf("got %v, want %s", result, want)
+		}
+	}
+
+	testEvalString(t, `(read-line (open-input-string "x\ny"))`, `"x"`)
+}
+
+func TestConsoleIOErrors(t *testing.T) {
+	tests := []string{
+		"(display)",
+		"(display 1 (current-input-port))",
+		"(newline 1)",
+		"(read-line (current-output-port))",
+		"(read-line 1 2)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	port := sexpr.NewOutputPort("closed", &bytes.Buffer{})
+	port.Close()
+	env.Runtime().SetOutput(port)
+	_, err := evalString(env, `(print "x")`)
+	var raised *RaiseError
+	if !errors.As(err, &raised) {
+		t.Fatalf("got %v, want an io-error", err)
+	}
+	if kind := raised.Value.(sexpr.Error).Kind.Name; kind != "io-error" {
+		t.Errorf("got kind %s, want io-error", kind)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(format false "plain")`, `"plain"`},
+		{`(format false "~a and ~s" "x" "x")`, `"x and \"x\""`},
+		{`(format false "~d items~%" 3)`, `"3 items\n"`},
+		{`(format false "~A ~S" 'sym #\c)`, `"sym #\\c"`},
+		{`(format false "100~~")`, `"100~"`},
+		{`(format false "~a" '(1 "two"))`, `"(1 two)"`},
+		{`(format (when false 1) "~d" 1.5)`, `"1.5"`},
+		{`(let ((p (open-output-string))) (begin (format p "~a-~a" 1 2) (get-output-string p)))`, `"1-2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	var buf bytes.Buffer
+	env.Runtime().SetOutput(sexpr.NewOutputPort("buffer", &buf))
+	if result := evalForms(t, env, `(format true "~a~%" "out")`); result.String() != "nil" {
+		t.Errorf("got %v, want nil", result)
+	}
+	if buf.String() != "out\n" {
+		t.Errorf("got output %q, want %q", buf.String(), "out\n")
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []string{
+		`(format false)`,
+		`(format false 1)`,
+		`(format false "~a")`,
+		`(format false "~a" 1 2)`,
+		`(format false "~d" "x")`,
+		`(format false "~q" 1)`,
+		`(format false "~")`,
+		`(format 1 "x")`,
+		`(format (current-input-port) "x")`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(read "(+ 1 2)")`, "(+ 1 2)"},
+		{`(eval (read "(+ 1 2)"))`, "3"},
+		{`(read "  sym more")`, "sym"},
+		{`(read "[1 {:a \"b\"}]")`, `[1 {:a "b"}]`},
+		{`(read "; nothing")`, "nil"},
+		{`(read "")`, "nil"},
+		{`(let ((p (open-input-string "1 (a\n b) ; c\n\"s\"")))
+			(list (read-port p) (read-port p) (read-port p) (read-port p)))`, `(1 (a b) "s" nil)`},
+		{`(let ((p (open-input-string "x\nrest of line")))
+			(list (read-port p) (read-line p) (read-line p)))`, `(x "" "rest of line")`},
+		{`(let ((p (open-input-string "(a) tail")))
+			(begin (read-port p) (read-line p)))`, `" tail"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetInput(sexpr.NewInputPort("input", strings.NewReader("(define x\n  42)\nx")))
+	if got := evalForms(t, env, "(eval (read-port))", "(read-port)"); got.String() != "x" {
+		t.Errorf("got %v, want x", got)
+	}
+	if got := evalForms(t, env, "x"); got.String() != "42" {
+		t.Errorf("got %v, want 42", got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, input := range []string{`(read "(a")`, `(read ")")`, `(read "\"abc")`} {
+		t.Run(input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			_, err := evalString(env, input)
+			var raised *RaiseError
+			if !errors.As(err, &raised) || raised.Value.(sexpr.Error).Kind.Name != "read-error" {
+				t.Fatalf("got %v, want a read-error", err)
+			}
+		})
+	}
+
+	for _, input := range []string{"(read)", "(read 1)", "(read-port 1)", "(read-port (current-output-port))"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
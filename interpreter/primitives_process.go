@@ -0,0 +1,110 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadProcessPrimitives adds the subprocess primitives to an environment.
+// They need the process capability. Commands run directly, not through a
+// shell; failing to start one raises a process-error whose data is the
+// command name.
+func loadProcessPrimitives(env *Env) {
+	env.Define("run-command", restricted(CapProcess, "run-command", primRunCommand))
+	env.Define("open-input-command", restricted(CapProcess, "open-input-command", primOpenInputCommand))
+}
+
+// processError returns a process-error condition for err, a failure of
+// name to run command
+func processError(name, command string, err error) error {
+	return &RaiseError{Value: sexpr.Error{
+		Kind:    sexpr.Symbol{Name: "process-error"},
+		Message: name + ": " + err.Error(),
+		Data:    sexpr.String{Value: command},
+	}}
+}
+
+// commandArgs returns the command name and arguments of a call, all of
+// which must be strings
+func commandArgs(name string, args []sexpr.SExpr) ([]string, error) {
+	if len(args) < 1 {
+		return nil, arityError(name, 1, -1, len(args))
+	}
+	return stringsArgs(name, args)
+}
+
+// primRunCommand handles (run-command command arg...), running command to
+// completion and returning a map of its exit code, :exit, and its
+// standard output and error as strings, :out and :err. The command is
+// killed if the evaluation is cancelled.
+func primRunCommand(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	argv, err := commandArgs("run-command", args)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(env.Context(), argv[0], argv[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err := env.interrupted(); err != nil {
+		return nil, err
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, processError("run-command", argv[0], err)
+	}
+	if err := env.runtime.alloc(stdout.Len() + stderr.Len()); err != nil {
+		return nil, err
+	}
+
+	// Keywords are always hashable
+	result, _ := sexpr.NewMap(
+		sexpr.Keyword{Name: "exit"}, sexpr.Number{Value: int64(cmd.ProcessState.ExitCode())},
+		sexpr.Keyword{Name: "out"}, sexpr.String{Value: stdout.String()},
+		sexpr.Keyword{Name: "err"}, sexpr.String{Value: stderr.String()},
+	)
+	return result, nil
+}
+
+// commandReader is the standard output of a running command. Closing it
+// waits for the command to finish.
+type commandReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (r *commandReader) Close() error {
+	r.ReadCloser.Close()
+	var exitErr *exec.ExitError
+	if err := r.cmd.Wait(); err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+	return nil
+}
+
+// primOpenInputCommand handles (open-input-command command arg...),
+// starting command and returning an input port that streams its standard
+// output as it is written. Its standard error is discarded. close-port
+// waits for the command to exit.
+func primOpenInputCommand(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	argv, err := commandArgs("open-input-command", args)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, processError("open-input-command", argv[0], err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, processError("open-input-command", argv[0], err)
+	}
+	return sexpr.NewInputPort(argv[0], &commandReader{ReadCloser: stdout, cmd: cmd}), nil
+}
@@ -0,0 +1,105 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func requireShell(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh on PATH")
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	requireShell(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(get (run-command "sh" "-c" "echo hi") :out)`, `"hi\n"`},
+		{`(get (run-command "sh" "-c" "echo oops >&2") :err)`, `"oops\n"`},
+		{`(get (run-command "sh" "-c" "exit 3") :exit)`, "3"},
+		{`(get (run-command "sh" "-c" "true") :exit)`, "0"},
+		{`(run-command "sh" "-c" "printf 'a b'")`, `{:exit 0 :out "a b" :err ""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestOpenInputCommand(t *testing.T) {
+	requireShell(t)
+
+	testEvalString(t, `(let ((p (open-input-command "sh" "-c" "echo one; echo two")))
+		(let ((lines (list (read-line p) (read-line p) (read-line p))))
+			(begin (close-port p) lines)))`, `("one" "two" nil)`)
+}
+
+func TestRunCommandErrors(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	for _, input := range []string{
+		`(run-command "zylisp-no-such-command")`,
+		`(open-input-command "zylisp-no-such-command")`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := evalString(env, input)
+			var raised *RaiseError
+			if !errors.As(err, &raised) {
+				t.Fatalf("got %v, want a process-error", err)
+			}
+			cond := raised.Value.(sexpr.Error)
+			if cond.Kind.Name != "process-error" || cond.Data.String() != `"zylisp-no-such-command"` {
+				t.Errorf("got %v with data %v", cond.Kind, cond.Data)
+			}
+		})
+	}
+
+	for _, input := range []string{"(run-command)", "(run-command 'ls)", `(open-input-command "ls" 1)`} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+
+	sandbox := NewSandbox(Policy{Allow: CapFilesystem})
+	_, err := evalString(sandbox, `(run-command "ls")`)
+	var pe *PolicyError
+	if !errors.As(err, &pe) || pe.Capability != CapProcess {
+		t.Errorf("got %v, want a process policy error", err)
+	}
+}
+
+func TestRunCommandCancelled(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep on PATH")
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	tokens, _ := parser.Tokenize(`(run-command "sleep" "10")`)
+	expr, _ := parser.Read(tokens)
+	start := time.Now()
+	_, err := EvalContext(ctx, expr, env)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command ran for %v after cancellation", elapsed)
+	}
+}
@@ -0,0 +1,404 @@
+package interpreter
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadStringPrimitives adds the string primitives to an environment.
+// Strings are indexed and measured in characters, not bytes.
+func loadStringPrimitives(env *Env) {
+	env.Define("str", makePrimitive("str", primStr))
+	env.Define("string-length", makePrimitive("string-length", primStringLength))
+	env.Define("string-append", makePrimitive("string-append", primStringAppend))
+	env.Define("substring", makePrimitive("substring", primSubstring))
+	env.Define("string-split", makePrimitive("string-split", primStringSplit))
+	env.Define("string-join", makePrimitive("string-join", primStringJoin))
+	env.Define("string-trim", makePrimitive("string-trim", primStringTrim))
+	env.Define("string-contains?", makePrimitive("string-contains?", primStringContains))
+	env.Define("string-index", makePrimitive("string-index", primStringIndex))
+	env.Define("string-upcase", makePrimitive("string-upcase", stringMapper("string-upcase", strings.ToUpper)))
+	env.Define("string-downcase", makePrimitive("string-downcase", stringMapper("string-downcase", strings.ToLower)))
+	env.Define("string->number", makePrimitive("string->number", primStringToNumber))
+	env.Define("number->string", makePrimitive("number->string", primNumberToString))
+	env.Define("string->symbol", makePrimitive("string->symbol", primStringToSymbol))
+	env.Define("symbol->string", makePrimitive("symbol->string", primSymbolToString))
+	env.Define("symbol->keyword", makePrimitive("symbol->keyword", primSymbolToKeyword))
+	env.Define("keyword->symbol", makePrimitive("keyword->symbol", primKeywordToSymbol))
+}
+
+// primStr handles (str value...), concatenating the displayed forms of
+// its arguments
+func primStr(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	var b strings.Builder
+	for i, arg := range args {
+		if err := env.poll(i); err != nil {
+			return nil, err
+		}
+		b.WriteString(sexpr.Display(arg))
+	}
+	if err := env.runtime.alloc(b.Len()); err != nil {
+		return nil, err
+	}
+	return sexpr.String{Value: b.String()}, nil
+}
+
+// strPrimitive is str for the compiled forms of string-interpolate, which
+// a binding of the name str must not change
+var strPrimitive = makePrimitive("str", primStr)
+
+// evalStringInterpolate handles (string-interpolate value...), the form
+// interpolated strings read as, joining the values like str
+func evalStringInterpolate(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	args, err := evalEach(list.Elements[1:], env)
+	if err != nil {
+		return nil, err
+	}
+	return primStr(args, env)
+}
+
+func stringArg(name string, value sexpr.SExpr) (string, error) {
+	s, ok := value.(sexpr.String)
+	if !ok {
+		return "", typeError(name, "string", value)
+	}
+	return s.Value, nil
+}
+
+// stringsArgs checks that args are all strings and returns their values
+func stringsArgs(name string, args []sexpr.SExpr) ([]string, error) {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		s, err := stringArg(name, arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = s
+	}
+	return values, nil
+}
+
+// newString returns s as a string value, charging it to the memory budget
+func newString(s string, env *Env) (sexpr.SExpr, error) {
+	if err := env.runtime.alloc(len(s)); err != nil {
+		return nil, err
+	}
+	return sexpr.String{Value: s}, nil
+}
+
+// joinStrings concatenates values with sep between them, like
+// strings.Join, stopping if the evaluation is interrupted
+func joinStrings(values []string, sep string, env *Env) (sexpr.SExpr, error) {
+	var b strings.Builder
+	for i, s := range values {
+		if err := env.poll(i); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(s)
+	}
+	return newString(b.String(), env)
+}
+
+func primStringLength(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("string-length", 1, 1, len(args))
+	}
+
+	s, err := stringArg("string-length", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Number{Value: int64(utf8.RuneCountInString(s))}, nil
+}
+
+func primStringAppend(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	values, err := stringsArgs("string-append", args)
+	if err != nil {
+		return nil, err
+	}
+	return joinStrings(values, "", env)
+}
+
+// primSubstring handles (substring s start [end]), the characters of s
+// from start up to but not including end
+func primSubstring(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, arityError("substring", 2, 3, len(args))
+	}
+
+	s, err := stringArg("substring", args[0])
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(s)
+	start, err := indexArg("substring", args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := len(runes)
+	if len(args) == 3 {
+		if end, err = indexArg("substring", args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if start > end || end > len(runes) {
+		return nil, evalError("substring", "range [%d, %d) out of bounds for length %d", start, end, len(runes))
+	}
+
+	return newString(string(runes[start:end]), env)
+}
+
+// primStringSplit handles (string-split s [sep]), splitting s around
+// each sep, or around runs of whitespace without one
+func primStringSplit(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("string-split", 1, 2, len(args))
+	}
+
+	values, err := stringsArgs("string-split", args)
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	if len(values) == 1 {
+		parts = strings.Fields(values[0])
+	} else {
+		parts = strings.Split(values[0], values[1])
+	}
+	if err := env.runtime.alloc(consSize*len(parts) + len(values[0])); err != nil {
+		return nil, err
+	}
+
+	elems := make([]sexpr.SExpr, len(parts))
+	for i, part := range parts {
+		if err := env.poll(i); err != nil {
+			return nil, err
+		}
+		elems[i] = sexpr.String{Value: part}
+	}
+	return sexpr.List{Elements: elems}, nil
+}
+
+// primStringJoin handles (string-join strings [sep]), concatenating a
+// list of strings with sep between them
+func primStringJoin(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("string-join", 1, 2, len(args))
+	}
+
+	elems, ok := sexpr.Elements(args[0])
+	if !ok {
+		return nil, typeError("string-join", "list", args[0])
+	}
+	values, err := stringsArgs("string-join", elems)
+	if err != nil {
+		return nil, err
+	}
+	sep := ""
+	if len(args) == 2 {
+		if sep, err = stringArg("string-join", args[1]); err != nil {
+			return nil, err
+		}
+	}
+	return joinStrings(values, sep, env)
+}
+
+// primStringTrim handles (string-trim s), removing leading and trailing
+// whitespace
+func primStringTrim(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("string-trim", 1, 1, len(args))
+	}
+
+	s, err := stringArg("string-trim", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.String{Value: strings.TrimSpace(s)}, nil
+}
+
+// primStringContains handles (string-contains? s sub)
+func primStringContains(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("string-contains?", 2, 2, len(args))
+	}
+
+	values, err := stringsArgs("string-contains?", args)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Bool{Value: strings.Contains(values[0], values[1])}, nil
+}
+
+// primStringIndex handles (string-index s sub), the index of the first
+// occurrence of sub in s, or nil if there is none
+func primStringIndex(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("string-index", 2, 2, len(args))
+	}
+
+	values, err := stringsArgs("string-index", args)
+	if err != nil {
+		return nil, err
+	}
+	i := strings.Index(values[0], values[1])
+	if i < 0 {
+		return sexpr.Nil{}, nil
+	}
+	return sexpr.Number{Value: int64(utf8.RuneCountInString(values[0][:i]))}, nil
+}
+
+// stringMapper returns a primitive applying fn to a string
+func stringMapper(name string, fn func(string) string) func([]sexpr.SExpr, *Env) (sexpr.SExpr, error) {
+	return func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 1 {
+			return nil, arityError(name, 1, 1, len(args))
+		}
+
+		s, err := stringArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return newString(fn(s), env)
+	}
+}
+
+// radixArg returns the optional radix argument of name, 10 by default
+func radixArg(name string, args []sexpr.SExpr) (int, error) {
+	if len(args) < 2 {
+		return 10, nil
+	}
+	n, ok := args[1].(sexpr.Number)
+	if !ok || n.Value < 2 || n.Value > 36 {
+		return 0, evalError(name, "radix must be an integer from 2 to 36, got %v", args[1])
+	}
+	return int(n.Value), nil
+}
+
+// primStringToNumber handles (string->number s [radix]), returning nil
+// if s is not a number. Integers may be written in any radix; numbers
+// with a fraction or exponent only in radix 10.
+func primStringToNumber(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("string->number", 1, 2, len(args))
+	}
+
+	s, err := stringArg("string->number", args[0])
+	if err != nil {
+		return nil, err
+	}
+	radix, err := radixArg("string->number", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, ok := new(big.Int).SetString(s, radix); ok {
+		return normalizeBig(n), nil
+	}
+	if radix == 10 && !strings.ContainsFunc(s, notDecimal) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return sexpr.Float{Value: f}, nil
+		}
+	}
+	return sexpr.Nil{}, nil
+}
+
+// notDecimal reports whether r cannot appear in a decimal float
+func notDecimal(r rune) bool {
+	return !strings.ContainsRune("0123456789+-.eE", r)
+}
+
+// primNumberToString handles (number->string n [radix]). Digits above 9
+// are written in lower case. Floats may only be written in radix 10.
+func primNumberToString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("number->string", 1, 2, len(args))
+	}
+
+	n := args[0]
+	if !isNumber(n) {
+		return nil, typeError("number->string", "number", n)
+	}
+	radix, err := radixArg("number->string", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isInteger(n) {
+		if radix != 10 {
+			return nil, evalError("number->string", "cannot write a float in radix %d", radix)
+		}
+		return newString(sexpr.Write(n), env)
+	}
+	return newString(toBig(n).Text(radix), env)
+}
+
+// nameArg checks that args is a single value, described by expected,
+// that convert accepts, and returns the non-empty name convert gives it
+func nameArg(name string, args []sexpr.SExpr, expected string, convert func(sexpr.SExpr) (string, bool)) (string, error) {
+	if len(args) != 1 {
+		return "", arityError(name, 1, 1, len(args))
+	}
+	s, ok := convert(args[0])
+	if !ok {
+		return "", typeError(name, expected, args[0])
+	}
+	if s == "" {
+		return "", evalError(name, "name cannot be empty")
+	}
+	return s, nil
+}
+
+func symbolName(value sexpr.SExpr) (string, bool) {
+	sym, ok := value.(sexpr.Symbol)
+	return sym.Name, ok
+}
+
+func keywordName(value sexpr.SExpr) (string, bool) {
+	kw, ok := value.(sexpr.Keyword)
+	return kw.Name, ok
+}
+
+func stringValue(value sexpr.SExpr) (string, bool) {
+	s, ok := value.(sexpr.String)
+	return s.Value, ok
+}
+
+func primStringToSymbol(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	name, err := nameArg("string->symbol", args, "string", stringValue)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Symbol{Name: name}, nil
+}
+
+func primSymbolToString(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	name, err := nameArg("symbol->string", args, "symbol", symbolName)
+	if err != nil {
+		return nil, err
+	}
+	return newString(name, env)
+}
+
+func primSymbolToKeyword(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	name, err := nameArg("symbol->keyword", args, "symbol", symbolName)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Keyword{Name: name}, nil
+}
+
+func primKeywordToSymbol(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	name, err := nameArg("keyword->symbol", args, "keyword", keywordName)
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Symbol{Name: name}, nil
+}
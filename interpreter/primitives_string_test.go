@@ -0,0 +1,179 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestStringPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(str)`, `""`},
+		{`(str "a" 1 'b (list 1 "c"))`, `"a1b(1 c)"`},
+		{`#"sum is ${(+ 1 2)}"`, `"sum is 3"`},
+		{`(let ((name "zy")) #"hello, ${name}!")`, `"hello, zy!"`},
+		{`#"${1}${2}"`, `"12"`},
+		{`#"braces ${(str "}" "{")} and \${x}"`, `"braces }{ and ${x}"`},
+		{`#"plain"`, `"plain"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestInterpolationIgnoresLocalStr(t *testing.T) {
+	for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+		t.Run(engine.String(), func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			env.Runtime().SetEngine(engine)
+			got := evalForms(t, env, `(define (f str) #"v=${str}")`, "(f 5)")
+			if got.String() != `"v=5"` {
+				t.Errorf("got %v, want \"v=5\"", got)
+			}
+		})
+	}
+}
+
+func TestStringLibrary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(string-length "")`, "0"},
+		{`(string-length "héllo")`, "5"},
+		{`(string-append)`, `""`},
+		{`(string-append "ab" "" "cd")`, `"abcd"`},
+		{`(substring "héllo" 1)`, `"éllo"`},
+		{`(substring "héllo" 1 3)`, `"él"`},
+		{`(substring "abc" 3)`, `""`},
+		{`(string-split "  a b\tc ")`, `("a" "b" "c")`},
+		{`(string-split "a,b,,c" ",")`, `("a" "b" "" "c")`},
+		{`(string-join (list "a" "b" "c"))`, `"abc"`},
+		{`(string-join (list "a" "b" "c") ", ")`, `"a, b, c"`},
+		{`(string-join '() ",")`, `""`},
+		{`(string-trim "  hi there \n")`, `"hi there"`},
+		{`(string-contains? "haystack" "st")`, "true"},
+		{`(string-contains? "haystack" "x")`, "false"},
+		{`(string-index "héllo" "l")`, "2"},
+		{`(string-index "hello" "z")`, "nil"},
+		{`(string-upcase "héllo")`, `"HÉLLO"`},
+		{`(string-downcase "ABC")`, `"abc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestStringLibraryErrors(t *testing.T) {
+	tests := []string{
+		"(string-length 1)",
+		`(string-append "a" 'b)`,
+		`(substring "abc" 2 1)`,
+		`(substring "abc" 0 4)`,
+		`(substring "abc" -1)`,
+		`(string-split "a" 1)`,
+		`(string-join "abc")`,
+		`(string-join (list "a" 1))`,
+		`(string-trim "a" "b")`,
+		`(string-contains? "a")`,
+		`(string-index 'a "a")`,
+		`(string-upcase)`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestNumberConversions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(string->number "42")`, "42"},
+		{`(string->number "-17")`, "-17"},
+		{`(string->number "+5")`, "5"},
+		{`(string->number "99999999999999999999")`, "99999999999999999999"},
+		{`(string->number "2.5")`, "2.5"},
+		{`(string->number "1e3")`, "1000.0"},
+		{`(string->number "ff" 16)`, "255"},
+		{`(string->number "FF" 16)`, "255"},
+		{`(string->number "-101" 2)`, "-5"},
+		{`(string->number "z" 36)`, "35"},
+		{`(string->number "12" 2)`, "nil"},
+		{`(string->number "1.5" 16)`, "nil"},
+		{`(string->number "abc")`, "nil"},
+		{`(string->number "")`, "nil"},
+		{`(string->number "inf")`, "nil"},
+		{`(string->number "0x10")`, "nil"},
+		{`(number->string 42)`, `"42"`},
+		{`(number->string 255 16)`, `"ff"`},
+		{`(number->string -5 2)`, `"-101"`},
+		{`(number->string 99999999999999999999 16)`, `"56bc75e2d630fffff"`},
+		{`(number->string 2.5)`, `"2.5"`},
+		{`(string->number (number->string 12345 7) 7)`, "12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		`(string->number 42)`,
+		`(string->number "1" 1)`,
+		`(string->number "1" 37)`,
+		`(number->string "42")`,
+		`(number->string 1.5 2)`,
+		`(number->string 1 2 3)`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestNameConversions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(string->symbol "foo")`, "foo"},
+		{`(symbol? (string->symbol "a b"))`, "true"},
+		{`(eq? (string->symbol "foo") 'foo)`, "true"},
+		{`(symbol->string 'foo)`, `"foo"`},
+		{`(symbol->keyword 'name)`, ":name"},
+		{`(keyword->symbol :name)`, "name"},
+		{`(symbol->string (keyword->symbol (symbol->keyword 'x)))`, `"x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		`(string->symbol 'foo)`,
+		`(string->symbol "")`,
+		`(symbol->string "foo")`,
+		`(symbol->keyword :foo)`,
+		`(keyword->symbol 'foo)`,
+		`(keyword->symbol)`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
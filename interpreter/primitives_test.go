@@ -33,6 +33,57 @@ func testEvalWithPrimitives(t *testing.T, input string, expected sexpr.SExpr) {
 	}
 }
 
+// testEvalString evaluates input with primitives loaded and compares the
+// printed result
+func testEvalString(t *testing.T, input string, expected string) {
+	t.Helper()
+
+	tokens, err := parser.Tokenize(input)
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result, err := Eval(expr, env)
+	if err != nil {
+		t.Fatalf("eval error: %v", err)
+	}
+
+	if result.String() != expected {
+		t.Errorf("got %v, want %s", result, expected)
+	}
+}
+
+// testEvalError checks that evaluating input with the primitives loaded
+// fails
+func testEvalError(t *testing.T, input string) {
+	t.Helper()
+
+	tokens, err := parser.Tokenize(input)
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	if result, err := Eval(expr, env); err == nil {
+		t.Errorf("expected error, got %v", result)
+	}
+}
+
 func TestPrimAdd(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -128,6 +179,83 @@ func TestPrimComparisons(t *testing.T) {
 	}
 }
 
+func TestPrimIsEqual(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"(equal? 1 1)", true},
+		{"(equal? 1 2)", false},
+		{`(equal? "abc" "abc")`, true},
+		{`(equal? "abc" (quote abc))`, false},
+		{"(equal? (quote x) (quote x))", true},
+		{"(equal? (list 1 (list 2 3)) (list 1 (list 2 3)))", true},
+		{"(equal? (list 1 (list 2 3)) (list 1 (list 2 4)))", false},
+		{"(equal? (list 1 2) (list 1 2 3))", false},
+		{"(equal? true true)", true},
+		{"(equal? + +)", true},
+		{"(equal? + -)", false},
+		{"(equal? [1 '(2)] [1 (list 2)])", true},
+		{"(equal? [1 2] (list 1 2))", false},
+		{"(equal? (cons 1 (list 2)) (list 1 2))", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalWithPrimitives(t, tt.input, sexpr.Bool{Value: tt.expected})
+		})
+	}
+}
+
+func TestPrimIsEq(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"(eq? 'a 'a)", true},
+		{"(eq? 'a 'b)", false},
+		{"(eq? :k :k)", true},
+		{"(eq? 1 1)", true},
+		{"(eq? 1 '1)", true},
+		{"(eq? true true)", true},
+		{"(eq? (list 1 2) (list 1 2))", false},
+		{"(let ((x (list 1 2))) (eq? x x))", true},
+		{"(eq? (list) (list))", true},
+		{`(eq? "abc" "abc")`, false},
+		{`(let ((s "abc")) (eq? s s))`, true},
+		{"(let ((v [1 2])) (eq? v v))", true},
+		{"(eq? [1 2] [1 2])", false},
+		{"(eq? car car)", true},
+		{"(eq? 100000000000000000000 100000000000000000000)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalWithPrimitives(t, tt.input, sexpr.Bool{Value: tt.expected})
+		})
+	}
+}
+
+func TestPrimIsEqv(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"(eqv? 'a 'a)", true},
+		{"(eqv? 100000000000000000000 100000000000000000000)", true},
+		{"(eqv? (+ 1 2) 3)", true},
+		{"(eqv? (list 1) (list 1))", false},
+		{`(eqv? "abc" "abc")`, false},
+		{"(let ((x (list 1))) (eqv? x x))", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalWithPrimitives(t, tt.input, sexpr.Bool{Value: tt.expected})
+		})
+	}
+}
+
 func TestPrimList(t *testing.T) {
 	input := "(list 1 2 3)"
 	expected := sexpr.List{
@@ -174,6 +302,31 @@ func TestPrimCons(t *testing.T) {
 	testEvalWithPrimitives(t, input, expected)
 }
 
+func TestPrimConsPairs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(cons 1 2)", "(1 . 2)"},
+		{"(car (cons 1 2))", "1"},
+		{"(cdr (cons 1 2))", "2"},
+		{"(cons 1 (cons 2 (list)))", "(1 2)"},
+		{"(cdr (cons 1 (cons 2 (list))))", "(2)"},
+		{"(list? (cons 1 (list)))", "true"},
+		{"(list? (cons 1 2))", "false"},
+		{"(null? (cdr (cons 1 (list))))", "true"},
+		{"(equal? (cons 1 (list 2)) (list 1 2))", "true"},
+		{"(car (cons + (list 1 2)))", "<primitive:+>"},
+		{"(cons (quote +) (list 1 2))", "(+ 1 2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
 func TestPrimTypePredicates(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -187,6 +340,33 @@ func TestPrimTypePredicates(t *testing.T) {
 		{"(list? 42)", false},
 		{"(null? (list))", true},
 		{"(null? (list 1))", false},
+		{"(boolean? false)", true},
+		{"(boolean? (when false 1))", false},
+		{`(string? "s")`, true},
+		{"(string? 's)", false},
+		{"(procedure? car)", true},
+		{"(procedure? (lambda (x) x))", true},
+		{"(procedure? 'car)", false},
+		{"(not false)", true},
+		{"(not (when false 1))", true},
+		{"(not 0)", false},
+		{"(not '())", false},
+		{"(zero? 0)", true},
+		{"(zero? -0.0)", true},
+		{"(zero? 1)", false},
+		{"(positive? 3)", true},
+		{"(positive? 0)", false},
+		{"(positive? 99999999999999999999)", true},
+		{"(negative? -0.5)", true},
+		{"(negative? -99999999999999999999)", true},
+		{"(negative? 0)", false},
+		{"(negative? (/ 0.0 0))", false},
+		{"(even? 0)", true},
+		{"(even? -4)", true},
+		{"(even? 7)", false},
+		{"(odd? -3)", true},
+		{"(odd? 100000000000000000001)", true},
+		{"(odd? 2)", false},
 	}
 
 	for _, tt := range tests {
@@ -196,6 +376,61 @@ func TestPrimTypePredicates(t *testing.T) {
 	}
 }
 
+func TestPrimPredicateErrors(t *testing.T) {
+	for _, input := range []string{
+		"(not)", "(string? 1 2)", "(zero? 'a)", "(positive?)", "(even? 1.0)", `(odd? "1")`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestPrimTypeOf(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(type-of 42)", "number"},
+		{"(type-of 99999999999999999999)", "number"},
+		{"(type-of 1.5)", "number"},
+		{`(type-of "s")`, "string"},
+		{`(type-of #\a)`, "char"},
+		{"(type-of 'x)", "symbol"},
+		{"(type-of :k)", "keyword"},
+		{"(type-of true)", "boolean"},
+		{"(type-of (when false 1))", "null"},
+		{"(eq? (type-of nil) 'null)", "true"},
+		{"(type-of '(1 2))", "list"},
+		{"(type-of '())", "list"},
+		{"(type-of (cons 1 2))", "pair"},
+		{"(type-of [1])", "vector"},
+		{"(type-of {:a 1})", "map"},
+		{"(type-of #{1})", "set"},
+		{"(type-of car)", "function"},
+		{"(type-of (lambda (x) x))", "function"},
+		{`(type-of (make-error 'e "m"))`, "error"},
+		{"(type-of (current-output-port))", "port"},
+		{"(type-of (atom 1))", "atom"},
+		{"(type-of (delay 1))", "promise"},
+		{"(type-of (current-environment))", "environment"},
+		{"(begin " + pointRecord + " (type-of (make-point 1 2)))", "point"},
+		{"(type-of (type-of 1))", "symbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{"(type-of)", "(type-of 1 2)"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
 func TestNestedExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -238,3 +473,159 @@ func TestUserDefinedFunctions(t *testing.T) {
 		t.Errorf("got %v, want %v", result, expected)
 	}
 }
+
+func TestPrimMeta(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(meta (quote x))", "nil"},
+		{"(meta (with-meta (quote x) (list (quote doc) \"the x\")))", `{doc "the x"}`},
+		{"(with-meta (list 1 2) (list (quote line) 3))", "(1 2)"},
+		{"(meta (with-meta + (list)))", "{}"},
+		{"(equal? (with-meta (quote x) (list 1 2)) (quote x))", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestPrimIsGoValue(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Define("conn", sexpr.GoValue{Value: &struct{ open bool }{open: true}})
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"(go-value? conn)", true},
+		{"(go-value? 42)", false},
+		{"(equal? conn conn)", true},
+		{"(go-value? (car (list conn)))", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, _ := parser.Tokenize(tt.input)
+			expr, _ := parser.Read(tokens)
+			result, err := Eval(expr, env)
+			if err != nil {
+				t.Fatalf("eval error: %v", err)
+			}
+
+			if !result.Equal(sexpr.Bool{Value: tt.expected}) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrimForce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(force (delay (+ 1 2)))", "3"},
+		{"(force 42)", "42"},
+		{"(promise? (delay 1))", "true"},
+		{"(promise? 1)", "false"},
+		{"(delay 1)", "#<promise>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestPrimApply(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(apply + (list 1 2 3))", "6"},
+		{"(apply + 1 2 (list 3 4))", "10"},
+		{"(apply list (list))", "()"},
+		{"(apply (lambda (x . rest) rest) (cons 1 (cons 2 (list))))", "(2)"},
+		{"(apply apply (list + (list 1 2)))", "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{"(apply +)", "(apply + 1)", "(apply 1 (list))"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestPrimEval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(eval '(+ 1 2))", "3"},
+		{"(eval (list '* 2 3))", "6"},
+		{"(let ((x 5)) (eval 'x (current-environment)))", "5"},
+		{"(let ((x 5)) (eval 'x))", "5"},
+		{"(begin (define y 1) (let ((y 2)) (eval 'y (global-environment))))", "1"},
+		{"(let ((e (let ((z 9)) (current-environment)))) (eval 'z e))", "9"},
+		{"(environment? (current-environment))", "true"},
+		{"(environment? 1)", "false"},
+		{"(global-environment)", "#<environment>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{"(eval)", "(eval 1 2)", "(eval 'undefined-var)", "(current-environment 1)"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestPrimValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(values 1)", "1"},
+		{"(values 1 2)", "1 2"},
+		{"(call-with-values (lambda () (values 1 2)) +)", "3"},
+		{"(call-with-values (lambda () 5) list)", "(5)"},
+		{"(call-with-values (lambda () (values)) list)", "()"},
+		{"(let-values (((q r) (values 7 2)) (all (values 1 2 3))) (list q r all))", "(7 2 (1 2 3))"},
+		{"(let-values (((a . more) (values 1 2 3))) more)", "(2 3)"},
+		{"(let-values (((a) 4)) a)", "4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+
+	for _, input := range []string{
+		"(call-with-values (lambda () 1))",
+		"(let-values (((a b) (values 1))) a)",
+		"(let-values (((a) (values 1 2))) a)",
+		"(let-values ((a)) a)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
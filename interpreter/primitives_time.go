@@ -0,0 +1,33 @@
+package interpreter
+
+import (
+	"time"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadTimePrimitives adds the clock primitives to an environment. They,
+// and the time special form, need the clock capability.
+func loadTimePrimitives(env *Env) {
+	env.Define("current-time", restricted(CapClock, "current-time", primCurrentTime))
+	env.Define("monotonic-millis", restricted(CapClock, "monotonic-millis", primMonotonicMillis))
+}
+
+// primCurrentTime handles (current-time), the wall clock time in
+// milliseconds since the Unix epoch
+func primCurrentTime(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("current-time", 0, 0, len(args))
+	}
+	return sexpr.Number{Value: time.Now().UnixMilli()}, nil
+}
+
+// primMonotonicMillis handles (monotonic-millis), the milliseconds since
+// the runtime was created by a clock that never goes backwards, for
+// measuring intervals
+func primMonotonicMillis(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 0 {
+		return nil, arityError("monotonic-millis", 0, 0, len(args))
+	}
+	return sexpr.Number{Value: time.Since(env.runtime.start).Milliseconds()}, nil
+}
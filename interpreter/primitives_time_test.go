@@ -0,0 +1,76 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestClockPrimitives(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	before := time.Now().UnixMilli()
+	now := evalForms(t, env, "(current-time)").(sexpr.Number).Value
+	if after := time.Now().UnixMilli(); now < before || now > after {
+		t.Errorf("(current-time) = %d, want between %d and %d", now, before, after)
+	}
+
+	first := evalForms(t, env, "(monotonic-millis)").(sexpr.Number).Value
+	time.Sleep(2 * time.Millisecond)
+	second := evalForms(t, env, "(monotonic-millis)").(sexpr.Number).Value
+	if first < 0 || second < first+2 {
+		t.Errorf("monotonic-millis went from %d to %d over 2ms", first, second)
+	}
+
+	for _, input := range []string{"(current-time 1)", "(monotonic-millis 1)"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestTimeForm(t *testing.T) {
+	for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+		t.Run(engine.String(), func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			env.Runtime().SetEngine(engine)
+			got := evalForms(t, env,
+				"(define (f x) (time (+ x 1)))",
+				"(let-values (((v ms) (f 41))) (list v (>= ms 0)))",
+			)
+			if got.String() != "(42 true)" {
+				t.Errorf("got %v, want (42 true)", got)
+			}
+		})
+	}
+
+	for _, input := range []string{"(time)", "(time 1 2)", "(time (car 1))"} {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+
+	tokens, _ := parser.Tokenize("(time 1 2)")
+	expr, _ := parser.Read(tokens)
+	if problems := Analyze(expr); len(problems) != 1 {
+		t.Errorf("got %v, want one arity problem", problems)
+	}
+}
+
+func TestClockSandbox(t *testing.T) {
+	env := NewSandbox(Policy{})
+	for _, input := range []string{"(current-time)", "(monotonic-millis)", "(time 1)"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := evalString(env, input)
+			var pe *PolicyError
+			if !errors.As(err, &pe) || pe.Capability != CapClock {
+				t.Fatalf("got %v, want a clock policy error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// loadVectorPrimitives adds the vector primitives to an environment.
+// Vectors written [a b c] or built by vector are persistent, so
+// vector-assoc returns an updated vector, in effectively constant time,
+// and leaves its argument unchanged. make-vector builds a mutable vector
+// instead, which vector-set! changes in place. The other primitives
+// accept either kind.
+func loadVectorPrimitives(env *Env) {
+	env.Define("vector?", makePrimitive("vector?", primIsVector))
+	env.Define("vector", makePrimitive("vector", primVector))
+	env.Define("make-vector", makePrimitive("make-vector", primMakeVector))
+	env.Define("vector-length", makePrimitive("vector-length", primVectorLength))
+	env.Define("vector-ref", makePrimitive("vector-ref", primVectorRef))
+	env.Define("vector-assoc", makePrimitive("vector-assoc", primVectorAssoc))
+	env.Define("vector-set!", makePrimitive("vector-set!", primVectorSet))
+	env.Define("vector->list", makePrimitive("vector->list", primVectorToList))
+	env.Define("list->vector", makePrimitive("list->vector", primListToVector))
+}
+
+// indexed is a persistent or mutable vector
+type indexed interface {
+	sexpr.SExpr
+	Len() int
+	Nth(i int) (sexpr.SExpr, bool)
+	Elements() []sexpr.SExpr
+}
+
+func vectorArg(name string, value sexpr.SExpr) (sexpr.Vector, error) {
+	v, ok := value.(sexpr.Vector)
+	if !ok {
+		return sexpr.Vector{}, typeError(name, "vector", value)
+	}
+	return v, nil
+}
+
+// indexedArg accepts a persistent or mutable vector
+func indexedArg(name string, value sexpr.SExpr) (indexed, error) {
+	switch v := value.(type) {
+	case sexpr.Vector:
+		return v, nil
+	case *sexpr.MutableVector:
+		return v, nil
+	}
+	return nil, typeError(name, "vector", value)
+}
+
+// vectorIndexArg converts value to an index of v
+func vectorIndexArg(name string, v indexed, value sexpr.SExpr) (int, error) {
+	i, err := indexArg(name, value)
+	if err != nil {
+		return 0, err
+	}
+	if i >= v.Len() {
+		return 0, evalError(name, "index %d out of range for length %d", i, v.Len())
+	}
+	return i, nil
+}
+
+func primIsVector(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("vector?", 1, 1, len(args))
+	}
+
+	_, err := indexedArg("vector?", args[0])
+	return sexpr.Bool{Value: err == nil}, nil
+}
+
+func primVector(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if err := env.runtime.alloc(consSize * len(args)); err != nil {
+		return nil, err
+	}
+	return sexpr.NewVector(args...), nil
+}
+
+// primMakeVector handles (make-vector n [fill]), a mutable vector of n
+// elements that are all fill, or nil
+func primMakeVector(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, arityError("make-vector", 1, 2, len(args))
+	}
+
+	n, err := indexArg("make-vector", args[0])
+	if err != nil {
+		return nil, err
+	}
+	var fill sexpr.SExpr = sexpr.Nil{}
+	if len(args) == 2 {
+		fill = args[1]
+	}
+	if err := env.runtime.alloc(consSize * n); err != nil {
+		return nil, err
+	}
+
+	elems := make([]sexpr.SExpr, n)
+	for i := range elems {
+		if err := env.poll(i); err != nil {
+			return nil, err
+		}
+		elems[i] = fill
+	}
+	return sexpr.NewMutableVector(elems...), nil
+}
+
+func primVectorLength(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("vector-length", 1, 1, len(args))
+	}
+
+	v, err := indexedArg("vector-length", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Number{Value: int64(v.Len())}, nil
+}
+
+func primVectorRef(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 2 {
+		return nil, arityError("vector-ref", 2, 2, len(args))
+	}
+
+	v, err := indexedArg("vector-ref", args[0])
+	if err != nil {
+		return nil, err
+	}
+	i, err := vectorIndexArg("vector-ref", v, args[1])
+	if err != nil {
+		return nil, err
+	}
+	elem, _ := v.Nth(i)
+	return elem, nil
+}
+
+// primVectorAssoc handles (vector-assoc v i x), v with the element at
+// index i replaced by x
+func primVectorAssoc(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 3 {
+		return nil, arityError("vector-assoc", 3, 3, len(args))
+	}
+
+	v, err := vectorArg("vector-assoc", args[0])
+	if err != nil {
+		return nil, err
+	}
+	i, err := vectorIndexArg("vector-assoc", v, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := env.runtime.alloc(consSize); err != nil {
+		return nil, err
+	}
+	return v.Assoc(i, args[2])
+}
+
+// primVectorSet handles (vector-set! v i x), replacing the element at
+// index i of the mutable vector v by x. A persistent vector cannot be
+// changed in place: vector-assoc returns an updated copy.
+func primVectorSet(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 3 {
+		return nil, arityError("vector-set!", 3, 3, len(args))
+	}
+
+	v, ok := args[0].(*sexpr.MutableVector)
+	if !ok {
+		if _, persistent := args[0].(sexpr.Vector); persistent {
+			return nil, evalError("vector-set!", "cannot change a persistent vector; use vector-assoc, or make-vector for a mutable one")
+		}
+		return nil, typeError("vector-set!", "mutable vector", args[0])
+	}
+	i, err := vectorIndexArg("vector-set!", v, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Set(i, args[2]); err != nil {
+		return nil, evalError("vector-set!", "%v", err)
+	}
+	return sexpr.Nil{}, nil
+}
+
+func primVectorToList(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("vector->list", 1, 1, len(args))
+	}
+
+	v, err := indexedArg("vector->list", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return newList(v.Elements(), env)
+}
+
+func primListToVector(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	if len(args) != 1 {
+		return nil, arityError("list->vector", 1, 1, len(args))
+	}
+
+	elems, err := listArg("list->vector", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return primVector(elems, env)
+}
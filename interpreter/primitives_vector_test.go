@@ -0,0 +1,77 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVectorPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(vector? [1])", "true"},
+		{"(vector? '(1))", "false"},
+		{"(vector)", "[]"},
+		{"(vector 1 'a \"b\")", `[1 a "b"]`},
+		{"(make-vector 3)", "#<vector nil nil nil>"},
+		{"(make-vector 2 0)", "#<vector 0 0>"},
+		{"(make-vector 0)", "#<vector>"},
+		{"(vector? (make-vector 1))", "true"},
+		{"(vector-length [1 2 3])", "3"},
+		{"(vector-ref [10 20 30] 2)", "30"},
+		{"(vector-assoc [1 2 3] 1 'x)", "[1 x 3]"},
+		{"(let ((v [1 2])) (vector-assoc v 0 9) v)", "[1 2]"},
+		{"(let ((v [0 0 0])) (begin (set! v (vector-assoc v 2 5)) v))", "[0 0 5]"},
+		{"(let ((v (make-vector 3 0))) (vector-set! v 2 5) (vector-set! v 0 'a) v)", "#<vector a 0 5>"},
+		{"(let* ((v (make-vector 2 0)) (alias v)) (vector-set! v 1 7) (vector-ref alias 1))", "7"},
+		{"(vector->list (let ((v (make-vector 2 0))) (vector-set! v 0 1) v))", "(1 0)"},
+		{"(vector-length (make-vector 4))", "4"},
+		{"(equal? (make-vector 2 0) (make-vector 2 0))", "true"},
+		{"(equal? (make-vector 2 0) [0 0])", "false"},
+		{"(vector->list [1 2])", "(1 2)"},
+		{"(vector->list [])", "()"},
+		{"(list->vector '(1 2))", "[1 2]"},
+		{"(equal? (list->vector (vector->list [1 [2]])) [1 [2]])", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestVectorPrimitiveErrors(t *testing.T) {
+	tests := []string{
+		"(make-vector -1)",
+		"(make-vector 'a)",
+		"(vector-length '(1))",
+		"(vector-ref [1 2] 2)",
+		"(vector-ref [1 2] -1)",
+		"(vector-ref '(1 2) 0)",
+		"(vector-assoc [1 2] 2 0)",
+		"(vector-assoc [1 2] 0)",
+		"(vector-set! [1 2] 0 9)",
+		"(vector-set! (make-vector 2) 2 0)",
+		"(vector-set! (make-vector 2) 0)",
+		"(vector-assoc (make-vector 2) 0 1)",
+		"(vector->list '(1))",
+		"(list->vector [1])",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
+
+func TestVectorSetOnPersistentVector(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	_, err := evalString(env, "(let ((v [1 2])) (vector-set! v 0 9) v)")
+	if err == nil || !strings.Contains(err.Error(), "vector-assoc") {
+		t.Errorf("expected an error pointing to vector-assoc, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// evalDefineRecordType handles
+//
+//	(define-record-type name
+//	  (constructor field...)
+//	  predicate
+//	  (field accessor [modifier])...)
+//
+// binding the type descriptor, constructor, predicate, accessors and
+// modifiers in the current environment
+func evalDefineRecordType(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) < 4 {
+		return nil, arityError("define-record-type", 3, -1, len(list.Elements)-1)
+	}
+
+	name, ok := list.Elements[1].(sexpr.Symbol)
+	if !ok {
+		return nil, evalError("define-record-type", "type name must be a symbol")
+	}
+
+	ctorSpec, ok := list.Elements[2].(sexpr.List)
+	if !ok || len(ctorSpec.Elements) == 0 {
+		return nil, evalError("define-record-type", "constructor spec must be a non-empty list")
+	}
+
+	predicate, ok := list.Elements[3].(sexpr.Symbol)
+	if !ok {
+		return nil, evalError("define-record-type", "predicate name must be a symbol")
+	}
+
+	// Field specs determine the layout of the record
+	recordType := &sexpr.RecordType{Name: name.Name}
+	var fieldSpecs [][]sexpr.Symbol
+	for _, spec := range list.Elements[4:] {
+		symbols, err := recordSymbols(spec)
+		if err != nil || len(symbols) < 2 || len(symbols) > 3 {
+			return nil, evalError("define-record-type", "field spec must be (field accessor [modifier]), got %v", spec)
+		}
+		if recordType.FieldIndex(symbols[0].Name) >= 0 {
+			return nil, evalError("define-record-type", "duplicate field %s", symbols[0].Name)
+		}
+		recordType.Fields = append(recordType.Fields, symbols[0].Name)
+		fieldSpecs = append(fieldSpecs, symbols)
+	}
+
+	ctorSymbols, err := recordSymbols(ctorSpec)
+	if err != nil {
+		return nil, evalError("define-record-type", "%v", err)
+	}
+
+	ctor, err := makeRecordConstructor(recordType, ctorSymbols)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Define(name.Name, recordType)
+	env.Define(ctorSymbols[0].Name, ctor)
+	env.Define(predicate.Name, makeRecordPredicate(recordType, predicate.Name))
+
+	for i, spec := range fieldSpecs {
+		env.Define(spec[1].Name, makeRecordAccessor(recordType, i, spec[1].Name))
+		if len(spec) == 3 {
+			env.Define(spec[2].Name, makeRecordModifier(recordType, i, spec[2].Name))
+		}
+	}
+
+	return recordType, nil
+}
+
+// recordSymbols checks that spec is a list of symbols
+func recordSymbols(spec sexpr.SExpr) ([]sexpr.Symbol, error) {
+	list, ok := spec.(sexpr.List)
+	if !ok {
+		return nil, evalError("", "expected a list of symbols, got %v", spec)
+	}
+
+	symbols := make([]sexpr.Symbol, len(list.Elements))
+	for i, elem := range list.Elements {
+		sym, ok := elem.(sexpr.Symbol)
+		if !ok {
+			return nil, evalError("", "expected symbol, got %v", elem)
+		}
+		symbols[i] = sym
+	}
+	return symbols, nil
+}
+
+// makeRecordConstructor returns a primitive taking the listed fields in
+// order, which are its parameters; fields not listed start as nil
+func makeRecordConstructor(recordType *sexpr.RecordType, spec []sexpr.Symbol) (sexpr.Primitive, error) {
+	name := spec[0].Name
+	positions := make([]int, len(spec)-1)
+	fields := make([]sexpr.SExpr, len(spec)-1)
+	for i, field := range spec[1:] {
+		fields[i] = field
+		index := recordType.FieldIndex(field.Name)
+		if index < 0 {
+			return sexpr.Primitive{}, evalError("define-record-type", "constructor field %s is not a field of %s", field.Name, recordType.Name)
+		}
+		positions[i] = index
+	}
+
+	ctor := makePrimitive(name, func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != len(positions) {
+			return nil, evalError(name, "requires %d arguments, got %d", len(positions), len(args))
+		}
+
+		values := make([]sexpr.SExpr, len(recordType.Fields))
+		for i := range values {
+			values[i] = sexpr.Nil{}
+		}
+		for i, index := range positions {
+			values[index] = args[i]
+		}
+		return &sexpr.Record{Type: recordType, Values: values}, nil
+	})
+	ctor.Params = sexpr.List{Elements: fields}
+	return ctor, nil
+}
+
+func makeRecordPredicate(recordType *sexpr.RecordType, name string) sexpr.Primitive {
+	return makePrimitive(name, func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 1 {
+			return nil, arityError(name, 1, 1, len(args))
+		}
+
+		record, ok := args[0].(*sexpr.Record)
+		return sexpr.Bool{Value: ok && record.Type == recordType}, nil
+	})
+}
+
+func makeRecordAccessor(recordType *sexpr.RecordType, index int, name string) sexpr.Primitive {
+	return makePrimitive(name, func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 1 {
+			return nil, arityError(name, 1, 1, len(args))
+		}
+
+		record, err := recordArg(recordType, name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[index], nil
+	})
+}
+
+func makeRecordModifier(recordType *sexpr.RecordType, index int, name string) sexpr.Primitive {
+	return makePrimitive(name, func(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+		if len(args) != 2 {
+			return nil, arityError(name, 2, 2, len(args))
+		}
+
+		record, err := recordArg(recordType, name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		record.Values[index] = args[1]
+		return args[1], nil
+	})
+}
+
+// recordArg checks that value is a record of the given type
+func recordArg(recordType *sexpr.RecordType, name string, value sexpr.SExpr) (*sexpr.Record, error) {
+	record, ok := value.(*sexpr.Record)
+	if !ok || record.Type != recordType {
+		return nil, evalError(name, "expected %s record, got %v", recordType.Name, value)
+	}
+	return record, nil
+}
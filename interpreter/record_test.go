@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+)
+
+const pointRecord = `(define-record-type point
+  (make-point x y)
+  point?
+  (x point-x set-point-x!)
+  (y point-y))`
+
+func TestDefineRecordType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(make-point 1 2)", "#<point x: 1 y: 2>"},
+		{"(point-x (make-point 1 2))", "1"},
+		{"(point-y (make-point 1 2))", "2"},
+		{"(point? (make-point 1 2))", "true"},
+		{"(point? (list 1 2))", "false"},
+		{"point", "#<record-type point>"},
+		{"(equal? (make-point 1 2) (make-point 1 2))", "true"},
+		{"(equal? (make-point 1 2) (make-point 2 1))", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+
+			result := evalForms(t, env, pointRecord, tt.input)
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecordModifier(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		pointRecord,
+		"(define p (make-point 1 2))",
+		"(set-point-x! p 10)",
+		"(point-x p)")
+
+	if result.String() != "10" {
+		t.Errorf("got %v, want 10", result)
+	}
+}
+
+func TestRecordCycleEquality(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		pointRecord,
+		"(define x (make-point 1 2))",
+		"(define y (make-point 1 2))",
+		"(set-point-x! x x)",
+		"(set-point-x! y y)",
+		"(list (equal? x y) (equal? x (make-point x 3)))")
+
+	if result.String() != "(true false)" {
+		t.Errorf("got %v, want (true false)", result)
+	}
+}
+
+func TestRecordCyclePrinting(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		pointRecord,
+		"(define p (make-point 1 2))",
+		"(set-point-x! p p)",
+		"p")
+
+	if got := result.String(); got != "#0=#<point x: #0# y: 2>" {
+		t.Errorf("got %s, want #0=#<point x: #0# y: 2>", got)
+	}
+}
+
+func TestRecordPartialConstructor(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	result := evalForms(t, env,
+		"(define-record-type node (make-node value) node? (value node-value) (next node-next))",
+		"(node-next (make-node 1))")
+
+	if result.String() != "nil" {
+		t.Errorf("got %v, want nil", result)
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"accessor on wrong type", "(point-x (list 1 2))"},
+		{"constructor arity", "(make-point 1)"},
+		{"bad field spec", "(define-record-type bad (make-bad a) bad? (a))"},
+		{"unknown constructor field", "(define-record-type bad (make-bad b) bad? (a bad-a))"},
+		{"duplicate field", "(define-record-type bad (make-bad a) bad? (a bad-a) (a bad-a2))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			evalForms(t, env, pointRecord)
+
+			tokens, _ := parser.Tokenize(tt.input)
+			expr, _ := parser.Read(tokens)
+			if _, err := Eval(expr, env); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// specialForms names the forms evalList handles itself. The resolver
+// must not treat them as calls, since their operands are not all
+// expressions.
+var specialForms = map[string]bool{
+	"define": true, "set!": true, "lambda": true, "if": true,
+	"quote": true, "quasiquote": true, "defmacro": true,
+	"define-syntax": true, "syntax-rules": true, "delay": true,
+	"go": true, "future": true, "generator": true, "receive": true,
+	"module": true, "import": true, "define-record-type": true,
+	"cond": true, "case": true, "match": true, "when": true,
+	"unless": true, "try": true, "begin": true, "and": true, "or": true,
+	"let": true, "let*": true, "letrec": true, "let-values": true,
+	"loop": true, "recur": true, "time": true, "assert": true,
+	"string-interpolate": true,
+}
+
+// IsSpecialForm reports whether name is a special form, which Eval
+// handles itself rather than by calling a function
+func IsSpecialForm(name string) bool {
+	return specialForms[name]
+}
+
+// localRef is a variable reference resolved to a slot of the frame depth
+// levels up from where it is evaluated. Resolution is only a guess: the
+// reference checks that no nearer frame may bind the name and that the
+// slot holds it, and otherwise falls back to looking the name up.
+type localRef struct {
+	name  string
+	depth int
+	index int
+	bit   uint64 // nameBit(name)
+}
+
+func (r localRef) String() string {
+	return r.name
+}
+
+// Equal reports whether other refers to the same name
+func (r localRef) Equal(other sexpr.SExpr) bool {
+	switch o := other.(type) {
+	case localRef:
+		return r.name == o.name
+	case sexpr.Symbol:
+		return r.name == o.Name
+	}
+	return false
+}
+
+// lookup returns the referenced value as seen from env
+func (r localRef) lookup(env *Env) (sexpr.SExpr, error) {
+	e := env
+	for i := 0; i < r.depth && e != nil; i++ {
+		if e.frame.binds(r.bit) {
+			return lookupSymbol(r.name, env)
+		}
+		e = e.parent
+	}
+	if e != nil {
+		if value, ok := e.frame.at(r.index, r.name); ok {
+			return value, nil
+		}
+	}
+	return lookupSymbol(r.name, env)
+}
+
+// lambdaForm is a lambda expression whose body has been resolved, so
+// that evaluating it again does not repeat the work
+type lambdaForm struct {
+	params []sexpr.Symbol
+	rest   *sexpr.Symbol
+	body   sexpr.SExpr
+	meta   *sexpr.Map // the docstring, if any, for the functions made
+	source sexpr.List // the original (lambda ...) form
+}
+
+func (l lambdaForm) String() string {
+	return l.source.String()
+}
+
+// Equal reports whether other is the same lambda expression
+func (l lambdaForm) Equal(other sexpr.SExpr) bool {
+	switch o := other.(type) {
+	case lambdaForm:
+		return l.source.Equal(o.source)
+	case sexpr.List:
+		return l.source.Equal(o)
+	}
+	return false
+}
+
+// scope is the resolver's model of one environment frame: either the
+// names a form will bind, or a frame that already exists
+type scope struct {
+	names  []string
+	env    *Env // an existing frame, in place of names
+	parent *scope
+}
+
+// newScope returns a scope binding names, each once, in a frame nested
+// in parent
+func newScope(parent *scope, names ...string) *scope {
+	s := &scope{parent: parent}
+	for _, name := range names {
+		s.add(name)
+	}
+	return s
+}
+
+// envScope models the frames of env below the global environment, whose
+// variables are always looked up by name
+func envScope(env *Env) *scope {
+	if env == nil || env.parent == nil {
+		return nil
+	}
+	return &scope{env: env}
+}
+
+func (s *scope) add(name string) {
+	for _, n := range s.names {
+		if n == name {
+			return
+		}
+	}
+	s.names = append(s.names, name)
+}
+
+// find returns the depth and slot of the nearest binding of name
+func (s *scope) find(name string) (int, int, bool) {
+	depth := 0
+	for s != nil {
+		if s.env != nil {
+			for e := s.env; e.parent != nil; e = e.parent {
+				e.frame.mu.RLock()
+				i := e.frame.slot(name)
+				e.frame.mu.RUnlock()
+				if i >= 0 {
+					return depth, i, true
+				}
+				depth++
+			}
+			return 0, 0, false
+		}
+		for i, n := range s.names {
+			if n == name {
+				return depth, i, true
+			}
+		}
+		depth++
+		s = s.parent
+	}
+	return 0, 0, false
+}
+
+// resolver rewrites the variable references in expressions into
+// localRefs. It only descends into forms whose scoping it models and
+// leaves every other special form as it is.
+type resolver struct {
+	rt *Runtime
+}
+
+// resolveLambda parses (lambda spec [doc] body) and resolves its body in
+// a frame of the parameters nested in s
+func (r resolver) resolveLambda(list sexpr.List, s *scope) (lambdaForm, error) {
+	form, doc := splitDoc(list)
+	if len(form.Elements) != 3 {
+		return lambdaForm{}, arityError("lambda", 2, 2, len(list.Elements)-1)
+	}
+
+	spec, body, err := destructureParams(form.Elements[1], form.Elements[2], r.rt)
+	if err != nil {
+		return lambdaForm{}, err
+	}
+	params, rest, err := parseParams(spec)
+	if err != nil {
+		return lambdaForm{}, err
+	}
+
+	inner := newScope(s)
+	for _, p := range params {
+		inner.add(p.Name)
+	}
+	if rest != nil {
+		inner.add(rest.Name)
+	}
+	return lambdaForm{
+		params: params,
+		rest:   rest,
+		body:   r.expr(body, inner),
+		meta:   docMeta(doc),
+		source: list,
+	}, nil
+}
+
+// expr resolves the references in x, evaluated in a frame modeled by s
+func (r resolver) expr(x sexpr.SExpr, s *scope) sexpr.SExpr {
+	switch e := x.(type) {
+	case sexpr.Symbol:
+		if depth, index, ok := s.find(e.Name); ok {
+			return localRef{name: e.Name, depth: depth, index: index, bit: nameBit(e.Name)}
+		}
+		return e
+	case sexpr.List:
+		return r.list(e, s)
+	}
+	return x
+}
+
+func (r resolver) list(list sexpr.List, s *scope) sexpr.SExpr {
+	if len(list.Elements) == 0 {
+		return list
+	}
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || !specialForms[head.Name] {
+		return r.each(list, 0, s)
+	}
+
+	switch head.Name {
+	case "if", "begin", "and", "or", "when", "unless", "recur", "string-interpolate":
+		return r.each(list, 1, s)
+	case "define", "set!":
+		if len(list.Elements) == 3 {
+			if _, ok := list.Elements[1].(sexpr.Symbol); ok {
+				return r.each(list, 2, s)
+			}
+		}
+	case "lambda":
+		if form, err := r.resolveLambda(list, s); err == nil {
+			return form
+		}
+	case "let", "let*", "letrec", "loop":
+		if names, ok := bindingNames(list); ok {
+			return r.let(head.Name, list, names, s)
+		}
+	}
+	return list
+}
+
+// each resolves the elements of list from index start on
+func (r resolver) each(list sexpr.List, start int, s *scope) sexpr.List {
+	elems := make([]sexpr.SExpr, len(list.Elements))
+	copy(elems, list.Elements[:start])
+	for i := start; i < len(elems); i++ {
+		elems[i] = r.expr(list.Elements[i], s)
+	}
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// let resolves a let, let*, letrec or loop whose bindings are all to
+// plain names, following the frames each creates
+func (r resolver) let(form string, list sexpr.List, names []string, s *scope) sexpr.SExpr {
+	specs := list.Elements[1].(sexpr.List).Elements
+	bindings := make([]sexpr.SExpr, len(specs))
+	value := func(i int, vs *scope) {
+		pair := specs[i].(sexpr.List)
+		bindings[i] = sexpr.List{
+			Elements: []sexpr.SExpr{pair.Elements[0], r.expr(pair.Elements[1], vs)},
+			Meta:     pair.Meta,
+		}
+	}
+
+	var body *scope
+	switch form {
+	case "let":
+		for i := range specs {
+			value(i, s)
+		}
+		body = newScope(s, names...)
+	case "let*":
+		// The first value is evaluated in the empty let frame, and each
+		// later binding gets a frame of its own
+		body = newScope(s)
+		for i := range specs {
+			value(i, body)
+			if i > 0 {
+				body = newScope(body)
+			}
+			body.add(names[i])
+		}
+	case "letrec":
+		body = newScope(s, names...)
+		for i := range specs {
+			value(i, body)
+		}
+	case "loop":
+		for i := range specs {
+			value(i, s)
+		}
+		body = newScope(s, append([]string{loopMarker}, names...)...)
+	}
+
+	elems := make([]sexpr.SExpr, len(list.Elements))
+	elems[0] = list.Elements[0]
+	elems[1] = sexpr.List{Elements: bindings, Meta: list.Elements[1].(sexpr.List).Meta}
+	for i := 2; i < len(elems); i++ {
+		elems[i] = r.expr(list.Elements[i], body)
+	}
+	return sexpr.List{Elements: elems, Meta: list.Meta}
+}
+
+// bindingNames returns the names bound by a let-like form if its
+// bindings are a list of (name value) pairs with plain names
+func bindingNames(list sexpr.List) ([]string, bool) {
+	if len(list.Elements) < 3 {
+		return nil, false
+	}
+	specs, ok := list.Elements[1].(sexpr.List)
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, len(specs.Elements))
+	for i, spec := range specs.Elements {
+		pair, ok := spec.(sexpr.List)
+		if !ok || len(pair.Elements) != 2 {
+			return nil, false
+		}
+		name, ok := pair.Elements[0].(sexpr.Symbol)
+		if !ok {
+			return nil, false
+		}
+		names[i] = name.Name
+	}
+	return names, true
+}
+
+// unresolve restores the symbols and lambda forms in x, for code that
+// is passed to a macro as data
+func unresolve(x sexpr.SExpr) sexpr.SExpr {
+	u, _ := unresolved(x)
+	return u
+}
+
+// unresolved implements unresolve, reporting whether x changed so that
+// unchanged lists are not copied
+func unresolved(x sexpr.SExpr) (sexpr.SExpr, bool) {
+	switch e := x.(type) {
+	case localRef:
+		return sexpr.Symbol{Name: e.name}, true
+	case lambdaForm:
+		return e.source, true
+	case sexpr.List:
+		var elems []sexpr.SExpr
+		for i, elem := range e.Elements {
+			u, changed := unresolved(elem)
+			if changed && elems == nil {
+				elems = make([]sexpr.SExpr, len(e.Elements))
+				copy(elems, e.Elements[:i])
+			}
+			if elems != nil {
+				elems[i] = u
+			}
+		}
+		if elems == nil {
+			return e, false
+		}
+		return sexpr.List{Elements: elems, Meta: e.Meta}, true
+	}
+	return x, false
+}
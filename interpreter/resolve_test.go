@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestResolvedReferences(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"((lambda (x y) (- x y)) 5 3)", "2"},
+		{"(((lambda (x) (lambda (y) (list x y))) 1) 2)", "(1 2)"},
+		{"((lambda (x) (let ((y (+ x 1))) (let* ((z (* y 2)) (w (+ z x))) (list x y z w)))) 1)", "(1 2 4 5)"},
+		{"((lambda (n) (loop ((i 0) (acc '())) (if (< i n) (recur (+ i 1) (cons i acc)) acc))) 3)", "(2 1 0)"},
+		{"((lambda (n) (letrec ((even? (lambda (k) (if (= k 0) true (odd? (- k 1))))) (odd? (lambda (k) (if (= k 0) false (even? (- k 1)))))) (even? n))) 10)", "true"},
+		// Bindings added at run time shadow resolved references
+		{"((lambda (x) (let () (define x 2) x)) 1)", "2"},
+		{"((lambda (x) (let ((f (lambda () x))) (define x 3) (f))) 1)", "1"},
+		{"((lambda (x) (let () (eval '(define x 4) (current-environment)) x)) 1)", "4"},
+		{"((lambda (x) (begin ((lambda () (set! x 5))) x)) 1)", "5"},
+		// Closures over let frames that exist before the lambda
+		{"(let ((a 1)) (let ((b 2)) ((lambda (c) (list a b c)) 3)))", "(1 2 3)"},
+		// Macro arguments are passed as the original code
+		{"(begin (defmacro quote-it (x) (list 'quote x)) ((lambda (y) (quote-it (f y (lambda (z) z)))) 1))", "(f y (lambda (z) z))"},
+		{"((lambda (x) (match x ((a b) (+ a b)))) '(1 2))", "3"},
+		{"((lambda (x) `(x ,x)) 1)", "(x 1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			testEvalString(t, tt.input, tt.expected)
+		})
+	}
+}
+
+func TestResolveLambda(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	f := evalForms(t, env, "(let ((a 1)) (lambda (x) (lambda (y) (list a x y z))))").(sexpr.Func)
+
+	// The outer body is a lambda form whose body refers to a two frames
+	// up, x one frame up and y in its own frame; z is not bound
+	inner, ok := f.Body.(lambdaForm)
+	if !ok {
+		t.Fatalf("body is %T, want lambdaForm", f.Body)
+	}
+	call := inner.body.(sexpr.List)
+	want := []struct {
+		name         string
+		depth, index int
+	}{
+		{"a", 2, 0},
+		{"x", 1, 0},
+		{"y", 0, 0},
+	}
+	for i, w := range want {
+		ref, ok := call.Elements[i+1].(localRef)
+		if !ok || ref.name != w.name || ref.depth != w.depth || ref.index != w.index {
+			t.Errorf("reference %d = %#v, want %s at (%d, %d)", i, call.Elements[i+1], w.name, w.depth, w.index)
+		}
+	}
+	if _, ok := call.Elements[0].(sexpr.Symbol); !ok {
+		t.Errorf("global list resolved to %#v", call.Elements[0])
+	}
+	if _, ok := call.Elements[4].(sexpr.Symbol); !ok {
+		t.Errorf("undefined z resolved to %#v", call.Elements[4])
+	}
+	if inner.String() != "(lambda (y) (list a x y z))" {
+		t.Errorf("String() = %q", inner.String())
+	}
+}
+
+func TestUnresolve(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	f := evalForms(t, env, "(lambda (x) (g x (lambda (y) (h x y)) 'x))").(sexpr.Func)
+
+	got := unresolve(f.Body)
+	if _, ok := got.(sexpr.List).Elements[1].(sexpr.Symbol); !ok {
+		t.Errorf("x not restored to a symbol in %v", got)
+	}
+	if got.String() != "(g x (lambda (y) (h x y)) (quote x))" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalString(env, "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))")
+	for i := 0; i < b.N; i++ {
+		if _, err := evalString(env, "(fib 15)"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
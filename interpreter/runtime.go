@@ -0,0 +1,353 @@
+package interpreter
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Runtime holds interpreter-wide state shared by a global environment
+// and every environment derived from it
+type Runtime struct {
+	mu     sync.Mutex
+	input  *sexpr.Port
+	output *sexpr.Port
+	errors *sexpr.Port // where errors no caller can see are reported
+	random *rand.Rand  // source for random, guarded by mu
+	start  time.Time   // creation time, the zero of monotonic-millis
+	args   []string    // command-line-args, guarded by mu
+
+	modules  map[string]*Module
+	required map[string]bool // names passed to require
+
+	gensyms  atomic.Uint64 // counter for Gensym
+	maxDepth atomic.Int64  // maximum call depth, 0 for no limit
+	maxFuel  atomic.Int64  // step budget set by SetFuel, 0 for no limit
+	fuel     atomic.Int64  // steps remaining of maxFuel
+
+	maxMemory atomic.Int64 // allocation budget set by SetMaxMemory, 0 for no limit
+	memory    atomic.Int64 // bytes allocated since SetMaxMemory
+
+	policy Policy       // fixed when the runtime is created
+	engine atomic.Int32 // Engine that Eval uses
+
+	overflow atomic.Int32 // OverflowMode of integer arithmetic
+
+	actors atomic.Uint64 // counter for actor ids
+	main   *Actor        // actor for evaluation outside spawned actors
+}
+
+// Approximate sizes in bytes charged against the memory budget
+const (
+	consSize  = 32 // a pair or one element of a list
+	frameSize = 64 // an environment frame
+)
+
+// DefaultMaxDepth is the maximum depth of nested calls allowed by a new
+// runtime
+const DefaultMaxDepth = 10000
+
+// newRuntime creates a runtime reading from stdin, writing to stdout and
+// reporting errors to stderr
+func newRuntime() *Runtime {
+	r := &Runtime{
+		input:  sexpr.NewInputPort("stdin", os.Stdin),
+		output: sexpr.NewOutputPort("stdout", os.Stdout),
+		errors: sexpr.NewOutputPort("stderr", os.Stderr),
+		random: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		start:  time.Now(),
+		policy: Unrestricted,
+	}
+	r.maxDepth.Store(DefaultMaxDepth)
+	return r
+}
+
+// MaxDepth returns the maximum depth of nested function calls and macro
+// expansions, or 0 if there is no limit
+func (r *Runtime) MaxDepth() int {
+	return int(r.maxDepth.Load())
+}
+
+// SetMaxDepth sets the maximum depth of nested function calls and macro
+// expansions. Evaluation deeper than this fails with ErrDepth instead of
+// exhausting the Go stack. 0 removes the limit.
+func (r *Runtime) SetMaxDepth(n int) {
+	r.maxDepth.Store(int64(n))
+}
+
+// SetFuel limits evaluation to n further steps, where each step is the
+// evaluation of one non-empty list: a call, special form or macro use.
+// Compiled code counts only calls and loop iterations as steps.
+// Primitives that loop over their input, such as range and sort, also
+// use a step per element.
+// Once the budget is spent evaluation fails with ErrFuel, which try cannot
+// catch, until SetFuel is called again. 0 removes the limit.
+func (r *Runtime) SetFuel(n int64) {
+	r.maxFuel.Store(n)
+	r.fuel.Store(n)
+}
+
+// Fuel returns the steps remaining from the budget given to SetFuel, or
+// -1 if there is no limit
+func (r *Runtime) Fuel() int64 {
+	if r.maxFuel.Load() <= 0 {
+		return -1
+	}
+	return max(r.fuel.Load(), 0)
+}
+
+// SetMaxMemory limits the approximate number of bytes evaluation may
+// allocate from now on, counting list cells, string and byte contents and
+// environment frames. Once the budget is exceeded evaluation fails with
+// ErrMemory, which try cannot catch, until SetMaxMemory is called again.
+// 0 removes the limit.
+func (r *Runtime) SetMaxMemory(n int64) {
+	r.maxMemory.Store(n)
+	r.memory.Store(0)
+}
+
+// Memory returns the approximate number of bytes allocated since the last
+// call to SetMaxMemory
+func (r *Runtime) Memory() int64 {
+	return r.memory.Load()
+}
+
+// alloc records n bytes of allocation, failing if that exceeds the
+// memory budget
+func (r *Runtime) alloc(n int) error {
+	total := r.memory.Add(int64(n))
+	if limit := r.maxMemory.Load(); limit > 0 && total > limit {
+		return &LimitError{Limit: ErrMemory, Max: limit}
+	}
+	return nil
+}
+
+// step uses one step of fuel, failing once none is left or once the
+// memory budget has been exceeded
+func (r *Runtime) step() error {
+	if limit := r.maxFuel.Load(); limit > 0 && r.fuel.Add(-1) < 0 {
+		return &LimitError{Limit: ErrFuel, Max: limit}
+	}
+	if limit := r.maxMemory.Load(); limit > 0 && r.memory.Load() > limit {
+		return &LimitError{Limit: ErrMemory, Max: limit}
+	}
+	return nil
+}
+
+// Engine selects how Eval runs expressions
+type Engine int32
+
+const (
+	// TreeWalker evaluates expressions by walking them directly
+	TreeWalker Engine = iota
+	// BytecodeVM compiles each expression passed to Eval to bytecode and
+	// runs it on a stack machine
+	BytecodeVM
+	// ClosureCompiler compiles each expression passed to Eval to a tree
+	// of Go closures and calls them
+	ClosureCompiler
+)
+
+func (e Engine) String() string {
+	switch e {
+	case TreeWalker:
+		return "tree-walker"
+	case BytecodeVM:
+		return "bytecode-vm"
+	case ClosureCompiler:
+		return "closure-compiler"
+	}
+	return fmt.Sprintf("Engine(%d)", int32(e))
+}
+
+// Engine returns the engine Eval uses
+func (r *Runtime) Engine() Engine {
+	return Engine(r.engine.Load())
+}
+
+// SetEngine changes the engine Eval uses. Code from any engine can call
+// functions defined by the others.
+func (r *Runtime) SetEngine(e Engine) {
+	r.engine.Store(int32(e))
+}
+
+// OverflowMode selects what integer arithmetic does when a result no
+// longer fits in an int64
+type OverflowMode int32
+
+const (
+	// OverflowPromote promotes the result to a big integer
+	OverflowPromote OverflowMode = iota
+	// OverflowRaise raises an error of kind overflow-error, which try can
+	// catch
+	OverflowRaise
+)
+
+func (m OverflowMode) String() string {
+	switch m {
+	case OverflowPromote:
+		return "promote"
+	case OverflowRaise:
+		return "raise"
+	}
+	return fmt.Sprintf("OverflowMode(%d)", int32(m))
+}
+
+// OverflowMode returns what +, - and * do on int64 overflow
+func (r *Runtime) OverflowMode() OverflowMode {
+	return OverflowMode(r.overflow.Load())
+}
+
+// SetOverflowMode changes what +, - and * do on int64 overflow. Big
+// integer operands are always allowed; only a result promoted from int64
+// operands counts as an overflow.
+func (r *Runtime) SetOverflowMode(m OverflowMode) {
+	r.overflow.Store(int32(m))
+}
+
+// Policy returns the sandbox policy evaluation runs under
+func (r *Runtime) Policy() Policy {
+	return r.policy
+}
+
+// mainActor returns the actor that code not running in a spawned actor
+// receives messages as, creating it on first use
+func (r *Runtime) mainActor() *Actor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.main == nil {
+		r.main = newActor(r)
+	}
+	return r.main
+}
+
+// Input returns the current input port
+func (r *Runtime) Input() *sexpr.Port {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.input
+}
+
+// SetInput replaces the current input port
+func (r *Runtime) SetInput(port *sexpr.Port) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.input = port
+}
+
+// Output returns the current output port
+func (r *Runtime) Output() *sexpr.Port {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.output
+}
+
+// SetOutput replaces the current output port
+func (r *Runtime) SetOutput(port *sexpr.Port) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = port
+}
+
+// ErrorOutput returns the current error port, where errors that no
+// caller can receive, such as those of a go form, are reported
+func (r *Runtime) ErrorOutput() *sexpr.Port {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errors
+}
+
+// SetErrorOutput replaces the current error port
+func (r *Runtime) SetErrorOutput(port *sexpr.Port) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = port
+}
+
+// Args returns the arguments command-line-args reports
+func (r *Runtime) Args() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.args
+}
+
+// SetArgs sets the arguments command-line-args reports, typically the
+// script's arguments after its name. A new runtime has none.
+func (r *Runtime) SetArgs(args []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.args = append([]string(nil), args...)
+}
+
+// SeedRandom reseeds the runtime's random number source so that the
+// numbers random and random-float return from now on are reproducible
+func (r *Runtime) SeedRandom(seed uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.random = rand.New(rand.NewPCG(seed, seed))
+}
+
+// randomInt returns a random integer in [0, n) for n > 0
+func (r *Runtime) randomInt(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.random.Int64N(n)
+}
+
+// randomFloat returns a random float in [0, 1)
+func (r *Runtime) randomFloat() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.random.Float64()
+}
+
+// Gensym returns a fresh symbol for use in macro expansions. Symbols are
+// numbered per runtime and start with prefix.
+func (r *Runtime) Gensym(prefix string) sexpr.Symbol {
+	return sexpr.Symbol{Name: fmt.Sprintf("%s%d", prefix, r.gensyms.Add(1))}
+}
+
+// Module returns the module registered under name
+func (r *Runtime) Module(name string) (*Module, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.modules[name]
+	return m, ok
+}
+
+// AddModule registers a module, replacing any module of the same name
+func (r *Runtime) AddModule(m *Module) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.modules == nil {
+		r.modules = make(map[string]*Module)
+	}
+	r.modules[m.Name] = m
+}
+
+// markRequired records that name has been required, reporting whether
+// it was new
+func (r *Runtime) markRequired(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.required[name] {
+		return false
+	}
+	if r.required == nil {
+		r.required = make(map[string]bool)
+	}
+	r.required[name] = true
+	return true
+}
+
+// unmarkRequired forgets a require that failed so it can be retried
+func (r *Runtime) unmarkRequired(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.required, name)
+}
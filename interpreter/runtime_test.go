@@ -0,0 +1,142 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaxDepth(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		"(define (count n) (if (= n 0) 0 (+ 1 (count (- n 1)))))",
+		"(define (forever n) (+ 1 (forever n)))",
+		"(defmacro again () '(again))",
+	)
+
+	if got := env.Runtime().MaxDepth(); got != DefaultMaxDepth {
+		t.Errorf("MaxDepth() = %d, want %d", got, DefaultMaxDepth)
+	}
+
+	// Recursion within the limit succeeds
+	if result := evalForms(t, env, "(count 5000)"); result.String() != "5000" {
+		t.Errorf("got %v, want 5000", result)
+	}
+
+	for _, input := range []string{"(forever 1)", "(again)"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := evalString(env, input)
+			var le *LimitError
+			if !errors.Is(err, ErrDepth) || !errors.As(err, &le) {
+				t.Fatalf("expected depth limit error, got %v", err)
+			}
+			if le.Max != DefaultMaxDepth {
+				t.Errorf("got limit %d, want %d", le.Max, DefaultMaxDepth)
+			}
+		})
+	}
+
+	env.Runtime().SetMaxDepth(100)
+	if _, err := evalString(env, "(count 200)"); !errors.Is(err, ErrDepth) {
+		t.Errorf("expected depth limit error, got %v", err)
+	}
+	if result := evalForms(t, env, "(count 50)"); result.String() != "50" {
+		t.Errorf("got %v, want 50", result)
+	}
+
+	env.Runtime().SetMaxDepth(0)
+	if result := evalForms(t, env, "(count 20000)"); result.String() != "20000" {
+		t.Errorf("got %v, want 20000", result)
+	}
+}
+
+func TestFuel(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (count n) (if (= n 0) 0 (+ 1 (count (- n 1)))))")
+
+	if got := env.Runtime().Fuel(); got != -1 {
+		t.Errorf("Fuel() = %d, want -1", got)
+	}
+
+	env.Runtime().SetFuel(1000)
+	if result := evalForms(t, env, "(count 10)"); result.String() != "10" {
+		t.Errorf("got %v, want 10", result)
+	}
+	if got := env.Runtime().Fuel(); got <= 0 || got >= 1000 {
+		t.Errorf("Fuel() = %d, want between 0 and 1000", got)
+	}
+
+	tests := []string{
+		"(loop () (recur))",
+		"(count 1000)",
+		"(try (loop () (recur)) (catch e :caught))",
+		"(range 1000)",
+		"(iota 1000)",
+		"(make-vector 1000)",
+		"(sort (vector->list (make-vector 400 1)))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			env.Runtime().SetFuel(500)
+			_, err := evalString(env, input)
+			var le *LimitError
+			if !errors.Is(err, ErrFuel) || !errors.As(err, &le) {
+				t.Fatalf("expected fuel error, got %v", err)
+			}
+			if le.Max != 500 {
+				t.Errorf("got limit %d, want 500", le.Max)
+			}
+			if got := env.Runtime().Fuel(); got != 0 {
+				t.Errorf("Fuel() = %d, want 0", got)
+			}
+		})
+	}
+
+	env.Runtime().SetFuel(0)
+	if result := evalForms(t, env, "(count 1000)"); result.String() != "1000" {
+		t.Errorf("got %v, want 1000", result)
+	}
+}
+
+func TestMaxMemory(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (grow xs) (grow (cons 1 xs)))")
+
+	env.Runtime().SetMaxMemory(1 << 16)
+	if result := evalForms(t, env, "(list 1 2 3)"); result.String() != "(1 2 3)" {
+		t.Errorf("got %v, want (1 2 3)", result)
+	}
+	if got := env.Runtime().Memory(); got <= 0 {
+		t.Errorf("Memory() = %d, want allocation recorded", got)
+	}
+
+	tests := []string{
+		"(loop ((xs ())) (recur (cons 1 xs)))",
+		"(loop ((s \"x\")) (recur (str s s)))",
+		"(grow ())",
+		"(make-bytes 100000)",
+		"(try (loop ((xs ())) (recur (cons 1 xs))) (catch e :caught))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			env.Runtime().SetMaxMemory(1 << 16)
+			_, err := evalString(env, input)
+			var le *LimitError
+			if !errors.Is(err, ErrMemory) || !errors.As(err, &le) {
+				t.Fatalf("expected memory limit error, got %v", err)
+			}
+			if le.Max != 1<<16 {
+				t.Errorf("got limit %d, want %d", le.Max, 1<<16)
+			}
+		})
+	}
+
+	env.Runtime().SetMaxMemory(0)
+	if result := evalForms(t, env, "(bytes-length (make-bytes 100000))"); result.String() != "100000" {
+		t.Errorf("got %v, want 100000", result)
+	}
+}
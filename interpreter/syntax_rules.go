@@ -0,0 +1,603 @@
+package interpreter
+
+import (
+	"strings"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// evalDefineSyntax handles (define-syntax name transformer), where
+// transformer evaluates to a macro such as one built by syntax-rules. A
+// syntax-rules macro without a docstring is documented by the uses its
+// rules accept.
+func evalDefineSyntax(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	if len(list.Elements) != 3 {
+		return nil, evalError("", "define-syntax requires a name and a transformer")
+	}
+
+	name, ok := list.Elements[1].(sexpr.Symbol)
+	if !ok {
+		return nil, evalError("define-syntax", "name must be a symbol, got %v", list.Elements[1])
+	}
+
+	value, err := Eval(list.Elements[2], env)
+	if err != nil {
+		return nil, err
+	}
+	macro, ok := value.(sexpr.Macro)
+	if !ok {
+		return nil, typeError("define-syntax", "a transformer", value)
+	}
+
+	macro.Name = name.Name
+	if fn, ok := macro.Fn.(sexpr.Primitive); ok && fn.Meta == nil {
+		fn.Meta = docMeta(syntaxRulesDoc(name.Name, list.Elements[2]))
+		macro.Fn = fn
+	}
+	env.Define(name.Name, macro)
+	return macro, nil
+}
+
+// syntaxRulesDoc returns the uses of the macro name that a syntax-rules
+// form accepts, the pattern of each rule on its own line with name in
+// place of the macro keyword, or "" if form is not a syntax-rules form
+func syntaxRulesDoc(name string, form sexpr.SExpr) string {
+	list, ok := form.(sexpr.List)
+	if !ok || len(list.Elements) < 2 || !list.Elements[0].Equal(sexpr.Symbol{Name: "syntax-rules"}) {
+		return ""
+	}
+	args := list.Elements[1:]
+	if _, ok := args[0].(sexpr.Symbol); ok {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return ""
+	}
+
+	keyword := sexpr.Symbol{Name: name}
+	var uses []string
+	for _, r := range args[1:] {
+		parts, ok := sexpr.Elements(r)
+		if !ok || len(parts) != 2 {
+			continue
+		}
+		switch pattern := parts[0].(type) {
+		case sexpr.List:
+			if len(pattern.Elements) > 0 {
+				elems := append([]sexpr.SExpr{keyword}, pattern.Elements[1:]...)
+				uses = append(uses, sexpr.Write(sexpr.List{Elements: elems}))
+			}
+		case sexpr.Pair:
+			uses = append(uses, sexpr.Write(sexpr.Cons(keyword, pattern.Cdr)))
+		}
+	}
+	return strings.Join(uses, "\n")
+}
+
+// syntaxRules is a transformer built by syntax-rules
+type syntaxRules struct {
+	env      *Env // where the macro was defined
+	ellipsis string
+	literals map[string]bool
+	rules    []syntaxRule
+}
+
+type syntaxRule struct {
+	pattern  sexpr.SExpr
+	template sexpr.SExpr
+}
+
+// evalSyntaxRules handles (syntax-rules [ellipsis] (literals...)
+// (pattern template)...). The result is a macro that rewrites a call
+// using the first rule whose pattern matches it.
+//
+// Patterns may contain pattern variables, literals, _, nested lists and
+// vectors, dotted tails and ellipses. Symbols introduced by a template
+// that end up bound by the expansion (as lambda parameters, let
+// variables or definitions) are renamed so they cannot capture variables
+// at the call site. Other template symbols keep their names unless the
+// call site or the expansion binds them differently from the macro's
+// definition, in which case they are replaced by the value they had
+// where the macro was defined.
+func evalSyntaxRules(list sexpr.List, env *Env) (sexpr.SExpr, error) {
+	args := list.Elements[1:]
+
+	sr := &syntaxRules{env: env, ellipsis: "...", literals: map[string]bool{}}
+	if len(args) > 0 {
+		if sym, ok := args[0].(sexpr.Symbol); ok {
+			sr.ellipsis = sym.Name
+			args = args[1:]
+		}
+	}
+	if len(args) == 0 {
+		return nil, evalError("", "syntax-rules requires a literals list")
+	}
+
+	literals, ok := sexpr.Elements(args[0])
+	if !ok {
+		return nil, evalError("syntax-rules", "literals must be a list, got %v", args[0])
+	}
+	for _, lit := range literals {
+		sym, ok := lit.(sexpr.Symbol)
+		if !ok {
+			return nil, evalError("syntax-rules", "literal must be a symbol, got %v", lit)
+		}
+		sr.literals[sym.Name] = true
+	}
+
+	for _, r := range args[1:] {
+		parts, ok := sexpr.Elements(r)
+		if !ok || len(parts) != 2 {
+			return nil, evalError("syntax-rules", "rule must be (pattern template), got %v", r)
+		}
+		if _, ok := parts[0].(sexpr.Symbol); ok {
+			return nil, evalError("syntax-rules", "pattern must be a list, got %v", parts[0])
+		}
+		if _, ok := listParts(parts[0]); !ok {
+			return nil, evalError("syntax-rules", "pattern must be a list, got %v", parts[0])
+		}
+		sr.rules = append(sr.rules, syntaxRule{pattern: parts[0], template: parts[1]})
+	}
+
+	return sexpr.Macro{
+		Name: "syntax-rules",
+		Fn:   makePrimitive("syntax-rules", sr.expand),
+	}, nil
+}
+
+// expand rewrites the argument forms of a macro call
+func (sr *syntaxRules) expand(args []sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	form := sexpr.List{Elements: args}
+	for _, rule := range sr.rules {
+		pattern, _ := listParts(rule.pattern)
+		// The macro keyword in the pattern is ignored
+		pattern.elems = pattern.elems[1:]
+
+		b := matches{}
+		if !sr.matchSeq(pattern, form, b) {
+			continue
+		}
+
+		x := &expansion{sr: sr, use: env, renames: map[string]sexpr.Symbol{}, runtime: env.Runtime()}
+		expanded, err := x.instantiate(rule.template, b)
+		if err != nil {
+			return nil, err
+		}
+		return x.rename(expanded)
+	}
+	return nil, evalError("", "no syntax rule matches %v", form)
+}
+
+// match is the value bound to a pattern variable: a single form, or one
+// match per repetition for variables under an ellipsis
+type match struct {
+	form  sexpr.SExpr
+	items []matches
+	deep  bool // bound under an ellipsis
+}
+
+type matches map[string]*match
+
+// seq is a list, dotted list or vector split into its elements and tail
+type seq struct {
+	elems  []sexpr.SExpr
+	tail   sexpr.SExpr // nil for proper lists
+	vector bool
+}
+
+// listParts splits a list-like form; ok is false for other values
+func listParts(x sexpr.SExpr) (seq, bool) {
+	switch v := x.(type) {
+	case sexpr.List:
+		return seq{elems: v.Elements}, true
+	case sexpr.Vector:
+		return seq{elems: v.Elements(), vector: true}, true
+	case sexpr.Pair:
+		var s seq
+		var cur sexpr.SExpr = v
+		for {
+			switch c := cur.(type) {
+			case sexpr.Pair:
+				s.elems = append(s.elems, c.Car)
+				cur = c.Cdr
+				continue
+			case sexpr.List:
+				s.elems = append(s.elems, c.Elements...)
+			case sexpr.Nil:
+			default:
+				s.tail = c
+			}
+			return s, true
+		}
+	}
+	return seq{}, false
+}
+
+// match reports whether form matches pattern, recording pattern
+// variables in b
+func (sr *syntaxRules) match(pattern, form sexpr.SExpr, b matches) bool {
+	switch p := pattern.(type) {
+	case sexpr.Symbol:
+		switch {
+		case p.Name == "_":
+			return true
+		case sr.literals[p.Name]:
+			sym, ok := form.(sexpr.Symbol)
+			return ok && sym.Name == p.Name
+		default:
+			b[p.Name] = &match{form: form}
+			return true
+		}
+
+	case sexpr.List, sexpr.Pair, sexpr.Vector:
+		ps, _ := listParts(p)
+		fs, ok := listParts(form)
+		if !ok || ps.vector != fs.vector {
+			// () in a pattern also matches nil
+			_, isNil := form.(sexpr.Nil)
+			return isNil && !ps.vector && len(ps.elems) == 0 && ps.tail == nil
+		}
+		return sr.matchSeq(ps, form, b)
+
+	default:
+		return pattern.Equal(form)
+	}
+}
+
+// matchSeq matches a sequence pattern, which may contain one ellipsis
+// and a dotted tail, against form
+func (sr *syntaxRules) matchSeq(ps seq, form sexpr.SExpr, b matches) bool {
+	fs, ok := listParts(form)
+	if !ok {
+		return false
+	}
+
+	ellipsisAt := -1
+	for i := 1; i < len(ps.elems); i++ {
+		if sr.isEllipsis(ps.elems[i]) {
+			ellipsisAt = i - 1
+			break
+		}
+	}
+
+	if ellipsisAt < 0 {
+		if len(fs.elems) < len(ps.elems) || (ps.tail == nil && len(fs.elems) != len(ps.elems)) {
+			return false
+		}
+		for i, p := range ps.elems {
+			if !sr.match(p, fs.elems[i], b) {
+				return false
+			}
+		}
+		return sr.matchTail(ps.tail, fs, len(ps.elems), b)
+	}
+
+	before := ps.elems[:ellipsisAt]
+	repeated := ps.elems[ellipsisAt]
+	after := ps.elems[ellipsisAt+2:]
+
+	n := len(fs.elems) - len(before) - len(after)
+	if n < 0 || (ps.tail == nil && fs.tail != nil) {
+		return false
+	}
+	for i, p := range before {
+		if !sr.match(p, fs.elems[i], b) {
+			return false
+		}
+	}
+
+	var items []matches
+	for _, f := range fs.elems[len(before) : len(before)+n] {
+		item := matches{}
+		if !sr.match(repeated, f, item) {
+			return false
+		}
+		items = append(items, item)
+	}
+	for _, name := range sr.patternVars(repeated) {
+		m := &match{deep: true}
+		for _, item := range items {
+			m.items = append(m.items, matches{name: item[name]})
+		}
+		b[name] = m
+	}
+
+	for i, p := range after {
+		if !sr.match(p, fs.elems[len(before)+n+i], b) {
+			return false
+		}
+	}
+	return sr.matchTail(ps.tail, fs, len(fs.elems), b)
+}
+
+// matchTail matches the dotted tail of a pattern against whatever
+// remains of the form after its first n elements
+func (sr *syntaxRules) matchTail(tail sexpr.SExpr, fs seq, n int, b matches) bool {
+	if tail == nil {
+		return fs.tail == nil
+	}
+	var rest sexpr.SExpr = sexpr.List{Elements: fs.elems[n:]}
+	if fs.tail != nil {
+		rest = fs.tail
+		for i := len(fs.elems) - 1; i >= n; i-- {
+			rest = sexpr.Cons(fs.elems[i], rest)
+		}
+	}
+	return sr.match(tail, rest, b)
+}
+
+// patternVars lists the pattern variables in pattern
+func (sr *syntaxRules) patternVars(pattern sexpr.SExpr) []string {
+	var names []string
+	sexpr.Walk(pattern, func(x sexpr.SExpr) bool {
+		if sym, ok := x.(sexpr.Symbol); ok && sym.Name != "_" &&
+			!sr.literals[sym.Name] && !sr.isEllipsis(sym) {
+			names = append(names, sym.Name)
+		}
+		return true
+	})
+	return names
+}
+
+func (sr *syntaxRules) isEllipsis(x sexpr.SExpr) bool {
+	sym, ok := x.(sexpr.Symbol)
+	return ok && sym.Name == sr.ellipsis
+}
+
+// expansion holds the state of a single macro expansion
+type expansion struct {
+	sr      *syntaxRules
+	use     *Env // where the macro is used
+	runtime *Runtime
+	renames map[string]sexpr.Symbol // introduced name -> fresh alias
+	aliases map[string]string       // fresh alias -> introduced name
+}
+
+// instantiate fills in template with the bound pattern variables.
+// Symbols introduced by the template are replaced by fresh aliases,
+// which rename later maps back unless the expansion binds them.
+func (x *expansion) instantiate(template sexpr.SExpr, b matches) (sexpr.SExpr, error) {
+	switch t := template.(type) {
+	case sexpr.Symbol:
+		if m, ok := b[t.Name]; ok {
+			if m.deep {
+				return nil, evalError("syntax-rules", "%s used without %s", t.Name, x.sr.ellipsis)
+			}
+			return m.form, nil
+		}
+		if x.sr.isEllipsis(t) {
+			return nil, evalError("syntax-rules", "misplaced %s", t.Name)
+		}
+		return x.alias(t), nil
+
+	case sexpr.List, sexpr.Pair, sexpr.Vector:
+		ts, _ := listParts(t)
+
+		// (... ...) escapes the ellipsis
+		if len(ts.elems) == 2 && !ts.vector && ts.tail == nil && x.sr.isEllipsis(ts.elems[0]) {
+			return ts.elems[1], nil
+		}
+
+		var elems []sexpr.SExpr
+		for i := 0; i < len(ts.elems); i++ {
+			elem := ts.elems[i]
+			depth := 0
+			for i+depth+1 < len(ts.elems) && x.sr.isEllipsis(ts.elems[i+depth+1]) {
+				depth++
+			}
+			if depth > 0 {
+				expanded, err := x.instantiateEach(elem, b, depth)
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, expanded...)
+				i += depth
+				continue
+			}
+			expanded, err := x.instantiate(elem, b)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, expanded)
+		}
+
+		if ts.vector {
+			return sexpr.NewVector(elems...), nil
+		}
+		if ts.tail == nil {
+			if elems == nil {
+				elems = []sexpr.SExpr{}
+			}
+			return sexpr.List{Elements: elems}, nil
+		}
+		result, err := x.instantiate(ts.tail, b)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(elems) - 1; i >= 0; i-- {
+			result = sexpr.Cons(elems[i], result)
+		}
+		return result, nil
+
+	default:
+		return template, nil
+	}
+}
+
+// instantiateEach expands a template followed by depth ellipses once for
+// each repetition of the pattern variables it uses, flattening one level
+// per extra ellipsis
+func (x *expansion) instantiateEach(template sexpr.SExpr, b matches, depth int) ([]sexpr.SExpr, error) {
+	n := -1
+	var deep []string
+	sexpr.Walk(template, func(e sexpr.SExpr) bool {
+		if sym, ok := e.(sexpr.Symbol); ok {
+			if m, ok := b[sym.Name]; ok && m.deep {
+				deep = append(deep, sym.Name)
+			}
+		}
+		return true
+	})
+	if len(deep) == 0 {
+		return nil, evalError("syntax-rules", "%s follows a template without pattern variables", x.sr.ellipsis)
+	}
+	for _, name := range deep {
+		count := len(b[name].items)
+		if n >= 0 && count != n {
+			return nil, evalError("syntax-rules", "%s matched %d times, expected %d", name, count, n)
+		}
+		n = count
+	}
+
+	var result []sexpr.SExpr
+	for i := 0; i < n; i++ {
+		inner := matches{}
+		for name, m := range b {
+			inner[name] = m
+		}
+		for _, name := range deep {
+			inner[name] = b[name].items[i][name]
+		}
+		if depth > 1 {
+			expanded, err := x.instantiateEach(template, inner, depth-1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, expanded...)
+			continue
+		}
+		expanded, err := x.instantiate(template, inner)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expanded)
+	}
+	return result, nil
+}
+
+// alias returns the fresh name for a symbol introduced by the template
+func (x *expansion) alias(sym sexpr.Symbol) sexpr.Symbol {
+	if renamed, ok := x.renames[sym.Name]; ok {
+		return renamed
+	}
+	renamed := x.runtime.Gensym(sym.Name + "#")
+	x.renames[sym.Name] = renamed
+	if x.aliases == nil {
+		x.aliases = map[string]string{}
+	}
+	x.aliases[renamed.Name] = sym.Name
+	return renamed
+}
+
+// rename restores the original name of every alias the expansion does
+// not bind, so free template symbols refer to their usual meaning. A
+// name that would be captured by a binding at the call site or in the
+// expansion is replaced by its value where the macro was defined.
+func (x *expansion) rename(expanded sexpr.SExpr) (sexpr.SExpr, error) {
+	bound := map[string]bool{}
+	sexpr.Walk(expanded, func(e sexpr.SExpr) bool {
+		elems, ok := sexpr.Elements(e)
+		if !ok || len(elems) < 2 {
+			return true
+		}
+		head, ok := elems[0].(sexpr.Symbol)
+		if !ok {
+			return true
+		}
+		switch x.original(head.Name) {
+		case "quote":
+			return false
+		case "lambda", "defmacro":
+			x.bindParams(elems[1], bound)
+		case "define":
+			x.bindParams(elems[1], bound)
+		case "let", "let*", "letrec", "let-values", "loop":
+			bindings, _ := sexpr.Elements(elems[1])
+			for _, b := range bindings {
+				if pair, ok := sexpr.Elements(b); ok && len(pair) > 0 {
+					x.bindParams(pair[0], bound)
+				}
+			}
+		}
+		return true
+	})
+
+	result, err := sexpr.Transform(expanded, func(e sexpr.SExpr) (sexpr.SExpr, error) {
+		sym, ok := e.(sexpr.Symbol)
+		if !ok || bound[sym.Name] {
+			return e, nil
+		}
+		name, ok := x.aliases[sym.Name]
+		if !ok {
+			return e, nil
+		}
+		if x.captured(name, bound) {
+			value, err := lookupSymbol(name, x.sr.env)
+			if err != nil {
+				return nil, err
+			}
+			return sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: "quote"}, value}}, nil
+		}
+		return sexpr.Symbol{Name: name}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return x.unrenameQuoted(result), nil
+}
+
+// captured reports whether name, free in the template, would refer to a
+// different binding in the expansion than where the macro was defined
+func (x *expansion) captured(name string, bound map[string]bool) bool {
+	if specialForms[name] {
+		return false
+	}
+	defined := x.sr.env.binding(name)
+	if defined == nil {
+		return false
+	}
+	return bound[name] || x.use.binding(name) != defined
+}
+
+// unrenameQuoted restores aliases inside quoted data, which must read
+// exactly as written in the template
+func (x *expansion) unrenameQuoted(expr sexpr.SExpr) sexpr.SExpr {
+	result, _ := sexpr.Transform(expr, func(e sexpr.SExpr) (sexpr.SExpr, error) {
+		list, ok := e.(sexpr.List)
+		if !ok || len(list.Elements) != 2 {
+			return e, nil
+		}
+		head, ok := list.Elements[0].(sexpr.Symbol)
+		if !ok || head.Name != "quote" {
+			return e, nil
+		}
+		datum, _ := sexpr.Transform(list.Elements[1], func(d sexpr.SExpr) (sexpr.SExpr, error) {
+			if sym, ok := d.(sexpr.Symbol); ok {
+				if name, ok := x.aliases[sym.Name]; ok {
+					return sexpr.Symbol{Name: name}, nil
+				}
+			}
+			return d, nil
+		})
+		return sexpr.List{Elements: []sexpr.SExpr{head, datum}}, nil
+	})
+	return result
+}
+
+// bindParams marks the symbols of a parameter spec as bound
+func (x *expansion) bindParams(spec sexpr.SExpr, bound map[string]bool) {
+	sexpr.Walk(spec, func(e sexpr.SExpr) bool {
+		if sym, ok := e.(sexpr.Symbol); ok {
+			bound[sym.Name] = true
+		}
+		return true
+	})
+}
+
+// original returns the template name behind an alias
+func (x *expansion) original(name string) string {
+	if orig, ok := x.aliases[name]; ok {
+		return orig
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestSyntaxRules(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	evalForms(t, env,
+		"(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp)))))",
+		"(define-syntax my-or (syntax-rules () ((_) false) ((_ e) e) ((_ e r ...) (let ((t e)) (if t t (my-or r ...))))))",
+		"(define-syntax my-let (syntax-rules () ((_ ((n v) ...) body ...) ((lambda (n ...) body ...) v ...))))",
+		"(define-syntax for (syntax-rules (in) ((_ x in lst body ...) (map (lambda (x) body ...) lst))))",
+		"(define-syntax my-list* (syntax-rules () ((_ a . rest) (cons a (quote rest)))))",
+		"(define-syntax vec (syntax-rules () ((_ [x ...]) (list x ...))))",
+		"(define-syntax name-of (syntax-rules () ((_ e) (quote (tmp e)))))",
+		"(define-syntax dots (syntax-rules etc () ((_ x etc) (quote (x etc ...)))))",
+		"(define-syntax first (syntax-rules () ((_ x) (car x))))",
+		"(define-syntax with-car (syntax-rules () ((_ v body) (let ((v 1)) (car body)))))",
+	)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(begin (define x 1) (define y 2) (swap! x y) (list x y))", "(2 1)"},
+		// tmp introduced by swap! does not capture the caller's tmp
+		{"(begin (define tmp 1) (define other 2) (swap! tmp other) (list tmp other))", "(2 1)"},
+		{"(my-or)", "false"},
+		{"(my-or false 3)", "3"},
+		// t introduced by my-or does not capture the caller's t
+		{"(let ((t 5)) (my-or false t))", "5"},
+		{"(my-let ((a 1) (b 2)) (+ a b))", "3"},
+		{"(my-let () 7)", "7"},
+		{"(my-list* 1 2 3)", "(1 2 3)"},
+		{"(vec [1 2 3])", "(1 2 3)"},
+		{"(name-of x)", "(tmp x)"},
+		{"(dots 1 2)", "(1 2 ...)"},
+		// car in the template means the car visible where first was defined
+		{"(begin (define (f car) (first (list car 2))) (f 9))", "9"},
+		{"(let ((car cdr)) (first '(1 2)))", "1"},
+		{"(with-car car (list car))", "1"},
+		{"swap!", "#<macro swap!>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := evalForms(t, env, tt.input)
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSyntaxRulesNested(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	evalForms(t, env,
+		"(define-syntax flat (syntax-rules () ((_ (a b ...) ...) (quote (a ... b ... ...)))))",
+	)
+	result := evalForms(t, env, "(flat (1 2 3) (4 5))")
+	if result.String() != "(1 4 2 3 5)" {
+		t.Errorf("got %v, want (1 4 2 3 5)", result)
+	}
+}
+
+func TestSyntaxRulesLiterals(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	evalForms(t, env,
+		"(define-syntax arrow (syntax-rules (=>) ((_ a => b) (list a b)) ((_ a b c) (quote no))))",
+	)
+	for input, expected := range map[string]string{
+		"(arrow 1 => 2)": "(1 2)",
+		"(arrow 1 -> 2)": "no",
+	} {
+		if result := evalForms(t, env, input); result.String() != expected {
+			t.Errorf("%s: got %v, want %s", input, result, expected)
+		}
+	}
+}
+
+func TestSyntaxRulesErrors(t *testing.T) {
+	tests := []string{
+		"(begin (define-syntax one (syntax-rules () ((_ a) a))) (one))",
+		"(begin (define-syntax bad (syntax-rules () ((_ a ...) a))) (bad 1 2))",
+		"(define-syntax bad 1)",
+		"(syntax-rules (1) ((_) 1))",
+		"(syntax-rules () (_ 1))",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			testEvalError(t, input)
+		})
+	}
+}
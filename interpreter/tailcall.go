@@ -0,0 +1,183 @@
+package interpreter
+
+import (
+	"github.com/zylisp/lang/sexpr"
+)
+
+// tailCall is the value of a function call in tail position of a
+// function body. The call is not made where it appears but passed back
+// to applyFunc, which makes it in place of the call it is running.
+type tailCall struct {
+	fn   sexpr.Func
+	args []sexpr.SExpr
+	name string     // the function called, for stack traces
+	form sexpr.List // the form that made the call
+}
+
+func (c tailCall) String() string {
+	return "#<tail-call>"
+}
+
+func (c tailCall) Equal(other sexpr.SExpr) bool {
+	return false
+}
+
+// evalTail evaluates body, the body of a function, like Eval, except that
+// a call of a function in tail position is returned as a tailCall
+// instead of being made. Tail position is followed through if, cond,
+// when, unless, begin, and, or, let, let*, letrec and macro uses; calls
+// anywhere else, and in code run by another engine, are made as usual.
+func evalTail(body sexpr.SExpr, env *Env) (sexpr.SExpr, error) {
+	expr := body
+	for {
+		list, ok := expr.(sexpr.List)
+		if !ok || len(list.Elements) == 0 || !isTailForm(list) ||
+			(!env.direct && env.runtime.Engine() != TreeWalker) {
+			return Eval(expr, env)
+		}
+		if err := env.runtime.step(); err != nil {
+			return nil, traceForm(err, list)
+		}
+
+		next, nextEnv, result, err := tailStep(list, env)
+		if err != nil {
+			return nil, traceForm(err, list)
+		}
+		if next == nil {
+			return result, nil
+		}
+		expr, env = next, nextEnv
+	}
+}
+
+// isTailForm reports whether evalTail handles list itself: a call, or a
+// special form with an expression in tail position
+func isTailForm(list sexpr.List) bool {
+	head, ok := list.Elements[0].(sexpr.Symbol)
+	if !ok || !specialForms[head.Name] {
+		return true
+	}
+	switch head.Name {
+	case "if", "cond", "when", "unless", "begin", "and", "or", "let", "let*", "letrec":
+		return true
+	}
+	return false
+}
+
+// tailStep evaluates list up to its expression in tail position, which
+// it returns with the environment to evaluate it in. If list has no
+// such expression left to evaluate, next is nil and result is its value.
+func tailStep(list sexpr.List, env *Env) (next sexpr.SExpr, nextEnv *Env, result sexpr.SExpr, err error) {
+	elems := list.Elements
+	if head, ok := elems[0].(sexpr.Symbol); ok && specialForms[head.Name] {
+		switch head.Name {
+		case "if":
+			if len(elems) != 4 {
+				return nil, nil, nil, arityError("if", 3, 3, len(elems)-1)
+			}
+			test, err := Eval(elems[1], env)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if isTruthy(test) {
+				return elems[2], env, nil, nil
+			}
+			return elems[3], env, nil, nil
+
+		case "cond":
+			test, body, fn, err := selectClause(list, env)
+			switch {
+			case err != nil:
+				return nil, nil, nil, err
+			case fn != nil:
+				return tailApply(list, "lambda", fn, []sexpr.SExpr{test}, env)
+			case len(body) == 0:
+				return nil, nil, test, nil
+			}
+			return tailBody(body, env)
+
+		case "when", "unless":
+			if len(elems) < 2 {
+				return nil, nil, nil, evalError("", "%s requires a test", head.Name)
+			}
+			test, err := Eval(elems[1], env)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if isTruthy(test) != (head.Name == "when") {
+				return nil, nil, sexpr.Nil{}, nil
+			}
+			return tailBody(elems[2:], env)
+
+		case "begin":
+			return tailBody(elems[1:], env)
+
+		case "and", "or":
+			want := head.Name == "or"
+			if len(elems) == 1 {
+				return nil, nil, sexpr.Bool{Value: !want}, nil
+			}
+			for _, expr := range elems[1 : len(elems)-1] {
+				value, err := Eval(expr, env)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				if isTruthy(value) == want {
+					return nil, nil, value, nil
+				}
+			}
+			return elems[len(elems)-1], env, nil, nil
+
+		case "let", "let*", "letrec":
+			var letEnv *Env
+			switch head.Name {
+			case "let":
+				letEnv, err = bindLet(list, env)
+			case "let*":
+				letEnv, err = bindLetStar(list, env)
+			default:
+				letEnv, err = bindLetrec(list, env)
+			}
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return tailBody(elems[2:], letEnv)
+		}
+	}
+
+	fn, err := Eval(elems[0], env)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if macro, ok := fn.(sexpr.Macro); ok {
+		expanded, deeper, err := expandCall(macro, list, env)
+		return expanded, deeper, nil, err
+	}
+	args, err := evalEach(elems[1:], env)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tailApply(list, callName(list), fn, args, env)
+}
+
+// tailApply calls fn, named name, with args for form, returning a call
+// of a function as a tailCall
+func tailApply(form sexpr.List, name string, fn sexpr.SExpr, args []sexpr.SExpr, env *Env) (sexpr.SExpr, *Env, sexpr.SExpr, error) {
+	if f, ok := fn.(sexpr.Func); ok {
+		return nil, nil, tailCall{fn: f, args: args, name: name, form: form}, nil
+	}
+	result, err := apply(fn, args, env)
+	return nil, nil, result, err
+}
+
+// tailBody evaluates all but the last of exprs and returns the last to
+// be evaluated in tail position. An empty body is nil.
+func tailBody(exprs []sexpr.SExpr, env *Env) (sexpr.SExpr, *Env, sexpr.SExpr, error) {
+	if len(exprs) == 0 {
+		return nil, nil, sexpr.Nil{}, nil
+	}
+	if _, err := evalBody(exprs[:len(exprs)-1], env); err != nil {
+		return nil, nil, nil, err
+	}
+	return exprs[len(exprs)-1], env, nil, nil
+}
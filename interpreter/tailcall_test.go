@@ -0,0 +1,142 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		expected string
+	}{
+		{"mutual recursion", []string{
+			"(define (even? n) (if (= n 0) true (odd? (- n 1))))",
+			"(define (odd? n) (if (= n 0) false (even? (- n 1))))",
+			"(even? 10001)",
+		}, "false"},
+		{"through cond", []string{
+			"(define (ping n) (cond ((= n 0) 'done) (else (pong (- n 1)))))",
+			"(define (pong n) (cond ((= n 0) 'done) ((> n 0) (ping (- n 1)))))",
+			"(ping 10000)",
+		}, "done"},
+		{"through cond =>", []string{
+			"(define (f n) (cond ((= n 0) 'done) ((- n 1) => f)))",
+			"(f 10000)",
+		}, "done"},
+		{"through when and unless", []string{
+			"(define (f n) (when (>= n 0) (unless (= n 0) (f (- n 1)))))",
+			"(f 10000)",
+		}, "nil"},
+		{"through let, let* and letrec", []string{
+			"(define (f n) (let ((m (- n 1))) (let* ((k m)) (letrec ((j k)) (if (< j 0) 'done (f j))))))",
+			"(f 10000)",
+		}, "done"},
+		{"through begin, and and or", []string{
+			"(define (f n) (begin 1 (or (= n 0) (and true (f (- n 1))))))",
+			"(f 10000)",
+		}, "true"},
+		{"through a macro", []string{
+			"(defmacro unless-zero (n body) (list 'if (list '= n 0) ''done body))",
+			"(define (f n) (unless-zero n (f (- n 1))))",
+			"(f 10000)",
+		}, "done"},
+		{"local function", []string{
+			"(define (count n) (letrec ((iter (lambda (i acc) (if (= i 0) acc (iter (- i 1) (+ acc 1)))))) (iter n 0)))",
+			"(count 10000)",
+		}, "10000"},
+		{"primitive in tail position", []string{
+			"(define (f n) (if (= n 0) (list n) (f (- n 1))))",
+			"(f 10)",
+		}, "(0)"},
+		{"deep self recursion", []string{
+			"(define (f n acc) (if (= n 0) acc (f (- n 1) (+ acc 1))))",
+			"(f 200000 0)",
+		}, "200000"},
+		{"deep mutual recursion", []string{
+			"(define (even? n) (if (= n 0) true (odd? (- n 1))))",
+			"(define (odd? n) (if (= n 0) false (even? (- n 1))))",
+			"(even? 200000)",
+		}, "true"},
+	}
+
+	for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+		for _, tt := range tests {
+			t.Run(engine.String()+"/"+tt.name, func(t *testing.T) {
+				env := NewEnv(nil)
+				LoadPrimitives(env)
+				env.Runtime().SetEngine(engine)
+				// Tail calls must not count towards the depth limit
+				env.Runtime().SetMaxDepth(100)
+				if got := evalForms(t, env, tt.inputs...); got.String() != tt.expected {
+					t.Errorf("got %v, want %s", got, tt.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestNonTailCallsNest(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		"(define (f n) (if (= n 0) 0 (+ 1 (g (- n 1)))))",
+		"(define (g n) (f n))",
+	)
+
+	env.Runtime().SetMaxDepth(100)
+	if _, err := evalString(env, "(f 200)"); !errors.Is(err, ErrDepth) {
+		t.Errorf("expected ErrDepth, got %v", err)
+	}
+}
+
+func TestTailCallLimits(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env,
+		"(define (ping) (pong))",
+		"(define (pong) (ping))",
+	)
+
+	env.Runtime().SetFuel(1000)
+	if _, err := evalString(env, "(ping)"); !errors.Is(err, ErrFuel) {
+		t.Errorf("expected ErrFuel, got %v", err)
+	}
+	env.Runtime().SetFuel(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	forms := evalForms(t, env, "'(ping)")
+	if _, err := EvalContext(ctx, forms, env); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestTailCallErrors(t *testing.T) {
+	for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+		t.Run(engine.String(), func(t *testing.T) {
+			env := NewEnv(nil)
+			LoadPrimitives(env)
+			env.Runtime().SetEngine(engine)
+			evalForms(t, env,
+				"(define (f x) (g x))",
+				"(define (g x) (h x 1))",
+				"(define (h x) x)",
+			)
+
+			_, err := evalString(env, "(f 1)")
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			// g's frame was replaced by the call of h
+			expected := "requires 1 argument, got 2\n  in h\n  called from f"
+			if !strings.HasPrefix(err.Error(), expected) {
+				t.Errorf("got:\n%v\nwant:\n%s", err, expected)
+			}
+		})
+	}
+}
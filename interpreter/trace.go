@@ -0,0 +1,121 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// maxTraceFrames is the number of frames StackError prints before
+// eliding the middle of a long trace
+const maxTraceFrames = 20
+
+// Frame is one call in a stack trace: the function called and, when
+// the source position is known, the form inside it that was running
+type Frame struct {
+	Name string
+	Pos  *sexpr.Position
+}
+
+// StackError is an evaluation error annotated with the zylisp calls that
+// were active when it happened, innermost first. Positions are only
+// known for code read with parser.ReadSource, such as loaded files.
+type StackError struct {
+	Err    error
+	Frames []Frame
+	// Pos is the position of the innermost form known to have failed
+	// outside every frame, such as the top-level call
+	Pos *sexpr.Position
+}
+
+func (e *StackError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+
+	for i, f := range e.Frames {
+		if len(e.Frames) > maxTraceFrames && i == maxTraceFrames/2 {
+			fmt.Fprintf(&b, "\n  ... %d more calls", len(e.Frames)-maxTraceFrames)
+		}
+		if len(e.Frames) > maxTraceFrames && i >= maxTraceFrames/2 && i < len(e.Frames)-maxTraceFrames/2 {
+			continue
+		}
+
+		if i == 0 {
+			b.WriteString("\n  in ")
+		} else {
+			b.WriteString("\n  called from ")
+		}
+		b.WriteString(f.Name)
+		if f.Pos != nil {
+			b.WriteString(" at " + f.Pos.String())
+		}
+	}
+
+	if e.Pos != nil {
+		if len(e.Frames) > 0 {
+			b.WriteString("\n  called from " + e.Pos.String())
+		} else {
+			b.WriteString("\n  at " + e.Pos.String())
+		}
+	}
+	return b.String()
+}
+
+func (e *StackError) Unwrap() error {
+	return e.Err
+}
+
+// traceForm records the position of a form whose evaluation failed. The
+// innermost form with a known position is kept as the location of the
+// failure in the current frame.
+func traceForm(err error, form sexpr.SExpr) error {
+	var esc *escape
+	if errors.As(err, &esc) {
+		return err
+	}
+
+	pos, hasPos := sexpr.PositionOf(form)
+
+	var located interface{ location() *Location }
+	if errors.As(err, &located) {
+		if loc := located.location(); loc.Expr == nil {
+			loc.Expr = form
+			if hasPos {
+				loc.Pos = &pos
+			}
+		}
+	}
+
+	se := stackError(&err)
+	if se.Pos == nil && hasPos {
+		se.Pos = &pos
+	}
+	return err
+}
+
+// traceCall records that the failure happened inside a call to the
+// function named name
+func traceCall(err error, name string) error {
+	var esc *escape
+	if errors.As(err, &esc) {
+		return err
+	}
+
+	se := stackError(&err)
+	se.Frames = append(se.Frames, Frame{Name: name, Pos: se.Pos})
+	se.Pos = nil
+	return err
+}
+
+// stackError returns the StackError in *err, wrapping *err in a new one
+// if there is none
+func stackError(err *error) *StackError {
+	var se *StackError
+	if !errors.As(*err, &se) {
+		se = &StackError{Err: *err}
+		*err = se
+	}
+	return se
+}
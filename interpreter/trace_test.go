@@ -0,0 +1,95 @@
+package interpreter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+func TestStackTrace(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSource(t, dir, "foo.zy", `(define (square x)
+  (* x (car x)))
+
+(define (main)
+  (+ 1 (square 5)))
+
+(main)
+`)
+
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+
+	_, err := LoadFile(path, env)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var se *StackError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected StackError, got %T: %v", err, err)
+	}
+
+	expected := strings.Join([]string{
+		"car: expected list, got 5",
+		"  in square at " + path + ":2:8",
+		"  called from main at " + path + ":5:8",
+		"  called from " + path + ":7:1",
+	}, "\n")
+	if err.Error() != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", err, expected)
+	}
+}
+
+func TestStackTraceWithoutPositions(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (f x) (g x))", "(define (g x) (car x))")
+
+	_, err := evalString(env, "(f 1)")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.HasSuffix(err.Error(), "\n  in g\n  called from f") {
+		t.Errorf("unexpected trace:\n%s", err)
+	}
+
+	// Errors caught by try carry only the message
+	result := evalForms(t, env, "(try (f 1) (catch e (error-message e)))")
+	if strings.Contains(result.String(), "called from") {
+		t.Errorf("caught error includes trace: %v", result)
+	}
+}
+
+func TestStackTraceElidesDeepRecursion(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (down n) (if (= n 0) (car n) (+ 1 (down (- n 1)))))")
+
+	_, err := evalString(env, "(down 100)")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "... 81 more calls") {
+		t.Errorf("trace was not elided:\n%s", err)
+	}
+	if lines := strings.Count(err.Error(), "\n"); lines != maxTraceFrames+1 {
+		t.Errorf("got %d trace lines, want %d", lines, maxTraceFrames+1)
+	}
+}
+
+// evalString evaluates a single form, returning any error
+func evalString(env *Env, input string) (sexpr.SExpr, error) {
+	tokens, err := parser.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := parser.Read(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return Eval(expr, env)
+}
@@ -0,0 +1,405 @@
+package interpreter
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// activation is a call of compiled code waiting for a compiled function
+// it called to return
+type activation struct {
+	code *Code
+	ip   int // where to continue
+	call int // offset of the call instruction
+	env  *Env
+	tail site // the last tail call made in place of the call's frame
+}
+
+// site is the call instruction at offset at of code, or a call made
+// by a form evaluated directly
+type site struct {
+	code *Code
+	at   int
+	call *tailCall // the call, if code is nil
+}
+
+// globalRef is a reference to a variable the compiler found no local
+// binding for. It remembers the slot of the global frame that held the
+// variable, so that later lookups that reach the global frame without
+// passing a frame that may bind the name go straight to the slot.
+type globalRef struct {
+	name string
+	bit  uint64 // nameBit(name)
+	slot atomic.Int32
+}
+
+func newGlobalRef(name string) *globalRef {
+	r := &globalRef{name: name, bit: nameBit(name)}
+	r.slot.Store(-1)
+	return r
+}
+
+func (r *globalRef) String() string {
+	return r.name
+}
+
+// Equal reports whether other refers to the same name
+func (r *globalRef) Equal(other sexpr.SExpr) bool {
+	switch o := other.(type) {
+	case *globalRef:
+		return r.name == o.name
+	case sexpr.Symbol:
+		return r.name == o.Name
+	}
+	return false
+}
+
+// lookup returns the referenced value as seen from env
+func (r *globalRef) lookup(env *Env) (sexpr.SExpr, error) {
+	e := env
+	for ; e.parent != nil; e = e.parent {
+		if e.frame.binds(r.bit) {
+			return lookupSymbol(r.name, env)
+		}
+	}
+	if value, ok := e.frame.at(int(r.slot.Load()), r.name); ok {
+		return value, nil
+	}
+	if i := e.frame.find(r.name); i >= 0 {
+		r.slot.Store(int32(i))
+	}
+	return lookupSymbol(r.name, env)
+}
+
+// run executes c in env on the virtual machine. Calls from compiled code
+// to compiled functions continue in the same loop instead of recursing,
+// though they still count toward the maximum depth. A call in tail
+// position of a function replaces the function's activation, so tail
+// calls run in constant space; the frames they replace are missing from
+// stack traces.
+func (c *Code) run(env *Env) (sexpr.SExpr, error) {
+	var stack []sexpr.SExpr
+	var calls []activation
+	var tail site
+	code, ip := c, 0
+
+	push := func(v sexpr.SExpr) {
+		stack = append(stack, v)
+	}
+	pop := func() sexpr.SExpr {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	popN := func(n int) []sexpr.SExpr {
+		if n == 0 {
+			return nil
+		}
+		vs := make([]sexpr.SExpr, n)
+		copy(vs, stack[len(stack)-n:])
+		stack = stack[:len(stack)-n]
+		return vs
+	}
+
+	for {
+		at := ip
+		op := opcode(code.ops[ip])
+		ip += 1 + 2*opInfo[op].operands
+		var err error
+
+		switch op {
+		case opConst:
+			push(code.consts[code.arg(at+1)])
+
+		case opGlobal:
+			var v sexpr.SExpr
+			if v, err = code.consts[code.arg(at+1)].(*globalRef).lookup(env); err == nil {
+				push(v)
+			}
+
+		case opLocal:
+			var v sexpr.SExpr
+			if v, err = code.consts[code.arg(at+1)].(localRef).lookup(env); err == nil {
+				push(v)
+			}
+
+		case opDefine:
+			env.Define(code.consts[code.arg(at+1)].(sexpr.Symbol).Name, stack[len(stack)-1])
+
+		case opSet:
+			name := code.consts[code.arg(at+1)].(sexpr.Symbol).Name
+			if err = env.Set(name, stack[len(stack)-1]); err != nil {
+				err = fmt.Errorf("set!: %w", err)
+			}
+
+		case opPop:
+			pop()
+
+		case opJump:
+			ip = code.arg(at + 1)
+
+		case opJumpIfFalse:
+			if !isTruthy(pop()) {
+				ip = code.arg(at + 1)
+			}
+
+		case opJumpIfTrue:
+			if isTruthy(pop()) {
+				ip = code.arg(at + 1)
+			}
+
+		case opAnd:
+			if !isTruthy(stack[len(stack)-1]) {
+				ip = code.arg(at + 1)
+			} else {
+				pop()
+			}
+
+		case opOr:
+			if isTruthy(stack[len(stack)-1]) {
+				ip = code.arg(at + 1)
+			} else {
+				pop()
+			}
+
+		case opClosure:
+			l := code.lambdas[code.arg(at+1)]
+			push(sexpr.Func{Params: l.params, Rest: l.rest, Body: l.body, Env: env, Meta: l.meta})
+
+		case opMacro:
+			macro, ok := stack[len(stack)-1].(sexpr.Macro)
+			if !ok {
+				break
+			}
+			use := code.consts[code.arg(at+1)].(sexpr.List)
+			args := append([]sexpr.SExpr{}, use.Elements[1:]...)
+			var v sexpr.SExpr
+			if v, err = expandMacro(macro, args, env); err != nil {
+				break
+			}
+			// Count the expansion as a call so that a macro that expands
+			// to itself hits the depth limit
+			var deeper *Env
+			if deeper, err = env.deeper(); err != nil {
+				break
+			}
+			// An expansion in tail position of a function continues in
+			// its place, returning from the function when it is done
+			if len(calls) > 0 && code.returnsAt(code.arg(at+3)) {
+				if expanded, cerr := Compile(v, deeper); cerr == nil {
+					pop()
+					code, ip, env = expanded, 0, deeper
+					continue
+				}
+			}
+			if v, err = Eval(v, deeper); err == nil {
+				stack[len(stack)-1] = v
+				ip = code.arg(at + 3)
+			}
+
+		case opCall:
+			n := code.arg(at + 1)
+			fn := stack[len(stack)-n-1]
+			if err = env.runtime.step(); err != nil {
+				break
+			}
+			f, isFunc := fn.(sexpr.Func)
+			if body, ok := f.Body.(*Code); isFunc && ok {
+				// The arguments are copied into the new frame, so they
+				// can be bound straight from the stack
+				args := stack[len(stack)-n:]
+				stack = stack[:len(stack)-n-1]
+				// A tail call is made from the caller of the function
+				// making it, which then waits for the new call instead
+				isTail := len(calls) > 0 && code.returnsAt(ip)
+				caller := env
+				if isTail {
+					caller = calls[len(calls)-1].env
+				}
+				var funcEnv *Env
+				if funcEnv, err = callEnv(f, args, caller); err != nil {
+					err = traceCall(err, code.callName(at))
+					if isTail {
+						// The failed call has replaced the function's frame
+						tail = site{}
+					}
+					break
+				}
+				if isTail {
+					tail = site{code: code, at: at}
+				} else {
+					calls = append(calls, activation{code: code, ip: ip, call: at, env: env, tail: tail})
+					tail = site{}
+				}
+				code, ip, env = body, 0, funcEnv
+				continue
+			}
+			args := popN(n)
+			pop()
+			var v sexpr.SExpr
+			if v, err = apply(fn, args, env); err != nil {
+				if isFunc {
+					err = traceCall(err, code.callName(at))
+				}
+				break
+			}
+			push(v)
+
+		case opReturn:
+			v := pop()
+			if len(calls) == 0 {
+				return v, nil
+			}
+			if _, ok := v.(recurSignal); ok {
+				err = evalError("recur", "cannot cross a function boundary")
+				break
+			}
+			a := calls[len(calls)-1]
+			calls = calls[:len(calls)-1]
+			code, ip, env, tail = a.code, a.ip, a.env, a.tail
+			push(v)
+
+		case opEval:
+			view := *env
+			view.direct = true
+			var v sexpr.SExpr
+			if len(calls) == 0 || !code.returnsAt(ip) {
+				if v, err = Eval(code.consts[code.arg(at+1)], &view); err == nil {
+					push(v)
+				}
+				break
+			}
+			// A form in tail position may end with a tail call, which
+			// replaces the function's activation like opCall's
+			if v, err = evalTail(code.consts[code.arg(at+1)], &view); err != nil {
+				break
+			}
+			call, ok := v.(tailCall)
+			if !ok {
+				push(v)
+				break
+			}
+			caller := calls[len(calls)-1].env
+			if body, ok := call.fn.Body.(*Code); ok {
+				var funcEnv *Env
+				if funcEnv, err = callEnv(call.fn, call.args, caller); err != nil {
+					err = traceForm(traceCall(err, call.name), call.form)
+					break
+				}
+				tail = site{call: &call}
+				code, ip, env = body, 0, funcEnv
+				continue
+			}
+			if v, err = applyFunc(call.fn, call.args, caller); err != nil {
+				err = traceForm(traceCall(err, call.name), call.form)
+				break
+			}
+			push(v)
+
+		case opLet:
+			k, n := code.arg(at+1), code.arg(at+3)
+			letEnv := newEnv(env, n)
+			for i, v := range popN(n) {
+				letEnv.Define(code.consts[k+i].(sexpr.Symbol).Name, v)
+			}
+			env = letEnv
+
+		case opLeave:
+			for n := code.arg(at + 1); n > 0; n-- {
+				env = env.parent
+			}
+
+		case opLoop:
+			if err = env.interrupted(); err != nil {
+				break
+			}
+			if err = env.runtime.step(); err != nil {
+				break
+			}
+			// A fresh frame per iteration keeps closures created in one
+			// iteration from seeing the next iteration's values
+			k, n := code.arg(at+1), code.arg(at+3)
+			loopEnv := newEnv(env, n+1)
+			loopEnv.Define(loopMarker, sexpr.Bool{Value: true})
+			for i, v := range popN(n) {
+				loopEnv.Define(code.consts[k+i].(sexpr.Symbol).Name, v)
+			}
+			env = loopEnv
+
+		case opLoopNext:
+			env = env.parent
+			recur, ok := stack[len(stack)-1].(recurSignal)
+			if !ok {
+				break
+			}
+			if n := code.arg(at + 1); len(recur.args) != n {
+				err = evalError("recur", "loop has %d bindings, got %d values", n, len(recur.args))
+				break
+			}
+			pop()
+			stack = append(stack, recur.args...)
+			ip = code.arg(at + 3)
+
+		case opLoopCheck:
+			if _, err = env.Lookup(loopMarker); err != nil {
+				err = evalError("recur", "not inside a loop")
+			}
+
+		case opRecur:
+			push(recurSignal{args: popN(code.arg(at + 1))})
+
+		default:
+			err = evalError("", "invalid opcode %d", op)
+		}
+
+		if err != nil {
+			return nil, unwind(err, code, at, tail, calls)
+		}
+	}
+}
+
+// returnsAt reports whether the instructions from offset ip of c do
+// nothing but return the top value, leaving any frames entered
+func (c *Code) returnsAt(ip int) bool {
+	for {
+		switch opcode(c.ops[ip]) {
+		case opReturn:
+			return true
+		case opJump:
+			ip = c.arg(ip + 1)
+		case opLeave:
+			ip += 1 + 2*opInfo[opLeave].operands
+		default:
+			return false
+		}
+	}
+}
+
+// unwind annotates err, raised by the instruction at offset at of code
+// in a frame last replaced by the tail call at tail, with the forms and
+// calls it passes through on its way out of run
+func unwind(err error, code *Code, at int, tail site, calls []activation) error {
+	err = code.trace(err, at)
+	err = tail.trace(err)
+	for i := len(calls) - 1; i >= 0; i-- {
+		a := calls[i]
+		err = traceCall(err, a.code.callName(a.call))
+		err = a.code.trace(err, a.call)
+		err = a.tail.trace(err)
+	}
+	return err
+}
+
+// trace annotates err, raised in the function called at s, with the call,
+// as applyFunc does for tail calls
+func (s site) trace(err error) error {
+	switch {
+	case s.code != nil:
+		return s.code.trace(traceCall(err, s.code.callName(s.at)), s.at)
+	case s.call != nil:
+		return traceForm(traceCall(err, s.call.name), s.call.form)
+	}
+	return err
+}
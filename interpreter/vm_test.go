@@ -0,0 +1,192 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// newVMEnv returns a global environment whose runtime uses the VM
+func newVMEnv() *Env {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	env.Runtime().SetEngine(BytecodeVM)
+	return env
+}
+
+// engineTests are run by each compiling engine and checked against the
+// tree walker
+var engineTests = []struct {
+	name     string
+	inputs   []string
+	expected string
+}{
+	{"arithmetic", []string{"(+ 1 (* 2 3))"}, "7"},
+	{"if", []string{"(if (< 1 2) 'yes 'no)"}, "yes"},
+	{"define and call", []string{"(define (sq x) (* x x))", "(sq 7)"}, "49"},
+	{"recursion", []string{"(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))", "(fib 15)"}, "610"},
+	{"closures", []string{"(define (adder n) (lambda (x) (+ x n)))", "((adder 3) 4)"}, "7"},
+	{"rest parameters", []string{"((lambda (a . rest) (list a rest)) 1 2 3)"}, "(1 (2 3))"},
+	{"destructuring parameters", []string{"((lambda ((a b)) (+ a b)) '(1 2))"}, "3"},
+	{"set!", []string{"(define n 1)", "(define (bump) (set! n (+ n 1)))", "(bump)", "(bump)", "n"}, "3"},
+	{"and or", []string{"(list (and 1 2) (and 1 false 2) (or false 3) (or false false) (and) (or))"}, "(2 false 3 false true false)"},
+	{"when unless", []string{"(list (when true 1 2) (when false 1) (unless false 3) (unless true 4))"}, "(2 nil 3 nil)"},
+	{"cond", []string{"(define (sign n) (cond ((< n 0) 'neg) ((= n 0) 'zero) (else 'pos)))", "(list (sign -2) (sign 0) (sign 5))"}, "(neg zero pos)"},
+	{"cond test only", []string{"(cond (false 1) (2) (else 3))"}, "2"},
+	{"cond arrow", []string{"(cond ((+ 1 1) => (lambda (x) (* x 10))))"}, "20"},
+	{"let", []string{"(let ((x 1) (y 2)) (let ((x y) (y x)) (list x y)))"}, "(2 1)"},
+	{"let*", []string{"(let* ((x 1) (y (+ x 1)) (z (* y 2))) (list x y z))"}, "(1 2 4)"},
+	{"letrec", []string{"(letrec ((ev? (lambda (n) (if (= n 0) true (od? (- n 1))))) (od? (lambda (n) (if (= n 0) false (ev? (- n 1)))))) (ev? 10))"}, "true"},
+	{"loop", []string{"(loop ((i 0) (acc '())) (if (< i 3) (recur (+ i 1) (cons i acc)) acc))"}, "(2 1 0)"},
+	{"loop closures", []string{"(define fs (loop ((i 0) (fs '())) (if (< i 3) (recur (+ i 1) (cons (lambda () i) fs)) fs)))", "(list ((car fs)) ((car (cdr fs))))"}, "(2 1)"},
+	{"nested loops", []string{"(loop ((i 0) (n 0)) (if (< i 3) (recur (+ i 1) (loop ((j 0) (n n)) (if (< j 2) (recur (+ j 1) (+ n 1)) n))) n))"}, "6"},
+	{"macros", []string{"(defmacro twice (e) (list 'begin e e))", "(define n 0)", "(define (f) (twice (set! n (+ n 1))))", "(f)", "n"}, "2"},
+	{"macro defined after use", []string{"(define (f x) (konst (a b)))", "(defmacro konst (e) (list 'quote e))", "(f 0)"}, "(a b)"},
+	{"hygienic syntax-rules", []string{"(define-syntax first (syntax-rules () ((_ x) (car x))))", "(define (f car) (first (list car 2)))", "(f 9)"}, "9"},
+	{"fallback forms", []string{"(define (f x) (match x ((a b) (+ a b)) (_ 0)))", "(list (f '(1 2)) (f 5))"}, "(3 0)"},
+	{"try", []string{"(try (car 1) (catch e 'caught))"}, "caught"},
+	{"quasiquote", []string{"((lambda (x) `(a ,x ,@(list x x))) 1)"}, "(a 1 1 1)"},
+	{"higher order primitives", []string{"(apply (lambda (x y) (* x y)) '(6 7))"}, "42"},
+	{"internal define", []string{"(define (f) (define y 2) (* y 3))", "(f)"}, "6"},
+	{"shadowed by define", []string{"((lambda (x) (let () (define x 2) x)) 1)"}, "2"},
+}
+
+func TestBytecodeVM(t *testing.T) {
+	for _, tt := range engineTests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newVMEnv()
+			vm := evalForms(t, env, tt.inputs...)
+			if vm.String() != tt.expected {
+				t.Errorf("VM got %v, want %s", vm, tt.expected)
+			}
+			// Functions defined on the VM run as bytecode
+			for i, value := range env.frame.values {
+				if f, ok := value.(sexpr.Func); ok {
+					if _, ok := f.Body.(*Code); !ok {
+						t.Errorf("%s was not compiled", env.frame.names[i])
+					}
+				}
+			}
+
+			tree := NewEnv(nil)
+			LoadPrimitives(tree)
+			if walked := evalForms(t, tree, tt.inputs...); walked.String() != vm.String() {
+				t.Errorf("tree walker got %v, VM got %v", walked, vm)
+			}
+		})
+	}
+}
+
+func TestBytecodeVMErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		expected string
+	}{
+		{"stack trace", []string{"(define (f x) (g x))", "(define (g x) (car x))", "(f 1)"}, "car: expected list, got 1\n  in g\n  called from f"},
+		{"arity", []string{"(define (f x) x)", "(f 1 2)"}, "requires 1 argument, got 2\n  in f"},
+		{"undefined", []string{"(define (f) nope)", "(f)"}, "undefined variable: nope\n  in f"},
+		{"recur outside loop", []string{"(recur 1)"}, "recur: not inside a loop"},
+		{"recur count", []string{"(loop ((i 0)) (recur 1 2))"}, "recur: loop has 1 bindings, got 2 values"},
+		{"recur across function", []string{"(loop ((i 0)) ((lambda () (recur 1))))"}, "recur: cannot cross a function boundary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newVMEnv()
+			evalForms(t, env, tt.inputs[:len(tt.inputs)-1]...)
+			_, err := evalString(env, tt.inputs[len(tt.inputs)-1])
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("got:\n%v\nwant:\n%s", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBytecodeVMCompileErrors(t *testing.T) {
+	// An expression too large for the VM fails instead of being walked
+	input := "(list" + strings.Repeat(" 0", maxOperand+1) + ")"
+	_, err := evalString(newVMEnv(), input)
+	if err == nil || !strings.Contains(err.Error(), "expression too large to compile") {
+		t.Errorf("expected a compile error, got %v", err)
+	}
+}
+
+func TestBytecodeVMLimits(t *testing.T) {
+	env := newVMEnv()
+	evalForms(t, env, "(define (down n) (if (= n 0) 0 (+ 1 (down (- n 1)))))")
+
+	env.Runtime().SetMaxDepth(100)
+	_, err := evalString(env, "(down 200)")
+	if !errors.Is(err, ErrDepth) {
+		t.Errorf("expected ErrDepth, got %v", err)
+	}
+
+	env.Runtime().SetMaxDepth(0)
+	env.Runtime().SetFuel(50)
+	_, err = evalString(env, "(loop ((i 0)) (recur (+ i 1)))")
+	if !errors.Is(err, ErrFuel) {
+		t.Errorf("expected ErrFuel, got %v", err)
+	}
+	env.Runtime().SetFuel(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	expr := compileString(t, env, "(loop ((i 0)) (recur (+ i 1)))")
+	_, err = EvalContext(ctx, expr, env)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestEngineInterop(t *testing.T) {
+	env := NewEnv(nil)
+	LoadPrimitives(env)
+	evalForms(t, env, "(define (walked x) (* x 2))")
+
+	env.Runtime().SetEngine(BytecodeVM)
+	evalForms(t, env, "(define (compiled x) (+ (walked x) 1))")
+
+	env.Runtime().SetEngine(TreeWalker)
+	if got := evalForms(t, env, "(compiled 20)"); got.String() != "41" {
+		t.Errorf("got %v, want 41", got)
+	}
+	if got := evalForms(t, env, "(apply compiled '(1))"); got.String() != "3" {
+		t.Errorf("got %v, want 3", got)
+	}
+}
+
+func BenchmarkEngines(b *testing.B) {
+	programs := []struct {
+		name  string
+		setup string
+		run   string
+	}{
+		{"fib", "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))", "(fib 15)"},
+		{"loop", "(define (sum n) (loop ((i 0) (acc 0)) (if (< i n) (recur (+ i 1) (+ acc i)) acc)))", "(sum 5000)"},
+	}
+	for _, p := range programs {
+		for _, engine := range []Engine{TreeWalker, BytecodeVM, ClosureCompiler} {
+			b.Run(p.name+"/"+engine.String(), func(b *testing.B) {
+				env := NewEnv(nil)
+				LoadPrimitives(env)
+				env.Runtime().SetEngine(engine)
+				if _, err := evalString(env, p.setup); err != nil {
+					b.Fatal(err)
+				}
+				for i := 0; i < b.N; i++ {
+					if _, err := evalString(env, p.run); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
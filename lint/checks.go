@@ -0,0 +1,117 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/zylisp/lang/sexpr"
+)
+
+// comparisons maps the comparison functions to what they return when
+// given the same variable twice
+var comparisons = map[string]bool{
+	"=":      true,
+	"<":      false,
+	">":      false,
+	"<=":     true,
+	">=":     true,
+	"eq?":    true,
+	"eqv?":   true,
+	"equal?": true,
+}
+
+// selfCompare reports a call such as (= x x), comparing a variable with
+// itself, whose result is always the same
+func (l *linter) selfCompare(list sexpr.List, name string) {
+	result, ok := comparisons[name]
+	if !ok || len(list.Elements) != 3 {
+		return
+	}
+	arg, ok := list.Elements[1].(sexpr.Symbol)
+	if !ok || !arg.Equal(list.Elements[2]) {
+		return
+	}
+	l.report(list, "self-compare", fmt.Sprintf("%v compares %s with itself and is always %v", list, arg.Name, result))
+}
+
+// cond checks the clauses of a cond, reporting those that cannot be
+// reached because an earlier test is always true or is the same test
+func (l *linter) cond(list sexpr.List, s *scope) {
+	var tests []sexpr.SExpr
+	var always sexpr.SExpr // the first test that is always true
+	for _, clause := range list.Elements[1:] {
+		parts, ok := sexpr.Elements(clause)
+		if !ok || len(parts) == 0 {
+			continue
+		}
+		test := parts[0]
+
+		switch {
+		case isSymbolNamed(test, "else"):
+			l.each(parts[1:], s)
+			continue
+		case always != nil:
+			l.report(clause, "unreachable", fmt.Sprintf("cond clause is unreachable: the test %v is always true", always))
+		case repeatable(test) && contains(tests, test):
+			l.report(clause, "unreachable", fmt.Sprintf("cond clause is unreachable: the test %v repeats an earlier one", test))
+		}
+		if always == nil && alwaysTrue(test) {
+			always = test
+		}
+		tests = append(tests, test)
+
+		l.expr(test, s)
+		if len(parts) > 1 && isSymbolNamed(parts[1], "=>") {
+			parts = parts[1:]
+		}
+		l.each(parts[1:], s)
+	}
+}
+
+// alwaysTrue reports whether test is a literal other than false
+func alwaysTrue(test sexpr.SExpr) bool {
+	switch t := test.(type) {
+	case sexpr.Bool:
+		return t.Value
+	case sexpr.Number, sexpr.BigInt, sexpr.Float, sexpr.String, sexpr.Keyword, sexpr.Char:
+		return true
+	}
+	return false
+}
+
+// repeatable reports whether test gives the same result each time it is
+// evaluated in a cond: a variable, or a call with only variables and
+// literals as arguments, which is assumed to have no side effects
+func repeatable(test sexpr.SExpr) bool {
+	switch t := test.(type) {
+	case sexpr.Symbol:
+		return true
+	case sexpr.List:
+		if len(t.Elements) < 2 {
+			return false
+		}
+		for _, elem := range t.Elements {
+			switch elem.(type) {
+			case sexpr.List, sexpr.Pair, sexpr.Vector, sexpr.Map:
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// contains reports whether exprs holds an expression equal to x
+func contains(exprs []sexpr.SExpr, x sexpr.SExpr) bool {
+	for _, e := range exprs {
+		if e.Equal(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSymbolNamed reports whether x is the symbol name
+func isSymbolNamed(x sexpr.SExpr, name string) bool {
+	sym, ok := x.(sexpr.Symbol)
+	return ok && sym.Name == name
+}
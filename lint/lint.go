@@ -0,0 +1,620 @@
+// Package lint finds likely mistakes in zylisp source code.
+//
+// Lint reads source without evaluating it and reports:
+//
+//   - malformed special forms, such as an if without an alternative or a
+//     let binding that is not a (name value) pair (check "syntax")
+//   - local bindings and internal or unexported module definitions that
+//     are never used (check "unused")
+//   - bindings that shadow an enclosing binding of the same name (check
+//     "shadow")
+//   - cond clauses that can never be reached (check "unreachable")
+//   - comparisons of a variable with itself, such as (= x x) (check
+//     "self-compare")
+//
+// Names starting with an underscore are exempt from the unused check.
+// Like interpreter.Analyze, lint cannot tell macro uses from calls, so
+// the arguments of macros are checked as expressions.
+package lint
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// Diagnostic is a problem found in source code
+type Diagnostic struct {
+	Pos     sexpr.Position // where the problem is, or the form containing it
+	Check   string         // the check that found it, such as "unused"
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return d.Pos.String() + ": " + d.Message
+}
+
+// Source reads the source text of file and lints it. It returns an error
+// only if the source cannot be read.
+func Source(file, src string) ([]Diagnostic, error) {
+	forms, err := parser.ReadSource(file, src)
+	if err != nil {
+		return nil, err
+	}
+	return Forms(forms), nil
+}
+
+// Forms lints the top-level forms of a program, as read by
+// parser.ReadSource. Diagnostics are ordered by position.
+func Forms(forms []sexpr.SExpr) []Diagnostic {
+	l := &linter{}
+	for _, form := range forms {
+		l.syntax(form)
+	}
+	l.body(forms, newScope(nil, false))
+
+	slices.SortStableFunc(l.diagnostics, func(a, b Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Pos.File, b.Pos.File),
+			cmp.Compare(a.Pos.Line, b.Pos.Line), cmp.Compare(a.Pos.Col, b.Pos.Col))
+	})
+	return l.diagnostics
+}
+
+// linter walks a program, collecting diagnostics
+type linter struct {
+	diagnostics []Diagnostic
+	located     sexpr.SExpr // innermost list with a source position
+}
+
+// report records a diagnostic at the position of form, or of the nearest
+// enclosing form that has one
+func (l *linter) report(form sexpr.SExpr, check, message string) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Pos: l.pos(form), Check: check, Message: message})
+}
+
+func (l *linter) pos(form sexpr.SExpr) sexpr.Position {
+	if pos, ok := sexpr.PositionOf(form); ok {
+		return pos
+	}
+	pos, _ := sexpr.PositionOf(l.located)
+	return pos
+}
+
+// syntax reports the malformed special forms interpreter.Analyze finds
+// in form
+func (l *linter) syntax(form sexpr.SExpr) {
+	for _, err := range interpreter.Analyze(form) {
+		var loc *interpreter.Location
+		var evalErr *interpreter.EvalError
+		var arityErr *interpreter.ArityError
+		switch {
+		case errors.As(err, &evalErr):
+			loc = &evalErr.Location
+		case errors.As(err, &arityErr):
+			loc = &arityErr.Location
+		}
+
+		d := Diagnostic{Check: "syntax", Message: err.Error()}
+		if loc != nil && loc.Pos != nil {
+			d.Pos = *loc.Pos
+		} else if pos, ok := sexpr.PositionOf(form); ok {
+			d.Pos = pos
+		}
+		l.diagnostics = append(l.diagnostics, d)
+	}
+}
+
+// binding is a name bound in a scope
+type binding struct {
+	name   string
+	form   sexpr.SExpr    // the form binding it
+	pos    sexpr.Position // the position of form
+	kind   string         // "variable", "definition" or "" for bindings not checked
+	used   bool
+	export bool // exported from a module
+}
+
+// scope is a set of bindings made by one form
+type scope struct {
+	parent   *scope
+	bindings map[string]*binding
+	order    []*binding
+	checked  bool // report unused definitions, as inside a function or module
+}
+
+func newScope(parent *scope, checked bool) *scope {
+	return &scope{parent: parent, bindings: map[string]*binding{}, checked: checked}
+}
+
+// lookup returns the innermost binding of name
+func (s *scope) lookup(name string) *binding {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.bindings[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// declare binds name in s, reporting it if it shadows an enclosing
+// binding. Kind is as for binding.kind.
+func (l *linter) declare(s *scope, name string, form sexpr.SExpr, kind string) {
+	if _, ok := s.bindings[name]; ok {
+		return
+	}
+	b := &binding{name: name, form: form, pos: l.pos(form), kind: kind}
+	if outer := s.parent.lookup(name); outer != nil && kind != "" && outer.kind != "" {
+		l.report(form, "shadow", fmt.Sprintf("%s shadows the binding on line %d", name, outer.pos.Line))
+	}
+	s.bindings[name] = b
+	s.order = append(s.order, b)
+}
+
+// use marks the binding name refers to as used
+func (s *scope) use(name string) {
+	if b := s.lookup(name); b != nil {
+		b.used = true
+	}
+}
+
+// close reports the bindings of s that were never used
+func (l *linter) close(s *scope) {
+	for _, b := range s.order {
+		if b.used || b.export || strings.HasPrefix(b.name, "_") {
+			continue
+		}
+		switch {
+		case b.kind == "variable":
+			l.report(b.form, "unused", b.name+" is bound but never used")
+		case b.kind == "definition" && s.checked:
+			l.report(b.form, "unused", b.name+" is defined but never used")
+		}
+	}
+}
+
+// body checks a sequence of expressions whose definitions go into s,
+// declaring them first so that they may refer to each other
+func (l *linter) body(exprs []sexpr.SExpr, s *scope) {
+	l.definitions(exprs, s)
+	for _, x := range exprs {
+		l.expr(x, s)
+	}
+}
+
+// definitions declares the names defined by exprs in s
+func (l *linter) definitions(exprs []sexpr.SExpr, s *scope) {
+	for _, x := range exprs {
+		list, ok := x.(sexpr.List)
+		if !ok || len(list.Elements) < 2 {
+			continue
+		}
+		head, _ := list.Elements[0].(sexpr.Symbol)
+		switch head.Name {
+		case "begin":
+			l.definitions(list.Elements[1:], s)
+		case "define", "defmacro", "define-syntax":
+			target := list.Elements[1]
+			for {
+				// (define ((curried a) b) ...) defines curried
+				inner, ok := target.(sexpr.List)
+				if !ok || len(inner.Elements) == 0 {
+					break
+				}
+				target = inner.Elements[0]
+			}
+			if pair, ok := target.(sexpr.Pair); ok {
+				target = pair.Car
+			}
+			if name, ok := target.(sexpr.Symbol); ok {
+				l.declare(s, name.Name, list, "definition")
+			}
+		case "define-record-type":
+			for _, name := range recordNames(list) {
+				l.declare(s, name, list, "definition")
+			}
+		}
+	}
+}
+
+// recordNames lists the names a define-record-type binds
+func recordNames(list sexpr.List) []string {
+	var names []string
+	add := func(x sexpr.SExpr) {
+		if sym, ok := x.(sexpr.Symbol); ok {
+			names = append(names, sym.Name)
+		}
+	}
+	add(list.Elements[1])
+	if len(list.Elements) < 4 {
+		return names
+	}
+	if ctor, ok := list.Elements[2].(sexpr.List); ok && len(ctor.Elements) > 0 {
+		add(ctor.Elements[0])
+	}
+	add(list.Elements[3])
+	for _, spec := range list.Elements[4:] {
+		if field, ok := spec.(sexpr.List); ok && len(field.Elements) > 1 {
+			for _, x := range field.Elements[1:] {
+				add(x)
+			}
+		}
+	}
+	return names
+}
+
+// expr checks an expression evaluated in s
+func (l *linter) expr(x sexpr.SExpr, s *scope) {
+	switch e := x.(type) {
+	case sexpr.Symbol:
+		s.use(e.Name)
+	case sexpr.Vector:
+		for _, elem := range e.Elements() {
+			l.expr(elem, s)
+		}
+	case sexpr.Map:
+		for _, entry := range e.Entries() {
+			l.expr(entry.Key, s)
+			l.expr(entry.Value, s)
+		}
+	case sexpr.List:
+		if _, ok := sexpr.PositionOf(e); ok {
+			outer := l.located
+			l.located = e
+			defer func() { l.located = outer }()
+		}
+		l.list(e, s)
+	}
+}
+
+// each checks exprs in s
+func (l *linter) each(exprs []sexpr.SExpr, s *scope) {
+	for _, x := range exprs {
+		l.expr(x, s)
+	}
+}
+
+func (l *linter) list(list sexpr.List, s *scope) {
+	elems := list.Elements
+	if len(elems) == 0 {
+		return
+	}
+	head, ok := elems[0].(sexpr.Symbol)
+	if !ok || !interpreter.IsSpecialForm(head.Name) || s.lookup(head.Name) != nil {
+		if ok {
+			l.selfCompare(list, head.Name)
+		}
+		l.each(elems, s)
+		return
+	}
+	args := elems[1:]
+
+	// Malformed forms are reported by the syntax check; only the parts
+	// that are well formed are checked here
+	switch head.Name {
+	case "quote", "define-syntax", "syntax-rules", "import", "recur":
+		if head.Name == "recur" {
+			l.each(args, s)
+		}
+	case "quasiquote":
+		for _, x := range args {
+			l.unquoted(x, s)
+		}
+	case "define":
+		l.define(list, s)
+	case "set!":
+		l.each(args, s)
+	case "lambda":
+		if len(args) > 1 {
+			l.function(args[0], args[len(args)-1:], list, s)
+		}
+	case "defmacro":
+		if len(args) > 1 {
+			l.function(args[1], args[2:], list, s)
+		}
+	case "let", "let*", "letrec", "loop":
+		l.let(head.Name, list, s)
+	case "let-values":
+		l.letValues(list, s)
+	case "cond":
+		l.cond(list, s)
+	case "case":
+		l.caseForm(list, s)
+	case "match", "receive":
+		if head.Name == "match" && len(args) > 0 {
+			l.expr(args[0], s)
+			args = args[1:]
+		}
+		for _, clause := range args {
+			l.patternClause(clause, s)
+		}
+	case "try":
+		l.try(list, s)
+	case "define-record-type":
+		// Its names are declared by definitions
+	case "module":
+		l.module(list, s)
+	default:
+		l.each(args, s)
+	}
+}
+
+// define checks (define name value) and (define (name params...) body...)
+func (l *linter) define(list sexpr.List, s *scope) {
+	args := list.Elements[1:]
+	if len(args) == 0 {
+		return
+	}
+	if len(args) > 1 {
+		if _, ok := args[1].(sexpr.String); ok && len(args) > 2 {
+			args = append(args[:1:1], args[2:]...) // skip the docstring
+		}
+	}
+
+	switch target := args[0].(type) {
+	case sexpr.List, sexpr.Pair:
+		// (define (name . params) body...), possibly curried
+		spec := sexpr.SExpr(target)
+		body := args[1:]
+		for {
+			parts, ok := spec.(sexpr.List)
+			if !ok || len(parts.Elements) == 0 {
+				break
+			}
+			if _, ok := parts.Elements[0].(sexpr.List); !ok {
+				break
+			}
+			params := sexpr.List{Elements: parts.Elements[1:]}
+			body = []sexpr.SExpr{sexpr.List{Elements: append([]sexpr.SExpr{sexpr.Symbol{Name: "lambda"}, params}, body...)}}
+			spec = parts.Elements[0]
+		}
+		var params sexpr.SExpr = sexpr.List{}
+		switch p := spec.(type) {
+		case sexpr.List:
+			params = sexpr.List{Elements: p.Elements[1:]}
+		case sexpr.Pair:
+			params = p.Cdr
+		}
+		l.function(params, body, list, s)
+	default:
+		l.each(args[1:], s)
+	}
+}
+
+// function checks a function with parameters params and a body, made by
+// form in s
+func (l *linter) function(params sexpr.SExpr, body []sexpr.SExpr, form sexpr.List, s *scope) {
+	inner := newScope(s, true)
+	for _, name := range patternNames(params) {
+		l.declare(inner, name, paramsForm(params, form), "parameter")
+	}
+	l.body(body, inner)
+	l.close(inner)
+}
+
+// paramsForm returns the form to report a parameter at: the parameter
+// list if it has a position, or else the function
+func paramsForm(params sexpr.SExpr, form sexpr.List) sexpr.SExpr {
+	if _, ok := sexpr.PositionOf(params); ok {
+		return params
+	}
+	return form
+}
+
+// patternNames lists the symbols bound by a parameter list or pattern
+func patternNames(pattern sexpr.SExpr) []string {
+	var names []string
+	sexpr.Walk(pattern, func(x sexpr.SExpr) bool {
+		if sym, ok := x.(sexpr.Symbol); ok {
+			names = append(names, sym.Name)
+		}
+		return true
+	})
+	return names
+}
+
+// let checks a let, let*, letrec or loop. Each binding is declared in
+// one scope; let* and letrec bindings are visible to the values after
+// and, for letrec, before them.
+func (l *linter) let(form string, list sexpr.List, s *scope) {
+	if len(list.Elements) < 3 {
+		return
+	}
+	specs, ok := sexpr.Elements(list.Elements[1])
+	if !ok {
+		return
+	}
+
+	inner := newScope(s, true)
+	var pairs [][]sexpr.SExpr
+	for _, spec := range specs {
+		if pair, ok := sexpr.Elements(spec); ok && len(pair) == 2 {
+			pairs = append(pairs, pair)
+		}
+	}
+	declare := func(pair []sexpr.SExpr, spec sexpr.SExpr) {
+		for _, name := range patternNames(pair[0]) {
+			l.declare(inner, name, spec, "variable")
+		}
+	}
+
+	switch form {
+	case "let", "loop":
+		for _, pair := range pairs {
+			l.expr(pair[1], s)
+		}
+		for i, pair := range pairs {
+			declare(pair, specs[i])
+		}
+	case "let*":
+		for i, pair := range pairs {
+			l.expr(pair[1], inner)
+			declare(pair, specs[i])
+		}
+	case "letrec":
+		for i, pair := range pairs {
+			declare(pair, specs[i])
+		}
+		for _, pair := range pairs {
+			l.expr(pair[1], inner)
+		}
+	}
+
+	body := newScope(inner, true)
+	l.body(list.Elements[2:], body)
+	l.close(body)
+	l.close(inner)
+}
+
+// letValues checks (let-values ((formals expr)...) body...)
+func (l *linter) letValues(list sexpr.List, s *scope) {
+	if len(list.Elements) < 3 {
+		return
+	}
+	specs, _ := sexpr.Elements(list.Elements[1])
+	inner := newScope(s, true)
+	for _, spec := range specs {
+		if pair, ok := sexpr.Elements(spec); ok && len(pair) == 2 {
+			l.expr(pair[1], s)
+			for _, name := range patternNames(pair[0]) {
+				l.declare(inner, name, spec, "variable")
+			}
+		}
+	}
+	body := newScope(inner, true)
+	l.body(list.Elements[2:], body)
+	l.close(body)
+	l.close(inner)
+}
+
+// patternClause checks a (pattern [:when guard] body...) clause of match
+// or receive. Pattern variables are bound but not checked, since a
+// pattern often names parts of a value only to show its shape.
+func (l *linter) patternClause(clause sexpr.SExpr, s *scope) {
+	parts, ok := sexpr.Elements(clause)
+	if !ok || len(parts) == 0 {
+		return
+	}
+	inner := newScope(s, true)
+	sexpr.Walk(parts[0], func(x sexpr.SExpr) bool {
+		if list, ok := x.(sexpr.List); ok && len(list.Elements) == 2 &&
+			isSymbolNamed(list.Elements[0], "quote") {
+			return false
+		}
+		if sym, ok := x.(sexpr.Symbol); ok && sym.Name != "_" {
+			l.declare(inner, sym.Name, clause, "")
+		}
+		return true
+	})
+	body := parts[1:]
+	if len(body) > 1 && body[0].Equal(sexpr.Keyword{Name: "when"}) {
+		l.expr(body[1], inner)
+		body = body[2:]
+	}
+	l.each(body, inner)
+}
+
+// caseForm checks (case key ((datum...) expr...)... (else expr...))
+func (l *linter) caseForm(list sexpr.List, s *scope) {
+	if len(list.Elements) < 2 {
+		return
+	}
+	l.expr(list.Elements[1], s)
+	for _, clause := range list.Elements[2:] {
+		if parts, ok := sexpr.Elements(clause); ok && len(parts) > 1 {
+			if isSymbolNamed(parts[1], "=>") {
+				parts = parts[1:]
+			}
+			l.each(parts[1:], s)
+		}
+	}
+}
+
+// try checks (try body... [(catch name handler...)] [(finally cleanup...)])
+func (l *linter) try(list sexpr.List, s *scope) {
+	for _, x := range list.Elements[1:] {
+		clause, ok := x.(sexpr.List)
+		if !ok || len(clause.Elements) == 0 {
+			l.expr(x, s)
+			continue
+		}
+		switch {
+		case isSymbolNamed(clause.Elements[0], "catch") && len(clause.Elements) > 1:
+			inner := newScope(s, true)
+			if name, ok := clause.Elements[1].(sexpr.Symbol); ok {
+				l.declare(inner, name.Name, clause, "")
+			}
+			l.each(clause.Elements[2:], inner)
+		case isSymbolNamed(clause.Elements[0], "finally"):
+			l.each(clause.Elements[1:], s)
+		default:
+			l.expr(clause, s)
+		}
+	}
+}
+
+// module checks (module name [(export symbol...)] body...). Its body is
+// evaluated apart from the enclosing definitions, and its definitions
+// must be used or exported.
+func (l *linter) module(list sexpr.List, s *scope) {
+	if len(list.Elements) < 2 {
+		return
+	}
+	inner := newScope(s.global(), true)
+	body := list.Elements[2:]
+	var exports []string
+	if len(body) > 0 {
+		if clause, ok := body[0].(sexpr.List); ok && len(clause.Elements) > 0 &&
+			isSymbolNamed(clause.Elements[0], "export") {
+			for _, e := range clause.Elements[1:] {
+				if sym, ok := e.(sexpr.Symbol); ok {
+					exports = append(exports, sym.Name)
+				}
+			}
+			body = body[1:]
+		}
+	}
+
+	l.definitions(body, inner)
+	for _, name := range exports {
+		if b, ok := inner.bindings[name]; ok {
+			b.export = true
+		}
+	}
+	for _, x := range body {
+		l.expr(x, inner)
+	}
+	l.close(inner)
+}
+
+// global returns the outermost scope, the top level of the program
+func (s *scope) global() *scope {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// unquoted checks the unquoted expressions in a quasiquote template
+func (l *linter) unquoted(template sexpr.SExpr, s *scope) {
+	var elems []sexpr.SExpr
+	switch t := template.(type) {
+	case sexpr.List:
+		elems = t.Elements
+		if len(elems) == 2 && (isSymbolNamed(elems[0], "unquote") ||
+			isSymbolNamed(elems[0], "unquote-splicing")) {
+			l.expr(elems[1], s)
+			return
+		}
+	case sexpr.Vector:
+		elems = t.Elements()
+	}
+	for _, elem := range elems {
+		l.unquoted(elem, s)
+	}
+}
@@ -0,0 +1,115 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+// lintString lints src and returns its diagnostics as "check line:col:
+// message" lines
+func lintString(t *testing.T, src string) []string {
+	t.Helper()
+	diagnostics, err := Source("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, d.Check+" "+strings.TrimPrefix(d.String(), "line "))
+	}
+	return got
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected []string
+	}{
+		{"clean", "(define (f x) (* x x))\n(f 2)", nil},
+		{"syntax", "(if true 1)", []string{"syntax 1, col 1: if: requires 3 arguments, got 2"}},
+		{
+			"unused let binding",
+			"(let ((a 1)\n      (b 2))\n  a)",
+			[]string{"unused 2, col 7: b is bound but never used"},
+		},
+		{"underscore is exempt", "(let ((_a 1)) 2)", nil},
+		{"unused parameters are allowed", "(lambda (x) 1)", nil},
+		{
+			"unused internal definition",
+			"(define (f)\n  (define (helper) 1)\n  2)",
+			[]string{"unused 2, col 3: helper is defined but never used"},
+		},
+		{"top-level definitions may be unused", "(define (f) 1)", nil},
+		{"definitions may come later", "(define (f) (g))\n(define (g) (f))", nil},
+		{"set! is a use", "(let ((a 1)) (set! a 2))", nil},
+		{"let* sees earlier bindings", "(let* ((a 1) (b a)) b)", nil},
+		{"let does not", "(define a 0)\n(let ((a 1) (b a)) b)", []string{
+			"shadow 2, col 7: a shadows the binding on line 1",
+			"unused 2, col 7: a is bound but never used",
+		}},
+		{"letrec", "(letrec ((even? (lambda (n) (odd? n))) (odd? (lambda (n) (even? n)))) 1)", nil},
+		{"let-values", "(let-values (((a b) (values 1 2))) a)", []string{"unused 1, col 14: b is bound but never used"}},
+		{"destructuring", "(let (((a . rest) (list 1 2))) a)", []string{"unused 1, col 7: rest is bound but never used"}},
+		{
+			"unused module definition",
+			"(module m (export pub)\n  (define (pub) (helper))\n  (define (helper) 1)\n  (define (dead) 2))",
+			[]string{"unused 4, col 3: dead is defined but never used"},
+		},
+		{
+			"shadowed parameter",
+			"(define (f x)\n  (lambda (x) x))",
+			[]string{"shadow 2, col 11: x shadows the binding on line 1"},
+		},
+		{
+			"shadowed let binding",
+			"(let ((x 1))\n  (let ((x 2)) x))",
+			[]string{"unused 1, col 7: x is bound but never used", "shadow 2, col 9: x shadows the binding on line 1"},
+		},
+		{"builtins are not shadowed", "(lambda (list) list)", nil},
+		{"match variables are not checked", "(define x 1)\n(match x ((x y) 1) (_ 2))", nil},
+		{"catch name", "(try (car 1) (catch e 0))", nil},
+		{"quoted symbols are not uses", "(let ((a 1)) 'a)", []string{"unused 1, col 7: a is bound but never used"}},
+		{"unquoted symbols are uses", "(let ((a 1)) `(a ,a))", nil},
+		{"self-compare", "(lambda (x) (= x x))", []string{"self-compare 1, col 13: (= x x) compares x with itself and is always true"}},
+		{"self-compare always false", "(lambda (x) (< x x))", []string{"self-compare 1, col 13: (< x x) compares x with itself and is always false"}},
+		{"different arguments", "(lambda (x y) (= x y))", nil},
+		{
+			"cond after always true test",
+			"(lambda (x)\n  (cond ((< x 0) 1)\n        (true 2)\n        ((> x 0) 3)))",
+			[]string{"unreachable 4, col 9: cond clause is unreachable: the test true is always true"},
+		},
+		{
+			"repeated cond test",
+			"(lambda (x)\n  (cond ((< x 0) 1)\n        ((< x 0) 2)))",
+			[]string{"unreachable 3, col 9: cond clause is unreachable: the test (< x 0) repeats an earlier one"},
+		},
+		{"calls may differ", "(cond ((read-line) 1) ((read-line) 2))", nil},
+		{"cond with else", "(lambda (x) (cond (x 1) (else 2)))", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lintString(t, tt.src)
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	if _, err := Source("bad.zy", "(a"); err == nil {
+		t.Error("expected an error for unreadable source")
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	diagnostics, err := Source("a.zy", "\n(let ((x 1)) 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].String() != "a.zy:2:7: x is bound but never used" {
+		t.Errorf("got %v", diagnostics)
+	}
+}
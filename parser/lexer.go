@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // TokenType represents the type of a token
@@ -18,6 +19,24 @@ const (
 	BOOL
 	EOF
 	ILLEGAL
+	COMMENT
+	WHITESPACE
+	DOT
+	LBRACKET
+	RBRACKET
+	LBRACE
+	RBRACE
+	SETOPEN // #{
+	KEYWORD
+	TAG     // #name, a tagged literal or dispatch prefix
+	DISCARD // #_
+	QUOTE
+	QUASIQUOTE
+	UNQUOTE
+	UNQUOTESPLICING
+	INTERPOLATED // #"...", value is the raw text between the quotes
+	CHAR         // #\c, value is the text after the backslash
+	NIL
 )
 
 func (tt TokenType) String() string {
@@ -38,6 +57,42 @@ func (tt TokenType) String() string {
 		return "EOF"
 	case ILLEGAL:
 		return "ILLEGAL"
+	case COMMENT:
+		return "COMMENT"
+	case WHITESPACE:
+		return "WHITESPACE"
+	case DOT:
+		return "DOT"
+	case LBRACKET:
+		return "LBRACKET"
+	case RBRACKET:
+		return "RBRACKET"
+	case LBRACE:
+		return "LBRACE"
+	case RBRACE:
+		return "RBRACE"
+	case SETOPEN:
+		return "SETOPEN"
+	case KEYWORD:
+		return "KEYWORD"
+	case TAG:
+		return "TAG"
+	case DISCARD:
+		return "DISCARD"
+	case QUOTE:
+		return "QUOTE"
+	case INTERPOLATED:
+		return "INTERPOLATED"
+	case QUASIQUOTE:
+		return "QUASIQUOTE"
+	case UNQUOTE:
+		return "UNQUOTE"
+	case UNQUOTESPLICING:
+		return "UNQUOTESPLICING"
+	case CHAR:
+		return "CHAR"
+	case NIL:
+		return "NIL"
 	default:
 		return "UNKNOWN"
 	}
@@ -45,10 +100,18 @@ func (tt TokenType) String() string {
 
 // Token represents a lexical token
 type Token struct {
-	Type  TokenType
-	Value string
-	Line  int
-	Col   int
+	Type   TokenType
+	Value  string
+	Raw    string // exact source text of the token
+	Line   int
+	Col    int
+	Offset int // byte offset of the token in the input
+}
+
+// IsTrivia reports whether the token carries only layout (whitespace or
+// comments) and no syntax.
+func (t Token) IsTrivia() bool {
+	return t.Type == COMMENT || t.Type == WHITESPACE
 }
 
 func (t Token) String() string {
@@ -57,11 +120,14 @@ func (t Token) String() string {
 
 // Lexer tokenizes Zylisp source code
 type Lexer struct {
-	input  string
-	pos    int // current position
-	line   int // current line
-	col    int // current column
-	tokens []Token
+	input      string
+	pos        int // current position
+	start      int // start position of the token being scanned
+	line       int // current line
+	col        int // current column
+	tokens     []Token
+	keepTrivia bool // emit WHITESPACE and COMMENT tokens
+	edn        bool // accept EDN syntax
 }
 
 // NewLexer creates a new lexer for the given input
@@ -80,10 +146,44 @@ func Tokenize(input string) ([]Token, error) {
 	return lexer.Tokenize()
 }
 
-// Tokenize produces all tokens
+// TokenizeWithTrivia returns all tokens from the input, including
+// WHITESPACE and COMMENT tokens, so that the input can be reproduced
+// exactly with Unparse. Like Lexer.Tokenize, it returns the tokens before
+// an illegal one along with the error.
+func TokenizeWithTrivia(input string) ([]Token, error) {
+	lexer := NewLexer(input)
+	lexer.KeepTrivia()
+	return lexer.Tokenize()
+}
+
+// KeepTrivia makes the lexer emit whitespace and comments as tokens
+// instead of skipping them
+func (l *Lexer) KeepTrivia() {
+	l.keepTrivia = true
+}
+
+// TokenizeEDN returns all tokens from EDN input. Commas are treated as
+// whitespace and numbers may have an N or M suffix.
+func TokenizeEDN(input string) ([]Token, error) {
+	lexer := NewLexer(input)
+	lexer.EDN()
+	return lexer.Tokenize()
+}
+
+// EDN makes the lexer accept EDN syntax
+func (l *Lexer) EDN() {
+	l.edn = true
+}
+
+// Tokenize produces all tokens. If the input holds an illegal token, it
+// returns the tokens before it along with the error.
 func (l *Lexer) Tokenize() ([]Token, error) {
 	for {
 		tok := l.nextToken()
+		if tok.Type != ILLEGAL {
+			tok.Raw = l.input[l.start:l.pos]
+			tok.Offset = l.start
+		}
 		l.tokens = append(l.tokens, tok)
 
 		if tok.Type == EOF {
@@ -91,7 +191,12 @@ func (l *Lexer) Tokenize() ([]Token, error) {
 		}
 
 		if tok.Type == ILLEGAL {
-			return nil, fmt.Errorf("illegal token at line %d, col %d: %q",
+			read := l.tokens[:len(l.tokens)-1]
+			if strings.HasPrefix(tok.Value, "unterminated") {
+				return read, incomplete("illegal token at line %d, col %d: %q",
+					tok.Line, tok.Col, tok.Value)
+			}
+			return read, fmt.Errorf("illegal token at line %d, col %d: %q",
 				tok.Line, tok.Col, tok.Value)
 		}
 	}
@@ -101,7 +206,15 @@ func (l *Lexer) Tokenize() ([]Token, error) {
 
 // nextToken returns the next token
 func (l *Lexer) nextToken() Token {
-	l.skipWhitespaceAndComments()
+	if l.keepTrivia {
+		l.start = l.pos
+		if tok, ok := l.scanTrivia(); ok {
+			return tok
+		}
+	} else {
+		l.skipWhitespaceAndComments()
+		l.start = l.pos
+	}
 
 	if l.isAtEnd() {
 		return l.makeToken(EOF, "")
@@ -114,8 +227,40 @@ func (l *Lexer) nextToken() Token {
 		return l.makeSingleCharToken(LPAREN)
 	case ')':
 		return l.makeSingleCharToken(RPAREN)
+	case '[':
+		return l.makeSingleCharToken(LBRACKET)
+	case ']':
+		return l.makeSingleCharToken(RBRACKET)
+	case '{':
+		return l.makeSingleCharToken(LBRACE)
+	case '}':
+		return l.makeSingleCharToken(RBRACE)
 	case '"':
 		return l.scanString()
+	case ':':
+		return l.scanKeyword()
+	case '#':
+		return l.scanDispatch()
+	case '|':
+		if !l.edn {
+			return l.scanBarSymbol()
+		}
+	case '\\':
+		if l.edn {
+			return l.scanChar(l.col)
+		}
+	case '\'':
+		return l.makeSingleCharToken(QUOTE)
+	case '`':
+		return l.makeSingleCharToken(QUASIQUOTE)
+	case ',':
+		if l.peekNext() == '@' {
+			startCol := l.col
+			l.advance()
+			l.advance()
+			return Token{Type: UNQUOTESPLICING, Value: ",@", Line: l.line, Col: startCol}
+		}
+		return l.makeSingleCharToken(UNQUOTE)
 	}
 
 	if isDigit(ch) || (ch == '-' && l.peekNext() != 0 && isDigit(l.peekNext())) {
@@ -142,7 +287,7 @@ func (l *Lexer) skipWhitespaceAndComments() {
 			continue
 		}
 
-		if isWhitespace(ch) {
+		if l.isWhitespace(ch) {
 			l.advance()
 			continue
 		}
@@ -151,6 +296,34 @@ func (l *Lexer) skipWhitespaceAndComments() {
 	}
 }
 
+// scanTrivia scans a run of whitespace or a single comment, reporting
+// false if the input does not start with either
+func (l *Lexer) scanTrivia() (Token, bool) {
+	if l.isAtEnd() {
+		return Token{}, false
+	}
+
+	start := l.pos
+	startLine, startCol := l.line, l.col
+
+	switch ch := l.peek(); {
+	case ch == ';':
+		for !l.isAtEnd() && l.peek() != '\n' {
+			l.advance()
+		}
+		return Token{Type: COMMENT, Value: l.input[start:l.pos],
+			Line: startLine, Col: startCol}, true
+	case l.isWhitespace(ch):
+		for !l.isAtEnd() && l.isWhitespace(l.peek()) {
+			l.advance()
+		}
+		return Token{Type: WHITESPACE, Value: l.input[start:l.pos],
+			Line: startLine, Col: startCol}, true
+	}
+
+	return Token{}, false
+}
+
 // scanNumber scans a number token
 func (l *Lexer) scanNumber() Token {
 	start := l.pos
@@ -160,7 +333,21 @@ func (l *Lexer) scanNumber() Token {
 		l.advance()
 	}
 
-	for !l.isAtEnd() && isDigit(l.peek()) {
+	l.skipDigits()
+
+	if l.peek() == '.' && isDigit(l.peekNext()) {
+		l.advance()
+		l.skipDigits()
+	}
+	if ch := l.peek(); ch == 'e' || ch == 'E' {
+		next := l.peekNext()
+		if isDigit(next) || next == '+' || next == '-' {
+			l.advance()
+			l.advance()
+			l.skipDigits()
+		}
+	}
+	if ch := l.peek(); l.edn && (ch == 'N' || ch == 'M') {
 		l.advance()
 	}
 
@@ -168,6 +355,80 @@ func (l *Lexer) scanNumber() Token {
 	return Token{Type: NUMBER, Value: value, Line: l.line, Col: startCol}
 }
 
+func (l *Lexer) skipDigits() {
+	for !l.isAtEnd() && isDigit(l.peek()) {
+		l.advance()
+	}
+}
+
+// scanKeyword scans a keyword token such as :name. The token value
+// excludes the colon.
+func (l *Lexer) scanKeyword() Token {
+	startCol := l.col
+	l.advance() // consume colon
+
+	start := l.pos
+	for !l.isAtEnd() && isSymbolChar(l.peek()) {
+		l.advance()
+	}
+
+	if l.pos == start {
+		return l.makeToken(ILLEGAL, ":")
+	}
+	return Token{Type: KEYWORD, Value: l.input[start:l.pos], Line: l.line, Col: startCol}
+}
+
+// scanDispatch scans syntax introduced by '#': a set opener #{, the
+// discard marker #_, an interpolated string #"..." or a tag such as
+// #inst. A tag's value excludes the '#'.
+func (l *Lexer) scanDispatch() Token {
+	startCol := l.col
+	l.advance() // consume '#'
+
+	switch ch := l.peek(); {
+	case ch == '{':
+		l.advance()
+		return Token{Type: SETOPEN, Value: "#{", Line: l.line, Col: startCol}
+	case ch == '_':
+		l.advance()
+		return Token{Type: DISCARD, Value: "#_", Line: l.line, Col: startCol}
+	case ch == '"':
+		return l.scanInterpolated(startCol)
+	case ch == '\\':
+		return l.scanChar(startCol)
+	case unicode.IsLetter(rune(ch)):
+		start := l.pos
+		for !l.isAtEnd() && isSymbolChar(l.peek()) {
+			l.advance()
+		}
+		return Token{Type: TAG, Value: l.input[start:l.pos], Line: l.line, Col: startCol}
+	}
+
+	return l.makeToken(ILLEGAL, "#")
+}
+
+// scanChar scans a character token from its backslash: any one
+// character, or a name or hex code made of symbol characters
+func (l *Lexer) scanChar(startCol int) Token {
+	l.advance() // consume '\\'
+	start := l.pos
+
+	r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+	if size == 0 || unicode.IsSpace(r) {
+		return l.makeToken(ILLEGAL, `#\`)
+	}
+	for range size {
+		l.advance()
+	}
+	if r < utf8.RuneSelf && unicode.IsLetter(r) {
+		for !l.isAtEnd() && isSymbolChar(l.peek()) {
+			l.advance()
+		}
+	}
+
+	return Token{Type: CHAR, Value: l.input[start:l.pos], Line: l.line, Col: startCol}
+}
+
 // scanSymbol scans a symbol token
 func (l *Lexer) scanSymbol() Token {
 	start := l.pos
@@ -179,14 +440,56 @@ func (l *Lexer) scanSymbol() Token {
 
 	value := l.input[start:l.pos]
 
-	// Check for boolean literals
+	// Check for boolean and nil literals
 	if value == "true" || value == "false" {
 		return Token{Type: BOOL, Value: value, Line: l.line, Col: startCol}
 	}
+	if value == "nil" {
+		return Token{Type: NIL, Value: value, Line: l.line, Col: startCol}
+	}
+
+	// A lone dot separates the tail of a dotted pair
+	if value == "." {
+		return Token{Type: DOT, Value: value, Line: l.line, Col: startCol}
+	}
+
+	// Infinities and NaN are written like symbols
+	if _, ok := specialFloats[value]; ok && !l.edn {
+		return Token{Type: NUMBER, Value: value, Line: l.line, Col: startCol}
+	}
 
 	return Token{Type: SYMBOL, Value: value, Line: l.line, Col: startCol}
 }
 
+// scanBarSymbol scans a symbol written between bars, such as |a b|,
+// which may contain any characters. A backslash escapes a bar or
+// backslash, as in strings.
+func (l *Lexer) scanBarSymbol() Token {
+	startCol := l.col
+	l.advance() // consume opening bar
+
+	var value strings.Builder
+	for !l.isAtEnd() && l.peek() != '|' {
+		ch := l.peek()
+		if ch == '\\' {
+			l.advance()
+			if l.isAtEnd() {
+				break
+			}
+			ch = unescape(l.peek())
+		}
+		value.WriteByte(ch)
+		l.advance()
+	}
+
+	if l.isAtEnd() {
+		return l.makeToken(ILLEGAL, "unterminated symbol")
+	}
+	l.advance() // consume closing bar
+
+	return Token{Type: SYMBOL, Value: value.String(), Line: l.line, Col: startCol}
+}
+
 // scanString scans a string token
 func (l *Lexer) scanString() Token {
 	startCol := l.col
@@ -203,22 +506,7 @@ func (l *Lexer) scanString() Token {
 				return l.makeToken(ILLEGAL, "unterminated string")
 			}
 
-			// Handle escape sequences
-			escaped := l.peek()
-			switch escaped {
-			case 'n':
-				value.WriteByte('\n')
-			case 't':
-				value.WriteByte('\t')
-			case 'r':
-				value.WriteByte('\r')
-			case '"':
-				value.WriteByte('"')
-			case '\\':
-				value.WriteByte('\\')
-			default:
-				value.WriteByte(escaped)
-			}
+			value.WriteByte(unescape(l.peek()))
 			l.advance()
 		} else {
 			value.WriteByte(ch)
@@ -235,6 +523,87 @@ func (l *Lexer) scanString() Token {
 	return Token{Type: STRING, Value: value.String(), Line: l.line, Col: startCol}
 }
 
+// scanInterpolated scans an interpolated string #"...${expr}...". The
+// expressions may themselves contain strings and braces; the token's
+// value is the raw text between the quotes, which the reader splits into
+// literal text and expressions.
+func (l *Lexer) scanInterpolated(startCol int) Token {
+	l.advance() // consume opening quote
+	start := l.pos
+
+	for !l.isAtEnd() && l.peek() != '"' {
+		switch {
+		case l.peek() == '\\':
+			l.advance()
+			if l.isAtEnd() {
+				return l.makeToken(ILLEGAL, "unterminated string")
+			}
+			l.advance()
+		case l.peek() == '$' && l.peekNext() == '{':
+			end := interpolationEnd(l.input, l.pos+2)
+			if end < 0 {
+				return l.makeToken(ILLEGAL, "unterminated interpolation")
+			}
+			for l.pos <= end {
+				l.advance()
+			}
+		default:
+			l.advance()
+		}
+	}
+
+	if l.isAtEnd() {
+		return l.makeToken(ILLEGAL, "unterminated string")
+	}
+
+	value := l.input[start:l.pos]
+	l.advance() // consume closing quote
+
+	return Token{Type: INTERPOLATED, Value: value, Line: l.line, Col: startCol}
+}
+
+// interpolationEnd returns the index of the '}' closing an interpolated
+// expression that starts at i, skipping nested braces and string
+// literals, or -1 if there is none
+func interpolationEnd(s string, i int) int {
+	depth := 1
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '"':
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(s) {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// unescape returns the character written as \ch in a string
+func unescape(ch byte) byte {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	default:
+		return ch
+	}
+}
+
 // Helper functions
 
 func (l *Lexer) peek() byte {
@@ -285,6 +654,12 @@ func (l *Lexer) makeSingleCharToken(typ TokenType) Token {
 
 // Character classification
 
+// isWhitespace reports whether ch separates tokens; EDN also treats
+// commas as whitespace
+func (l *Lexer) isWhitespace(ch byte) bool {
+	return isWhitespace(ch) || (l.edn && ch == ',')
+}
+
 func isWhitespace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
@@ -302,5 +677,5 @@ func isSymbolChar(ch byte) bool {
 }
 
 func isSymbolSpecial(ch byte) bool {
-	return strings.ContainsRune("+-*/<>=!?&|%$_", rune(ch))
+	return strings.ContainsRune("+-*/<>=!?&|%$_.", rune(ch))
 }
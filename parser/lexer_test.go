@@ -36,6 +36,33 @@ func TestLexerSimple(t *testing.T) {
 			"(+ 1 2)",
 			[]TokenType{LPAREN, SYMBOL, NUMBER, NUMBER, RPAREN, EOF},
 		},
+		{
+			"dotted pair",
+			"(a . b)",
+			[]TokenType{LPAREN, SYMBOL, DOT, SYMBOL, RPAREN, EOF},
+		},
+		{
+			"dots inside symbols",
+			"...",
+			[]TokenType{SYMBOL, EOF},
+		},
+		{
+			"collections",
+			"[:a {b #{1}}]",
+			[]TokenType{LBRACKET, KEYWORD, LBRACE, SYMBOL, SETOPEN, NUMBER,
+				RBRACE, RBRACE, RBRACKET, EOF},
+		},
+		{
+			"dispatch",
+			"#_x #inst",
+			[]TokenType{DISCARD, SYMBOL, TAG, EOF},
+		},
+		{
+			"quote shorthands",
+			"'a `(b ,c ,@d)",
+			[]TokenType{QUOTE, SYMBOL, QUASIQUOTE, LPAREN, SYMBOL, UNQUOTE, SYMBOL,
+				UNQUOTESPLICING, SYMBOL, RPAREN, EOF},
+		},
 		{
 			"nested list",
 			"(+ (* 2 3) 4)",
@@ -81,6 +108,17 @@ func TestLexerTokenValues(t *testing.T) {
 				{Type: EOF, Value: ""},
 			},
 		},
+		{
+			"floats",
+			"1.5 -0.25 2e10 1.5e-3",
+			[]Token{
+				{Type: NUMBER, Value: "1.5"},
+				{Type: NUMBER, Value: "-0.25"},
+				{Type: NUMBER, Value: "2e10"},
+				{Type: NUMBER, Value: "1.5e-3"},
+				{Type: EOF, Value: ""},
+			},
+		},
 		{
 			"symbols",
 			"+ hello-world foo?",
@@ -91,6 +129,17 @@ func TestLexerTokenValues(t *testing.T) {
 				{Type: EOF, Value: ""},
 			},
 		},
+		{
+			"bar symbols",
+			`|a b| |x\|y| |nil| nil`,
+			[]Token{
+				{Type: SYMBOL, Value: "a b"},
+				{Type: SYMBOL, Value: "x|y"},
+				{Type: SYMBOL, Value: "nil"},
+				{Type: NIL, Value: "nil"},
+				{Type: EOF, Value: ""},
+			},
+		},
 		{
 			"strings",
 			`"hello" "world"`,
@@ -100,6 +149,18 @@ func TestLexerTokenValues(t *testing.T) {
 				{Type: EOF, Value: ""},
 			},
 		},
+		{
+			"characters",
+			`#\a #\space #\x41 #\( #\λ`,
+			[]Token{
+				{Type: CHAR, Value: "a"},
+				{Type: CHAR, Value: "space"},
+				{Type: CHAR, Value: "x41"},
+				{Type: CHAR, Value: "("},
+				{Type: CHAR, Value: "λ"},
+				{Type: EOF, Value: ""},
+			},
+		},
 		{
 			"booleans",
 			"true false",
@@ -188,3 +249,100 @@ func TestLexerStringEscapes(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerTrivia(t *testing.T) {
+	input := "(a ; note\n  1)"
+	expected := []Token{
+		{Type: LPAREN, Raw: "(", Offset: 0},
+		{Type: SYMBOL, Raw: "a", Offset: 1},
+		{Type: WHITESPACE, Raw: " ", Offset: 2},
+		{Type: COMMENT, Raw: "; note", Offset: 3},
+		{Type: WHITESPACE, Raw: "\n  ", Offset: 9},
+		{Type: NUMBER, Raw: "1", Offset: 12},
+		{Type: RPAREN, Raw: ")", Offset: 13},
+		{Type: EOF, Raw: "", Offset: 14},
+	}
+
+	tokens, err := TokenizeWithTrivia(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(expected))
+	}
+
+	for i, tok := range tokens {
+		if tok.Type != expected[i].Type || tok.Raw != expected[i].Raw ||
+			tok.Offset != expected[i].Offset {
+			t.Errorf("token %d: got %v %q@%d, want %v %q@%d", i,
+				tok.Type, tok.Raw, tok.Offset,
+				expected[i].Type, expected[i].Raw, expected[i].Offset)
+		}
+	}
+}
+
+func TestLexerEDN(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Token
+	}{
+		{"1,2", []Token{{Type: NUMBER, Value: "1"}, {Type: NUMBER, Value: "2"}}},
+		{"1.5e-3", []Token{{Type: NUMBER, Value: "1.5e-3"}}},
+		{"42N", []Token{{Type: NUMBER, Value: "42N"}}},
+		{":ns/key", []Token{{Type: KEYWORD, Value: "ns/key"}}},
+		{"#uuid", []Token{{Type: TAG, Value: "uuid"}}},
+		{`\a \newline`, []Token{{Type: CHAR, Value: "a"}, {Type: CHAR, Value: "newline"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := TokenizeEDN(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			var got []Token
+			for _, tok := range tokens[:len(tokens)-1] {
+				got = append(got, Token{Type: tok.Type, Value: tok.Value})
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	// Outside EDN mode a comma is unquote, not whitespace
+	tokens, err := Tokenize("1,2")
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+	if len(tokens) != 4 || tokens[1].Type != UNQUOTE {
+		t.Errorf("got %v, want NUMBER UNQUOTE NUMBER EOF", tokens)
+	}
+}
+
+func TestLexerPartialTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []TokenType
+	}{
+		{`(f "abc`, []TokenType{LPAREN, SYMBOL, WHITESPACE}},
+		{"(a) @ b", []TokenType{LPAREN, SYMBOL, RPAREN, WHITESPACE}},
+		{"(f |a b", []TokenType{LPAREN, SYMBOL, WHITESPACE}},
+	}
+
+	for _, tt := range tests {
+		tokens, err := TokenizeWithTrivia(tt.input)
+		if err == nil {
+			t.Errorf("%q: expected an error", tt.input)
+		}
+		var got []TokenType
+		for _, tok := range tokens {
+			got = append(got, tok.Type)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: got %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
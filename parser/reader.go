@@ -1,34 +1,166 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/zylisp/lang/sexpr"
 )
 
+// ErrIncomplete is matched by errors.Is for errors reading input that
+// ends inside an expression, such as an unclosed list or string, so that
+// more input could complete it
+var ErrIncomplete = errors.New("incomplete input")
+
+// incompleteError is an error that is ErrIncomplete
+type incompleteError struct {
+	msg string
+}
+
+func (e *incompleteError) Error() string {
+	return e.msg
+}
+
+func (e *incompleteError) Is(target error) bool {
+	return target == ErrIncomplete
+}
+
+// incomplete returns an error that is ErrIncomplete with a formatted
+// message
+func incomplete(format string, args ...any) error {
+	return &incompleteError{msg: fmt.Sprintf(format, args...)}
+}
+
 // Reader parses tokens into S-expressions
 type Reader struct {
 	tokens []Token
 	pos    int
+	edn    bool // read nil and tagged literals as EDN does
+
+	positions bool   // record source positions in list metadata
+	file      string // file name recorded in positions
 }
 
-// NewReader creates a new reader for the given tokens
+// NewReader creates a new reader for the given tokens. Trivia tokens
+// (whitespace and comments) are ignored.
 func NewReader(tokens []Token) *Reader {
-	return &Reader{tokens: tokens, pos: 0}
+	syntax := tokens[:0:0]
+	for _, tok := range tokens {
+		if !tok.IsTrivia() {
+			syntax = append(syntax, tok)
+		}
+	}
+	return &Reader{tokens: syntax, pos: 0}
 }
 
 // Read parses tokens into an S-expression
 func Read(tokens []Token) (sexpr.SExpr, error) {
+	return NewReader(tokens).readOne()
+}
+
+// ReadEDN parses a single EDN value. In addition to the usual syntax,
+// nil reads as Nil and tagged literals such as #inst "2024-01-01" read as
+// sexpr.Tagged values.
+func ReadEDN(input string) (sexpr.SExpr, error) {
+	tokens, err := TokenizeEDN(input)
+	if err != nil {
+		return nil, err
+	}
 	reader := NewReader(tokens)
-	expr, err := reader.readExpr()
+	reader.edn = true
+	return reader.readOne()
+}
+
+// ReadAll parses every expression in input, such as the contents of a
+// source file
+func ReadAll(input string) ([]sexpr.SExpr, error) {
+	tokens, err := Tokenize(input)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewReader(tokens).readAll()
+}
+
+// ReadSource parses every expression in the source text of a file. Each
+// list read records its position as :file, :line and :col metadata (see
+// sexpr.PositionOf), which the interpreter uses in error messages.
+func ReadSource(file, input string) ([]sexpr.SExpr, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	r := NewReader(tokens)
+	r.positions = true
+	r.file = file
+	exprs, err := r.readAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return exprs, nil
+}
+
+// ReadPrefix parses the first expression in input, returning it with the
+// number of bytes of input it and any preceding whitespace and comments
+// take up. If input holds no expression the error is io.EOF; if it ends
+// inside one the error is ErrIncomplete.
+func ReadPrefix(input string) (sexpr.SExpr, int, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r := NewReader(tokens)
+	if err := r.skipDiscarded(); err != nil {
+		return nil, 0, err
+	}
+	if r.isAtEnd() {
+		return nil, 0, io.EOF
+	}
+	expr, err := r.readExpr()
+	if err != nil {
+		return nil, 0, err
+	}
+	last := r.tokens[r.pos-1]
+	return expr, last.Offset + len(last.Raw), nil
+}
+
+// readAll reads expressions until the end of input
+func (r *Reader) readAll() ([]sexpr.SExpr, error) {
+	exprs := []sexpr.SExpr{}
+	for {
+		if err := r.skipDiscarded(); err != nil {
+			return nil, err
+		}
+		if r.isAtEnd() || r.peek().Type == EOF {
+			return exprs, nil
+		}
+		expr, err := r.readExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+}
+
+// readOne reads exactly one expression
+func (r *Reader) readOne() (sexpr.SExpr, error) {
+	expr, err := r.readExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.skipDiscarded(); err != nil {
+		return nil, err
+	}
 	// Check for extra tokens after the expression (excluding EOF)
-	if !reader.isAtEnd() && reader.peek().Type != EOF {
-		tok := reader.peek()
+	if !r.isAtEnd() && r.peek().Type != EOF {
+		tok := r.peek()
 		return nil, fmt.Errorf("unexpected token after expression at line %d, col %d: %v",
 			tok.Line, tok.Col, tok.Type)
 	}
@@ -38,8 +170,12 @@ func Read(tokens []Token) (sexpr.SExpr, error) {
 
 // readExpr reads a single expression
 func (r *Reader) readExpr() (sexpr.SExpr, error) {
+	if err := r.skipDiscarded(); err != nil {
+		return nil, err
+	}
+
 	if r.isAtEnd() {
-		return nil, fmt.Errorf("unexpected end of input")
+		return nil, incomplete("unexpected end of input")
 	}
 
 	tok := r.peek()
@@ -47,19 +183,50 @@ func (r *Reader) readExpr() (sexpr.SExpr, error) {
 	switch tok.Type {
 	case LPAREN:
 		return r.readList()
+	case LBRACKET:
+		return r.readVector()
+	case LBRACE:
+		return r.readMap()
+	case SETOPEN:
+		return r.readSet()
+	case KEYWORD:
+		return sexpr.Keyword{Name: r.advance().Value}, nil
+	case TAG:
+		return r.readTagged()
+	case QUOTE:
+		return r.readQuoted("quote")
+	case QUASIQUOTE:
+		return r.readQuoted("quasiquote")
+	case UNQUOTE:
+		return r.readQuoted("unquote")
+	case UNQUOTESPLICING:
+		return r.readQuoted("unquote-splicing")
 	case NUMBER:
 		return r.readNumber()
 	case SYMBOL:
 		return r.readSymbol()
 	case STRING:
 		return r.readString()
+	case INTERPOLATED:
+		return r.readInterpolated()
 	case BOOL:
 		return r.readBool()
+	case NIL:
+		r.advance()
+		return sexpr.Nil{}, nil
+	case CHAR:
+		return r.readChar()
 	case RPAREN:
 		return nil, fmt.Errorf("unexpected closing paren at line %d, col %d",
 			tok.Line, tok.Col)
+	case RBRACKET, RBRACE:
+		return nil, fmt.Errorf("unexpected %q at line %d, col %d",
+			tok.Value, tok.Line, tok.Col)
+	case DOT:
+		return nil, fmt.Errorf("unexpected dot at line %d, col %d",
+			tok.Line, tok.Col)
 	case EOF:
-		return nil, fmt.Errorf("unexpected end of file")
+		return nil, incomplete("unexpected end of file")
 	default:
 		return nil, fmt.Errorf("unexpected token %v at line %d, col %d",
 			tok.Type, tok.Line, tok.Col)
@@ -68,11 +235,21 @@ func (r *Reader) readExpr() (sexpr.SExpr, error) {
 
 // readList reads a list expression
 func (r *Reader) readList() (sexpr.SExpr, error) {
-	r.advance() // consume LPAREN
+	open := r.advance() // consume LPAREN
 
 	elements := []sexpr.SExpr{}
 
-	for !r.isAtEnd() && r.peek().Type != RPAREN {
+	for {
+		if err := r.skipDiscarded(); err != nil {
+			return nil, err
+		}
+		if r.isAtEnd() || r.peek().Type == RPAREN {
+			break
+		}
+		if r.peek().Type == DOT {
+			return r.readDottedTail(elements)
+		}
+
 		expr, err := r.readExpr()
 		if err != nil {
 			return nil, err
@@ -81,19 +258,213 @@ func (r *Reader) readList() (sexpr.SExpr, error) {
 	}
 
 	if r.isAtEnd() {
-		return nil, fmt.Errorf("unclosed list")
+		return nil, incomplete("unclosed list")
 	}
 
 	r.advance() // consume RPAREN
 
-	return sexpr.List{Elements: elements}, nil
+	list := sexpr.List{Elements: elements}
+	if r.positions {
+		list.Meta = sexpr.PositionMeta(sexpr.Position{File: r.file, Line: open.Line, Col: open.Col})
+	}
+	return list, nil
+}
+
+// readSeq reads the elements of a bracketed sequence up to and including
+// the closing token
+func (r *Reader) readSeq(close TokenType, what string) ([]sexpr.SExpr, error) {
+	open := r.advance()
+
+	elements := []sexpr.SExpr{}
+	for {
+		if err := r.skipDiscarded(); err != nil {
+			return nil, err
+		}
+		if r.isAtEnd() {
+			return nil, incomplete("unclosed %s starting at line %d, col %d",
+				what, open.Line, open.Col)
+		}
+		if r.peek().Type == close {
+			r.advance()
+			return elements, nil
+		}
+
+		expr, err := r.readExpr()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, expr)
+	}
+}
+
+// readVector reads [a b c]
+func (r *Reader) readVector() (sexpr.SExpr, error) {
+	elements, err := r.readSeq(RBRACKET, "vector")
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.NewVector(elements...), nil
+}
+
+// readMap reads {k1 v1 k2 v2}
+func (r *Reader) readMap() (sexpr.SExpr, error) {
+	open := r.peek()
+	elements, err := r.readSeq(RBRACE, "map")
+	if err != nil {
+		return nil, err
+	}
+	if len(elements)%2 != 0 {
+		return nil, fmt.Errorf("map literal at line %d, col %d has an odd number of forms",
+			open.Line, open.Col)
+	}
+
+	m, err := sexpr.NewMap(elements...)
+	if err != nil {
+		return nil, fmt.Errorf("map literal at line %d, col %d: %v", open.Line, open.Col, err)
+	}
+	return m, nil
+}
+
+// readSet reads #{a b c}
+func (r *Reader) readSet() (sexpr.SExpr, error) {
+	open := r.peek()
+	elements, err := r.readSeq(RBRACE, "set")
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := sexpr.NewSet(elements...)
+	if err != nil {
+		return nil, fmt.Errorf("set literal at line %d, col %d: %v", open.Line, open.Col, err)
+	}
+	if s.Len() != len(elements) {
+		return nil, fmt.Errorf("set literal at line %d, col %d has duplicate elements",
+			open.Line, open.Col)
+	}
+	return s, nil
+}
+
+// readTagged reads a tagged literal such as #inst "2024-01-01". Apart
+// from byte vectors, tagged literals are only accepted in EDN mode.
+func (r *Reader) readTagged() (sexpr.SExpr, error) {
+	tok := r.advance()
+	if tok.Value == "u8" && r.peek().Type == LPAREN {
+		return r.readBytes(tok)
+	}
+	if !r.edn {
+		return nil, fmt.Errorf("unknown reader tag #%s at line %d, col %d",
+			tok.Value, tok.Line, tok.Col)
+	}
+
+	value, err := r.readExpr()
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.Tagged{Tag: sexpr.Symbol{Name: tok.Value}, Value: value}, nil
+}
+
+// readQuoted reads the form after a quote character, expanding 'x to
+// (quote x) and likewise for quasiquote, unquote and unquote-splicing
+func (r *Reader) readQuoted(name string) (sexpr.SExpr, error) {
+	r.advance()
+	expr, err := r.readExpr()
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: name}, expr}}, nil
+}
+
+// readBytes reads the list of octets in #u8(1 2 3)
+func (r *Reader) readBytes(tag Token) (sexpr.SExpr, error) {
+	elements, err := r.readSeq(RPAREN, "byte vector")
+	if err != nil {
+		return nil, err
+	}
+
+	octets := make([]byte, len(elements))
+	for i, elem := range elements {
+		n, ok := elem.(sexpr.Number)
+		if !ok || n.Value < 0 || n.Value > 255 {
+			return nil, fmt.Errorf("byte vector at line %d, col %d: expected byte, got %v",
+				tag.Line, tag.Col, elem)
+		}
+		octets[i] = byte(n.Value)
+	}
+	return sexpr.Bytes{Value: octets}, nil
+}
+
+// skipDiscarded skips any forms prefixed with #_
+func (r *Reader) skipDiscarded() error {
+	for r.peek().Type == DISCARD {
+		r.advance()
+		if _, err := r.readExpr(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDottedTail reads the tail of (a b . c) after the elements before
+// the dot, producing a chain of pairs
+func (r *Reader) readDottedTail(elements []sexpr.SExpr) (sexpr.SExpr, error) {
+	dot := r.advance() // consume DOT
+
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("unexpected dot at line %d, col %d",
+			dot.Line, dot.Col)
+	}
+
+	tail, err := r.readExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.isAtEnd() {
+		return nil, incomplete("unclosed list")
+	}
+	if tok := r.peek(); tok.Type != RPAREN {
+		return nil, fmt.Errorf("expected closing paren after dotted tail at line %d, col %d",
+			tok.Line, tok.Col)
+	}
+	r.advance() // consume RPAREN
+
+	for i := len(elements) - 1; i >= 0; i-- {
+		tail = sexpr.Cons(elements[i], tail)
+	}
+	return tail, nil
+}
+
+// specialFloats are the floats that have no digits, by their written form
+var specialFloats = map[string]float64{
+	"+inf.0": math.Inf(1),
+	"-inf.0": math.Inf(-1),
+	"+nan.0": math.NaN(),
 }
 
 // readNumber reads a number expression
 func (r *Reader) readNumber() (sexpr.SExpr, error) {
 	tok := r.advance()
 
-	value, err := strconv.ParseInt(tok.Value, 10, 64)
+	text := tok.Value
+	if value, ok := specialFloats[text]; ok {
+		return sexpr.Float{Value: value}, nil
+	}
+	if strings.HasSuffix(text, "M") || strings.ContainsAny(text, ".eE") {
+		value, err := strconv.ParseFloat(strings.TrimSuffix(text, "M"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at line %d, col %d: %v",
+				tok.Value, tok.Line, tok.Col, err)
+		}
+		return sexpr.Float{Value: value}, nil
+	}
+	text = strings.TrimSuffix(text, "N")
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if big, ok := new(big.Int).SetString(text, 10); ok {
+			return sexpr.BigInt{Value: big}, nil
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("invalid number %q at line %d, col %d: %v",
 			tok.Value, tok.Line, tok.Col, err)
@@ -114,6 +485,60 @@ func (r *Reader) readString() (sexpr.SExpr, error) {
 	return sexpr.String{Value: tok.Value}, nil
 }
 
+// readInterpolated reads an interpolated string. #"a ${x} b" reads as
+// (string-interpolate "a " x " b"), a special form that joins its values
+// like str; a string without interpolations reads as a plain string.
+// \$ writes a literal '$'.
+func (r *Reader) readInterpolated() (sexpr.SExpr, error) {
+	tok := r.advance()
+	raw := tok.Value
+
+	parts := []sexpr.SExpr{sexpr.Symbol{Name: "string-interpolate"}}
+	var text strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\':
+			i++
+			text.WriteByte(unescape(raw[i]))
+		case raw[i] == '$' && i+1 < len(raw) && raw[i+1] == '{':
+			end := interpolationEnd(raw, i+2)
+			expr, err := readInterpolation(raw[i+2 : end])
+			if err != nil {
+				return nil, fmt.Errorf("interpolated string at line %d, col %d: %w",
+					tok.Line, tok.Col, err)
+			}
+			if text.Len() > 0 {
+				parts = append(parts, sexpr.String{Value: text.String()})
+				text.Reset()
+			}
+			parts = append(parts, expr)
+			i = end
+		default:
+			text.WriteByte(raw[i])
+		}
+	}
+
+	if len(parts) == 1 {
+		return sexpr.String{Value: text.String()}, nil
+	}
+	if text.Len() > 0 {
+		parts = append(parts, sexpr.String{Value: text.String()})
+	}
+	return sexpr.List{Elements: parts}, nil
+}
+
+// readInterpolation reads the single expression inside ${...}
+func readInterpolation(src string) (sexpr.SExpr, error) {
+	tokens, err := Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, fmt.Errorf("empty interpolation")
+	}
+	return Read(tokens)
+}
+
 // readBool reads a boolean expression
 func (r *Reader) readBool() (sexpr.SExpr, error) {
 	tok := r.advance()
@@ -121,6 +546,17 @@ func (r *Reader) readBool() (sexpr.SExpr, error) {
 	return sexpr.Bool{Value: value}, nil
 }
 
+// readChar reads a character literal
+func (r *Reader) readChar() (sexpr.SExpr, error) {
+	tok := r.advance()
+	c, ok := sexpr.ParseChar(tok.Value)
+	if !ok {
+		return nil, fmt.Errorf("invalid character %q at line %d, col %d",
+			tok.Raw, tok.Line, tok.Col)
+	}
+	return c, nil
+}
+
 // Helper functions
 
 func (r *Reader) peek() Token {
@@ -1,7 +1,12 @@
 package parser
 
 import (
+	"errors"
+	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/zylisp/lang/sexpr"
@@ -15,6 +20,14 @@ func TestReaderNumbers(t *testing.T) {
 		{"42", sexpr.Number{Value: 42}},
 		{"-17", sexpr.Number{Value: -17}},
 		{"0", sexpr.Number{Value: 0}},
+		{"9223372036854775808", sexpr.BigInt{
+			Value: new(big.Int).Lsh(big.NewInt(1), 63)}},
+		{"2.5", sexpr.Float{Value: 2.5}},
+		{"-0.125", sexpr.Float{Value: -0.125}},
+		{"1e3", sexpr.Float{Value: 1000}},
+		{"6.02E-3", sexpr.Float{Value: 0.00602}},
+		{"+inf.0", sexpr.Float{Value: math.Inf(1)}},
+		{"-inf.0", sexpr.Float{Value: math.Inf(-1)}},
 	}
 
 	for _, tt := range tests {
@@ -34,6 +47,19 @@ func TestReaderNumbers(t *testing.T) {
 			}
 		})
 	}
+
+	// NaN is not equal to itself, so it is checked apart
+	tokens, err := Tokenize("+nan.0")
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+	result, err := Read(tokens)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if f, ok := result.(sexpr.Float); !ok || !math.IsNaN(f.Value) {
+		t.Errorf("got %v, want NaN", result)
+	}
 }
 
 func TestReaderSymbols(t *testing.T) {
@@ -182,6 +208,39 @@ func TestReaderStrings(t *testing.T) {
 	}
 }
 
+func TestReaderChars(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected sexpr.SExpr
+	}{
+		{`#\a`, sexpr.Char{Value: 'a'}},
+		{`#\A`, sexpr.Char{Value: 'A'}},
+		{`#\space`, sexpr.Char{Value: ' '}},
+		{`#\newline`, sexpr.Char{Value: '\n'}},
+		{`#\x3bb`, sexpr.Char{Value: 'λ'}},
+		{`#\)`, sexpr.Char{Value: ')'}},
+		{`(#\a #\b)`, sexpr.List{Elements: []sexpr.SExpr{sexpr.Char{Value: 'a'}, sexpr.Char{Value: 'b'}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			result, err := Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if !result.Equal(tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestReaderErrors(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -190,6 +249,8 @@ func TestReaderErrors(t *testing.T) {
 		{"unclosed list", "(+ 1 2"},
 		{"extra closing paren", "(+ 1 2))"},
 		{"just closing paren", ")"},
+		{"unknown character name", `#\bogus`},
+		{"missing character", `#\ `},
 	}
 
 	for _, tt := range tests {
@@ -207,3 +268,437 @@ func TestReaderErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestIncompleteInput(t *testing.T) {
+	tests := []struct {
+		input      string
+		incomplete bool
+	}{
+		{"(+ 1 2", true},
+		{"(a (b", true},
+		{"[1 2", true},
+		{"{:a 1", true},
+		{"#{1", true},
+		{"(a . b", true},
+		{"'", true},
+		{"#_", true},
+		{`"abc`, true},
+		{`(f "a\`, true},
+		{`#"x ${(+ 1`, true},
+		{"(+ 1 2))", false},
+		{")", false},
+		{"(a . )", false},
+		{`#\bogus`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ReadAll(tt.input)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if errors.Is(err, ErrIncomplete) != tt.incomplete {
+				t.Errorf("errors.Is(%v, ErrIncomplete) = %v, want %v", err, !tt.incomplete, tt.incomplete)
+			}
+		})
+	}
+
+	if _, err := ReadSource("f.zy", "(a"); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("ReadSource error %v is not ErrIncomplete", err)
+	}
+}
+
+func TestReadPrefix(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		n        int
+	}{
+		{"42", "42", 2},
+		{"  (a b) (c)", "(a b)", 7},
+		{"; comment\nsym rest", "sym", 13},
+		{"#_ skipped 'x", "(quote x)", 13},
+		{`"s" 1`, `"s"`, 3},
+		{"[1 2]\n", "[1 2]", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, n, err := ReadPrefix(tt.input)
+			if err != nil {
+				t.Fatalf("ReadPrefix error: %v", err)
+			}
+			if expr.String() != tt.expected || n != tt.n {
+				t.Errorf("got %v, %d, want %s, %d", expr, n, tt.expected, tt.n)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "   ", "; only a comment", "#_ x"} {
+		if _, _, err := ReadPrefix(input); err != io.EOF {
+			t.Errorf("ReadPrefix(%q) error %v, want io.EOF", input, err)
+		}
+	}
+	if _, _, err := ReadPrefix("(a"); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("ReadPrefix error %v is not ErrIncomplete", err)
+	}
+	if _, _, err := ReadPrefix(")"); err == nil || errors.Is(err, ErrIncomplete) {
+		t.Errorf("ReadPrefix error %v, want a syntax error", err)
+	}
+}
+
+func TestReaderIgnoresTrivia(t *testing.T) {
+	tokens, err := TokenizeWithTrivia("( + 1 ; one\n 2 )")
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+
+	result, err := Read(tokens)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	if result.String() != "(+ 1 2)" {
+		t.Errorf("got %v, want (+ 1 2)", result)
+	}
+}
+
+func TestReaderDottedPairs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected sexpr.SExpr
+	}{
+		{"(1 . 2)", sexpr.Cons(sexpr.Number{Value: 1}, sexpr.Number{Value: 2})},
+		{"(a b . rest)", sexpr.Cons(sexpr.Symbol{Name: "a"},
+			sexpr.Cons(sexpr.Symbol{Name: "b"}, sexpr.Symbol{Name: "rest"}))},
+		{"(a . (b))", sexpr.Cons(sexpr.Symbol{Name: "a"},
+			sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: "b"}}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			result, err := Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReaderDottedPairErrors(t *testing.T) {
+	for _, input := range []string{"(. a)", "(a . b c)", "(a .)", "."} {
+		t.Run(input, func(t *testing.T) {
+			tokens, err := Tokenize(input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			if _, err := Read(tokens); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReaderReadsWriteOutput(t *testing.T) {
+	values := []sexpr.SExpr{
+		sexpr.String{Value: "line\nbreak \"quoted\" back\\slash\ttab"},
+		sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.Symbol{Name: "f"},
+			sexpr.String{Value: "x"},
+			sexpr.Number{Value: -3},
+			sexpr.Bool{Value: true},
+		}},
+		sexpr.Cons(sexpr.Symbol{Name: "a"}, sexpr.Symbol{Name: "b"}),
+		sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.Char{Value: 'a'},
+			sexpr.Char{Value: ' '},
+			sexpr.Char{Value: 0},
+			sexpr.Char{Value: '('},
+		}},
+		sexpr.Nil{},
+		sexpr.List{Elements: []sexpr.SExpr{sexpr.Nil{}, sexpr.Symbol{Name: "nil"}}},
+		sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.Float{Value: math.Inf(1)},
+			sexpr.Float{Value: math.Inf(-1)},
+		}},
+		sexpr.List{Elements: []sexpr.SExpr{
+			sexpr.Symbol{Name: "a b"},
+			sexpr.Symbol{Name: "x|y\\z"},
+			sexpr.Symbol{Name: "|x"},
+			sexpr.Symbol{Name: "1"},
+			sexpr.Symbol{Name: "-2"},
+			sexpr.Symbol{Name: "+inf.0"},
+			sexpr.Symbol{Name: "true"},
+			sexpr.Symbol{Name: ":k"},
+			sexpr.Symbol{Name: ""},
+		}},
+	}
+
+	for _, value := range values {
+		written := sexpr.Write(value)
+		t.Run(written, func(t *testing.T) {
+			tokens, err := Tokenize(written)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			result, err := Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if !result.Equal(value) {
+				t.Errorf("got %v, want %v", result, value)
+			}
+		})
+	}
+}
+
+func TestReaderCollections(t *testing.T) {
+	m, _ := sexpr.NewMap(sexpr.Keyword{Name: "a"}, sexpr.Number{Value: 1})
+	set, _ := sexpr.NewSet(sexpr.Symbol{Name: "x"}, sexpr.String{Value: "y"})
+
+	tests := []struct {
+		input    string
+		expected sexpr.SExpr
+	}{
+		{":key", sexpr.Keyword{Name: "key"}},
+		{"[]", sexpr.NewVector()},
+		{"[1 (x)]", sexpr.NewVector(
+			sexpr.Number{Value: 1},
+			sexpr.List{Elements: []sexpr.SExpr{sexpr.Symbol{Name: "x"}}},
+		)},
+		{"{:a 1}", m},
+		{`#{x "y"}`, set},
+		{"#u8(0 255)", sexpr.Bytes{Value: []byte{0, 255}}},
+		{"[1 #_2 3 #_4]", sexpr.NewVector(
+			sexpr.Number{Value: 1}, sexpr.Number{Value: 3},
+		)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			result, err := Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if !result.Equal(tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReaderCollectionErrors(t *testing.T) {
+	for _, input := range []string{"[1 2", "{:a}", "#{1 1}", "(1]", "]", "#u8(256)", "#u8(a)", "#inst \"2024\""} {
+		t.Run(input, func(t *testing.T) {
+			tokens, err := Tokenize(input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			if _, err := Read(tokens); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReadEDN(t *testing.T) {
+	m, _ := sexpr.NewMap(
+		sexpr.Keyword{Name: "id"}, sexpr.Number{Value: 7},
+		sexpr.Keyword{Name: "owner"}, sexpr.Nil{},
+	)
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	tests := []struct {
+		input    string
+		expected sexpr.SExpr
+	}{
+		{"nil", sexpr.Nil{}},
+		{"{:id 7, :owner nil}", m},
+		{"2.5", sexpr.Float{Value: 2.5}},
+		{"1e3", sexpr.Float{Value: 1000}},
+		{"10N", sexpr.Number{Value: 10}},
+		{"123456789012345678901234567890N", sexpr.BigInt{Value: huge}},
+		{`#inst "2024-01-01"`, sexpr.Tagged{
+			Tag:   sexpr.Symbol{Name: "inst"},
+			Value: sexpr.String{Value: "2024-01-01"},
+		}},
+		{"#_ignored [a]", sexpr.NewVector(sexpr.Symbol{Name: "a"})},
+		{`[\a \space \u03bb]`, sexpr.NewVector(sexpr.Char{Value: 'a'}, sexpr.Char{Value: ' '}, sexpr.Char{Value: 'λ'})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ReadEDN(tt.input)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if !result.Equal(tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadEDNRoundTrip(t *testing.T) {
+	input := `{:name "zy" :tags #{:lisp} :versions [1 2.5] :released #inst "2024-01-01" :meta nil}`
+
+	value, err := ReadEDN(input)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	written, err := sexpr.WriteEDN(value)
+	if err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if written != input {
+		t.Errorf("got %s, want %s", written, input)
+	}
+}
+
+func TestReaderQuoteShorthand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"'x", "(quote x)"},
+		{"'(1 2)", "(quote (1 2))"},
+		{"`(a ,b ,@c)", "(quasiquote (a (unquote b) (unquote-splicing c)))"},
+		{"''x", "(quote (quote x))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			result, err := Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+
+	tokens, _ := Tokenize("'")
+	if _, err := Read(tokens); err == nil {
+		t.Error("expected error for quote without a form")
+	}
+}
+
+func TestReaderInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#"sum is ${(+ 1 2)}"`, `(string-interpolate "sum is " (+ 1 2))`},
+		{`#"${a} and ${b}!"`, `(string-interpolate a " and " b "!")`},
+		{`#"no holes"`, `"no holes"`},
+		{`#"tab\t\${x}"`, `"tab\t${x}"`},
+		{`#"${(f "}")}"`, `(string-interpolate (f "}"))`},
+		{`#"${{:a 1}}"`, `(string-interpolate {:a 1})`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			result, err := Read(tokens)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+
+			if result.String() != tt.expected {
+				t.Errorf("got %v, want %s", result, tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{`#"${}"`, `#"${1 2}"`, `#"${(}"`, `#"${x"`, `#"open`} {
+		t.Run(input, func(t *testing.T) {
+			tokens, err := Tokenize(input)
+			if err != nil {
+				return
+			}
+			if _, err := Read(tokens); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	exprs, err := ReadAll("(define x 1) ; comment\n#_ignored x \"s\"")
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	var got []string
+	for _, e := range exprs {
+		got = append(got, e.String())
+	}
+	if strings.Join(got, " ") != `(define x 1) x "s"` {
+		t.Errorf("got %v", got)
+	}
+
+	if exprs, err := ReadAll("  "); err != nil || len(exprs) != 0 {
+		t.Errorf("empty input: got %v, %v", exprs, err)
+	}
+	if _, err := ReadAll("(a"); err == nil {
+		t.Error("expected error for unterminated list")
+	}
+}
+
+func TestReadSourcePositions(t *testing.T) {
+	exprs, err := ReadSource("foo.zy", "(a)\n  (b (c))")
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	inner := exprs[1].(sexpr.List).Elements[1]
+	tests := []struct {
+		expr     sexpr.SExpr
+		expected string
+	}{
+		{exprs[0], "foo.zy:1:1"},
+		{exprs[1], "foo.zy:2:3"},
+		{inner, "foo.zy:2:6"},
+	}
+	for _, tt := range tests {
+		pos, ok := sexpr.PositionOf(tt.expr)
+		if !ok || pos.String() != tt.expected {
+			t.Errorf("%v: got %v, %v, want %s", tt.expr, pos, ok, tt.expected)
+		}
+	}
+
+	if _, err := ReadSource("bad.zy", "(a"); err == nil || !strings.HasPrefix(err.Error(), "bad.zy: ") {
+		t.Errorf("expected error naming the file, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Unparse regenerates source text from a token stream. For tokens
+// produced by TokenizeWithTrivia the result is identical to the original
+// input. Tokens without raw text (for example ones synthesized by a
+// refactoring tool) are rendered from their type and value.
+func Unparse(tokens []Token) string {
+	var out strings.Builder
+	for _, tok := range tokens {
+		out.WriteString(tokenText(tok))
+	}
+	return out.String()
+}
+
+// UnparseNormalized regenerates source text with minimal normalization:
+// line indentation and comments are kept, but runs of inline whitespace
+// collapse to a single space, trailing whitespace is removed, consecutive
+// blank lines collapse to one and the output ends with a single newline.
+// Adjacent tokens with no trivia between them are separated by a space
+// where needed, so plain Tokenize output can be unparsed too.
+func UnparseNormalized(tokens []Token) string {
+	var out strings.Builder
+	var prev *Token
+	lineStart := true
+	blankLines := 0
+
+	for i := range tokens {
+		tok := tokens[i]
+
+		switch tok.Type {
+		case EOF:
+			continue
+		case WHITESPACE:
+			newlines := strings.Count(tok.Value, "\n")
+			if newlines == 0 {
+				if !lineStart && i+1 < len(tokens) && !isLineEnd(tokens[i+1]) {
+					out.WriteByte(' ')
+				}
+				prev = nil
+				continue
+			}
+
+			if !lineStart {
+				out.WriteByte('\n')
+				newlines--
+			} else if out.Len() == 0 {
+				newlines = 0
+			}
+			if newlines > 0 && blankLines == 0 {
+				out.WriteByte('\n')
+				blankLines++
+			}
+
+			// Keep the indentation of the following line
+			indent := tok.Value[strings.LastIndex(tok.Value, "\n")+1:]
+			if i+1 < len(tokens) && !isLineEnd(tokens[i+1]) {
+				out.WriteString(indent)
+			}
+			lineStart = true
+			prev = nil
+			continue
+		}
+
+		if prev != nil && needsSpace(*prev, tok) {
+			out.WriteByte(' ')
+		}
+		out.WriteString(tokenText(tok))
+		lineStart = false
+		blankLines = 0
+		prev = &tokens[i]
+	}
+
+	result := strings.TrimRight(out.String(), " \t\n")
+	if result == "" {
+		return ""
+	}
+	return result + "\n"
+}
+
+// tokenText returns the source text for a token
+func tokenText(tok Token) string {
+	if tok.Raw != "" || tok.Type == EOF {
+		return tok.Raw
+	}
+	switch tok.Type {
+	case STRING:
+		return strconv.Quote(tok.Value)
+	case KEYWORD:
+		return ":" + tok.Value
+	case TAG:
+		return "#" + tok.Value
+	case INTERPOLATED:
+		return `#"` + tok.Value + `"`
+	case CHAR:
+		return `#\` + tok.Value
+	}
+	return tok.Value
+}
+
+// isLineEnd reports whether a token ends the current line
+func isLineEnd(tok Token) bool {
+	return tok.Type == EOF ||
+		(tok.Type == WHITESPACE && strings.Contains(tok.Value, "\n"))
+}
+
+// needsSpace reports whether two adjacent tokens must be separated to be
+// read back as two tokens
+func needsSpace(prev, next Token) bool {
+	switch prev.Type {
+	case LPAREN, LBRACKET, LBRACE, SETOPEN, DISCARD,
+		QUOTE, QUASIQUOTE, UNQUOTE, UNQUOTESPLICING:
+		return false
+	}
+	switch next.Type {
+	case RPAREN, RBRACKET, RBRACE:
+		return false
+	}
+	return true
+}
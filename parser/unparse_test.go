@@ -0,0 +1,92 @@
+package parser
+
+import "testing"
+
+func TestUnparseIdentity(t *testing.T) {
+	tests := []string{
+		"",
+		"42",
+		"(+ 1 2)",
+		"(a)(b)",
+		"; leading comment\n(define x   42) ; trailing\n\n\n  (f \"a\\tb\")\n",
+		"(lambda (x)\n  ;; body\n  (* x x))",
+		`(list #\a #\space #\))`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			tokens, err := TokenizeWithTrivia(input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			if got := Unparse(tokens); got != input {
+				t.Errorf("got %q, want %q", got, input)
+			}
+		})
+	}
+}
+
+func TestUnparseNormalized(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"collapses inline whitespace",
+			"(+   1\t2)",
+			"(+ 1 2)\n",
+		},
+		{
+			"strips trailing whitespace and blank lines",
+			"\n\n(a)   \n\n\n\n(b) ; note  \n\n",
+			"(a)\n\n(b) ; note\n",
+		},
+		{
+			"keeps indentation",
+			"(f\n    x)",
+			"(f\n    x)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := TokenizeWithTrivia(tt.input)
+			if err != nil {
+				t.Fatalf("tokenize error: %v", err)
+			}
+
+			if got := UnparseNormalized(tokens); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnparseWithoutTrivia(t *testing.T) {
+	tokens, err := Tokenize(`(define (f x) (g "s" x))`)
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+
+	expected := "(define (f x) (g \"s\" x))\n"
+	if got := UnparseNormalized(tokens); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
+
+func TestUnparseSynthesizedTokens(t *testing.T) {
+	tokens, err := TokenizeWithTrivia("(greet  \"bob\")")
+	if err != nil {
+		t.Fatalf("tokenize error: %v", err)
+	}
+
+	// Rewrite the string literal without touching the surrounding layout
+	tokens[3] = Token{Type: STRING, Value: "al\"ice"}
+
+	expected := "(greet  \"al\\\"ice\")"
+	if got := Unparse(tokens); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,263 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// errInterrupted is returned by a line reader when Ctrl-C is typed
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads lines of input, showing a prompt before each
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// plainReader reads lines without editing, for input that is not a
+// terminal
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *plainReader) readLine(prompt string) (string, error) {
+	io.WriteString(p.out, prompt)
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// terminalReader puts a terminal in raw mode while its editor reads a
+// line, so that output and interrupts behave normally in between
+type terminalReader struct {
+	fd     int
+	editor *editor
+}
+
+func (t *terminalReader) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return t.editor.readLine(prompt)
+}
+
+// editor reads a line from a terminal in raw mode, redrawing it as it is
+// edited. It understands the arrow, Home, End and Delete keys and the
+// usual Emacs control keys, recalls earlier lines from a history and
+// completes the word before the cursor on Tab.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete func(prefix string) []string // the names a word may complete to, if set
+
+	prompt string
+	line   []rune
+	pos    int    // the cursor's index in line
+	recall int    // the index of the history entry shown, or len(entries) for a new line
+	draft  []rune // the new line, kept while recalling history
+}
+
+// ctrl returns the character typed with the control key and c
+func ctrl(c rune) rune {
+	return c & 0x1f
+}
+
+func (e *editor) readLine(prompt string) (string, error) {
+	e.prompt, e.line, e.pos = prompt, nil, 0
+	e.recall, e.draft = len(e.history.entries), nil
+	e.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			io.WriteString(e.out, "\r\n")
+			return string(e.line), nil
+		case ctrl('C'):
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case ctrl('D'):
+			if len(e.line) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete()
+		case ctrl('H'), 127:
+			if e.pos > 0 {
+				e.pos--
+				e.delete()
+			}
+		case ctrl('A'):
+			e.pos = 0
+		case ctrl('E'):
+			e.pos = len(e.line)
+		case ctrl('B'):
+			e.pos = max(e.pos-1, 0)
+		case ctrl('F'):
+			e.pos = min(e.pos+1, len(e.line))
+		case ctrl('K'):
+			e.line = e.line[:e.pos]
+		case ctrl('U'):
+			e.line = append(e.line[:0], e.line[e.pos:]...)
+			e.pos = 0
+		case ctrl('P'):
+			e.browse(-1)
+		case ctrl('N'):
+			e.browse(1)
+		case '\t':
+			e.completeWord()
+		case '\x1b':
+			if err := e.escape(); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.insert(string(r))
+			}
+		}
+		e.refresh()
+	}
+}
+
+// escape handles the rest of an escape sequence, such as ESC [ A for
+// the up arrow
+func (e *editor) escape() error {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return err
+	}
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return err
+	}
+
+	// Keys such as Delete, ESC [ 3 ~, end with a tilde
+	if r >= '0' && r <= '9' {
+		code := r
+		for r != '~' {
+			if r, _, err = e.in.ReadRune(); err != nil {
+				return err
+			}
+		}
+		r = code
+	}
+
+	switch r {
+	case 'A':
+		e.browse(-1)
+	case 'B':
+		e.browse(1)
+	case 'C':
+		e.pos = min(e.pos+1, len(e.line))
+	case 'D':
+		e.pos = max(e.pos-1, 0)
+	case 'H', '1', '7':
+		e.pos = 0
+	case 'F', '4', '8':
+		e.pos = len(e.line)
+	case '3':
+		e.delete()
+	}
+	return nil
+}
+
+// insert adds text at the cursor and moves the cursor past it
+func (e *editor) insert(text string) {
+	runes := []rune(text)
+	e.line = append(e.line[:e.pos], append(runes, e.line[e.pos:]...)...)
+	e.pos += len(runes)
+}
+
+// completeWord completes the word before the cursor. A single completion
+// is inserted; several are extended to their longest common prefix, or
+// listed below the line if that adds nothing.
+func (e *editor) completeWord() {
+	start := e.pos
+	for start > 0 && !isDelimiter(e.line[start-1]) {
+		start--
+	}
+	if e.complete == nil || start == e.pos {
+		return
+	}
+
+	word := string(e.line[start:e.pos])
+	candidates := e.complete(word)
+	if len(candidates) == 0 {
+		return
+	}
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	if len(common) > len(word) {
+		e.insert(common[len(word):])
+		return
+	}
+	fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
+
+// isDelimiter reports whether r ends a symbol
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("()[]{}'`,\";", r)
+}
+
+// delete removes the character under the cursor
+func (e *editor) delete() {
+	if e.pos < len(e.line) {
+		e.line = append(e.line[:e.pos], e.line[e.pos+1:]...)
+	}
+}
+
+// browse replaces the line with the history entry delta places from the
+// one shown, going back to the new line after the last entry
+func (e *editor) browse(delta int) {
+	entries := e.history.entries
+	next := e.recall + delta
+	if next < 0 || next > len(entries) {
+		return
+	}
+	if e.recall == len(entries) {
+		e.draft = e.line
+	}
+
+	e.recall = next
+	if next == len(entries) {
+		e.line = e.draft
+	} else {
+		e.line = []rune(entries[next])
+	}
+	e.pos = len(e.line)
+}
+
+// refresh redraws the prompt and line and puts the cursor in place
+func (e *editor) refresh() {
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(e.prompt)
+	b.WriteString(string(e.line))
+	b.WriteString("\x1b[K")
+	if n := len(e.line) - e.pos; n > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", n)
+	}
+	io.WriteString(e.out, b.String())
+}
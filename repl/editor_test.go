@@ -0,0 +1,148 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEditor(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"typing", "(+ 1 2)\r", "(+ 1 2)"},
+		{"newline", "abc\n", "abc"},
+		{"backspace", "abx\x7fc\r", "abc"},
+		{"backspace at start", "\x7f\x08ab\r", "ab"},
+		{"left arrow", "ac\x1b[Db\r", "abc"},
+		{"right arrow", "ac\x1b[D\x1b[C\x1b[Cd\r", "acd"},
+		{"home and end", "bc\x1b[Ha\x1b[Fd\r", "abcd"},
+		{"home and end with tildes", "bc\x1b[1~a\x1b[4~d\r", "abcd"},
+		{"delete key", "abc\x1b[D\x1b[D\x1b[3~\r", "ac"},
+		{"control keys", "bc\x01a\x05d\x02\x02\x04\r", "abd"},
+		{"kill to end", "abcd\x02\x02\x0b\r", "ab"},
+		{"kill to start", "abcd\x02\x15\r", "d"},
+		{"unicode", "λx\x1b[Dy\r", "λyx"},
+		{"control characters ignored", "a\x07\tb\r", "ab"},
+		{"history", "\x1b[A\r", "(second)"},
+		{"history twice", "\x1b[A\x1b[A\r", "(first)"},
+		{"history stops at the oldest", "\x1b[A\x1b[A\x1b[A\r", "(first)"},
+		{"history back to draft", "dr\x1b[A\x1b[Baft\r", "draft"},
+		{"history with control keys", "\x10\x10\x0e\r", "(second)"},
+		{"edit recalled entry", "\x1b[A\x7f x)\r", "(second x)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			e := &editor{
+				in:      bufio.NewReader(strings.NewReader(tt.keys)),
+				out:     &out,
+				history: &history{entries: []string{"(first)", "(second)"}},
+			}
+			line, err := e.readLine("> ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != tt.expected {
+				t.Errorf("got %q, want %q", line, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEditorEndings(t *testing.T) {
+	tests := []struct {
+		keys string
+		err  error
+	}{
+		{"ab\x03", errInterrupted},
+		{"\x04", io.EOF},
+		{"ab", io.EOF},
+	}
+
+	for _, tt := range tests {
+		e := &editor{
+			in:      bufio.NewReader(strings.NewReader(tt.keys)),
+			out:     io.Discard,
+			history: &history{},
+		}
+		if _, err := e.readLine("> "); !errors.Is(err, tt.err) {
+			t.Errorf("%q: got %v, want %v", tt.keys, err, tt.err)
+		}
+	}
+}
+
+func TestEditorCompletion(t *testing.T) {
+	names := []string{"define", "define-record-type", "defmacro", "display", "éa", "éb"}
+	complete := func(prefix string) []string {
+		var matches []string
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				matches = append(matches, name)
+			}
+		}
+		return matches
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+		listed   bool
+	}{
+		{"(disp\t x)\r", "(display x)", false},
+		{"(define-r\t\r", "(define-record-type", false},
+		{"(defi\t\r", "(define", false},
+		{"(def\t\r", "(def", true},
+		{"(é\t\r", "(é", true},
+		{"(xyz\t\r", "(xyz", false},
+		{"(\t\r", "(", false},
+		{"(dis)\x1b[D\t\r", "(display)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keys, func(t *testing.T) {
+			var out bytes.Buffer
+			e := &editor{
+				in:       bufio.NewReader(strings.NewReader(tt.keys)),
+				out:      &out,
+				history:  &history{},
+				complete: complete,
+			}
+			line, err := e.readLine("> ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != tt.expected {
+				t.Errorf("got %q, want %q", line, tt.expected)
+			}
+			if listed := strings.Contains(out.String(), "  "); listed != tt.listed {
+				t.Errorf("completions listed: %v, want %v", listed, tt.listed)
+			}
+		})
+	}
+}
+
+func TestEditorRedraw(t *testing.T) {
+	var out bytes.Buffer
+	e := &editor{
+		in:      bufio.NewReader(strings.NewReader("ab\x1b[D\r")),
+		out:     &out,
+		history: &history{},
+	}
+	if _, err := e.readLine("> "); err != nil {
+		t.Fatal(err)
+	}
+
+	// The line is redrawn after each key, with the cursor moved back
+	// from the end to its place
+	want := "\r> \x1b[K\r> a\x1b[K\r> ab\x1b[K\r> ab\x1b[K\x1b[1D\r\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,71 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// history is the list of inputs entered, oldest first, which may also be
+// kept in a file with one entry per line
+type history struct {
+	entries []string
+	max     int    // the most entries kept, or 0 for no limit
+	file    string // where entries are saved, if set
+}
+
+// load reads the entries saved in the history file, if there is one
+func (h *history) load() error {
+	if h.file == "" {
+		return nil
+	}
+	f, err := os.Open(h.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	h.trim()
+	return scanner.Err()
+}
+
+// add records an entry, unless it is empty or repeats the last one, and
+// appends it to the history file
+func (h *history) add(entry string) error {
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return nil
+	}
+	h.entries = append(h.entries, entry)
+	h.trim()
+
+	if h.file == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, entry); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// trim drops the oldest entries beyond the limit
+func (h *history) trim() {
+	if h.max > 0 && len(h.entries) > h.max {
+		h.entries = append([]string(nil), h.entries[len(h.entries)-h.max:]...)
+	}
+}
@@ -0,0 +1,241 @@
+// Package repl provides an interactive read-eval-print loop for zylisp.
+//
+// The loop reads expressions from its input, evaluates them in an
+// environment and prints their values. Input that ends inside an
+// expression, such as an unclosed list, is continued on the next line.
+// On a terminal, lines can be edited, earlier input recalled with the
+// arrow keys and names completed with Tab; Ctrl-C abandons the line
+// being typed, or interrupts the evaluation in progress without ending
+// the loop.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/zylisp/lang/interpreter"
+	"github.com/zylisp/lang/parser"
+	"github.com/zylisp/lang/sexpr"
+)
+
+// REPL is a read-eval-print loop. Change its fields before calling Run.
+type REPL struct {
+	Env *interpreter.Env
+
+	In  io.Reader // where input is read, os.Stdin by default
+	Out io.Writer // where prompts and values are written, os.Stdout by default
+	Err io.Writer // where errors are written, os.Stderr by default
+
+	Prompt   string // shown before an expression, "zy> " by default
+	Continue string // shown before each further line of one, "... " by default
+
+	// HistoryFile, if set, is where input is saved, so that it can be
+	// recalled in later sessions
+	HistoryFile string
+
+	history history
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // interrupts the evaluation in progress
+}
+
+// New returns a REPL evaluating in env, reading standard input and
+// writing standard output
+func New(env *interpreter.Env) *REPL {
+	return &REPL{
+		Env:      env,
+		In:       os.Stdin,
+		Out:      os.Stdout,
+		Err:      os.Stderr,
+		Prompt:   "zy> ",
+		Continue: "... ",
+		history:  history{max: 1000},
+	}
+}
+
+// History returns the inputs entered so far, oldest first, including
+// those loaded from HistoryFile
+func (r *REPL) History() []string {
+	return append([]string(nil), r.history.entries...)
+}
+
+// Run reads, evaluates and prints until the input ends or ctx is done.
+// Errors are reported and the loop carries on; an *interpreter.ExitError
+// from a call to exit ends it and is returned. While Run is evaluating,
+// an interrupt signal stops the evaluation as Interrupt does; while it
+// reads input, the signal has its default effect. Work an input starts
+// in the background, such as a future, is not interrupted with it but
+// runs until Run returns.
+func (r *REPL) Run(ctx context.Context) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	r.history.file = r.HistoryFile
+	if err := r.history.load(); err != nil {
+		fmt.Fprintf(r.Err, "history: %v\n", err)
+	}
+
+	lines := r.lineReader()
+	var pending []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		prompt := r.Prompt
+		if len(pending) > 0 {
+			prompt = r.Continue
+		}
+		line, err := lines.readLine(prompt)
+		if errors.Is(err, errInterrupted) {
+			pending = nil
+			continue
+		}
+		if err == io.EOF {
+			if len(pending) > 0 {
+				fmt.Fprintln(r.Err, "error: unexpected end of input")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pending = append(pending, line)
+		source := strings.Join(pending, "\n")
+		forms, err := parser.ReadAll(source)
+		if errors.Is(err, parser.ErrIncomplete) {
+			continue
+		}
+		if err := r.history.add(historyEntry(pending)); err != nil {
+			fmt.Fprintf(r.Err, "history: %v\n", err)
+		}
+		pending = nil
+		if err != nil {
+			fmt.Fprintf(r.Err, "error: %v\n", err)
+			continue
+		}
+
+		if err := r.evalAll(ctx, forms); err != nil {
+			return err
+		}
+	}
+}
+
+// historyEntry joins the lines of an input into one line to keep in the
+// history
+func historyEntry(lines []string) string {
+	trimmed := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			trimmed = append(trimmed, line)
+		}
+	}
+	return strings.Join(trimmed, " ")
+}
+
+// evalAll evaluates forms in turn, printing their values, and stops at
+// the first error. It returns only the errors that end the loop.
+func (r *REPL) evalAll(ctx context.Context, forms []sexpr.SExpr) error {
+	for _, form := range forms {
+		value, err := r.eval(ctx, form)
+		var exit *interpreter.ExitError
+		switch {
+		case errors.As(err, &exit):
+			return err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, context.Canceled):
+			fmt.Fprintln(r.Err, "interrupted")
+			return nil
+		case err != nil:
+			fmt.Fprintf(r.Err, "error: %v\n", err)
+			return nil
+		}
+		r.print(value)
+	}
+	return nil
+}
+
+// eval evaluates form so that Interrupt or an interrupt signal can stop
+// it, but not the background work it starts, which runs under session.
+// The signal is only caught for the evaluation.
+func (r *REPL) eval(session context.Context, form sexpr.SExpr) (sexpr.SExpr, error) {
+	ctx, cancel := context.WithCancel(session)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	r.mu.Lock()
+	r.cancel = cancel
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.cancel = nil
+		r.mu.Unlock()
+		cancel()
+	}()
+	return interpreter.EvalSession(session, ctx, form, r.Env)
+}
+
+// Complete returns the names starting with prefix that input may refer
+// to, as the editor offers them on Tab
+func (r *REPL) Complete(prefix string) []string {
+	if r.Env == nil {
+		return nil
+	}
+	return r.Env.Complete(prefix)
+}
+
+// Interrupt stops the evaluation in progress, if any. Run reports the
+// interruption and reads the next expression.
+func (r *REPL) Interrupt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// print writes value as data, each of several values on its own line.
+// Nil, the value of most forms run for their effects, is not shown.
+func (r *REPL) print(value sexpr.SExpr) {
+	if values, ok := value.(sexpr.Values); ok {
+		for _, v := range values.Elements {
+			fmt.Fprintln(r.Out, sexpr.Write(v))
+		}
+		return
+	}
+	if _, ok := value.(sexpr.Nil); ok {
+		return
+	}
+	fmt.Fprintln(r.Out, sexpr.Write(value))
+}
+
+// lineReader returns an editor if the REPL reads from and writes to a
+// terminal, and a plain reader otherwise
+func (r *REPL) lineReader() lineReader {
+	in := bufio.NewReader(r.In)
+	inFile, inOK := r.In.(*os.File)
+	outFile, outOK := r.Out.(*os.File)
+	if inOK && outOK && isTerminal(int(inFile.Fd())) && isTerminal(int(outFile.Fd())) {
+		return &terminalReader{
+			fd:     int(inFile.Fd()),
+			editor: &editor{in: in, out: r.Out, history: &r.history, complete: r.Complete},
+		}
+	}
+	return &plainReader{in: in, out: r.Out}
+}
@@ -0,0 +1,220 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zylisp/lang/interpreter"
+)
+
+// newTestREPL returns a REPL reading input and recording what it writes
+func newTestREPL(input string) (*REPL, *bytes.Buffer, *bytes.Buffer) {
+	env := interpreter.NewEnv(nil)
+	interpreter.LoadPrimitives(env)
+	r := New(env)
+	var out, errs bytes.Buffer
+	r.In, r.Out, r.Err = strings.NewReader(input), &out, &errs
+	return r, &out, &errs
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+		errors string
+	}{
+		{"values", "(+ 1 2)\n\"hi\"\n", "zy> 3\nzy> \"hi\"\nzy> ", ""},
+		{"several forms on a line", "(define x 2) (* x 3)\n", "zy> 2\n6\nzy> ", ""},
+		{"nil is not shown", "(when false 1)\n", "zy> zy> ", ""},
+		{"multiple values", "(values 1 2)\n", "zy> 1\n2\nzy> ", ""},
+		{"continuation", "(+ 1\n2\n)\n", "zy> ... ... 3\nzy> ", ""},
+		{"unterminated string", "(str \"a\nb\")\n", "zy> ... \"a\\nb\"\nzy> ", ""},
+		{"blank lines", "\n\n1\n", "zy> zy> zy> 1\nzy> ", ""},
+		{"last line without newline", "(+ 1 2)", "zy> 3\nzy> ", ""},
+		{"errors continue", "(car 1)\n5\n", "zy> zy> 5\nzy> ", "error: car:"},
+		{"error stops the line", "(car 1) 5\n", "zy> zy> ", "error: car:"},
+		{"read error", ")\n1\n", "zy> zy> 1\nzy> ", "error: "},
+		{"incomplete at end", "(+ 1\n", "zy> ... ", "error: unexpected end of input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, out, errs := newTestREPL(tt.input)
+			if err := r.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.output {
+				t.Errorf("output %q, want %q", out, tt.output)
+			}
+			if !strings.HasPrefix(errs.String(), tt.errors) || (tt.errors == "") != (errs.Len() == 0) {
+				t.Errorf("errors %q, want %q", errs, tt.errors)
+			}
+		})
+	}
+}
+
+func TestRunExit(t *testing.T) {
+	r, out, _ := newTestREPL("(exit 3)\n(+ 1 2)\n")
+	err := r.Run(context.Background())
+	var exit *interpreter.ExitError
+	if !errors.As(err, &exit) || exit.Code != 3 {
+		t.Fatalf("got %v, want exit status 3", err)
+	}
+	if out.String() != "zy> " {
+		t.Errorf("evaluation continued after exit: %q", out)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	r, _, _ := newTestREPL("1\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+// waitForEval waits until r is evaluating
+func waitForEval(t *testing.T, r *REPL) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mu.Lock()
+		running := r.cancel != nil
+		r.mu.Unlock()
+		if running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("evaluation did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackgroundOutlivesInput(t *testing.T) {
+	input := "(define (spin n) (if (= n 0) 'done (spin (- n 1))))\n" +
+		"(define f (future (spin 300000)))\n" +
+		"(await f)\n" +
+		"(define c (chan 1))\n" +
+		"(go (begin (spin 100000) (send! c 7)))\n" +
+		"(recv! c)\n"
+	r, out, errs := newTestREPL(input)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if errs.Len() > 0 {
+		t.Errorf("errors %q", errs)
+	}
+	if !strings.Contains(out.String(), "done\n") || !strings.Contains(out.String(), "7\n") {
+		t.Errorf("background work did not finish: %q", out)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	r, out, errs := newTestREPL("(loop ((i 0)) (recur (+ i 1)))\n(+ 1 2)\n")
+	result := make(chan error)
+	go func() { result <- r.Run(context.Background()) }()
+
+	waitForEval(t, r)
+	r.Interrupt()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Interrupt did not stop the evaluation")
+	}
+	if errs.String() != "interrupted\n" {
+		t.Errorf("errors %q, want interrupted", errs)
+	}
+	if !strings.Contains(out.String(), "3\n") {
+		t.Errorf("the loop did not carry on after the interrupt: %q", out)
+	}
+}
+
+func TestInterruptSignal(t *testing.T) {
+	r, _, errs := newTestREPL("(loop ((i 0)) (recur (+ i 1)))\n")
+	result := make(chan error)
+	go func() { result <- r.Run(context.Background()) }()
+
+	waitForEval(t, r)
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot send an interrupt: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the signal did not stop the evaluation")
+	}
+	if errs.String() != "interrupted\n" {
+		t.Errorf("errors %q, want interrupted", errs)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(file, []byte("(old)\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _, _ := newTestREPL("(+ 1\n  2)\n(+ 1 2)\n\n3\n3\n")
+	r.HistoryFile = file
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"(old)", "(+ 1 2)", "3"}
+	if got := r.History(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("history %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "(old)\n(+ 1 2)\n3\n" {
+		t.Errorf("history file %q", data)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	h := history{max: 2}
+	for _, entry := range []string{"a", "b", "c"} {
+		if err := h.add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(h.entries, "") != "bc" {
+		t.Errorf("entries %q, want [b c]", h.entries)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	r, _, _ := newTestREPL("")
+	got := r.Complete("string-tr")
+	if len(got) != 1 || got[0] != "string-trim" {
+		t.Errorf("got %q, want [string-trim]", got)
+	}
+	if got := (&REPL{}).Complete("str"); got != nil {
+		t.Errorf("got %q without an environment", got)
+	}
+}
@@ -0,0 +1,68 @@
+package sexpr
+
+import "sync/atomic"
+
+// Atom is a mutable reference to a value that goroutines can share.
+// Updates replace the value atomically; Swap retries its update function
+// until it applies to the current value. Atoms are compared by identity.
+type Atom struct {
+	value atomic.Pointer[atomValue]
+}
+
+// atomValue boxes a value so that updates can compare and swap pointers
+type atomValue struct {
+	v SExpr
+}
+
+// NewAtom returns an atom holding v
+func NewAtom(v SExpr) *Atom {
+	a := &Atom{}
+	a.value.Store(&atomValue{v: v})
+	return a
+}
+
+// Deref returns the atom's current value
+func (a *Atom) Deref() SExpr {
+	return a.value.Load().v
+}
+
+// Reset sets the atom's value to v
+func (a *Atom) Reset(v SExpr) {
+	a.value.Store(&atomValue{v: v})
+}
+
+// Swap sets the atom's value to f applied to the current value and
+// returns the new value. If another goroutine changes the atom while f
+// runs, f is called again with the newer value, so it should be free of
+// side effects. An error from f leaves the atom unchanged.
+func (a *Atom) Swap(f func(SExpr) (SExpr, error)) (SExpr, error) {
+	for {
+		old := a.value.Load()
+		v, err := f(old.v)
+		if err != nil {
+			return nil, err
+		}
+		if a.value.CompareAndSwap(old, &atomValue{v: v}) {
+			return v, nil
+		}
+	}
+}
+
+// CompareAndSet sets the atom's value to v if its current value is Equal
+// to old, reporting whether it did
+func (a *Atom) CompareAndSet(old, v SExpr) bool {
+	current := a.value.Load()
+	if !current.v.Equal(old) {
+		return false
+	}
+	return a.value.CompareAndSwap(current, &atomValue{v: v})
+}
+
+func (a *Atom) String() string {
+	return Write(a)
+}
+
+func (a *Atom) Equal(other SExpr) bool {
+	o, ok := other.(*Atom)
+	return ok && a == o
+}
@@ -0,0 +1,62 @@
+package sexpr
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestAtom(t *testing.T) {
+	a := NewAtom(Number{Value: 1})
+	if a.String() != "#<atom 1>" {
+		t.Errorf("String() = %q", a.String())
+	}
+
+	a.Reset(Number{Value: 5})
+	if !a.Deref().Equal(Number{Value: 5}) {
+		t.Errorf("Deref() = %v, want 5", a.Deref())
+	}
+
+	if a.CompareAndSet(Number{Value: 4}, Number{Value: 6}) {
+		t.Error("CompareAndSet succeeded with a stale value")
+	}
+	if !a.CompareAndSet(Number{Value: 5}, Number{Value: 6}) {
+		t.Error("CompareAndSet failed with the current value")
+	}
+
+	boom := errors.New("boom")
+	if _, err := a.Swap(func(SExpr) (SExpr, error) { return nil, boom }); err != boom {
+		t.Errorf("got error %v, want boom", err)
+	}
+	if !a.Deref().Equal(Number{Value: 6}) {
+		t.Errorf("failed Swap changed the value to %v", a.Deref())
+	}
+
+	b := NewAtom(Number{Value: 6})
+	if !a.Equal(a) || a.Equal(b) {
+		t.Error("atoms should be equal only to themselves")
+	}
+}
+
+func TestAtomConcurrentSwap(t *testing.T) {
+	a := NewAtom(Number{Value: 0})
+	inc := func(v SExpr) (SExpr, error) {
+		return Number{Value: v.(Number).Value + 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.Swap(inc)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !a.Deref().Equal(Number{Value: 1000}) {
+		t.Errorf("got %v, want 1000", a.Deref())
+	}
+}
@@ -0,0 +1,35 @@
+package sexpr
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Bytes is an immutable byte vector written #u8(1 2 3). Value must not
+// be modified once the Bytes is shared.
+type Bytes struct {
+	Value []byte
+}
+
+func (b Bytes) String() string {
+	var s strings.Builder
+	s.WriteString("#u8(")
+	for i, octet := range b.Value {
+		if i > 0 {
+			s.WriteByte(' ')
+		}
+		s.WriteString(strconv.Itoa(int(octet)))
+	}
+	s.WriteByte(')')
+	return s.String()
+}
+
+func (b Bytes) Equal(other SExpr) bool {
+	o, ok := other.(Bytes)
+	return ok && bytes.Equal(b.Value, o.Value)
+}
+
+func (b Bytes) Hash() uint64 {
+	return hashBytes(tagBytes, b.Value)
+}
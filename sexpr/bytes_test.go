@@ -0,0 +1,30 @@
+package sexpr
+
+import "testing"
+
+func TestBytes(t *testing.T) {
+	b := Bytes{Value: []byte{1, 2, 255}}
+	if got := b.String(); got != "#u8(1 2 255)" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Bytes{}).String(); got != "#u8()" {
+		t.Errorf("empty String() = %q", got)
+	}
+
+	same := Bytes{Value: []byte{1, 2, 255}}
+	if !b.Equal(same) {
+		t.Error("equal bytes should be Equal")
+	}
+	if b.Equal(List{Elements: []SExpr{Number{Value: 1}, Number{Value: 2}, Number{Value: 255}}}) {
+		t.Error("bytes should not equal a list of numbers")
+	}
+
+	bh, _ := Hash(b)
+	sh, _ := Hash(same)
+	if bh != sh {
+		t.Error("equal bytes should hash equally")
+	}
+	if _, err := WriteEDN(b); err == nil {
+		t.Error("expected WriteEDN to reject bytes")
+	}
+}
@@ -0,0 +1,83 @@
+package sexpr
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned when sending on a closed channel
+var ErrClosed = errors.New("send on closed channel")
+
+// Channel is a Go channel of values for communicating between goroutines.
+// Channels are reference values: two channels are Equal only if they are
+// the same channel.
+type Channel struct {
+	ch   chan SExpr
+	done chan struct{}
+	once sync.Once
+}
+
+// NewChannel returns a channel buffering up to size values; 0 makes an
+// unbuffered channel
+func NewChannel(size int) *Channel {
+	return &Channel{ch: make(chan SExpr, size), done: make(chan struct{})}
+}
+
+// Send sends v, blocking until it is received or buffered. It fails if
+// the channel is closed or ctx is done first.
+func (c *Channel) Send(ctx context.Context, v SExpr) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case c.ch <- v:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Recv receives a value, blocking until one is available. Once the
+// channel is closed and its buffer drained, it returns false. It fails
+// if ctx is done first.
+func (c *Channel) Recv(ctx context.Context) (SExpr, bool, error) {
+	select {
+	case v := <-c.ch:
+		return v, true, nil
+	case <-c.done:
+		// Values buffered before the close are still delivered
+		select {
+		case v := <-c.ch:
+			return v, true, nil
+		default:
+			return nil, false, nil
+		}
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// Close closes the channel, waking blocked senders and receivers.
+// Closing a channel twice is not an error.
+func (c *Channel) Close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// Cap returns the size of the channel's buffer
+func (c *Channel) Cap() int {
+	return cap(c.ch)
+}
+
+func (c *Channel) String() string {
+	return "#<channel>"
+}
+
+func (c *Channel) Equal(other SExpr) bool {
+	o, ok := other.(*Channel)
+	return ok && c == o
+}
@@ -0,0 +1,85 @@
+package sexpr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChannelSendRecv(t *testing.T) {
+	ctx := context.Background()
+	c := NewChannel(0)
+
+	go func() {
+		for i := int64(1); i <= 3; i++ {
+			if err := c.Send(ctx, Number{Value: i}); err != nil {
+				t.Errorf("send: %v", err)
+			}
+		}
+		c.Close()
+	}()
+
+	for i := int64(1); i <= 3; i++ {
+		v, ok, err := c.Recv(ctx)
+		if err != nil || !ok {
+			t.Fatalf("recv: %v, %v", ok, err)
+		}
+		if !v.Equal(Number{Value: i}) {
+			t.Errorf("got %v, want %d", v, i)
+		}
+	}
+
+	if _, ok, err := c.Recv(ctx); ok || err != nil {
+		t.Errorf("recv on closed channel = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestChannelClose(t *testing.T) {
+	ctx := context.Background()
+	c := NewChannel(2)
+	if c.Cap() != 2 {
+		t.Errorf("Cap() = %d, want 2", c.Cap())
+	}
+
+	if err := c.Send(ctx, Number{Value: 1}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	c.Close()
+	c.Close()
+
+	if err := c.Send(ctx, Number{Value: 2}); !errors.Is(err, ErrClosed) {
+		t.Errorf("send on closed channel: got %v, want ErrClosed", err)
+	}
+
+	// Buffered values survive the close
+	if v, ok, _ := c.Recv(ctx); !ok || !v.Equal(Number{Value: 1}) {
+		t.Errorf("got %v, %v; want 1, true", v, ok)
+	}
+	if _, ok, _ := c.Recv(ctx); ok {
+		t.Error("expected closed channel to be drained")
+	}
+}
+
+func TestChannelContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c := NewChannel(0)
+
+	if _, _, err := c.Recv(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("recv: got %v, want deadline exceeded", err)
+	}
+	if err := c.Send(ctx, Nil{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("send: got %v, want deadline exceeded", err)
+	}
+}
+
+func TestChannelEqual(t *testing.T) {
+	a, b := NewChannel(0), NewChannel(0)
+	if !a.Equal(a) || a.Equal(b) {
+		t.Error("channels should be equal only to themselves")
+	}
+	if a.String() != "#<channel>" {
+		t.Errorf("String() = %q", a.String())
+	}
+}
@@ -0,0 +1,96 @@
+package sexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Char represents a single Unicode character
+type Char struct {
+	Value rune
+}
+
+// charNames are the characters written by name rather than as themselves
+var charNames = map[string]rune{
+	"nul":       0,
+	"alarm":     '\a',
+	"backspace": '\b',
+	"tab":       '\t',
+	"newline":   '\n',
+	"return":    '\r',
+	"escape":    0x1b,
+	"space":     ' ',
+	"delete":    0x7f,
+}
+
+// String writes c as #\ followed by the character, its name or, for
+// other unprintable characters, x and its hex code
+func (c Char) String() string {
+	return `#\` + c.name()
+}
+
+// name returns the text of c after the #\ prefix
+func (c Char) name() string {
+	for name, r := range charNames {
+		if r == c.Value {
+			return name
+		}
+	}
+	if unicode.IsGraphic(c.Value) && !unicode.IsSpace(c.Value) {
+		return string(c.Value)
+	}
+	return fmt.Sprintf("x%x", c.Value)
+}
+
+func (c Char) Equal(other SExpr) bool {
+	o, ok := other.(Char)
+	return ok && c.Value == o.Value
+}
+
+func (c Char) Hash() uint64 {
+	return hashInt64(tagChar, int64(c.Value))
+}
+
+// ParseChar returns the character written as #\text: a single character,
+// one of the names such as space and newline, or x or u followed by a
+// hex code
+func ParseChar(text string) (Char, bool) {
+	if r, size := utf8.DecodeRuneInString(text); size == len(text) && r != utf8.RuneError {
+		return Char{Value: r}, true
+	}
+	if r, ok := charNames[text]; ok {
+		return Char{Value: r}, true
+	}
+	if strings.HasPrefix(text, "x") || strings.HasPrefix(text, "u") {
+		code, err := strconv.ParseUint(text[1:], 16, 32)
+		if err == nil && utf8.ValidRune(rune(code)) {
+			return Char{Value: rune(code)}, true
+		}
+	}
+	return Char{}, false
+}
+
+// ednChar writes c in EDN syntax, a backslash followed by the character,
+// one of the names EDN knows or u and a four digit hex code
+func ednChar(c Char) (string, bool) {
+	switch c.Value {
+	case '\n':
+		return `\newline`, true
+	case '\r':
+		return `\return`, true
+	case ' ':
+		return `\space`, true
+	case '\t':
+		return `\tab`, true
+	}
+	if unicode.IsGraphic(c.Value) && !unicode.IsSpace(c.Value) {
+		return `\` + string(c.Value), true
+	}
+	if c.Value > 0xffff {
+		return "", false
+	}
+	return fmt.Sprintf(`\u%04x`, c.Value), true
+}
@@ -0,0 +1,87 @@
+package sexpr
+
+import "testing"
+
+func TestCharString(t *testing.T) {
+	tests := []struct {
+		value    rune
+		expected string
+		display  string
+		edn      string
+	}{
+		{'a', `#\a`, "a", `\a`},
+		{'é', `#\é`, "é", `\é`},
+		{'(', `#\(`, "(", `\(`},
+		{' ', `#\space`, " ", `\space`},
+		{'\n', `#\newline`, "\n", `\newline`},
+		{0, `#\nul`, "\x00", `\u0000`},
+		{0x200b, `#\x200b`, "\u200b", `\u200b`},
+	}
+
+	for _, tt := range tests {
+		c := Char{Value: tt.value}
+		if got := Write(c); got != tt.expected {
+			t.Errorf("Write(%q) = %q, want %q", tt.value, got, tt.expected)
+		}
+		if got := Display(c); got != tt.display {
+			t.Errorf("Display(%q) = %q, want %q", tt.value, got, tt.display)
+		}
+		if got, err := WriteEDN(c); err != nil || got != tt.edn {
+			t.Errorf("WriteEDN(%q) = %q, %v, want %q", tt.value, got, err, tt.edn)
+		}
+	}
+
+	if _, err := WriteEDN(Char{Value: 0x1f600}); err != nil {
+		t.Errorf("a graphic character outside the BMP should be EDN: %v", err)
+	}
+	if _, err := WriteEDN(Char{Value: 0xe0001}); err == nil {
+		t.Error("an unprintable character outside the BMP has no EDN form")
+	}
+}
+
+func TestParseChar(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected rune
+		ok       bool
+	}{
+		{"a", 'a', true},
+		{"x", 'x', true},
+		{"λ", 'λ', true},
+		{"space", ' ', true},
+		{"newline", '\n', true},
+		{"x41", 'A', true},
+		{"u03bb", 'λ', true},
+		{"xd800", 0, false},
+		{"spaces", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		c, ok := ParseChar(tt.text)
+		if ok != tt.ok || (ok && c.Value != tt.expected) {
+			t.Errorf("ParseChar(%q) = %q, %v, want %q, %v", tt.text, c.Value, ok, tt.expected, tt.ok)
+		}
+	}
+
+	for _, c := range []Char{{'a'}, {' '}, {0}, {0x200b}, {'\x7f'}} {
+		if back, ok := ParseChar(c.name()); !ok || back != c {
+			t.Errorf("%s does not read back", c)
+		}
+	}
+}
+
+func TestCharEqual(t *testing.T) {
+	if !(Char{Value: 'a'}).Equal(Char{Value: 'a'}) {
+		t.Error("equal characters should be Equal")
+	}
+	if (Char{Value: 'a'}).Equal(String{Value: "a"}) {
+		t.Error("a character is not Equal to a string")
+	}
+	if (Char{Value: 'a'}).Hash() == (Number{Value: 'a'}).Hash() {
+		t.Error("a character should not hash like its code")
+	}
+	if !Identical(Char{Value: 'a'}, Char{Value: 'a'}) {
+		t.Error("equal characters should be identical")
+	}
+}
@@ -0,0 +1,193 @@
+package sexpr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keyword represents a self-evaluating name such as :name
+type Keyword struct {
+	Name string
+}
+
+func (k Keyword) String() string {
+	return ":" + k.Name
+}
+
+func (k Keyword) Equal(other SExpr) bool {
+	o, ok := other.(Keyword)
+	return ok && k.Name == o.Name
+}
+
+func (k Keyword) Hash() uint64 {
+	return hashBytes(tagKeyword, []byte(k.Name))
+}
+
+// Vector is a persistent indexed sequence written [a b c]. Updates
+// return a new vector in O(log n), sharing structure with the receiver.
+// Vectors and lists with the same elements are not Equal. The zero value
+// is an empty vector.
+type Vector struct {
+	v pvector[SExpr]
+}
+
+// NewVector builds a vector holding elems
+func NewVector(elems ...SExpr) Vector {
+	v := Vector{}
+	for _, elem := range elems {
+		v = v.Conj(elem)
+	}
+	return v
+}
+
+// Len returns the number of elements
+func (v Vector) Len() int {
+	return v.v.len()
+}
+
+// Nth returns the element at index i
+func (v Vector) Nth(i int) (SExpr, bool) {
+	if i < 0 || i >= v.v.len() {
+		return nil, false
+	}
+	return v.v.get(i), true
+}
+
+// Conj returns a vector with elem appended
+func (v Vector) Conj(elem SExpr) Vector {
+	return Vector{v: v.v.push(elem)}
+}
+
+// Assoc returns a vector with the element at index i replaced by elem.
+// An index equal to the length appends.
+func (v Vector) Assoc(i int, elem SExpr) (Vector, error) {
+	switch {
+	case i == v.v.len():
+		return v.Conj(elem), nil
+	case i < 0 || i > v.v.len():
+		return Vector{}, fmt.Errorf("vector index %d out of range [0, %d]", i, v.v.len())
+	}
+	return Vector{v: v.v.set(i, elem)}, nil
+}
+
+// Elements returns the elements in order
+func (v Vector) Elements() []SExpr {
+	return v.v.slice()
+}
+
+func (v Vector) String() string {
+	return seqString("[", v.Elements(), "]")
+}
+
+func (v Vector) Equal(other SExpr) bool {
+	o, ok := other.(Vector)
+	if !ok || v.Len() != o.Len() {
+		return false
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if !v.v.get(i).Equal(o.v.get(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v Vector) Hash() uint64 {
+	return hashInt64(tagVector, int64(List{Elements: v.Elements()}.Hash()))
+}
+
+// Set is an immutable collection of distinct hashable values written
+// #{a b c}. Elements keep their insertion order. The zero value is an
+// empty set.
+type Set struct {
+	m Map
+}
+
+// NewSet builds a set from elements, ignoring duplicates
+func NewSet(elems ...SExpr) (Set, error) {
+	s := Set{}
+	for _, elem := range elems {
+		var err error
+		if s, err = s.Add(elem); err != nil {
+			return Set{}, err
+		}
+	}
+	return s, nil
+}
+
+// Add returns a set that also contains elem
+func (s Set) Add(elem SExpr) (Set, error) {
+	if s.Contains(elem) {
+		return s, nil
+	}
+
+	m, err := s.m.Assoc(elem, Bool{Value: true})
+	if err != nil {
+		return Set{}, fmt.Errorf("unhashable set element: %v", elem)
+	}
+	return Set{m: m}, nil
+}
+
+// Contains reports whether elem is in the set
+func (s Set) Contains(elem SExpr) bool {
+	_, ok := s.m.Get(elem)
+	return ok
+}
+
+// Len returns the number of elements
+func (s Set) Len() int {
+	return s.m.Len()
+}
+
+// Elements returns the elements in insertion order
+func (s Set) Elements() []SExpr {
+	entries := s.m.Entries()
+	elems := make([]SExpr, len(entries))
+	for i, entry := range entries {
+		elems[i] = entry.Key
+	}
+	return elems
+}
+
+func (s Set) String() string {
+	return seqString("#{", s.Elements(), "}")
+}
+
+func (s Set) Equal(other SExpr) bool {
+	o, ok := other.(Set)
+	return ok && s.m.Equal(o.m)
+}
+
+func (s Set) Hash() uint64 {
+	return hashInt64(tagSet, int64(s.m.Hash()))
+}
+
+// Tagged represents an EDN tagged literal such as #inst "2024-01-01"
+// whose tag has no built-in interpretation
+type Tagged struct {
+	Tag   Symbol
+	Value SExpr
+}
+
+func (t Tagged) String() string {
+	return "#" + t.Tag.Name + " " + t.Value.String()
+}
+
+func (t Tagged) Equal(other SExpr) bool {
+	o, ok := other.(Tagged)
+	return ok && t.Tag.Name == o.Tag.Name && t.Value.Equal(o.Value)
+}
+
+func seqString(open string, elems []SExpr, close string) string {
+	var b strings.Builder
+	b.WriteString(open)
+	for i, elem := range elems {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(elem.String())
+	}
+	b.WriteString(close)
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package sexpr
+
+import "testing"
+
+func TestKeyword(t *testing.T) {
+	k := Keyword{Name: "name"}
+	if got := k.String(); got != ":name" {
+		t.Errorf("String() = %q, want %q", got, ":name")
+	}
+	if !k.Equal(Keyword{Name: "name"}) {
+		t.Error("equal keywords should be Equal")
+	}
+	if k.Equal(Symbol{Name: "name"}) {
+		t.Error("keyword should not equal symbol with the same name")
+	}
+
+	kh, _ := Hash(k)
+	sh, _ := Hash(Symbol{Name: "name"})
+	if kh == sh {
+		t.Error("keyword and symbol should hash differently")
+	}
+}
+
+func TestVector(t *testing.T) {
+	v := NewVector(Number{Value: 1}, Keyword{Name: "a"})
+	if got := v.String(); got != "[1 :a]" {
+		t.Errorf("String() = %q", got)
+	}
+	if !v.Equal(NewVector(Number{Value: 1}, Keyword{Name: "a"})) {
+		t.Error("equal vectors should be Equal")
+	}
+	if v.Equal(List{Elements: v.Elements()}) {
+		t.Error("vector should not equal list with the same elements")
+	}
+
+	vh, _ := Hash(v)
+	lh, _ := Hash(List{Elements: v.Elements()})
+	if vh == lh {
+		t.Error("vector and list should hash differently")
+	}
+}
+
+func TestVectorAssocAndNth(t *testing.T) {
+	v := NewVector(Number{Value: 1}, Number{Value: 2})
+
+	v2, err := v.Assoc(0, Number{Value: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v3, err := v2.Assoc(2, Number{Value: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := v.String(); got != "[1 2]" {
+		t.Errorf("original = %s, want [1 2]", got)
+	}
+	if got := v3.String(); got != "[10 2 3]" {
+		t.Errorf("got %s, want [10 2 3]", got)
+	}
+
+	if elem, ok := v3.Nth(2); !ok || !elem.Equal(Number{Value: 3}) {
+		t.Errorf("Nth(2) = %v, %v", elem, ok)
+	}
+	if _, ok := v3.Nth(3); ok {
+		t.Error("Nth past the end should fail")
+	}
+	if _, err := v.Assoc(5, Nil{}); err == nil {
+		t.Error("expected error for out of range index")
+	}
+
+	var empty Vector
+	if empty.Len() != 0 || empty.String() != "[]" || !empty.Equal(NewVector()) {
+		t.Error("zero Vector should be empty")
+	}
+}
+
+func TestSet(t *testing.T) {
+	s, err := NewSet(Number{Value: 1}, Number{Value: 2}, Number{Value: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Len() != 2 {
+		t.Errorf("got %d elements, want 2", s.Len())
+	}
+	if !s.Contains(Number{Value: 2}) || s.Contains(Number{Value: 3}) {
+		t.Error("Contains reported wrong membership")
+	}
+	if got := s.String(); got != "#{1 2}" {
+		t.Errorf("String() = %q", got)
+	}
+
+	// Equality ignores insertion order
+	other, _ := NewSet(Number{Value: 2}, Number{Value: 1})
+	if !s.Equal(other) {
+		t.Error("sets with the same elements should be Equal")
+	}
+	sh, _ := Hash(s)
+	oh, _ := Hash(other)
+	if sh != oh {
+		t.Error("equal sets should hash equally")
+	}
+
+	if _, err := NewSet(Func{Body: Nil{}}); err == nil {
+		t.Error("expected error for unhashable element")
+	}
+}
+
+func TestTagged(t *testing.T) {
+	tagged := Tagged{Tag: Symbol{Name: "inst"}, Value: String{Value: "2024-01-01"}}
+	if got := tagged.String(); got != `#inst "2024-01-01"` {
+		t.Errorf("String() = %q", got)
+	}
+	if tagged.Equal(Tagged{Tag: Symbol{Name: "uuid"}, Value: tagged.Value}) {
+		t.Error("tagged values with different tags should not be Equal")
+	}
+}
@@ -0,0 +1,29 @@
+package sexpr
+
+import "fmt"
+
+// Error is a condition value carrying a kind symbol that classifies the
+// error, a human-readable message and an arbitrary data payload
+type Error struct {
+	Kind    Symbol
+	Message string
+	Data    SExpr
+}
+
+func (e Error) String() string {
+	return fmt.Sprintf("#<error %s: %s>", e.Kind.Name, e.Message)
+}
+
+func (e Error) Equal(other SExpr) bool {
+	o, ok := other.(Error)
+	return ok && e.Kind.Name == o.Kind.Name && e.Message == o.Message &&
+		e.data().Equal(o.data())
+}
+
+// data returns the payload, treating a missing one as nil
+func (e Error) data() SExpr {
+	if e.Data == nil {
+		return Nil{}
+	}
+	return e.Data
+}
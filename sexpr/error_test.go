@@ -0,0 +1,27 @@
+package sexpr
+
+import "testing"
+
+func TestErrorString(t *testing.T) {
+	e := Error{Kind: Symbol{Name: "type-error"}, Message: "expected number"}
+	if got := e.String(); got != "#<error type-error: expected number>" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestErrorEqual(t *testing.T) {
+	kind := Symbol{Name: "io-error"}
+	a := Error{Kind: kind, Message: "closed", Data: String{Value: "f.txt"}}
+	b := Error{Kind: kind, Message: "closed", Data: String{Value: "f.txt"}}
+	c := Error{Kind: kind, Message: "closed"}
+
+	if !a.Equal(b) {
+		t.Error("identical errors should be equal")
+	}
+	if a.Equal(c) {
+		t.Error("errors with different data should differ")
+	}
+	if !c.Equal(Error{Kind: kind, Message: "closed", Data: Nil{}}) {
+		t.Error("missing data should equal nil data")
+	}
+}
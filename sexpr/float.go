@@ -0,0 +1,45 @@
+package sexpr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Float represents a double-precision floating-point number
+type Float struct {
+	Value float64
+}
+
+// String always includes a decimal point or exponent so the text reads
+// back as a float rather than an integer. Infinities and NaN are written
+// +inf.0, -inf.0 and +nan.0, as in Scheme.
+func (f Float) String() string {
+	switch {
+	case math.IsInf(f.Value, 1):
+		return "+inf.0"
+	case math.IsInf(f.Value, -1):
+		return "-inf.0"
+	case math.IsNaN(f.Value):
+		return "+nan.0"
+	}
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+func (f Float) Equal(other SExpr) bool {
+	o, ok := other.(Float)
+	return ok && f.Value == o.Value
+}
+
+// Hash is consistent with Equal, so 0.0 and -0.0 hash alike
+func (f Float) Hash() uint64 {
+	v := f.Value
+	if v == 0 {
+		v = 0
+	}
+	return hashInt64(tagFloat, int64(math.Float64bits(v)))
+}
@@ -0,0 +1,43 @@
+package sexpr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloatString(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1.5, "1.5"},
+		{2, "2.0"},
+		{-0.25, "-0.25"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+inf.0"},
+		{math.Inf(-1), "-inf.0"},
+		{math.NaN(), "+nan.0"},
+	}
+
+	for _, tt := range tests {
+		if got := (Float{Value: tt.value}).String(); got != tt.expected {
+			t.Errorf("Float(%v).String() = %q, want %q", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestFloatEqual(t *testing.T) {
+	if !(Float{Value: 1.5}).Equal(Float{Value: 1.5}) {
+		t.Error("equal floats should be Equal")
+	}
+	if (Float{Value: 1}).Equal(Number{Value: 1}) {
+		t.Error("a float is not Equal to an integer")
+	}
+	if (Float{Value: 1.5}).Hash() != (Float{Value: 1.5}).Hash() {
+		t.Error("equal floats should hash alike")
+	}
+	negZero := Float{Value: math.Copysign(0, -1)}
+	if !negZero.Equal(Float{Value: 0}) || negZero.Hash() != (Float{Value: 0}).Hash() {
+		t.Error("0.0 and -0.0 should be Equal and hash alike")
+	}
+}
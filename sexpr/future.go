@@ -0,0 +1,72 @@
+package sexpr
+
+import (
+	"context"
+	"time"
+)
+
+// Future is the result of a computation running on its own goroutine.
+// Waiting for it blocks until the computation finishes and then returns
+// its value or error; later waits return the same outcome.
+type Future struct {
+	done  chan struct{}
+	value SExpr
+	err   error
+}
+
+// NewFuture starts fn on a new goroutine and returns its future
+func NewFuture(fn func() (SExpr, error)) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.value, f.err = fn()
+	}()
+	return f
+}
+
+// Await waits for the computation to finish and returns its outcome. It
+// fails if ctx is done first.
+func (f *Future) Await(ctx context.Context) (SExpr, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AwaitTimeout is like Await but gives up after timeout, returning false
+func (f *Future) AwaitTimeout(ctx context.Context, timeout time.Duration) (SExpr, bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-f.done:
+		return f.value, true, f.err
+	case <-timer.C:
+		return nil, false, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// IsDone reports whether the computation has finished
+func (f *Future) IsDone() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *Future) String() string {
+	if f.IsDone() {
+		return "#<future done>"
+	}
+	return "#<future>"
+}
+
+func (f *Future) Equal(other SExpr) bool {
+	o, ok := other.(*Future)
+	return ok && f == o
+}
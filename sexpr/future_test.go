@@ -0,0 +1,63 @@
+package sexpr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	f := NewFuture(func() (SExpr, error) {
+		<-release
+		return Number{Value: 42}, nil
+	})
+
+	if f.IsDone() || f.String() != "#<future>" {
+		t.Fatal("future finished before its computation")
+	}
+	if _, ok, err := f.AwaitTimeout(ctx, 5*time.Millisecond); ok || err != nil {
+		t.Errorf("AwaitTimeout = %v, %v; want timeout", ok, err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		v, err := f.Await(ctx)
+		if err != nil || !v.Equal(Number{Value: 42}) {
+			t.Errorf("Await = %v, %v; want 42", v, err)
+		}
+	}
+	if !f.IsDone() || f.String() != "#<future done>" {
+		t.Error("future not done after Await")
+	}
+	if v, ok, _ := f.AwaitTimeout(ctx, time.Millisecond); !ok || !v.Equal(Number{Value: 42}) {
+		t.Errorf("AwaitTimeout = %v, %v; want 42", v, ok)
+	}
+}
+
+func TestFutureError(t *testing.T) {
+	boom := errors.New("boom")
+	f := NewFuture(func() (SExpr, error) { return nil, boom })
+	if _, err := f.Await(context.Background()); err != boom {
+		t.Errorf("got error %v, want boom", err)
+	}
+}
+
+func TestFutureContext(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	f := NewFuture(func() (SExpr, error) {
+		<-block
+		return Nil{}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want canceled", err)
+	}
+	if f.Equal(NewFuture(func() (SExpr, error) { return Nil{}, nil })) || !f.Equal(f) {
+		t.Error("futures should be equal only to themselves")
+	}
+}
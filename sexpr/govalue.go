@@ -0,0 +1,45 @@
+package sexpr
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GoValue wraps an arbitrary host value so that it can flow through the
+// evaluator opaquely. Zylisp code can store and pass it around but not
+// look inside it.
+type GoValue struct {
+	Value any
+}
+
+func (g GoValue) String() string {
+	return fmt.Sprintf("#<go-value %T>", g.Value)
+}
+
+// Equal compares comparable host values with ==, and reference types
+// (maps, slices, pointers, channels, functions) by identity
+func (g GoValue) Equal(other SExpr) bool {
+	o, ok := other.(GoValue)
+	if !ok {
+		return false
+	}
+
+	a, b := reflect.ValueOf(g.Value), reflect.ValueOf(o.Value)
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Func, reflect.Chan,
+		reflect.Pointer, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	}
+
+	if a.Comparable() && b.Comparable() {
+		return a.Equal(b)
+	}
+	return false
+}
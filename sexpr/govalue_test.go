@@ -0,0 +1,46 @@
+package sexpr
+
+import "testing"
+
+type hostHandle struct {
+	id int
+}
+
+func TestGoValueString(t *testing.T) {
+	g := GoValue{Value: &hostHandle{id: 1}}
+	if got := g.String(); got != "#<go-value *sexpr.hostHandle>" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := Write(g); got != g.String() {
+		t.Errorf("Write() = %q, want %q", got, g.String())
+	}
+}
+
+func TestGoValueEqual(t *testing.T) {
+	handle := &hostHandle{id: 1}
+	slice := []int{1, 2}
+
+	tests := []struct {
+		name     string
+		a, b     GoValue
+		expected bool
+	}{
+		{"same pointer", GoValue{Value: handle}, GoValue{Value: handle}, true},
+		{"different pointers", GoValue{Value: handle},
+			GoValue{Value: &hostHandle{id: 1}}, false},
+		{"equal structs", GoValue{Value: hostHandle{id: 1}},
+			GoValue{Value: hostHandle{id: 1}}, true},
+		{"same slice", GoValue{Value: slice}, GoValue{Value: slice}, true},
+		{"different slices", GoValue{Value: slice}, GoValue{Value: []int{1, 2}}, false},
+		{"different types", GoValue{Value: 1}, GoValue{Value: "1"}, false},
+		{"nil values", GoValue{}, GoValue{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.expected {
+				t.Errorf("Equal() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package sexpr
+
+import "math/bits"
+
+// hamtNode is a node of a hash array mapped trie mapping hashable keys
+// to values. Each level consumes five bits of the key's hash; a bitmap
+// records which of the 32 slots are occupied so that entries are stored
+// densely. Keys whose full hashes collide share a collision node. Nodes
+// are never modified once built: updates copy the path to the changed
+// entry.
+type hamtNode[V any] struct {
+	bitmap    uint32
+	entries   []hamtEntry[V]
+	collision bool // entries all share one hash and are searched linearly
+}
+
+// hamtEntry is either a key/value leaf or, when child is set, a subtree
+type hamtEntry[V any] struct {
+	hash  uint64
+	key   SExpr
+	value V
+	child *hamtNode[V]
+}
+
+const (
+	hamtBits     = 5
+	hamtMaxShift = 64 // shifts at or beyond this have no hash bits left
+)
+
+func hamtSlot(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & 31)
+}
+
+// index returns the position in entries of the entry for slot
+func (n *hamtNode[V]) index(slot uint32) int {
+	return bits.OnesCount32(n.bitmap & (slot - 1))
+}
+
+func (n *hamtNode[V]) get(shift uint, hash uint64, key SExpr) (V, bool) {
+	var zero V
+	if n == nil {
+		return zero, false
+	}
+
+	if n.collision {
+		for _, e := range n.entries {
+			if e.key.Equal(key) {
+				return e.value, true
+			}
+		}
+		return zero, false
+	}
+
+	slot := hamtSlot(hash, shift)
+	if n.bitmap&slot == 0 {
+		return zero, false
+	}
+
+	e := n.entries[n.index(slot)]
+	switch {
+	case e.child != nil:
+		return e.child.get(shift+hamtBits, hash, key)
+	case e.hash == hash && e.key.Equal(key):
+		return e.value, true
+	default:
+		return zero, false
+	}
+}
+
+// assoc returns a trie with key bound to value, reporting whether the
+// key is new
+func (n *hamtNode[V]) assoc(shift uint, hash uint64, key SExpr, value V) (*hamtNode[V], bool) {
+	leaf := hamtEntry[V]{hash: hash, key: key, value: value}
+	if n == nil {
+		return &hamtNode[V]{bitmap: hamtSlot(hash, shift), entries: []hamtEntry[V]{leaf}}, true
+	}
+
+	if n.collision {
+		entries := append([]hamtEntry[V](nil), n.entries...)
+		for i, e := range entries {
+			if e.key.Equal(key) {
+				entries[i] = leaf
+				return &hamtNode[V]{entries: entries, collision: true}, false
+			}
+		}
+		return &hamtNode[V]{entries: append(entries, leaf), collision: true}, true
+	}
+
+	slot := hamtSlot(hash, shift)
+	i := n.index(slot)
+
+	if n.bitmap&slot == 0 {
+		entries := make([]hamtEntry[V], 0, len(n.entries)+1)
+		entries = append(entries, n.entries[:i]...)
+		entries = append(entries, leaf)
+		entries = append(entries, n.entries[i:]...)
+		return &hamtNode[V]{bitmap: n.bitmap | slot, entries: entries}, true
+	}
+
+	entries := append([]hamtEntry[V](nil), n.entries...)
+	e := entries[i]
+	added := false
+	switch {
+	case e.child != nil:
+		entries[i].child, added = e.child.assoc(shift+hamtBits, hash, key, value)
+	case e.hash == hash && e.key.Equal(key):
+		entries[i] = leaf
+	default:
+		entries[i] = hamtEntry[V]{child: mergeHamtLeaves(shift+hamtBits, e, leaf)}
+		added = true
+	}
+	return &hamtNode[V]{bitmap: n.bitmap, entries: entries}, added
+}
+
+// mergeHamtLeaves builds the subtree at shift holding two distinct keys
+// that share a slot at the level above
+func mergeHamtLeaves[V any](shift uint, a, b hamtEntry[V]) *hamtNode[V] {
+	if shift >= hamtMaxShift {
+		return &hamtNode[V]{entries: []hamtEntry[V]{a, b}, collision: true}
+	}
+
+	slotA, slotB := hamtSlot(a.hash, shift), hamtSlot(b.hash, shift)
+	if slotA == slotB {
+		child := mergeHamtLeaves(shift+hamtBits, a, b)
+		return &hamtNode[V]{bitmap: slotA, entries: []hamtEntry[V]{{child: child}}}
+	}
+
+	if slotA > slotB {
+		a, b = b, a
+	}
+	return &hamtNode[V]{bitmap: slotA | slotB, entries: []hamtEntry[V]{a, b}}
+}
+
+// dissoc returns a trie without key, reporting whether it was present.
+// The result is nil when the trie becomes empty.
+func (n *hamtNode[V]) dissoc(shift uint, hash uint64, key SExpr) (*hamtNode[V], bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	if n.collision {
+		for i, e := range n.entries {
+			if e.key.Equal(key) {
+				if len(n.entries) == 1 {
+					return nil, true
+				}
+				entries := make([]hamtEntry[V], 0, len(n.entries)-1)
+				entries = append(entries, n.entries[:i]...)
+				entries = append(entries, n.entries[i+1:]...)
+				return &hamtNode[V]{entries: entries, collision: true}, true
+			}
+		}
+		return n, false
+	}
+
+	slot := hamtSlot(hash, shift)
+	if n.bitmap&slot == 0 {
+		return n, false
+	}
+
+	i := n.index(slot)
+	e := n.entries[i]
+	if e.child != nil {
+		child, removed := e.child.dissoc(shift+hamtBits, hash, key)
+		if !removed {
+			return n, false
+		}
+		if child != nil {
+			entries := append([]hamtEntry[V](nil), n.entries...)
+			entries[i].child = child
+			return &hamtNode[V]{bitmap: n.bitmap, entries: entries}, true
+		}
+	} else if e.hash != hash || !e.key.Equal(key) {
+		return n, false
+	}
+
+	// Remove the slot entirely
+	if len(n.entries) == 1 {
+		return nil, true
+	}
+	entries := make([]hamtEntry[V], 0, len(n.entries)-1)
+	entries = append(entries, n.entries[:i]...)
+	entries = append(entries, n.entries[i+1:]...)
+	return &hamtNode[V]{bitmap: n.bitmap &^ slot, entries: entries}, true
+}
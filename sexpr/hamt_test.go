@@ -0,0 +1,29 @@
+package sexpr
+
+import "testing"
+
+func TestHamtCollisions(t *testing.T) {
+	// Force every key onto the same hash
+	const hash = 0xdeadbeef
+	var root *hamtNode[int]
+	for i := 0; i < 10; i++ {
+		root, _ = root.assoc(0, hash, Number{Value: int64(i)}, i)
+	}
+
+	for i := 0; i < 10; i++ {
+		if got, ok := root.get(0, hash, Number{Value: int64(i)}); !ok || got != i {
+			t.Errorf("get(%d) = %d, %v", i, got, ok)
+		}
+	}
+
+	root, removed := root.dissoc(0, hash, Number{Value: 3})
+	if !removed {
+		t.Fatal("dissoc did not find key")
+	}
+	if _, ok := root.get(0, hash, Number{Value: 3}); ok {
+		t.Error("key still present after dissoc")
+	}
+	if got, ok := root.get(0, hash, Number{Value: 4}); !ok || got != 4 {
+		t.Errorf("get(4) = %d, %v", got, ok)
+	}
+}
@@ -0,0 +1,105 @@
+package sexpr
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Hashable is implemented by immutable S-expressions that can be used as
+// hash keys. Values that are Equal must return the same Hash.
+type Hashable interface {
+	SExpr
+	Hash() uint64
+}
+
+// Hash returns the hash of value, reporting false if the value is not
+// hashable
+func Hash(value SExpr) (uint64, bool) {
+	h, ok := value.(Hashable)
+	if !ok {
+		return 0, false
+	}
+	return h.Hash(), true
+}
+
+// Type tags keep values of different types that share a representation
+// (for example the symbol x and the string "x") apart
+const (
+	tagNumber byte = iota + 1
+	tagSymbol
+	tagString
+	tagBool
+	tagNil
+	tagList
+	tagMap
+	tagPair
+	tagFloat
+	tagKeyword
+	tagVector
+	tagSet
+	tagBytes
+	tagChar
+	tagUnhashable
+)
+
+func hashBytes(tag byte, data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte{tag})
+	h.Write(data)
+	return h.Sum64()
+}
+
+func hashInt64(tag byte, value int64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(value))
+	return hashBytes(tag, buf[:])
+}
+
+func (n Number) Hash() uint64 {
+	return hashInt64(tagNumber, n.Value)
+}
+
+func (b BigInt) Hash() uint64 {
+	if b.Value.IsInt64() {
+		return hashInt64(tagNumber, b.Value.Int64())
+	}
+	return hashBytes(tagNumber, []byte(b.Value.String()))
+}
+
+func (s Symbol) Hash() uint64 {
+	return hashBytes(tagSymbol, []byte(s.Name))
+}
+
+func (s String) Hash() uint64 {
+	return hashBytes(tagString, []byte(s.Value))
+}
+
+func (b Bool) Hash() uint64 {
+	if b.Value {
+		return hashBytes(tagBool, []byte{1})
+	}
+	return hashBytes(tagBool, []byte{0})
+}
+
+func (n Nil) Hash() uint64 {
+	return hashBytes(tagNil, nil)
+}
+
+// Hash combines the hashes of the list elements. Elements that are not
+// hashable contribute a constant, which keeps the contract with Equal
+// at the cost of more collisions.
+func (l List) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte{tagList})
+
+	var buf [8]byte
+	for _, elem := range l.Elements {
+		elemHash, ok := Hash(elem)
+		if !ok {
+			elemHash = hashBytes(tagUnhashable, nil)
+		}
+		binary.LittleEndian.PutUint64(buf[:], elemHash)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
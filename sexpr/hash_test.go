@@ -0,0 +1,75 @@
+package sexpr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHashConsistentWithEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b SExpr
+	}{
+		{"numbers", Number{Value: 42}, Number{Value: 42}},
+		{"number and small big int", Number{Value: 42},
+			BigInt{Value: big.NewInt(42)}},
+		{"symbols", Symbol{Name: "x"}, Symbol{Name: "x"}},
+		{"strings", String{Value: "hello"}, String{Value: "hello"}},
+		{"bools", Bool{Value: true}, Bool{Value: true}},
+		{"nils", Nil{}, Nil{}},
+		{
+			"lists",
+			List{Elements: []SExpr{Number{Value: 1}, Symbol{Name: "a"}}},
+			List{Elements: []SExpr{Number{Value: 1}, Symbol{Name: "a"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.a.Equal(tt.b) {
+				t.Fatalf("%v and %v should be equal", tt.a, tt.b)
+			}
+
+			ha, ok1 := Hash(tt.a)
+			hb, ok2 := Hash(tt.b)
+			if !ok1 || !ok2 {
+				t.Fatalf("expected both values to be hashable")
+			}
+			if ha != hb {
+				t.Errorf("equal values hash differently: %x != %x", ha, hb)
+			}
+		})
+	}
+}
+
+func TestHashDistinguishesTypes(t *testing.T) {
+	values := []SExpr{
+		Number{Value: 1},
+		String{Value: "x"},
+		Symbol{Name: "x"},
+		Bool{Value: true},
+		Nil{},
+		List{Elements: []SExpr{}},
+	}
+
+	seen := make(map[uint64]SExpr)
+	for _, v := range values {
+		h, _ := Hash(v)
+		if prev, ok := seen[h]; ok {
+			t.Errorf("%v and %v share hash %x", prev, v, h)
+		}
+		seen[h] = v
+	}
+}
+
+func TestHashUnhashable(t *testing.T) {
+	if _, ok := Hash(Primitive{Name: "+"}); ok {
+		t.Error("primitives should not be hashable")
+	}
+
+	fn := Func{Body: Nil{}}
+	list := List{Elements: []SExpr{fn}}
+	if _, ok := Hash(list); !ok {
+		t.Error("lists should be hashable even with unhashable elements")
+	}
+}
@@ -0,0 +1,121 @@
+package sexpr
+
+import (
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Identical reports whether a and b are the same object, the test made
+// by eq?. Values are compared as follows:
+//
+//   - nil, booleans, symbols, keywords, characters and integers that fit
+//     in a Number are immediate: equal values are identical
+//   - big integers are identical when they share their *big.Int
+//   - strings, byte vectors and lists are identical when they share
+//     storage, so a value is identical to itself but not to an equal
+//     copy; all empty strings, byte vectors and lists are identical
+//   - vectors, maps and sets are identical when they share their root,
+//     so a collection is not identical to one derived from it by an
+//     update
+//   - pairs, errors, tagged literals and multiple values are copied by
+//     value and have no identity of their own: they are identical when
+//     their parts are
+//   - functions, primitives, macros and host values are identical when
+//     Equal, since closures of one lambda over one environment cannot be
+//     told apart
+//   - atoms, channels, ports and other reference values are identical
+//     only to themselves
+//
+// Floats are immediate too, but compare like Equivalent: 0.0 and -0.0
+// are not identical, and a NaN is identical to itself.
+func Identical(a, b SExpr) bool {
+	switch x := a.(type) {
+	case Nil, Bool, Symbol, Keyword, Number, Char:
+		return a.Equal(b)
+	case Float:
+		y, ok := b.(Float)
+		return ok && math.Float64bits(x.Value) == math.Float64bits(y.Value)
+	case BigInt:
+		y, ok := b.(BigInt)
+		return ok && x.Value == y.Value
+	case String:
+		y, ok := b.(String)
+		return ok && len(x.Value) == len(y.Value) &&
+			(len(x.Value) == 0 || unsafe.StringData(x.Value) == unsafe.StringData(y.Value))
+	case Bytes:
+		y, ok := b.(Bytes)
+		return ok && len(x.Value) == len(y.Value) &&
+			(len(x.Value) == 0 || &x.Value[0] == &y.Value[0])
+	case List:
+		y, ok := b.(List)
+		return ok && len(x.Elements) == len(y.Elements) &&
+			(len(x.Elements) == 0 || &x.Elements[0] == &y.Elements[0])
+	case Vector:
+		y, ok := b.(Vector)
+		return ok && x.v.identical(y.v)
+	case Map:
+		y, ok := b.(Map)
+		return ok && x.identical(y)
+	case Set:
+		y, ok := b.(Set)
+		return ok && x.m.identical(y.m)
+	case Pair:
+		y, ok := b.(Pair)
+		return ok && Identical(x.Car, y.Car) && Identical(x.Cdr, y.Cdr)
+	case Error:
+		y, ok := b.(Error)
+		return ok && x.Kind.Name == y.Kind.Name && x.Message == y.Message &&
+			Identical(x.data(), y.data())
+	case Tagged:
+		y, ok := b.(Tagged)
+		return ok && x.Tag.Name == y.Tag.Name && Identical(x.Value, y.Value)
+	case Values:
+		y, ok := b.(Values)
+		if !ok || len(x.Elements) != len(y.Elements) {
+			return false
+		}
+		for i := range x.Elements {
+			if !Identical(x.Elements[i], y.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case Func, Primitive, Macro, GoValue:
+		return a.Equal(b)
+	}
+
+	// Reference types are pointers, which compare by identity
+	if a == nil || b == nil || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// Equivalent reports whether a and b are Identical or are numbers of the
+// same exactness with the same value, the test made by eqv?. Integers
+// are exact and floats inexact, so 2 and 2.0 are not equivalent.
+func Equivalent(a, b SExpr) bool {
+	switch a.(type) {
+	case Number, BigInt:
+		switch b.(type) {
+		case Number, BigInt:
+			return a.Equal(b)
+		}
+		return false
+	}
+	return Identical(a, b)
+}
+
+// identical reports whether v and o share their storage
+func (v pvector[T]) identical(o pvector[T]) bool {
+	if v.count != o.count || v.root != o.root || len(v.tail) != len(o.tail) {
+		return false
+	}
+	return len(v.tail) == 0 || &v.tail[0] == &o.tail[0]
+}
+
+// identical reports whether m and o share their storage
+func (m Map) identical(o Map) bool {
+	return m.count == o.count && m.keys == o.keys && m.order.identical(o.order)
+}
@@ -0,0 +1,80 @@
+package sexpr
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestIdentical(t *testing.T) {
+	list := List{Elements: []SExpr{Number{Value: 1}, Number{Value: 2}}}
+	str := String{Value: strings.Repeat("ab", 2)}
+	vec := NewVector(Number{Value: 1})
+	m, _ := NewMap(Keyword{Name: "a"}, Number{Value: 1})
+	big1 := BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}
+	atom := NewAtom(Nil{})
+
+	tests := []struct {
+		name     string
+		a, b     SExpr
+		expected bool
+	}{
+		{"nil", Nil{}, Nil{}, true},
+		{"symbols", Symbol{Name: "x"}, Symbol{Name: "x"}, true},
+		{"keywords", Keyword{Name: "k"}, Keyword{Name: "k"}, true},
+		{"numbers", Number{Value: 7}, Number{Value: 7}, true},
+		{"different types", Number{Value: 0}, Bool{Value: false}, false},
+		{"same big integer", big1, big1, true},
+		{"equal big integers", big1, BigInt{Value: new(big.Int).Set(big1.Value)}, false},
+		{"same list", list, list, true},
+		{"list copy", list, List{Elements: []SExpr{Number{Value: 1}, Number{Value: 2}}}, false},
+		{"empty lists", List{}, List{Elements: []SExpr{}}, true},
+		{"same string", str, str, true},
+		{"string copy", str, String{Value: strings.Repeat("ab", 2)}, false},
+		{"empty strings", String{}, String{Value: ""}, true},
+		{"same vector", vec, vec, true},
+		{"vector copy", vec, NewVector(Number{Value: 1}), false},
+		{"same map", m, m, true},
+		{"map copy", m, func() SExpr { c, _ := NewMap(Keyword{Name: "a"}, Number{Value: 1}); return c }(), false},
+		{"pairs of identical parts", Cons(list, str), Cons(list, str), true},
+		{"pairs of copies", Cons(str, Nil{}), Cons(String{Value: strings.Repeat("ab", 2)}, Nil{}), false},
+		{"same atom", atom, atom, true},
+		{"different atoms", atom, NewAtom(Nil{}), false},
+		{"zeros", Float{Value: 0}, Float{Value: math.Copysign(0, -1)}, false},
+		{"NaN", Float{Value: math.NaN()}, Float{Value: math.NaN()}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Identical(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Identical(%v, %v) = %t, want %t", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	big1 := new(big.Int).Lsh(big.NewInt(1), 70)
+
+	tests := []struct {
+		name     string
+		a, b     SExpr
+		expected bool
+	}{
+		{"numbers", Number{Value: 7}, Number{Value: 7}, true},
+		{"equal big integers", BigInt{Value: big1}, BigInt{Value: new(big.Int).Set(big1)}, true},
+		{"floats", Float{Value: 1.5}, Float{Value: 1.5}, true},
+		{"exactness", Number{Value: 2}, Float{Value: 2}, false},
+		{"string copies", String{Value: strings.Repeat("a", 2)}, String{Value: strings.Repeat("a", 2)}, false},
+		{"symbols", Symbol{Name: "x"}, Symbol{Name: "x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equivalent(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Equivalent(%v, %v) = %t, want %t", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package sexpr
+
+// Macro is a function from unevaluated argument forms to a new form,
+// which is evaluated in place of the macro call. Fn is a Func for
+// defmacro macros or a Primitive for built-in transformers such as
+// syntax-rules.
+type Macro struct {
+	Name string
+	Fn   SExpr
+}
+
+func (m Macro) String() string {
+	return "#<macro " + m.Name + ">"
+}
+
+func (m Macro) Equal(other SExpr) bool {
+	o, ok := other.(Macro)
+	return ok && m.Name == o.Name && m.Fn.Equal(o.Fn)
+}
@@ -0,0 +1,19 @@
+package sexpr
+
+import "testing"
+
+func TestMacro(t *testing.T) {
+	m := Macro{Name: "my-if", Fn: Func{Body: Nil{}}}
+	if got := m.String(); got != "#<macro my-if>" {
+		t.Errorf("String() = %q", got)
+	}
+	if !m.Equal(Macro{Name: "my-if", Fn: Func{Body: Nil{}}}) {
+		t.Error("macros with equal functions should be Equal")
+	}
+	if m.Equal(m.Fn) {
+		t.Error("macro should not equal its function")
+	}
+	if _, err := WriteEDN(m); err == nil {
+		t.Error("expected WriteEDN to reject a macro")
+	}
+}
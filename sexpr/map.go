@@ -0,0 +1,161 @@
+package sexpr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MapEntry is a single key/value association in a Map
+type MapEntry struct {
+	Key   SExpr
+	Value SExpr
+}
+
+// Map is a persistent hash map from hashable keys to values. Updates
+// return a new map in O(log n), sharing structure with the receiver,
+// which is left unchanged. Entries keep their insertion order, which
+// makes printing deterministic. The zero value is an empty map.
+type Map struct {
+	keys  *hamtNode[int]    // key -> position in order
+	order pvector[MapEntry] // entries in insertion order; removed ones have a nil Key
+	count int
+}
+
+// NewMap builds a map from alternating keys and values
+func NewMap(keysAndValues ...SExpr) (Map, error) {
+	if len(keysAndValues)%2 != 0 {
+		return Map{}, fmt.Errorf("map requires an even number of keys and values, got %d",
+			len(keysAndValues))
+	}
+
+	m := Map{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var err error
+		m, err = m.Assoc(keysAndValues[i], keysAndValues[i+1])
+		if err != nil {
+			return Map{}, err
+		}
+	}
+	return m, nil
+}
+
+// Len returns the number of entries
+func (m Map) Len() int {
+	return m.count
+}
+
+// Entries returns the entries in insertion order
+func (m Map) Entries() []MapEntry {
+	entries := make([]MapEntry, 0, m.count)
+	for _, entry := range m.order.slice() {
+		if entry.Key != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Get returns the value bound to key
+func (m Map) Get(key SExpr) (SExpr, bool) {
+	h, ok := Hash(key)
+	if !ok {
+		return nil, false
+	}
+	if i, ok := m.keys.get(0, h, key); ok {
+		return m.order.get(i).Value, true
+	}
+	return nil, false
+}
+
+// Assoc returns a map with key bound to value. A key that is already
+// present keeps its position in the insertion order.
+func (m Map) Assoc(key, value SExpr) (Map, error) {
+	h, ok := Hash(key)
+	if !ok {
+		return Map{}, fmt.Errorf("unhashable map key: %v", key)
+	}
+
+	entry := MapEntry{Key: key, Value: value}
+	if i, ok := m.keys.get(0, h, key); ok {
+		m.order = m.order.set(i, entry)
+		return m, nil
+	}
+
+	m.keys, _ = m.keys.assoc(0, h, key, m.order.len())
+	m.order = m.order.push(entry)
+	m.count++
+	return m, nil
+}
+
+// Dissoc returns a map without key
+func (m Map) Dissoc(key SExpr) Map {
+	h, ok := Hash(key)
+	if !ok {
+		return m
+	}
+	i, ok := m.keys.get(0, h, key)
+	if !ok {
+		return m
+	}
+
+	m.keys, _ = m.keys.dissoc(0, h, key)
+	m.order = m.order.set(i, MapEntry{})
+	m.count--
+
+	// Rebuild once removed entries outnumber live ones so that the
+	// order vector does not grow without bound
+	if m.order.len() > 2*m.count+pvectorWidth {
+		compacted := Map{}
+		for _, entry := range m.Entries() {
+			compacted, _ = compacted.Assoc(entry.Key, entry.Value)
+		}
+		return compacted
+	}
+	return m
+}
+
+func (m Map) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, entry := range m.Entries() {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(entry.Key.String())
+		b.WriteByte(' ')
+		b.WriteString(entry.Value.String())
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Equal reports whether other is a map with equal keys bound to equal
+// values, regardless of insertion order
+func (m Map) Equal(other SExpr) bool {
+	o, ok := other.(Map)
+	if !ok || m.Len() != o.Len() {
+		return false
+	}
+
+	for _, entry := range m.Entries() {
+		value, ok := o.Get(entry.Key)
+		if !ok || !entry.Value.Equal(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash is independent of insertion order so that equal maps hash alike
+func (m Map) Hash() uint64 {
+	var sum uint64
+	for _, entry := range m.Entries() {
+		k, _ := Hash(entry.Key)
+		v, ok := Hash(entry.Value)
+		if !ok {
+			v = hashBytes(tagUnhashable, nil)
+		}
+		sum += k*31 + v
+	}
+	return hashInt64(tagMap, int64(sum))
+}
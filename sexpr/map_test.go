@@ -0,0 +1,115 @@
+package sexpr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMapAssocAndGet(t *testing.T) {
+	m, err := NewMap(Symbol{Name: "a"}, Number{Value: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated, err := m.Assoc(String{Value: "b"}, Number{Value: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The original map is unchanged
+	if m.Len() != 1 {
+		t.Errorf("original map has %d entries, want 1", m.Len())
+	}
+	if _, ok := m.Get(String{Value: "b"}); ok {
+		t.Error("original map should not contain the new key")
+	}
+
+	value, ok := updated.Get(Symbol{Name: "a"})
+	if !ok || !value.Equal(Number{Value: 1}) {
+		t.Errorf("got %v, want 1", value)
+	}
+
+	// Symbol a and string "a" are distinct keys
+	if _, ok := updated.Get(String{Value: "a"}); ok {
+		t.Error("string key should not match symbol key")
+	}
+
+	replaced, _ := updated.Assoc(Symbol{Name: "a"}, Number{Value: 10})
+	if replaced.Len() != 2 {
+		t.Errorf("got %d entries, want 2", replaced.Len())
+	}
+	if got := replaced.String(); got != `{a 10 "b" 2}` {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	if _, err := NewMap(Symbol{Name: "a"}); err == nil {
+		t.Error("expected error for odd number of arguments")
+	}
+
+	if _, err := NewMap(Primitive{Name: "+"}, Nil{}); err == nil {
+		t.Error("expected error for unhashable key")
+	}
+}
+
+func TestMapEqualIgnoresOrder(t *testing.T) {
+	a, _ := NewMap(Symbol{Name: "x"}, Number{Value: 1}, Symbol{Name: "y"}, Number{Value: 2})
+	b, _ := NewMap(Symbol{Name: "y"}, Number{Value: 2}, Symbol{Name: "x"}, Number{Value: 1})
+	c, _ := NewMap(Symbol{Name: "x"}, Number{Value: 1})
+
+	if !a.Equal(b) {
+		t.Error("maps with the same entries should be equal")
+	}
+	if a.Hash() != b.Hash() {
+		t.Error("equal maps should hash alike")
+	}
+	if a.Equal(c) {
+		t.Error("maps with different entries should not be equal")
+	}
+
+	var empty Map
+	if empty.Len() != 0 || empty.String() != "{}" {
+		t.Errorf("zero map should be empty, got %v", empty)
+	}
+}
+
+func TestMapLarge(t *testing.T) {
+	const n = 5000
+
+	m := Map{}
+	for i := 0; i < n; i++ {
+		m, _ = m.Assoc(String{Value: fmt.Sprint(i)}, Number{Value: int64(i)})
+	}
+	if m.Len() != n {
+		t.Fatalf("Len() = %d, want %d", m.Len(), n)
+	}
+
+	// Remove the even keys
+	smaller := m
+	for i := 0; i < n; i += 2 {
+		smaller = smaller.Dissoc(String{Value: fmt.Sprint(i)})
+	}
+
+	if smaller.Len() != n/2 {
+		t.Errorf("Len() = %d, want %d", smaller.Len(), n/2)
+	}
+	if m.Len() != n {
+		t.Errorf("Dissoc changed the original map")
+	}
+
+	for i := 0; i < n; i++ {
+		_, ok := smaller.Get(String{Value: fmt.Sprint(i)})
+		if ok != (i%2 == 1) {
+			t.Fatalf("Get(%d) present = %v", i, ok)
+		}
+	}
+
+	// Insertion order survives removal
+	entries := smaller.Entries()
+	for i, entry := range entries {
+		if !entry.Value.Equal(Number{Value: int64(2*i + 1)}) {
+			t.Fatalf("entry %d = %v", i, entry.Value)
+		}
+	}
+}
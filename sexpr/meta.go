@@ -0,0 +1,42 @@
+package sexpr
+
+import "fmt"
+
+// MetaOf returns the metadata attached to value, or nil if there is none
+// or the value cannot carry metadata
+func MetaOf(value SExpr) *Map {
+	switch v := value.(type) {
+	case Symbol:
+		return v.Meta
+	case List:
+		return v.Meta
+	case Func:
+		return v.Meta
+	case Primitive:
+		return v.Meta
+	default:
+		return nil
+	}
+}
+
+// WithMeta returns a copy of value carrying the given metadata. Symbols,
+// lists and functions can carry metadata; other values return an error.
+// A nil meta removes any existing metadata.
+func WithMeta(value SExpr, meta *Map) (SExpr, error) {
+	switch v := value.(type) {
+	case Symbol:
+		v.Meta = meta
+		return v, nil
+	case List:
+		v.Meta = meta
+		return v, nil
+	case Func:
+		v.Meta = meta
+		return v, nil
+	case Primitive:
+		v.Meta = meta
+		return v, nil
+	default:
+		return nil, fmt.Errorf("cannot attach metadata to %v", value)
+	}
+}
@@ -0,0 +1,44 @@
+package sexpr
+
+import "testing"
+
+func TestWithMeta(t *testing.T) {
+	meta, _ := NewMap(Symbol{Name: "doc"}, String{Value: "a symbol"})
+
+	tests := []SExpr{
+		Symbol{Name: "x"},
+		List{Elements: []SExpr{Number{Value: 1}}},
+		Func{Body: Nil{}},
+		Primitive{Name: "+"},
+	}
+
+	for _, value := range tests {
+		t.Run(value.String(), func(t *testing.T) {
+			tagged, err := WithMeta(value, &meta)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := MetaOf(tagged); got == nil || !got.Equal(meta) {
+				t.Errorf("MetaOf = %v, want %v", got, meta)
+			}
+
+			// Metadata does not change the value itself
+			if MetaOf(value) != nil {
+				t.Error("original value should not carry metadata")
+			}
+			if !tagged.Equal(value) {
+				t.Error("metadata should be ignored by Equal")
+			}
+		})
+	}
+}
+
+func TestWithMetaUnsupported(t *testing.T) {
+	if _, err := WithMeta(Number{Value: 1}, &Map{}); err == nil {
+		t.Error("expected error attaching metadata to a number")
+	}
+	if MetaOf(String{Value: "s"}) != nil {
+		t.Error("strings never carry metadata")
+	}
+}
@@ -0,0 +1,66 @@
+package sexpr
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MutableVector is an indexed sequence whose elements can be replaced in
+// place, written #<vector a b c>. Unlike a Vector it is handled by
+// reference, so a change is seen through every reference to it. Mutable
+// vectors are Equal when they have equal elements; they are never Equal
+// to a Vector.
+type MutableVector struct {
+	mu    sync.RWMutex
+	elems []SExpr
+}
+
+// NewMutableVector returns a mutable vector holding a copy of elems
+func NewMutableVector(elems ...SExpr) *MutableVector {
+	return &MutableVector{elems: append([]SExpr{}, elems...)}
+}
+
+// Len returns the number of elements
+func (v *MutableVector) Len() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.elems)
+}
+
+// Nth returns the element at index i
+func (v *MutableVector) Nth(i int) (SExpr, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if i < 0 || i >= len(v.elems) {
+		return nil, false
+	}
+	return v.elems[i], true
+}
+
+// Set replaces the element at index i with elem
+func (v *MutableVector) Set(i int, elem SExpr) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if i < 0 || i >= len(v.elems) {
+		return fmt.Errorf("vector index %d out of range [0, %d)", i, len(v.elems))
+	}
+	v.elems[i] = elem
+	return nil
+}
+
+// Elements returns a copy of the elements in order
+func (v *MutableVector) Elements() []SExpr {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]SExpr{}, v.elems...)
+}
+
+func (v *MutableVector) String() string {
+	return Write(v)
+}
+
+// Equal compares elements like Record.Equal, so mutable vectors that
+// contain themselves are compared without looping
+func (v *MutableVector) Equal(other SExpr) bool {
+	return equalShared(v, other, nil)
+}
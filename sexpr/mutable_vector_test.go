@@ -0,0 +1,31 @@
+package sexpr
+
+import "testing"
+
+func TestMutableVector(t *testing.T) {
+	v := NewMutableVector(Number{Value: 1}, Number{Value: 2})
+	if err := v.Set(1, Symbol{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Set(2, Nil{}); err == nil {
+		t.Error("expected an error setting past the end")
+	}
+	if elem, _ := v.Nth(1); !elem.Equal(Symbol{Name: "x"}) {
+		t.Errorf("Nth(1) = %v, want x", elem)
+	}
+	if v.String() != "#<vector 1 x>" {
+		t.Errorf("String() = %q", v.String())
+	}
+
+	w := NewMutableVector(Number{Value: 1}, Symbol{Name: "x"})
+	if !v.Equal(w) || v.Equal(NewVector(v.Elements()...)) {
+		t.Error("mutable vectors should equal mutable vectors with equal elements only")
+	}
+
+	if err := v.Set(0, v); err != nil {
+		t.Fatal(err)
+	}
+	if got := Write(v); got != "#0=#<vector #0# x>" {
+		t.Errorf("Write() = %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package sexpr
+
+import "strings"
+
+// Pair represents a cons cell. A chain of pairs whose final Cdr is a List
+// is a proper list and behaves like the equivalent List: it prints the
+// same, is Equal to it and hashes alike. Pairs make cons and cdr O(1)
+// without copying the tail.
+type Pair struct {
+	Car SExpr
+	Cdr SExpr
+}
+
+// Cons returns a new pair with car and cdr
+func Cons(car, cdr SExpr) Pair {
+	return Pair{Car: car, Cdr: cdr}
+}
+
+func (p Pair) String() string {
+	var b strings.Builder
+	b.WriteByte('(')
+	b.WriteString(p.Car.String())
+
+	var rest SExpr = p.Cdr
+	for {
+		switch r := rest.(type) {
+		case Pair:
+			b.WriteByte(' ')
+			b.WriteString(r.Car.String())
+			rest = r.Cdr
+			continue
+		case List:
+			for _, elem := range r.Elements {
+				b.WriteByte(' ')
+				b.WriteString(elem.String())
+			}
+		default:
+			b.WriteString(" . ")
+			b.WriteString(r.String())
+		}
+		break
+	}
+
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Equal compares proper lists element-wise (so a proper pair chain is
+// equal to a List with the same elements) and improper ones cell by cell
+func (p Pair) Equal(other SExpr) bool {
+	if elems, ok := Elements(p); ok {
+		otherElems, ok := Elements(other)
+		return ok && (List{Elements: elems}).Equal(List{Elements: otherElems})
+	}
+
+	o, ok := other.(Pair)
+	return ok && p.Car.Equal(o.Car) && p.Cdr.Equal(o.Cdr)
+}
+
+func (p Pair) Hash() uint64 {
+	if elems, ok := Elements(p); ok {
+		return List{Elements: elems}.Hash()
+	}
+
+	car, ok := Hash(p.Car)
+	if !ok {
+		car = hashBytes(tagUnhashable, nil)
+	}
+	cdr, ok := Hash(p.Cdr)
+	if !ok {
+		cdr = hashBytes(tagUnhashable, nil)
+	}
+	return hashInt64(tagPair, int64(car*31+cdr))
+}
+
+// Elements returns the elements of a proper list, which is either a List
+// or a chain of pairs ending in a List. It reports false for any other
+// value, including improper lists.
+func Elements(value SExpr) ([]SExpr, bool) {
+	switch v := value.(type) {
+	case List:
+		return v.Elements, true
+	case Pair:
+		var elems []SExpr
+		var rest SExpr = v
+		for {
+			switch r := rest.(type) {
+			case Pair:
+				elems = append(elems, r.Car)
+				rest = r.Cdr
+				continue
+			case List:
+				return append(elems, r.Elements...), true
+			}
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+}
+
+// IsList reports whether value is a proper list
+func IsList(value SExpr) bool {
+	_, ok := Elements(value)
+	return ok
+}
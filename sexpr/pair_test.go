@@ -0,0 +1,82 @@
+package sexpr
+
+import "testing"
+
+func TestPairString(t *testing.T) {
+	tests := []struct {
+		name     string
+		pair     Pair
+		expected string
+	}{
+		{
+			"proper list ending in empty list",
+			Cons(Number{Value: 1}, Cons(Number{Value: 2}, List{})),
+			"(1 2)",
+		},
+		{
+			"pair onto slice-backed list",
+			Cons(Number{Value: 0}, List{Elements: []SExpr{Number{Value: 1}, Number{Value: 2}}}),
+			"(0 1 2)",
+		},
+		{
+			"dotted pair",
+			Cons(Number{Value: 1}, Number{Value: 2}),
+			"(1 . 2)",
+		},
+		{
+			"improper list",
+			Cons(Number{Value: 1}, Cons(Number{Value: 2}, Symbol{Name: "x"})),
+			"(1 2 . x)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pair.String(); got != tt.expected {
+				t.Errorf("Pair.String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestElements(t *testing.T) {
+	chain := Cons(Number{Value: 1}, Cons(Number{Value: 2}, List{Elements: []SExpr{Number{Value: 3}}}))
+
+	elems, ok := Elements(chain)
+	if !ok {
+		t.Fatal("expected a proper list")
+	}
+	if len(elems) != 3 {
+		t.Fatalf("got %d elements, want 3", len(elems))
+	}
+
+	if _, ok := Elements(Cons(Number{Value: 1}, Number{Value: 2})); ok {
+		t.Error("dotted pair is not a proper list")
+	}
+	if IsList(Number{Value: 1}) {
+		t.Error("a number is not a list")
+	}
+	if !IsList(List{}) {
+		t.Error("the empty list is a list")
+	}
+}
+
+func TestPairEqualsList(t *testing.T) {
+	chain := Cons(Number{Value: 1}, Cons(Number{Value: 2}, List{}))
+	list := List{Elements: []SExpr{Number{Value: 1}, Number{Value: 2}}}
+
+	if !chain.Equal(list) || !list.Equal(chain) {
+		t.Error("proper pair chain should equal the equivalent List")
+	}
+	if chain.Hash() != list.Hash() {
+		t.Error("proper pair chain should hash like the equivalent List")
+	}
+
+	dotted := Cons(Number{Value: 1}, Number{Value: 2})
+	if !dotted.Equal(Cons(Number{Value: 1}, Number{Value: 2})) {
+		t.Error("equal dotted pairs should be Equal")
+	}
+	if dotted.Equal(list) {
+		t.Error("dotted pair should not equal a list")
+	}
+}
@@ -0,0 +1,102 @@
+package sexpr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Port is a stream value wrapping an io.Reader, an io.Writer or both.
+// Ports are reference values: copies share the underlying stream and
+// two ports are Equal only if they are the same port.
+type Port struct {
+	Name   string
+	reader *bufio.Reader
+	source io.Reader // what reader buffers, for Unread
+	writer io.Writer
+	closer io.Closer
+	closed bool
+}
+
+// NewInputPort returns a port reading from r
+func NewInputPort(name string, r io.Reader) *Port {
+	p := &Port{Name: name, reader: bufio.NewReader(r), source: r}
+	if c, ok := r.(io.Closer); ok {
+		p.closer = c
+	}
+	return p
+}
+
+// NewOutputPort returns a port writing to w
+func NewOutputPort(name string, w io.Writer) *Port {
+	p := &Port{Name: name, writer: w}
+	if c, ok := w.(io.Closer); ok {
+		p.closer = c
+	}
+	return p
+}
+
+// IsInput reports whether the port can be read from
+func (p *Port) IsInput() bool {
+	return p.reader != nil
+}
+
+// IsOutput reports whether the port can be written to
+func (p *Port) IsOutput() bool {
+	return p.writer != nil
+}
+
+// Reader returns the buffered reader of an input port, or nil
+func (p *Port) Reader() *bufio.Reader {
+	return p.reader
+}
+
+// Unread pushes s back onto an input port, to be read again before the
+// rest of its input. The reader returned by Reader changes.
+func (p *Port) Unread(s string) {
+	if s == "" {
+		return
+	}
+	buffered, _ := p.reader.Peek(p.reader.Buffered())
+	p.reader = bufio.NewReader(io.MultiReader(strings.NewReader(s+string(buffered)), p.source))
+}
+
+// Writer returns the writer of an output port, or nil
+func (p *Port) Writer() io.Writer {
+	return p.writer
+}
+
+// Closed reports whether Close has been called
+func (p *Port) Closed() bool {
+	return p.closed
+}
+
+// Close closes the underlying stream if it is closable. Closing a port
+// twice is not an error.
+func (p *Port) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if p.closer != nil {
+		return p.closer.Close()
+	}
+	return nil
+}
+
+func (p *Port) String() string {
+	switch {
+	case p.IsInput() && p.IsOutput():
+		return fmt.Sprintf("#<port %s>", p.Name)
+	case p.IsInput():
+		return fmt.Sprintf("#<input-port %s>", p.Name)
+	default:
+		return fmt.Sprintf("#<output-port %s>", p.Name)
+	}
+}
+
+func (p *Port) Equal(other SExpr) bool {
+	o, ok := other.(*Port)
+	return ok && p == o
+}
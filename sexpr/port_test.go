@@ -0,0 +1,85 @@
+package sexpr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInputPort(t *testing.T) {
+	p := NewInputPort("string", strings.NewReader("line one\nline two"))
+
+	if !p.IsInput() || p.IsOutput() {
+		t.Fatal("expected an input-only port")
+	}
+
+	line, err := p.Reader().ReadString('\n')
+	if err != nil || line != "line one\n" {
+		t.Errorf("got %q, %v", line, err)
+	}
+
+	if got := p.String(); got != "#<input-port string>" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestPortUnread(t *testing.T) {
+	p := NewInputPort("string", strings.NewReader("one\ntwo\nthree\n"))
+
+	line, _ := p.Reader().ReadString('\n')
+	p.Unread("zero " + line)
+	// Text pushed back can be read and pushed back again
+	for range 3 {
+		line, _ = p.Reader().ReadString(' ')
+		p.Unread(line)
+	}
+
+	var lines []string
+	for {
+		line, err := p.Reader().ReadString('\n')
+		if err != nil {
+			break
+		}
+		lines = append(lines, line)
+	}
+	if got := strings.Join(lines, ""); got != "zero one\ntwo\nthree\n" {
+		t.Errorf("read %q after Unread", got)
+	}
+}
+
+func TestOutputPort(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewOutputPort("buffer", &buf)
+
+	if p.IsInput() || !p.IsOutput() {
+		t.Fatal("expected an output-only port")
+	}
+
+	p.Writer().Write([]byte("hello"))
+	if buf.String() != "hello" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	if got := p.String(); got != "#<output-port buffer>" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestPortEqualityAndClose(t *testing.T) {
+	a := NewOutputPort("a", &bytes.Buffer{})
+	b := NewOutputPort("a", &bytes.Buffer{})
+
+	if !a.Equal(a) || a.Equal(b) {
+		t.Error("ports should compare by identity")
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("second close should not fail: %v", err)
+	}
+	if !a.Closed() {
+		t.Error("port should report closed")
+	}
+}
@@ -0,0 +1,65 @@
+package sexpr
+
+import "fmt"
+
+// Position is a location in source text. File may be empty for input
+// that did not come from a file.
+type Position struct {
+	File string
+	Line int
+	Col  int
+}
+
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("line %d, col %d", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+var (
+	fileKey = Keyword{Name: "file"}
+	lineKey = Keyword{Name: "line"}
+	colKey  = Keyword{Name: "col"}
+)
+
+// PositionMeta returns metadata recording pos as :file, :line and :col
+// entries, as the reader attaches to forms it reads from source
+func PositionMeta(pos Position) *Map {
+	m := Map{}
+	if pos.File != "" {
+		m, _ = m.Assoc(fileKey, String{Value: pos.File})
+	}
+	m, _ = m.Assoc(lineKey, Number{Value: int64(pos.Line)})
+	m, _ = m.Assoc(colKey, Number{Value: int64(pos.Col)})
+	return &m
+}
+
+// PositionOf returns the source position recorded in the metadata of
+// value, if any
+func PositionOf(value SExpr) (Position, bool) {
+	meta := MetaOf(value)
+	if meta == nil {
+		return Position{}, false
+	}
+
+	line, ok := meta.Get(lineKey)
+	if !ok {
+		return Position{}, false
+	}
+	var pos Position
+	if n, ok := line.(Number); ok {
+		pos.Line = int(n.Value)
+	}
+	if col, ok := meta.Get(colKey); ok {
+		if n, ok := col.(Number); ok {
+			pos.Col = int(n.Value)
+		}
+	}
+	if file, ok := meta.Get(fileKey); ok {
+		if s, ok := file.(String); ok {
+			pos.File = s.Value
+		}
+	}
+	return pos, true
+}
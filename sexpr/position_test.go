@@ -0,0 +1,31 @@
+package sexpr
+
+import "testing"
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		pos      Position
+		expected string
+	}{
+		{Position{File: "foo.zy", Line: 12, Col: 3}, "foo.zy:12:3"},
+		{Position{Line: 1, Col: 5}, "line 1, col 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := tt.pos.String(); got != tt.expected {
+				t.Errorf("String() = %q", got)
+			}
+
+			list := List{Elements: []SExpr{Symbol{Name: "f"}}, Meta: PositionMeta(tt.pos)}
+			got, ok := PositionOf(list)
+			if !ok || got != tt.pos {
+				t.Errorf("PositionOf = %v, %v, want %v", got, ok, tt.pos)
+			}
+		})
+	}
+
+	if _, ok := PositionOf(List{}); ok {
+		t.Error("expected no position for a list without metadata")
+	}
+}
@@ -0,0 +1,122 @@
+package sexpr
+
+import "strings"
+
+// bodyForms are special forms whose trailing arguments form a body. When
+// such a form is broken across lines, the body is indented by two
+// columns instead of being aligned with the first argument.
+var bodyForms = map[string]bool{
+	"define":     true,
+	"defmacro":   true,
+	"lambda":     true,
+	"let":        true,
+	"let*":       true,
+	"letrec":     true,
+	"let-values": true,
+	"loop":       true,
+	"when":       true,
+	"unless":     true,
+	"begin":      true,
+}
+
+// PrettyPrint renders expr so that, where possible, no line is longer
+// than width columns. Forms that fit on the remaining line are printed
+// as String would print them; longer ones are broken with arguments
+// aligned under the first argument, or indented by two columns for the
+// body of definition and binding forms.
+func PrettyPrint(expr SExpr, width int) string {
+	p := prettyPrinter{width: width}
+	p.print(expr, 0)
+	return p.out.String()
+}
+
+type prettyPrinter struct {
+	out   strings.Builder
+	width int
+}
+
+// print writes expr assuming the cursor is at column col
+func (p *prettyPrinter) print(expr SExpr, col int) {
+	flat := expr.String()
+	if col+len(flat) <= p.width {
+		p.out.WriteString(flat)
+		return
+	}
+
+	switch e := expr.(type) {
+	case Map:
+		p.printMap(e, col)
+		return
+	case List, Pair:
+		if elems, ok := Elements(e); ok && len(elems) > 0 {
+			p.printList(elems, col)
+			return
+		}
+	}
+
+	p.out.WriteString(flat)
+}
+
+func (p *prettyPrinter) printList(elems []SExpr, col int) {
+	p.out.WriteByte('(')
+
+	head, isSymbol := elems[0].(Symbol)
+	if !isSymbol || len(elems) == 1 {
+		// Data list: one element per line, aligned after the paren
+		p.printLines(elems, col+1, true)
+		p.out.WriteByte(')')
+		return
+	}
+
+	p.out.WriteString(head.Name)
+	argCol := col + len(head.Name) + 2
+
+	switch {
+	case bodyForms[head.Name]:
+		// (define (name args)
+		//   body)
+		p.out.WriteByte(' ')
+		p.print(elems[1], argCol)
+		p.printLines(elems[2:], col+2, false)
+	case argCol > p.width/2:
+		// Long operator name: all arguments on their own lines
+		p.printLines(elems[1:], col+2, false)
+	default:
+		// (op arg1
+		//     arg2)
+		p.out.WriteByte(' ')
+		p.printLines(elems[1:], argCol, true)
+	}
+
+	p.out.WriteByte(')')
+}
+
+func (p *prettyPrinter) printMap(m Map, col int) {
+	p.out.WriteByte('{')
+	for i, entry := range m.Entries() {
+		if i > 0 {
+			p.newline(col + 1)
+		}
+		key := entry.Key.String()
+		p.out.WriteString(key)
+		p.out.WriteByte(' ')
+		p.print(entry.Value, col+1+len(key)+1)
+	}
+	p.out.WriteByte('}')
+}
+
+// printLines prints each expression on its own line at column col. When
+// first is true the first expression continues the current line.
+func (p *prettyPrinter) printLines(exprs []SExpr, col int, first bool) {
+	for i, expr := range exprs {
+		if i > 0 || !first {
+			p.newline(col)
+		}
+		p.print(expr, col)
+	}
+}
+
+func (p *prettyPrinter) newline(col int) {
+	p.out.WriteByte('\n')
+	p.out.WriteString(strings.Repeat(" ", col))
+}
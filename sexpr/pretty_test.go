@@ -0,0 +1,74 @@
+package sexpr
+
+import "testing"
+
+func sym(name string) Symbol   { return Symbol{Name: name} }
+func num(value int64) Number   { return Number{Value: value} }
+func list(elems ...SExpr) List { return List{Elements: elems} }
+
+func TestPrettyPrintFits(t *testing.T) {
+	expr := list(sym("+"), num(1), num(2))
+	if got := PrettyPrint(expr, 80); got != "(+ 1 2)" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPrettyPrintBreaks(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     SExpr
+		width    int
+		expected string
+	}{
+		{
+			"call aligns arguments",
+			list(sym("foo"), list(sym("+"), num(1), num(2)), list(sym("*"), num(3), num(4))),
+			20,
+			"(foo (+ 1 2)\n     (* 3 4))",
+		},
+		{
+			"body form indents body",
+			list(sym("define"), list(sym("square"), sym("x")),
+				list(sym("*"), sym("x"), sym("x"))),
+			20,
+			"(define (square x)\n  (* x x))",
+		},
+		{
+			"data list one element per line",
+			list(num(1000), num(2000), num(3000)),
+			10,
+			"(1000\n 2000\n 3000)",
+		},
+		{
+			"nested breaking",
+			list(sym("lambda"), list(sym("x")),
+				list(sym("if"), list(sym("<"), sym("x"), num(0)),
+					list(sym("-"), sym("x")), sym("x"))),
+			20,
+			"(lambda (x)\n  (if (< x 0)\n      (- x)\n      x))",
+		},
+		{
+			"long operator name",
+			list(sym("a-very-long-function-name"), num(1), num(2)),
+			20,
+			"(a-very-long-function-name\n  1\n  2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrettyPrint(tt.expr, tt.width); got != tt.expected {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrettyPrintMap(t *testing.T) {
+	m, _ := NewMap(sym("name"), String{Value: "zylisp"}, sym("version"), num(1))
+
+	expected := "{name \"zylisp\"\n version 1}"
+	if got := PrettyPrint(m, 15); got != expected {
+		t.Errorf("got\n%s\nwant\n%s", got, expected)
+	}
+}
@@ -0,0 +1,357 @@
+package sexpr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Write renders expr in machine-readable form: strings are quoted and
+// escaped, and symbols that would not read back as themselves, such as
+// one containing a space, are written between bars as |a b|, so that the
+// parser reads back an equal value. Values with no
+// readable syntax, such as functions, print as #<...>, which the reader
+// rejects rather than misreading.
+//
+// A mutable value that contains itself is printed once with a datum
+// label, #0=, and referred to as #0# where it recurs.
+func Write(expr SExpr) string {
+	p := newPrinter(expr)
+	p.print(expr)
+	return p.out.String()
+}
+
+// Display renders expr for humans: strings appear without quotes or
+// escapes. Nested values are displayed the same way.
+func Display(expr SExpr) string {
+	p := newPrinter(expr)
+	p.display = true
+	p.print(expr)
+	return p.out.String()
+}
+
+// WriteEDN renders expr as EDN for exchange with Clojure tooling. It
+// fails for values EDN cannot represent, such as functions, improper
+// lists and non-finite floats.
+func WriteEDN(expr SExpr) (string, error) {
+	p := newPrinter(expr)
+	p.edn = true
+	p.print(expr)
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.out.String(), nil
+}
+
+type printer struct {
+	out     strings.Builder
+	display bool
+	edn     bool
+	err     error // first value that could not be printed as EDN
+
+	// labels maps each value on a cycle to its datum label, or -1
+	// until it is first printed
+	labels    map[SExpr]int
+	nextLabel int
+}
+
+func newPrinter(expr SExpr) *printer {
+	return &printer{labels: findCycles(expr)}
+}
+
+func (p *printer) print(expr SExpr) {
+	if label, ok := p.label(expr); ok {
+		if label >= 0 {
+			fmt.Fprintf(&p.out, "#%d#", label)
+			return
+		}
+		p.fail(expr)
+		p.labels[expr] = p.nextLabel
+		fmt.Fprintf(&p.out, "#%d=", p.nextLabel)
+		p.nextLabel++
+	}
+
+	switch e := expr.(type) {
+	case String:
+		if p.display {
+			p.out.WriteString(e.Value)
+		} else {
+			p.out.WriteString(quoteString(e.Value))
+		}
+	case List:
+		p.printSeq("(", e.Elements, nil, ")")
+	case Vector:
+		p.printSeq("[", e.Elements(), nil, "]")
+	case Set:
+		p.printSeq("#{", e.Elements(), nil, "}")
+	case Tagged:
+		p.out.WriteString("#" + e.Tag.Name + " ")
+		p.print(e.Value)
+	case Pair:
+		p.printPair(e)
+	case Map:
+		p.out.WriteByte('{')
+		for i, entry := range e.Entries() {
+			if i > 0 {
+				p.out.WriteByte(' ')
+			}
+			p.print(entry.Key)
+			p.out.WriteByte(' ')
+			p.print(entry.Value)
+		}
+		p.out.WriteByte('}')
+	case Symbol:
+		switch {
+		case p.display || plainSymbol(e.Name):
+			p.out.WriteString(e.Name)
+		case p.edn:
+			p.fail(expr)
+			p.out.WriteString(e.Name)
+		default:
+			p.out.WriteString(quoteSymbol(e.Name))
+		}
+	case Number, BigInt, Keyword, Bool, Nil:
+		p.out.WriteString(expr.String())
+	case Char:
+		switch {
+		case p.display:
+			p.out.WriteRune(e.Value)
+		case p.edn:
+			text, ok := ednChar(e)
+			if !ok {
+				p.fail(expr)
+				text = e.String()
+			}
+			p.out.WriteString(text)
+		default:
+			p.out.WriteString(e.String())
+		}
+	case Float:
+		if p.edn && (math.IsInf(e.Value, 0) || math.IsNaN(e.Value)) {
+			p.fail(expr)
+		}
+		p.out.WriteString(expr.String())
+	case *Record:
+		p.fail(expr)
+		p.out.WriteString("#<" + e.Type.Name)
+		for i, field := range e.Type.Fields {
+			p.out.WriteString(" " + field + ": ")
+			p.print(e.Values[i])
+		}
+		p.out.WriteByte('>')
+	case *MutableVector:
+		p.fail(expr)
+		p.out.WriteString("#<vector")
+		for _, elem := range e.Elements() {
+			p.out.WriteByte(' ')
+			p.print(elem)
+		}
+		p.out.WriteByte('>')
+	case *Atom:
+		p.fail(expr)
+		p.out.WriteString("#<atom ")
+		p.print(e.Deref())
+		p.out.WriteByte('>')
+	case Func:
+		p.fail(expr)
+		p.out.WriteString("#<function>")
+	case Primitive:
+		p.fail(expr)
+		fmt.Fprintf(&p.out, "#<primitive:%s>", e.Name)
+	default:
+		p.fail(expr)
+		p.out.WriteString(expr.String())
+	}
+}
+
+// fail records that expr has no EDN representation
+func (p *printer) fail(expr SExpr) {
+	if p.edn && p.err == nil {
+		p.err = fmt.Errorf("cannot represent %s as EDN", Write(expr))
+	}
+}
+
+// printSeq prints elements between open and close, with an optional
+// dotted tail
+func (p *printer) printSeq(open string, elems []SExpr, tail SExpr, close string) {
+	p.out.WriteString(open)
+	for i, elem := range elems {
+		if i > 0 {
+			p.out.WriteByte(' ')
+		}
+		p.print(elem)
+	}
+	if tail != nil {
+		p.fail(Cons(elems[len(elems)-1], tail))
+		p.out.WriteString(" . ")
+		p.print(tail)
+	}
+	p.out.WriteString(close)
+}
+
+func (p *printer) printPair(pair Pair) {
+	if elems, ok := Elements(pair); ok {
+		p.printSeq("(", elems, nil, ")")
+		return
+	}
+
+	var elems []SExpr
+	var rest SExpr = pair
+	for {
+		next, ok := rest.(Pair)
+		if !ok {
+			break
+		}
+		elems = append(elems, next.Car)
+		rest = next.Cdr
+	}
+	p.printSeq("(", elems, rest, ")")
+}
+
+// label returns the datum label state of expr if it is on a cycle
+func (p *printer) label(expr SExpr) (int, bool) {
+	if p.labels == nil || !isReference(expr) {
+		return 0, false
+	}
+	label, ok := p.labels[expr]
+	return label, ok
+}
+
+// isReference reports whether expr is a mutable value compared by
+// identity, the only kind of value that can contain itself
+func isReference(expr SExpr) bool {
+	switch expr.(type) {
+	case *Record, *Atom, *MutableVector:
+		return true
+	}
+	return false
+}
+
+// findCycles returns the reference values in expr that can be reached
+// from themselves, each mapped to -1
+func findCycles(expr SExpr) map[SExpr]int {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[SExpr]int{}
+	var cycles map[SExpr]int
+
+	var walk func(SExpr)
+	walk = func(expr SExpr) {
+		if isReference(expr) {
+			switch state[expr] {
+			case visiting:
+				if cycles == nil {
+					cycles = map[SExpr]int{}
+				}
+				cycles[expr] = -1
+				return
+			case visited:
+				return
+			}
+			state[expr] = visiting
+			defer func() { state[expr] = visited }()
+		}
+
+		switch e := expr.(type) {
+		case List:
+			for _, elem := range e.Elements {
+				walk(elem)
+			}
+		case Vector:
+			for _, elem := range e.Elements() {
+				walk(elem)
+			}
+		case Pair:
+			walk(e.Car)
+			walk(e.Cdr)
+		case Map:
+			for _, entry := range e.Entries() {
+				walk(entry.Key)
+				walk(entry.Value)
+			}
+		case Set:
+			for _, elem := range e.Elements() {
+				walk(elem)
+			}
+		case Tagged:
+			walk(e.Value)
+		case *Record:
+			for _, value := range e.Values {
+				walk(value)
+			}
+		case *Atom:
+			walk(e.Deref())
+		case *MutableVector:
+			for _, elem := range e.Elements() {
+				walk(elem)
+			}
+		}
+	}
+	walk(expr)
+
+	return cycles
+}
+
+// plainSymbol reports whether the lexer reads name, written as is, as the
+// symbol name
+func plainSymbol(name string) bool {
+	switch name {
+	case "", ".", "true", "false", "nil", "+inf.0", "-inf.0", "+nan.0":
+		return false
+	}
+	if name[0] == '|' || isDigit(name[0]) || (name[0] == '-' && len(name) > 1 && isDigit(name[1])) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !unicode.IsLetter(rune(ch)) && !isDigit(ch) && !strings.ContainsRune("+-*/<>=!?&|%$_.", rune(ch)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// quoteSymbol writes name between bars, escaping bars and backslashes
+func quoteSymbol(name string) string {
+	var b strings.Builder
+	b.WriteByte('|')
+	for i := 0; i < len(name); i++ {
+		if ch := name[i]; ch == '|' || ch == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(name[i])
+	}
+	b.WriteByte('|')
+	return b.String()
+}
+
+// quoteString quotes s using only the escapes understood by the lexer
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; ch {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package sexpr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWriteAndDisplay(t *testing.T) {
+	m, _ := NewMap(Symbol{Name: "greeting"}, String{Value: "hi"})
+	set, _ := NewSet(String{Value: "a"}, Keyword{Name: "b"})
+
+	tests := []struct {
+		name    string
+		expr    SExpr
+		write   string
+		display string
+	}{
+		{"number", Number{Value: 42}, "42", "42"},
+		{"symbol", Symbol{Name: "x"}, "x", "x"},
+		{"symbol with a space", Symbol{Name: "a b"}, "|a b|", "a b"},
+		{"symbol with a bar", Symbol{Name: "|x\\"}, `|\|x\\|`, "|x\\"},
+		{"symbol named nil", Symbol{Name: "nil"}, "|nil|", "nil"},
+		{"nil", Nil{}, "nil", "nil"},
+		{"string", String{Value: "hello"}, `"hello"`, "hello"},
+		{"escapes", String{Value: "a\"b\\c\nd\te"}, `"a\"b\\c\nd\te"`, "a\"b\\c\nd\te"},
+		{"unicode", String{Value: "héllo"}, `"héllo"`, "héllo"},
+		{
+			"nested list",
+			List{Elements: []SExpr{String{Value: "a"}, List{Elements: []SExpr{String{Value: "b"}}}}},
+			`("a" ("b"))`,
+			"(a (b))",
+		},
+		{"dotted pair", Cons(String{Value: "a"}, Number{Value: 1}), `("a" . 1)`, "(a . 1)"},
+		{"map", m, `{greeting "hi"}`, "{greeting hi}"},
+		{"vector", NewVector(String{Value: "a"}, Number{Value: 1}), `["a" 1]`, "[a 1]"},
+		{"set", set, `#{"a" :b}`, "#{a :b}"},
+		{"tagged", Tagged{Tag: Symbol{Name: "inst"}, Value: String{Value: "2024"}}, `#inst "2024"`, "#inst 2024"},
+		{"function", Func{Body: Nil{}}, "#<function>", "#<function>"},
+		{"primitive", Primitive{Name: "+"}, "#<primitive:+>", "#<primitive:+>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Write(tt.expr); got != tt.write {
+				t.Errorf("Write() = %q, want %q", got, tt.write)
+			}
+			if got := Display(tt.expr); got != tt.display {
+				t.Errorf("Display() = %q, want %q", got, tt.display)
+			}
+		})
+	}
+}
+
+func TestWriteEDN(t *testing.T) {
+	m, _ := NewMap(Keyword{Name: "name"}, String{Value: "zy"}, Keyword{Name: "tags"},
+		NewVector(Symbol{Name: "lisp"}, Nil{}))
+	set, _ := NewSet(Number{Value: 1}, Float{Value: 2.5})
+
+	tests := []struct {
+		name     string
+		expr     SExpr
+		expected string
+	}{
+		{"map", m, `{:name "zy" :tags [lisp nil]}`},
+		{"set", set, "#{1 2.5}"},
+		{"list", List{Elements: []SExpr{Bool{Value: true}, String{Value: "a\nb"}}}, `(true "a\nb")`},
+		{"tagged", Tagged{Tag: Symbol{Name: "inst"}, Value: String{Value: "2024-01-01"}}, `#inst "2024-01-01"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WriteEDN(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("WriteEDN() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteEDNErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr SExpr
+	}{
+		{"function", Func{Body: Nil{}}},
+		{"nested primitive", NewVector(Primitive{Name: "+"})},
+		{"dotted pair", Cons(Number{Value: 1}, Number{Value: 2})},
+		{"infinity", Float{Value: math.Inf(1)}},
+		{"go value", GoValue{Value: 1}},
+		{"symbol with a space", Symbol{Name: "a b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := WriteEDN(tt.expr); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWriteCycles(t *testing.T) {
+	nodeType := &RecordType{Name: "node", Fields: []string{"value", "next"}}
+
+	self := &Record{Type: nodeType, Values: []SExpr{Number{Value: 1}, Nil{}}}
+	self.Values[1] = self
+
+	// a -> (list b) -> a
+	a := &Record{Type: nodeType, Values: []SExpr{String{Value: "a"}, Nil{}}}
+	b := &Record{Type: nodeType, Values: []SExpr{String{Value: "b"}, a}}
+	a.Values[1] = List{Elements: []SExpr{b}}
+
+	// An atom holding itself
+	atom := NewAtom(Number{Value: 0})
+	atom.Reset(atom)
+
+	// Shared but acyclic values are printed in full each time
+	leaf := &Record{Type: nodeType, Values: []SExpr{Number{Value: 0}, Nil{}}}
+	shared := NewVector(leaf, leaf)
+
+	tests := []struct {
+		name     string
+		expr     SExpr
+		expected string
+	}{
+		{"self reference", self, "#0=#<node value: 1 next: #0#>"},
+		{"indirect cycle", a, `#0=#<node value: "a" next: (#<node value: "b" next: #0#>)>`},
+		{"inside a list", List{Elements: []SExpr{self, self}}, "(#0=#<node value: 1 next: #0#> #0#)"},
+		{"atom holding itself", atom, "#0=#<atom #0#>"},
+		{"atom in a record", NewAtom(self), "#<atom #0=#<node value: 1 next: #0#>>"},
+		{"shared", shared, "[#<node value: 0 next: nil> #<node value: 0 next: nil>]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Write(tt.expr); got != tt.expected {
+				t.Errorf("Write() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	if got := self.String(); got != "#0=#<node value: 1 next: #0#>" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := atom.String(); got != "#0=#<atom #0#>" {
+		t.Errorf("atom String() = %q", got)
+	}
+	if _, err := WriteEDN(self); err == nil {
+		t.Error("expected WriteEDN to reject cyclic value")
+	}
+}
@@ -0,0 +1,64 @@
+package sexpr
+
+import "sync"
+
+// Promise is a memoizing deferred computation. The thunk runs the first
+// time the promise is forced and its result is cached for later forces.
+// A failed force is not cached, so forcing again retries the thunk.
+type Promise struct {
+	mu    sync.Mutex
+	thunk func() (SExpr, error)
+	value SExpr
+	done  bool
+}
+
+// NewPromise returns an unforced promise for thunk
+func NewPromise(thunk func() (SExpr, error)) *Promise {
+	return &Promise{thunk: thunk}
+}
+
+// Force returns the promise's value, running the thunk if needed. The
+// lock is not held while the thunk runs, so a promise may force itself
+// recursively; the first value to be produced wins.
+func (p *Promise) Force() (SExpr, error) {
+	p.mu.Lock()
+	if p.done {
+		defer p.mu.Unlock()
+		return p.value, nil
+	}
+	thunk := p.thunk
+	p.mu.Unlock()
+
+	value, err := thunk()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.done {
+		p.value = value
+		p.done = true
+		p.thunk = nil
+	}
+	return p.value, nil
+}
+
+// IsForced reports whether the promise already holds a value
+func (p *Promise) IsForced() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done
+}
+
+func (p *Promise) String() string {
+	if p.IsForced() {
+		return "#<promise forced>"
+	}
+	return "#<promise>"
+}
+
+func (p *Promise) Equal(other SExpr) bool {
+	o, ok := other.(*Promise)
+	return ok && p == o
+}
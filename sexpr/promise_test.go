@@ -0,0 +1,55 @@
+package sexpr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPromiseMemoizes(t *testing.T) {
+	calls := 0
+	p := NewPromise(func() (SExpr, error) {
+		calls++
+		return Number{Value: 42}, nil
+	})
+
+	if p.IsForced() || p.String() != "#<promise>" {
+		t.Fatal("new promise should not be forced")
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := p.Force()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !value.Equal(Number{Value: 42}) {
+			t.Errorf("got %v, want 42", value)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("thunk ran %d times, want 1", calls)
+	}
+	if p.String() != "#<promise forced>" {
+		t.Errorf("String() = %q", p.String())
+	}
+}
+
+func TestPromiseRetriesAfterError(t *testing.T) {
+	calls := 0
+	p := NewPromise(func() (SExpr, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("transient")
+		}
+		return String{Value: "ok"}, nil
+	})
+
+	if _, err := p.Force(); err == nil {
+		t.Fatal("expected error on first force")
+	}
+
+	value, err := p.Force()
+	if err != nil || !value.Equal(String{Value: "ok"}) {
+		t.Errorf("got %v, %v", value, err)
+	}
+}
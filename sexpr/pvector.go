@@ -0,0 +1,157 @@
+package sexpr
+
+// pvector is a persistent vector: a 32-way trie of leaves plus a tail
+// holding the last partial leaf, as in Clojure's PersistentVector.
+// Appends and updates copy only the path from the root to the changed
+// leaf, O(log32 n), and share everything else with the original. The
+// zero value is empty.
+type pvector[T any] struct {
+	count int
+	shift uint // bit offset of the root's level
+	root  *pvectorNode[T]
+	tail  []T
+}
+
+type pvectorNode[T any] struct {
+	children []*pvectorNode[T] // interior nodes
+	values   []T               // leaves
+}
+
+const (
+	pvectorBits  = 5
+	pvectorWidth = 1 << pvectorBits
+	pvectorMask  = pvectorWidth - 1
+)
+
+func (v pvector[T]) len() int {
+	return v.count
+}
+
+// tailOffset returns the index of the first element held in the tail
+func (v pvector[T]) tailOffset() int {
+	if v.count < pvectorWidth {
+		return 0
+	}
+	return ((v.count - 1) >> pvectorBits) << pvectorBits
+}
+
+func (v pvector[T]) get(i int) T {
+	if i >= v.tailOffset() {
+		return v.tail[i-v.tailOffset()]
+	}
+
+	node := v.root
+	for level := v.shift; level > 0; level -= pvectorBits {
+		node = node.children[(i>>level)&pvectorMask]
+	}
+	return node.values[i&pvectorMask]
+}
+
+// set returns a vector with element i replaced by value
+func (v pvector[T]) set(i int, value T) pvector[T] {
+	if i >= v.tailOffset() {
+		tail := append([]T(nil), v.tail...)
+		tail[i-v.tailOffset()] = value
+		v.tail = tail
+		return v
+	}
+
+	v.root = v.root.set(v.shift, i, value)
+	return v
+}
+
+func (n *pvectorNode[T]) set(level uint, i int, value T) *pvectorNode[T] {
+	if level == 0 {
+		values := append([]T(nil), n.values...)
+		values[i&pvectorMask] = value
+		return &pvectorNode[T]{values: values}
+	}
+
+	children := append([]*pvectorNode[T](nil), n.children...)
+	sub := (i >> level) & pvectorMask
+	children[sub] = children[sub].set(level-pvectorBits, i, value)
+	return &pvectorNode[T]{children: children}
+}
+
+// push returns a vector with value appended
+func (v pvector[T]) push(value T) pvector[T] {
+	if v.count-v.tailOffset() < pvectorWidth {
+		// Clip the capacity so that appending never writes into a tail
+		// shared with another vector
+		v.tail = append(v.tail[:len(v.tail):len(v.tail)], value)
+		v.count++
+		return v
+	}
+
+	// The tail is full: move it into the trie
+	leaf := &pvectorNode[T]{values: v.tail}
+	switch {
+	case v.root == nil:
+		v.root = &pvectorNode[T]{children: []*pvectorNode[T]{leaf}}
+		v.shift = pvectorBits
+	case v.count>>pvectorBits > 1<<v.shift:
+		// The trie is full at this height: add a level
+		v.root = &pvectorNode[T]{children: []*pvectorNode[T]{
+			v.root,
+			newPvectorPath(v.shift, leaf),
+		}}
+		v.shift += pvectorBits
+	default:
+		v.root = v.pushLeaf(v.shift, v.root, leaf)
+	}
+
+	v.tail = []T{value}
+	v.count++
+	return v
+}
+
+// pushLeaf returns a copy of parent with leaf added as the rightmost
+// leaf below it
+func (v pvector[T]) pushLeaf(level uint, parent, leaf *pvectorNode[T]) *pvectorNode[T] {
+	sub := ((v.count - 1) >> level) & pvectorMask
+	children := append([]*pvectorNode[T](nil), parent.children...)
+
+	var child *pvectorNode[T]
+	switch {
+	case level == pvectorBits:
+		child = leaf
+	case sub < len(children):
+		child = v.pushLeaf(level-pvectorBits, children[sub], leaf)
+	default:
+		child = newPvectorPath(level-pvectorBits, leaf)
+	}
+
+	if sub < len(children) {
+		children[sub] = child
+	} else {
+		children = append(children, child)
+	}
+	return &pvectorNode[T]{children: children}
+}
+
+// newPvectorPath wraps leaf in interior nodes down from level
+func newPvectorPath[T any](level uint, leaf *pvectorNode[T]) *pvectorNode[T] {
+	if level == 0 {
+		return leaf
+	}
+	return &pvectorNode[T]{children: []*pvectorNode[T]{newPvectorPath(level-pvectorBits, leaf)}}
+}
+
+// slice returns the elements in order
+func (v pvector[T]) slice() []T {
+	result := make([]T, 0, v.count)
+	if v.root != nil {
+		result = v.root.appendValues(result)
+	}
+	return append(result, v.tail...)
+}
+
+func (n *pvectorNode[T]) appendValues(result []T) []T {
+	if n.children == nil {
+		return append(result, n.values...)
+	}
+	for _, child := range n.children {
+		result = child.appendValues(result)
+	}
+	return result
+}
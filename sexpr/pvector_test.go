@@ -0,0 +1,55 @@
+package sexpr
+
+import "testing"
+
+func TestPvectorPushAndGet(t *testing.T) {
+	// Large enough to need three levels of trie
+	const n = 40000
+
+	var v pvector[int]
+	for i := 0; i < n; i++ {
+		v = v.push(i)
+	}
+
+	if v.len() != n {
+		t.Fatalf("len() = %d, want %d", v.len(), n)
+	}
+	for i := 0; i < n; i++ {
+		if got := v.get(i); got != i {
+			t.Fatalf("get(%d) = %d", i, got)
+		}
+	}
+
+	values := v.slice()
+	for i, value := range values {
+		if value != i {
+			t.Fatalf("slice()[%d] = %d", i, value)
+		}
+	}
+}
+
+func TestPvectorIsPersistent(t *testing.T) {
+	var base pvector[int]
+	for i := 0; i < 100; i++ {
+		base = base.push(i)
+	}
+
+	// Updates in the trie and in the tail leave base unchanged
+	updated := base.set(5, -5).set(99, -99)
+	if base.get(5) != 5 || base.get(99) != 99 {
+		t.Error("set modified the original vector")
+	}
+	if updated.get(5) != -5 || updated.get(99) != -99 {
+		t.Error("set did not update the new vector")
+	}
+
+	// Two vectors pushed from the same version do not share their tails
+	a := base.push(1000)
+	b := base.push(2000)
+	if a.get(100) != 1000 || b.get(100) != 2000 {
+		t.Errorf("got %d and %d, want 1000 and 2000", a.get(100), b.get(100))
+	}
+	if base.len() != 100 {
+		t.Errorf("base len() = %d, want 100", base.len())
+	}
+}
@@ -0,0 +1,123 @@
+package sexpr
+
+import "fmt"
+
+// RecordType describes a named record type and its fields. Record types
+// are compared by identity: two definitions with the same name are
+// distinct types.
+type RecordType struct {
+	Name   string
+	Fields []string
+}
+
+func (t *RecordType) String() string {
+	return fmt.Sprintf("#<record-type %s>", t.Name)
+}
+
+func (t *RecordType) Equal(other SExpr) bool {
+	o, ok := other.(*RecordType)
+	return ok && t == o
+}
+
+// FieldIndex returns the position of a field, or -1 if the type has no
+// such field
+func (t *RecordType) FieldIndex(name string) int {
+	for i, field := range t.Fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Record is an instance of a record type. Records are mutable through
+// their type's modifiers, so they are handled by reference.
+type Record struct {
+	Type   *RecordType
+	Values []SExpr
+}
+
+func (r *Record) String() string {
+	return Write(r)
+}
+
+// Equal reports whether other is a record of the same type with equal
+// field values. Records that contain themselves are compared without
+// looping: they are Equal if their cycles have the same shape.
+func (r *Record) Equal(other SExpr) bool {
+	return equalShared(r, other, nil)
+}
+
+// refPair is a pair of reference values being compared
+type refPair struct {
+	a, b SExpr
+}
+
+// equalShared compares a and b like Equal, following the values inside
+// records and mutable vectors itself so that it can stop at cycles. A
+// pair of references met again while it is being compared is taken to be
+// equal; any difference is found on the first visit.
+func equalShared(a, b SExpr, seen map[refPair]bool) bool {
+	if isReference(a) && isReference(b) {
+		if a == b {
+			return true
+		}
+		pair := refPair{a, b}
+		if seen[pair] {
+			return true
+		}
+		if seen == nil {
+			seen = map[refPair]bool{}
+		}
+		seen[pair] = true
+	}
+
+	switch x := a.(type) {
+	case *Record:
+		y, ok := b.(*Record)
+		if !ok || x.Type != y.Type {
+			return false
+		}
+		return equalAll(x.Values, y.Values, seen)
+	case *MutableVector:
+		y, ok := b.(*MutableVector)
+		return ok && equalAll(x.Elements(), y.Elements(), seen)
+	case List:
+		y, ok := b.(List)
+		return ok && equalAll(x.Elements, y.Elements, seen)
+	case Vector:
+		y, ok := b.(Vector)
+		return ok && equalAll(x.Elements(), y.Elements(), seen)
+	case Pair:
+		y, ok := b.(Pair)
+		return ok && equalShared(x.Car, y.Car, seen) && equalShared(x.Cdr, y.Cdr, seen)
+	case Tagged:
+		y, ok := b.(Tagged)
+		return ok && x.Tag.Name == y.Tag.Name && equalShared(x.Value, y.Value, seen)
+	case Map:
+		y, ok := b.(Map)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		for _, entry := range x.Entries() {
+			value, ok := y.Get(entry.Key)
+			if !ok || !equalShared(entry.Value, value, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return a.Equal(b)
+}
+
+func equalAll(a, b []SExpr, seen map[refPair]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalShared(a[i], b[i], seen) {
+			return false
+		}
+	}
+	return true
+}
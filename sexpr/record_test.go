@@ -0,0 +1,58 @@
+package sexpr
+
+import "testing"
+
+func TestRecordString(t *testing.T) {
+	point := &RecordType{Name: "point", Fields: []string{"x", "y"}}
+	r := &Record{Type: point, Values: []SExpr{Number{Value: 1}, Number{Value: 2}}}
+
+	if got := r.String(); got != "#<point x: 1 y: 2>" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := point.String(); got != "#<record-type point>" {
+		t.Errorf("String() = %q", got)
+	}
+	if point.FieldIndex("y") != 1 || point.FieldIndex("z") != -1 {
+		t.Error("unexpected field index")
+	}
+}
+
+func TestRecordEqual(t *testing.T) {
+	point := &RecordType{Name: "point", Fields: []string{"x", "y"}}
+	other := &RecordType{Name: "point", Fields: []string{"x", "y"}}
+
+	a := &Record{Type: point, Values: []SExpr{Number{Value: 1}, Number{Value: 2}}}
+	b := &Record{Type: point, Values: []SExpr{Number{Value: 1}, Number{Value: 2}}}
+	c := &Record{Type: other, Values: []SExpr{Number{Value: 1}, Number{Value: 2}}}
+
+	if !a.Equal(b) {
+		t.Error("records of the same type with equal fields should be equal")
+	}
+	if a.Equal(c) {
+		t.Error("records of distinct types should not be equal")
+	}
+}
+
+func TestRecordEqualCycles(t *testing.T) {
+	node := &RecordType{Name: "node", Fields: []string{"next"}}
+	x := &Record{Type: node}
+	x.Values = []SExpr{x}
+	y := &Record{Type: node}
+	y.Values = []SExpr{y}
+	if !x.Equal(y) {
+		t.Error("records that contain themselves should be equal")
+	}
+
+	// A two-record cycle and a record holding a list of itself
+	a := &Record{Type: node}
+	b := &Record{Type: node, Values: []SExpr{a}}
+	a.Values = []SExpr{b}
+	if !a.Equal(x) {
+		t.Error("cycles of the same shape should be equal")
+	}
+	z := &Record{Type: node}
+	z.Values = []SExpr{List{Elements: []SExpr{z}}}
+	if x.Equal(z) || z.Equal(x) {
+		t.Error("cycles of different shapes should not be equal")
+	}
+}
@@ -1,10 +1,16 @@
 package sexpr
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+)
 
 // SExpr is the base interface for all S-expression types
 type SExpr interface {
 	String() string
+
+	// Equal reports whether the value is structurally equal to other
+	Equal(other SExpr) bool
 }
 
 // Number represents an integer
@@ -16,15 +22,54 @@ func (n Number) String() string {
 	return fmt.Sprintf("%d", n.Value)
 }
 
+func (n Number) Equal(other SExpr) bool {
+	switch o := other.(type) {
+	case Number:
+		return n.Value == o.Value
+	case BigInt:
+		return o.Value.IsInt64() && o.Value.Int64() == n.Value
+	default:
+		return false
+	}
+}
+
+// BigInt represents an integer too large for Number. Arithmetic
+// promotes to BigInt on int64 overflow and demotes back when the result
+// fits again, so a BigInt always holds a value outside the int64 range.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b BigInt) String() string {
+	return b.Value.String()
+}
+
+func (b BigInt) Equal(other SExpr) bool {
+	switch o := other.(type) {
+	case BigInt:
+		return b.Value.Cmp(o.Value) == 0
+	case Number:
+		return o.Equal(b)
+	default:
+		return false
+	}
+}
+
 // Symbol represents a name/identifier
 type Symbol struct {
 	Name string
+	Meta *Map // optional metadata, ignored by Equal
 }
 
 func (s Symbol) String() string {
 	return s.Name
 }
 
+func (s Symbol) Equal(other SExpr) bool {
+	o, ok := other.(Symbol)
+	return ok && s.Name == o.Name
+}
+
 // String represents a string literal
 type String struct {
 	Value string
@@ -34,6 +79,11 @@ func (s String) String() string {
 	return fmt.Sprintf("%q", s.Value)
 }
 
+func (s String) Equal(other SExpr) bool {
+	o, ok := other.(String)
+	return ok && s.Value == o.Value
+}
+
 // Bool represents a boolean value
 type Bool struct {
 	Value bool
@@ -46,6 +96,11 @@ func (b Bool) String() string {
 	return "false"
 }
 
+func (b Bool) Equal(other SExpr) bool {
+	o, ok := other.(Bool)
+	return ok && b.Value == o.Value
+}
+
 // Nil represents the empty value
 type Nil struct{}
 
@@ -53,9 +108,15 @@ func (n Nil) String() string {
 	return "nil"
 }
 
+func (n Nil) Equal(other SExpr) bool {
+	_, ok := other.(Nil)
+	return ok
+}
+
 // List represents a sequence of expressions
 type List struct {
 	Elements []SExpr
+	Meta     *Map // optional metadata, ignored by Equal
 }
 
 func (l List) String() string {
@@ -74,23 +135,68 @@ func (l List) String() string {
 	return result
 }
 
+// Equal compares element-wise against any proper list, including pair
+// chains
+func (l List) Equal(other SExpr) bool {
+	elems, ok := Elements(other)
+	if !ok || len(l.Elements) != len(elems) {
+		return false
+	}
+
+	for i, elem := range l.Elements {
+		if !elem.Equal(elems[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // Func represents a user-defined function
 type Func struct {
 	Params []Symbol
+	Rest   *Symbol // collects arguments beyond Params into a list, if set
 	Body   SExpr
 	Env    interface{} // Use interface{} to avoid circular import
+	Meta   *Map        // optional metadata, ignored by Equal
 }
 
 func (f Func) String() string {
 	return "<function>"
 }
 
+// Equal reports whether other is a function with the same parameters and
+// body closed over the same environment
+func (f Func) Equal(other SExpr) bool {
+	o, ok := other.(Func)
+	if !ok || f.Env != o.Env || len(f.Params) != len(o.Params) {
+		return false
+	}
+	if (f.Rest == nil) != (o.Rest == nil) || (f.Rest != nil && f.Rest.Name != o.Rest.Name) {
+		return false
+	}
+
+	for i, param := range f.Params {
+		if param.Name != o.Params[i].Name {
+			return false
+		}
+	}
+	return f.Body.Equal(o.Body)
+}
+
 // Primitive represents a built-in function
 type Primitive struct {
-	Name string
-	Fn   func([]SExpr, interface{}) (SExpr, error)
+	Name   string
+	Fn     func([]SExpr, interface{}) (SExpr, error)
+	Params SExpr // the parameters in its signature, such as (s start [end]), or nil if unknown
+	Meta   *Map  // optional metadata, ignored by Equal
 }
 
 func (p Primitive) String() string {
 	return fmt.Sprintf("<primitive:%s>", p.Name)
 }
+
+// Equal reports whether other is the primitive with the same name
+func (p Primitive) Equal(other SExpr) bool {
+	o, ok := other.(Primitive)
+	return ok && p.Name == o.Name
+}
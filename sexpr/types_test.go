@@ -1,6 +1,9 @@
 package sexpr
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+)
 
 func TestNumberString(t *testing.T) {
 	tests := []struct {
@@ -106,3 +109,64 @@ func TestListString(t *testing.T) {
 		})
 	}
 }
+
+func TestBigIntString(t *testing.T) {
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	b := BigInt{Value: n}
+	if got := b.String(); got != "123456789012345678901234567890" {
+		t.Errorf("BigInt.String() = %q", got)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	big1, _ := new(big.Int).SetString("99999999999999999999", 10)
+	big2, _ := new(big.Int).SetString("99999999999999999999", 10)
+	rest := Symbol{Name: "rest"}
+
+	tests := []struct {
+		name     string
+		a, b     SExpr
+		expected bool
+	}{
+		{"equal numbers", Number{Value: 1}, Number{Value: 1}, true},
+		{"different numbers", Number{Value: 1}, Number{Value: 2}, false},
+		{"number and string", Number{Value: 1}, String{Value: "1"}, false},
+		{"big ints", BigInt{Value: big1}, BigInt{Value: big2}, true},
+		{"number and small big int", Number{Value: 7},
+			BigInt{Value: big.NewInt(7)}, true},
+		{"symbols", Symbol{Name: "x"}, Symbol{Name: "x"}, true},
+		{"symbol and string", Symbol{Name: "x"}, String{Value: "x"}, false},
+		{"strings", String{Value: "a"}, String{Value: "a"}, true},
+		{"bools", Bool{Value: true}, Bool{Value: false}, false},
+		{"nils", Nil{}, Nil{}, true},
+		{"nil and empty list", Nil{}, List{}, false},
+		{
+			"nested lists",
+			List{Elements: []SExpr{Number{Value: 1},
+				List{Elements: []SExpr{String{Value: "a"}}}}},
+			List{Elements: []SExpr{Number{Value: 1},
+				List{Elements: []SExpr{String{Value: "a"}}}}},
+			true,
+		},
+		{
+			"lists of different length",
+			List{Elements: []SExpr{Number{Value: 1}}},
+			List{Elements: []SExpr{Number{Value: 1}, Number{Value: 2}}},
+			false,
+		},
+		{"variadic and fixed functions", Func{Body: Nil{}, Rest: &rest}, Func{Body: Nil{}}, false},
+		{"variadic functions", Func{Body: Nil{}, Rest: &rest}, Func{Body: Nil{}, Rest: &Symbol{Name: "rest"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.expected {
+				t.Errorf("%v.Equal(%v) = %v, want %v", tt.a, tt.b, got, tt.expected)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.expected {
+				t.Errorf("%v.Equal(%v) = %v, want %v", tt.b, tt.a, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package sexpr
+
+import "strings"
+
+// Values holds the results of a (values ...) call that returned zero or
+// several values. A single value is returned as itself.
+type Values struct {
+	Elements []SExpr
+}
+
+// String prints the values separated by spaces
+func (v Values) String() string {
+	parts := make([]string, len(v.Elements))
+	for i, elem := range v.Elements {
+		parts[i] = Write(elem)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (v Values) Equal(other SExpr) bool {
+	o, ok := other.(Values)
+	if !ok || len(v.Elements) != len(o.Elements) {
+		return false
+	}
+	for i := range v.Elements {
+		if !v.Elements[i].Equal(o.Elements[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,19 @@
+package sexpr
+
+import "testing"
+
+func TestValues(t *testing.T) {
+	v := Values{Elements: []SExpr{Number{Value: 1}, String{Value: "a"}}}
+	if got := v.String(); got != `1 "a"` {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Values{}).String(); got != "" {
+		t.Errorf("empty String() = %q", got)
+	}
+	if !v.Equal(Values{Elements: []SExpr{Number{Value: 1}, String{Value: "a"}}}) {
+		t.Error("equal values should be Equal")
+	}
+	if v.Equal(Values{Elements: []SExpr{Number{Value: 1}}}) {
+		t.Error("values of different lengths should not be Equal")
+	}
+}
@@ -0,0 +1,119 @@
+package sexpr
+
+// Walk visits expr and the values nested inside it in depth-first
+// pre-order. If visit returns false the children of that node are
+// skipped. Lists, pairs, vectors, maps (keys then values), sets and
+// tagged literals are descended into; records, functions and other
+// runtime values are visited as leaves.
+func Walk(expr SExpr, visit func(SExpr) bool) {
+	if !visit(expr) {
+		return
+	}
+
+	switch e := expr.(type) {
+	case List:
+		for _, elem := range e.Elements {
+			Walk(elem, visit)
+		}
+	case Pair:
+		Walk(e.Car, visit)
+		Walk(e.Cdr, visit)
+	case Vector:
+		for _, elem := range e.Elements() {
+			Walk(elem, visit)
+		}
+	case Map:
+		for _, entry := range e.Entries() {
+			Walk(entry.Key, visit)
+			Walk(entry.Value, visit)
+		}
+	case Set:
+		for _, elem := range e.Elements() {
+			Walk(elem, visit)
+		}
+	case Tagged:
+		Walk(e.Value, visit)
+	}
+}
+
+// Transform rebuilds expr bottom-up: the children of each node are
+// transformed first, then rewrite is called on the node rebuilt from
+// them and its result replaces the node. Lists keep their metadata.
+// Descends into the same values as Walk. The first error returned by
+// rewrite, or from rebuilding a map or set with an unhashable key,
+// stops the transformation.
+func Transform(expr SExpr, rewrite func(SExpr) (SExpr, error)) (SExpr, error) {
+	switch e := expr.(type) {
+	case List:
+		elems, err := transformEach(e.Elements, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		expr = List{Elements: elems, Meta: e.Meta}
+
+	case Pair:
+		car, err := Transform(e.Car, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		cdr, err := Transform(e.Cdr, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		expr = Cons(car, cdr)
+
+	case Vector:
+		elems, err := transformEach(e.Elements(), rewrite)
+		if err != nil {
+			return nil, err
+		}
+		expr = NewVector(elems...)
+
+	case Map:
+		m := Map{}
+		for _, entry := range e.Entries() {
+			key, err := Transform(entry.Key, rewrite)
+			if err != nil {
+				return nil, err
+			}
+			value, err := Transform(entry.Value, rewrite)
+			if err != nil {
+				return nil, err
+			}
+			if m, err = m.Assoc(key, value); err != nil {
+				return nil, err
+			}
+		}
+		expr = m
+
+	case Set:
+		elems, err := transformEach(e.Elements(), rewrite)
+		if err != nil {
+			return nil, err
+		}
+		if expr, err = NewSet(elems...); err != nil {
+			return nil, err
+		}
+
+	case Tagged:
+		value, err := Transform(e.Value, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		expr = Tagged{Tag: e.Tag, Value: value}
+	}
+
+	return rewrite(expr)
+}
+
+func transformEach(exprs []SExpr, rewrite func(SExpr) (SExpr, error)) ([]SExpr, error) {
+	result := make([]SExpr, len(exprs))
+	for i, expr := range exprs {
+		transformed, err := Transform(expr, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = transformed
+	}
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package sexpr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	m, _ := NewMap(Keyword{Name: "k"}, Symbol{Name: "v"})
+	expr := List{Elements: []SExpr{
+		Symbol{Name: "f"},
+		NewVector(Number{Value: 1}, m),
+		Cons(Symbol{Name: "a"}, Symbol{Name: "b"}),
+	}}
+
+	var visited []string
+	Walk(expr, func(e SExpr) bool {
+		visited = append(visited, e.String())
+		return true
+	})
+
+	expected := []string{
+		"(f [1 {:k v}] (a . b))",
+		"f",
+		"[1 {:k v}]",
+		"1",
+		"{:k v}",
+		":k",
+		"v",
+		"(a . b)",
+		"a",
+		"b",
+	}
+	if len(visited) != len(expected) {
+		t.Fatalf("visited %v, want %v", visited, expected)
+	}
+	for i := range expected {
+		if visited[i] != expected[i] {
+			t.Errorf("visit %d = %s, want %s", i, visited[i], expected[i])
+		}
+	}
+}
+
+func TestWalkSkipsChildren(t *testing.T) {
+	expr := List{Elements: []SExpr{
+		Symbol{Name: "quote"},
+		List{Elements: []SExpr{Symbol{Name: "hidden"}}},
+	}}
+
+	var symbols []string
+	Walk(expr, func(e SExpr) bool {
+		if sym, ok := e.(Symbol); ok {
+			symbols = append(symbols, sym.Name)
+		}
+		// Do not descend into the quoted list
+		_, isList := e.(List)
+		return !isList || e.Equal(expr)
+	})
+
+	if len(symbols) != 1 || symbols[0] != "quote" {
+		t.Errorf("got %v, want [quote]", symbols)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	meta, _ := NewMap(Keyword{Name: "line"}, Number{Value: 3})
+	set, _ := NewSet(Symbol{Name: "x"})
+	expr := List{Elements: []SExpr{
+		Symbol{Name: "+"},
+		Symbol{Name: "x"},
+		NewVector(Symbol{Name: "x"}, set),
+	}, Meta: &meta}
+
+	// Replace x with 10
+	result, err := Transform(expr, func(e SExpr) (SExpr, error) {
+		if e.Equal(Symbol{Name: "x"}) {
+			return Number{Value: 10}, nil
+		}
+		return e, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := result.String(); got != "(+ 10 [10 #{10}])" {
+		t.Errorf("got %s", got)
+	}
+	if MetaOf(result) != &meta {
+		t.Error("Transform should keep list metadata")
+	}
+
+	// The input is unchanged
+	if got := expr.String(); got != "(+ x [x #{x}])" {
+		t.Errorf("input changed to %s", got)
+	}
+}
+
+func TestTransformBottomUp(t *testing.T) {
+	// Children are rewritten before their parent sees them
+	expr := List{Elements: []SExpr{
+		Symbol{Name: "+"},
+		Number{Value: 1},
+		List{Elements: []SExpr{Symbol{Name: "+"}, Number{Value: 2}, Number{Value: 3}}},
+	}}
+
+	result, err := Transform(expr, func(e SExpr) (SExpr, error) {
+		list, ok := e.(List)
+		if !ok || !list.Elements[0].Equal(Symbol{Name: "+"}) {
+			return e, nil
+		}
+		var sum int64
+		for _, arg := range list.Elements[1:] {
+			n, ok := arg.(Number)
+			if !ok {
+				return e, nil
+			}
+			sum += n.Value
+		}
+		return Number{Value: sum}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Equal(Number{Value: 6}) {
+		t.Errorf("got %v, want 6", result)
+	}
+}
+
+func TestTransformError(t *testing.T) {
+	failure := errors.New("stop")
+	expr := List{Elements: []SExpr{Number{Value: 1}, Symbol{Name: "bad"}}}
+
+	_, err := Transform(expr, func(e SExpr) (SExpr, error) {
+		if e.Equal(Symbol{Name: "bad"}) {
+			return nil, failure
+		}
+		return e, nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("got %v, want %v", err, failure)
+	}
+}